@@ -0,0 +1,76 @@
+// ABOUTME: Expands user-defined command aliases before CLI parsing
+// ABOUTME: Replaces a leading alias name with its definition, substituting $1, $@ and ${flag}
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+)
+
+// globalValueFlags are the global flags that take a separate value
+var globalValueFlags = map[string]bool{
+	"-o": true, "--output": true,
+	"-c": true, "--config-file": true,
+	"--profile": true,
+}
+
+// expandCLIAlias replaces the command word in args with the definition of
+// the alias it names. Built-in commands and plugins always win over
+// aliases, and an alias is expanded only once.
+func expandCLIAlias(parser *kong.Kong, cfg *config.Config, args []string) ([]string, error) {
+	i := commandIndex(args)
+	if i < 0 {
+		return args, nil
+	}
+	name := args[i]
+	for _, child := range parser.Model.Children {
+		if child.Name == name {
+			return args, nil
+		}
+		for _, alias := range child.Aliases {
+			if alias == name {
+				return args, nil
+			}
+		}
+	}
+
+	aliases, _ := cfg.Get("aliases").(map[string]interface{})
+	definition, _ := aliases[name].(string)
+	if strings.TrimSpace(definition) == "" {
+		return args, nil
+	}
+
+	words, err := command.ExpandAlias(definition, args[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("alias %s: %w", name, err)
+	}
+	logging.LogDebug("Expanding alias", "alias", name, "args", words)
+
+	expanded := append([]string{}, args[:i]...)
+	return append(expanded, words...), nil
+}
+
+// commandIndex returns the index of the first argument that is not a
+// global flag or its value, or -1 if there is none
+func commandIndex(args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return -1
+		}
+		if !strings.HasPrefix(arg, "-") {
+			return i
+		}
+		if globalValueFlags[arg] {
+			i++
+		}
+	}
+	return -1
+}
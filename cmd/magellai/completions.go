@@ -0,0 +1,90 @@
+// ABOUTME: Dynamic shell completion values for kongplete
+// ABOUTME: Completes session IDs, model names, profile names, and config keys from live data
+
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/posener/complete"
+	"github.com/willabides/kongplete"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command/core"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/models"
+)
+
+// completionPredictors returns the predictors named by predictor tags in
+// the CLI. Values are looked up only when a completion is requested.
+func completionPredictors(cfg *config.Config) kongplete.Option {
+	return kongplete.WithPredictors(map[string]complete.Predictor{
+		"session":    complete.PredictFunc(func(complete.Args) []string { return sessionCompletions(cfg) }),
+		"model":      complete.PredictFunc(func(complete.Args) []string { return modelCompletions(cfg) }),
+		"profile":    complete.PredictFunc(func(complete.Args) []string { return profileCompletions(cfg) }),
+		"config-key": complete.PredictFunc(func(complete.Args) []string { return configKeyCompletions(cfg) }),
+	})
+}
+
+// sessionCompletions returns stored session IDs, newest first
+func sessionCompletions(cfg *config.Config) []string {
+	ids, err := core.SessionIDs(cfg)
+	if err != nil {
+		logging.LogDebug("Session completion unavailable", "error", err)
+		return nil
+	}
+	return ids
+}
+
+// modelCompletions returns provider/model names from the models inventory
+// and the configured default
+func modelCompletions(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	add(cfg.GetString("model.default"))
+	if inv, err := models.LoadDefaultInventory(); err == nil {
+		for _, m := range inv.Models {
+			add(m.Provider + "/" + m.Name)
+		}
+	} else {
+		logging.LogDebug("Model completion inventory unavailable", "error", err)
+	}
+	return names
+}
+
+// profileCompletions returns the configured profile names
+func profileCompletions(cfg *config.Config) []string {
+	profiles, _ := cfg.Get("profiles").(map[string]interface{})
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configKeyCompletions returns every configuration key and its parent
+// sections
+func configKeyCompletions(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	for key := range cfg.All() {
+		parts := strings.Split(key, ".")
+		for i := range parts {
+			seen[strings.Join(parts[:i+1], ".")] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+// ABOUTME: Builds the documentation command tree from the Kong CLI model
+// ABOUTME: Provides the docs generate subcommand wiring
+
+package main
+
+import (
+	"strings"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/docgen"
+)
+
+// DocsCmd handles the docs command
+type DocsCmd struct {
+	Generate DocsGenerateCmd `cmd:"" help:"Generate man pages or markdown reference docs"`
+}
+
+// DocsGenerateCmd handles docs generate
+type DocsGenerateCmd struct {
+	Format    string `default:"markdown" enum:"man,markdown" help:"Docs format (man, markdown)"`
+	OutputDir string `type:"path" help:"Write one page per command into this directory"`
+}
+
+// Run executes the docs generate command
+func (d *DocsGenerateCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"generate"},
+		Flags:   command.NewFlags(map[string]interface{}{"format": d.Format, "output-dir": d.OutputDir}),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "docs", exec)
+}
+
+// commandDocs converts the Kong model into a docgen command tree. Hidden
+// commands and flags, and external plugins, are left out. The root page
+// documents the exit codes.
+func commandDocs(app *kong.Application) *docgen.Command {
+	root := nodeDocs(app.Node, app.Name)
+	root.Detail = strings.TrimSpace(root.Detail + "\n\n" + exitCodesDoc)
+	return root
+}
+
+// nodeDocs converts one Kong node and its children
+func nodeDocs(node *kong.Node, name string) *docgen.Command {
+	doc := &docgen.Command{
+		Name:    name,
+		Usage:   strings.Join(strings.Fields(rootName(node)+" "+node.Summary()), " "),
+		Help:    node.Help,
+		Detail:  node.Detail,
+		Aliases: node.Aliases,
+	}
+
+	for _, arg := range node.Positional {
+		doc.Args = append(doc.Args, docgen.Arg{Name: arg.Name, Help: arg.Help, Required: arg.Required})
+	}
+	for _, flag := range node.Flags {
+		if flag.Hidden || flag.Name == "help" {
+			continue
+		}
+		f := docgen.Flag{Name: flag.Name, Help: flag.Help, Default: flag.Default}
+		if flag.Short != 0 {
+			f.Short = string(flag.Short)
+		}
+		if !flag.IsBool() && !flag.IsCounter() {
+			f.Placeholder = flagPlaceholder(flag)
+		}
+		doc.Flags = append(doc.Flags, f)
+	}
+
+	for _, child := range node.Children {
+		if child.Hidden || child.Type != kong.CommandNode {
+			continue
+		}
+		if child.Group != nil && child.Group.Key == "plugins" {
+			continue
+		}
+		doc.Subcommands = append(doc.Subcommands, nodeDocs(child, name+" "+child.Name))
+	}
+	return doc
+}
+
+// rootName returns the application name for a node
+func rootName(node *kong.Node) string {
+	for node.Parent != nil {
+		node = node.Parent
+	}
+	return node.Name
+}
+
+// flagPlaceholder returns a flag's value placeholder. Kong shows the default
+// value there, but docs list defaults in their own column.
+func flagPlaceholder(flag *kong.Flag) string {
+	if !flag.HasDefault || flag.PlaceHolder != "" {
+		return flag.FormatPlaceHolder()
+	}
+	placeholder := strings.ToUpper(flag.Name)
+	if flag.IsSlice() {
+		placeholder += ",..."
+	}
+	return placeholder
+}
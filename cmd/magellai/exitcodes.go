@@ -0,0 +1,113 @@
+// ABOUTME: Exit codes that let scripts branch on why magellai failed
+// ABOUTME: Maps command errors to config, provider, rate limit, and empty response codes
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alecthomas/kong"
+	llmdomain "github.com/lexlapax/go-llms/pkg/llm/domain"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/llm"
+)
+
+// Exit codes. Usage errors keep Kong's code so every invalid command line
+// exits the same way.
+const (
+	exitOK            = 0
+	exitError         = 1
+	exitConfigError   = 2
+	exitProviderError = 3
+	exitRateLimited   = 4
+	exitEmptyResponse = 5
+	exitUsageError    = 80
+	exitInterrupted   = 130
+)
+
+// exitCodesDoc documents the exit codes in generated reference docs
+const exitCodesDoc = `Exit status:
+  0    Success
+  1    Any other error
+  2    Configuration error (invalid config, unknown profile, missing API key)
+  3    Provider error (request failed, authentication, unknown model, timeout)
+  4    Rate limited by the provider
+  5    Empty response, with --fail-on-empty
+  80   Invalid command line
+  130  Interrupted`
+
+// configErrors are the errors that mean the configuration needs fixing
+var configErrors = []error{
+	config.ErrConfigNotFound,
+	config.ErrInvalidConfig,
+	config.ErrConfigLoadFailed,
+	config.ErrProfileNotFound,
+	config.ErrInvalidProfile,
+	config.ErrInvalidSettingValue,
+	config.ErrValidationFailed,
+	config.ErrPermission,
+	llm.ErrAPIKeyMissing,
+}
+
+// providerErrors are the errors that mean the provider request failed
+var providerErrors = []error{
+	llm.ErrProviderNotFound,
+	llm.ErrModelNotFound,
+	llm.ErrInvalidAPIKey,
+	llm.ErrProviderUnavailable,
+	llm.ErrContextLengthExceeded,
+	llm.ErrTokenLimitExceeded,
+	llm.ErrInvalidResponse,
+	llm.ErrPartialResponse,
+	llm.ErrProviderTimeout,
+	llm.ErrProviderError,
+	llmdomain.ErrRequestFailed,
+	llmdomain.ErrResponseParsing,
+	llmdomain.ErrAuthenticationFailed,
+	llmdomain.ErrContextTooLong,
+	llmdomain.ErrProviderUnavailable,
+	llmdomain.ErrNoResponse,
+	llmdomain.ErrTimeout,
+	llmdomain.ErrContentFiltered,
+	llmdomain.ErrModelNotFound,
+	llmdomain.ErrNetworkConnectivity,
+	llmdomain.ErrTokenQuotaExceeded,
+	llmdomain.ErrInvalidModelParameters,
+}
+
+// exitCode returns the process exit code for a command error
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, context.Canceled), errors.Is(err, command.ErrCommandCanceled):
+		return exitInterrupted
+	case errors.Is(err, llm.ErrEmptyResponse):
+		return exitEmptyResponse
+	case errors.Is(err, llm.ErrRateLimitExceeded), llmdomain.IsRateLimitError(err):
+		return exitRateLimited
+	}
+
+	var parseErr *kong.ParseError
+	if errors.As(err, &parseErr) {
+		return exitUsageError
+	}
+	for _, target := range configErrors {
+		if errors.Is(err, target) {
+			return exitConfigError
+		}
+	}
+	var providerErr *llmdomain.ProviderError
+	if errors.As(err, &providerErr) {
+		return exitProviderError
+	}
+	for _, target := range providerErrors {
+		if errors.Is(err, target) {
+			return exitProviderError
+		}
+	}
+	return exitError
+}
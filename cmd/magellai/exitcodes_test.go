@@ -0,0 +1,42 @@
+// ABOUTME: Tests for mapping command errors to exit codes
+// ABOUTME: Verifies config, provider, rate limit, empty response, and usage errors
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	llmdomain "github.com/lexlapax/go-llms/pkg/llm/domain"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/llm"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, exitOK},
+		{"generic", errors.New("boom"), exitError},
+		{"config", fmt.Errorf("load: %w", config.ErrInvalidConfig), exitConfigError},
+		{"missing api key", fmt.Errorf("failed to create provider: %w", llm.ErrAPIKeyMissing), exitConfigError},
+		{"provider", fmt.Errorf("failed to generate response: %w", llmdomain.ErrAuthenticationFailed), exitProviderError},
+		{"provider error type", llmdomain.NewProviderError("openai", "Generate", 500, "server error", nil), exitProviderError},
+		{"rate limit", fmt.Errorf("failed to generate response: %w",
+			llmdomain.NewProviderError("openai", "Generate", 429, "rate limit exceeded", llmdomain.ErrRateLimitExceeded)), exitRateLimited},
+		{"empty response", fmt.Errorf("%w from openai/gpt-4o", llm.ErrEmptyResponse), exitEmptyResponse},
+		{"interrupted", fmt.Errorf("stream: %w", context.Canceled), exitInterrupted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exitCode(tt.err))
+		})
+	}
+}
@@ -8,14 +8,26 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/willabides/kongplete"
 
+	"github.com/lexlapax/magellai/internal/configdir"
 	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/audit"
 	"github.com/lexlapax/magellai/pkg/command"
 	"github.com/lexlapax/magellai/pkg/command/core"
 	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/tracing"
+	"github.com/lexlapax/magellai/pkg/util/stringutil"
 	// Import package with side effects to ensure REPL factory registration
 	_ "github.com/lexlapax/magellai/pkg/repl"
 )
@@ -31,10 +43,12 @@ var (
 type CLI struct {
 	// Global flags
 	Verbosity   int    `short:"v" type:"counter" help:"Increase verbosity level"`
-	Output      string `short:"o" enum:"text,json,markdown" default:"text" help:"Output format"`
-	ConfigFile  string `short:"c" type:"path" help:"Config file to use"`
-	ProfileName string `name:"profile" help:"Configuration profile to use"`
+	Quiet       bool   `short:"q" help:"Print only the answer: no banners, logs, or metadata"`
+	Output      string `short:"o" enum:"text,json,yaml,table,markdown" default:"text" help:"Output format (text, json, yaml, table, markdown)"`
+	ConfigFile  string `short:"c" type:"path" help:"Config file to use (after ask, -c means --continue)"`
+	ProfileName string `name:"profile" predictor:"profile" help:"Configuration profile to use"`
 	NoColor     bool   `help:"Disable color output"`
+	DebugHTTP   bool   `name:"debug-http" help:"Save sanitized provider HTTP requests and responses to ~/.config/magellai/debug/http"`
 	ShowVersion bool   `name:"version" help:"Show version information"`
 
 	// Subcommands
@@ -50,8 +64,34 @@ type CLI struct {
 	Profile ProfileCmd `cmd:"" help:"Manage configuration profiles" group:"config"`
 	Alias   AliasCmd   `cmd:"" help:"Manage command aliases" group:"config"`
 
+	// Prompt templates
+	Template TemplateCmd `cmd:"" help:"Manage reusable prompt templates" group:"core"`
+	Prompt   PromptCmd   `cmd:"" help:"Manage named prompts stored in config" group:"core"`
+
+	// Git helpers
+	Git GitCmd `cmd:"" help:"Generate commit messages and review changes" group:"core"`
+
+	// Workflows
+	Run RunCmd `cmd:"" help:"Run a multi-step workflow file" group:"core"`
+
 	// Session management commands
 	History HistoryCmd `cmd:"" help:"Manage REPL session history" group:"session"`
+	Import  ImportCmd  `cmd:"" help:"Import conversations from ChatGPT, Claude, OpenAI JSON, or markdown" group:"session"`
+	Storage StorageCmd `cmd:"" help:"Check and repair session storage" group:"session"`
+
+	// API server
+	Serve ServeCmd `cmd:"" help:"Run an HTTP API server" group:"core"`
+
+	// Diagnostics
+	Doctor DoctorCmd `cmd:"" help:"Diagnose configuration and environment problems" group:"config"`
+	Stats  StatsCmd  `cmd:"" help:"Show command execution statistics" group:"info"`
+	Audit  AuditCmd  `cmd:"" help:"Show the audit log of session and config changes" group:"info"`
+
+	// API key management
+	Keys KeysCmd `cmd:"" help:"Manage provider API keys in the OS keychain" group:"config"`
+
+	// Reference documentation
+	Docs DocsCmd `cmd:"" help:"Generate man pages or markdown reference docs" group:"info"`
 
 	// Shell completion command
 	InstallCompletions kongplete.InstallCompletions `cmd:"" help:"Install shell completions" group:"config"`
@@ -98,13 +138,21 @@ func (v *VersionCmd) Run(ctx *Context) error {
 // AskCmd handles the ask command
 type AskCmd struct {
 	Prompt         string   `arg:"" optional:"" help:"The prompt to send to the LLM (reads from stdin if not provided)"`
-	Model          string   `short:"m" help:"Model to use (provider/model format)"`
+	Model          string   `short:"m" predictor:"model" help:"Model to use (provider/model format)"`
 	Attach         []string `short:"a" help:"Files to attach to the prompt"`
 	Stream         bool     `help:"Enable streaming response"`
 	Temperature    float64  `short:"t" help:"Temperature for the model"`
 	MaxTokens      int      `name:"max-tokens" help:"Maximum tokens in response"`
 	System         string   `short:"s" help:"System prompt"`
 	ResponseFormat string   `name:"format" help:"Response format (text, json, markdown)"`
+	Continue       bool     `help:"Continue the most recently updated session and save the exchange to it (short: -c)"`
+	Session        string   `help:"Append the exchange to the session with this ID"`
+	Estimate       bool     `help:"Print estimated token usage and cost without sending the request"`
+	Batch          string   `type:"existingfile" help:"JSONL file of prompts to submit in bulk"`
+	BatchOutput    string   `name:"batch-output" type:"path" help:"Write batch results to this JSONL file (default stdout)"`
+	BatchMode      string   `name:"batch-mode" help:"Batch submission mode: auto, api (OpenAI Batch API), or concurrent (default auto)"`
+	Concurrency    int      `help:"Maximum concurrent requests in batch mode (default 4)"`
+	FailOnEmpty    bool     `name:"fail-on-empty" help:"Exit with status 5 if the response is empty"`
 }
 
 // Run executes the ask command
@@ -112,8 +160,8 @@ func (a *AskCmd) Run(ctx *Context) error {
 	var prompt string
 	var stdinData string
 
-	// Check if stdin has data (not a terminal)
-	if stat, _ := os.Stdin.Stat(); (stat.Mode() & os.ModeCharDevice) == 0 {
+	// Check if stdin has data (not a terminal); batch mode reads prompts from its file
+	if stat, _ := os.Stdin.Stat(); a.Batch == "" && (stat.Mode()&os.ModeCharDevice) == 0 {
 		// Read from stdin
 		data, err := io.ReadAll(os.Stdin)
 		if err != nil {
@@ -123,7 +171,10 @@ func (a *AskCmd) Run(ctx *Context) error {
 	}
 
 	// Handle prompt logic
-	if a.Prompt != "" && stdinData != "" {
+	if a.Batch != "" {
+		// Batch mode reads prompts from the batch file
+		prompt = a.Prompt
+	} else if a.Prompt != "" && stdinData != "" {
 		// Both provided - combine them
 		prompt = stdinData + "\n\n" + a.Prompt
 	} else if a.Prompt != "" {
@@ -145,11 +196,20 @@ func (a *AskCmd) Run(ctx *Context) error {
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
+	if prompt == "" {
+		exec.Args = []string{}
+	}
 
 	// Map flags
 	if a.Model != "" {
 		exec.Flags.Set("model", a.Model)
 	}
+	if a.Batch != "" {
+		exec.Flags.Set("batch", a.Batch)
+		exec.Flags.Set("batch-output", a.BatchOutput)
+		exec.Flags.Set("batch-mode", a.BatchMode)
+		exec.Flags.Set("concurrency", a.Concurrency)
+	}
 	if len(a.Attach) > 0 {
 		exec.Flags.Set("attach", a.Attach)
 	}
@@ -168,6 +228,21 @@ func (a *AskCmd) Run(ctx *Context) error {
 	if a.ResponseFormat != "" {
 		exec.Flags.Set("format", a.ResponseFormat)
 	}
+	if a.Continue {
+		exec.Flags.Set("continue", true)
+	}
+	if a.Session != "" {
+		exec.Flags.Set("session", a.Session)
+	}
+	if a.Estimate {
+		exec.Flags.Set("estimate", a.Estimate)
+	}
+	if a.FailOnEmpty {
+		exec.Flags.Set("fail-on-empty", true)
+	}
+	if ctx.CLI != nil && ctx.CLI.Quiet {
+		exec.Flags.Set("quiet", true)
+	}
 	// Use global output flag
 	if ctx.CLI != nil && ctx.CLI.Output != "" {
 		exec.Flags.Set("output", ctx.CLI.Output)
@@ -178,9 +253,14 @@ func (a *AskCmd) Run(ctx *Context) error {
 
 // ChatCmd handles the chat command
 type ChatCmd struct {
-	Resume string   `short:"r" help:"Resume a previous session by ID"`
-	Model  string   `short:"m" help:"Model to use (provider/model format)"`
-	Attach []string `short:"a" help:"Initial files to attach"`
+	Resume    string        `short:"r" help:"Resume a previous session by ID"`
+	Model     string        `short:"m" predictor:"model" help:"Model to use (provider/model format)"`
+	Template  string        `help:"Start the session from a session template"`
+	Ephemeral time.Duration `help:"Delete the session automatically after this duration (e.g. 24h)"`
+	ReadOnly  bool          `name:"read-only" help:"Browse and export sessions without writing to session storage"`
+	Attach    []string      `short:"a" help:"Initial files to attach"`
+	NoRC      bool          `name:"no-rc" help:"Skip the replrc startup commands file"`
+	Script    string        `help:"Run commands and prompts from a file (- for stdin), printing JSON lines"`
 }
 
 // Run executes the chat command
@@ -201,9 +281,27 @@ func (c *ChatCmd) Run(ctx *Context) error {
 	if c.Model != "" {
 		exec.Flags.Set("model", c.Model)
 	}
+	if c.Template != "" {
+		exec.Flags.Set("template", c.Template)
+	}
+	if c.Ephemeral > 0 {
+		exec.Flags.Set("ephemeral", c.Ephemeral)
+	}
+	if c.ReadOnly {
+		exec.Flags.Set("read-only", true)
+	}
 	if len(c.Attach) > 0 {
 		exec.Flags.Set("attach", c.Attach)
 	}
+	if c.NoRC {
+		exec.Flags.Set("no-rc", true)
+	}
+	if c.Script != "" {
+		exec.Flags.Set("script", c.Script)
+	}
+	if ctx.CLI != nil && ctx.CLI.Quiet {
+		exec.Flags.Set("quiet", true)
+	}
 
 	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "chat", exec)
 }
@@ -211,11 +309,13 @@ func (c *ChatCmd) Run(ctx *Context) error {
 // ConfigCmd handles the config command
 type ConfigCmd struct {
 	// Subcommands with brief descriptions
-	Show     ConfigShowCmd     `cmd:"" help:"Show all configuration settings"`
-	Get      ConfigGetCmd      `cmd:"" help:"Get a specific value"`
-	Set      ConfigSetCmd      `cmd:"" help:"Set a configuration value"`
-	Validate ConfigValidateCmd `cmd:"" help:"Validate configuration file"`
-	Generate ConfigGenerateCmd `cmd:"" help:"Generate an example configuration file"`
+	Show        ConfigShowCmd        `cmd:"" help:"Show all configuration settings"`
+	Get         ConfigGetCmd         `cmd:"" help:"Get a specific value"`
+	Set         ConfigSetCmd         `cmd:"" help:"Set a configuration value"`
+	Validate    ConfigValidateCmd    `cmd:"" help:"Validate configuration file"`
+	Generate    ConfigGenerateCmd    `cmd:"" help:"Generate an example configuration file"`
+	Diff        ConfigDiffCmd        `cmd:"" help:"Show values that differ from the defaults and where each came from"`
+	EncryptKeys ConfigEncryptKeysCmd `cmd:"" name:"encrypt-keys" help:"Encrypt API keys and tokens in a config file"`
 }
 
 // ConfigShowCmd handles config show
@@ -231,22 +331,12 @@ func (c *ConfigShowCmd) Run(ctx *Context) error {
 		Data:    make(map[string]interface{}),
 	}
 
-	err := ctx.Registry.GetExecutor().Execute(ctx.Ctx, "config", exec)
-	if err != nil {
-		return err
-	}
-
-	// Print the output
-	if output, ok := exec.Data["output"].(string); ok {
-		fmt.Fprintln(ctx.Stdout, output)
-	}
-
-	return nil
+	return runCommand(ctx, "config", exec)
 }
 
 // ConfigGetCmd handles config get
 type ConfigGetCmd struct {
-	Key string `arg:"" required:"" help:"Configuration key to get"`
+	Key string `arg:"" required:"" predictor:"config-key" help:"Configuration key to get"`
 }
 
 func (c *ConfigGetCmd) Run(ctx *Context) error {
@@ -259,22 +349,12 @@ func (c *ConfigGetCmd) Run(ctx *Context) error {
 		Data:    make(map[string]interface{}),
 	}
 
-	err := ctx.Registry.GetExecutor().Execute(ctx.Ctx, "config", exec)
-	if err != nil {
-		return err
-	}
-
-	// Print the output
-	if output, ok := exec.Data["output"].(string); ok {
-		fmt.Fprintln(ctx.Stdout, output)
-	}
-
-	return nil
+	return runCommand(ctx, "config", exec)
 }
 
 // ConfigSetCmd handles config set
 type ConfigSetCmd struct {
-	Key   string `arg:"" required:"" help:"Configuration key to set"`
+	Key   string `arg:"" required:"" predictor:"config-key" help:"Configuration key to set"`
 	Value string `arg:"" required:"" help:"Value to set"`
 }
 
@@ -288,17 +368,7 @@ func (c *ConfigSetCmd) Run(ctx *Context) error {
 		Data:    make(map[string]interface{}),
 	}
 
-	err := ctx.Registry.GetExecutor().Execute(ctx.Ctx, "config", exec)
-	if err != nil {
-		return err
-	}
-
-	// Print the output
-	if output, ok := exec.Data["output"].(string); ok {
-		fmt.Fprintln(ctx.Stdout, output)
-	}
-
-	return nil
+	return runCommand(ctx, "config", exec)
 }
 
 // ConfigValidateCmd handles config validate
@@ -314,17 +384,7 @@ func (c *ConfigValidateCmd) Run(ctx *Context) error {
 		Data:    make(map[string]interface{}),
 	}
 
-	err := ctx.Registry.GetExecutor().Execute(ctx.Ctx, "config", exec)
-	if err != nil {
-		return err
-	}
-
-	// Print the output
-	if output, ok := exec.Data["output"].(string); ok {
-		fmt.Fprintln(ctx.Stdout, output)
-	}
-
-	return nil
+	return runCommand(ctx, "config", exec)
 }
 
 // ConfigGenerateCmd handles config generate
@@ -352,11 +412,67 @@ func (c *ConfigGenerateCmd) Run(ctx *Context) error {
 	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "config", exec)
 }
 
+// ConfigDiffCmd handles config diff
+type ConfigDiffCmd struct {
+	Prefix string `arg:"" optional:"" predictor:"config-key" help:"Only show keys under this prefix"`
+	All    bool   `help:"Include values that match their defaults"`
+}
+
+func (c *ConfigDiffCmd) Run(ctx *Context) error {
+	args := []string{"diff"}
+	if c.Prefix != "" {
+		args = append(args, c.Prefix)
+	}
+	flags := make(map[string]interface{})
+	if c.All {
+		flags["all"] = true
+	}
+
+	exec := &command.ExecutionContext{
+		Args:    args,
+		Flags:   command.NewFlags(flags),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+		Data:    make(map[string]interface{}),
+	}
+	return runCommand(ctx, "config", exec)
+}
+
+// ConfigEncryptKeysCmd handles config encrypt-keys
+type ConfigEncryptKeysCmd struct {
+	File    string `arg:"" optional:"" type:"existingfile" help:"Config file to encrypt (default: the user config file)"`
+	Keyring bool   `help:"Encrypt with a key kept in the OS keychain instead of a passphrase"`
+}
+
+func (c *ConfigEncryptKeysCmd) Run(ctx *Context) error {
+	args := []string{"encrypt-keys"}
+	if c.File != "" {
+		args = append(args, c.File)
+	}
+	flags := make(map[string]interface{})
+	if c.Keyring {
+		flags["keyring"] = true
+	}
+
+	exec := &command.ExecutionContext{
+		Args:    args,
+		Flags:   command.NewFlags(flags),
+		Stdin:   pipedStdin(),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+		Data:    make(map[string]interface{}),
+	}
+	return runCommand(ctx, "config", exec)
+}
+
 // ModelCmd handles the model command
 type ModelCmd struct {
 	List   ModelListCmd   `cmd:"" help:"List available models"`
 	Info   ModelInfoCmd   `cmd:"" help:"Show model information"`
 	Select ModelSelectCmd `cmd:"" help:"Select default model"`
+	Update ModelUpdateCmd `cmd:"" help:"Download the latest models inventory"`
 }
 
 // ModelListCmd handles model list
@@ -379,12 +495,12 @@ func (m *ModelListCmd) Run(ctx *Context) error {
 	if m.Capability != "" {
 		exec.Flags.Set("capability", m.Capability)
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "model", exec)
+	return runCommand(ctx, "model", exec)
 }
 
 // ModelInfoCmd handles model info
 type ModelInfoCmd struct {
-	Model string `arg:"" required:"" help:"Model to show info for"`
+	Model string `arg:"" required:"" predictor:"model" help:"Model to show info for"`
 }
 
 func (m *ModelInfoCmd) Run(ctx *Context) error {
@@ -395,12 +511,12 @@ func (m *ModelInfoCmd) Run(ctx *Context) error {
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "model", exec)
+	return runCommand(ctx, "model", exec)
 }
 
 // ModelSelectCmd handles model select
 type ModelSelectCmd struct {
-	Model string `arg:"" required:"" help:"Model to select"`
+	Model string `arg:"" required:"" predictor:"model" help:"Model to select"`
 }
 
 func (m *ModelSelectCmd) Run(ctx *Context) error {
@@ -411,7 +527,26 @@ func (m *ModelSelectCmd) Run(ctx *Context) error {
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "model", exec)
+	return runCommand(ctx, "model", exec)
+}
+
+// ModelUpdateCmd handles model update
+type ModelUpdateCmd struct {
+	URL string `name:"url" help:"URL to download models.json from (default: model.inventory_url or the project repository)"`
+}
+
+func (m *ModelUpdateCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"update"},
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	if m.URL != "" {
+		exec.Flags.Set("url", m.URL)
+	}
+	return runCommand(ctx, "model", exec)
 }
 
 // ProfileCmd handles the profile command
@@ -435,7 +570,7 @@ func (p *ProfileListCmd) Run(ctx *Context) error {
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "profile", exec)
+	return runCommand(ctx, "profile", exec)
 }
 
 // ProfileCreateCmd handles profile create
@@ -463,28 +598,33 @@ func (p *ProfileCreateCmd) Run(ctx *Context) error {
 	if p.Description != "" {
 		exec.Flags.Set("description", p.Description)
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "profile", exec)
+	return runCommand(ctx, "profile", exec)
 }
 
 // ProfileSwitchCmd handles profile switch
 type ProfileSwitchCmd struct {
-	Name string `arg:"" required:"" help:"Profile to switch to"`
+	Name string `arg:"" optional:"" predictor:"profile" help:"Profile to switch to (chosen from a list when omitted)"`
 }
 
 func (p *ProfileSwitchCmd) Run(ctx *Context) error {
+	args := []string{"switch"}
+	if p.Name != "" {
+		args = append(args, p.Name)
+	}
 	exec := &command.ExecutionContext{
-		Args:    []string{"switch", p.Name},
+		Args:    args,
 		Flags:   command.NewFlags(nil),
 		Stdout:  ctx.Stdout,
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "profile", exec)
+	return runCommand(ctx, "profile", exec)
 }
 
 // ProfileShowCmd handles profile show
 type ProfileShowCmd struct {
-	Name string `arg:"" help:"Profile to show (default: current)"`
+	Name     string `arg:"" predictor:"profile" help:"Profile to show (default: current)"`
+	Resolved bool   `help:"Include the values the profile inherits from profiles it extends"`
 }
 
 func (p *ProfileShowCmd) Run(ctx *Context) error {
@@ -492,19 +632,23 @@ func (p *ProfileShowCmd) Run(ctx *Context) error {
 	if p.Name != "" {
 		args = append(args, p.Name)
 	}
+	flags := make(map[string]interface{})
+	if p.Resolved {
+		flags["resolved"] = true
+	}
 	exec := &command.ExecutionContext{
 		Args:    args,
-		Flags:   command.NewFlags(nil),
+		Flags:   command.NewFlags(flags),
 		Stdout:  ctx.Stdout,
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "profile", exec)
+	return runCommand(ctx, "profile", exec)
 }
 
 // ProfileUpdateCmd handles profile update
 type ProfileUpdateCmd struct {
-	Name   string   `arg:"" required:"" help:"Profile to update"`
+	Name   string   `arg:"" required:"" predictor:"profile" help:"Profile to update"`
 	Config []string `arg:"" required:"" help:"Configuration values (key=value)"`
 }
 
@@ -518,23 +662,24 @@ func (p *ProfileUpdateCmd) Run(ctx *Context) error {
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "profile", exec)
+	return runCommand(ctx, "profile", exec)
 }
 
 // ProfileDeleteCmd handles profile delete
 type ProfileDeleteCmd struct {
-	Name string `arg:"" required:"" help:"Profile to delete"`
+	Name string `arg:"" required:"" predictor:"profile" help:"Profile to delete"`
+	Yes  bool   `short:"y" help:"Delete without asking for confirmation"`
 }
 
 func (p *ProfileDeleteCmd) Run(ctx *Context) error {
 	exec := &command.ExecutionContext{
 		Args:    []string{"delete", p.Name},
-		Flags:   command.NewFlags(nil),
+		Flags:   command.NewFlags(map[string]interface{}{command.FlagYes: assumeYes(ctx, p.Yes)}),
 		Stdout:  ctx.Stdout,
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "profile", exec)
+	return runCommand(ctx, "profile", exec)
 }
 
 // AliasCmd handles the alias command
@@ -548,7 +693,7 @@ type AliasCmd struct {
 // AliasAddCmd handles alias add
 type AliasAddCmd struct {
 	Name    string   `arg:"" required:"" help:"Alias name"`
-	Command []string `arg:"" required:"" help:"Command to alias"`
+	Command []string `arg:"" required:"" passthrough:"" help:"Command to alias ($1, $@ and flag parameters are filled in when it runs)"`
 	Scope   string   `help:"Alias scope (cli, repl, all)"`
 }
 
@@ -565,7 +710,7 @@ func (a *AliasAddCmd) Run(ctx *Context) error {
 	if a.Scope != "" {
 		exec.Flags.Set("scope", a.Scope)
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "alias", exec)
+	return runCommand(ctx, "alias", exec)
 }
 
 // AliasRemoveCmd handles alias remove
@@ -581,7 +726,7 @@ func (a *AliasRemoveCmd) Run(ctx *Context) error {
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "alias", exec)
+	return runCommand(ctx, "alias", exec)
 }
 
 // AliasListCmd handles alias list
@@ -600,7 +745,7 @@ func (a *AliasListCmd) Run(ctx *Context) error {
 	if a.Scope != "" {
 		exec.Flags.Set("scope", a.Scope)
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "alias", exec)
+	return runCommand(ctx, "alias", exec)
 }
 
 // AliasShowCmd handles alias show
@@ -616,176 +761,936 @@ func (a *AliasShowCmd) Run(ctx *Context) error {
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "alias", exec)
+	return runCommand(ctx, "alias", exec)
 }
 
-// Context provides runtime context for commands
-// HistoryCmd handles the history command
-type HistoryCmd struct {
-	List   HistoryListCmd   `cmd:"" help:"List all sessions"`
-	Show   HistoryShowCmd   `cmd:"" help:"Show session details"`
-	Delete HistoryDeleteCmd `cmd:"" help:"Delete a session"`
-	Export HistoryExportCmd `cmd:"" help:"Export a session"`
-	Search HistorySearchCmd `cmd:"" help:"Search sessions by content"`
+// TemplateCmd handles the template command
+type TemplateCmd struct {
+	List   TemplateListCmd   `cmd:"" help:"List all templates"`
+	Add    TemplateAddCmd    `cmd:"" help:"Add or replace a template"`
+	Show   TemplateShowCmd   `cmd:"" help:"Show a template and its variables"`
+	Remove TemplateRemoveCmd `cmd:"" help:"Remove a template"`
+	Render TemplateRenderCmd `cmd:"" help:"Print a template filled in with variables"`
+	Run    TemplateRunCmd    `cmd:"" help:"Fill in a template and send it to the LLM"`
 }
 
-// HistoryListCmd lists all sessions
-type HistoryListCmd struct{}
-
-// Run executes the history list command
-func (h *HistoryListCmd) Run(ctx *Context) error {
+// runTemplate executes a template subcommand and prints its output
+func runTemplate(ctx *Context, args []string, flags map[string]interface{}) error {
 	exec := &command.ExecutionContext{
-		Args:    []string{"list"},
-		Flags:   command.NewFlags(nil),
+		Args:    args,
+		Flags:   command.NewFlags(flags),
+		Stdin:   pipedStdin(),
 		Stdout:  ctx.Stdout,
 		Stderr:  ctx.Stderr,
 		Context: ctx.Ctx,
+		Data:    make(map[string]interface{}),
+	}
+	if ctx.CLI != nil && ctx.CLI.Output != "" {
+		exec.Flags.Set("output", ctx.CLI.Output)
+	}
+
+	if err := ctx.Registry.GetExecutor().Execute(ctx.Ctx, "template", exec); err != nil {
+		return err
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "history", exec)
+	if output, ok := exec.Data["output"].(string); ok {
+		fmt.Fprintln(ctx.Stdout, output)
+	}
+	return nil
 }
 
-// HistoryShowCmd shows session details
-type HistoryShowCmd struct {
-	SessionID string `arg:"" required:"" help:"Session ID to show"`
+// assumeYes reports whether confirmations are skipped, by --yes or by
+// turning off cli.confirm in the config
+func assumeYes(ctx *Context, yes bool) bool {
+	return yes || (ctx.Config != nil && ctx.Config.Exists("cli.confirm") && !ctx.Config.GetBool("cli.confirm"))
 }
 
-// Run executes the history show command
-func (h *HistoryShowCmd) Run(ctx *Context) error {
-	exec := &command.ExecutionContext{
-		Args:    []string{"show", h.SessionID},
-		Flags:   command.NewFlags(nil),
-		Stdout:  ctx.Stdout,
-		Stderr:  ctx.Stderr,
-		Context: ctx.Ctx,
+// runCommand executes a command with the global output format and prints the
+// output it leaves in exec.Data
+func runCommand(ctx *Context, name string, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+	if ctx.CLI != nil && ctx.CLI.Output != "" {
+		exec.Data["outputFormat"] = ctx.CLI.Output
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "history", exec)
-}
 
-// HistoryDeleteCmd deletes a session
-type HistoryDeleteCmd struct {
-	SessionID string `arg:"" required:"" help:"Session ID to delete"`
+	if err := ctx.Registry.GetExecutor().Execute(ctx.Ctx, name, exec); err != nil {
+		return err
+	}
+	if output, ok := exec.Data["output"].(string); ok && output != "" {
+		fmt.Fprintln(ctx.Stdout, strings.TrimRight(output, "\n"))
+	}
+	return nil
 }
 
-// Run executes the history delete command
-func (h *HistoryDeleteCmd) Run(ctx *Context) error {
-	exec := &command.ExecutionContext{
-		Args:    []string{"delete", h.SessionID},
-		Flags:   command.NewFlags(nil),
-		Stdout:  ctx.Stdout,
-		Stderr:  ctx.Stderr,
-		Context: ctx.Ctx,
+// newStatsStore returns the store of command telemetry in the stats
+// directory, or nil when there is no home directory to keep it in
+func newStatsStore() *command.StatsStore {
+	paths, err := configdir.GetPaths()
+	if err != nil {
+		logging.LogWarn("Command telemetry disabled", "error", err)
+		return nil
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "history", exec)
+	return command.NewStatsStore(filepath.Join(paths.Stats, "commands.jsonl"))
 }
 
-// HistoryExportCmd exports a session
-type HistoryExportCmd struct {
-	SessionID string `arg:"" required:"" help:"Session ID to export"`
-	Format    string `default:"json" enum:"json,markdown" help:"Export format"`
+// logConfigFrom returns base with the log format, file, and rotation set
+// in the configuration. The level is left alone; it comes from
+// MAGELLAI_LOG_LEVEL and the -v and -q flags.
+func logConfigFrom(cfg *config.Config, base logging.LogConfig) logging.LogConfig {
+	if format := cfg.GetString("log.format"); format != "" {
+		base.Format = format
+	}
+	if file := cfg.GetString("log.file"); file != "" {
+		base.OutputPath = stringutil.ExpandPath(file)
+	}
+	base.Rotation = logging.RotationConfig{
+		MaxSize:    int64(cfg.GetInt("log.rotation.size")) * 1024 * 1024,
+		MaxBackups: cfg.GetInt("log.rotation.keep"),
+	}
+	if interval, err := time.ParseDuration(cfg.GetString("log.rotation.interval")); err == nil {
+		base.Rotation.Interval = interval
+	}
+	if age, err := time.ParseDuration(cfg.GetString("log.rotation.age")); err == nil {
+		base.Rotation.MaxAge = age
+	}
+	return base
 }
 
-// Run executes the history export command
-func (h *HistoryExportCmd) Run(ctx *Context) error {
-	exec := &command.ExecutionContext{
-		Args:    []string{"export", h.SessionID},
-		Flags:   command.NewFlags(nil),
-		Stdout:  ctx.Stdout,
-		Stderr:  ctx.Stderr,
-		Context: ctx.Ctx,
+// newAuditLog returns the audit log at audit.path, or in the audit
+// directory when no path is set. It is nil when there is no home directory
+// to keep it in.
+func newAuditLog(cfg *config.Config) *audit.Log {
+	if path := cfg.GetString("audit.path"); path != "" {
+		return audit.NewLog(stringutil.ExpandPath(path))
 	}
-	exec.Flags.Set("format", h.Format)
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "history", exec)
+	paths, err := configdir.GetPaths()
+	if err != nil {
+		logging.LogWarn("Audit log disabled", "error", err)
+		return nil
+	}
+	return audit.NewLog(filepath.Join(paths.Audit, "audit.jsonl"))
 }
 
-// HistorySearchCmd searches sessions
-type HistorySearchCmd struct {
-	Query string `arg:"" required:"" help:"Search query"`
+// commandContext returns the context a command runs with. The first Ctrl-C
+// or SIGTERM cancels it, so requests in flight stop and the command returns;
+// a second one exits at once. Chat handles Ctrl-C itself, so its context is
+// only canceled when the command returns.
+func commandContext(command string) (context.Context, context.CancelFunc) {
+	if name, _, _ := strings.Cut(command, " "); name == "chat" {
+		return context.WithCancel(context.Background())
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
 }
 
-// Run executes the history search command
-func (h *HistorySearchCmd) Run(ctx *Context) error {
-	exec := &command.ExecutionContext{
-		Args:    []string{"search", h.Query},
-		Flags:   command.NewFlags(nil),
-		Stdout:  ctx.Stdout,
-		Stderr:  ctx.Stderr,
-		Context: ctx.Ctx,
+// pipedStdin returns stdin when data is piped in, and nil for a terminal
+func pipedStdin() io.Reader {
+	if stat, err := os.Stdin.Stat(); err == nil && (stat.Mode()&os.ModeCharDevice) == 0 {
+		return os.Stdin
 	}
-	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "history", exec)
+	return nil
 }
 
-type Context struct {
-	*kong.Context
-	Registry *command.Registry
-	Config   *config.Config
-	Logger   *logging.Logger
-	Stdout   io.Writer
-	Stderr   io.Writer
-	Ctx      context.Context
-	CLI      *CLI // Reference to global CLI options
+// TemplateListCmd handles template list
+type TemplateListCmd struct{}
+
+func (t *TemplateListCmd) Run(ctx *Context) error {
+	return runTemplate(ctx, []string{"list"}, nil)
 }
 
-func main() {
-	// Create the CLI parser
-	parser := kong.Must(&CLI{},
-		kong.Name("magellai"),
-		kong.Description("A command-line interface for interacting with Large Language Models"),
-		kong.UsageOnError(),
-		kong.ConfigureHelp(kong.HelpOptions{
-			Compact:             true,
-			Summary:             true,
-			NoExpandSubcommands: true,
-		}),
-	)
+// TemplateAddCmd handles template add
+type TemplateAddCmd struct {
+	Name        string `arg:"" required:"" help:"Template name"`
+	Content     string `arg:"" optional:"" help:"Template content with {{placeholders}} (reads --file or stdin if omitted)"`
+	Description string `short:"d" help:"Template description"`
+	File        string `short:"f" type:"existingfile" help:"Read template content from a file"`
+}
 
-	// Check for version flag early
-	for _, arg := range os.Args[1:] {
-		if arg == "--version" {
-			// Initialize logger early for version output
-			if err := logging.Initialize(logging.DefaultConfig()); err == nil {
-				logger := logging.GetLogger()
-				logger.Info("Version information", "version", version)
-			} else {
-				// Fall back to fmt if logging init fails
-				fmt.Printf("magellai version %s\n", version)
-			}
-			os.Exit(0)
-		}
+func (t *TemplateAddCmd) Run(ctx *Context) error {
+	flags := map[string]interface{}{}
+	if t.Description != "" {
+		flags["description"] = t.Description
 	}
-
-	// Parse arguments
-	kongCtx, err := parser.Parse(os.Args[1:])
-	if err != nil {
-		parser.FatalIfErrorf(err)
+	if t.File != "" {
+		flags["file"] = t.File
 	}
+	return runTemplate(ctx, []string{"add", t.Name, t.Content}, flags)
+}
 
-	// Initialize logger
-	logLevel := "warn"
-	if envLevel := os.Getenv("MAGELLAI_LOG_LEVEL"); envLevel != "" {
-		logLevel = envLevel
-	}
+// TemplateShowCmd handles template show
+type TemplateShowCmd struct {
+	Name string `arg:"" required:"" help:"Template to show"`
+}
+
+func (t *TemplateShowCmd) Run(ctx *Context) error {
+	return runTemplate(ctx, []string{"show", t.Name}, nil)
+}
+
+// TemplateRemoveCmd handles template remove
+type TemplateRemoveCmd struct {
+	Name string `arg:"" required:"" help:"Template to remove"`
+}
+
+func (t *TemplateRemoveCmd) Run(ctx *Context) error {
+	return runTemplate(ctx, []string{"remove", t.Name}, nil)
+}
+
+// TemplateRenderCmd handles template render
+type TemplateRenderCmd struct {
+	Name string   `arg:"" required:"" help:"Template to render"`
+	Vars []string `arg:"" optional:"" help:"Variables as name=value (@file reads a file, - reads stdin)"`
+}
+
+func (t *TemplateRenderCmd) Run(ctx *Context) error {
+	return runTemplate(ctx, append([]string{"render", t.Name}, t.Vars...), nil)
+}
+
+// TemplateRunCmd handles template run
+type TemplateRunCmd struct {
+	Name   string   `arg:"" required:"" help:"Template to run"`
+	Vars   []string `arg:"" optional:"" help:"Variables as name=value (@file reads a file, - reads stdin)"`
+	Model  string   `short:"m" predictor:"model" help:"Model to use (provider/model format)"`
+	Stream bool     `help:"Stream the response"`
+}
 
-	logConfig := logging.LogConfig{
-		Level:      logLevel,
-		Format:     "text",
-		OutputPath: "stderr",
-		AddSource:  false,
+func (t *TemplateRunCmd) Run(ctx *Context) error {
+	flags := map[string]interface{}{}
+	if t.Model != "" {
+		flags["model"] = t.Model
 	}
-	if err := logging.Initialize(logConfig); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+	if t.Stream {
+		flags["stream"] = t.Stream
 	}
-	logger := logging.GetLogger()
+	return runTemplate(ctx, append([]string{"run", t.Name}, t.Vars...), flags)
+}
 
-	// Initialize configuration
-	if err := config.Init(); err != nil {
-		logger.Error("Failed to initialize configuration", "error", err)
-		os.Exit(1)
-	}
-	cfg := config.Manager
+// PromptCmd handles the prompt command
+type PromptCmd struct {
+	List   PromptListCmd   `cmd:"" help:"List all prompts"`
+	Add    PromptAddCmd    `cmd:"" help:"Add or replace a prompt"`
+	Show   PromptShowCmd   `cmd:"" help:"Show a prompt and its variables"`
+	Remove PromptRemoveCmd `cmd:"" help:"Remove a prompt"`
+	Run    PromptRunCmd    `cmd:"" help:"Fill in a prompt and send it to the LLM"`
+}
 
-	// Apply global flags to configuration
-	var cli CLI
+// runPrompt executes a prompt subcommand
+func runPrompt(ctx *Context, args []string, flags map[string]interface{}) error {
+	exec := &command.ExecutionContext{
+		Args:    args,
+		Flags:   command.NewFlags(flags),
+		Stdin:   pipedStdin(),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	if ctx.CLI != nil && ctx.CLI.Output != "" {
+		exec.Flags.Set("output", ctx.CLI.Output)
+	}
+	return runCommand(ctx, "prompt", exec)
+}
+
+// PromptListCmd handles prompt list
+type PromptListCmd struct{}
+
+func (p *PromptListCmd) Run(ctx *Context) error {
+	return runPrompt(ctx, []string{"list"}, nil)
+}
+
+// PromptAddCmd handles prompt add
+type PromptAddCmd struct {
+	Name        string `arg:"" required:"" help:"Prompt name"`
+	Content     string `arg:"" optional:"" help:"Prompt content with {{placeholders}} (reads --file or stdin if omitted)"`
+	Description string `short:"d" help:"Prompt description"`
+	File        string `short:"f" type:"existingfile" help:"Read prompt content from a file"`
+}
+
+func (p *PromptAddCmd) Run(ctx *Context) error {
+	return runPrompt(ctx, []string{"add", p.Name, p.Content}, map[string]interface{}{
+		"description": p.Description,
+		"file":        p.File,
+	})
+}
+
+// PromptShowCmd handles prompt show
+type PromptShowCmd struct {
+	Name string `arg:"" required:"" help:"Prompt name"`
+}
+
+func (p *PromptShowCmd) Run(ctx *Context) error {
+	return runPrompt(ctx, []string{"show", p.Name}, nil)
+}
+
+// PromptRemoveCmd handles prompt remove
+type PromptRemoveCmd struct {
+	Name string `arg:"" required:"" help:"Prompt name"`
+}
+
+func (p *PromptRemoveCmd) Run(ctx *Context) error {
+	return runPrompt(ctx, []string{"remove", p.Name}, nil)
+}
+
+// PromptRunCmd handles prompt run
+type PromptRunCmd struct {
+	Name   string   `arg:"" required:"" help:"Prompt to run"`
+	Var    []string `sep:"none" help:"Variable as name=value (@file reads a file, - reads stdin; repeatable)"`
+	Model  string   `short:"m" predictor:"model" help:"Model to use (provider/model format)"`
+	Stream bool     `help:"Stream the response"`
+}
+
+func (p *PromptRunCmd) Run(ctx *Context) error {
+	flags := map[string]interface{}{"var": p.Var}
+	if p.Model != "" {
+		flags["model"] = p.Model
+	}
+	if p.Stream {
+		flags["stream"] = p.Stream
+	}
+	return runPrompt(ctx, []string{"run", p.Name}, flags)
+}
+
+// GitCmd handles the git command
+type GitCmd struct {
+	CommitMsg GitCommitMsgCmd `cmd:"" name:"commit-msg" help:"Write a commit message for the staged changes"`
+	Review    GitReviewCmd    `cmd:"" help:"Review uncommitted changes, a commit range, or a diff on stdin"`
+}
+
+// runGit executes a git subcommand
+func runGit(ctx *Context, args []string, flags map[string]interface{}) error {
+	exec := &command.ExecutionContext{
+		Args:    args,
+		Flags:   command.NewFlags(flags),
+		Stdin:   pipedStdin(),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	if ctx.CLI != nil && ctx.CLI.Output != "" {
+		exec.Flags.Set("output", ctx.CLI.Output)
+	}
+	return runCommand(ctx, "git", exec)
+}
+
+// GitCommitMsgCmd handles git commit-msg
+type GitCommitMsgCmd struct {
+	Model        string `short:"m" predictor:"model" help:"Model to use (provider/model format)"`
+	Conventional bool   `help:"Use the Conventional Commits format"`
+	Stream       bool   `help:"Stream the response"`
+}
+
+func (g *GitCommitMsgCmd) Run(ctx *Context) error {
+	flags := map[string]interface{}{"conventional": g.Conventional}
+	if g.Model != "" {
+		flags["model"] = g.Model
+	}
+	if g.Stream {
+		flags["stream"] = g.Stream
+	}
+	return runGit(ctx, []string{"commit-msg"}, flags)
+}
+
+// GitReviewCmd handles git review
+type GitReviewCmd struct {
+	Revisions []string `arg:"" optional:"" help:"Revision or range to review (e.g. HEAD~3, main...feature), or - for a diff on stdin"`
+	Staged    bool     `help:"Review only staged changes"`
+	Model     string   `short:"m" predictor:"model" help:"Model to use (provider/model format)"`
+	Stream    bool     `help:"Stream the response"`
+}
+
+func (g *GitReviewCmd) Run(ctx *Context) error {
+	flags := map[string]interface{}{"staged": g.Staged}
+	if g.Model != "" {
+		flags["model"] = g.Model
+	}
+	if g.Stream {
+		flags["stream"] = g.Stream
+	}
+	return runGit(ctx, append([]string{"review"}, g.Revisions...), flags)
+}
+
+// ServeCmd handles the serve command
+type ServeCmd struct {
+	Addr  string `help:"Address to listen on (default server.address)"`
+	Token string `help:"Bearer token required on requests; needed to listen off loopback (default server.token)"`
+}
+
+// Run executes the serve command until interrupted
+func (s *ServeCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{},
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	if s.Addr != "" {
+		exec.Flags.Set("addr", s.Addr)
+	}
+	if s.Token != "" {
+		exec.Flags.Set("token", s.Token)
+	}
+	return ctx.Registry.GetExecutor().Execute(ctx.Ctx, "serve", exec)
+}
+
+// DoctorCmd handles the doctor command
+type DoctorCmd struct{}
+
+// Run executes the doctor command
+func (d *DoctorCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{},
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "doctor", exec)
+}
+
+// StatsCmd handles the stats command
+type StatsCmd struct {
+	Commands StatsCommandsCmd `cmd:"" default:"withargs" help:"Summarize runs, failures, durations, and tokens per command"`
+	Clear    StatsClearCmd    `cmd:"" help:"Delete all recorded command runs"`
+}
+
+// StatsCommandsCmd summarizes command telemetry
+type StatsCommandsCmd struct {
+	Command string        `arg:"" optional:"" help:"Only count runs of this command"`
+	Since   time.Duration `help:"Only count runs started within this duration (e.g. 24h)"`
+}
+
+// Run executes the stats commands command
+func (s *StatsCommandsCmd) Run(ctx *Context) error {
+	flags := map[string]interface{}{}
+	if s.Command != "" {
+		flags["command"] = s.Command
+	}
+	if s.Since > 0 {
+		flags["since"] = s.Since
+	}
+	exec := &command.ExecutionContext{
+		Args:    []string{"commands"},
+		Flags:   command.NewFlags(flags),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "stats", exec)
+}
+
+// StatsClearCmd deletes command telemetry
+type StatsClearCmd struct {
+	Yes bool `short:"y" help:"Clear without asking for confirmation"`
+}
+
+// Run executes the stats clear command
+func (s *StatsClearCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"clear"},
+		Flags:   command.NewFlags(map[string]interface{}{command.FlagYes: assumeYes(ctx, s.Yes)}),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "stats", exec)
+}
+
+// AuditCmd handles the audit command
+type AuditCmd struct {
+	Log AuditLogCmd `cmd:"" default:"withargs" help:"List who changed sessions and configuration, and when"`
+}
+
+// AuditLogCmd lists audit log entries
+type AuditLogCmd struct {
+	Since   time.Duration `help:"Only show entries recorded within this duration (e.g. 168h)"`
+	Action  string        `help:"Only show entries of this action (e.g. session.deleted)"`
+	Session string        `help:"Only show entries about this session"`
+	Limit   int           `help:"Only show the newest entries, up to this many"`
+}
+
+// Run executes the audit log command
+func (a *AuditLogCmd) Run(ctx *Context) error {
+	flags := map[string]interface{}{}
+	if a.Since > 0 {
+		flags["since"] = a.Since
+	}
+	if a.Action != "" {
+		flags["action"] = a.Action
+	}
+	if a.Session != "" {
+		flags["session"] = a.Session
+	}
+	if a.Limit > 0 {
+		flags["limit"] = a.Limit
+	}
+	exec := &command.ExecutionContext{
+		Args:    []string{"log"},
+		Flags:   command.NewFlags(flags),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "audit", exec)
+}
+
+// RunCmd handles the run command
+type RunCmd struct {
+	Workflow string   `arg:"" type:"existingfile" help:"Workflow YAML file"`
+	Var      []string `sep:"none" help:"Variable as name=value (@file reads a file, - reads stdin; repeatable)"`
+	Model    string   `short:"m" predictor:"model" help:"Model for prompt steps that do not set one (provider/model format)"`
+	Save     bool     `help:"Save the prompts and responses as a session"`
+	Name     string   `help:"Name for the saved session"`
+}
+
+// Run executes the run command
+func (r *RunCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args: []string{r.Workflow},
+		Flags: command.NewFlags(map[string]interface{}{
+			"var":   r.Var,
+			"model": r.Model,
+			"save":  r.Save,
+			"name":  r.Name,
+		}),
+		Stdin:   pipedStdin(),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	if ctx.CLI != nil && ctx.CLI.Quiet {
+		exec.Flags.Set("quiet", true)
+	}
+	return runCommand(ctx, "run", exec)
+}
+
+// ImportCmd handles the import command
+type ImportCmd struct {
+	Format string `short:"f" required:"" help:"Export format (chatgpt, claude, openai, markdown)"`
+	File   string `arg:"" help:"Export file (zip, JSON, or markdown), or - for stdin"`
+}
+
+// Run executes the import command
+func (i *ImportCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{i.File},
+		Flags:   command.NewFlags(map[string]interface{}{"format": i.Format}),
+		Stdin:   pipedStdin(),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "import", exec)
+}
+
+// StorageCmd handles the storage command
+type StorageCmd struct {
+	Fsck   StorageFsckCmd   `cmd:"" help:"Find damaged session files and restore them from recovery copies"`
+	Repair StorageRepairCmd `cmd:"" help:"Bring the storage replica up to date with the primary backend"`
+	GC     StorageGCCmd     `cmd:"" name:"gc" help:"Delete data that no session refers to any more"`
+}
+
+// StorageFsckCmd handles storage fsck
+type StorageFsckCmd struct {
+	DryRun bool `name:"dry-run" help:"Report damaged files without restoring them"`
+}
+
+// Run executes the storage fsck command
+func (s *StorageFsckCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"fsck"},
+		Flags:   command.NewFlags(map[string]interface{}{"dry-run": s.DryRun}),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "storage", exec)
+}
+
+// StorageRepairCmd handles storage repair
+type StorageRepairCmd struct {
+	DryRun bool `name:"dry-run" help:"Report differences without fixing them"`
+}
+
+// Run executes the storage repair command
+func (s *StorageRepairCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"repair"},
+		Flags:   command.NewFlags(map[string]interface{}{"dry-run": s.DryRun}),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "storage", exec)
+}
+
+// StorageGCCmd handles storage gc
+type StorageGCCmd struct {
+	DryRun bool `name:"dry-run" help:"Report orphaned data without deleting it"`
+}
+
+// Run executes the storage gc command
+func (s *StorageGCCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"gc"},
+		Flags:   command.NewFlags(map[string]interface{}{"dry-run": s.DryRun}),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "storage", exec)
+}
+
+// KeysCmd handles the keys command
+type KeysCmd struct {
+	List   KeysListCmd   `cmd:"" help:"Show where each provider's key comes from"`
+	Set    KeysSetCmd    `cmd:"" help:"Store a key in the keychain"`
+	Get    KeysGetCmd    `cmd:"" help:"Show a stored key"`
+	Remove KeysRemoveCmd `cmd:"" help:"Delete a stored key"`
+}
+
+// runKeys executes a keys subcommand
+func runKeys(ctx *Context, args []string, flags map[string]interface{}) error {
+	exec := &command.ExecutionContext{
+		Args:    args,
+		Flags:   command.NewFlags(flags),
+		Stdin:   pipedStdin(),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "keys", exec)
+}
+
+// KeysListCmd handles keys list
+type KeysListCmd struct{}
+
+func (k *KeysListCmd) Run(ctx *Context) error {
+	return runKeys(ctx, []string{"list"}, nil)
+}
+
+// KeysSetCmd handles keys set
+type KeysSetCmd struct {
+	Provider string `arg:"" required:"" help:"Provider (openai, anthropic, gemini)"`
+	Key      string `arg:"" optional:"" help:"API key (read from stdin or prompted if omitted)"`
+}
+
+func (k *KeysSetCmd) Run(ctx *Context) error {
+	return runKeys(ctx, []string{"set", k.Provider, k.Key}, nil)
+}
+
+// KeysGetCmd handles keys get
+type KeysGetCmd struct {
+	Provider string `arg:"" required:"" help:"Provider (openai, anthropic, gemini)"`
+	Reveal   bool   `help:"Print the full key instead of a masked one"`
+}
+
+func (k *KeysGetCmd) Run(ctx *Context) error {
+	return runKeys(ctx, []string{"get", k.Provider}, map[string]interface{}{"reveal": k.Reveal})
+}
+
+// KeysRemoveCmd handles keys remove
+type KeysRemoveCmd struct {
+	Provider string `arg:"" required:"" help:"Provider (openai, anthropic, gemini)"`
+}
+
+func (k *KeysRemoveCmd) Run(ctx *Context) error {
+	return runKeys(ctx, []string{"remove", k.Provider}, nil)
+}
+
+// Context provides runtime context for commands
+// HistoryCmd handles the history command
+type HistoryCmd struct {
+	List      HistoryListCmd      `cmd:"" help:"List all sessions"`
+	Show      HistoryShowCmd      `cmd:"" help:"Show session details"`
+	Delete    HistoryDeleteCmd    `cmd:"" help:"Delete a session"`
+	Rename    HistoryRenameCmd    `cmd:"" help:"Rename a session"`
+	Fork      HistoryForkCmd      `cmd:"" help:"Start a new session from the first messages of a session"`
+	Export    HistoryExportCmd    `cmd:"" help:"Export one or more sessions"`
+	Search    HistorySearchCmd    `cmd:"" help:"Search sessions by content"`
+	Snapshots HistorySnapshotsCmd `cmd:"" help:"List the snapshots kept of a session"`
+	Restore   HistoryRestoreCmd   `cmd:"" help:"Roll a session back to an earlier snapshot"`
+}
+
+// HistoryListCmd lists all sessions
+type HistoryListCmd struct{}
+
+// Run executes the history list command
+func (h *HistoryListCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"list"},
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "history", exec)
+}
+
+// HistoryShowCmd shows session details
+type HistoryShowCmd struct {
+	SessionID string `arg:"" required:"" predictor:"session" help:"Session ID to show"`
+}
+
+// Run executes the history show command
+func (h *HistoryShowCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"show", h.SessionID},
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "history", exec)
+}
+
+// HistoryDeleteCmd deletes a session
+type HistoryDeleteCmd struct {
+	SessionID string `arg:"" required:"" predictor:"session" help:"Session ID to delete"`
+	Yes       bool   `short:"y" help:"Delete without asking for confirmation"`
+	DryRun    bool   `help:"Show what would be deleted without deleting it"`
+}
+
+// Run executes the history delete command
+func (h *HistoryDeleteCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args: []string{"delete", h.SessionID},
+		Flags: command.NewFlags(map[string]interface{}{
+			command.FlagYes:    assumeYes(ctx, h.Yes),
+			command.FlagDryRun: h.DryRun,
+		}),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "history", exec)
+}
+
+// HistoryRenameCmd renames a session
+type HistoryRenameCmd struct {
+	SessionID string   `arg:"" required:"" help:"Session ID to rename"`
+	Name      []string `arg:"" required:"" help:"New session name"`
+}
+
+// Run executes the history rename command
+func (h *HistoryRenameCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    append([]string{"rename", h.SessionID}, h.Name...),
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "history", exec)
+}
+
+// HistoryForkCmd forks a session at a message
+type HistoryForkCmd struct {
+	SessionID    string `arg:"" required:"" predictor:"session" help:"Session ID to fork"`
+	MessageIndex int    `arg:"" required:"" help:"Number of messages to keep in the fork"`
+}
+
+// Run executes the history fork command
+func (h *HistoryForkCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"fork", h.SessionID, strconv.Itoa(h.MessageIndex)},
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "history", exec)
+}
+
+// HistoryExportCmd exports sessions
+type HistoryExportCmd struct {
+	SessionIDs []string `arg:"" optional:"" name:"session-id" predictor:"session" help:"Session IDs to export"`
+	Format     string   `default:"json" enum:"json,markdown,org,text,jsonl,csv" help:"Export format"`
+	All        bool     `help:"Export every session"`
+	Tag        []string `help:"Export sessions with this tag (repeatable; all must match)"`
+	OutputDir  string   `type:"path" help:"Write one file per session into this directory"`
+	Archive    string   `type:"path" help:"Write all sessions into this zip archive"`
+
+	PerExchange      bool     `help:"JSONL: write one line per user message and its replies"`
+	Roles            []string `help:"JSONL: roles to keep (default system,user,assistant)"`
+	StripAttachments bool     `help:"JSONL: leave attachments out"`
+	Dialect          string   `default:"openai" enum:"openai,anthropic" help:"JSONL: fine-tuning message format"`
+}
+
+// Run executes the history export command
+func (h *HistoryExportCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    append([]string{"export"}, h.SessionIDs...),
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	exec.Flags.Set("format", h.Format)
+	exec.Flags.Set("all", h.All)
+	exec.Flags.Set("tag", h.Tag)
+	exec.Flags.Set("output-dir", h.OutputDir)
+	exec.Flags.Set("archive", h.Archive)
+	exec.Flags.Set("per-exchange", h.PerExchange)
+	exec.Flags.Set("roles", h.Roles)
+	exec.Flags.Set("strip-attachments", h.StripAttachments)
+	exec.Flags.Set("dialect", h.Dialect)
+	return runCommand(ctx, "history", exec)
+}
+
+// HistorySnapshotsCmd lists the snapshots of a session
+type HistorySnapshotsCmd struct {
+	SessionID string `arg:"" required:"" predictor:"session" help:"Session ID whose snapshots to list"`
+}
+
+// Run executes the history snapshots command
+func (h *HistorySnapshotsCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"snapshots", h.SessionID},
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "history", exec)
+}
+
+// HistoryRestoreCmd rolls a session back to a snapshot
+type HistoryRestoreCmd struct {
+	SessionID string `arg:"" required:"" predictor:"session" help:"Session ID to restore"`
+	At        string `required:"" help:"Time to roll back to: a timestamp such as \"2025-01-31 14:30\" or a duration ago such as 2h"`
+}
+
+// Run executes the history restore command
+func (h *HistoryRestoreCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    []string{"restore", h.SessionID},
+		Flags:   command.NewFlags(map[string]interface{}{"at": h.At}),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "history", exec)
+}
+
+// HistorySearchCmd searches sessions
+type HistorySearchCmd struct {
+	Query []string `arg:"" required:"" help:"Search query, e.g. tag:work model:gpt-4o before:2025-01-01 role:assistant \"exact phrase\""`
+}
+
+// Run executes the history search command
+func (h *HistorySearchCmd) Run(ctx *Context) error {
+	// The shell has removed the quotes around phrases, so put them back
+	terms := make([]string, len(h.Query))
+	for i, term := range h.Query {
+		if strings.ContainsAny(term, " \t") && !strings.Contains(term, `"`) {
+			if key, value, found := strings.Cut(term, ":"); found && !strings.ContainsAny(key, " \t") {
+				term = key + `:"` + value + `"`
+			} else {
+				term = `"` + term + `"`
+			}
+		}
+		terms[i] = term
+	}
+
+	exec := &command.ExecutionContext{
+		Args:    append([]string{"search"}, terms...),
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, "history", exec)
+}
+
+type Context struct {
+	*kong.Context
+	Registry *command.Registry
+	Config   *config.Config
+	Logger   *logging.Logger
+	Stdout   io.Writer
+	Stderr   io.Writer
+	Ctx      context.Context
+	CLI      *CLI // Reference to global CLI options
+}
+
+func main() {
+	// Create the CLI parser
+	options := []kong.Option{
+		kong.Name("magellai"),
+		kong.Description("A command-line interface for interacting with Large Language Models"),
+		kong.UsageOnError(),
+		kong.ConfigureHelp(kong.HelpOptions{
+			Compact:             true,
+			Summary:             true,
+			NoExpandSubcommands: true,
+		}),
+	}
+	parser := kong.Must(&CLI{}, options...)
+
+	// Surface external magellai-<name> plugins as subcommands. Ctrl-C stops
+	// plugins being asked for their help text.
+	discoverCtx, stopDiscover := commandContext("help")
+	plugins := discoverPlugins(discoverCtx, parser, os.Args[1:])
+	stopDiscover()
+
+	// and commands declared by manifests in the commands directory
+	manifests := discoverManifests(parser, plugins)
+	if len(plugins) > 0 || len(manifests) > 0 {
+		dynamic := append(pluginOptions(plugins), manifestOptions(manifests)...)
+		parser = kong.Must(&CLI{}, append(options, dynamic...)...)
+	}
+
+	// Check for version flag early
+	for _, arg := range os.Args[1:] {
+		if arg == "--version" {
+			// Initialize logger early for version output
+			if err := logging.Initialize(logging.DefaultConfig()); err == nil {
+				logger := logging.GetLogger()
+				logger.Info("Version information", "version", version)
+			} else {
+				// Fall back to fmt if logging init fails
+				fmt.Printf("magellai version %s\n", version)
+			}
+			os.Exit(0)
+		}
+	}
+
+	// Initialize logger
+	if err := logging.Initialize(logging.DefaultConfig()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	logger := logging.GetLogger()
+
+	// Initialize configuration
+	if err := config.Init(); err != nil {
+		logger.Error("Failed to initialize configuration", "error", err)
+		os.Exit(exitConfigError)
+	}
+	cfg := config.Manager
+
+	// Answer shell completion requests before parsing
+	kongplete.Complete(parser, completionPredictors(cfg))
+
+	// Expand a user-defined alias in place of the command
+	args, err := expandCLIAlias(parser, cfg, os.Args[1:])
+	if err != nil {
+		parser.FatalIfErrorf(err)
+	}
+	args = scopeShortFlags(args)
+
+	// Parse arguments
+	kongCtx, err := parser.Parse(args)
+	if err != nil {
+		parser.FatalIfErrorf(err)
+	}
+
+	// Apply global flags to configuration
+	var cli CLI
 	switch v := kongCtx.Model.Target.Interface().(type) {
 	case CLI:
 		cli = v
@@ -799,7 +1704,7 @@ func main() {
 		// Load specific config file
 		if err := cfg.LoadFile(cli.ConfigFile); err != nil {
 			logger.Error("Failed to load config file", "file", cli.ConfigFile, "error", err)
-			os.Exit(1)
+			os.Exit(exitConfigError)
 		}
 	}
 	if cli.ProfileName != "" {
@@ -807,17 +1712,56 @@ func main() {
 		// Set current profile directly first
 		if err := cfg.SetValue("profile.current", cli.ProfileName); err != nil {
 			logger.Error("Failed to save current profile", "profile", cli.ProfileName, "error", err)
-			os.Exit(1)
+			os.Exit(exitConfigError)
 		}
 		// Then apply the profile settings
 		if err := cfg.SetProfile(cli.ProfileName); err != nil {
 			logger.Error("Failed to set profile", "profile", cli.ProfileName, "error", err)
-			os.Exit(1)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	// Log in the format, to the file, and with the rotation configured
+	if err := logging.Initialize(logConfigFrom(cfg, logging.CurrentConfig())); err != nil {
+		logger.Error("Failed to apply log configuration", "error", err)
+	}
+	logger = logging.GetLogger()
+
+	// Export tracing spans when configured
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.GetBool("tracing.enabled") {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+			Endpoint:       cfg.GetString("tracing.endpoint"),
+			Insecure:       cfg.GetBool("tracing.insecure"),
+			Sampling:       cfg.GetFloat64("tracing.sampling"),
+			ServiceVersion: version,
+		})
+		if err != nil {
+			logger.Warn("Tracing disabled", "error", err)
+		} else {
+			shutdownTracing = shutdown
 		}
 	}
 
-	// Set verbosity - map -v flags to log levels
-	if cli.Verbosity > 0 {
+	// Capture provider HTTP traffic for debugging rejected requests
+	if cli.DebugHTTP {
+		if paths, err := configdir.GetPaths(); err != nil {
+			logger.Warn("HTTP capture disabled", "error", err)
+		} else {
+			llm.SetDebugHTTPDir(paths.DebugHTTP)
+			if !cli.Quiet {
+				fmt.Fprintf(os.Stderr, "Saving provider HTTP traffic to %s\n", paths.DebugHTTP)
+			}
+		}
+	}
+
+	// Set verbosity - map -v flags to log levels. Quiet mode only logs errors
+	// and takes precedence.
+	if cli.Quiet {
+		if err := logging.SetLogLevel("error"); err != nil {
+			logger.Error("Failed to set log level", "error", err)
+		}
+	} else if cli.Verbosity > 0 {
 		switch cli.Verbosity {
 		case 1:
 			if err := logging.SetLogLevel("info"); err != nil {
@@ -836,6 +1780,22 @@ func main() {
 
 	// Initialize command registry
 	registry := command.NewRegistry()
+	registry.SetExecutorOptions(command.WithFlagDefaults(cfg.GetCommandDefaults))
+
+	// Record each command's duration, outcome, and token usage locally
+	statsStore := newStatsStore()
+	if statsStore != nil && cfg.GetBool("telemetry.enabled") {
+		registry.AddExecutorOptions(command.WithTelemetry(statsStore))
+	}
+	if cfg.GetBool("tracing.enabled") {
+		registry.AddExecutorOptions(command.WithTracing())
+	}
+
+	// Record session and config changes in the audit log
+	auditLog := newAuditLog(cfg)
+	if auditLog != nil && cfg.GetBool("audit.enabled") {
+		auditLog.Subscribe(domain.DefaultEventBus)
+	}
 
 	// Register core commands
 	configCmd := core.NewConfigCommand(cfg)
@@ -892,7 +1852,94 @@ func main() {
 		os.Exit(1)
 	}
 
+	templateCmd := core.NewTemplateCommand(cfg)
+	if err := registry.Register(templateCmd); err != nil {
+		logger.Error("failed to register template command", "error", err)
+		os.Exit(1)
+	}
+
+	serveCmd := core.NewServeCommand(cfg, registry)
+	if err := registry.Register(serveCmd); err != nil {
+		logger.Error("failed to register serve command", "error", err)
+		os.Exit(1)
+	}
+
+	keysCmd := core.NewKeysCommand(cfg)
+	if err := registry.Register(keysCmd); err != nil {
+		logger.Error("failed to register keys command", "error", err)
+		os.Exit(1)
+	}
+
+	statsCmd := core.NewStatsCommand(statsStore)
+	if err := registry.Register(statsCmd); err != nil {
+		logger.Error("failed to register stats command", "error", err)
+		os.Exit(1)
+	}
+
+	auditCmd := core.NewAuditCommand(auditLog)
+	if err := registry.Register(auditCmd); err != nil {
+		logger.Error("failed to register audit command", "error", err)
+		os.Exit(1)
+	}
+
+	doctorCmd := core.NewDoctorCommand(cfg)
+	if err := registry.Register(doctorCmd); err != nil {
+		logger.Error("failed to register doctor command", "error", err)
+		os.Exit(1)
+	}
+
+	promptCmd := core.NewPromptCommand(cfg)
+	if err := registry.Register(promptCmd); err != nil {
+		logger.Error("failed to register prompt command", "error", err)
+		os.Exit(1)
+	}
+
+	gitCmd := core.NewGitCommand(cfg)
+	if err := registry.Register(gitCmd); err != nil {
+		logger.Error("failed to register git command", "error", err)
+		os.Exit(1)
+	}
+
+	runCmd := core.NewRunCommand(cfg)
+	if err := registry.Register(runCmd); err != nil {
+		logger.Error("failed to register run command", "error", err)
+		os.Exit(1)
+	}
+
+	importCmd := core.NewImportCommand(cfg)
+	if err := registry.Register(importCmd); err != nil {
+		logger.Error("failed to register import command", "error", err)
+		os.Exit(1)
+	}
+
+	storageCmd := core.NewStorageCommand(cfg)
+	if err := registry.Register(storageCmd); err != nil {
+		logger.Error("failed to register storage command", "error", err)
+		os.Exit(1)
+	}
+
+	docsCmd := core.NewDocsCommand(registry, commandDocs(parser.Model), version)
+	if err := registry.Register(docsCmd); err != nil {
+		logger.Error("failed to register docs command", "error", err)
+		os.Exit(1)
+	}
+
+	for _, p := range plugins {
+		pluginCmd := core.NewPluginCommand(cfg, p.Plugin, p.description, version)
+		if err := registry.Register(pluginCmd); err != nil {
+			logger.Warn("failed to register plugin command", "plugin", p.Name, "error", err)
+		}
+	}
+
+	runLine := manifestLineRunner(&cli)
+	for _, m := range manifests {
+		if err := registry.Register(command.NewManifestCommand(m, false, runLine)); err != nil {
+			logger.Warn("failed to register manifest command", "name", m.Name, "path", m.Path, "error", err)
+		}
+	}
+
 	// Create context
+	runCtx, cancel := commandContext(kongCtx.Command())
 	ctx := &Context{
 		Context:  kongCtx,
 		Registry: registry,
@@ -900,17 +1947,23 @@ func main() {
 		Logger:   logger,
 		Stdout:   os.Stdout,
 		Stderr:   os.Stderr,
-		Ctx:      context.Background(),
+		Ctx:      runCtx,
 		CLI:      &cli,
 	}
 
-	// Handle completions
-	kongplete.Complete(parser)
-
 	// Run the command
 	err = kongCtx.Run(ctx)
+	cancel()
+
+	// Send the spans still waiting to be exported
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	if shutdownErr := shutdownTracing(flushCtx); shutdownErr != nil {
+		logger.Warn("Failed to export traces", "error", shutdownErr)
+	}
+	cancelFlush()
+
 	if err != nil {
 		logger.Error("Command failed", "error", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
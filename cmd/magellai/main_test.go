@@ -4,11 +4,15 @@
 package main
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/alecthomas/kong"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/docgen"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/willabides/kongplete"
 )
 
 func TestCLI_VersionFlag(t *testing.T) {
@@ -397,3 +401,107 @@ func TestCLI_ConfigCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandDocs(t *testing.T) {
+	parser, err := kong.New(&CLI{}, kong.Name("magellai"))
+	require.NoError(t, err)
+
+	root := commandDocs(parser.Model)
+	assert.Equal(t, "magellai", root.Name)
+	assert.Equal(t, "magellai <command> [flags]", root.Usage)
+
+	var export *docgen.Command
+	root.Walk(func(c *docgen.Command) {
+		if c.Name == "magellai history export" {
+			export = c
+		}
+	})
+	require.NotNil(t, export)
+	assert.Equal(t, "magellai history export [<session-id> ...] [flags]", export.Usage)
+
+	flags := make(map[string]docgen.Flag)
+	for _, f := range export.Flags {
+		flags[f.Name] = f
+	}
+	assert.Equal(t, "FORMAT", flags["format"].Placeholder)
+	assert.Equal(t, "json", flags["format"].Default)
+	assert.Empty(t, flags["all"].Placeholder)
+	assert.NotContains(t, flags, "help")
+}
+
+func TestExpandCLIAlias(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	require.NoError(t, config.Init())
+	cfg := config.Manager
+	require.NoError(t, cfg.SetValue("aliases.review", "ask --model openai/gpt-4o 'Review this diff: $1'"))
+	require.NoError(t, cfg.SetValue("aliases.ask", "chat"))
+
+	parser, err := kong.New(&CLI{}, kong.Name("magellai"))
+	require.NoError(t, err)
+
+	args, err := expandCLIAlias(parser, cfg, []string{"-o", "json", "review", "a.diff", "--stream"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-o", "json", "ask", "--model", "openai/gpt-4o", "Review this diff: a.diff", "--stream"}, args)
+
+	// Built-in commands are never shadowed by aliases
+	args, err = expandCLIAlias(parser, cfg, []string{"ask", "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ask", "hi"}, args)
+
+	args, err = expandCLIAlias(parser, cfg, []string{"unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unknown"}, args)
+
+	_, err = expandCLIAlias(parser, cfg, []string{"review"})
+	assert.Error(t, err)
+}
+
+func TestScopeShortFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"ask", "-c", "follow-up"}, []string{"ask", "--continue", "follow-up"}},
+		{[]string{"-c", "work.yaml", "ask", "-c", "hi"}, []string{"-c", "work.yaml", "ask", "--continue", "hi"}},
+		{[]string{"ask", "--", "-c"}, []string{"ask", "--", "-c"}},
+		{[]string{"chat", "-c", "work.yaml"}, []string{"chat", "-c", "work.yaml"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, scopeShortFlags(tt.args))
+	}
+
+	var cli CLI
+	parser := kong.Must(&cli)
+	_, err := parser.Parse(scopeShortFlags([]string{"-c", "work.yaml", "ask", "-c", "hi"}))
+	require.NoError(t, err)
+	assert.True(t, cli.Ask.Continue)
+	assert.Equal(t, "hi", cli.Ask.Prompt)
+	assert.Equal(t, "work.yaml", filepath.Base(cli.ConfigFile))
+}
+
+func TestCompletionPredictors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	require.NoError(t, config.Init())
+	cfg := config.Manager
+	require.NoError(t, cfg.SetValue("profiles.work.provider", "openai"))
+	require.NoError(t, cfg.SetValue("profiles.home.provider", "ollama"))
+	require.NoError(t, cfg.SetValue("model.default", "mock/test-model"))
+
+	// Every predictor tag in the CLI must name a registered predictor
+	parser, err := kong.New(&CLI{}, kong.Name("magellai"))
+	require.NoError(t, err)
+	_, err = kongplete.Command(parser, completionPredictors(cfg))
+	require.NoError(t, err)
+
+	profiles := profileCompletions(cfg)
+	assert.Subset(t, profiles, []string{"home", "work"})
+	assert.IsNonDecreasing(t, profiles)
+	assert.Contains(t, modelCompletions(cfg), "mock/test-model")
+
+	keys := configKeyCompletions(cfg)
+	assert.Contains(t, keys, "profiles")
+	assert.Contains(t, keys, "profiles.work")
+	assert.Contains(t, keys, "profiles.work.provider")
+
+	assert.Empty(t, sessionCompletions(cfg))
+}
@@ -0,0 +1,168 @@
+// ABOUTME: Surfaces commands declared by manifests in the commands directory as CLI subcommands
+// ABOUTME: Adds them to Kong as dynamic commands and runs their command lines as new magellai processes
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/lexlapax/magellai/internal/configdir"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+)
+
+// ManifestCmd runs a command declared by a manifest, parsing its arguments
+// with the flags the manifest declares
+type ManifestCmd struct {
+	name string
+	Args []string `arg:"" optional:"" help:"Arguments and flags of the command"`
+}
+
+// Run executes the manifest command
+func (m *ManifestCmd) Run(ctx *Context) error {
+	for _, arg := range m.Args {
+		if arg == "-h" || arg == "--help" {
+			return runCommand(ctx, "help", &command.ExecutionContext{
+				Args:    []string{m.name},
+				Flags:   command.NewFlags(nil),
+				Stdout:  ctx.Stdout,
+				Stderr:  ctx.Stderr,
+				Context: ctx.Ctx,
+			})
+		}
+	}
+
+	cmd, err := ctx.Registry.Get(m.name)
+	if err != nil {
+		return err
+	}
+	args, flags, err := command.ParseArgs(m.Args, cmd.Metadata())
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.name, err)
+	}
+	exec := &command.ExecutionContext{
+		Args:    args,
+		Flags:   command.NewFlags(flags),
+		Stdin:   os.Stdin,
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, m.name, exec)
+}
+
+// discoverManifests loads the command manifests in the commands directory
+// that are available in the CLI, dropping any whose name is taken by a
+// built-in command or a plugin. Invalid manifests are logged and skipped.
+func discoverManifests(builtin *kong.Kong, plugins []discoveredPlugin) []command.Manifest {
+	paths, err := configdir.GetPaths()
+	if err != nil {
+		return nil
+	}
+	manifests, errs := command.LoadManifests(paths.Commands)
+	for _, err := range errs {
+		logging.LogWarn("Skipping command manifest", "error", err)
+	}
+
+	taken := make(map[string]bool)
+	for _, child := range builtin.Model.Children {
+		taken[child.Name] = true
+		for _, alias := range child.Aliases {
+			taken[alias] = true
+		}
+	}
+	for _, p := range plugins {
+		taken[p.Name] = true
+	}
+
+	var available []command.Manifest
+	for _, m := range manifests {
+		if m.Metadata().Category == command.CategoryREPL {
+			continue
+		}
+		if taken[m.Name] {
+			logging.LogWarn("Skipping command manifest that shadows a command", "name", m.Name, "path", m.Path)
+			continue
+		}
+		taken[m.Name] = true
+		available = append(available, m)
+	}
+	return available
+}
+
+// manifestOptions returns a Kong dynamic command for each manifest
+func manifestOptions(manifests []command.Manifest) []kong.Option {
+	options := make([]kong.Option, 0, len(manifests))
+	for _, m := range manifests {
+		tags := []string{`cmd:""`, `passthrough:""`}
+		if len(m.Aliases) > 0 {
+			tags = append(tags, fmt.Sprintf("aliases:%q", strings.Join(m.Aliases, ",")))
+		}
+		options = append(options, kong.DynamicCommand(m.Name, m.Metadata().Description, "commands", &ManifestCmd{name: m.Name}, tags...))
+	}
+	return options
+}
+
+// manifestLineRunner runs the command lines of manifest commands as new
+// magellai processes with the same global flags
+func manifestLineRunner(cli *CLI) command.LineRunner {
+	return func(ctx context.Context, execCtx *command.ExecutionContext, words []string) error {
+		self, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate magellai: %w", err)
+		}
+
+		args := globalArgs(cli)
+		args = append(args, words...)
+		cmd := exec.CommandContext(ctx, self, args...)
+		cmd.WaitDelay = time.Second
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = execCtx.Stdin, execCtx.Stdout, execCtx.Stderr
+		if cmd.Stdout == nil {
+			cmd.Stdout = os.Stdout
+		}
+		if cmd.Stderr == nil {
+			cmd.Stderr = os.Stderr
+		}
+
+		logging.LogDebug("Running command line", "args", args)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(words, " "), err)
+		}
+		return nil
+	}
+}
+
+// globalArgs returns the global flags set on the command line, to pass on
+// to another magellai process
+func globalArgs(cli *CLI) []string {
+	var args []string
+	if cli == nil {
+		return args
+	}
+	if cli.Verbosity > 0 {
+		args = append(args, "-"+strings.Repeat("v", cli.Verbosity))
+	}
+	if cli.Quiet {
+		args = append(args, "--quiet")
+	}
+	if cli.Output != "" && cli.Output != "text" {
+		args = append(args, "--output", cli.Output)
+	}
+	if cli.ConfigFile != "" {
+		args = append(args, "--config-file", cli.ConfigFile)
+	}
+	if cli.ProfileName != "" {
+		args = append(args, "--profile", cli.ProfileName)
+	}
+	if cli.NoColor {
+		args = append(args, "--no-color")
+	}
+	return args
+}
@@ -0,0 +1,104 @@
+// ABOUTME: Surfaces external magellai-<name> plugins as CLI subcommands
+// ABOUTME: Discovers plugins before parsing and adds them to Kong as dynamic commands
+
+package main
+
+import (
+	"context"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/lexlapax/magellai/internal/configdir"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/plugin"
+)
+
+// PluginCmd runs an external plugin, passing every argument through
+type PluginCmd struct {
+	name string
+	Args []string `arg:"" optional:"" help:"Arguments passed to the plugin"`
+}
+
+// Run executes the plugin command
+func (p *PluginCmd) Run(ctx *Context) error {
+	exec := &command.ExecutionContext{
+		Args:    p.Args,
+		Flags:   command.NewFlags(nil),
+		Stdout:  ctx.Stdout,
+		Stderr:  ctx.Stderr,
+		Context: ctx.Ctx,
+	}
+	return runCommand(ctx, p.name, exec)
+}
+
+// discoveredPlugin is a plugin that does not shadow a built-in command
+type discoveredPlugin struct {
+	plugin.Plugin
+	description string
+}
+
+// discoverPlugins finds plugins in the plugins directory and on PATH,
+// dropping any whose name is taken by a built-in command. Plugins are only
+// asked for their help text when args request top-level help, since that
+// means running every plugin; ctx cancels those runs.
+func discoverPlugins(ctx context.Context, builtin *kong.Kong, args []string) []discoveredPlugin {
+	var dirs []string
+	if paths, err := configdir.GetPaths(); err == nil {
+		dirs = append(dirs, paths.Plugins)
+	}
+
+	taken := make(map[string]bool)
+	for _, child := range builtin.Model.Children {
+		taken[child.Name] = true
+		for _, alias := range child.Aliases {
+			taken[alias] = true
+		}
+	}
+
+	var plugins []discoveredPlugin
+	for _, p := range plugin.Discover(plugin.SearchPath(dirs...)) {
+		if taken[p.Name] {
+			continue
+		}
+		plugins = append(plugins, discoveredPlugin{Plugin: p})
+	}
+
+	if helpRequested(args, plugins) {
+		for i := range plugins {
+			plugins[i].description = plugins[i].Help(ctx)
+		}
+	}
+	return plugins
+}
+
+// helpRequested reports whether args ask for magellai's own help rather than
+// a plugin's
+func helpRequested(args []string, plugins []discoveredPlugin) bool {
+	if len(args) == 0 {
+		return true
+	}
+	for _, p := range plugins {
+		if args[0] == p.Name {
+			return false
+		}
+	}
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginOptions returns a Kong dynamic command for each plugin
+func pluginOptions(plugins []discoveredPlugin) []kong.Option {
+	options := make([]kong.Option, 0, len(plugins))
+	for _, p := range plugins {
+		help := p.description
+		if help == "" {
+			help = "External plugin (" + p.Path + ")"
+		}
+		options = append(options, kong.DynamicCommand(p.Name, help, "plugins", &PluginCmd{name: p.Name}, `cmd:""`, `passthrough:""`))
+	}
+	return options
+}
@@ -0,0 +1,33 @@
+// ABOUTME: Short flags of a command that reuse the letter of a global flag
+// ABOUTME: Rewrites them to their long form after the command word, before parsing
+
+package main
+
+// commandShortFlags maps commands to short flags that, after the command
+// word, stand for one of the command's flags rather than the global flag
+// with the same letter
+var commandShortFlags = map[string]map[string]string{
+	"ask": {"-c": "--continue"},
+}
+
+// scopeShortFlags rewrites the command-scoped short flags in args, so
+// "magellai ask -c" continues a session while "magellai -c file ask" still
+// names a config file
+func scopeShortFlags(args []string) []string {
+	i := commandIndex(args)
+	if i < 0 {
+		return args
+	}
+	flags, ok := commandShortFlags[args[i]]
+	if !ok {
+		return args
+	}
+
+	scoped := append([]string{}, args...)
+	for j := i + 1; j < len(scoped) && scoped[j] != "--"; j++ {
+		if long, ok := flags[scoped[j]]; ok {
+			scoped[j] = long
+		}
+	}
+	return scoped
+}
@@ -19,10 +19,17 @@ const (
 
 // Paths contains all configuration directory paths
 type Paths struct {
-	Base     string // Base config directory (~/.config/magellai)
-	Sessions string // Session storage directory
-	Plugins  string // Plugin installation directory
-	Logs     string // Log files directory
+	Base      string // Base config directory (~/.config/magellai)
+	Sessions  string // Session storage directory
+	Plugins   string // Plugin installation directory
+	Logs      string // Log files directory
+	Templates string // Prompt template directory
+	ReplRC    string // REPL startup commands file
+	Scripts   string // User hook and command scripts
+	Commands  string // User command manifests
+	Stats     string // Command telemetry
+	Audit     string // Append-only audit log
+	DebugHTTP string // Provider HTTP captures written by --debug-http
 }
 
 // GetPaths returns the configuration directory paths for the current user
@@ -35,10 +42,17 @@ func GetPaths() (Paths, error) {
 	base := filepath.Join(home, ".config", "magellai")
 
 	return Paths{
-		Base:     base,
-		Sessions: filepath.Join(base, "sessions"),
-		Plugins:  filepath.Join(base, "plugins"),
-		Logs:     filepath.Join(base, "logs"),
+		Base:      base,
+		Sessions:  filepath.Join(base, "sessions"),
+		Plugins:   filepath.Join(base, "plugins"),
+		Logs:      filepath.Join(base, "logs"),
+		Templates: filepath.Join(base, "templates"),
+		ReplRC:    filepath.Join(base, "replrc"),
+		Scripts:   filepath.Join(base, "scripts"),
+		Commands:  filepath.Join(base, "commands"),
+		Stats:     filepath.Join(base, "stats"),
+		Audit:     filepath.Join(base, "audit"),
+		DebugHTTP: filepath.Join(base, "debug", "http"),
 	}, nil
 }
 
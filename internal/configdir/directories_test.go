@@ -41,6 +41,27 @@ func TestGetPaths(t *testing.T) {
 	if !strings.HasPrefix(paths.Logs, paths.Base) {
 		t.Error("Logs path is not under base path")
 	}
+	if !strings.HasPrefix(paths.Templates, paths.Base) {
+		t.Error("Templates path is not under base path")
+	}
+	if !strings.HasPrefix(paths.ReplRC, paths.Base) {
+		t.Error("ReplRC path is not under base path")
+	}
+	if !strings.HasPrefix(paths.Scripts, paths.Base) {
+		t.Error("Scripts path is not under base path")
+	}
+	if !strings.HasPrefix(paths.Commands, paths.Base) {
+		t.Error("Commands path is not under base path")
+	}
+	if !strings.HasPrefix(paths.Stats, paths.Base) {
+		t.Error("Stats path is not under base path")
+	}
+	if !strings.HasPrefix(paths.Audit, paths.Base) {
+		t.Error("Audit path is not under base path")
+	}
+	if !strings.HasPrefix(paths.DebugHTTP, paths.Base) {
+		t.Error("DebugHTTP path is not under base path")
+	}
 }
 
 func TestEnsureDirectories(t *testing.T) {
@@ -24,28 +24,50 @@ type Logger struct {
 var (
 	defaultLogger *Logger
 	once          sync.Once
+
+	// currentConfig is the configuration the default logger was last
+	// initialized with
+	currentConfig = DefaultConfig()
+	configMu      sync.Mutex
 )
 
 // LogConfig represents logging configuration
 type LogConfig struct {
-	Level      string // debug, info, warn, error
-	Format     string // text, json
-	OutputPath string // stdout, stderr, or file path
-	AddSource  bool   // whether to add source code location
+	Level      string         // debug, info, warn, error
+	Format     string         // text, json
+	OutputPath string         // stdout, stderr, or file path
+	AddSource  bool           // whether to add source code location
+	Rotation   RotationConfig // when a log file is rotated; unused for stdout and stderr
 }
 
-// DefaultConfig returns default logging configuration
+// DefaultConfig returns default logging configuration, taking the level,
+// format, and log file from MAGELLAI_LOG_LEVEL, MAGELLAI_LOG_FORMAT, and
+// MAGELLAI_LOG_FILE when they are set
 func DefaultConfig() LogConfig {
-	level := "warn"
-	if envLevel := os.Getenv("MAGELLAI_LOG_LEVEL"); envLevel != "" {
-		level = envLevel
-	}
-	return LogConfig{
-		Level:      level,
+	config := LogConfig{
+		Level:      "warn",
 		Format:     "text",
 		OutputPath: "stderr",
 		AddSource:  false,
 	}
+	if envLevel := os.Getenv("MAGELLAI_LOG_LEVEL"); envLevel != "" {
+		config.Level = envLevel
+	}
+	if envFormat := os.Getenv("MAGELLAI_LOG_FORMAT"); envFormat != "" {
+		config.Format = envFormat
+	}
+	if envFile := os.Getenv("MAGELLAI_LOG_FILE"); envFile != "" {
+		config.OutputPath = envFile
+	}
+	return config
+}
+
+// CurrentConfig returns the configuration the default logger was last
+// initialized with
+func CurrentConfig() LogConfig {
+	configMu.Lock()
+	defer configMu.Unlock()
+	return currentConfig
 }
 
 // Initialize sets up the global logger with the provided configuration
@@ -55,7 +77,7 @@ func Initialize(config LogConfig) error {
 		return fmt.Errorf("invalid log level: %w", err)
 	}
 
-	output, err := getOutput(config.OutputPath)
+	output, err := getOutput(config.OutputPath, config.Rotation)
 	if err != nil {
 		return fmt.Errorf("invalid output path: %w", err)
 	}
@@ -68,7 +90,8 @@ func Initialize(config LogConfig) error {
 			if a.Key == slog.TimeKey {
 				return slog.Attr{Key: "time", Value: slog.StringValue(a.Value.Time().Format(time.RFC3339))}
 			}
-			return a
+			// Secrets never reach the log, whatever the level or output
+			return redactAttr(a)
 		},
 	}
 
@@ -86,9 +109,21 @@ func Initialize(config LogConfig) error {
 	}
 
 	// Always update the default logger, don't use once.Do
+	previous := defaultLogger
 	defaultLogger = logger
 	slog.SetDefault(logger.Logger)
 
+	configMu.Lock()
+	currentConfig = config
+	configMu.Unlock()
+
+	// Close the log file the previous logger wrote to
+	if previous != nil && previous.output != output {
+		if closer, ok := previous.output.(io.Closer); ok && previous.output != os.Stdout && previous.output != os.Stderr {
+			_ = closer.Close()
+		}
+	}
+
 	return nil
 }
 
@@ -149,19 +184,16 @@ func parseLevel(level string) (slog.Level, error) {
 	}
 }
 
-// getOutput returns the appropriate io.Writer for the output path
-func getOutput(path string) (io.Writer, error) {
+// getOutput returns the appropriate io.Writer for the output path. Files
+// are rotated according to rotation.
+func getOutput(path string, rotation RotationConfig) (io.Writer, error) {
 	switch path {
 	case "stdout":
 		return os.Stdout, nil
-	case "stderr":
+	case "stderr", "":
 		return os.Stderr, nil
 	default:
-		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			return nil, err
-		}
-		return file, nil
+		return OpenRotatingFile(path, rotation)
 	}
 }
 
@@ -192,16 +224,10 @@ func LogWarn(msg string, args ...any) {
 	GetLogger().Warn(msg, args...)
 }
 
-// SetLogLevel sets the global log level by re-initializing the logger
+// SetLogLevel sets the global log level by re-initializing the logger,
+// keeping its format and output
 func SetLogLevel(level string) error {
-	// Get current configuration
-	config := LogConfig{
-		Level:      level,
-		Format:     "text",   // Preserve current format
-		OutputPath: "stderr", // Preserve current output
-		AddSource:  false,
-	}
-
-	// Re-initialize with new level
+	config := CurrentConfig()
+	config.Level = level
 	return Initialize(config)
 }
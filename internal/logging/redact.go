@@ -0,0 +1,161 @@
+// ABOUTME: Redaction of API keys, credentials, and other secrets from log lines
+// ABOUTME: Masks registered secret values, secret-named attributes, and common key formats before anything is written
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces masked secrets in log lines
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the length below which registered values are not
+// masked, so a short or placeholder value does not blank out ordinary text
+const minSecretLength = 8
+
+// secretAttrNames are the endings, ignoring case, dashes, and underscores,
+// of attribute keys whose values are always masked
+var secretAttrNames = []string{"apikey", "token", "password", "passphrase", "secret", "authorization", "credentials"}
+
+// secretPatterns match credentials in free text. Where a pattern has a
+// group, only the group is masked, keeping the label that shows what it was.
+var secretPatterns = []*regexp.Regexp{
+	// Authorization: Bearer xyz, Authorization: Basic xyz, x-api-key: xyz
+	regexp.MustCompile(`(?i)(?:authorization|x-api-key|x-goog-api-key|api-key)["']?\s*[:=]\s*["']?(?:bearer\s+|basic\s+)?([^\s"',}]+)`),
+	// Bearer tokens on their own
+	regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/=-]{8,})`),
+	// Keys in URLs and key=value pairs
+	regexp.MustCompile(`(?i)\b(?:api[_-]?key|access[_-]?token|client[_-]?secret|password|passwd)["']?\s*[:=]\s*["']?([^\s"'&,}]+)`),
+	regexp.MustCompile(`[?&]key=([^\s&"']+)`),
+	// OpenAI and Anthropic keys
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`),
+	// Google API keys
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	// AWS access key IDs
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	// GitHub tokens
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+	// Slack tokens
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+	// Private keys
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+var (
+	secretsMu sync.RWMutex
+	// secretValues are registered secrets, longest first so a secret that
+	// contains another is masked whole
+	secretValues []string
+)
+
+// AddSecrets registers values, such as configured API keys, that are masked
+// wherever they appear in log lines. Empty and very short values are
+// ignored.
+func AddSecrets(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if len(value) < minSecretLength || containsSecret(secretValues, value) {
+			continue
+		}
+		secretValues = append(secretValues, value)
+	}
+	sort.Slice(secretValues, func(i, j int) bool { return len(secretValues[i]) > len(secretValues[j]) })
+}
+
+func containsSecret(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact masks registered secrets and anything that looks like a
+// credential in s
+func Redact(s string) string {
+	secretsMu.RLock()
+	for _, secret := range secretValues {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	secretsMu.RUnlock()
+
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+			groups := pattern.FindStringSubmatchIndex(match)
+			if len(groups) < 4 || groups[2] < 0 {
+				return Redacted
+			}
+			if match[groups[2]:groups[3]] == Redacted {
+				return match
+			}
+			return match[:groups[2]] + Redacted + match[groups[3]:]
+		})
+	}
+	return s
+}
+
+// isSecretAttr reports whether an attribute key names a secret, such as
+// api_key, X-Api-Key, or server.token
+func isSecretAttr(key string) bool {
+	name := strings.ToLower(key)
+	name = name[strings.LastIndex(name, ".")+1:]
+	name = strings.NewReplacer("_", "", "-", "").Replace(name)
+	for _, suffix := range secretAttrNames {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr masks the secrets in a log attribute. Values of secret-named
+// keys are masked whole; other strings, errors, and values that print as
+// text are masked where they contain secrets. The attributes of a group
+// are masked one by one.
+func redactAttr(a slog.Attr) slog.Attr {
+	value := a.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, attr := range group {
+			attrs[i] = redactAttr(attr)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	case slog.KindString:
+		if isSecretAttr(a.Key) && value.String() != "" {
+			return slog.String(a.Key, Redacted)
+		}
+		if redacted := Redact(value.String()); redacted != value.String() {
+			return slog.String(a.Key, redacted)
+		}
+	case slog.KindAny:
+		text := ""
+		switch v := value.Any().(type) {
+		case error:
+			text = v.Error()
+		case fmt.Stringer:
+			text = v.String()
+		case nil:
+			return a
+		default:
+			text = fmt.Sprint(v)
+		}
+		if isSecretAttr(a.Key) {
+			return slog.String(a.Key, Redacted)
+		}
+		if redacted := Redact(text); redacted != text {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	return a
+}
@@ -0,0 +1,216 @@
+// ABOUTME: Log file writer with size- and time-based rotation and retention
+// ABOUTME: Renames the log file aside with a timestamp when it grows too large or its period ends, pruning old ones
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the timestamp added to the names of rotated files.
+// It sorts in time order.
+const rotatedTimeFormat = "20060102-150405.000"
+
+// RotationConfig controls when a log file is rotated and how long rotated
+// files are kept. Zero values turn each limit off.
+type RotationConfig struct {
+	MaxSize    int64         // Rotate before the file would grow beyond this many bytes
+	Interval   time.Duration // Rotate when a write falls in a later interval than the last one, e.g. daily at UTC midnight for 24h
+	MaxBackups int           // Number of rotated files kept, newest first
+	MaxAge     time.Duration // Rotated files older than this are deleted
+}
+
+// Enabled returns true if the file is ever rotated
+func (c RotationConfig) Enabled() bool {
+	return c.MaxSize > 0 || c.Interval > 0
+}
+
+// RotatingFile is a log file that is rotated by size and time. Rotated
+// files sit next to it, named with the time they were rotated, such as
+// magellai-20250131-143000.000.log for magellai.log.
+type RotatingFile struct {
+	path     string
+	config   RotationConfig
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	lastTime time.Time
+	now      func() time.Time
+}
+
+// OpenRotatingFile opens the log file at path for appending, creating it
+// and its directory if needed. A file left over from an earlier period is
+// rotated first.
+func OpenRotatingFile(path string, config RotationConfig) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, config: config, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	if f.size > 0 && f.periodEnded(f.now()) {
+		if err := f.rotate(); err != nil {
+			f.file.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Path returns the path of the current log file
+func (f *RotatingFile) Path() string {
+	return f.path
+}
+
+// open opens the log file, noting its size and when it was last written
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.lastTime = info.ModTime()
+	if f.size == 0 {
+		f.lastTime = f.now()
+	}
+	return nil
+}
+
+// Write implements io.Writer, rotating the file first when the write would
+// take it over the size limit or falls in a new period
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	now := f.now()
+	tooLarge := f.config.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.config.MaxSize
+	if tooLarge || (f.size > 0 && f.periodEnded(now)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	f.lastTime = now
+	return n, err
+}
+
+// periodEnded returns true if now falls in a later rotation interval than
+// the last write
+func (f *RotatingFile) periodEnded(now time.Time) bool {
+	if f.config.Interval <= 0 {
+		return false
+	}
+	return now.Truncate(f.config.Interval).After(f.lastTime.Truncate(f.config.Interval))
+}
+
+// Rotate moves the current file aside and starts a new one
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// rotate moves the current file aside, starts a new one, and prunes old
+// rotated files. The caller must hold f.mu.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	f.file = nil
+	if err := os.Rename(f.path, f.rotatedPath(f.now())); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.prune()
+	return nil
+}
+
+// rotatedPath returns the name a file rotated at t is moved to
+func (f *RotatingFile) rotatedPath(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	return strings.TrimSuffix(f.path, ext) + "-" + t.Format(rotatedTimeFormat) + ext
+}
+
+// Backups returns the rotated files, oldest first
+func (f *RotatingFile) Backups() ([]string, error) {
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if _, err := time.Parse(rotatedTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(f.path), name))
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// prune deletes rotated files beyond the number kept or older than the
+// maximum age. Failures are ignored; the files are tried again on the next
+// rotation.
+func (f *RotatingFile) prune() {
+	if f.config.MaxBackups <= 0 && f.config.MaxAge <= 0 {
+		return
+	}
+	backups, err := f.Backups()
+	if err != nil {
+		return
+	}
+	for i, path := range backups {
+		expired := f.config.MaxBackups > 0 && i < len(backups)-f.config.MaxBackups
+		if !expired && f.config.MaxAge > 0 {
+			if info, err := os.Stat(path); err == nil && f.now().Sub(info.ModTime()) > f.config.MaxAge {
+				expired = true
+			}
+		}
+		if expired {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// Close closes the current log file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,197 @@
+// ABOUTME: Unit tests for the rotating log file writer
+// ABOUTME: Tests size- and time-based rotation, retention of rotated files, and logging to a file as JSON
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openTestFile opens a rotating file in a temporary directory whose clock
+// is read from *now
+func openTestFile(t *testing.T, config RotationConfig, now *time.Time) *RotatingFile {
+	t.Helper()
+	f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "logs", "magellai.log"), config)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() error = %v", err)
+	}
+	f.now = func() time.Time { return *now }
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func writeLine(t *testing.T, f *RotatingFile, line string) {
+	t.Helper()
+	if _, err := f.Write([]byte(line + "\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+}
+
+func backups(t *testing.T, f *RotatingFile) []string {
+	t.Helper()
+	names, err := f.Backups()
+	if err != nil {
+		t.Fatalf("Backups() error = %v", err)
+	}
+	return names
+}
+
+func TestRotatingFile_Size(t *testing.T) {
+	now := time.Date(2025, 1, 31, 14, 30, 0, 0, time.UTC)
+	f := openTestFile(t, RotationConfig{MaxSize: 20}, &now)
+
+	writeLine(t, f, "first line")
+	writeLine(t, f, "second")
+	if got := backups(t, f); len(got) != 0 {
+		t.Fatalf("expected no rotation within the size limit, got %v", got)
+	}
+
+	now = now.Add(time.Second)
+	writeLine(t, f, "third line")
+	got := backups(t, f)
+	if len(got) != 1 {
+		t.Fatalf("expected one rotated file, got %v", got)
+	}
+	if filepath.Base(got[0]) != "magellai-20250131-143001.000.log" {
+		t.Errorf("unexpected rotated file name %s", filepath.Base(got[0]))
+	}
+	rotated, _ := os.ReadFile(got[0])
+	if string(rotated) != "first line\nsecond\n" {
+		t.Errorf("unexpected rotated content %q", rotated)
+	}
+	current, _ := os.ReadFile(f.Path())
+	if string(current) != "third line\n" {
+		t.Errorf("unexpected current content %q", current)
+	}
+
+	// A single write larger than the limit still goes to an empty file
+	writeLine(t, f, strings.Repeat("x", 40))
+	now = now.Add(time.Second)
+	writeLine(t, f, "after")
+	if got := backups(t, f); len(got) != 2 {
+		t.Errorf("expected two rotated files, got %v", got)
+	}
+}
+
+func TestRotatingFile_Interval(t *testing.T) {
+	now := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)
+	f := openTestFile(t, RotationConfig{Interval: 24 * time.Hour}, &now)
+
+	writeLine(t, f, "before midnight")
+	now = now.Add(30 * time.Minute)
+	writeLine(t, f, "still the same day")
+	if got := backups(t, f); len(got) != 0 {
+		t.Fatalf("expected no rotation within the interval, got %v", got)
+	}
+
+	now = now.Add(time.Hour)
+	writeLine(t, f, "the next day")
+	if got := backups(t, f); len(got) != 1 {
+		t.Errorf("expected rotation at midnight, got %v", got)
+	}
+}
+
+func TestRotatingFile_RotatesStaleFileOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "magellai.log")
+	if err := os.WriteFile(path, []byte("yesterday\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenRotatingFile(path, RotationConfig{Interval: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+	if got := backups(t, f); len(got) != 1 {
+		t.Errorf("expected the stale file to be rotated, got %v", got)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("expected a new empty log file, got %v, %v", info, err)
+	}
+}
+
+func TestRotatingFile_Retention(t *testing.T) {
+	now := time.Date(2025, 1, 31, 14, 30, 0, 0, time.UTC)
+	f := openTestFile(t, RotationConfig{MaxBackups: 2}, &now)
+
+	for i := 0; i < 4; i++ {
+		writeLine(t, f, "line")
+		now = now.Add(time.Second)
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("Rotate() error = %v", err)
+		}
+	}
+	got := backups(t, f)
+	if len(got) != 2 {
+		t.Fatalf("expected two rotated files kept, got %v", got)
+	}
+	if filepath.Base(got[0]) != "magellai-20250131-143003.000.log" {
+		t.Errorf("expected the oldest files to be deleted, got %v", got)
+	}
+
+	t.Run("max age", func(t *testing.T) {
+		now := time.Now()
+		f := openTestFile(t, RotationConfig{MaxAge: 24 * time.Hour}, &now)
+		stale := f.rotatedPath(now.Add(-72 * time.Hour))
+		if err := os.WriteFile(stale, []byte("old\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		old := now.Add(-72 * time.Hour)
+		if err := os.Chtimes(stale, old, old); err != nil {
+			t.Fatal(err)
+		}
+
+		writeLine(t, f, "line")
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("Rotate() error = %v", err)
+		}
+		got := backups(t, f)
+		if len(got) != 1 || got[0] == stale {
+			t.Errorf("expected only the new rotated file, got %v", got)
+		}
+	})
+}
+
+func TestInitialize_JSONFile(t *testing.T) {
+	t.Cleanup(func() { _ = Initialize(DefaultConfig()) })
+	path := filepath.Join(t.TempDir(), "magellai.log")
+
+	err := Initialize(LogConfig{Level: "info", Format: "json", OutputPath: path, Rotation: RotationConfig{MaxSize: 1024 * 1024}})
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	LogInfo("to the file", "key", "value")
+
+	// Changing the level keeps the format and file
+	if err := SetLogLevel("debug"); err != nil {
+		t.Fatalf("SetLogLevel() error = %v", err)
+	}
+	if got := CurrentConfig(); got.Format != "json" || got.OutputPath != path || got.Rotation.MaxSize != 1024*1024 {
+		t.Errorf("SetLogLevel() lost the configuration: %+v", got)
+	}
+	LogDebug("still to the file")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two log lines, got %q", data)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	if entry["msg"] != "to the file" || entry["key"] != "value" {
+		t.Errorf("unexpected log entry %v", entry)
+	}
+}
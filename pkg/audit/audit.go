@@ -0,0 +1,204 @@
+// ABOUTME: Append-only audit log of session and configuration changes
+// ABOUTME: Records who changed what and when from domain events, one JSON entry per line
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// AuditedEvents are the events recorded in the audit log
+var AuditedEvents = []domain.EventType{
+	domain.EventSessionCreated,
+	domain.EventSessionDeleted,
+	domain.EventSessionMerged,
+	domain.EventSessionExported,
+	domain.EventConfigChanged,
+}
+
+// Entry is one recorded action
+type Entry struct {
+	Time      time.Time              `json:"time"`
+	User      string                 `json:"user"`
+	Host      string                 `json:"host,omitempty"`
+	Action    string                 `json:"action"`
+	SessionID string                 `json:"session_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Filter selects entries from the log. Zero fields match every entry.
+type Filter struct {
+	Since     time.Time // Only entries at or after this time
+	Action    string    // Only entries of this action
+	SessionID string    // Only entries about this session
+	Limit     int       // Only the newest entries, up to this many
+}
+
+// Log is an append-only audit log kept in a local file. Entries can be
+// added and read but not changed or removed.
+type Log struct {
+	path string
+	user string
+	host string
+	mu   sync.Mutex
+}
+
+// NewLog creates a log that keeps entries in path, recording them as the
+// current operating system user
+func NewLog(path string) *Log {
+	log := &Log{path: path, user: currentUser()}
+	log.host, _ = os.Hostname()
+	return log
+}
+
+// currentUser returns the name of the operating system user
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// Path returns the file the log keeps entries in
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record appends an entry to the log, filling in the time, user and host
+// when they are not set
+func (l *Log) Record(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.User == "" {
+		entry.User = l.user
+	}
+	if entry.Host == "" {
+		entry.Host = l.host
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// Entries returns the entries matching filter, oldest first. Lines that do
+// not decode are skipped.
+func (l *Log) Entries(filter Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			logging.LogDebug("Skipping unreadable audit entry", "error", err)
+			continue
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+// matches returns true if an entry is selected by the filter
+func (f Filter) matches(entry Entry) bool {
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if f.Action != "" && entry.Action != f.Action {
+		return false
+	}
+	if f.SessionID != "" && entry.SessionID != f.SessionID {
+		return false
+	}
+	return true
+}
+
+// Subscribe records the audited events published on bus. It returns a
+// function that stops recording. Entries that cannot be written are logged,
+// since the change they describe has already happened.
+func (l *Log) Subscribe(bus *domain.EventBus) func() {
+	var unsubscribes []func()
+	for _, eventType := range AuditedEvents {
+		unsubscribes = append(unsubscribes, bus.Subscribe(eventType, func(event domain.Event) {
+			if err := l.Record(EntryFor(event)); err != nil {
+				logging.LogWarn("Failed to record audit entry", "action", event.Type, "session_id", event.SessionID, "error", err)
+			}
+		}))
+	}
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// EntryFor describes a domain event as an audit entry
+func EntryFor(event domain.Event) Entry {
+	entry := Entry{
+		Time:      event.Time,
+		Action:    string(event.Type),
+		SessionID: event.SessionID,
+	}
+	if len(event.Data) > 0 {
+		entry.Details = make(map[string]interface{}, len(event.Data))
+		for k, v := range event.Data {
+			entry.Details[k] = v
+		}
+	}
+	if event.Type == domain.EventSessionCreated && event.Session != nil && event.Session.Name != "" {
+		if entry.Details == nil {
+			entry.Details = make(map[string]interface{})
+		}
+		entry.Details["name"] = event.Session.Name
+	}
+	return entry
+}
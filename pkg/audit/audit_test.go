@@ -0,0 +1,105 @@
+// ABOUTME: Tests for the audit log
+// ABOUTME: Verifies entries are appended, filtered, and recorded from the audited domain events only
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog_RecordAndEntries(t *testing.T) {
+	log := NewLog(filepath.Join(t.TempDir(), "audit", "audit.jsonl"))
+
+	entries, err := log.Entries(Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now()
+	require.NoError(t, log.Record(Entry{Time: now.Add(-48 * time.Hour), Action: "session.created", SessionID: "old"}))
+	require.NoError(t, log.Record(Entry{Time: now, Action: "session.deleted", SessionID: "new"}))
+	require.NoError(t, log.Record(Entry{Action: "config.changed", Details: map[string]interface{}{"key": "stream"}}))
+
+	info, err := os.Stat(log.Path())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err = log.Entries(Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "old", entries[0].SessionID)
+	assert.NotEmpty(t, entries[2].User)
+	assert.False(t, entries[2].Time.IsZero())
+	assert.Equal(t, "stream", entries[2].Details["key"])
+
+	// Unreadable lines are skipped
+	f, err := os.OpenFile(log.Path(), os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString("not json\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all", Filter{}, []string{"session.created", "session.deleted", "config.changed"}},
+		{"since", Filter{Since: now.Add(-time.Hour)}, []string{"session.deleted", "config.changed"}},
+		{"action", Filter{Action: "session.deleted"}, []string{"session.deleted"}},
+		{"session", Filter{SessionID: "old"}, []string{"session.created"}},
+		{"limit keeps the newest", Filter{Limit: 2}, []string{"session.deleted", "config.changed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := log.Entries(tt.filter)
+			require.NoError(t, err)
+			var actions []string
+			for _, entry := range entries {
+				actions = append(actions, entry.Action)
+			}
+			assert.Equal(t, tt.want, actions)
+		})
+	}
+}
+
+func TestLog_Subscribe(t *testing.T) {
+	log := NewLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	bus := domain.NewEventBus()
+	unsubscribe := log.Subscribe(bus)
+
+	session := domain.NewSession("session_1")
+	session.Name = "Quarterly report"
+	created := domain.NewEvent(domain.EventSessionCreated, session.ID)
+	created.Session = session
+	bus.Publish(created)
+	bus.Publish(domain.NewEvent(domain.EventSessionSaved, session.ID))
+	domain.PublishMessageAdded(session.ID, *domain.NewMessage("msg-1", domain.MessageRoleUser, "hello"))
+	exported := domain.NewEvent(domain.EventSessionExported, session.ID)
+	exported.Data["format"] = "markdown"
+	bus.Publish(exported)
+	changed := domain.NewEvent(domain.EventConfigChanged, "")
+	changed.Data["key"] = "provider.openai.api_key"
+	bus.Publish(changed)
+
+	unsubscribe()
+	bus.Publish(domain.NewEvent(domain.EventSessionDeleted, session.ID))
+
+	entries, err := log.Entries(Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "session.created", entries[0].Action)
+	assert.Equal(t, session.ID, entries[0].SessionID)
+	assert.Equal(t, "Quarterly report", entries[0].Details["name"])
+	assert.Equal(t, "session.exported", entries[1].Action)
+	assert.Equal(t, "markdown", entries[1].Details["format"])
+	assert.Equal(t, "config.changed", entries[2].Action)
+	assert.Empty(t, entries[2].SessionID)
+	assert.Equal(t, "provider.openai.api_key", entries[2].Details["key"])
+}
@@ -0,0 +1,209 @@
+// ABOUTME: Parameterized alias expansion shared by the CLI and REPL
+// ABOUTME: Substitutes $1, $@ and ${flag} references with the arguments an alias was invoked with
+
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// aliasRef matches a parameter reference in an alias definition:
+// $$, $@, $*, $1-$9, ${N}, ${name}, and either braced form with a
+// :-default suffix
+var aliasRef = regexp.MustCompile(`\$(\$|@|\*|[1-9])|\$\{([0-9]+|[A-Za-z][A-Za-z0-9_-]*)(?::-([^}]*))?\}`)
+
+// ExpandAlias expands an alias definition with the arguments it was invoked
+// with and returns the resulting words. The definition is split like a
+// shell command line, with quotes grouping words, and references are
+// replaced inside quotes too:
+//
+//	$1 ... $9, ${N}   the Nth positional argument
+//	$@                all positional arguments, as separate words when the
+//	                  reference is a whole word
+//	$*                all positional arguments joined by spaces
+//	${name}           the value of --name (or --name=value) in the arguments
+//	${N:-x}, ${name:-x}  the same, defaulting to x
+//	$$                a literal $
+//
+// Flags are only taken from the arguments when the definition references
+// them. Arguments that no reference uses are appended, so a definition
+// without references behaves like a plain alias.
+func ExpandAlias(definition string, args []string) ([]string, error) {
+	words, err := splitWords(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	positional, values, err := splitAliasArgs(args, referencedFlags(definition))
+	if err != nil {
+		return nil, err
+	}
+
+	used := make([]bool, len(positional))
+	useAll := func() {
+		for i := range used {
+			used[i] = true
+		}
+	}
+
+	var expanded []string
+	for _, word := range words {
+		if word == "$@" {
+			useAll()
+			expanded = append(expanded, positional...)
+			continue
+		}
+
+		var expandErr error
+		word = aliasRef.ReplaceAllStringFunc(word, func(ref string) string {
+			m := aliasRef.FindStringSubmatch(ref)
+			name, def := m[1]+m[2], m[3]
+			hasDefault := strings.Contains(ref, ":-")
+
+			switch {
+			case name == "$":
+				return "$"
+			case name == "@" || name == "*":
+				useAll()
+				return strings.Join(positional, " ")
+			case isDigits(name):
+				n, _ := strconv.Atoi(name)
+				if n >= 1 && n <= len(positional) {
+					used[n-1] = true
+					return positional[n-1]
+				}
+				if !hasDefault && expandErr == nil {
+					expandErr = fmt.Errorf("%w: $%d", ErrMissingArgument, n)
+				}
+				return def
+			default:
+				if value, ok := values[name]; ok {
+					return value
+				}
+				if !hasDefault && expandErr == nil {
+					expandErr = fmt.Errorf("%w: --%s", ErrMissingArgument, name)
+				}
+				return def
+			}
+		})
+		if expandErr != nil {
+			return nil, expandErr
+		}
+		expanded = append(expanded, word)
+	}
+
+	for i, arg := range positional {
+		if !used[i] {
+			expanded = append(expanded, arg)
+		}
+	}
+	return expanded, nil
+}
+
+// referencedFlags returns the names of the flags an alias definition
+// references with ${name}
+func referencedFlags(definition string) map[string]bool {
+	flags := make(map[string]bool)
+	for _, m := range aliasRef.FindAllStringSubmatch(definition, -1) {
+		if m[2] != "" && !isDigits(m[2]) {
+			flags[m[2]] = true
+		}
+	}
+	return flags
+}
+
+// splitAliasArgs separates --name value arguments for the referenced flags
+// from the positional arguments. Everything after -- is positional.
+func splitAliasArgs(args []string, flags map[string]bool) ([]string, map[string]string, error) {
+	var positional []string
+	values := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !strings.HasPrefix(arg, "--") || !flags[name] {
+			positional = append(positional, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("%w: --%s needs a value", ErrInvalidFlagValue, name)
+			}
+			i++
+			value = args[i]
+		}
+		values[name] = value
+	}
+	return positional, values, nil
+}
+
+// splitWords splits a command line into words. Single and double quotes
+// group words, and a backslash outside single quotes escapes the next
+// character.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: unterminated %c quote", ErrInvalidArguments, quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+// ABOUTME: Tests for parameterized alias expansion
+// ABOUTME: Covers positional, flag, and default parameters and command line splitting
+
+package command
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandAlias(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		args       []string
+		want       []string
+		wantErr    error
+	}{
+		{
+			name:       "plain alias appends arguments",
+			definition: "model gpt-4",
+			args:       []string{"--verbose"},
+			want:       []string{"model", "gpt-4", "--verbose"},
+		},
+		{
+			name:       "positional inside quotes",
+			definition: "ask --model openai/gpt-4o 'Review this diff: $1'",
+			args:       []string{"a.diff"},
+			want:       []string{"ask", "--model", "openai/gpt-4o", "Review this diff: a.diff"},
+		},
+		{
+			name:       "unused arguments are appended",
+			definition: `ask "Explain $1"`,
+			args:       []string{"closures", "--stream"},
+			want:       []string{"ask", "Explain closures", "--stream"},
+		},
+		{
+			name:       "all arguments as words",
+			definition: "ask $@",
+			args:       []string{"one", "two words"},
+			want:       []string{"ask", "one", "two words"},
+		},
+		{
+			name:       "all arguments joined",
+			definition: "ask 'Translate: $*'",
+			args:       []string{"hello", "world"},
+			want:       []string{"ask", "Translate: hello world"},
+		},
+		{
+			name:       "flag parameter",
+			definition: "ask --model ${model} 'Translate to ${lang:-French}: $1'",
+			args:       []string{"--model=openai/gpt-4o", "hi", "--lang", "German"},
+			want:       []string{"ask", "--model", "openai/gpt-4o", "Translate to German: hi"},
+		},
+		{
+			name:       "defaults",
+			definition: "ask 'Translate to ${lang:-French}: ${1:-nothing}'",
+			want:       []string{"ask", "Translate to French: nothing"},
+		},
+		{
+			name:       "unreferenced flags are positional",
+			definition: "ask $1",
+			args:       []string{"--lang", "x"},
+			want:       []string{"ask", "--lang", "x"},
+		},
+		{
+			name:       "literal dollar",
+			definition: `ask "costs $$5 or $HOME"`,
+			want:       []string{"ask", "costs $5 or $HOME"},
+		},
+		{
+			name:       "double dash ends flags",
+			definition: "ask ${lang:-en} $1",
+			args:       []string{"--", "--lang"},
+			want:       []string{"ask", "en", "--lang"},
+		},
+		{
+			name:       "missing positional",
+			definition: "ask $2",
+			args:       []string{"one"},
+			wantErr:    ErrMissingArgument,
+		},
+		{
+			name:       "missing flag",
+			definition: "ask ${model}",
+			wantErr:    ErrMissingArgument,
+		},
+		{
+			name:       "flag without value",
+			definition: "ask ${model:-x}",
+			args:       []string{"--model"},
+			wantErr:    ErrInvalidFlagValue,
+		},
+		{
+			name:       "unterminated quote",
+			definition: "ask 'oops",
+			wantErr:    ErrInvalidArguments,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandAlias(tt.definition, tt.args)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	words, err := splitWords(`ask  "a \"quoted\" word" 'it''s' back\ slash`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ask", `a "quoted" word`, "its", "back slash"}, words)
+
+	words, err = splitWords(`""`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, words)
+}
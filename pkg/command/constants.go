@@ -16,3 +16,14 @@ const (
 	// OutputFormatYAML is YAML output format
 	OutputFormatYAML OutputFormat = "yaml"
 )
+
+const (
+	// OutputFormatTable is aligned table output format
+	OutputFormatTable OutputFormat = "table"
+
+	// OutputFormatMarkdown is Markdown output format
+	OutputFormatMarkdown OutputFormat = "markdown"
+)
+
+// DataKeyOutputFormat is the exec.Data key holding the global output format
+const DataKeyOutputFormat = "outputFormat"
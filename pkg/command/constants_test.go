@@ -14,6 +14,8 @@ func TestOutputFormatConstants(t *testing.T) {
 	assert.Equal(t, OutputFormat("text"), OutputFormatText)
 	assert.Equal(t, OutputFormat("json"), OutputFormatJSON)
 	assert.Equal(t, OutputFormat("yaml"), OutputFormatYAML)
+	assert.Equal(t, OutputFormat("table"), OutputFormatTable)
+	assert.Equal(t, OutputFormat("markdown"), OutputFormatMarkdown)
 }
 
 func TestOutputFormatType(t *testing.T) {
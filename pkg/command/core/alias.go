@@ -92,14 +92,22 @@ Examples:
   alias add gpt4 "model gpt-4"                 # Create alias for model switch
   alias add claude "model anthropic/claude-3"  # Create provider/model alias
   alias add fast "model gpt-4o --temperature 0.1"  # With options
+  alias add review "ask --model openai/gpt-4o 'Review this diff: $1'"
+  alias add tr "ask 'Translate to ${lang:-French}: $*'"  # magellai tr --lang German hi
   alias show gpt4             # Show specific alias
   alias remove gpt4           # Remove an alias
   alias clear                 # Remove all aliases
   alias export > aliases.json # Export aliases
   alias import aliases.json   # Import aliases
 
-Note: Aliases can be used in both CLI and REPL modes. In CLI, they're expanded
-before command execution. In REPL, they can be invoked directly.`,
+Aliases are saved to the user config file. They can be used in both CLI and
+REPL modes. In CLI, they're expanded before command execution. In REPL, they
+can be invoked directly.
+
+Parameters are filled in when an alias runs: $1 to $9 are positional
+arguments, $@ is all of them, and ${name} is the value of --name. Use
+${1:-x} or ${name:-x} for a default. Arguments no parameter uses are
+appended.`,
 		Category: command.CategoryShared,
 		Flags: []command.Flag{
 			{
@@ -153,47 +161,38 @@ func (a *AliasCommand) listAliases(ctx context.Context, exec *command.ExecutionC
 	}
 
 	if len(aliases) == 0 {
-		exec.Data["output"] = "No aliases defined"
-		return nil
+		return setResultOutput(exec, outputFormat, "No aliases defined",
+			map[string]interface{}{"aliases": aliases, "count": 0})
 	}
 
-	switch outputFormat {
-	case "json":
-		data := map[string]interface{}{
-			"aliases": aliases,
-			"count":   len(aliases),
-		}
-		jsonData, _ := json.MarshalIndent(data, "", "  ")
-		exec.Data["output"] = string(jsonData)
-	default:
-		// Text format
-		var output strings.Builder
-		output.WriteString("Defined aliases:\n")
-
-		// Sort aliases for consistent output
-		names := make([]string, 0, len(aliases))
-		for name := range aliases {
-			names = append(names, name)
-		}
-		sort.Strings(names)
-
-		// Find longest name for alignment
-		maxLen := 0
-		for _, name := range names {
-			if len(name) > maxLen {
-				maxLen = len(name)
-			}
-		}
+	// Sort aliases for consistent output
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-		for _, name := range names {
-			cmd := aliases[name]
-			output.WriteString(fmt.Sprintf("  %-*s → %s\n", maxLen+2, name, cmd))
+	// Find longest name for alignment
+	maxLen := 0
+	for _, name := range names {
+		if len(name) > maxLen {
+			maxLen = len(name)
 		}
+	}
 
-		exec.Data["output"] = output.String()
+	var output strings.Builder
+	output.WriteString("Defined aliases:\n")
+	table := command.NewTable("NAME", "COMMAND")
+	for _, name := range names {
+		output.WriteString(fmt.Sprintf("  %-*s → %s\n", maxLen+2, name, aliases[name]))
+		table.AddRow(name, aliases[name])
 	}
 
-	return nil
+	return setFormattedOutput(exec, outputFormat, command.Result{
+		Text:  output.String(),
+		Table: table,
+		Value: map[string]interface{}{"aliases": aliases, "count": len(aliases)},
+	})
 }
 
 // addAlias adds or updates an alias
@@ -211,24 +210,20 @@ func (a *AliasCommand) addAlias(ctx context.Context, exec *command.ExecutionCont
 		}
 	}
 
-	scope := a.getScope(exec)
-
-	// Set alias based on scope
-	if scope == "repl" {
-		key := fmt.Sprintf("repl.aliases.%s", name)
-		if err := a.config.SetValue(key, command); err != nil {
-			return fmt.Errorf("failed to set alias: %w", err)
-		}
-	} else {
-		// Default to CLI aliases
-		key := fmt.Sprintf("aliases.%s", name)
-		if err := a.config.SetValue(key, command); err != nil {
-			return fmt.Errorf("failed to set alias: %w", err)
-		}
+	// Set alias based on scope, defaulting to CLI aliases
+	scope := "cli"
+	key := fmt.Sprintf("aliases.%s", name)
+	if a.getScope(exec) == "repl" {
+		scope = "repl"
+		key = fmt.Sprintf("repl.aliases.%s", name)
+	}
+	if err := a.config.PersistValue(key, command); err != nil {
+		return fmt.Errorf("failed to set alias: %w", err)
 	}
 
-	exec.Data["output"] = fmt.Sprintf("Alias '%s' created: %s", name, command)
-	return nil
+	return setResultOutput(exec, a.getOutputFormat(exec),
+		fmt.Sprintf("Alias '%s' created: %s", name, command),
+		map[string]string{"name": name, "command": command, "scope": scope})
 }
 
 // removeAlias removes an alias
@@ -240,9 +235,8 @@ func (a *AliasCommand) removeAlias(ctx context.Context, exec *command.ExecutionC
 	if scope == "all" || scope == "cli" {
 		key := fmt.Sprintf("aliases.%s", name)
 		if a.config.Exists(key) {
-			// Setting empty string as a workaround for key deletion
-			// Full key deletion will be implemented in a future release
-			if err := a.config.SetValue(key, ""); err != nil {
+			// An empty value also hides aliases that come from the defaults
+			if err := a.config.PersistValue(key, ""); err != nil {
 				return fmt.Errorf("failed to remove alias: %w", err)
 			}
 			found = true
@@ -253,9 +247,8 @@ func (a *AliasCommand) removeAlias(ctx context.Context, exec *command.ExecutionC
 	if scope == "all" || scope == "repl" {
 		key := fmt.Sprintf("repl.aliases.%s", name)
 		if a.config.Exists(key) {
-			// Setting empty string as a workaround for key deletion
-			// Full key deletion will be implemented in a future release
-			if err := a.config.SetValue(key, ""); err != nil {
+			// An empty value also hides aliases that come from the defaults
+			if err := a.config.PersistValue(key, ""); err != nil {
 				return fmt.Errorf("failed to remove alias: %w", err)
 			}
 			found = true
@@ -266,8 +259,9 @@ func (a *AliasCommand) removeAlias(ctx context.Context, exec *command.ExecutionC
 		return fmt.Errorf("alias '%s' not found", name)
 	}
 
-	exec.Data["output"] = fmt.Sprintf("Alias '%s' removed", name)
-	return nil
+	return setResultOutput(exec, a.getOutputFormat(exec),
+		fmt.Sprintf("Alias '%s' removed", name),
+		map[string]string{"removed": name})
 }
 
 // showAlias shows a specific alias
@@ -276,16 +270,18 @@ func (a *AliasCommand) showAlias(ctx context.Context, exec *command.ExecutionCon
 	cliKey := fmt.Sprintf("aliases.%s", name)
 	if a.config.Exists(cliKey) {
 		command := a.config.GetString(cliKey)
-		exec.Data["output"] = fmt.Sprintf("%s → %s", name, command)
-		return nil
+		return setResultOutput(exec, a.getOutputFormat(exec),
+			fmt.Sprintf("%s → %s", name, command),
+			map[string]string{"name": name, "command": command, "scope": "cli"})
 	}
 
 	// Check REPL aliases
 	replKey := fmt.Sprintf("repl.aliases.%s", name)
 	if a.config.Exists(replKey) {
 		command := a.config.GetString(replKey)
-		exec.Data["output"] = fmt.Sprintf("%s (repl) → %s", name, command)
-		return nil
+		return setResultOutput(exec, a.getOutputFormat(exec),
+			fmt.Sprintf("%s (repl) → %s", name, command),
+			map[string]string{"name": name, "command": command, "scope": "repl"})
 	}
 
 	return fmt.Errorf("alias '%s' not found", name)
@@ -301,7 +297,7 @@ func (a *AliasCommand) clearAliases(ctx context.Context, exec *command.Execution
 		aliases := a.getAllAliases("aliases")
 		for name := range aliases {
 			key := fmt.Sprintf("aliases.%s", name)
-			if err := a.config.SetValue(key, ""); err != nil {
+			if err := a.config.PersistValue(key, ""); err != nil {
 				return fmt.Errorf("failed to clear alias '%s': %w", name, err)
 			}
 			cleared++
@@ -313,15 +309,16 @@ func (a *AliasCommand) clearAliases(ctx context.Context, exec *command.Execution
 		aliases := a.getAllAliases("repl.aliases")
 		for name := range aliases {
 			key := fmt.Sprintf("repl.aliases.%s", name)
-			if err := a.config.SetValue(key, ""); err != nil {
+			if err := a.config.PersistValue(key, ""); err != nil {
 				return fmt.Errorf("failed to clear alias '%s': %w", name, err)
 			}
 			cleared++
 		}
 	}
 
-	exec.Data["output"] = fmt.Sprintf("Cleared %d aliases", cleared)
-	return nil
+	return setResultOutput(exec, a.getOutputFormat(exec),
+		fmt.Sprintf("Cleared %d aliases", cleared),
+		map[string]int{"cleared": cleared})
 }
 
 // exportAliases exports aliases to JSON
@@ -399,12 +396,9 @@ func (a *AliasCommand) getScope(exec *command.ExecutionContext) string {
 }
 
 // getOutputFormat gets the output format from flags or data
-func (a *AliasCommand) getOutputFormat(exec *command.ExecutionContext) string {
+func (a *AliasCommand) getOutputFormat(exec *command.ExecutionContext) command.OutputFormat {
 	if format := exec.Flags.GetString("format"); format != "" {
-		return format
-	}
-	if format, ok := exec.Data["outputFormat"].(string); ok && format != "" {
-		return format
+		return command.OutputFormat(format)
 	}
-	return "text"
+	return outputFormat(exec)
 }
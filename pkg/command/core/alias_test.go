@@ -92,6 +92,13 @@ func TestAliasCommand_Execute(t *testing.T) {
 			flags:          map[string]interface{}{"scope": "repl"},
 			expectedOutput: "Alias 'h' created: help",
 		},
+		{
+			name:           "add alias - JSON format",
+			args:           []string{"add", "h", "help"},
+			flags:          map[string]interface{}{"scope": "repl"},
+			outputFormat:   "json",
+			expectedOutput: `"scope": "repl"`,
+		},
 
 		// Show command
 		{
@@ -102,6 +109,15 @@ func TestAliasCommand_Execute(t *testing.T) {
 			},
 			expectedOutput: "gpt4 → model gpt-4",
 		},
+		{
+			name: "show alias - JSON format",
+			args: []string{"show", "gpt4"},
+			setupConfig: func(c *config.Config) {
+				require.NoError(t, c.SetValue("aliases.gpt4", "model gpt-4"))
+			},
+			outputFormat:   "json",
+			expectedOutput: `"command": "model gpt-4"`,
+		},
 		{
 			name: "show REPL alias",
 			args: []string{"show", "r"},
@@ -471,6 +487,7 @@ func TestAliasCommand_CompleteLifecycle(t *testing.T) {
 
 func TestAliasCommand_ScopeHandling(t *testing.T) {
 	// Use clean config to avoid pre-existing aliases
+	t.Setenv("HOME", t.TempDir())
 	config.Manager = nil
 	err := config.Init()
 	require.NoError(t, err)
@@ -5,15 +5,18 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/command"
 	"github.com/lexlapax/magellai/pkg/config"
 	"github.com/lexlapax/magellai/pkg/domain"
 	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
 )
 
 // AskCommand implements the ask command for one-shot queries
@@ -81,22 +84,91 @@ func (c *AskCommand) Metadata() *command.Metadata {
 				Type:        command.FlagTypeString,
 				Description: "Output format (text, json)",
 			},
+			{
+				Name:        "continue",
+				Short:       "c",
+				Type:        command.FlagTypeBool,
+				Description: "Continue the most recently updated session and save the exchange to it",
+			},
+			{
+				Name:        "session",
+				Type:        command.FlagTypeString,
+				Description: "Append the exchange to the session with this ID",
+			},
+			{
+				Name:        "estimate",
+				Type:        command.FlagTypeBool,
+				Description: "Print the estimated token usage and cost without sending the request",
+			},
+			{
+				Name:        "batch",
+				Type:        command.FlagTypeString,
+				Description: "JSONL file of prompts to submit in bulk",
+			},
+			{
+				Name:        "batch-output",
+				Type:        command.FlagTypeString,
+				Description: "File to write batch results to as JSONL (default stdout)",
+			},
+			{
+				Name:        "batch-mode",
+				Type:        command.FlagTypeString,
+				Description: "Batch submission mode (auto, api, concurrent)",
+				Default:     "auto",
+			},
+			{
+				Name:        "concurrency",
+				Type:        command.FlagTypeInt,
+				Description: "Maximum concurrent requests in batch mode",
+				Default:     4,
+			},
+			{
+				Name:        "fail-on-empty",
+				Type:        command.FlagTypeBool,
+				Description: "Return an error if the response is empty",
+			},
+			{
+				Name:        "quiet",
+				Type:        command.FlagTypeBool,
+				Description: "Print only the response text, without JSON metadata or batch progress",
+			},
 		},
 	}
 }
 
 // Execute runs the ask command
 func (c *AskCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
-	// Validate we have a prompt
-	if len(exec.Args) == 0 {
+	// Validate we have a prompt (batch mode reads prompts from a file)
+	batchFile := exec.Flags.GetString("batch")
+	if len(exec.Args) == 0 && batchFile == "" {
 		return fmt.Errorf("prompt is required")
 	}
 
 	// Combine args into the prompt
 	prompt := strings.Join(exec.Args, " ")
 
-	// Get model from flags, profile, or config
+	// Quiet mode prints only the response text
+	if exec.Flags.GetBool("quiet") {
+		exec.Flags.Set("output", "text")
+	}
+
+	// Continue a stored session when requested
+	sess, err := c.openAskSession(exec)
+	if err != nil {
+		return err
+	}
+	if sess != nil {
+		defer sess.close()
+		if batchFile != "" {
+			return fmt.Errorf("%w: --continue and --session cannot be combined with --batch", command.ErrInvalidArguments)
+		}
+	}
+
+	// Get model from flags, the continued session, profile, or config
 	model := exec.Flags.GetString("model")
+	if model == "" && sess != nil {
+		model = sess.model()
+	}
 	if model == "" {
 		// Check current profile for model setting
 		profileName := c.config.GetString("profile.current")
@@ -143,8 +215,13 @@ func (c *AskCommand) Execute(ctx context.Context, exec *command.ExecutionContext
 	// Parse provider and model
 	providerName, modelName := llm.ParseModelString(model)
 
-	// Get API key from config for the provider
-	apiKey := c.config.GetString(fmt.Sprintf("provider.%s.api_key", providerName))
+	// Estimate cost without contacting the provider
+	if exec.Flags.GetBool("estimate") {
+		return c.executeEstimate(exec, providerName, modelName, prompt)
+	}
+
+	// Get API key from the keychain, environment, or config for the provider
+	apiKey := c.config.GetProviderAPIKeyContext(ctx, providerName)
 
 	// Create the provider, passing the API key from config
 	provider, err := llm.NewProvider(providerName, modelName, apiKey)
@@ -167,20 +244,29 @@ func (c *AskCommand) Execute(ctx context.Context, exec *command.ExecutionContext
 		opts = append(opts, llm.WithResponseFormat(format))
 	}
 
+	// Bulk mode submits every prompt in the batch file
+	if batchFile != "" {
+		return c.executeBatch(ctx, exec, provider, apiKey, batchFile, opts)
+	}
+
 	// Build messages
 	messages := []domain.Message{}
 
-	// Add system prompt if provided
-	if system := exec.Flags.GetString("system"); system != "" {
+	// Add system prompt if provided; a continued session keeps its own
+	system := c.systemPrompt(exec)
+	if sess != nil && exec.Flags.GetString("system") == "" && sess.session.Conversation.SystemPrompt != "" {
+		system = sess.session.Conversation.SystemPrompt
+	}
+	if system != "" {
 		messages = append(messages, domain.Message{
 			Role:    "system",
 			Content: system,
 		})
-	} else if defaultSystem := c.config.GetString("defaults.system_prompt"); defaultSystem != "" {
-		messages = append(messages, domain.Message{
-			Role:    "system",
-			Content: defaultSystem,
-		})
+	}
+
+	// Replay the continued conversation before the new prompt
+	if sess != nil {
+		messages = append(messages, sess.session.Conversation.Messages...)
 	}
 
 	// Process attachments
@@ -214,6 +300,12 @@ func (c *AskCommand) Execute(ctx context.Context, exec *command.ExecutionContext
 		}
 	}
 
+	// Attachments passed in memory, such as inline content from the API
+	// server, are used as given
+	if inline, ok := exec.Data["attachments"].([]domain.Attachment); ok {
+		attachments = append(attachments, inline...)
+	}
+
 	// Add user message with prompt and attachments
 	userMessage := domain.Message{
 		Role:    "user",
@@ -224,20 +316,263 @@ func (c *AskCommand) Execute(ctx context.Context, exec *command.ExecutionContext
 	}
 	messages = append(messages, userMessage)
 
-	// Handle streaming vs non-streaming
-	if exec.Flags.GetBool("stream") {
-		return c.executeStreaming(ctx, exec, provider, messages, opts)
+	// Set up the optional moderation stage
+	policy, err := llm.NewModerationPolicy(llm.LoadModerationConfig(c.config))
+	if err != nil {
+		return fmt.Errorf("failed to configure moderation: %w", err)
+	}
+
+	// Moderate the outgoing prompt before anything is sent
+	if err := c.moderate(ctx, exec, policy, llm.ModerationStagePrompt, prompt); err != nil {
+		sess.saveModerationAudit(exec)
+		return err
+	}
+
+	// Handle streaming vs non-streaming. Blocking response moderation needs the
+	// full response before anything is printed, so it disables streaming.
+	var response string
+	started := time.Now()
+	if exec.Flags.GetBool("stream") && !policy.Blocks(llm.ModerationStageResponse) {
+		response, err = c.executeStreaming(ctx, exec, provider, messages, opts, policy)
+	} else {
+		response, err = c.executeNonStreaming(ctx, exec, provider, messages, opts, policy)
+	}
+	if err != nil {
+		if errors.Is(err, llm.ErrContentBlocked) {
+			sess.saveModerationAudit(exec)
+		}
+		return err
+	}
+	latency := time.Since(started)
+	// Streams report no usage, so estimate it from the text
+	estimated := command.UsageOf(exec).TotalTokens == 0
+	if estimated {
+		counter := llm.NewEstimatedTokenCounter()
+		command.AddUsage(exec, counter.CountMessageTokens(messages), counter.CountTokens(response))
+	}
+	if exec.Flags.GetBool("fail-on-empty") && strings.TrimSpace(response) == "" {
+		return fmt.Errorf("%w from %s", llm.ErrEmptyResponse, model)
+	}
+
+	// Persist the exchange when continuing a session
+	if sess != nil {
+		tokens := command.UsageOf(exec)
+		providerName, modelName := llm.ParseModelString(model)
+		usage := &domain.Usage{
+			PromptTokens:     tokens.InputTokens,
+			CompletionTokens: tokens.OutputTokens,
+			Latency:          latency,
+			Provider:         providerName,
+			Model:            modelName,
+			Estimated:        estimated,
+		}
+		if inventory, err := models.LoadDefaultInventory(); err == nil {
+			if info := inventory.GetModel(providerName, modelName); info != nil {
+				cost := info.Cost(usage.PromptTokens, usage.CompletionTokens)
+				usage.Cost, usage.CostKnown = cost.TotalCost, cost.PricingKnown
+			}
+		}
+		sess.addModerationAudit(exec)
+		return sess.record(userMessage, response, usage, model, exec.Flags.GetString("system"))
+	}
+	return nil
+}
+
+// systemPrompt returns the system prompt from flags or the configured default
+func (c *AskCommand) systemPrompt(exec *command.ExecutionContext) string {
+	if system := exec.Flags.GetString("system"); system != "" {
+		return system
+	}
+	return c.config.GetString("defaults.system_prompt")
+}
+
+// executeEstimate prints the estimated token usage and cost of the request
+func (c *AskCommand) executeEstimate(exec *command.ExecutionContext, providerName, modelName, prompt string) error {
+	messages := []domain.Message{}
+	if system := c.systemPrompt(exec); system != "" {
+		messages = append(messages, domain.Message{Role: domain.MessageRoleSystem, Content: system})
+	}
+
+	userMessage := domain.Message{Role: domain.MessageRoleUser, Content: prompt}
+	for _, file := range exec.Flags.GetStringSlice("attach") {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", file, err)
+		}
+		userMessage.Attachments = append(userMessage.Attachments, domain.Attachment{
+			Type:    domain.AttachmentTypeText,
+			Content: content,
+		})
+	}
+	messages = append(messages, userMessage)
+
+	inputTokens := llm.NewEstimatedTokenCounter().CountMessageTokens(messages)
+
+	inventory, err := models.LoadDefaultInventory()
+	if err != nil {
+		logging.LogWarn("Models inventory unavailable, pricing will be omitted", "error", err)
+	}
+	estimate := inventory.EstimateRequestCost(providerName, modelName, inputTokens, exec.Flags.GetInt("max-tokens"))
+
+	outputFormat := exec.Flags.GetString("output")
+	if outputFormat == "" {
+		outputFormat = c.config.GetString("output")
+	}
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(exec.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(estimate)
+	}
+
+	_, err = fmt.Fprint(exec.Stdout, estimate.Format())
+	return err
+}
+
+// defaultBatchConcurrency is the number of in-flight requests for concurrent batches
+const defaultBatchConcurrency = 4
+
+// executeBatch submits every prompt in a JSONL file and writes the results as JSONL.
+// OpenAI models use the Batch API unless concurrent mode is requested; other
+// providers always use concurrent requests.
+func (c *AskCommand) executeBatch(ctx context.Context, exec *command.ExecutionContext, provider llm.Provider, apiKey, batchFile string, opts []llm.ProviderOption) error {
+	in, err := os.Open(batchFile)
+	if err != nil {
+		return fmt.Errorf("failed to open batch file: %w", err)
+	}
+	defer in.Close()
+
+	requests, err := llm.ReadBatchRequests(in)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return fmt.Errorf("%w: %s contains no prompts", llm.ErrInvalidBatchInput, batchFile)
+	}
+
+	out := exec.Stdout
+	if outputFile := exec.Flags.GetString("batch-output"); outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create batch output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	progress := func(p llm.BatchProgress) {
+		if exec.Stderr != nil && !exec.Flags.GetBool("quiet") {
+			fmt.Fprintf(exec.Stderr, "Batch %s: %d/%d completed, %d failed\n", p.Status, p.Completed, p.Total, p.Failed)
+		}
+	}
+
+	info := provider.GetModelInfo()
+	mode := exec.Flags.GetString("batch-mode")
+	if mode == "" {
+		mode = "auto"
+	}
+
+	var useAPI bool
+	switch mode {
+	case "auto":
+		useAPI = info.Provider == llm.ProviderOpenAI
+	case "api":
+		if info.Provider != llm.ProviderOpenAI {
+			return fmt.Errorf("batch API mode is only supported for %s models", llm.ProviderOpenAI)
+		}
+		useAPI = true
+	case "concurrent":
+	default:
+		return fmt.Errorf("invalid batch mode: %s (use auto, api, or concurrent)", mode)
+	}
+
+	// Moderate prompts up front; blocked prompts are reported but never submitted
+	policy, err := llm.NewModerationPolicy(llm.LoadModerationConfig(c.config))
+	if err != nil {
+		return fmt.Errorf("failed to configure moderation: %w", err)
+	}
+	results := make([]llm.BatchResult, len(requests))
+	var submitted []llm.BatchRequest
+	var submittedIdx []int
+	for i, req := range requests {
+		if err := c.moderate(ctx, exec, policy, llm.ModerationStagePrompt, req.Prompt); err != nil {
+			if !errors.Is(err, llm.ErrContentBlocked) {
+				return err
+			}
+			results[i] = llm.BatchResult{ID: req.ID, Prompt: req.Prompt, Error: err.Error(), Provider: info.Provider, Model: info.Model}
+			continue
+		}
+		submitted = append(submitted, req)
+		submittedIdx = append(submittedIdx, i)
 	}
 
-	return c.executeNonStreaming(ctx, exec, provider, messages, opts)
+	system := c.systemPrompt(exec)
+	logging.LogInfo("Submitting batch", "file", batchFile, "requests", len(submitted), "provider", info.Provider, "useBatchAPI", useAPI)
+
+	var batchResults []llm.BatchResult
+	if len(submitted) > 0 {
+		if useAPI {
+			client := llm.NewOpenAIBatchClient(apiKey, c.config.GetString("provider.openai.base_url"))
+			batchResults, err = client.Run(ctx, info.Model, submitted, system, progress, opts...)
+			if err != nil {
+				return fmt.Errorf("batch failed: %w", err)
+			}
+		} else {
+			concurrency := exec.Flags.GetInt("concurrency")
+			if concurrency <= 0 {
+				concurrency = defaultBatchConcurrency
+			}
+			batchResults = llm.RunConcurrentBatch(ctx, provider, submitted, concurrency, system, progress, opts...)
+		}
+	}
+
+	for j, result := range batchResults {
+		if result.Error == "" {
+			if err := c.moderate(ctx, exec, policy, llm.ModerationStageResponse, result.Content); err != nil {
+				if !errors.Is(err, llm.ErrContentBlocked) {
+					return err
+				}
+				result.Content = ""
+				result.Error = err.Error()
+			}
+		}
+		results[submittedIdx[j]] = result
+	}
+
+	return llm.WriteBatchResults(out, results)
+}
+
+// moderate runs the moderation policy on text. Flagged results are added to
+// the audit trail in the execution data, which a continued session keeps, and
+// those let through are reported on stderr.
+func (c *AskCommand) moderate(ctx context.Context, exec *command.ExecutionContext, policy *llm.ModerationPolicy, stage llm.ModerationStage, text string) error {
+	record, err := policy.Check(ctx, stage, text)
+	if record != nil && record.Flagged {
+		if exec.Data == nil {
+			exec.Data = make(map[string]interface{})
+		}
+		llm.AppendModerationRecord(exec.Data, *record)
+
+		if !record.Blocked && exec.Stderr != nil {
+			fmt.Fprintf(exec.Stderr, "Warning: %s flagged by moderation (%s)\n", stage, strings.Join(record.Categories, ", "))
+		}
+	}
+	return err
 }
 
 // executeNonStreaming handles non-streaming requests
-func (c *AskCommand) executeNonStreaming(ctx context.Context, exec *command.ExecutionContext, provider llm.Provider, messages []domain.Message, opts []llm.ProviderOption) error {
+func (c *AskCommand) executeNonStreaming(ctx context.Context, exec *command.ExecutionContext, provider llm.Provider, messages []domain.Message, opts []llm.ProviderOption, policy *llm.ModerationPolicy) (string, error) {
 	// Generate response
 	response, err := provider.GenerateMessage(ctx, messages, opts...)
 	if err != nil {
-		return fmt.Errorf("failed to generate response: %w", err)
+		return "", fmt.Errorf("failed to generate response: %w", err)
+	}
+	if response.Usage != nil {
+		command.AddUsage(exec, response.Usage.InputTokens, response.Usage.OutputTokens)
+	}
+
+	// Moderate the response before printing it
+	if err := c.moderate(ctx, exec, policy, llm.ModerationStageResponse, response.Content); err != nil {
+		return "", err
 	}
 
 	// Output based on format
@@ -261,41 +596,46 @@ func (c *AskCommand) executeNonStreaming(ctx context.Context, exec *command.Exec
 
 		encoder := json.NewEncoder(exec.Stdout)
 		encoder.SetIndent("", "  ")
-		return encoder.Encode(jsonOutput)
+		return response.Content, encoder.Encode(jsonOutput)
 
 	default: // text
 		_, err := fmt.Fprint(exec.Stdout, response.Content)
-		return err
+		return response.Content, err
 	}
 }
 
 // executeStreaming handles streaming requests
-func (c *AskCommand) executeStreaming(ctx context.Context, exec *command.ExecutionContext, provider llm.Provider, messages []domain.Message, opts []llm.ProviderOption) error {
+func (c *AskCommand) executeStreaming(ctx context.Context, exec *command.ExecutionContext, provider llm.Provider, messages []domain.Message, opts []llm.ProviderOption, policy *llm.ModerationPolicy) (string, error) {
 	// Start streaming
 	stream, err := provider.StreamMessage(ctx, messages, opts...)
 	if err != nil {
-		return fmt.Errorf("failed to start stream: %w", err)
+		return "", fmt.Errorf("failed to start stream: %w", err)
 	}
 
-	// Collect content for final output if needed
+	// Collect content for final output and moderation
 	var content strings.Builder
 	isJSON := exec.Flags.GetString("output") == "json"
 
 	// Stream chunks to output
 	for chunk := range stream {
 		if chunk.Error != nil {
-			return fmt.Errorf("streaming error: %w", chunk.Error)
+			return "", fmt.Errorf("streaming error: %w", chunk.Error)
 		}
 
-		if isJSON {
-			// Collect for JSON output
-			content.WriteString(chunk.Content)
-		} else {
+		content.WriteString(chunk.Content)
+		if !isJSON {
 			// Stream directly to output
 			fmt.Fprint(exec.Stdout, chunk.Content)
 		}
 	}
 
+	// Moderate the completed response. Streaming only runs when response
+	// moderation warns, so flagged output has already been written and is
+	// reported after the fact rather than blocked.
+	if err := c.moderate(ctx, exec, policy, llm.ModerationStageResponse, content.String()); err != nil {
+		return "", err
+	}
+
 	// Output JSON format if requested
 	if isJSON {
 		jsonOutput := map[string]interface{}{
@@ -306,10 +646,10 @@ func (c *AskCommand) executeStreaming(ctx context.Context, exec *command.Executi
 
 		encoder := json.NewEncoder(exec.Stdout)
 		encoder.SetIndent("", "  ")
-		return encoder.Encode(jsonOutput)
+		return content.String(), encoder.Encode(jsonOutput)
 	}
 
-	return nil
+	return content.String(), nil
 }
 
 // Validate implements the Command interface
@@ -0,0 +1,139 @@
+// ABOUTME: Session continuation for the ask command
+// ABOUTME: Loads a stored conversation for ask to continue and persists each new exchange
+
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/repl/session"
+)
+
+// askSession is a stored conversation that an ask continues
+type askSession struct {
+	manager *session.SessionManager
+	session *domain.Session
+	owned   bool // Whether the manager was opened here and must be closed
+}
+
+// openAskSession returns the session to continue: the one named by
+// --session, or with --continue the most recently updated one (starting a new
+// session if none exist yet). It returns nil when not continuing.
+func (c *AskCommand) openAskSession(exec *command.ExecutionContext) (*askSession, error) {
+	sessionID := exec.Flags.GetString("session")
+	continueLatest := exec.Flags.GetBool("continue")
+	if sessionID == "" && !continueLatest {
+		return nil, nil
+	}
+	if sessionID != "" && continueLatest {
+		return nil, fmt.Errorf("%w: use either --session or --continue", command.ErrInvalidArguments)
+	}
+
+	_, injected := exec.Data["session_manager"].(*session.SessionManager)
+	manager, err := openSessionManager(c.config, exec)
+	if err != nil {
+		return nil, err
+	}
+	sess := &askSession{manager: manager, owned: !injected}
+
+	if sessionID != "" {
+		sess.session, err = manager.StorageManager.LoadSession(sessionID)
+	} else {
+		sess.session, err = latestSession(manager)
+	}
+	if err != nil {
+		sess.close()
+		return nil, err
+	}
+	if sess.session == nil {
+		logging.LogInfo("No session to continue, starting a new one")
+		if sess.session, err = manager.NewSession(""); err != nil {
+			sess.close()
+			return nil, fmt.Errorf("failed to create session: %w", err)
+		}
+	}
+
+	logging.LogInfo("Continuing session", "id", sess.session.ID, "messages", len(sess.session.Conversation.Messages))
+	return sess, nil
+}
+
+// model returns the session's model in provider/model form, or "" if unset
+func (s *askSession) model() string {
+	conv := s.session.Conversation
+	if conv.Model == "" || strings.Contains(conv.Model, "/") || conv.Provider == "" {
+		return conv.Model
+	}
+	return conv.Provider + "/" + conv.Model
+}
+
+// record appends the prompt and response, with the usage of the request, to
+// the session and saves it
+func (s *askSession) record(user domain.Message, response string, usage *domain.Usage, model, system string) error {
+	conv := s.session.Conversation
+	now := time.Now()
+
+	user.ID = uuid.New().String()
+	user.Timestamp = now
+	assistant := domain.Message{
+		ID:        uuid.New().String(),
+		Role:      domain.MessageRoleAssistant,
+		Content:   response,
+		Timestamp: now,
+		Usage:     usage,
+	}
+	conv.AddMessage(user)
+	conv.AddMessage(assistant)
+	domain.PublishMessageAdded(s.session.ID, user)
+	domain.PublishMessageAdded(s.session.ID, assistant)
+
+	providerName, _ := llm.ParseModelString(model)
+	conv.Provider = providerName
+	conv.Model = model
+	if system != "" {
+		conv.SystemPrompt = system
+	}
+	s.session.Updated = now
+
+	if err := s.manager.SaveSession(s.session); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	logging.LogDebug("Saved ask exchange to session", "id", s.session.ID, "messages", len(conv.Messages))
+	return nil
+}
+
+// addModerationAudit copies the moderation records of the ask into the
+// session's audit trail
+func (s *askSession) addModerationAudit(exec *command.ExecutionContext) {
+	if s.session.Metadata == nil {
+		s.session.Metadata = make(map[string]interface{})
+	}
+	llm.MergeModerationTrail(s.session.Metadata, exec.Data)
+}
+
+// saveModerationAudit records why an ask was blocked in the session without
+// adding the exchange. It does nothing when the ask continues no session.
+func (s *askSession) saveModerationAudit(exec *command.ExecutionContext) {
+	if s == nil {
+		return
+	}
+	s.addModerationAudit(exec)
+	if err := s.manager.SaveSession(s.session); err != nil {
+		logging.LogWarn("Failed to save moderation audit to session", "id", s.session.ID, "error", err)
+	}
+}
+
+// close releases session storage opened for the ask
+func (s *askSession) close() {
+	if s.owned {
+		if err := s.manager.Close(); err != nil {
+			logging.LogWarn("Failed to close session storage", "error", err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// ABOUTME: Tests for continuing sessions from the ask command
+// ABOUTME: Verifies the latest or named session is extended and saved across asks
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/repl/session"
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAskSessionManager creates session storage in a temp directory
+func newAskSessionManager(t *testing.T) *session.SessionManager {
+	t.Helper()
+	manager, err := session.CreateStorageManager(storage.FileSystemBackend, storage.Config{"base_dir": t.TempDir()})
+	require.NoError(t, err)
+	return &session.SessionManager{StorageManager: manager}
+}
+
+func TestAskCommandContinue(t *testing.T) {
+	require.NoError(t, config.Init())
+	cfg := config.Manager
+	require.NoError(t, cfg.SetValue("model.default", "mock/test-model"))
+	cmd := NewAskCommand(cfg)
+
+	ask := func(t *testing.T, manager *session.SessionManager, flags map[string]interface{}, prompt string) string {
+		t.Helper()
+		var stdout bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   []string{prompt},
+			Flags:  command.NewFlags(flags),
+			Stdout: &stdout,
+			Stderr: &bytes.Buffer{},
+			Data:   map[string]interface{}{"session_manager": manager},
+		}
+		require.NoError(t, cmd.Execute(context.Background(), exec))
+		return stdout.String()
+	}
+
+	t.Run("starts a session when none exist and extends it", func(t *testing.T) {
+		manager := newAskSessionManager(t)
+
+		out := ask(t, manager, map[string]interface{}{"continue": true, "system": "Be brief"}, "first question")
+		assert.NotEmpty(t, out)
+		ask(t, manager, map[string]interface{}{"continue": true}, "follow-up")
+
+		sessions, err := manager.ListSessions()
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+
+		stored, err := manager.StorageManager.LoadSession(sessions[0].ID)
+		require.NoError(t, err)
+		require.Len(t, stored.Conversation.Messages, 4)
+		assert.Equal(t, "first question", stored.Conversation.Messages[0].Content)
+		assert.Equal(t, domain.MessageRoleAssistant, stored.Conversation.Messages[1].Role)
+		assert.Equal(t, "follow-up", stored.Conversation.Messages[2].Content)
+		assert.Equal(t, "Be brief", stored.Conversation.SystemPrompt)
+		assert.Equal(t, "mock/test-model", stored.Conversation.Model)
+	})
+
+	t.Run("continues the most recently updated session", func(t *testing.T) {
+		manager := newAskSessionManager(t)
+		older, err := manager.NewSession("older")
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+		newer, err := manager.NewSession("newer")
+		require.NoError(t, err)
+
+		ask(t, manager, map[string]interface{}{"continue": true}, "hello")
+
+		stored, err := manager.StorageManager.LoadSession(newer.ID)
+		require.NoError(t, err)
+		assert.Len(t, stored.Conversation.Messages, 2)
+		stored, err = manager.StorageManager.LoadSession(older.ID)
+		require.NoError(t, err)
+		assert.Empty(t, stored.Conversation.Messages)
+	})
+
+	t.Run("without continue nothing is saved", func(t *testing.T) {
+		manager := newAskSessionManager(t)
+		ask(t, manager, map[string]interface{}{}, "one-off")
+
+		sessions, err := manager.ListSessions()
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("appends to a named session", func(t *testing.T) {
+		manager := newAskSessionManager(t)
+		target, err := manager.NewSession("target")
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+		_, err = manager.NewSession("latest")
+		require.NoError(t, err)
+
+		out := ask(t, manager, map[string]interface{}{"session": target.ID}, "hello")
+		assert.NotContains(t, out, "hello", "only the response is printed")
+
+		stored, err := manager.StorageManager.LoadSession(target.ID)
+		require.NoError(t, err)
+		require.Len(t, stored.Conversation.Messages, 2)
+		assert.Equal(t, "hello", stored.Conversation.Messages[0].Content)
+	})
+
+	t.Run("rejects unknown sessions and conflicting flags", func(t *testing.T) {
+		manager := newAskSessionManager(t)
+		for _, flags := range []map[string]interface{}{
+			{"session": "missing"},
+			{"session": "missing", "continue": true},
+		} {
+			exec := &command.ExecutionContext{
+				Args:   []string{"hello"},
+				Flags:  command.NewFlags(flags),
+				Stdout: &bytes.Buffer{},
+				Stderr: &bytes.Buffer{},
+				Data:   map[string]interface{}{"session_manager": manager},
+			}
+			assert.Error(t, cmd.Execute(context.Background(), exec))
+		}
+	})
+}
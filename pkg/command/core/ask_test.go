@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -14,6 +15,9 @@ import (
 
 	"github.com/lexlapax/magellai/pkg/command"
 	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
 )
 
 func TestAskCommand(t *testing.T) {
@@ -175,6 +179,35 @@ func TestAskCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("Quiet output", func(t *testing.T) {
+		ctx := context.Background()
+		var stdout, stderr bytes.Buffer
+
+		exec := &command.ExecutionContext{
+			Context: ctx,
+			Args:    []string{"Test quiet output"},
+			Flags: command.NewFlags(map[string]interface{}{
+				"output": "json",
+				"quiet":  true,
+			}),
+			Stdout: &stdout,
+			Stderr: &stderr,
+			Config: cfg,
+		}
+
+		if err := cmd.Execute(ctx, exec); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		// Quiet mode prints the bare response, not the JSON envelope
+		if stdout.Len() == 0 || strings.HasPrefix(stdout.String(), "{") {
+			t.Errorf("expected only the response text, got %q", stdout.String())
+		}
+		if stderr.Len() != 0 {
+			t.Errorf("expected nothing on stderr, got %q", stderr.String())
+		}
+	})
+
 	t.Run("Multi-word prompt", func(t *testing.T) {
 		ctx := context.Background()
 		var stdout bytes.Buffer
@@ -270,6 +303,210 @@ func TestAskCommandStreaming(t *testing.T) {
 	})
 }
 
+func TestAskCommandModeration(t *testing.T) {
+	// Initialize config
+	if err := config.Init(); err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+
+	cfg := config.Manager
+	require.NoError(t, cfg.SetValue("model.default", "mock/test-model"))
+	require.NoError(t, cfg.SetValue("provider.mock.api_key", "mock-api-key"))
+	require.NoError(t, cfg.SetValue("moderation.enabled", true))
+	require.NoError(t, cfg.SetValue("moderation.provider", "local"))
+	require.NoError(t, cfg.SetValue("moderation.blocked_terms", []string{"forbidden"}))
+	defer func() {
+		_ = cfg.SetValue("moderation.enabled", false)
+		_ = cfg.SetValue("moderation.action", "warn")
+	}()
+
+	cmd := NewAskCommand(cfg)
+
+	t.Run("block rejects flagged prompt", func(t *testing.T) {
+		require.NoError(t, cfg.SetValue("moderation.action", "block"))
+
+		ctx := context.Background()
+		var stdout bytes.Buffer
+		exec := &command.ExecutionContext{
+			Context: ctx,
+			Args:    []string{"a forbidden question"},
+			Flags:   command.NewFlags(map[string]interface{}{}),
+			Stdout:  &stdout,
+			Stderr:  &bytes.Buffer{},
+			Config:  cfg,
+		}
+
+		err := cmd.Execute(ctx, exec)
+		require.Error(t, err)
+		require.ErrorIs(t, err, llm.ErrContentBlocked)
+		require.Zero(t, stdout.Len())
+
+		trail, ok := exec.Data[llm.ModerationMetadataKey].([]interface{})
+		require.True(t, ok)
+		require.Len(t, trail, 1)
+		require.Equal(t, true, trail[0].(map[string]interface{})["blocked"])
+	})
+
+	t.Run("block is audited in a continued session", func(t *testing.T) {
+		require.NoError(t, cfg.SetValue("moderation.action", "block"))
+
+		manager := newAskSessionManager(t)
+		exec := &command.ExecutionContext{
+			Args:   []string{"a forbidden question"},
+			Flags:  command.NewFlags(map[string]interface{}{"continue": true}),
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Data:   map[string]interface{}{"session_manager": manager},
+		}
+		require.ErrorIs(t, cmd.Execute(context.Background(), exec), llm.ErrContentBlocked)
+
+		sessions, err := manager.ListSessions()
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		stored, err := manager.StorageManager.LoadSession(sessions[0].ID)
+		require.NoError(t, err)
+		require.Empty(t, stored.Conversation.Messages)
+		require.Len(t, stored.Metadata[llm.ModerationMetadataKey], 1)
+	})
+
+	t.Run("warn reports flagged prompt", func(t *testing.T) {
+		require.NoError(t, cfg.SetValue("moderation.action", "warn"))
+
+		ctx := context.Background()
+		var stdout, stderr bytes.Buffer
+		exec := &command.ExecutionContext{
+			Context: ctx,
+			Args:    []string{"a forbidden question"},
+			Flags:   command.NewFlags(map[string]interface{}{}),
+			Stdout:  &stdout,
+			Stderr:  &stderr,
+			Config:  cfg,
+		}
+
+		err := cmd.Execute(ctx, exec)
+		if err != nil && !strings.Contains(err.Error(), "mock provider") {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		require.Contains(t, stderr.String(), "prompt flagged by moderation")
+	})
+}
+
+func TestAskCommandEstimate(t *testing.T) {
+	// Initialize config
+	if err := config.Init(); err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+
+	inventoryFile, err := filepath.Abs(filepath.Join("..", "..", "..", "models.json"))
+	require.NoError(t, err)
+	t.Setenv(models.InventoryEnvVar, inventoryFile)
+
+	cfg := config.Manager
+	cmd := NewAskCommand(cfg)
+
+	t.Run("text output", func(t *testing.T) {
+		ctx := context.Background()
+		var stdout bytes.Buffer
+		exec := &command.ExecutionContext{
+			Context: ctx,
+			Args:    []string{"How much does this cost?"},
+			Flags: command.NewFlags(map[string]interface{}{
+				"model":      "openai/gpt-4o",
+				"estimate":   true,
+				"max-tokens": 200,
+				"output":     "text",
+			}),
+			Stdout: &stdout,
+			Stderr: &bytes.Buffer{},
+			Config: cfg,
+		}
+
+		// No API key is needed because nothing is sent
+		require.NoError(t, cmd.Execute(ctx, exec))
+		require.Contains(t, stdout.String(), "openai/gpt-4o")
+		require.Contains(t, stdout.String(), "Output tokens:  up to 200")
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		ctx := context.Background()
+		var stdout bytes.Buffer
+		exec := &command.ExecutionContext{
+			Context: ctx,
+			Args:    []string{"How much does this cost?"},
+			Flags: command.NewFlags(map[string]interface{}{
+				"model":    "openai/gpt-4o",
+				"estimate": true,
+				"output":   "json",
+			}),
+			Stdout: &stdout,
+			Stderr: &bytes.Buffer{},
+			Config: cfg,
+		}
+
+		require.NoError(t, cmd.Execute(ctx, exec))
+
+		var estimate models.CostEstimate
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &estimate))
+		require.True(t, estimate.PricingKnown)
+		require.Greater(t, estimate.InputTokens, 0)
+		require.Greater(t, estimate.TotalCost, 0.0)
+	})
+}
+
+func TestAskCommandBatch(t *testing.T) {
+	// Initialize config
+	if err := config.Init(); err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+
+	cfg := config.Manager
+	require.NoError(t, cfg.SetValue("model.default", "mock/test-model"))
+	require.NoError(t, cfg.SetValue("provider.mock.api_key", "mock-api-key"))
+
+	cmd := NewAskCommand(cfg)
+
+	dir := t.TempDir()
+	batchFile := filepath.Join(dir, "prompts.jsonl")
+	outputFile := filepath.Join(dir, "results.jsonl")
+	require.NoError(t, os.WriteFile(batchFile, []byte("{\"id\":\"q1\",\"prompt\":\"first\"}\n\"second\"\n"), 0644))
+
+	ctx := context.Background()
+	var stderr bytes.Buffer
+	exec := &command.ExecutionContext{
+		Context: ctx,
+		Args:    []string{},
+		Flags: command.NewFlags(map[string]interface{}{
+			"batch":        batchFile,
+			"batch-output": outputFile,
+			"concurrency":  2,
+		}),
+		Stdout: &bytes.Buffer{},
+		Stderr: &stderr,
+		Config: cfg,
+	}
+
+	require.NoError(t, cmd.Execute(ctx, exec))
+	require.Contains(t, stderr.String(), "Batch completed: 2/2 completed")
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var first llm.BatchResult
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.Equal(t, "q1", first.ID)
+	require.Equal(t, "first", first.Prompt)
+	require.Equal(t, "mock", first.Provider)
+
+	t.Run("api mode requires openai", func(t *testing.T) {
+		exec.Flags.Set("batch-mode", "api")
+		err := cmd.Execute(ctx, exec)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only supported")
+	})
+}
+
 func TestAskCommandAPIKeyResolution(t *testing.T) {
 	// Initialize config
 	if err := config.Init(); err != nil {
@@ -428,4 +665,24 @@ func TestAskCommandAttachments(t *testing.T) {
 			t.Errorf("Unexpected error: %v", err)
 		}
 	})
+	t.Run("Inline attachments", func(t *testing.T) {
+		manager := newAskSessionManager(t)
+		inline := domain.Attachment{Type: domain.AttachmentTypeText, Content: []byte("hello"), Name: "notes.txt", MimeType: "text/plain"}
+		exec := &command.ExecutionContext{
+			Args:   []string{"Summarize this"},
+			Flags:  command.NewFlags(map[string]interface{}{"continue": true}),
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Data:   map[string]interface{}{"session_manager": manager, "attachments": []domain.Attachment{inline}},
+		}
+		require.NoError(t, cmd.Execute(context.Background(), exec))
+
+		sessions, err := manager.ListSessions()
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		stored, err := manager.StorageManager.LoadSession(sessions[0].ID)
+		require.NoError(t, err)
+		require.Len(t, stored.Conversation.Messages[0].Attachments, 1)
+		require.Equal(t, "notes.txt", stored.Conversation.Messages[0].Attachments[0].Name)
+	})
 }
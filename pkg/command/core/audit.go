@@ -0,0 +1,151 @@
+// ABOUTME: Audit command that shows the append-only log of session and config changes
+// ABOUTME: Lists who created, deleted, merged, or exported sessions and changed configuration, and when
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/audit"
+	"github.com/lexlapax/magellai/pkg/command"
+)
+
+// AuditCommand shows the audit log
+type AuditCommand struct {
+	log *audit.Log
+}
+
+// NewAuditCommand creates an audit command that reads the entries in log
+func NewAuditCommand(log *audit.Log) *AuditCommand {
+	return &AuditCommand{log: log}
+}
+
+// Execute runs the audit command
+func (c *AuditCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+	if len(exec.Args) == 0 {
+		return c.showLog(exec)
+	}
+
+	switch exec.Args[0] {
+	case "log":
+		return c.showLog(exec)
+	default:
+		return fmt.Errorf("audit: %w - unknown subcommand %q", command.ErrInvalidArguments, exec.Args[0])
+	}
+}
+
+// showLog lists the recorded entries, oldest first
+func (c *AuditCommand) showLog(exec *command.ExecutionContext) error {
+	filter := audit.Filter{
+		Action:    exec.Flags.GetString("action"),
+		SessionID: exec.Flags.GetString("session"),
+		Limit:     exec.Flags.GetInt("limit"),
+	}
+	if since := exec.Flags.GetDuration("since"); since > 0 {
+		filter.Since = time.Now().Add(-since)
+	}
+
+	entries, err := c.log.Entries(filter)
+	if err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	exec.Data["entries"] = entries
+	if structuredOutputRequested(exec) {
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+		return printOutput(exec, map[string]interface{}{"entries": entries})
+	}
+	if len(entries) == 0 {
+		return printOutput(exec, "No audit entries recorded")
+	}
+
+	table := command.NewTable("TIME", "USER", "ACTION", "SESSION", "DETAILS")
+	for _, entry := range entries {
+		session := entry.SessionID
+		if session == "" {
+			session = "-"
+		}
+		table.AddRow(entry.Time.Local().Format("2006-01-02 15:04:05"), entry.User, entry.Action, session,
+			formatAuditDetails(entry.Details))
+	}
+	return printOutput(exec, table)
+}
+
+// formatAuditDetails renders entry details as sorted key=value pairs
+func formatAuditDetails(details map[string]interface{}) string {
+	if len(details) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, details[key]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Metadata returns the command metadata
+func (c *AuditCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "audit",
+		Description: "Show the audit log of session and config changes",
+		LongDescription: `The audit command shows an append-only log of who created, deleted, merged,
+or exported sessions and changed configuration, and when. Entries are recorded
+while audit.enabled is set; config values are not recorded, only the keys.
+
+Subcommands:
+  log   List recorded entries, oldest first (default)
+
+Actions:
+  session.created, session.deleted, session.merged, session.exported,
+  config.changed
+
+Examples:
+  audit log
+  audit log --since 168h --action session.deleted
+  audit log --session session_123 --limit 20`,
+		Category: command.CategoryCLI,
+		Flags: []command.Flag{
+			{
+				Name:        "since",
+				Description: "Only show entries recorded within this duration",
+				Type:        command.FlagTypeDuration,
+			},
+			{
+				Name:        "action",
+				Description: "Only show entries of this action",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "session",
+				Description: "Only show entries about this session",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "limit",
+				Description: "Only show the newest entries, up to this many",
+				Type:        command.FlagTypeInt,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *AuditCommand) Validate() error {
+	if c.log == nil {
+		return fmt.Errorf("audit log not initialized")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// ABOUTME: Tests for the audit command
+// ABOUTME: Verifies the audit log is listed as a table or structured output, with its filters
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/audit"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditCommand_Metadata(t *testing.T) {
+	meta := NewAuditCommand(audit.NewLog("")).Metadata()
+	assert.Equal(t, "audit", meta.Name)
+	assert.Equal(t, command.CategoryCLI, meta.Category)
+	assert.Len(t, meta.Flags, 4)
+	assert.Error(t, NewAuditCommand(nil).Validate())
+}
+
+func TestAuditCommand_Execute(t *testing.T) {
+	log := audit.NewLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	cmd := NewAuditCommand(log)
+
+	run := func(args []string, flags, data map[string]interface{}) (string, error) {
+		var output bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdout: &output,
+			Data:   data,
+		}
+		err := cmd.Execute(context.Background(), exec)
+		return output.String(), err
+	}
+
+	output, err := run(nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "No audit entries recorded\n", output)
+
+	now := time.Now()
+	require.NoError(t, log.Record(audit.Entry{Time: now.Add(-48 * time.Hour), User: "alice", Action: "session.created",
+		SessionID: "session_1", Details: map[string]interface{}{"name": "Planning"}}))
+	require.NoError(t, log.Record(audit.Entry{Time: now, User: "bob", Action: "session.exported",
+		SessionID: "session_1", Details: map[string]interface{}{"format": "markdown"}}))
+	require.NoError(t, log.Record(audit.Entry{Time: now, User: "bob", Action: "config.changed",
+		Details: map[string]interface{}{"key": "stream", "removed": false}}))
+
+	output, err = run([]string{"log"}, nil, nil)
+	require.NoError(t, err)
+	assert.Contains(t, output, "ACTION")
+	assert.Regexp(t, `alice\s+session\.created\s+session_1\s+name=Planning`, output)
+	assert.Regexp(t, `bob\s+config\.changed\s+-\s+key=stream removed=false`, output)
+
+	output, err = run([]string{"log"}, map[string]interface{}{"since": "24h", "session": "session_1"}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, output, "session.exported")
+	assert.NotContains(t, output, "session.created")
+	assert.NotContains(t, output, "config.changed")
+
+	output, err = run([]string{"log"}, map[string]interface{}{"action": "session.created"},
+		map[string]interface{}{"outputFormat": "json"})
+	require.NoError(t, err)
+	var result struct {
+		Entries []audit.Entry `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &result))
+	require.Len(t, result.Entries, 1)
+	assert.Equal(t, "alice", result.Entries[0].User)
+
+	_, err = run([]string{"erase"}, nil, nil)
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+}
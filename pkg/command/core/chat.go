@@ -57,6 +57,45 @@ func (c *ChatCommand) Metadata() *command.Metadata {
 				Required:    false,
 				Default:     []string{},
 			},
+			{
+				Name:        "no-rc",
+				Description: "Skip the replrc startup commands file",
+				Type:        command.FlagTypeBool,
+				Required:    false,
+				Default:     false,
+			},
+			{
+				Name:        "script",
+				Description: "Run commands and prompts from a file (- for stdin), printing JSON lines",
+				Type:        command.FlagTypeString,
+				Required:    false,
+				Default:     "",
+			},
+			{
+				Name:        "quiet",
+				Description: "Skip the welcome banner",
+				Type:        command.FlagTypeBool,
+			},
+			{
+				Name:        "template",
+				Description: "Start the session from a session template",
+				Type:        command.FlagTypeString,
+				Required:    false,
+				Default:     "",
+			},
+			{
+				Name:        "ephemeral",
+				Description: "Delete the session automatically after this duration (e.g. 24h)",
+				Type:        command.FlagTypeDuration,
+				Required:    false,
+			},
+			{
+				Name:        "read-only",
+				Description: "Browse and export sessions without writing to session storage",
+				Type:        command.FlagTypeBool,
+				Required:    false,
+				Default:     false,
+			},
 		},
 	}
 }
@@ -76,8 +115,15 @@ func (c *ChatCommand) Execute(ctx context.Context, exec *command.ExecutionContex
 		Config:    &replConfigAdapter{cfg},
 		SessionID: sessionID,
 		Model:     model,
+		Template:  exec.Flags.GetString("template"),
+		Ephemeral: exec.Flags.GetDuration("ephemeral"),
+		ReadOnly:  exec.Flags.GetBool("read-only"),
+		NoRC:      exec.Flags.GetBool("no-rc"),
+		Script:    exec.Flags.GetString("script"),
+		Quiet:     exec.Flags.GetBool("quiet"),
 		Writer:    exec.Stdout,
 		Reader:    os.Stdin,
+		Context:   ctx,
 	}
 
 	// Create and run REPL using factory
@@ -91,7 +137,17 @@ func (c *ChatCommand) Execute(ctx context.Context, exec *command.ExecutionContex
 	_ = attachments
 
 	// Run the REPL
-	return replInstance.Run()
+	err = replInstance.Run()
+	if reporter, ok := replInstance.(usageReporter); ok {
+		input, output := reporter.Usage()
+		command.AddUsage(exec, input, output)
+	}
+	return err
+}
+
+// usageReporter is implemented by REPLs that count the tokens they used
+type usageReporter interface {
+	Usage() (inputTokens, outputTokens int)
 }
 
 // Validate checks if the command execution context is valid
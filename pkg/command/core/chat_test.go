@@ -20,7 +20,7 @@ func TestChatCommand(t *testing.T) {
 		assert.Equal(t, "chat", meta.Name)
 		assert.Equal(t, "Start an interactive chat session with the LLM", meta.Description)
 		assert.Equal(t, command.CategoryCLI, meta.Category)
-		require.Len(t, meta.Flags, 3)
+		require.Len(t, meta.Flags, 9)
 
 		// Check flags
 		flags := meta.Flags
@@ -35,6 +35,24 @@ func TestChatCommand(t *testing.T) {
 		assert.Equal(t, "attach", flags[2].Name)
 		assert.Equal(t, "a", flags[2].Short)
 		assert.Equal(t, command.FlagTypeStringSlice, flags[2].Type)
+
+		assert.Equal(t, "no-rc", flags[3].Name)
+		assert.Equal(t, command.FlagTypeBool, flags[3].Type)
+
+		assert.Equal(t, "script", flags[4].Name)
+		assert.Equal(t, command.FlagTypeString, flags[4].Type)
+
+		assert.Equal(t, "quiet", flags[5].Name)
+		assert.Equal(t, command.FlagTypeBool, flags[5].Type)
+
+		assert.Equal(t, "template", flags[6].Name)
+		assert.Equal(t, command.FlagTypeString, flags[6].Type)
+
+		assert.Equal(t, "ephemeral", flags[7].Name)
+		assert.Equal(t, command.FlagTypeDuration, flags[7].Type)
+
+		assert.Equal(t, "read-only", flags[8].Name)
+		assert.Equal(t, command.FlagTypeBool, flags[8].Type)
 	})
 
 	t.Run("validate", func(t *testing.T) {
@@ -16,7 +16,9 @@ import (
 	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/command"
 	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/keyring"
 	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/secrets"
 )
 
 // ConfigCommand implements configuration management
@@ -40,6 +42,9 @@ func (c *ConfigCommand) Execute(ctx context.Context, exec *command.ExecutionCont
 	if len(exec.Args) == 0 {
 		return c.showCurrentConfig(ctx, exec)
 	}
+	if command.IsDryRun(exec) && exec.Args[0] != "import" {
+		return fmt.Errorf("config %s: %w", exec.Args[0], command.ErrDryRunUnsupported)
+	}
 
 	switch exec.Args[0] {
 	case "list":
@@ -72,6 +77,18 @@ func (c *ConfigCommand) Execute(ctx context.Context, exec *command.ExecutionCont
 		return c.handleProfileCommand(ctx, exec, exec.Args[1:])
 	case "generate":
 		return c.generateConfig(ctx, exec)
+	case "diff":
+		prefix := ""
+		if len(exec.Args) > 1 {
+			prefix = exec.Args[1]
+		}
+		return c.diffConfig(exec, prefix)
+	case "encrypt-keys":
+		path := config.UserConfigPath()
+		if len(exec.Args) > 1 {
+			path = exec.Args[1]
+		}
+		return c.encryptKeys(exec, path)
 	default:
 		return fmt.Errorf("config: %w - invalid subcommand '%s'", command.ErrInvalidArguments, exec.Args[0])
 	}
@@ -91,9 +108,11 @@ Subcommands:
   set <key> <value>  Set a configuration value
   validate           Validate the current configuration
   export             Export configuration to stdout
-  import <file>      Import configuration from file
+  import <file>      Import configuration from file (--dry-run lists the changes)
   edit               Open configuration in editor
   generate           Generate an example configuration file
+  diff [prefix]      Show values that differ from the defaults and where each came from
+  encrypt-keys [file] Encrypt API keys and tokens in a config file
   profiles           Manage configuration profiles
     list             List all profiles
     switch <name>    Switch to a profile
@@ -108,8 +127,14 @@ Examples:
   config validate          # Check configuration
   config export > my.yaml  # Export config
   config import my.yaml    # Import config
+  config import my.yaml --dry-run  # Show what importing would change
   config generate          # Generate example config
   config generate -o custom.yaml  # Generate to custom path
+  config generate -o ~/.config/magellai/config.toml  # Generate TOML (or .json)
+  config diff              # Show non-default values and their sources
+  config diff model --all  # Show every model setting and its source
+  config encrypt-keys      # Encrypt keys in the user config with a passphrase
+  config encrypt-keys --keyring  # Encrypt with a key kept in the OS keychain
   config profiles list     # List profiles
   config profiles switch work  # Switch to work profile`,
 		Category: command.CategoryShared,
@@ -134,6 +159,19 @@ Examples:
 				Type:        command.FlagTypeBool,
 				Default:     false,
 			},
+			{
+				Name:        "all",
+				Description: "Include values that match their defaults (diff subcommand)",
+				Type:        command.FlagTypeBool,
+				Default:     false,
+			},
+			{
+				Name:        "keyring",
+				Description: "Encrypt with a key kept in the OS keychain instead of a passphrase (encrypt-keys subcommand)",
+				Type:        command.FlagTypeBool,
+				Default:     false,
+			},
+			command.DryRunFlag,
 		},
 	}
 }
@@ -148,43 +186,29 @@ func (c *ConfigCommand) Validate() error {
 
 // showCurrentConfig displays the current configuration overview
 func (c *ConfigCommand) showCurrentConfig(ctx context.Context, exec *command.ExecutionContext) error {
-	// For tests, let's return simplified output
-	if exec.Data["outputFormat"] != nil || exec.Flags.GetString("format") == "json" {
-		// JSON output
-		info := map[string]interface{}{
-			"provider": c.config.GetDefaultProvider(),
-			"model":    c.config.GetDefaultModel(),
-			"profile":  c.config.GetString("profile.current"),
-		}
-		if info["profile"] == "" {
-			info["profile"] = "default"
-		}
-		jsonBytes, err := json.MarshalIndent(info, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal config to JSON: %w", err)
-		}
-		exec.Data["output"] = string(jsonBytes)
-		return nil
-	}
-
-	// Text format for regular showCurrentConfig
-	var output strings.Builder
-	output.WriteString("Current configuration:\n")
-	output.WriteString(fmt.Sprintf("  Provider: %s\n", c.config.GetDefaultProvider()))
-	output.WriteString(fmt.Sprintf("  Model: %s\n", c.config.GetDefaultModel()))
-
 	profile := c.config.GetString("profile.current")
 	if profile == "" {
 		profile = "default"
 	}
-	output.WriteString(fmt.Sprintf("  Profile: %s\n", profile))
-
-	exec.Data["output"] = output.String()
-
-	// Also write to stdout for interactive use
-	fmt.Fprint(exec.Stdout, output.String())
+	info := map[string]interface{}{
+		"provider": c.config.GetDefaultProvider(),
+		"model":    c.config.GetDefaultModel(),
+		"profile":  profile,
+	}
+	return setFormattedOutput(exec, c.getOutputFormat(exec), command.Result{
+		Text: fmt.Sprintf("Current configuration:\n  Provider: %s\n  Model: %s\n  Profile: %s",
+			info["provider"], info["model"], profile),
+		Value: info,
+	})
+}
 
-	return nil
+// getOutputFormat returns the format set by --format, or the global output
+// format
+func (c *ConfigCommand) getOutputFormat(exec *command.ExecutionContext) command.OutputFormat {
+	if format := exec.Flags.GetString("format"); format != "" {
+		return command.OutputFormat(format)
+	}
+	return outputFormat(exec)
 }
 
 // listConfig lists all configuration settings
@@ -192,12 +216,10 @@ func (c *ConfigCommand) listConfig(ctx context.Context, exec *command.ExecutionC
 	allSettings := c.config.All()
 	logging.LogDebug("ListConfig: Retrieved settings", "count", len(allSettings))
 
-	outputFormat := exec.Flags.GetString("format")
-	if outputFormat == "" {
-		outputFormat = "text"
+	formatted, err := formatSettings(allSettings, c.getOutputFormat(exec))
+	if err != nil {
+		return err
 	}
-
-	formatted := formatSettings(allSettings, outputFormat)
 	logging.LogDebug("ListConfig: Formatted output", "length", len(formatted))
 	exec.Data["output"] = formatted
 	return nil
@@ -217,8 +239,9 @@ func (c *ConfigCommand) getConfig(ctx context.Context, exec *command.ExecutionCo
 		return fmt.Errorf("key not found: %s", key)
 	}
 
-	exec.Data["output"] = fmt.Sprintf("%s: %v", key, value)
-	return nil
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("%s: %v", key, value),
+		map[string]interface{}{"key": key, "value": value})
 }
 
 // setConfig sets a configuration value
@@ -230,8 +253,9 @@ func (c *ConfigCommand) setConfig(ctx context.Context, exec *command.ExecutionCo
 			return fmt.Errorf("failed to set provider: %w", err)
 		}
 		logging.LogInfo("Configuration changed", "key", "provider", "old", previousValue, "new", value)
-		exec.Data["output"] = fmt.Sprintf("Provider set to: %s", value)
-		return nil
+		return setResultOutput(exec, outputFormat(exec),
+			fmt.Sprintf("Provider set to: %s", value),
+			map[string]string{"key": "provider.default", "value": value, "previous": previousValue})
 	}
 
 	if key == "model" {
@@ -251,8 +275,9 @@ func (c *ConfigCommand) setConfig(ctx context.Context, exec *command.ExecutionCo
 			return fmt.Errorf("failed to set model: %w", err)
 		}
 		logging.LogInfo("Configuration changed", "key", "model", "old", previousModel, "new", value)
-		exec.Data["output"] = fmt.Sprintf("Model set to: %s", value)
-		return nil
+		return setResultOutput(exec, outputFormat(exec),
+			fmt.Sprintf("Model set to: %s", value),
+			map[string]string{"key": "model.default", "value": value, "previous": previousModel})
 	}
 
 	// For other keys, use generic set
@@ -261,19 +286,31 @@ func (c *ConfigCommand) setConfig(ctx context.Context, exec *command.ExecutionCo
 		return fmt.Errorf("failed to set value: %w", err)
 	}
 	logging.LogInfo("Configuration changed", "key", key, "old", previousValue, "new", value)
-	exec.Data["output"] = fmt.Sprintf("%s set to: %s", key, value)
-	return nil
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("%s set to: %s", key, value),
+		map[string]string{"key": key, "value": value, "previous": previousValue})
 }
 
 // validateConfig validates the current configuration
 func (c *ConfigCommand) validateConfig(ctx context.Context, exec *command.ExecutionContext) error {
-	err := c.config.Validate()
-	if err != nil {
-		return fmt.Errorf("configuration validation failed: %w", err)
+	if structuredOutputRequested(exec) {
+		// The report goes to stdout even when validation fails, so scripts
+		// can read the problems
+		if problems := c.config.ValidationErrors(); len(problems) > 0 {
+			if exec.Stdout != nil {
+				if err := printOutput(exec, map[string]interface{}{"valid": false, "errors": problems}); err != nil {
+					return fmt.Errorf("failed to encode validation errors: %w", err)
+				}
+			}
+			return fmt.Errorf("%w: %d problems found", config.ErrValidationFailed, len(problems))
+		}
+	}
+	if err := c.config.Validate(); err != nil {
+		return err
 	}
 
-	exec.Data["output"] = "Configuration is valid"
-	return nil
+	return setResultOutput(exec, outputFormat(exec), "Configuration is valid",
+		map[string]bool{"valid": true})
 }
 
 // exportConfig exports the configuration
@@ -304,13 +341,35 @@ func (c *ConfigCommand) exportConfig(ctx context.Context, exec *command.Executio
 
 // importConfig imports configuration from a file
 func (c *ConfigCommand) importConfig(ctx context.Context, exec *command.ExecutionContext, filename string) error {
+	if command.IsDryRun(exec) {
+		values, err := c.config.FileChanges(filename)
+		if err != nil {
+			return fmt.Errorf("import failed: %w", err)
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		changes := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if current := c.config.Get(key); current != nil {
+				changes = append(changes, fmt.Sprintf("set %s: %v -> %v", key, current, values[key]))
+			} else {
+				changes = append(changes, fmt.Sprintf("set %s: %v", key, values[key]))
+			}
+		}
+		return command.ReportDryRun(exec, changes...)
+	}
+
 	err := c.config.LoadFile(filename)
 	if err != nil {
 		return fmt.Errorf("import failed: %w", err)
 	}
 
-	exec.Data["output"] = fmt.Sprintf("Configuration imported from: %s", filename)
-	return nil
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("Configuration imported from: %s", filename),
+		map[string]string{"imported": filename})
 }
 
 // editConfig opens the configuration file in the user's editor
@@ -435,27 +494,24 @@ func (c *ConfigCommand) listProfiles(ctx context.Context, exec *command.Executio
 	// Sort profiles for consistent output
 	sort.Strings(profiles)
 
-	if format, ok := exec.Data["outputFormat"].(string); ok && format == "json" {
-		data := map[string]interface{}{
-			"profiles": profiles,
-			"current":  current,
-		}
-		jsonData, _ := json.MarshalIndent(data, "", "  ")
-		exec.Data["output"] = string(jsonData)
-		return nil
-	}
-
-	var output strings.Builder
-	output.WriteString("Available profiles:\n")
+	lines := []string{"Available profiles:"}
+	table := command.NewTable("NAME", "CURRENT")
 	for _, profile := range profiles {
 		if profile == current {
-			output.WriteString(fmt.Sprintf("  * %s (current)\n", profile))
+			lines = append(lines, fmt.Sprintf("  * %s (current)", profile))
 		} else {
-			output.WriteString(fmt.Sprintf("    %s\n", profile))
+			lines = append(lines, fmt.Sprintf("    %s", profile))
 		}
+		table.AddRow(profile, profile == current)
 	}
-	exec.Data["output"] = output.String()
-	return nil
+	return setOutput(exec, command.Result{
+		Text:  strings.Join(lines, "\n"),
+		Table: table,
+		Value: map[string]interface{}{
+			"profiles": profiles,
+			"current":  current,
+		},
+	})
 }
 
 // switchProfile switches to a different profile
@@ -465,8 +521,9 @@ func (c *ConfigCommand) switchProfile(ctx context.Context, exec *command.Executi
 		return fmt.Errorf("failed to switch profile: %w", err)
 	}
 
-	exec.Data["output"] = fmt.Sprintf("Switched to profile: %s", name)
-	return nil
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("Switched to profile: %s", name),
+		map[string]string{"current": name})
 }
 
 // createProfile creates a new profile
@@ -482,8 +539,9 @@ func (c *ConfigCommand) createProfile(ctx context.Context, exec *command.Executi
 		return fmt.Errorf("failed to create profile: %w", err)
 	}
 
-	exec.Data["output"] = fmt.Sprintf("Created profile: %s", name)
-	return nil
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("Created profile: %s", name),
+		map[string]string{"created": name})
 }
 
 // deleteProfile deletes a profile
@@ -509,8 +567,9 @@ func (c *ConfigCommand) deleteProfile(ctx context.Context, exec *command.Executi
 		return fmt.Errorf("failed to delete profile: %w", err)
 	}
 
-	exec.Data["output"] = fmt.Sprintf("Deleted profile: %s", name)
-	return nil
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("Deleted profile: %s", name),
+		map[string]string{"deleted": name})
 }
 
 // exportProfile exports a specific profile
@@ -520,37 +579,20 @@ func (c *ConfigCommand) exportProfile(ctx context.Context, exec *command.Executi
 		return fmt.Errorf("failed to export profile: %w", err)
 	}
 
-	var config []byte
-	// All profile exports currently use JSON marshaling.
-	// YAML marshaling will be added in a future release.
-	config, err = json.MarshalIndent(profile, "", "  ")
-
-	if err != nil {
-		return fmt.Errorf("failed to marshal profile: %w", err)
-	}
-
-	exec.Data["output"] = string(config)
-	return nil
+	// Profile exports are JSON whatever the output format
+	return setFormattedOutput(exec, command.OutputFormatJSON, profile)
 }
 
-// formatSettings formats all settings for display
-func formatSettings(settings map[string]interface{}, format string) string {
-	switch format {
-	case "json":
-		data, _ := json.MarshalIndent(settings, "", "  ")
-		return string(data)
-	case "yaml":
-		// Simple YAML-like formatting
-		var output strings.Builder
-		formatValue(&output, settings, 0)
-		return output.String()
-	default:
-		// Text format
-		var output strings.Builder
-		output.WriteString("Configuration settings:\n")
-		formatSettingsText(&output, settings, "  ")
-		return output.String()
-	}
+// formatSettings formats all settings for display: nested lines in the
+// text format, and the settings themselves in the structured formats
+func formatSettings(settings map[string]interface{}, format command.OutputFormat) (string, error) {
+	var text strings.Builder
+	text.WriteString("Configuration settings:\n")
+	formatSettingsText(&text, settings, "  ")
+	return command.NewFormatter(format).Format(command.Result{
+		Text:  strings.TrimRight(text.String(), "\n"),
+		Value: settings,
+	})
 }
 
 // formatSettingsText recursively formats settings as text
@@ -578,28 +620,6 @@ func formatSettingsText(output *strings.Builder, settings interface{}, indent st
 	}
 }
 
-// formatValue recursively formats values in YAML-like style
-func formatValue(output *strings.Builder, value interface{}, indent int) {
-	indentStr := strings.Repeat("  ", indent)
-
-	switch v := value.(type) {
-	case map[string]interface{}:
-		if indent > 0 {
-			output.WriteString("\n")
-		}
-		for k, val := range v {
-			output.WriteString(fmt.Sprintf("%s%s:", indentStr, k))
-			if _, isMap := val.(map[string]interface{}); isMap {
-				formatValue(output, val, indent+1)
-			} else {
-				output.WriteString(fmt.Sprintf(" %v\n", val))
-			}
-		}
-	default:
-		output.WriteString(fmt.Sprintf(" %v\n", v))
-	}
-}
-
 // generateConfig generates an example configuration file
 func (c *ConfigCommand) generateConfig(ctx context.Context, exec *command.ExecutionContext) error {
 	// Get the output path from flags or use default
@@ -625,11 +645,19 @@ func (c *ConfigCommand) generateConfig(ctx context.Context, exec *command.Execut
 		return fmt.Errorf("config file already exists at %s. Use --force to overwrite", outputPath)
 	}
 
-	// Generate example config
-	configContent := config.GenerateExampleConfig()
+	// Generate example config. TOML and JSON files get the defaults without
+	// the YAML example's comments.
+	configContent := []byte(config.GenerateExampleConfig())
+	if ext := strings.ToLower(filepath.Ext(outputPath)); ext == ".toml" || ext == ".json" {
+		data, err := config.Encode(outputPath, config.GetCompleteDefaultConfig())
+		if err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+		configContent = data
+	}
 
 	// Write to file
-	if err := os.WriteFile(outputPath, []byte(configContent), 0644); err != nil {
+	if err := os.WriteFile(outputPath, configContent, 0644); err != nil {
 		logging.LogError(err, "Failed to write config file", "path", outputPath)
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
@@ -649,3 +677,133 @@ func (c *ConfigCommand) generateConfig(ctx context.Context, exec *command.Execut
 
 	return nil
 }
+
+// diffConfig shows the effective values that differ from the defaults, or
+// all values with --all, and the layer each one came from
+func (c *ConfigCommand) diffConfig(exec *command.ExecutionContext, prefix string) error {
+	var origins []config.ValueOrigin
+	for _, origin := range c.config.Origins(exec.Flags.GetBool("all")) {
+		if prefix != "" && origin.Key != prefix && !strings.HasPrefix(origin.Key, prefix+".") {
+			continue
+		}
+		if config.IsSensitiveKey(origin.Key) {
+			origin.Value, origin.Default = maskSecret(origin.Value), maskSecret(origin.Default)
+		}
+		origins = append(origins, origin)
+	}
+
+	if structuredOutputRequested(exec) {
+		if origins == nil {
+			origins = []config.ValueOrigin{}
+		}
+		return setOutput(exec, origins)
+	}
+	if len(origins) == 0 {
+		return setOutput(exec, "No settings differ from the defaults")
+	}
+
+	table := command.NewTable("KEY", "VALUE", "DEFAULT", "SOURCE")
+	for _, origin := range origins {
+		def := "(none)"
+		if origin.HasDefault {
+			def = diffValue(origin.Default)
+		}
+		table.AddRow(origin.Key, diffValue(origin.Value), def, origin.Source)
+	}
+	return setOutput(exec, command.Result{Table: table, Value: origins})
+}
+
+// diffValue formats a config value for the diff table
+func diffValue(value interface{}) string {
+	const maxWidth = 40
+	s := fmt.Sprintf("%v", value)
+	if str, ok := value.(string); ok {
+		s = fmt.Sprintf("%q", str)
+	}
+	if len(s) > maxWidth {
+		s = s[:maxWidth-3] + "..."
+	}
+	return s
+}
+
+// maskSecret hides all but the ends of a non-empty secret value
+func maskSecret(value interface{}) interface{} {
+	if s, ok := value.(string); ok && s != "" {
+		return llm.SanitizeAPIKey(s)
+	}
+	return value
+}
+
+// encryptKeys encrypts the API keys and tokens in a config file with a
+// passphrase or a key in the OS keychain
+func (c *ConfigCommand) encryptKeys(exec *command.ExecutionContext, path string) error {
+	var enc *secrets.Encrypter
+	var err error
+	if exec.Flags.GetBool("keyring") {
+		enc, err = secrets.NewKeyringEncrypter()
+	} else {
+		var passphrase string
+		if passphrase, err = readPassphrase(exec); err != nil {
+			return err
+		}
+		enc, err = secrets.NewPassphraseEncrypter(passphrase)
+	}
+	if err != nil {
+		return fmt.Errorf("config encrypt-keys: %w", err)
+	}
+
+	keys, err := config.EncryptKeys(path, enc)
+	if err != nil {
+		return fmt.Errorf("config encrypt-keys: %w", err)
+	}
+
+	if keys == nil {
+		keys = []string{}
+	}
+	lines := []string{fmt.Sprintf("No plaintext keys to encrypt in %s", path)}
+	if len(keys) > 0 {
+		lines = []string{fmt.Sprintf("Encrypted %d key(s) in %s:", len(keys), path)}
+		for _, key := range keys {
+			lines = append(lines, "  "+key)
+		}
+		if enc.Method() == secrets.MethodPassphrase {
+			lines = append(lines, fmt.Sprintf("Set %s to the passphrase so the keys can be decrypted", config.EnvConfigPassphrase))
+		} else {
+			lines = append(lines, fmt.Sprintf("The encryption key is stored in %s", keyring.Name()))
+		}
+	}
+	return setOutput(exec, command.Result{
+		Text: strings.Join(lines, "\n"),
+		Value: map[string]interface{}{
+			"file":   path,
+			"method": enc.Method(),
+			"keys":   keys,
+		},
+	})
+}
+
+// readPassphrase returns the config passphrase from the environment, or
+// prompts for it, twice in a terminal
+func readPassphrase(exec *command.ExecutionContext) (string, error) {
+	if passphrase := os.Getenv(config.EnvConfigPassphrase); passphrase != "" {
+		return passphrase, nil
+	}
+	prompter := command.PrompterFor(exec)
+	passphrase, err := prompter.Secret("Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("config encrypt-keys: %w - passphrase required (or use --keyring)", command.ErrMissingArgument)
+	}
+	if prompter.Interactive() {
+		confirm, err := prompter.Secret("Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if confirm != passphrase {
+			return "", fmt.Errorf("config encrypt-keys: %w - passphrases do not match", command.ErrInvalidArguments)
+		}
+	}
+	return passphrase, nil
+}
@@ -6,10 +6,15 @@ package core
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/lexlapax/magellai/pkg/command"
 	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/keyring"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -32,7 +37,7 @@ func TestConfigCommand_Execute(t *testing.T) {
 				require.NoError(t, c.SetDefaultProvider("openai"))
 				require.NoError(t, c.SetDefaultModel("gpt-4"))
 			},
-			expectedOutput: "Current configuration:\n  Provider: openai\n  Model: gpt-4\n  Profile: default\n",
+			expectedOutput: "Current configuration:\n  Provider: openai\n  Model: gpt-4\n  Profile: default",
 		},
 		{
 			name:           "show current config JSON",
@@ -63,6 +68,21 @@ func TestConfigCommand_Execute(t *testing.T) {
 			},
 			expectedOutput: "provider.default: openai",
 		},
+		{
+			name: "get key JSON",
+			args: []string{"get", "provider"},
+			setupConfig: func(c *config.Config) {
+				require.NoError(t, c.SetDefaultProvider("openai"))
+			},
+			outputFormat:   "json",
+			expectedOutput: "{\n  \"key\": \"provider.default\",\n  \"value\": \"openai\"\n}",
+		},
+		{
+			name:           "list all config with global JSON output",
+			args:           []string{"list"},
+			outputFormat:   "json",
+			expectedOutput: "{",
+		},
 		{
 			name:          "get missing key",
 			args:          []string{"get", "nonexistent"},
@@ -247,6 +267,92 @@ func TestConfigCommand_Execute(t *testing.T) {
 	}
 }
 
+func TestConfigCommand_ValidateReport(t *testing.T) {
+	cfg := createTestConfig(t)
+	require.NoError(t, cfg.SetValue("log.level", "inf"))
+	cmd := NewConfigCommand(cfg)
+
+	// Text output lists each problem in the error
+	exec := &command.ExecutionContext{Args: []string{"validate"}, Flags: command.NewFlags(nil), Data: map[string]interface{}{}}
+	err := cmd.Execute(context.Background(), exec)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, config.ErrValidationFailed)
+	assert.Contains(t, err.Error(), `log.level: invalid log level, must be one of: [debug info warn error]; did you mean "info"?`)
+
+	// JSON output reports the problems on stdout
+	var stdout bytes.Buffer
+	exec = &command.ExecutionContext{Args: []string{"validate"}, Flags: command.NewFlags(nil), Stdout: &stdout,
+		Data: map[string]interface{}{"outputFormat": "json"}}
+	err = cmd.Execute(context.Background(), exec)
+	assert.ErrorIs(t, err, config.ErrValidationFailed)
+
+	var report struct {
+		Valid  bool                     `json:"valid"`
+		Errors []config.ValidationError `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	assert.False(t, report.Valid)
+	var levelErr config.ValidationError
+	for _, verr := range report.Errors {
+		if verr.Field == "log.level" {
+			levelErr = verr
+		}
+	}
+	assert.Equal(t, "info", levelErr.Suggestion)
+	assert.Equal(t, []string{"debug", "info", "warn", "error"}, levelErr.Allowed)
+}
+
+func TestConfigCommand_EncryptKeys(t *testing.T) {
+	cfg := createTestConfig(t)
+	defer keyring.SetBackend(keyring.NewMemoryBackend())()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("provider:\n  openai:\n    api_key: sk-openai\n"), 0600))
+	cmd := NewConfigCommand(cfg)
+
+	exec := &command.ExecutionContext{Args: []string{"encrypt-keys", path}, Flags: command.NewFlags(map[string]interface{}{"keyring": true}),
+		Data: map[string]interface{}{}}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	assert.Contains(t, exec.Data["output"], "Encrypted 1 key(s)")
+	assert.Contains(t, exec.Data["output"], "provider.openai.api_key")
+
+	// A passphrase is read from stdin when not set in the environment
+	t.Setenv(config.EnvConfigPassphrase, "")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  token: bearer\n"), 0600))
+	exec = &command.ExecutionContext{Args: []string{"encrypt-keys", path}, Flags: command.NewFlags(nil),
+		Stdin: strings.NewReader("correct horse\n"), Data: map[string]interface{}{"outputFormat": "json"}}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(exec.Data["output"].(string)), &result))
+	assert.Equal(t, "passphrase", result["method"])
+	assert.Equal(t, []interface{}{"server.token"}, result["keys"])
+}
+
+func TestConfigCommand_Diff(t *testing.T) {
+	cfg := createTestConfig(t)
+	require.NoError(t, cfg.SetValue("provider.openai.api_key", "sk-abcdefghijklmnopqrstuvwxyz"))
+	cmd := NewConfigCommand(cfg)
+
+	exec := &command.ExecutionContext{Args: []string{"diff"}, Flags: command.NewFlags(nil), Data: map[string]interface{}{}}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	output := exec.Data["output"].(string)
+	assert.Contains(t, output, "KEY")
+	assert.Contains(t, output, "SOURCE")
+	assert.Regexp(t, `provider.openai.api_key\s+"sk-abc...wxyz"\s+""\s+flag`, output)
+	assert.NotContains(t, output, "sk-abcdefghijklmnopqrstuvwxyz", "secrets are masked")
+	assert.NotContains(t, output, "log.level")
+
+	// A prefix limits the keys, and --all includes default values
+	exec = &command.ExecutionContext{Args: []string{"diff", "log"}, Flags: command.NewFlags(map[string]interface{}{"all": true}),
+		Data: map[string]interface{}{"outputFormat": "json"}}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	var origins []config.ValueOrigin
+	require.NoError(t, json.Unmarshal([]byte(exec.Data["output"].(string)), &origins))
+	require.NotEmpty(t, origins)
+	for _, origin := range origins {
+		assert.Contains(t, origin.Key, "log.")
+	}
+}
+
 func TestConfigCommand_Metadata(t *testing.T) {
 	cmd := NewConfigCommand(nil)
 	meta := cmd.Metadata()
@@ -263,10 +369,12 @@ func TestConfigCommand_Metadata(t *testing.T) {
 	assert.Contains(t, meta.LongDescription, "export")
 	assert.Contains(t, meta.LongDescription, "import")
 	assert.Contains(t, meta.LongDescription, "generate")
+	assert.Contains(t, meta.LongDescription, "diff")
+	assert.Contains(t, meta.LongDescription, "encrypt-keys")
 	assert.Contains(t, meta.LongDescription, "profiles")
 
 	// Check flags
-	assert.Len(t, meta.Flags, 3)
+	assert.Len(t, meta.Flags, 6)
 
 	// Check format flag
 	formatFlag := meta.Flags[0]
@@ -287,6 +395,16 @@ func TestConfigCommand_Metadata(t *testing.T) {
 	assert.Equal(t, "force", forceFlag.Name)
 	assert.Equal(t, command.FlagTypeBool, forceFlag.Type)
 	assert.Equal(t, false, forceFlag.Default)
+
+	// Check all flag
+	allFlag := meta.Flags[3]
+	assert.Equal(t, "all", allFlag.Name)
+	assert.Equal(t, command.FlagTypeBool, allFlag.Type)
+
+	// Check keyring flag
+	keyringFlag := meta.Flags[4]
+	assert.Equal(t, "keyring", keyringFlag.Name)
+	assert.Equal(t, command.FlagTypeBool, keyringFlag.Type)
 }
 
 func TestConfigCommand_Validate(t *testing.T) {
@@ -450,7 +568,8 @@ func TestConfigCommand_FormatSettings(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			output := formatSettings(settings, tt.format)
+			output, err := formatSettings(settings, command.OutputFormat(tt.format))
+			require.NoError(t, err)
 			for _, expected := range tt.expectedOutput {
 				assert.Contains(t, output, expected)
 			}
@@ -491,7 +610,35 @@ func TestConfigCommand_ImportExport(t *testing.T) {
 	assert.Contains(t, err.Error(), "import failed")
 }
 
+func TestConfigCommand_ImportDryRun(t *testing.T) {
+	manager := createTestConfig(t)
+	require.NoError(t, manager.SetDefaultModel("openai/gpt-4o"))
+	cmd := NewConfigCommand(manager)
+
+	file := filepath.Join(t.TempDir(), "import.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("model:\n  default: anthropic/claude-3\ndry_run_test: true\n"), 0644))
+
+	var output bytes.Buffer
+	exec := &command.ExecutionContext{
+		Args:   []string{"import", file},
+		Flags:  command.NewFlags(map[string]interface{}{command.FlagDryRun: true}),
+		Stdout: &output,
+		Data:   make(map[string]interface{}),
+	}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	assert.Contains(t, output.String(), "Would set dry_run_test: true")
+	assert.Contains(t, output.String(), "Would set model.default: openai/gpt-4o -> anthropic/claude-3")
+	assert.Equal(t, "openai/gpt-4o", manager.GetDefaultModel())
+	assert.False(t, manager.Exists("dry_run_test"))
+
+	exec.Args = []string{"set", "model.default", "x"}
+	assert.ErrorIs(t, cmd.Execute(context.Background(), exec), command.ErrDryRunUnsupported)
+}
+
 func createTestConfig(t *testing.T) *config.Config {
+	// Keep persisted values out of the real user config file
+	t.Setenv("HOME", t.TempDir())
+
 	// Initialize config for testing
 	err := config.Init()
 	require.NoError(t, err)
@@ -0,0 +1,171 @@
+// ABOUTME: Docs command - Generates man pages and markdown reference from the command tree
+// ABOUTME: Combines the CLI parser model with registry descriptions so docs match real flags
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/docgen"
+)
+
+// DocsCommand implements reference documentation generation
+type DocsCommand struct {
+	registry *command.Registry
+	root     *docgen.Command
+	version  string
+}
+
+// NewDocsCommand creates a docs command for a command tree built from the
+// CLI parser
+func NewDocsCommand(registry *command.Registry, root *docgen.Command, version string) *DocsCommand {
+	return &DocsCommand{
+		registry: registry,
+		root:     root,
+		version:  version,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *DocsCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "docs",
+		Description: "Generate man pages or markdown reference docs",
+		LongDescription: `The docs command generates reference documentation from the commands,
+arguments, and flags the CLI actually accepts, so docs can't drift from the
+binary.
+
+Subcommands:
+  generate   Write the reference to stdout, or one page per command to --output-dir
+
+Examples:
+  magellai docs generate --format markdown > docs/cli.md
+  magellai docs generate --format man --output-dir ./man`,
+		Category: command.CategoryCLI,
+		Flags: []command.Flag{
+			{
+				Name:        "format",
+				Description: "Docs format (man|markdown)",
+				Type:        command.FlagTypeString,
+				Default:     string(docgen.FormatMarkdown),
+			},
+			{
+				Name:        "output-dir",
+				Description: "Write one page per command into this directory",
+				Type:        command.FlagTypeString,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *DocsCommand) Validate() error {
+	if c.root == nil {
+		return fmt.Errorf("command tree not initialized")
+	}
+	return nil
+}
+
+// Execute runs the docs command
+func (c *DocsCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+
+	if len(exec.Args) == 0 || exec.Args[0] != "generate" {
+		return fmt.Errorf("docs: %w - expected subcommand 'generate'", command.ErrInvalidArguments)
+	}
+
+	formatName := exec.Flags.GetString("format")
+	if formatName == "" {
+		formatName = string(docgen.FormatMarkdown)
+	}
+	format, err := docgen.ParseFormat(formatName)
+	if err != nil {
+		return fmt.Errorf("docs generate: %w - %v", command.ErrInvalidArguments, err)
+	}
+
+	c.addRegistryDetails()
+
+	outputDir := exec.Flags.GetString("output-dir")
+	if outputDir == "" {
+		return c.render(exec.Stdout, format, c.root)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create docs directory: %w", err)
+	}
+	ext := ".md"
+	if format == docgen.FormatMan {
+		ext = ".1"
+	}
+
+	var files []string
+	for _, page := range docgen.Pages(c.root) {
+		var buf bytes.Buffer
+		if err := c.render(&buf, format, page); err != nil {
+			return err
+		}
+		path := filepath.Join(outputDir, page.FileName()+ext)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		files = append(files, path)
+	}
+	logging.LogInfo("Generated docs", "format", format, "dir", outputDir, "pages", len(files))
+
+	exec.Data["files"] = files
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("Wrote %d %s page(s) to %s", len(files), format, outputDir),
+		map[string]interface{}{"format": format, "files": files})
+}
+
+// render writes one page in the given format
+func (c *DocsCommand) render(w io.Writer, format docgen.Format, cmd *docgen.Command) error {
+	if format == docgen.FormatMan {
+		return docgen.RenderMan(w, cmd, docgen.ManOptions{Version: c.version, Date: docsDate()})
+	}
+	return docgen.RenderMarkdown(w, cmd)
+}
+
+// addRegistryDetails fills in long descriptions for top-level commands from
+// their registered metadata, which the parser model does not carry
+func (c *DocsCommand) addRegistryDetails() {
+	if c.registry == nil {
+		return
+	}
+	registered := make(map[string]bool)
+	for _, name := range c.registry.Names() {
+		registered[name] = true
+	}
+	for _, sub := range c.root.Subcommands {
+		name := strings.TrimSpace(strings.TrimPrefix(sub.Name, c.root.Name))
+		if sub.Detail != "" || !registered[name] {
+			continue
+		}
+		cmd, err := c.registry.Get(name)
+		if err != nil {
+			continue
+		}
+		sub.Detail = cmd.Metadata().LongDescription
+	}
+}
+
+// docsDate returns the man page date, honoring SOURCE_DATE_EPOCH for
+// reproducible builds
+func docsDate() string {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC().Format("2006-01-02")
+	}
+	return time.Now().Format("2006-01-02")
+}
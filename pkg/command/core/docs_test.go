@@ -0,0 +1,77 @@
+// ABOUTME: Tests for the docs generation command
+// ABOUTME: Verifies stdout output, per-command pages, and registry descriptions
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/docgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocsCommand_Execute(t *testing.T) {
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(NewVersionCommand("1.0", "abc", "today")))
+
+	newCommand := func() *DocsCommand {
+		root := &docgen.Command{
+			Name:  "magellai",
+			Usage: "magellai <command>",
+			Help:  "LLM CLI",
+			Subcommands: []*docgen.Command{
+				{Name: "magellai version", Usage: "magellai version", Help: "Show version information"},
+				{Name: "magellai other", Usage: "magellai other", Help: "Not registered"},
+			},
+		}
+		return NewDocsCommand(registry, root, "1.0")
+	}
+
+	run := func(cmd *DocsCommand, args []string, flags map[string]interface{}) (*command.ExecutionContext, string, error) {
+		var stdout bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdout: &stdout,
+		}
+		err := cmd.Execute(context.Background(), exec)
+		return exec, stdout.String(), err
+	}
+
+	t.Run("markdown to stdout with registry details", func(t *testing.T) {
+		cmd := newCommand()
+		require.NoError(t, cmd.Validate())
+		_, out, err := run(cmd, []string{"generate"}, nil)
+		require.NoError(t, err)
+		assert.Contains(t, out, "# magellai\n")
+		assert.Contains(t, out, "## magellai version\n")
+		assert.Contains(t, out, NewVersionCommand("", "", "").Metadata().LongDescription)
+	})
+
+	t.Run("man pages into a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		exec, _, err := run(newCommand(), []string{"generate"}, map[string]interface{}{"format": "man", "output-dir": dir})
+		require.NoError(t, err)
+		assert.Equal(t, "Wrote 3 man page(s) to "+dir, exec.Data["output"])
+
+		for _, name := range []string{"magellai.1", "magellai-version.1", "magellai-other.1"} {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			require.NoError(t, err)
+			assert.Contains(t, string(data), ".TH ")
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, _, err := run(newCommand(), nil, nil)
+		assert.ErrorIs(t, err, command.ErrInvalidArguments)
+
+		_, _, err = run(newCommand(), []string{"generate"}, map[string]interface{}{"format": "html"})
+		assert.ErrorIs(t, err, command.ErrInvalidArguments)
+	})
+}
@@ -0,0 +1,426 @@
+// ABOUTME: Doctor command - Diagnoses common setup problems
+// ABOUTME: Checks config, API keys, session storage, terminal, model registry, and file permissions
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/configdir"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/keyring"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
+	"github.com/lexlapax/magellai/pkg/ui"
+)
+
+// Doctor finding statuses
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+// modelRegistryMaxAge is how old models.json can get before doctor suggests updating it
+const modelRegistryMaxAge = 90 * 24 * time.Hour
+
+// doctorFinding is the result of a single diagnostic check
+type doctorFinding struct {
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+// DoctorCommand implements setup diagnostics
+type DoctorCommand struct {
+	config *config.Config
+}
+
+// NewDoctorCommand creates a new doctor command instance
+func NewDoctorCommand(cfg *config.Config) *DoctorCommand {
+	return &DoctorCommand{
+		config: cfg,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *DoctorCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "doctor",
+		Description: "Diagnose configuration and environment problems",
+		LongDescription: `The doctor command checks your setup and suggests fixes:
+
+  config       Configuration file is present and valid
+  api-keys     API keys are available (keychain, environment, or config)
+  storage      Session storage backend can be opened and listed
+  terminal     Terminal capabilities for line editing and color
+  models       Model registry (models.json) is present and recent
+  permissions  Config and session directories are writable, and files
+               holding API keys are not readable by other users
+
+The command exits with an error when any check fails.
+
+Examples:
+  magellai doctor
+  magellai --output json doctor`,
+		Category: command.CategoryCLI,
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *DoctorCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	return nil
+}
+
+// Execute runs all checks and prints the findings
+func (c *DoctorCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+
+	var findings []doctorFinding
+	findings = append(findings, c.checkConfig()...)
+	findings = append(findings, c.checkAPIKeys(ctx)...)
+	findings = append(findings, c.checkStorage(exec)...)
+	findings = append(findings, c.checkTerminal()...)
+	findings = append(findings, c.checkModelRegistry()...)
+	findings = append(findings, c.checkPermissions()...)
+
+	failed := 0
+	for _, f := range findings {
+		if f.Status == doctorFail {
+			failed++
+		}
+	}
+	logging.LogInfo("Doctor checks completed", "findings", len(findings), "failed", failed)
+	exec.Data["findings"] = findings
+
+	if structuredOutputRequested(exec) {
+		if err := printOutput(exec, map[string]interface{}{
+			"findings": findings,
+			"ok":       failed == 0,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printFindings(exec, findings)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("doctor: %d check(s) failed", failed)
+	}
+	return nil
+}
+
+// printFindings writes findings as a checklist with suggested fixes
+func printFindings(exec *command.ExecutionContext, findings []doctorFinding) {
+	for _, f := range findings {
+		mark := "✓"
+		switch f.Status {
+		case doctorWarn:
+			mark = "!"
+		case doctorFail:
+			mark = "✗"
+		}
+		fmt.Fprintf(exec.Stdout, "%s %-12s %s\n", mark, f.Check, f.Message)
+		if f.Fix != "" {
+			fmt.Fprintf(exec.Stdout, "  %-12s → %s\n", "", f.Fix)
+		}
+	}
+}
+
+// checkConfig verifies the configuration file exists and validates
+func (c *DoctorCommand) checkConfig() []doctorFinding {
+	var findings []doctorFinding
+
+	if file := c.config.GetPrimaryConfigFile(); file == "" {
+		findings = append(findings, doctorFinding{
+			Check:   "config",
+			Status:  doctorWarn,
+			Message: "no configuration file found, using defaults",
+			Fix:     "run 'magellai config generate' to create one",
+		})
+	} else {
+		findings = append(findings, doctorFinding{
+			Check:   "config",
+			Status:  doctorOK,
+			Message: fmt.Sprintf("using %s", file),
+		})
+	}
+
+	invalid := 0
+	for _, verr := range c.config.ValidationErrors() {
+		// Missing API keys are reported by the api-keys check
+		if strings.HasSuffix(verr.Field, ".api_key") {
+			continue
+		}
+		invalid++
+		fix := fmt.Sprintf("correct %s with 'magellai config set %s <value>'", verr.Field, verr.Field)
+		if verr.File != "" {
+			fix = fmt.Sprintf("edit %s", verr.File)
+		}
+		findings = append(findings, doctorFinding{
+			Check:   "config",
+			Status:  doctorFail,
+			Message: verr.String(),
+			Fix:     fix,
+		})
+	}
+	if invalid == 0 {
+		findings = append(findings, doctorFinding{
+			Check:   "config",
+			Status:  doctorOK,
+			Message: "configuration is valid",
+		})
+	}
+	return findings
+}
+
+// apiKeyEnvVars maps cloud providers to their standard API key variables
+var apiKeyEnvVars = map[string]string{
+	llm.ProviderOpenAI:    config.EnvOpenAIKey,
+	llm.ProviderAnthropic: config.EnvAnthropicKey,
+	llm.ProviderGemini:    config.EnvGeminiKey,
+}
+
+// checkAPIKeys reports which providers have API keys. A missing key is only
+// a failure for the default provider.
+func (c *DoctorCommand) checkAPIKeys(ctx context.Context) []doctorFinding {
+	defaultProvider := c.config.GetDefaultProvider()
+
+	var findings []doctorFinding
+	for _, provider := range []string{llm.ProviderOpenAI, llm.ProviderAnthropic, llm.ProviderGemini} {
+		envVar := apiKeyEnvVars[provider]
+		source := ""
+		switch {
+		case keyringHasKey(provider):
+			source = "keychain"
+		case os.Getenv(envVar) != "":
+			source = envVar
+		case c.config.GetProviderAPIKeyContext(ctx, provider) != "":
+			source = "config"
+		}
+
+		if source != "" {
+			findings = append(findings, doctorFinding{
+				Check:   "api-keys",
+				Status:  doctorOK,
+				Message: fmt.Sprintf("%s key found (%s)", provider, source),
+			})
+			continue
+		}
+
+		status := doctorWarn
+		if provider == defaultProvider {
+			status = doctorFail
+		}
+		findings = append(findings, doctorFinding{
+			Check:   "api-keys",
+			Status:  status,
+			Message: fmt.Sprintf("no API key for %s", provider),
+			Fix:     fmt.Sprintf("run 'magellai keys set %s' or export %s", provider, envVar),
+		})
+	}
+	return findings
+}
+
+// keyringHasKey reports whether the OS keychain holds a key for provider
+func keyringHasKey(provider string) bool {
+	key, err := keyring.Get(provider)
+	return err == nil && key != ""
+}
+
+// checkStorage opens the session backend and lists sessions
+func (c *DoctorCommand) checkStorage(exec *command.ExecutionContext) []doctorFinding {
+	backend := "filesystem"
+	if c.config.Exists("session.storage.type") {
+		backend = c.config.GetString("session.storage.type")
+	}
+
+	manager, err := openSessionManager(c.config, exec)
+	if err != nil {
+		return []doctorFinding{{
+			Check:   "storage",
+			Status:  doctorFail,
+			Message: fmt.Sprintf("%s backend unavailable: %v", backend, err),
+			Fix:     "check session.storage.type and session.storage.settings",
+		}}
+	}
+	if _, ok := exec.Data["session_manager"]; !ok {
+		defer func() {
+			if err := manager.Close(); err != nil {
+				logging.LogWarn("Failed to close session storage", "error", err)
+			}
+		}()
+	}
+
+	sessions, err := manager.ListSessions()
+	if err != nil {
+		return []doctorFinding{{
+			Check:   "storage",
+			Status:  doctorFail,
+			Message: fmt.Sprintf("%s backend cannot list sessions: %v", backend, err),
+			Fix:     "check that the session storage location is readable",
+		}}
+	}
+	return []doctorFinding{{
+		Check:   "storage",
+		Status:  doctorOK,
+		Message: fmt.Sprintf("%s backend healthy (%d sessions)", backend, len(sessions)),
+	}}
+}
+
+// checkTerminal reports whether interactive line editing and color are available
+func (c *DoctorCommand) checkTerminal() []doctorFinding {
+	if !ui.IsTerminal() {
+		return []doctorFinding{{
+			Check:   "terminal",
+			Status:  doctorWarn,
+			Message: "stdin is not a terminal; chat runs without line editing",
+			Fix:     "run magellai chat from an interactive terminal",
+		}}
+	}
+
+	term := os.Getenv("TERM")
+	switch {
+	case term == "" || term == "dumb":
+		return []doctorFinding{{
+			Check:   "terminal",
+			Status:  doctorWarn,
+			Message: fmt.Sprintf("TERM=%q limits line editing and color", term),
+			Fix:     "set TERM, for example TERM=xterm-256color",
+		}}
+	case os.Getenv("NO_COLOR") != "":
+		return []doctorFinding{{
+			Check:   "terminal",
+			Status:  doctorOK,
+			Message: fmt.Sprintf("interactive terminal (TERM=%s), color disabled by NO_COLOR", term),
+		}}
+	default:
+		return []doctorFinding{{
+			Check:   "terminal",
+			Status:  doctorOK,
+			Message: fmt.Sprintf("interactive terminal (TERM=%s)", term),
+		}}
+	}
+}
+
+// checkModelRegistry verifies models.json can be loaded and is recent
+func (c *DoctorCommand) checkModelRegistry() []doctorFinding {
+	inventory, err := models.LoadDefaultInventory()
+	if err != nil {
+		return []doctorFinding{{
+			Check:   "models",
+			Status:  doctorWarn,
+			Message: fmt.Sprintf("model registry unavailable: %v", err),
+			Fix:     fmt.Sprintf("install models.json next to the binary or set %s", models.InventoryEnvVar),
+		}}
+	}
+
+	updated, err := inventory.GetLastUpdated()
+	if err != nil {
+		return []doctorFinding{{
+			Check:   "models",
+			Status:  doctorWarn,
+			Message: fmt.Sprintf("model registry has no valid last_updated date (%q)", inventory.Metadata.LastUpdated),
+		}}
+	}
+
+	age := time.Since(updated)
+	if age > modelRegistryMaxAge {
+		return []doctorFinding{{
+			Check:   "models",
+			Status:  doctorWarn,
+			Message: fmt.Sprintf("model registry last updated %s (%d days ago)", updated.Format("2006-01-02"), int(age.Hours()/24)),
+			Fix:     "update models.json to pick up new models and pricing",
+		}}
+	}
+	return []doctorFinding{{
+		Check:   "models",
+		Status:  doctorOK,
+		Message: fmt.Sprintf("model registry updated %s (%d models)", updated.Format("2006-01-02"), len(inventory.Models)),
+	}}
+}
+
+// checkPermissions verifies directories are writable and that config files
+// with API keys are private
+func (c *DoctorCommand) checkPermissions() []doctorFinding {
+	paths, err := configdir.GetPaths()
+	if err != nil {
+		return []doctorFinding{{
+			Check:   "permissions",
+			Status:  doctorFail,
+			Message: err.Error(),
+		}}
+	}
+
+	var findings []doctorFinding
+	for _, dir := range []string{paths.Base, paths.Sessions} {
+		if err := checkWritableDir(dir); err != nil {
+			findings = append(findings, doctorFinding{
+				Check:   "permissions",
+				Status:  doctorFail,
+				Message: err.Error(),
+				Fix:     fmt.Sprintf("mkdir -p %s && chmod u+rwx %s", dir, dir),
+			})
+		}
+	}
+
+	if file := c.config.GetPrimaryConfigFile(); file != "" && c.configHasAPIKeys() {
+		if info, err := os.Stat(file); err == nil && info.Mode().Perm()&0o077 != 0 {
+			findings = append(findings, doctorFinding{
+				Check:   "permissions",
+				Status:  doctorWarn,
+				Message: fmt.Sprintf("%s contains API keys and is readable by others (%s)", file, info.Mode().Perm()),
+				Fix:     fmt.Sprintf("chmod 600 %s", file),
+			})
+		}
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, doctorFinding{
+			Check:   "permissions",
+			Status:  doctorOK,
+			Message: fmt.Sprintf("%s is writable", paths.Base),
+		})
+	}
+	return findings
+}
+
+// configHasAPIKeys reports whether any provider API key is set in the config
+func (c *DoctorCommand) configHasAPIKeys() bool {
+	for key, value := range c.config.All() {
+		if strings.HasSuffix(key, ".api_key") && fmt.Sprint(value) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWritableDir reports an error unless dir exists and a file can be created in it
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("%s is missing", dir)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	probe, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable", dir)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
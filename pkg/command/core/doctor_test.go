@@ -0,0 +1,89 @@
+// ABOUTME: Tests for the doctor diagnostics command
+// ABOUTME: Verifies findings for API keys, storage, directories, and JSON output
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/lexlapax/magellai/internal/configdir"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctorCommand_Execute(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+	cfg := createTestConfig(t)
+
+	run := func(data map[string]interface{}) (string, []doctorFinding, error) {
+		var stdout bytes.Buffer
+		exec := &command.ExecutionContext{
+			Flags:  command.NewFlags(nil),
+			Stdout: &stdout,
+			Stderr: &bytes.Buffer{},
+			Data:   data,
+		}
+		err := NewDoctorCommand(cfg).Execute(context.Background(), exec)
+		findings, _ := exec.Data["findings"].([]doctorFinding)
+		return stdout.String(), findings, err
+	}
+	find := func(findings []doctorFinding, check, status string) *doctorFinding {
+		for i := range findings {
+			if findings[i].Check == check && findings[i].Status == status {
+				return &findings[i]
+			}
+		}
+		return nil
+	}
+
+	t.Run("missing key for default provider fails", func(t *testing.T) {
+		output, findings, err := run(map[string]interface{}{})
+		require.Error(t, err)
+
+		keyFinding := find(findings, "api-keys", doctorFail)
+		require.NotNil(t, keyFinding)
+		assert.Contains(t, keyFinding.Message, "openai")
+		assert.Contains(t, keyFinding.Fix, "OPENAI_API_KEY")
+		assert.Contains(t, output, "✗ api-keys")
+	})
+
+	t.Run("healthy setup passes", func(t *testing.T) {
+		t.Setenv("OPENAI_API_KEY", "sk-test")
+		require.NoError(t, configdir.EnsureDirectories())
+
+		_, findings, err := run(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.NotNil(t, find(findings, "api-keys", doctorOK))
+		assert.NotNil(t, find(findings, "storage", doctorOK))
+		assert.NotNil(t, find(findings, "permissions", doctorOK))
+	})
+
+	t.Run("JSON output", func(t *testing.T) {
+		t.Setenv("OPENAI_API_KEY", "sk-test")
+		require.NoError(t, configdir.EnsureDirectories())
+
+		output, _, err := run(map[string]interface{}{"outputFormat": "json"})
+		require.NoError(t, err)
+
+		var result struct {
+			Findings []doctorFinding `json:"findings"`
+			OK       bool            `json:"ok"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(output), &result))
+		assert.True(t, result.OK)
+		assert.NotEmpty(t, result.Findings)
+	})
+}
+
+func TestCheckWritableDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, checkWritableDir(dir))
+	assert.ErrorContains(t, checkWritableDir(dir+"/missing"), "is missing")
+}
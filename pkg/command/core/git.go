@@ -0,0 +1,268 @@
+// ABOUTME: Git command - Generates commit messages and reviews changes with the LLM
+// ABOUTME: Gathers diffs and history from git and sends them through the ask pipeline
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	osExec "os/exec"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+)
+
+// maxGitDiffBytes caps how much of a diff is sent to the model
+const maxGitDiffBytes = 200000
+
+const commitMessageSystemPrompt = `You write git commit messages. Reply with only the commit message: a summary line of at most 72 characters in the imperative mood, then a blank line and a short body explaining what changed and why, unless the change is trivial. Do not wrap the message in code fences.`
+
+const conventionalCommitsPrompt = ` Use the Conventional Commits format for the summary line: type(scope): summary.`
+
+const reviewSystemPrompt = `You are an experienced code reviewer. Point out bugs, security problems, risky changes, missing tests, and unclear code, most important first, citing the file and line where possible. Keep it concise. If the change looks good, say so briefly.`
+
+// GitCommand implements git helpers backed by the LLM
+type GitCommand struct {
+	config *config.Config
+	dir    string
+}
+
+// NewGitCommand creates a new git command instance that runs git in the
+// current directory
+func NewGitCommand(cfg *config.Config) *GitCommand {
+	return &GitCommand{
+		config: cfg,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *GitCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "git",
+		Description: "Generate commit messages and review changes",
+		LongDescription: `The git command gathers context from the git repository in the current
+directory and asks the LLM about it.
+
+Subcommands:
+  commit-msg           Write a commit message for the staged changes
+  review [range]       Review uncommitted changes, a commit range, or a diff
+
+review takes what git diff takes: nothing for all uncommitted changes,
+--staged for staged changes, a revision such as HEAD~3, or a range such as
+main...feature for a pull request. Use "-" to review a diff read from stdin.
+
+Examples:
+  magellai git commit-msg
+  git commit -m "$(magellai git commit-msg --conventional)"
+  magellai git review
+  magellai git review main...feature
+  gh pr diff 42 | magellai git review -`,
+		Category: command.CategoryCLI,
+		Flags: []command.Flag{
+			{
+				Name:        "model",
+				Short:       "m",
+				Description: "Model to use (provider/model format)",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "staged",
+				Description: "Review only staged changes (review)",
+				Type:        command.FlagTypeBool,
+			},
+			{
+				Name:        "conventional",
+				Description: "Use the Conventional Commits format (commit-msg)",
+				Type:        command.FlagTypeBool,
+			},
+			{
+				Name:        "stream",
+				Description: "Stream the response",
+				Type:        command.FlagTypeBool,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *GitCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	return nil
+}
+
+// Execute runs the git command
+func (c *GitCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+	if len(exec.Args) == 0 {
+		return fmt.Errorf("git: %w - subcommand required (commit-msg or review)", command.ErrMissingArgument)
+	}
+
+	var prompt, system string
+	var err error
+	switch exec.Args[0] {
+	case "commit-msg", "commit-message":
+		if len(exec.Args) > 1 {
+			return fmt.Errorf("git commit-msg: %w - unexpected arguments: %s", command.ErrInvalidArguments, strings.Join(exec.Args[1:], " "))
+		}
+		prompt, err = c.commitMessagePrompt(ctx)
+		system = commitMessageSystemPrompt
+		if exec.Flags.GetBool("conventional") {
+			system += conventionalCommitsPrompt
+		}
+	case "review":
+		prompt, err = c.reviewPrompt(ctx, exec, exec.Args[1:])
+		system = reviewSystemPrompt
+	default:
+		return fmt.Errorf("git: %w - invalid subcommand '%s'", command.ErrInvalidArguments, exec.Args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	logging.LogInfo("Running git helper", "subcommand", exec.Args[0], "promptLength", len(prompt))
+	flags := map[string]interface{}{"system": system}
+	for _, name := range []string{"model", "stream", "output"} {
+		if exec.Flags.Has(name) {
+			flags[name] = exec.Flags.Get(name)
+		}
+	}
+	askExec := *exec
+	askExec.Args = []string{prompt}
+	askExec.Flags = command.NewFlags(flags)
+	return NewAskCommand(c.config).Execute(ctx, &askExec)
+}
+
+// commitMessagePrompt builds the prompt for a commit message from the
+// staged changes and recent history
+func (c *GitCommand) commitMessagePrompt(ctx context.Context) (string, error) {
+	diff, err := c.git(ctx, "diff", "--cached", "--no-color")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", fmt.Errorf("git commit-msg: no staged changes (stage them with git add)")
+	}
+	stat, err := c.git(ctx, "diff", "--cached", "--stat", "--no-color")
+	if err != nil {
+		return "", err
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Write a commit message for these staged changes.\n")
+	// A new repository has no history to match
+	if log, err := c.git(ctx, "log", "--oneline", "-n", "10", "--no-color"); err == nil && strings.TrimSpace(log) != "" {
+		prompt.WriteString("\nRecent commits, to match their style:\n")
+		prompt.WriteString(log)
+	}
+	prompt.WriteString("\nFiles changed:\n")
+	prompt.WriteString(stat)
+	prompt.WriteString("\nDiff:\n")
+	prompt.WriteString(truncateDiff(diff))
+	return prompt.String(), nil
+}
+
+// reviewPrompt builds the prompt for a review of uncommitted changes, a
+// revision range, or a diff from stdin
+func (c *GitCommand) reviewPrompt(ctx context.Context, exec *command.ExecutionContext, revisions []string) (string, error) {
+	var prompt strings.Builder
+
+	if len(revisions) == 1 && revisions[0] == "-" {
+		if exec.Stdin == nil {
+			return "", fmt.Errorf("git review: %w - no diff on stdin", command.ErrMissingArgument)
+		}
+		data, err := io.ReadAll(exec.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read diff from stdin: %w", err)
+		}
+		if strings.TrimSpace(string(data)) == "" {
+			return "", fmt.Errorf("git review: no changes to review")
+		}
+		prompt.WriteString("Review this change.\n\nDiff:\n")
+		prompt.WriteString(truncateDiff(string(data)))
+		return prompt.String(), nil
+	}
+
+	args := []string{"diff", "--no-color"}
+	description := "the uncommitted changes"
+	switch {
+	case exec.Flags.GetBool("staged"):
+		if len(revisions) > 0 {
+			return "", fmt.Errorf("git review: %w - --staged cannot be combined with a range", command.ErrInvalidArguments)
+		}
+		args = append(args, "--cached")
+		description = "the staged changes"
+	case len(revisions) == 0:
+		args = append(args, "HEAD")
+	case len(revisions) > 2:
+		return "", fmt.Errorf("git review: %w - expected a revision or range", command.ErrInvalidArguments)
+	default:
+		args = append(args, revisions...)
+		description = strings.Join(revisions, " ")
+	}
+
+	diff, err := c.git(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", fmt.Errorf("git review: no changes to review in %s", description)
+	}
+
+	prompt.WriteString(fmt.Sprintf("Review %s.\n", description))
+	if logRange := reviewLogRange(revisions); logRange != "" {
+		if log, err := c.git(ctx, "log", "--no-color", "--format=%h %s%n%n%b", logRange); err == nil && strings.TrimSpace(log) != "" {
+			prompt.WriteString("\nCommits:\n")
+			prompt.WriteString(log)
+		}
+	}
+	prompt.WriteString("\nDiff:\n")
+	prompt.WriteString(truncateDiff(diff))
+	return prompt.String(), nil
+}
+
+// reviewLogRange returns the git log range for the commits being reviewed,
+// or "" when reviewing uncommitted changes
+func reviewLogRange(revisions []string) string {
+	switch len(revisions) {
+	case 1:
+		if strings.Contains(revisions[0], "..") {
+			return revisions[0]
+		}
+		return revisions[0] + "..HEAD"
+	case 2:
+		return revisions[0] + ".." + revisions[1]
+	}
+	return ""
+}
+
+// git runs a git command and returns its output
+func (c *GitCommand) git(ctx context.Context, args ...string) (string, error) {
+	cmd := osExec.CommandContext(ctx, "git", args...)
+	cmd.Dir = c.dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// truncateDiff shortens diffs too large to send in full
+func truncateDiff(diff string) string {
+	if len(diff) <= maxGitDiffBytes {
+		return diff
+	}
+	return diff[:maxGitDiffBytes] + "\n[diff truncated]\n"
+}
@@ -0,0 +1,135 @@
+// ABOUTME: Unit tests for the git command
+// ABOUTME: Tests commit message and review prompts built from a temporary repository
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGitTestRepo creates a repository with one commit and returns its path
+func newGitTestRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+	} {
+		runTestGit(t, dir, args...)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644))
+	runTestGit(t, dir, "add", ".")
+	runTestGit(t, dir, "commit", "-q", "-m", "Add main package")
+	return dir
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestGitCommand_CommitMessagePrompt(t *testing.T) {
+	dir := newGitTestRepo(t)
+	cmd := &GitCommand{config: createTestConfig(t), dir: dir}
+	ctx := context.Background()
+
+	_, err := cmd.commitMessagePrompt(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no staged changes")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	runTestGit(t, dir, "add", "main.go")
+
+	prompt, err := cmd.commitMessagePrompt(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Add main package")
+	assert.Contains(t, prompt, "main.go | 2 ++")
+	assert.Contains(t, prompt, "+func main() {}")
+}
+
+func TestGitCommand_ReviewPrompt(t *testing.T) {
+	dir := newGitTestRepo(t)
+	cmd := &GitCommand{config: createTestConfig(t), dir: dir}
+	ctx := context.Background()
+
+	review := func(args []string, flags map[string]interface{}, stdin string) (string, error) {
+		exec := &command.ExecutionContext{Flags: command.NewFlags(flags)}
+		if stdin != "" {
+			exec.Stdin = strings.NewReader(stdin)
+		}
+		return cmd.reviewPrompt(ctx, exec, args)
+	}
+
+	_, err := review(nil, nil, "")
+	assert.Error(t, err, "no uncommitted changes")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "util.go"), []byte("package main\n\nfunc helper() {}\n"), 0644))
+	runTestGit(t, dir, "add", "util.go")
+	prompt, err := review(nil, map[string]interface{}{"staged": true}, "")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Review the staged changes")
+	assert.Contains(t, prompt, "+func helper() {}")
+
+	runTestGit(t, dir, "commit", "-q", "-m", "Add helper", "-m", "Used by the next change.")
+	prompt, err = review([]string{"HEAD~1..HEAD"}, nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Review HEAD~1..HEAD")
+	assert.Contains(t, prompt, "Add helper")
+	assert.Contains(t, prompt, "Used by the next change.")
+	assert.Contains(t, prompt, "+func helper() {}")
+
+	prompt, err = review([]string{"-"}, nil, "--- a/x\n+++ b/x\n+new line\n")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "+new line")
+
+	_, err = review([]string{"HEAD"}, map[string]interface{}{"staged": true}, "")
+	assert.True(t, errors.Is(err, command.ErrInvalidArguments))
+	_, err = review([]string{"no-such-branch"}, nil, "")
+	assert.Error(t, err)
+}
+
+func TestGitCommand_Execute(t *testing.T) {
+	dir := newGitTestRepo(t)
+	cfg := createTestConfig(t)
+	require.NoError(t, cfg.SetValue("model.default", "mock/test-model"))
+	require.NoError(t, cfg.SetValue("provider.mock.api_key", "mock-api-key"))
+	cmd := &GitCommand{config: cfg, dir: dir}
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	runTestGit(t, dir, "add", "main.go")
+
+	var stdout bytes.Buffer
+	exec := &command.ExecutionContext{
+		Context: ctx,
+		Args:    []string{"commit-msg"},
+		Flags:   command.NewFlags(map[string]interface{}{"conventional": true}),
+		Stdout:  &stdout,
+		Stderr:  &bytes.Buffer{},
+		Data:    make(map[string]interface{}),
+		Config:  cfg,
+	}
+	require.NoError(t, cmd.Execute(ctx, exec))
+	assert.NotEmpty(t, stdout.String())
+
+	err := cmd.Execute(ctx, &command.ExecutionContext{Args: []string{"bogus"}, Flags: command.NewFlags(nil)})
+	assert.True(t, errors.Is(err, command.ErrInvalidArguments))
+	err = cmd.Execute(ctx, &command.ExecutionContext{Flags: command.NewFlags(nil)})
+	assert.True(t, errors.Is(err, command.ErrMissingArgument))
+}
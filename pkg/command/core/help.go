@@ -65,7 +65,7 @@ func (h *HelpCommand) Execute(ctx context.Context, exec *command.ExecutionContex
 		cmdName := exec.Args[0]
 
 		// Try exact match
-		cmd, err := h.registry.Get(cmdName)
+		cmd, deprecation, err := h.registry.Resolve(cmdName)
 		if err != nil {
 			// Try alias resolution
 			aliasKey := fmt.Sprintf("aliases.%s", cmdName)
@@ -91,12 +91,35 @@ func (h *HelpCommand) Execute(ctx context.Context, exec *command.ExecutionContex
 		}
 
 		// Show specific command help
+		if structuredOutputRequested(exec) {
+			entry := h.commandHelp(cmd)
+			if deprecation != nil {
+				entry.Deprecation = deprecation
+			}
+			return printOutput(exec, entry)
+		}
+		if deprecation != nil {
+			fmt.Fprintf(exec.Stdout, "Note: %s\n\n", deprecation.Warning())
+		}
 		fmt.Fprintln(exec.Stdout, h.formatter.FormatCommand(cmd))
 		return nil
 	}
 
 	// Show general help
 	commands := h.registry.List(h.category)
+	if structuredOutputRequested(exec) {
+		entries := make([]commandHelp, 0, len(commands))
+		for _, cmd := range commands {
+			if cmd.Metadata().Hidden && !showAll {
+				continue
+			}
+			entries = append(entries, h.commandHelp(cmd))
+		}
+		return printOutput(exec, map[string]interface{}{
+			"commands":     entries,
+			"deprecations": h.registry.Deprecations(),
+		})
+	}
 	fmt.Fprintln(exec.Stdout, h.formatter.FormatCommandList(commands, h.category))
 
 	// Show aliases if in REPL mode
@@ -114,6 +137,52 @@ func (h *HelpCommand) Execute(ctx context.Context, exec *command.ExecutionContex
 	return nil
 }
 
+// commandHelp is the help of a command for JSON and YAML output
+type commandHelp struct {
+	Name            string               `json:"name"`
+	Aliases         []string             `json:"aliases,omitempty"`
+	Description     string               `json:"description"`
+	LongDescription string               `json:"long_description,omitempty"`
+	Flags           []flagHelp           `json:"flags,omitempty"`
+	Hidden          bool                 `json:"hidden,omitempty"`
+	Deprecation     *command.Deprecation `json:"deprecation,omitempty"`
+	DeprecatedNames []string             `json:"deprecated_names,omitempty"`
+}
+
+// flagHelp is the help of a command flag for JSON and YAML output
+type flagHelp struct {
+	Name        string      `json:"name"`
+	Short       string      `json:"short,omitempty"`
+	Description string      `json:"description"`
+	Default     interface{} `json:"default,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+}
+
+// commandHelp returns the structured help of a command, including how it
+// is deprecated and the old names that still run it
+func (h *HelpCommand) commandHelp(cmd command.Interface) commandHelp {
+	meta := cmd.Metadata()
+	entry := commandHelp{
+		Name:            meta.Name,
+		Aliases:         meta.Aliases,
+		Description:     meta.Description,
+		LongDescription: meta.LongDescription,
+		Hidden:          meta.Hidden,
+		Deprecation:     meta.Deprecation(),
+		DeprecatedNames: h.registry.DeprecatedNames(meta.Name),
+	}
+	for _, flag := range meta.Flags {
+		entry.Flags = append(entry.Flags, flagHelp{
+			Name:        flag.Name,
+			Short:       flag.Short,
+			Description: flag.Description,
+			Default:     flag.Default,
+			Required:    flag.Required,
+		})
+	}
+	return entry
+}
+
 // formatAliases formats the aliases for display
 func (h *HelpCommand) formatAliases(aliases map[string]interface{}) string {
 	// If formatter is ContextAwareHelpFormatter, use its color formatter
@@ -283,9 +352,9 @@ func (f *ContextAwareHelpFormatter) FormatCommand(cmd command.Interface) string
 	}
 
 	// Deprecated warning
-	if meta.Deprecated != "" {
+	if deprecation := meta.Deprecation(); deprecation != nil {
 		b.WriteString("\n")
-		b.WriteString(f.colorFormatter.FormatError(fmt.Sprintf("DEPRECATED: %s", meta.Deprecated)))
+		b.WriteString(f.colorFormatter.FormatError(fmt.Sprintf("DEPRECATED: %s", deprecation.Warning())))
 		b.WriteString("\n")
 	}
 
@@ -434,7 +503,7 @@ func (f *ContextAwareHelpFormatter) formatCommandTable(commands []command.Interf
 		}
 
 		desc := meta.Description
-		if meta.Deprecated != "" {
+		if meta.Deprecation() != nil {
 			desc = f.colorFormatter.FormatError(fmt.Sprintf("[DEPRECATED] %s", desc))
 		}
 
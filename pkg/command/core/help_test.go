@@ -6,6 +6,7 @@ package core
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -490,3 +491,59 @@ func TestHelpCommand_AliasResolution(t *testing.T) {
 	output := stdout.String()
 	assert.Contains(t, output, "Command: test")
 }
+
+func TestHelpCommand_Deprecation(t *testing.T) {
+	require.NoError(t, config.Init())
+	cfg := config.Manager
+	require.NoError(t, cfg.Load(nil))
+	require.NoError(t, cfg.SetValue("repl.colors.enabled", false))
+
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(&SimpleTestCommand{
+		name:        "history",
+		description: "Manage history",
+		category:    command.CategoryShared,
+	}))
+	require.NoError(t, registry.Deprecate("sessions", "history", "v1.0.0"))
+	helpCmd := NewHelpCommand(registry, cfg)
+
+	run := func(args []string, data map[string]interface{}) string {
+		stdout := &bytes.Buffer{}
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(nil),
+			Stdout: stdout,
+			Stderr: &bytes.Buffer{},
+			Data:   data,
+		}
+		require.NoError(t, helpCmd.Execute(context.Background(), exec))
+		return stdout.String()
+	}
+
+	output := run([]string{"sessions"}, nil)
+	assert.Contains(t, output, `Note: "sessions" is deprecated and will be removed in v1.0.0; use "history" instead`)
+	assert.Contains(t, output, "Command: history")
+
+	var entry struct {
+		Name        string               `json:"name"`
+		Deprecation *command.Deprecation `json:"deprecation"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(run([]string{"sessions"}, map[string]interface{}{"outputFormat": "json"})), &entry))
+	assert.Equal(t, "history", entry.Name)
+	require.NotNil(t, entry.Deprecation)
+	assert.Equal(t, "sessions", entry.Deprecation.Name)
+	assert.Equal(t, "v1.0.0", entry.Deprecation.RemovalVersion)
+
+	var list struct {
+		Commands []struct {
+			Name            string   `json:"name"`
+			DeprecatedNames []string `json:"deprecated_names"`
+		} `json:"commands"`
+		Deprecations []command.Deprecation `json:"deprecations"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(run(nil, map[string]interface{}{"outputFormat": "json"})), &list))
+	require.Len(t, list.Commands, 1)
+	assert.Equal(t, []string{"sessions"}, list.Commands[0].DeprecatedNames)
+	require.Len(t, list.Deprecations, 1)
+	assert.Equal(t, "history", list.Deprecations[0].ReplacedBy)
+}
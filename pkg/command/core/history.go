@@ -1,11 +1,17 @@
 // ABOUTME: Implements the history command for managing and viewing REPL session history
-// ABOUTME: Provides subcommands for listing, showing, deleting, exporting, and searching sessions
+// ABOUTME: Provides subcommands for listing, showing, deleting, exporting, searching and restoring sessions
 
 package core
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -13,6 +19,8 @@ import (
 	"github.com/lexlapax/magellai/internal/configdir"
 	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/domain"
 	"github.com/lexlapax/magellai/pkg/repl/session"
 	"github.com/lexlapax/magellai/pkg/storage"
 )
@@ -41,6 +49,9 @@ func (c *HistoryCommand) Execute(ctx context.Context, exec *command.ExecutionCon
 	}
 
 	c.subcommand = exec.Args[0]
+	if command.IsDryRun(exec) && c.subcommand != "delete" {
+		return fmt.Errorf("history %s: %w", c.subcommand, command.ErrDryRunUnsupported)
+	}
 
 	// Process flags
 	if format, ok := exec.Flags.Get("format").(string); ok {
@@ -61,12 +72,19 @@ func (c *HistoryCommand) Execute(ctx context.Context, exec *command.ExecutionCon
 		}
 
 		// Create storage manager using filesystem backend
-		manager, err := session.CreateStorageManager(storage.FileSystemBackend, storage.Config{
-			"base_dir": paths.Sessions,
-		})
+		storageConfig := storage.Config{"base_dir": paths.Sessions}
+		if config.Manager != nil && config.Manager.GetBool("session.storage.read_only") {
+			storageConfig["read_only"] = true
+		}
+		if config.Manager != nil && config.Manager.Exists("session.snapshots.keep") {
+			storageConfig["snapshot_interval"] = config.Manager.Get("session.snapshots.interval")
+			storageConfig["snapshot_keep"] = config.Manager.Get("session.snapshots.keep")
+		}
+		manager, err := session.CreateStorageManager(storage.FileSystemBackend, storageConfig)
 		if err != nil {
 			return fmt.Errorf("failed to create storage manager: %v", err)
 		}
+		manager.SetTraceContext(ctx)
 
 		// Create session manager wrapping storage manager
 		sessionManager = &session.SessionManager{StorageManager: manager}
@@ -88,17 +106,41 @@ func (c *HistoryCommand) Execute(ctx context.Context, exec *command.ExecutionCon
 		c.sessionID = exec.Args[1]
 		return c.executeDelete(ctx, exec, sessionManager)
 	case "export":
-		if len(exec.Args) < 2 {
-			return fmt.Errorf("session ID required for export command")
+		return c.executeExport(ctx, exec, sessionManager, exec.Args[1:])
+	case "rename":
+		if len(exec.Args) < 3 {
+			return fmt.Errorf("session ID and new name required for rename command")
+		}
+		c.sessionID = exec.Args[1]
+		return c.executeRename(ctx, exec, sessionManager, strings.Join(exec.Args[2:], " "))
+	case "fork":
+		if len(exec.Args) < 3 {
+			return fmt.Errorf("session ID and message index required for fork command")
 		}
 		c.sessionID = exec.Args[1]
-		return c.executeExport(ctx, exec, sessionManager)
+		index, err := strconv.Atoi(exec.Args[2])
+		if err != nil {
+			return fmt.Errorf("invalid message index: %s", exec.Args[2])
+		}
+		return c.executeFork(ctx, exec, sessionManager, index)
 	case "search":
 		if len(exec.Args) < 2 {
 			return fmt.Errorf("search term required for search command")
 		}
 		c.searchTerm = strings.Join(exec.Args[1:], " ")
 		return c.executeSearch(ctx, exec, sessionManager)
+	case "snapshots":
+		if len(exec.Args) < 2 {
+			return fmt.Errorf("session ID required for snapshots command")
+		}
+		c.sessionID = exec.Args[1]
+		return c.executeSnapshots(ctx, exec, sessionManager)
+	case "restore":
+		if len(exec.Args) < 2 {
+			return fmt.Errorf("session ID required for restore command")
+		}
+		c.sessionID = exec.Args[1]
+		return c.executeRestore(ctx, exec, sessionManager)
 	default:
 		return fmt.Errorf("unknown subcommand: %s", c.subcommand)
 	}
@@ -112,29 +154,31 @@ func (c *HistoryCommand) executeList(ctx context.Context, exec *command.Executio
 		return fmt.Errorf("failed to list sessions: %v", err)
 	}
 
-	if len(sessions) == 0 {
-		fmt.Fprintln(exec.Stdout, "No sessions found")
-		return nil
+	exec.Data["sessions"] = sessions
+	if structuredOutputRequested(exec) {
+		if sessions == nil {
+			sessions = []*domain.SessionInfo{}
+		}
+		return printOutput(exec, map[string]interface{}{
+			"sessions": sessions,
+			"count":    len(sessions),
+		})
 	}
 
-	// Format output as table
-	w := tabwriter.NewWriter(exec.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tNAME\tCREATED\tUPDATED\tMESSAGES")
+	if len(sessions) == 0 {
+		return printOutput(exec, "No sessions found")
+	}
 
+	table := command.NewTable("ID", "NAME", "CREATED", "UPDATED", "MESSAGES")
 	for _, session := range sessions {
-		created := session.Created.Format("2006-01-02 15:04")
-		updated := session.Updated.Format("2006-01-02 15:04")
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
+		table.AddRow(
 			session.ID,
 			session.Name,
-			created,
-			updated,
+			session.Created.Format("2006-01-02 15:04"),
+			session.Updated.Format("2006-01-02 15:04"),
 			session.MessageCount)
 	}
-
-	w.Flush()
-	exec.Data["sessions"] = sessions
-	return nil
+	return printOutput(exec, table)
 }
 
 func (c *HistoryCommand) executeShow(ctx context.Context, exec *command.ExecutionContext, manager *session.SessionManager) error {
@@ -145,6 +189,11 @@ func (c *HistoryCommand) executeShow(ctx context.Context, exec *command.Executio
 		return fmt.Errorf("failed to load session: %v", err)
 	}
 
+	exec.Data["session"] = session
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, session)
+	}
+
 	// Format session details
 	fmt.Fprintf(exec.Stdout, "Session ID: %s\n", session.ID)
 	if session.Name != "" {
@@ -169,36 +218,345 @@ func (c *HistoryCommand) executeShow(ctx context.Context, exec *command.Executio
 		}
 	}
 
-	exec.Data["session"] = session
 	return nil
 }
 
 func (c *HistoryCommand) executeDelete(ctx context.Context, exec *command.ExecutionContext, manager *session.SessionManager) error {
+	if command.IsDryRun(exec) {
+		sess, err := manager.StorageManager.LoadSession(c.sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to load session: %v", err)
+		}
+		change := fmt.Sprintf("delete session %s", sess.ID)
+		if sess.Name != "" {
+			change += fmt.Sprintf(" (%s)", sess.Name)
+		}
+		change += fmt.Sprintf(" with %d messages", len(sess.Conversation.Messages))
+		return command.ReportDryRun(exec, change)
+	}
+
+	ok, err := command.PrompterFor(exec).Confirm(fmt.Sprintf("Delete session %s?", c.sessionID), false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintln(exec.Stdout, "Deletion canceled")
+		return nil
+	}
+
 	logging.LogInfo("Deleting session", "id", c.sessionID)
 
-	err := manager.DeleteSession(c.sessionID)
+	err = manager.DeleteSession(c.sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to delete session: %v", err)
 	}
 
-	fmt.Fprintf(exec.Stdout, "Session %s deleted\n", c.sessionID)
 	exec.Data["deleted_id"] = c.sessionID
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, map[string]string{"deleted": c.sessionID})
+	}
+	fmt.Fprintf(exec.Stdout, "Session %s deleted\n", c.sessionID)
+	return nil
+}
+
+func (c *HistoryCommand) executeRename(ctx context.Context, exec *command.ExecutionContext, manager *session.SessionManager, name string) error {
+	logging.LogInfo("Renaming session", "id", c.sessionID, "name", name)
+
+	session, err := manager.StorageManager.LoadSession(c.sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %v", err)
+	}
+
+	session.Name = name
+	session.Updated = time.Now()
+	if err := manager.SaveSession(session); err != nil {
+		return fmt.Errorf("failed to save session: %v", err)
+	}
+
+	exec.Data["renamed_id"] = c.sessionID
+	exec.Data["name"] = name
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, map[string]string{"renamed": c.sessionID, "name": name})
+	}
+	fmt.Fprintf(exec.Stdout, "Session %s renamed to '%s'\n", c.sessionID, name)
 	return nil
 }
 
-func (c *HistoryCommand) executeExport(ctx context.Context, exec *command.ExecutionContext, manager *session.SessionManager) error {
-	logging.LogInfo("Exporting session", "id", c.sessionID, "format", c.format)
+func (c *HistoryCommand) executeFork(ctx context.Context, exec *command.ExecutionContext, manager *session.SessionManager, index int) error {
+	logging.LogInfo("Forking session", "id", c.sessionID, "index", index)
+
+	parent, err := manager.StorageManager.LoadSession(c.sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %v", err)
+	}
 
-	err := manager.ExportSession(c.sessionID, c.format, exec.Stdout)
+	fork, err := parent.ForkAt(index)
 	if err != nil {
-		return fmt.Errorf("failed to export session: %v", err)
+		return fmt.Errorf("failed to fork session: %v", err)
+	}
+	if err := manager.SaveSession(fork); err != nil {
+		return fmt.Errorf("failed to save fork: %v", err)
+	}
+	if err := manager.SaveSession(parent); err != nil {
+		return fmt.Errorf("failed to update parent session: %v", err)
 	}
 
-	exec.Data["exported_id"] = c.sessionID
+	exec.Data["fork_id"] = fork.ID
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, map[string]interface{}{
+			"forked":   fork.ID,
+			"parent":   parent.ID,
+			"messages": len(fork.Conversation.Messages),
+		})
+	}
+	fmt.Fprintf(exec.Stdout, "Forked session %s at message %d as %s\n", c.sessionID, index, fork.ID)
+	return nil
+}
+
+func (c *HistoryCommand) executeExport(ctx context.Context, exec *command.ExecutionContext, manager *session.SessionManager, ids []string) error {
+	outputDir := exec.Flags.GetString("output-dir")
+	archive := exec.Flags.GetString("archive")
+	if outputDir != "" && archive != "" {
+		return fmt.Errorf("use either --output-dir or --archive")
+	}
+
+	opts, err := exportOptions(exec)
+	if err != nil {
+		return err
+	}
+
+	ids, err = c.selectExportSessions(exec, manager, ids)
+	if err != nil {
+		return err
+	}
+
+	// Without a destination, sessions go to stdout: a single session, or any
+	// number in a format whose output can be concatenated
+	if outputDir == "" && archive == "" {
+		if len(ids) != 1 && !concatenableFormats[c.format] {
+			return fmt.Errorf("exporting %d sessions requires --output-dir or --archive", len(ids))
+		}
+		logging.LogInfo("Exporting sessions", "count", len(ids), "format", c.format)
+
+		for i, id := range ids {
+			// A CSV table has one header row
+			opts.OmitHeader = i > 0
+			if err := manager.ExportSessionWithOptions(id, c.format, opts, exec.Stdout); err != nil {
+				return fmt.Errorf("failed to export session %s: %v", id, err)
+			}
+		}
+
+		if len(ids) == 1 {
+			c.sessionID = ids[0]
+			exec.Data["exported_id"] = c.sessionID
+		} else {
+			exec.Data["exported_ids"] = ids
+		}
+		exec.Data["format"] = c.format
+		return nil
+	}
+
+	ext, ok := exportExtensions[c.format]
+	if !ok {
+		return fmt.Errorf("unsupported export format: %s", c.format)
+	}
+	logging.LogInfo("Exporting sessions", "count", len(ids), "format", c.format, "dir", outputDir, "archive", archive)
+
+	destination := outputDir
+	if archive != "" {
+		destination = archive
+		err = exportArchive(manager, ids, c.format, opts, ext, archive)
+	} else {
+		err = exportDirectory(manager, ids, c.format, opts, ext, outputDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	exec.Data["exported_ids"] = ids
 	exec.Data["format"] = c.format
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, map[string]interface{}{
+			"exported": ids,
+			"count":    len(ids),
+			"format":   c.format,
+			"path":     destination,
+		})
+	}
+	fmt.Fprintf(exec.Stdout, "Exported %d session(s) to %s\n", len(ids), destination)
+	return nil
+}
+
+// exportExtensions maps export formats to file extensions
+var exportExtensions = map[string]string{
+	"json":     ".json",
+	"markdown": ".md",
+	"text":     ".txt",
+	"org":      ".org",
+	"jsonl":    ".jsonl",
+	"csv":      ".csv",
+}
+
+// concatenableFormats are the export formats whose output for several
+// sessions can be written one after another to a single stream
+var concatenableFormats = map[string]bool{
+	"jsonl": true,
+	"csv":   true,
+}
+
+// exportOptions reads the flags of formats that reshape a session
+func exportOptions(exec *command.ExecutionContext) (storage.ExportOptions, error) {
+	roles, err := storage.ParseExportRoles(exec.Flags.GetStringSlice("roles"))
+	if err != nil {
+		return storage.ExportOptions{}, fmt.Errorf("history export: %w - %v", command.ErrInvalidArguments, err)
+	}
+	opts := storage.ExportOptions{
+		PerExchange:      exec.Flags.GetBool("per-exchange"),
+		Roles:            roles,
+		StripAttachments: exec.Flags.GetBool("strip-attachments"),
+		Dialect:          exec.Flags.GetString("dialect"),
+	}
+	switch opts.Dialect {
+	case "", storage.JSONLDialectOpenAI, storage.JSONLDialectAnthropic:
+	default:
+		return opts, fmt.Errorf("history export: %w - unknown dialect '%s' (openai or anthropic)", command.ErrInvalidArguments, opts.Dialect)
+	}
+	return opts, nil
+}
+
+// selectExportSessions resolves the sessions to export: the given IDs, or
+// with --all every session, narrowed to those carrying all --tag values
+func (c *HistoryCommand) selectExportSessions(exec *command.ExecutionContext, manager *session.SessionManager, ids []string) ([]string, error) {
+	all := exec.Flags.GetBool("all")
+	tags := exec.Flags.GetStringSlice("tag")
+
+	// In a pipeline, sessions listed or found by the previous command are
+	// exported when none are named
+	if len(ids) == 0 && !all {
+		if piped, ok := pipelineSessionIDs(exec); ok {
+			if len(piped) == 0 {
+				return nil, fmt.Errorf("no sessions to export from %s", command.GetPipelineInput(exec).Command)
+			}
+			ids = piped
+		}
+	}
+
+	if len(ids) > 0 && all {
+		return nil, fmt.Errorf("use either session IDs or --all")
+	}
+	if len(ids) > 0 && len(tags) == 0 {
+		return ids, nil
+	}
+	if len(ids) == 0 && !all && len(tags) == 0 {
+		return nil, fmt.Errorf("session ID, --tag, or --all required for export command")
+	}
+
+	sessions, err := manager.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %v", err)
+	}
+	requested := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		requested[id] = true
+	}
+
+	var selected []string
+	for _, info := range sessions {
+		if len(ids) > 0 && !requested[info.ID] {
+			continue
+		}
+		if hasAllTags(info.Tags, tags) {
+			selected = append(selected, info.ID)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no sessions match the export filters")
+	}
+	sort.Strings(selected)
+	return selected, nil
+}
+
+// pipelineSessionIDs returns the IDs of sessions listed or found by the
+// previous command of a pipeline, and false when there is none
+func pipelineSessionIDs(exec *command.ExecutionContext) ([]string, bool) {
+	input := command.GetPipelineInput(exec)
+	if input == nil {
+		return nil, false
+	}
+	var ids []string
+	switch sessions := input.Data["sessions"].(type) {
+	case []*domain.SessionInfo:
+		for _, info := range sessions {
+			ids = append(ids, info.ID)
+		}
+	case []*domain.SearchResult:
+		for _, result := range sessions {
+			ids = append(ids, result.Session.ID)
+		}
+	default:
+		return nil, false
+	}
+	return ids, true
+}
+
+// hasAllTags reports whether sessionTags contains every tag in want
+func hasAllTags(sessionTags, want []string) bool {
+	for _, tag := range want {
+		found := false
+		for _, t := range sessionTags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// exportDirectory writes each session to <dir>/<id><ext>
+func exportDirectory(manager *session.SessionManager, ids []string, format string, opts storage.ExportOptions, ext, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create export directory: %v", err)
+	}
+	for _, id := range ids {
+		var buf bytes.Buffer
+		if err := manager.ExportSessionWithOptions(id, format, opts, &buf); err != nil {
+			return fmt.Errorf("failed to export session %s: %v", id, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, id+ext), buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write session %s: %v", id, err)
+		}
+	}
 	return nil
 }
 
+// exportArchive writes every session into a zip archive, one file each
+func exportArchive(manager *session.SessionManager, ids []string, format string, opts storage.ExportOptions, ext, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export archive: %v", err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	for _, id := range ids {
+		w, err := archive.Create(id + ext)
+		if err != nil {
+			return fmt.Errorf("failed to add session %s to archive: %v", id, err)
+		}
+		if err := manager.ExportSessionWithOptions(id, format, opts, w); err != nil {
+			return fmt.Errorf("failed to export session %s: %v", id, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write export archive: %v", err)
+	}
+	return file.Close()
+}
+
 func (c *HistoryCommand) executeSearch(ctx context.Context, exec *command.ExecutionContext, manager *session.SessionManager) error {
 	logging.LogInfo("Searching sessions", "query", c.searchTerm)
 
@@ -207,6 +565,19 @@ func (c *HistoryCommand) executeSearch(ctx context.Context, exec *command.Execut
 		return fmt.Errorf("failed to search sessions: %v", err)
 	}
 
+	exec.Data["sessions"] = sessions
+	exec.Data["query"] = c.searchTerm
+	if structuredOutputRequested(exec) {
+		if sessions == nil {
+			sessions = []*domain.SearchResult{}
+		}
+		return printOutput(exec, map[string]interface{}{
+			"query":   c.searchTerm,
+			"results": sessions,
+			"count":   len(sessions),
+		})
+	}
+
 	if len(sessions) == 0 {
 		fmt.Fprintf(exec.Stdout, "No sessions found matching '%s'\n", c.searchTerm)
 		return nil
@@ -242,11 +613,86 @@ func (c *HistoryCommand) executeSearch(ctx context.Context, exec *command.Execut
 	}
 
 	w.Flush()
-	exec.Data["sessions"] = sessions
-	exec.Data["query"] = c.searchTerm
 	return nil
 }
 
+func (c *HistoryCommand) executeSnapshots(ctx context.Context, exec *command.ExecutionContext, manager *session.SessionManager) error {
+	logging.LogInfo("Listing session snapshots", "id", c.sessionID)
+
+	snapshots, err := manager.StorageManager.ListSnapshots(c.sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %v", err)
+	}
+
+	exec.Data["snapshots"] = snapshots
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, map[string]interface{}{
+			"session_id": c.sessionID,
+			"snapshots":  snapshots,
+			"count":      len(snapshots),
+		})
+	}
+
+	if len(snapshots) == 0 {
+		return printOutput(exec, fmt.Sprintf("No snapshots of session %s", c.sessionID))
+	}
+
+	table := command.NewTable("ID", "CREATED", "REASON", "MESSAGES")
+	for _, snapshot := range snapshots {
+		table.AddRow(
+			snapshot.ID,
+			snapshot.Created.Format("2006-01-02 15:04:05"),
+			snapshot.Reason,
+			snapshot.MessageCount)
+	}
+	return printOutput(exec, table)
+}
+
+func (c *HistoryCommand) executeRestore(ctx context.Context, exec *command.ExecutionContext, manager *session.SessionManager) error {
+	value, _ := exec.Flags.Get("at").(string)
+	if value == "" {
+		return fmt.Errorf("history restore: %w - --at is required", command.ErrInvalidArguments)
+	}
+	at, err := parseRestoreTime(value, time.Now())
+	if err != nil {
+		return fmt.Errorf("history restore: %w - %v", command.ErrInvalidArguments, err)
+	}
+	logging.LogInfo("Restoring session", "id", c.sessionID, "at", at)
+
+	snapshot, err := manager.StorageManager.RestoreSession(c.sessionID, at)
+	if err != nil {
+		return fmt.Errorf("failed to restore session: %w", err)
+	}
+
+	exec.Data["restored_id"] = c.sessionID
+	exec.Data["snapshot"] = snapshot
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, map[string]interface{}{"restored": c.sessionID, "snapshot": snapshot})
+	}
+	fmt.Fprintf(exec.Stdout, "Session %s restored to snapshot %s from %s (%d messages)\n",
+		c.sessionID, snapshot.ID, snapshot.Created.Format("2006-01-02 15:04:05"), snapshot.MessageCount)
+	fmt.Fprintf(exec.Stdout, "The state before the restore was kept as a snapshot; see history snapshots %s\n", c.sessionID)
+	return nil
+}
+
+// parseRestoreTime reads the --at value of history restore: an RFC 3339
+// timestamp, a local date or date and time, or a duration such as 2h meaning
+// that long before now
+func parseRestoreTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s': use a timestamp like 2025-01-31 14:30 or 2025-01-31T14:30:00Z, or a duration ago like 2h", value)
+}
+
 func (c *HistoryCommand) Metadata() *command.Metadata {
 	return &command.Metadata{
 		Name:        "history",
@@ -255,24 +701,116 @@ func (c *HistoryCommand) Metadata() *command.Metadata {
 		LongDescription: `The history command allows you to manage and view REPL session history.
 
 Subcommands:
-  list    - List all sessions
-  show    - Show detailed information about a specific session
-  delete  - Delete a specific session
-  export  - Export a session in JSON or markdown format
-  search  - Search sessions by content
+  list      - List all sessions
+  show      - Show detailed information about a specific session
+  delete    - Delete a specific session, after confirming in a terminal
+              (--dry-run shows the session without deleting it)
+  rename    - Rename a specific session
+  fork      - Start a new session from the first n messages of a session
+  export    - Export sessions as JSON, markdown, org-mode, plain text, JSONL
+              fine-tuning datasets, or CSV tables of their messages
+  search    - Search sessions by content; narrow the search with tag:, model:,
+              provider:, role:, before: and after: filters and "exact phrases"
+  snapshots - List the snapshots kept of a session
+  restore   - Roll a session back to its newest snapshot at or before --at
 
 Examples:
   magellai history list
   magellai history show <session-id>
-  magellai history delete <session-id>
+  magellai history delete <session-id> --yes
+  magellai history rename <session-id> "new name"
+  magellai history fork <session-id> 4
   magellai history export <session-id> --format=markdown
-  magellai history search "python code"`,
+  magellai history export --tag notes --format org --output-dir ~/org/magellai
+  magellai history export --all --output-dir ./backup
+  magellai history export --tag work --archive work.zip
+  magellai history export --all --format jsonl --per-exchange --strip-attachments > train.jsonl
+  magellai history export <session-id> --format jsonl --dialect anthropic --roles user,assistant
+  magellai history export --tag work --format csv > messages.csv
+  magellai history search "python code"
+  magellai history search tag:work model:gpt-4o before:2025-01-01 role:assistant "exact phrase"
+  magellai history snapshots <session-id>
+  magellai history restore <session-id> --at "2025-01-31 14:30"
+  magellai history restore <session-id> --at 2h
+
+In a pipeline, export takes the sessions listed or found by the previous
+command:
+  history search "python code" | history export --format markdown --output-dir ./found
+
+JSONL exports write one line per session, or with --per-exchange one line per
+user message and its replies, in the OpenAI fine-tuning message format or with
+--dialect anthropic in the Anthropic one. Several sessions can be written to
+stdout. --roles keeps only the given roles (default system, user, assistant);
+--strip-attachments leaves attachments out instead of embedding images and
+text files as content parts.
+
+CSV exports write one row per message with the columns session_id, timestamp,
+role, content, tokens and model, under a single header row when several
+sessions are written to stdout.
+
+Snapshots are taken when a session is saved and its last snapshot is older
+than session.snapshots.interval, and before /reset, /merge and /undo; the
+newest session.snapshots.keep are kept. restore takes a snapshot of the
+current state first, so it can be undone with another restore.`,
 		Flags: []command.Flag{
 			{
 				Name:        "format",
-				Description: "Export format (json|markdown)",
+				Description: "Export format (json|markdown|org|text|jsonl|csv)",
 				Default:     "json",
 			},
+			{
+				Name:        "per-exchange",
+				Description: "JSONL: write one line per user message and its replies",
+				Type:        command.FlagTypeBool,
+			},
+			{
+				Name:        "roles",
+				Description: "JSONL: roles to keep (default system,user,assistant)",
+				Type:        command.FlagTypeStringSlice,
+			},
+			{
+				Name:        "strip-attachments",
+				Description: "JSONL: leave attachments out",
+				Type:        command.FlagTypeBool,
+			},
+			{
+				Name:        "dialect",
+				Description: "JSONL: message format (openai|anthropic)",
+				Type:        command.FlagTypeString,
+				Default:     "openai",
+			},
+			{
+				Name:        "all",
+				Description: "Export every session",
+				Type:        command.FlagTypeBool,
+			},
+			{
+				Name:        "tag",
+				Description: "Export sessions with this tag (repeatable)",
+				Type:        command.FlagTypeStringSlice,
+			},
+			{
+				Name:        "output-dir",
+				Description: "Write one file per session into this directory",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "archive",
+				Description: "Write all sessions into this zip archive",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "at",
+				Description: "Restore: time to roll back to (timestamp, or duration ago such as 2h)",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        command.FlagYes,
+				Short:       "y",
+				Description: "Delete without asking for confirmation",
+				Type:        command.FlagTypeBool,
+			},
+			command.DryRunFlag,
 		},
 	}
 }
@@ -1,9 +1,15 @@
 package core
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -262,6 +268,213 @@ func TestHistoryCommand_Execute_Delete(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestHistoryCommand_Execute_DeleteDryRun(t *testing.T) {
+	backend, err := storage.CreateBackend(storage.FileSystemBackend, storage.Config{
+		"base_dir": t.TempDir(),
+	})
+	require.NoError(t, err)
+	storageManager, err := session.NewStorageManager(backend)
+	require.NoError(t, err)
+	manager, err := session.NewSessionManager(storageManager)
+	require.NoError(t, err)
+
+	sess, err := manager.NewSession("keep-me")
+	require.NoError(t, err)
+	require.NoError(t, manager.SaveSession(sess))
+
+	cmd := NewHistoryCommand()
+	var output bytes.Buffer
+	exec := &command.ExecutionContext{
+		Args:   []string{"delete", sess.ID},
+		Flags:  command.NewFlags(map[string]interface{}{command.FlagDryRun: true}),
+		Stdout: &output,
+		Data:   map[string]interface{}{"session_manager": manager},
+	}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	assert.Contains(t, output.String(), "Would delete session "+sess.ID+" (keep-me)")
+	assert.Contains(t, output.String(), "Dry run: nothing was changed")
+
+	// The session is still there
+	_, err = manager.StorageManager.LoadSession(sess.ID)
+	assert.NoError(t, err)
+
+	// Subcommands that do not honor --dry-run refuse it
+	exec.Args = []string{"rename", sess.ID, "other"}
+	assert.ErrorIs(t, cmd.Execute(context.Background(), exec), command.ErrDryRunUnsupported)
+}
+
+func TestHistoryCommand_Execute_Rename(t *testing.T) {
+	tempDir := t.TempDir()
+
+	backend, err := storage.CreateBackend(storage.FileSystemBackend, storage.Config{
+		"base_dir": tempDir,
+	})
+	require.NoError(t, err)
+
+	storageManager, err := session.NewStorageManager(backend)
+	require.NoError(t, err)
+
+	manager, err := session.NewSessionManager(storageManager)
+	require.NoError(t, err)
+
+	session, err := manager.NewSession("test-session")
+	require.NoError(t, err)
+	require.NoError(t, manager.SaveSession(session))
+
+	cmd := NewHistoryCommand()
+	var output bytes.Buffer
+	exec := &command.ExecutionContext{
+		Args:   []string{"rename", session.ID, "renamed", "session"},
+		Flags:  command.NewFlags(nil),
+		Stdout: &output,
+		Data: map[string]interface{}{
+			"session_manager": manager,
+		},
+	}
+
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	assert.Contains(t, output.String(), "renamed to 'renamed session'")
+
+	loaded, err := manager.StorageManager.LoadSession(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed session", loaded.Name)
+
+	// A name is required
+	exec.Args = []string{"rename", session.ID}
+	assert.Error(t, cmd.Execute(context.Background(), exec))
+}
+
+func TestHistoryCommand_Execute_Fork(t *testing.T) {
+	tempDir := t.TempDir()
+
+	backend, err := storage.CreateBackend(storage.FileSystemBackend, storage.Config{
+		"base_dir": tempDir,
+	})
+	require.NoError(t, err)
+
+	storageManager, err := session.NewStorageManager(backend)
+	require.NoError(t, err)
+
+	manager, err := session.NewSessionManager(storageManager)
+	require.NoError(t, err)
+
+	parent, err := manager.NewSession("planning")
+	require.NoError(t, err)
+	parent.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "Plan a trip"))
+	parent.Conversation.AddMessage(*domain.NewMessage("msg-2", domain.MessageRoleAssistant, "Where to?"))
+	parent.Conversation.AddMessage(*domain.NewMessage("msg-3", domain.MessageRoleUser, "Paris"))
+	require.NoError(t, manager.SaveSession(parent))
+
+	cmd := NewHistoryCommand()
+	var output bytes.Buffer
+	exec := &command.ExecutionContext{
+		Args:   []string{"fork", parent.ID, "2"},
+		Flags:  command.NewFlags(nil),
+		Stdout: &output,
+		Data: map[string]interface{}{
+			"session_manager": manager,
+		},
+	}
+
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	forkID, _ := exec.Data["fork_id"].(string)
+	require.NotEmpty(t, forkID)
+	assert.Contains(t, output.String(), "at message 2 as "+forkID)
+
+	fork, err := manager.StorageManager.LoadSession(forkID)
+	require.NoError(t, err)
+	assert.Len(t, fork.Conversation.Messages, 2)
+	assert.Equal(t, parent.ID, fork.ParentID)
+	assert.Equal(t, "planning (fork at 2)", fork.Name)
+
+	loaded, err := manager.StorageManager.LoadSession(parent.ID)
+	require.NoError(t, err)
+	assert.Contains(t, loaded.ChildIDs, forkID)
+
+	// The index must be a number within the conversation
+	exec.Args = []string{"fork", parent.ID, "two"}
+	assert.Error(t, cmd.Execute(context.Background(), exec))
+	exec.Args = []string{"fork", parent.ID, "9"}
+	assert.Error(t, cmd.Execute(context.Background(), exec))
+}
+
+func TestHistoryCommand_Execute_SnapshotsAndRestore(t *testing.T) {
+	storageManager, err := session.CreateStorageManager(storage.FileSystemBackend, storage.Config{
+		"base_dir":          t.TempDir(),
+		"snapshot_interval": "0s",
+		"snapshot_keep":     10,
+	})
+	require.NoError(t, err)
+	manager, err := session.NewSessionManager(storageManager)
+	require.NoError(t, err)
+
+	sess, err := manager.NewSession("snapshotted")
+	require.NoError(t, err)
+	sess.Conversation.AddMessage(createTestMessage("user", "before the reset"))
+	require.NoError(t, manager.SaveSession(sess))
+	_, err = storageManager.Snapshot(sess, domain.SnapshotReasonBeforeReset)
+	require.NoError(t, err)
+	sess.Conversation.Messages = nil
+	require.NoError(t, manager.SaveSession(sess))
+
+	cmd := NewHistoryCommand()
+	run := func(flags map[string]interface{}, args ...string) (string, error) {
+		var output bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdout: &output,
+			Data:   map[string]interface{}{"session_manager": manager},
+		}
+		err := cmd.Execute(context.Background(), exec)
+		return output.String(), err
+	}
+
+	output, err := run(nil, "snapshots", sess.ID)
+	require.NoError(t, err)
+	assert.Contains(t, output, domain.SnapshotReasonBeforeReset)
+
+	_, err = run(nil, "restore", sess.ID)
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+	_, err = run(map[string]interface{}{"at": "yesterday-ish"}, "restore", sess.ID)
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+	_, err = run(map[string]interface{}{"at": "2000-01-01"}, "restore", sess.ID)
+	assert.ErrorIs(t, err, storage.ErrSnapshotNotFound)
+
+	output, err = run(map[string]interface{}{"at": "0s"}, "restore", sess.ID)
+	require.NoError(t, err)
+	assert.Contains(t, output, "restored to snapshot")
+
+	loaded, err := manager.StorageManager.LoadSession(sess.ID)
+	require.NoError(t, err)
+	require.Len(t, loaded.Conversation.Messages, 1)
+	assert.Equal(t, "before the reset", loaded.Conversation.Messages[0].Content)
+}
+
+func TestParseRestoreTime(t *testing.T) {
+	now := time.Date(2025, 1, 31, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2025-01-31T14:30:00Z", time.Date(2025, 1, 31, 14, 30, 0, 0, time.UTC)},
+		{"2025-01-31 14:30", time.Date(2025, 1, 31, 14, 30, 0, 0, time.Local)},
+		{"2025-01-31 14:30:15", time.Date(2025, 1, 31, 14, 30, 15, 0, time.Local)},
+		{"2025-01-30", time.Date(2025, 1, 30, 0, 0, 0, 0, time.Local)},
+		{"2h", now.Add(-2 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := parseRestoreTime(tt.value, now)
+		require.NoError(t, err, tt.value)
+		assert.True(t, tt.want.Equal(got), "%s: got %s, want %s", tt.value, got, tt.want)
+	}
+
+	_, err := parseRestoreTime("last week", now)
+	assert.Error(t, err)
+	_, err = parseRestoreTime("-2h", now)
+	assert.Error(t, err)
+}
+
 func TestHistoryCommand_Execute_Search(t *testing.T) {
 	// Create a temporary directory for the test
 	tempDir, err := os.MkdirTemp("", "history-test-*")
@@ -315,3 +528,245 @@ func TestHistoryCommand_Execute_Search(t *testing.T) {
 	assert.Contains(t, outputStr, "decorators")
 	assert.NotContains(t, outputStr, "JavaScript")
 }
+
+func TestHistoryCommand_Execute_JSON(t *testing.T) {
+	backend, err := storage.CreateBackend(storage.FileSystemBackend, storage.Config{
+		"base_dir": t.TempDir(),
+	})
+	require.NoError(t, err)
+	storageManager, err := session.NewStorageManager(backend)
+	require.NoError(t, err)
+	manager, err := session.NewSessionManager(storageManager)
+	require.NoError(t, err)
+
+	sess, err := manager.NewSession("json-session")
+	require.NoError(t, err)
+	sess.Conversation.AddMessage(createTestMessage("user", "parse me"))
+	require.NoError(t, manager.SaveSession(sess))
+
+	run := func(args ...string) []byte {
+		var output bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(nil),
+			Stdout: &output,
+			Data: map[string]interface{}{
+				"session_manager": manager,
+				"outputFormat":    "json",
+			},
+		}
+		require.NoError(t, NewHistoryCommand().Execute(context.Background(), exec))
+		return output.Bytes()
+	}
+
+	var list struct {
+		Sessions []domain.SessionInfo `json:"sessions"`
+		Count    int                  `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(run("list"), &list))
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, sess.ID, list.Sessions[0].ID)
+	assert.Equal(t, "json-session", list.Sessions[0].Name)
+
+	var shown domain.Session
+	require.NoError(t, json.Unmarshal(run("show", sess.ID), &shown))
+	require.Len(t, shown.Conversation.Messages, 1)
+	assert.Equal(t, "parse me", shown.Conversation.Messages[0].Content)
+
+	var search struct {
+		Query   string                `json:"query"`
+		Results []domain.SearchResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(run("search", "nothing-matches"), &search))
+	assert.Equal(t, "nothing-matches", search.Query)
+	assert.NotNil(t, search.Results)
+
+	var renamed map[string]string
+	require.NoError(t, json.Unmarshal(run("rename", sess.ID, "renamed"), &renamed))
+	assert.Equal(t, "renamed", renamed["name"])
+}
+
+func TestHistoryCommand_Execute_ExportMultiple(t *testing.T) {
+	manager := newAskSessionManager(t)
+	var ids []string
+	for i, tags := range [][]string{{"work"}, {"work", "go"}, {"home"}} {
+		s, err := manager.NewSession(fmt.Sprintf("session %d", i))
+		require.NoError(t, err)
+		s.Tags = tags
+		s.Conversation.AddMessage(createTestMessage("user", fmt.Sprintf("message %d", i)))
+		require.NoError(t, manager.SaveSession(s))
+		ids = append(ids, s.ID)
+	}
+
+	export := func(args []string, flags map[string]interface{}) (string, error) {
+		var output bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   append([]string{"export"}, args...),
+			Flags:  command.NewFlags(flags),
+			Stdout: &output,
+			Data:   map[string]interface{}{"session_manager": manager},
+		}
+		err := NewHistoryCommand().Execute(context.Background(), exec)
+		return output.String(), err
+	}
+
+	t.Run("all sessions into a directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "out")
+		output, err := export(nil, map[string]interface{}{"all": true, "output-dir": dir, "format": "markdown"})
+		require.NoError(t, err)
+		assert.Contains(t, output, "Exported 3 session(s)")
+
+		for i, id := range ids {
+			data, err := os.ReadFile(filepath.Join(dir, id+".md"))
+			require.NoError(t, err)
+			assert.Contains(t, string(data), fmt.Sprintf("message %d", i))
+		}
+	})
+
+	t.Run("tag filter into an archive", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "work.zip")
+		_, err := export(nil, map[string]interface{}{"tag": []string{"work"}, "archive": path})
+		require.NoError(t, err)
+
+		archive, err := zip.OpenReader(path)
+		require.NoError(t, err)
+		defer archive.Close()
+		var names []string
+		for _, f := range archive.File {
+			names = append(names, f.Name)
+		}
+		assert.ElementsMatch(t, []string{ids[0] + ".json", ids[1] + ".json"}, names)
+	})
+
+	t.Run("listed IDs narrowed by tag", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := export([]string{ids[0], ids[2]}, map[string]interface{}{"tag": []string{"home"}, "output-dir": dir})
+		require.NoError(t, err)
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, ids[2]+".json", entries[0].Name())
+	})
+
+	t.Run("invalid combinations", func(t *testing.T) {
+		_, err := export(nil, nil)
+		assert.Error(t, err)
+
+		_, err = export([]string{ids[0], ids[1]}, nil)
+		assert.ErrorContains(t, err, "requires --output-dir or --archive")
+
+		_, err = export(nil, map[string]interface{}{"all": true, "output-dir": t.TempDir(), "archive": "x.zip"})
+		assert.Error(t, err)
+
+		_, err = export(nil, map[string]interface{}{"tag": []string{"none"}, "output-dir": t.TempDir()})
+		assert.ErrorContains(t, err, "no sessions match")
+	})
+}
+
+func TestHistoryCommand_Execute_ExportJSONL(t *testing.T) {
+	manager := newAskSessionManager(t)
+	for i := 0; i < 2; i++ {
+		s, err := manager.NewSession(fmt.Sprintf("session %d", i))
+		require.NoError(t, err)
+		s.Conversation.AddMessage(createTestMessage("user", fmt.Sprintf("question %d", i)))
+		s.Conversation.AddMessage(createTestMessage("assistant", fmt.Sprintf("answer %d", i)))
+		s.Conversation.AddMessage(createTestMessage("user", "follow-up"))
+		s.Conversation.AddMessage(createTestMessage("assistant", "more"))
+		require.NoError(t, manager.SaveSession(s))
+	}
+
+	export := func(flags map[string]interface{}) ([]string, error) {
+		var output bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   []string{"export"},
+			Flags:  command.NewFlags(flags),
+			Stdout: &output,
+			Data:   map[string]interface{}{"session_manager": manager},
+		}
+		err := NewHistoryCommand().Execute(context.Background(), exec)
+		return strings.Split(strings.TrimSpace(output.String()), "\n"), err
+	}
+
+	// Every session goes to stdout, one line each or one per exchange
+	lines, err := export(map[string]interface{}{"all": true, "format": "jsonl"})
+	require.NoError(t, err)
+	assert.Len(t, lines, 2)
+
+	lines, err = export(map[string]interface{}{"all": true, "format": "jsonl", "per-exchange": true, "roles": []string{"user", "assistant"}})
+	require.NoError(t, err)
+	require.Len(t, lines, 4)
+	var line struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	require.Len(t, line.Messages, 2)
+	assert.Equal(t, "user", line.Messages[0].Role)
+	assert.Equal(t, "assistant", line.Messages[1].Role)
+
+	_, err = export(map[string]interface{}{"all": true, "format": "jsonl", "roles": []string{"narrator"}})
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+	_, err = export(map[string]interface{}{"all": true, "format": "jsonl", "dialect": "llama"})
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+}
+
+func TestHistoryCommand_Execute_ExportCSV(t *testing.T) {
+	manager := newAskSessionManager(t)
+	for i := 0; i < 2; i++ {
+		s, err := manager.NewSession(fmt.Sprintf("session %d", i))
+		require.NoError(t, err)
+		s.Conversation.AddMessage(createTestMessage("user", fmt.Sprintf("question %d", i)))
+		s.Conversation.AddMessage(createTestMessage("assistant", fmt.Sprintf("answer %d", i)))
+		require.NoError(t, manager.SaveSession(s))
+	}
+
+	var output bytes.Buffer
+	exec := &command.ExecutionContext{
+		Args:   []string{"export"},
+		Flags:  command.NewFlags(map[string]interface{}{"all": true, "format": "csv"}),
+		Stdout: &output,
+		Data:   map[string]interface{}{"session_manager": manager},
+	}
+	require.NoError(t, NewHistoryCommand().Execute(context.Background(), exec))
+
+	// Both sessions share one table under a single header row
+	rows, err := csv.NewReader(&output).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	assert.Equal(t, "session_id", rows[0][0])
+	assert.Equal(t, []string{"user", "question 0"}, rows[1][2:4])
+	assert.Equal(t, []string{"assistant", "answer 1"}, rows[4][2:4])
+}
+
+func TestHistoryCommand_Pipeline(t *testing.T) {
+	manager := newAskSessionManager(t)
+	for _, content := range []string{"How to use Python decorators?", "What is JavaScript async/await?"} {
+		s, err := manager.NewSession(content[:12])
+		require.NoError(t, err)
+		s.Conversation.AddMessage(createTestMessage("user", content))
+		require.NoError(t, manager.SaveSession(s))
+	}
+
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(NewHistoryCommand()))
+	var output bytes.Buffer
+	executor := command.NewExecutor(registry,
+		command.WithDefaultStreams(nil, &output, &output),
+		command.WithPreExecuteHook(func(ctx context.Context, cmd command.Interface, exec *command.ExecutionContext) error {
+			exec.Data["session_manager"] = manager
+			return nil
+		}))
+
+	err := executor.ParseAndExecute(context.Background(),
+		[]string{"history", "search", "Python", "|", "history", "export", "--format", "markdown"})
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), "Python decorators")
+	assert.NotContains(t, output.String(), "JavaScript")
+	assert.NotContains(t, output.String(), "NAME", "the search table is passed on, not shown")
+
+	err = executor.ParseAndExecute(context.Background(),
+		[]string{"history", "search", "Rust", "|", "history", "export"})
+	assert.ErrorContains(t, err, "no sessions to export from history")
+}
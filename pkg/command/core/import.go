@@ -0,0 +1,177 @@
+// ABOUTME: Import command - Converts conversations exported from other tools into sessions
+// ABOUTME: Supports ChatGPT and Claude data exports, OpenAI messages JSON, and markdown transcripts
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/importer"
+)
+
+// ImportCommand implements importing external conversation exports
+type ImportCommand struct {
+	config *config.Config
+}
+
+// NewImportCommand creates a new import command instance
+func NewImportCommand(cfg *config.Config) *ImportCommand {
+	return &ImportCommand{
+		config: cfg,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *ImportCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "import",
+		Description: "Import conversations from ChatGPT, Claude, OpenAI messages JSON, or markdown",
+		LongDescription: `The import command converts conversations exported from other tools into
+sessions in the configured storage backend, keeping each message's role and
+timestamp. Imported sessions are tagged "imported".
+
+Formats:
+  chatgpt   ChatGPT data export (the zip, or its conversations.json)
+  claude    Claude data export (the zip, or its conversations.json)
+  openai    One conversation as {"messages": [{"role": ..., "content": ...}]}
+  markdown  One conversation as a transcript with a heading per message
+            naming its role ("## User", "### Assistant", ...), such as a
+            markdown export from history export or hand-kept notes
+
+Use "-" as the file to read from stdin. A markdown transcript without a
+"# Title" heading is named after its file.
+
+Examples:
+  magellai import --format chatgpt export.zip
+  magellai import --format claude conversations.json
+  cat chat.json | magellai import --format openai -
+  magellai import --format markdown transcript.md`,
+		Category: command.CategoryCLI,
+		Flags: []command.Flag{
+			{
+				Name:        "format",
+				Short:       "f",
+				Description: "Export format (chatgpt|claude|openai|markdown)",
+				Type:        command.FlagTypeString,
+				Required:    true,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *ImportCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	return nil
+}
+
+// importedSession summarizes one imported session
+type importedSession struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Messages int    `json:"messages"`
+}
+
+// Execute runs the import command
+func (c *ImportCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+
+	if len(exec.Args) == 0 {
+		return fmt.Errorf("import: %w - export file required", command.ErrMissingArgument)
+	}
+	format, err := importer.ParseFormat(exec.Flags.GetString("format"))
+	if err != nil {
+		return fmt.Errorf("import: %w - %v", command.ErrInvalidArguments, err)
+	}
+
+	data, err := readImportFile(exec, exec.Args[0])
+	if err != nil {
+		return err
+	}
+	sessions, err := importer.Import(format, data)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", exec.Args[0], err)
+	}
+
+	manager, err := openSessionManager(c.config, exec)
+	if err != nil {
+		return err
+	}
+	if _, ok := exec.Data["session_manager"]; !ok {
+		defer func() {
+			if err := manager.Close(); err != nil {
+				logging.LogWarn("Failed to close session storage", "error", err)
+			}
+		}()
+	}
+
+	imported := make([]importedSession, 0, len(sessions))
+	for _, s := range sessions {
+		if len(s.Conversation.Messages) == 0 {
+			logging.LogDebug("Skipping empty imported conversation", "name", s.Name)
+			continue
+		}
+		if s.Name == "" && format == importer.FormatMarkdown && exec.Args[0] != "-" {
+			s.Name = strings.TrimSuffix(filepath.Base(exec.Args[0]), filepath.Ext(exec.Args[0]))
+		}
+		if err := manager.StorageManager.SaveSession(s); err != nil {
+			return fmt.Errorf("failed to save imported session %q: %w", s.Name, err)
+		}
+		imported = append(imported, importedSession{ID: s.ID, Name: s.Name, Messages: len(s.Conversation.Messages)})
+	}
+	logging.LogInfo("Imported conversations", "format", format, "file", exec.Args[0], "count", len(imported))
+
+	exec.Data["imported"] = imported
+	lines := []string{fmt.Sprintf("Imported %d session(s) from %s", len(imported), format)}
+	table := command.NewTable("ID", "NAME", "MESSAGES")
+	for _, s := range imported {
+		name := s.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		lines = append(lines, fmt.Sprintf("  %s  %s (%d messages)", s.ID, name, s.Messages))
+		table.AddRow(s.ID, s.Name, s.Messages)
+	}
+	return setOutput(exec, command.Result{
+		Text:  strings.Join(lines, "\n"),
+		Table: table,
+		Value: map[string]interface{}{
+			"format":   format,
+			"imported": imported,
+			"count":    len(imported),
+		},
+	})
+}
+
+// readImportFile reads the export file, or stdin for "-"
+func readImportFile(exec *command.ExecutionContext, path string) ([]byte, error) {
+	if path != "-" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export file: %w", err)
+		}
+		return data, nil
+	}
+
+	in := exec.Stdin
+	if in == nil {
+		in = os.Stdin
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export from stdin: %w", err)
+	}
+	return data, nil
+}
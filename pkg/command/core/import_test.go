@@ -0,0 +1,99 @@
+// ABOUTME: Tests for the import command
+// ABOUTME: Verifies imported conversations are saved to session storage
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCommand_Execute(t *testing.T) {
+	cfg := createTestConfig(t)
+	cmd := NewImportCommand(cfg)
+	require.NoError(t, cmd.Validate())
+
+	export := filepath.Join(t.TempDir(), "chat.json")
+	require.NoError(t, os.WriteFile(export, []byte(`{"title": "Imported chat", "messages": [
+  {"role": "user", "content": "hello"},
+  {"role": "assistant", "content": "hi there"}
+]}`), 0644))
+
+	run := func(args []string, flags map[string]interface{}, data map[string]interface{}) (*command.ExecutionContext, error) {
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdin:  strings.NewReader(`[{"role": "user", "content": "from stdin"}]`),
+			Stdout: &bytes.Buffer{},
+			Data:   data,
+		}
+		return exec, cmd.Execute(context.Background(), exec)
+	}
+
+	t.Run("saves sessions from a file", func(t *testing.T) {
+		manager := newAskSessionManager(t)
+		exec, err := run([]string{export}, map[string]interface{}{"format": "openai"},
+			map[string]interface{}{"session_manager": manager})
+		require.NoError(t, err)
+		assert.Contains(t, exec.Data["output"], "Imported 1 session(s) from openai")
+
+		sessions, err := manager.ListSessions()
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		stored, err := manager.StorageManager.LoadSession(sessions[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Imported chat", stored.Name)
+		assert.Len(t, stored.Conversation.Messages, 2)
+		assert.Contains(t, stored.Tags, "imported")
+	})
+
+	t.Run("reads stdin with JSON output", func(t *testing.T) {
+		manager := newAskSessionManager(t)
+		exec, err := run([]string{"-"}, map[string]interface{}{"format": "openai"},
+			map[string]interface{}{"session_manager": manager, "outputFormat": OutputFormatJSON})
+		require.NoError(t, err)
+
+		var result struct {
+			Count    int               `json:"count"`
+			Imported []importedSession `json:"imported"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(exec.Data["output"].(string)), &result))
+		assert.Equal(t, 1, result.Count)
+		assert.Equal(t, 1, result.Imported[0].Messages)
+	})
+
+	t.Run("names an untitled markdown transcript after its file", func(t *testing.T) {
+		manager := newAskSessionManager(t)
+		notes := filepath.Join(t.TempDir(), "trip-notes.md")
+		require.NoError(t, os.WriteFile(notes, []byte("## Me\nWhere to?\n\n## AI\nLisbon.\n"), 0644))
+		_, err := run([]string{notes}, map[string]interface{}{"format": "markdown"},
+			map[string]interface{}{"session_manager": manager})
+		require.NoError(t, err)
+
+		sessions, err := manager.ListSessions()
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, "trip-notes", sessions[0].Name)
+		assert.Equal(t, 2, sessions[0].MessageCount)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := run(nil, map[string]interface{}{"format": "openai"}, nil)
+		assert.ErrorIs(t, err, command.ErrMissingArgument)
+
+		_, err = run([]string{export}, map[string]interface{}{"format": "bard"}, nil)
+		assert.ErrorIs(t, err, command.ErrInvalidArguments)
+
+		_, err = run([]string{filepath.Join(t.TempDir(), "missing.json")}, map[string]interface{}{"format": "openai"}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,160 @@
+// ABOUTME: Job command for background commands started with & or --background
+// ABOUTME: Lists jobs and shows the status and output of one job or cancels it
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/command"
+)
+
+// JobCommand manages background jobs
+type JobCommand struct {
+	jobs *command.JobManager
+}
+
+// NewJobCommand creates a job command for the jobs of a registry
+func NewJobCommand(jobs *command.JobManager) *JobCommand {
+	return &JobCommand{jobs: jobs}
+}
+
+// Execute runs the job command
+func (c *JobCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+	if len(exec.Args) == 0 {
+		return c.listJobs(exec)
+	}
+
+	switch exec.Args[0] {
+	case "list":
+		return c.listJobs(exec)
+	case "status", "show":
+		job, err := c.job(exec, "status")
+		if err != nil {
+			return err
+		}
+		if exec.Flags.GetBool("wait") {
+			_ = job.Wait(ctx)
+		}
+		return c.showJob(exec, job)
+	case "cancel", "kill":
+		job, err := c.job(exec, "cancel")
+		if err != nil {
+			return err
+		}
+		if err := c.jobs.Cancel(job.ID()); err != nil {
+			return fmt.Errorf("job cancel: %w", err)
+		}
+		exec.Data["job"] = job.Status()
+		if structuredOutputRequested(exec) {
+			return printOutput(exec, map[string]interface{}{"canceled": job.ID()})
+		}
+		fmt.Fprintf(exec.Stdout, "Canceled job %d\n", job.ID())
+		return nil
+	default:
+		return fmt.Errorf("job: %w - unknown subcommand %q", command.ErrInvalidArguments, exec.Args[0])
+	}
+}
+
+// job returns the job numbered by the second argument
+func (c *JobCommand) job(exec *command.ExecutionContext, subcommand string) (*command.Job, error) {
+	if len(exec.Args) < 2 {
+		return nil, fmt.Errorf("job %s: %w - job number required", subcommand, command.ErrMissingArgument)
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(exec.Args[1], "%"))
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w - invalid job number %q", subcommand, command.ErrInvalidArguments, exec.Args[1])
+	}
+	job, err := c.jobs.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", subcommand, err)
+	}
+	return job, nil
+}
+
+// listJobs shows every job with its state
+func (c *JobCommand) listJobs(exec *command.ExecutionContext) error {
+	statuses := make([]command.JobStatus, 0)
+	for _, job := range c.jobs.List() {
+		statuses = append(statuses, job.Status())
+	}
+	exec.Data["jobs"] = statuses
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, map[string]interface{}{"jobs": statuses, "count": len(statuses)})
+	}
+	if len(statuses) == 0 {
+		return printOutput(exec, "No background jobs")
+	}
+
+	table := command.NewTable("ID", "STATE", "TIME", "COMMAND")
+	for _, status := range statuses {
+		table.AddRow(status.ID, status.State, status.Duration.Round(time.Second), status.CommandLine())
+	}
+	return printOutput(exec, table)
+}
+
+// showJob shows a job's state and its output so far
+func (c *JobCommand) showJob(exec *command.ExecutionContext, job *command.Job) error {
+	status := job.Status()
+	exec.Data["job"] = status
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, map[string]interface{}{"job": status, "output": job.Output()})
+	}
+
+	fmt.Fprintf(exec.Stdout, "Job %d: %s\n", status.ID, status.CommandLine())
+	fmt.Fprintf(exec.Stdout, "State: %s\n", status.State)
+	fmt.Fprintf(exec.Stdout, "Started: %s\n", status.Started.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(exec.Stdout, "Time: %s\n", status.Duration.Round(time.Millisecond))
+	if status.Error != "" {
+		fmt.Fprintf(exec.Stdout, "Error: %s\n", status.Error)
+	}
+	if text := job.Output(); text != "" {
+		fmt.Fprintf(exec.Stdout, "\n%s", text)
+	}
+	return nil
+}
+
+// Metadata returns the command metadata
+func (c *JobCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "job",
+		Aliases:     []string{"jobs"},
+		Description: "Manage background jobs",
+		LongDescription: `The job command manages commands running in the background. End a
+command with & or add --background to run it as a job.
+
+Subcommands:
+  list           List jobs (default)
+  status <n>     Show the state and output of job n
+  cancel <n>     Stop job n
+
+Examples:
+  ask "Summarize this report" --attach report.pdf &
+  jobs
+  job status 1 --wait
+  job cancel 1`,
+		Category: command.CategoryShared,
+		Flags: []command.Flag{
+			{
+				Name:        "wait",
+				Description: "Wait for the job to finish before showing its status",
+				Type:        command.FlagTypeBool,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *JobCommand) Validate() error {
+	if c.jobs == nil {
+		return fmt.Errorf("job manager not initialized")
+	}
+	return nil
+}
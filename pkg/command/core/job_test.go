@@ -0,0 +1,98 @@
+// ABOUTME: Tests for the job command
+// ABOUTME: Verifies listing background jobs, showing their status, and canceling them
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobCommand_Metadata(t *testing.T) {
+	meta := NewJobCommand(command.NewJobManager()).Metadata()
+	assert.Equal(t, "job", meta.Name)
+	assert.Equal(t, []string{"jobs"}, meta.Aliases)
+	assert.Len(t, meta.Flags, 1)
+}
+
+func TestJobCommand_Execute(t *testing.T) {
+	jobs := command.NewJobManager()
+	cmd := NewJobCommand(jobs)
+
+	run := func(args []string, flags map[string]interface{}, data map[string]interface{}) (string, error) {
+		var output bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdout: &output,
+			Data:   data,
+		}
+		err := cmd.Execute(context.Background(), exec)
+		return output.String(), err
+	}
+
+	output, err := run(nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "No background jobs\n", output)
+
+	done := jobs.Start(context.Background(), "ask", []string{"hello"}, func(ctx context.Context, out io.Writer) error {
+		_, err := io.WriteString(out, "Hi there\n")
+		return err
+	})
+	running := jobs.Start(context.Background(), "index", []string{"./docs"}, func(ctx context.Context, out io.Writer) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	output, err = run([]string{"status", "1"}, map[string]interface{}{"wait": true}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, output, "Job 1: ask hello")
+	assert.Contains(t, output, "State: done")
+	assert.Contains(t, output, "Hi there")
+
+	output, err = run([]string{"list"}, nil, nil)
+	require.NoError(t, err)
+	assert.Contains(t, output, "1   done")
+	assert.Contains(t, output, "index ./docs")
+
+	output, err = run([]string{"cancel", "%2"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Canceled job 2\n", output)
+	<-running.Done()
+	<-done.Done()
+
+	output, err = run(nil, nil, map[string]interface{}{"outputFormat": OutputFormatJSON})
+	require.NoError(t, err)
+	var result struct {
+		Jobs  []command.JobStatus `json:"jobs"`
+		Count int                 `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &result))
+	assert.Equal(t, 2, result.Count)
+	assert.Equal(t, command.JobCanceled, result.Jobs[1].State)
+
+	output, err = run(nil, nil, map[string]interface{}{"outputFormat": "yaml"})
+	require.NoError(t, err)
+	assert.Contains(t, output, "count: 2\n")
+	assert.Contains(t, output, "state: canceled\n")
+
+	output, err = run(nil, nil, map[string]interface{}{"outputFormat": "markdown"})
+	require.NoError(t, err)
+	assert.Contains(t, output, "| ID | STATE | TIME | COMMAND |\n")
+
+	_, err = run([]string{"cancel", "2"}, nil, nil)
+	assert.ErrorIs(t, err, command.ErrJobNotRunning)
+	_, err = run([]string{"status"}, nil, nil)
+	assert.ErrorIs(t, err, command.ErrMissingArgument)
+	_, err = run([]string{"status", "x"}, nil, nil)
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+	_, err = run([]string{"status", "7"}, nil, nil)
+	assert.ErrorIs(t, err, command.ErrJobNotFound)
+}
@@ -0,0 +1,213 @@
+// ABOUTME: Keys command - Manages provider API keys in the OS keychain
+// ABOUTME: Provides set, get, list, and remove backed by Keychain, Credential Manager, or Secret Service
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/keyring"
+	"github.com/lexlapax/magellai/pkg/llm"
+)
+
+// keyProviders are the providers whose keys can be stored in the keychain
+var keyProviders = []string{llm.ProviderOpenAI, llm.ProviderAnthropic, llm.ProviderGemini}
+
+// KeysCommand implements API key management in the OS keychain
+type KeysCommand struct {
+	config *config.Config
+}
+
+// NewKeysCommand creates a new keys command instance
+func NewKeysCommand(cfg *config.Config) *KeysCommand {
+	return &KeysCommand{
+		config: cfg,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *KeysCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "keys",
+		Aliases:     []string{"key"},
+		Description: "Manage provider API keys in the OS keychain",
+		LongDescription: `The keys command stores provider API keys in the macOS Keychain, the
+Windows Credential Manager, or the Secret Service on Linux (via secret-tool).
+Keys in the keychain take precedence over environment variables and config.
+
+Subcommands:
+  list                   Show where each provider's key comes from
+  set <provider> [key]   Store a key (read from stdin or prompted if omitted)
+  get <provider>         Show a stored key (masked unless --reveal)
+  remove <provider>      Delete a stored key
+
+Set MAGELLAI_NO_KEYRING=1 to skip the keychain entirely.
+
+Examples:
+  magellai keys set openai
+  echo "$KEY" | magellai keys set anthropic
+  magellai keys get openai --reveal
+  magellai keys list`,
+		Category: command.CategoryCLI,
+		Flags: []command.Flag{
+			{
+				Name:        "reveal",
+				Description: "Print the full key (get)",
+				Type:        command.FlagTypeBool,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *KeysCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	return nil
+}
+
+// Execute runs the keys command
+func (c *KeysCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+
+	if len(exec.Args) == 0 || exec.Args[0] == "list" {
+		return c.listKeys(ctx, exec)
+	}
+
+	subcommand := exec.Args[0]
+	if len(exec.Args) < 2 {
+		return fmt.Errorf("keys %s: %w - provider required", subcommand, command.ErrMissingArgument)
+	}
+	provider := strings.ToLower(exec.Args[1])
+	if !isKeyProvider(provider) {
+		return fmt.Errorf("keys %s: %w - unknown provider '%s' (expected %s)",
+			subcommand, command.ErrInvalidArguments, provider, strings.Join(keyProviders, ", "))
+	}
+
+	switch subcommand {
+	case "set", "add":
+		return c.setKey(exec, provider, strings.Join(exec.Args[2:], ""))
+	case "get", "show":
+		return c.getKey(exec, provider)
+	case "remove", "delete", "rm":
+		return c.removeKey(exec, provider)
+	default:
+		return fmt.Errorf("keys: %w - invalid subcommand '%s'", command.ErrInvalidArguments, subcommand)
+	}
+}
+
+// setKey stores a provider key, reading it from stdin or a prompt when not given
+func (c *KeysCommand) setKey(exec *command.ExecutionContext, provider, key string) error {
+	if key == "" {
+		var err error
+		if key, err = command.PrompterFor(exec).Secret(fmt.Sprintf("API key for %s: ", provider)); err != nil {
+			return err
+		}
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("keys set: %w - key required", command.ErrMissingArgument)
+	}
+
+	if err := keyring.Set(provider, key); err != nil {
+		return fmt.Errorf("failed to store key in %s: %w", keyring.Name(), err)
+	}
+
+	logging.LogInfo("API key stored in keychain", "provider", provider, "key", llm.SanitizeAPIKey(key))
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("Stored %s key in %s", provider, keyring.Name()),
+		map[string]string{"provider": provider, "keyring": keyring.Name(), "key": llm.SanitizeAPIKey(key)})
+}
+
+// getKey prints a stored key, masked unless --reveal is given
+func (c *KeysCommand) getKey(exec *command.ExecutionContext, provider string) error {
+	key, err := keyring.Get(provider)
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("no %s key in %s", provider, keyring.Name())
+		}
+		return fmt.Errorf("failed to read key from %s: %w", keyring.Name(), err)
+	}
+	if !exec.Flags.GetBool("reveal") {
+		key = llm.SanitizeAPIKey(key)
+	}
+
+	return setResultOutput(exec, outputFormat(exec), key,
+		map[string]string{"provider": provider, "key": key})
+}
+
+// removeKey deletes a stored key
+func (c *KeysCommand) removeKey(exec *command.ExecutionContext, provider string) error {
+	if err := keyring.Delete(provider); err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("no %s key in %s", provider, keyring.Name())
+		}
+		return fmt.Errorf("failed to remove key from %s: %w", keyring.Name(), err)
+	}
+
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("Removed %s key from %s", provider, keyring.Name()),
+		map[string]string{"removed": provider})
+}
+
+// keyStatus describes where a provider's key is resolved from
+type keyStatus struct {
+	Provider string `json:"provider"`
+	Source   string `json:"source"`
+	Key      string `json:"key,omitempty"`
+}
+
+// listKeys shows the source of each provider's key, in resolution order
+func (c *KeysCommand) listKeys(ctx context.Context, exec *command.ExecutionContext) error {
+	statuses := make([]keyStatus, 0, len(keyProviders))
+	for _, provider := range keyProviders {
+		status := keyStatus{Provider: provider, Source: "none"}
+		if key, err := keyring.Get(provider); err == nil && key != "" {
+			status.Source, status.Key = "keychain", key
+		} else if key := c.config.GetProviderAPIKeyContext(ctx, provider); key != "" {
+			status.Source, status.Key = "environment/config", key
+		}
+		if status.Key != "" {
+			status.Key = llm.SanitizeAPIKey(status.Key)
+		}
+		statuses = append(statuses, status)
+	}
+
+	lines := []string{fmt.Sprintf("API keys (keyring: %s):", keyring.Name())}
+	table := command.NewTable("PROVIDER", "SOURCE", "KEY")
+	for _, status := range statuses {
+		table.AddRow(status.Provider, status.Source, status.Key)
+		if status.Key == "" {
+			lines = append(lines, fmt.Sprintf("  %-10s not set", status.Provider))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %-10s %-20s %s", status.Provider, status.Source, status.Key))
+	}
+	return setOutput(exec, command.Result{
+		Text:  strings.Join(lines, "\n"),
+		Table: table,
+		Value: map[string]interface{}{
+			"keyring": keyring.Name(),
+			"keys":    statuses,
+		},
+	})
+}
+
+// isKeyProvider reports whether provider uses an API key
+func isKeyProvider(provider string) bool {
+	for _, p := range keyProviders {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+// ABOUTME: Tests for the keys command
+// ABOUTME: Verifies storing, reading, listing, and removing keys with an in-memory keyring
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeysCommand_Execute(t *testing.T) {
+	t.Setenv(keyring.DisableEnvVar, "")
+	t.Setenv("MAGELLAI_OPENAI_API_KEY", "")
+	restore := keyring.SetBackend(keyring.NewMemoryBackend())
+	defer restore()
+
+	cfg := createTestConfig(t)
+	run := func(args []string, flags, data map[string]interface{}, stdin string) (string, error) {
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdin:  strings.NewReader(stdin),
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Data:   data,
+		}
+		err := NewKeysCommand(cfg).Execute(context.Background(), exec)
+		output, _ := exec.Data["output"].(string)
+		return output, err
+	}
+
+	output, err := run([]string{"set", "openai", "sk-openai-test-key-1234"}, nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Stored openai key in memory", output)
+
+	output, err = run([]string{"set", "anthropic"}, nil, nil, "sk-ant-from-stdin-5678\n")
+	require.NoError(t, err)
+	assert.Contains(t, output, "anthropic")
+	stored, err := keyring.Get("anthropic")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-ant-from-stdin-5678", stored)
+
+	output, err = run([]string{"get", "openai"}, nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-ope...1234", output)
+
+	output, err = run([]string{"get", "openai"}, map[string]interface{}{"reveal": true}, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-openai-test-key-1234", output)
+
+	assert.Equal(t, "sk-openai-test-key-1234", cfg.GetProviderAPIKey("openai"), "keychain wins over config")
+
+	output, err = run([]string{"list"}, nil, map[string]interface{}{"outputFormat": "json"}, "")
+	require.NoError(t, err)
+	var list struct {
+		Keys []keyStatus `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &list))
+	require.Len(t, list.Keys, 3)
+	assert.Equal(t, "keychain", list.Keys[0].Source)
+	assert.NotContains(t, output, "sk-openai-test-key-1234")
+
+	_, err = run([]string{"remove", "openai"}, nil, nil, "")
+	require.NoError(t, err)
+	_, err = run([]string{"get", "openai"}, nil, nil, "")
+	assert.ErrorContains(t, err, "no openai key")
+
+	_, err = run([]string{"set", "ollama", "x"}, nil, nil, "")
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+	_, err = run([]string{"set"}, nil, nil, "")
+	assert.ErrorIs(t, err, command.ErrMissingArgument)
+}
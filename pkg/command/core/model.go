@@ -1,17 +1,19 @@
 // ABOUTME: Model command implementation for switching between LLM models
-// ABOUTME: Supports list, select, info, and validation operations
+// ABOUTME: Supports list, select, info, update, and validation operations
 
 package core
 
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/command"
 	"github.com/lexlapax/magellai/pkg/config"
 	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
 )
 
 // OutputFormat constants for different output formats
@@ -45,6 +47,8 @@ func (c *ModelCommand) Execute(ctx context.Context, exec *command.ExecutionConte
 			return c.listModels(ctx, exec)
 		case "info":
 			return c.showModelInfo(ctx, exec)
+		case "update":
+			return c.updateInventory(ctx, exec)
 		default:
 			// If not a subcommand, handle model selection
 			return c.selectModel(ctx, exec)
@@ -75,6 +79,12 @@ func (c *ModelCommand) Metadata() *command.Metadata {
 				Type:        command.FlagTypeString,
 				Required:    false,
 			},
+			{
+				Name:        "url",
+				Description: "URL to download models.json from (update)",
+				Type:        command.FlagTypeString,
+				Required:    false,
+			},
 		},
 		LongDescription: `The model command manages LLM models. Examples:
 			model                          # Show current model
@@ -82,7 +92,12 @@ func (c *ModelCommand) Metadata() *command.Metadata {
 			model anthropic/claude-3-opus # Switch to Anthropic Claude 3 Opus  
 			model list                    # List all available models
 			model list --provider openai  # List OpenAI models
-			model info gemini/pro         # Show info about Gemini Pro`,
+			model info gemini/pro         # Show info about Gemini Pro
+			model update                  # Install the latest models.json
+
+model update downloads models.json from model.inventory_url (default: the
+project repository), validates it, and installs it in ~/.config/magellai,
+keeping the previous file as models.json.bak.`,
 	}
 }
 
@@ -156,15 +171,9 @@ func (c *ModelCommand) listModels(ctx context.Context, exec *command.ExecutionCo
 		filteredModels = append(filteredModels, model)
 	}
 
-	// Format output
-	if outputFormat, ok := exec.Data["outputFormat"]; ok && outputFormat == OutputFormatJSON {
-		exec.Data["output"] = filteredModels
-		return nil
-	}
-
-	// Text output
-	output := strings.Builder{}
-	output.WriteString("Available Models:\n\n")
+	// Text grouped by provider, with a flat table for --output table
+	lines := []string{"Available Models:", ""}
+	table := command.NewTable("MODEL", "CAPABILITIES", "CURRENT")
 
 	currentProvider := ""
 	currentModel := c.config.GetDefaultModel()
@@ -172,40 +181,52 @@ func (c *ModelCommand) listModels(ctx context.Context, exec *command.ExecutionCo
 	for _, model := range filteredModels {
 		if model.Provider != currentProvider {
 			if currentProvider != "" {
-				output.WriteString("\n")
+				lines = append(lines, "")
 			}
-			output.WriteString(fmt.Sprintf("%s:\n", capitalizeProviderName(model.Provider)))
+			lines = append(lines, fmt.Sprintf("%s:", capitalizeProviderName(model.Provider)))
 			currentProvider = model.Provider
 		}
 
 		modelName := fmt.Sprintf("%s/%s", model.Provider, model.Model)
-		indicator := "  "
+		indicator, current := "  ", ""
 		if modelName == currentModel {
-			indicator = "* "
+			indicator, current = "* ", "*"
 		}
 
-		caps := []string{}
-		if model.Capabilities.Text {
-			caps = append(caps, "text")
-		}
-		if model.Capabilities.Audio {
-			caps = append(caps, "audio")
-		}
-		if model.Capabilities.Video {
-			caps = append(caps, "video")
-		}
-		if model.Capabilities.Image {
-			caps = append(caps, "image")
-		}
-		if model.Capabilities.File {
-			caps = append(caps, "file")
-		}
-
-		output.WriteString(fmt.Sprintf("%s%s [%s]\n", indicator, model.Model, strings.Join(caps, ", ")))
+		caps := capabilityNames(model.Capabilities)
+		lines = append(lines, fmt.Sprintf("%s%s [%s]", indicator, model.Model, strings.Join(caps, ", ")))
+		table.AddRow(modelName, strings.Join(caps, ","), current)
 	}
 
-	exec.Data["output"] = output.String()
-	return nil
+	return setOutput(exec, command.Result{
+		Text:  strings.Join(lines, "\n"),
+		Table: table,
+		Value: map[string]interface{}{
+			"models":  filteredModels,
+			"count":   len(filteredModels),
+			"current": currentModel,
+		},
+	})
+}
+
+// capabilityNames lists the input types a model supports
+func capabilityNames(caps llm.ModelCapabilities) []string {
+	names := []string{}
+	for _, capability := range []struct {
+		name      string
+		supported bool
+	}{
+		{"text", caps.Text},
+		{"audio", caps.Audio},
+		{"video", caps.Video},
+		{"image", caps.Image},
+		{"file", caps.File},
+	} {
+		if capability.supported {
+			names = append(names, capability.name)
+		}
+	}
+	return names
 }
 
 // showModelInfo shows detailed information about a model
@@ -230,45 +251,27 @@ func (c *ModelCommand) showModelInfo(ctx context.Context, exec *command.Executio
 		return fmt.Errorf("model not found: %s", modelName)
 	}
 
-	// Format output
-	if outputFormat, ok := exec.Data["outputFormat"]; ok && outputFormat == OutputFormatJSON {
-		exec.Data["output"] = modelInfo
-		return nil
+	lines := []string{
+		fmt.Sprintf("Model: %s/%s", modelInfo.Provider, modelInfo.Model),
+		fmt.Sprintf("Provider: %s", capitalizeProviderName(modelInfo.Provider)),
+		fmt.Sprintf("Display Name: %s", modelInfo.DisplayName),
+		fmt.Sprintf("Description: %s", modelInfo.Description),
+		"",
+		"Capabilities:",
 	}
-
-	// Text output
-	output := strings.Builder{}
-	output.WriteString(fmt.Sprintf("Model: %s/%s\n", modelInfo.Provider, modelInfo.Model))
-	output.WriteString(fmt.Sprintf("Provider: %s\n", capitalizeProviderName(modelInfo.Provider)))
-	output.WriteString(fmt.Sprintf("Display Name: %s\n", modelInfo.DisplayName))
-	output.WriteString(fmt.Sprintf("Description: %s\n", modelInfo.Description))
-	output.WriteString("\nCapabilities:\n")
-
-	if modelInfo.Capabilities.Text {
-		output.WriteString("  - Text processing\n")
+	for _, capability := range capabilityNames(modelInfo.Capabilities) {
+		lines = append(lines, fmt.Sprintf("  - %s%s processing", strings.ToUpper(capability[:1]), capability[1:]))
 	}
-	if modelInfo.Capabilities.Audio {
-		output.WriteString("  - Audio processing\n")
-	}
-	if modelInfo.Capabilities.Video {
-		output.WriteString("  - Video processing\n")
-	}
-	if modelInfo.Capabilities.Image {
-		output.WriteString("  - Image processing\n")
-	}
-	if modelInfo.Capabilities.File {
-		output.WriteString("  - File processing\n")
-	}
-
-	output.WriteString(fmt.Sprintf("\nMax Tokens: %d\n", modelInfo.MaxTokens))
-	output.WriteString(fmt.Sprintf("Context Window: %d\n", modelInfo.ContextWindow))
-
+	lines = append(lines,
+		"",
+		fmt.Sprintf("Max Tokens: %d", modelInfo.MaxTokens),
+		fmt.Sprintf("Context Window: %d", modelInfo.ContextWindow),
+	)
 	if modelInfo.DefaultTemperature > 0 {
-		output.WriteString(fmt.Sprintf("Default Temperature: %.2f\n", modelInfo.DefaultTemperature))
+		lines = append(lines, fmt.Sprintf("Default Temperature: %.2f", modelInfo.DefaultTemperature))
 	}
 
-	exec.Data["output"] = output.String()
-	return nil
+	return setOutput(exec, command.Result{Text: strings.Join(lines, "\n"), Value: modelInfo})
 }
 
 // selectModel switches to a specified model
@@ -304,18 +307,73 @@ func (c *ModelCommand) selectModel(ctx context.Context, exec *command.ExecutionC
 	// Log the model change
 	logging.LogInfo("Model changed", "from", currentModel, "to", modelName)
 
-	// Format output
-	if outputFormat, ok := exec.Data["outputFormat"]; ok && outputFormat == OutputFormatJSON {
-		exec.Data["output"] = map[string]string{
+	return setOutput(exec, command.Result{
+		Text: fmt.Sprintf("Switched to %s (%s)", modelInfo.DisplayName, modelName),
+		Value: map[string]string{
 			"provider": provider,
 			"model":    modelName,
+			"previous": currentModel,
 			"message":  fmt.Sprintf("Switched to %s", modelName),
-		}
-		return nil
+		},
+	})
+}
+
+// inventoryUpdate describes an installed models inventory
+type inventoryUpdate struct {
+	URL             string `json:"url"`
+	Path            string `json:"path"`
+	Backup          string `json:"backup,omitempty"`
+	Version         string `json:"version"`
+	PreviousVersion string `json:"previous_version,omitempty"`
+	Models          int    `json:"models"`
+}
+
+// updateInventory downloads the latest models.json and installs it in the
+// user config directory
+func (c *ModelCommand) updateInventory(ctx context.Context, exec *command.ExecutionContext) error {
+	url := exec.Flags.GetString("url")
+	if url == "" {
+		url = c.config.GetString("model.inventory_url")
+	}
+	if url == "" {
+		url = models.DefaultInventoryURL
+	}
+	path, err := models.UserInventoryPath()
+	if err != nil {
+		return err
 	}
 
-	exec.Data["output"] = fmt.Sprintf("Switched to %s (%s)", modelInfo.DisplayName, modelName)
-	return nil
+	logging.LogInfo("Downloading models inventory", "url", url)
+	data, inventory, err := models.DownloadInventory(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	result := inventoryUpdate{
+		URL:     url,
+		Path:    path,
+		Version: inventory.GetLatestVersion(),
+		Models:  len(inventory.Models),
+	}
+	if previous, err := models.LoadInventoryFile(path); err == nil {
+		result.PreviousVersion = previous.GetLatestVersion()
+	}
+	if result.Backup, err = models.InstallInventory(data, path); err != nil {
+		return err
+	}
+	logging.LogInfo("Installed models inventory", "path", path, "version", result.Version, "models", result.Models)
+
+	lines := []string{fmt.Sprintf("Installed models inventory %s (%d models) to %s", result.Version, result.Models, path)}
+	if result.PreviousVersion != "" {
+		lines = append(lines, fmt.Sprintf("Previous version: %s", result.PreviousVersion))
+	}
+	if result.Backup != "" {
+		lines = append(lines, fmt.Sprintf("Backup: %s", result.Backup))
+	}
+	if file := os.Getenv(models.InventoryEnvVar); file != "" {
+		lines = append(lines, fmt.Sprintf("Note: %s is set, so %s is used instead", models.InventoryEnvVar, file))
+	}
+	return setOutput(exec, command.Result{Text: strings.Join(lines, "\n"), Value: result})
 }
 
 // showCurrentModel displays the currently selected model
@@ -323,8 +381,7 @@ func (c *ModelCommand) showCurrentModel(ctx context.Context, exec *command.Execu
 	currentModel := c.config.GetDefaultModel()
 
 	if currentModel == "" {
-		exec.Data["output"] = "No model selected"
-		return nil
+		return setOutput(exec, command.Result{Text: "No model selected", Value: map[string]string{"model": ""}})
 	}
 
 	// Parse provider/model format
@@ -333,20 +390,21 @@ func (c *ModelCommand) showCurrentModel(ctx context.Context, exec *command.Execu
 	// Get model info
 	modelInfo, err := llm.GetModelInfo(provider, model)
 	if err != nil {
-		exec.Data["output"] = fmt.Sprintf("Current model: %s (not found in registry)", currentModel)
-		return nil
+		return setOutput(exec, command.Result{
+			Text: fmt.Sprintf("Current model: %s (not found in registry)", currentModel),
+			Value: map[string]string{
+				"provider": provider,
+				"model":    currentModel,
+			},
+		})
 	}
 
-	// Format output
-	if outputFormat, ok := exec.Data["outputFormat"]; ok && outputFormat == OutputFormatJSON {
-		exec.Data["output"] = map[string]string{
+	return setOutput(exec, command.Result{
+		Text: fmt.Sprintf("Current model: %s (%s)", modelInfo.DisplayName, currentModel),
+		Value: map[string]string{
 			"provider":     provider,
 			"model":        currentModel,
 			"display_name": modelInfo.DisplayName,
-		}
-		return nil
-	}
-
-	exec.Data["output"] = fmt.Sprintf("Current model: %s (%s)", modelInfo.DisplayName, currentModel)
-	return nil
+		},
+	})
 }
@@ -1,15 +1,20 @@
 // ABOUTME: Unit tests for the model command implementation
-// ABOUTME: Tests list, select, info, and validation operations
+// ABOUTME: Tests list, select, info, update, and validation operations
 
 package core
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/lexlapax/magellai/pkg/command"
 	"github.com/lexlapax/magellai/pkg/config"
 	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -224,8 +229,8 @@ func TestModelCommand_Execute(t *testing.T) {
 			},
 			expectedError: false,
 			checkOutput: func(t *testing.T, output interface{}) {
-				data, ok := output.(map[string]string)
-				require.True(t, ok)
+				var data map[string]string
+				require.NoError(t, json.Unmarshal([]byte(output.(string)), &data))
 				assert.Equal(t, "openai", data["provider"])
 				assert.Equal(t, "openai/gpt-4", data["model"])
 				assert.Equal(t, "GPT-4", data["display_name"])
@@ -243,10 +248,13 @@ func TestModelCommand_Execute(t *testing.T) {
 			},
 			expectedError: false,
 			checkOutput: func(t *testing.T, output interface{}) {
-				// Should be a slice of ModelInfo structs
-				models, ok := output.([]llm.ModelInfo)
-				require.True(t, ok, "Expected []llm.ModelInfo, got %T", output)
-				assert.NotEmpty(t, models)
+				var data struct {
+					Models []llm.ModelInfo `json:"models"`
+					Count  int             `json:"count"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(output.(string)), &data))
+				assert.NotEmpty(t, data.Models)
+				assert.Equal(t, len(data.Models), data.Count)
 			},
 		},
 		{
@@ -290,6 +298,36 @@ func TestModelCommand_Execute(t *testing.T) {
 	}
 }
 
+func TestModelCommand_Update(t *testing.T) {
+	cfg := createTestConfig(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"_metadata": {"version": "2.0.0"}, "models": [{"provider": "openai", "name": "gpt-5"}]}`))
+	}))
+	defer server.Close()
+	require.NoError(t, cfg.SetValue("model.inventory_url", server.URL))
+
+	cmd := NewModelCommand(cfg)
+	exec := &command.ExecutionContext{
+		Args:  []string{"update"},
+		Flags: command.NewFlags(nil),
+		Data:  make(map[string]interface{}),
+	}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	assert.Contains(t, exec.Data["output"], "Installed models inventory 2.0.0 (1 models)")
+
+	path, err := models.UserInventoryPath()
+	require.NoError(t, err)
+	inv, err := models.LoadInventory(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", inv.GetLatestVersion())
+
+	// A second update keeps the first as a backup
+	exec.Data = map[string]interface{}{"outputFormat": OutputFormatJSON}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	assert.Contains(t, exec.Data["output"], `"backup": "`+path+`.bak"`)
+	assert.FileExists(t, path+".bak")
+}
+
 func TestModelCommand_Metadata(t *testing.T) {
 	cmd := NewModelCommand(&config.Config{})
 	meta := cmd.Metadata()
@@ -297,7 +335,7 @@ func TestModelCommand_Metadata(t *testing.T) {
 	assert.Equal(t, "model", meta.Name)
 	assert.NotEmpty(t, meta.Description)
 	assert.Equal(t, command.CategoryShared, meta.Category)
-	assert.Len(t, meta.Flags, 2)
+	assert.Len(t, meta.Flags, 3)
 	assert.NotEmpty(t, meta.LongDescription)
 }
 
@@ -0,0 +1,47 @@
+// ABOUTME: Helpers for rendering command output in the global output format
+// ABOUTME: Stores or prints results as text, JSON, YAML, tables, or Markdown via command.Formatter
+
+package core
+
+import (
+	"github.com/lexlapax/magellai/pkg/command"
+)
+
+// outputFormat returns the global --output format passed to the command in
+// exec.Data["outputFormat"]
+func outputFormat(exec *command.ExecutionContext) command.OutputFormat {
+	return command.OutputFormatOf(exec)
+}
+
+// structuredOutputRequested reports whether the global output format is
+// one for programs, JSON or YAML
+func structuredOutputRequested(exec *command.ExecutionContext) bool {
+	return command.FormatterFor(exec).Structured()
+}
+
+// setOutput stores v rendered in the command's output format in
+// exec.Data["output"]
+func setOutput(exec *command.ExecutionContext, v interface{}) error {
+	return setFormattedOutput(exec, outputFormat(exec), v)
+}
+
+// setFormattedOutput stores v rendered in format in exec.Data["output"]
+func setFormattedOutput(exec *command.ExecutionContext, format command.OutputFormat, v interface{}) error {
+	text, err := command.NewFormatter(format).Format(v)
+	if err != nil {
+		return err
+	}
+	exec.Data["output"] = text
+	return nil
+}
+
+// printOutput writes v to stdout in the command's output format, for
+// commands that print their output directly
+func printOutput(exec *command.ExecutionContext, v interface{}) error {
+	return command.FormatterFor(exec).Write(exec.Stdout, v)
+}
+
+// setResultOutput stores result in format, with message as its text view
+func setResultOutput(exec *command.ExecutionContext, format command.OutputFormat, message string, result interface{}) error {
+	return setFormattedOutput(exec, format, command.Result{Text: message, Value: result})
+}
@@ -0,0 +1,137 @@
+// ABOUTME: Plugin command - Runs an external magellai-<name> executable as a subcommand
+// ABOUTME: Passes arguments through and sends config and the latest session id as JSON on stdin
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/plugin"
+)
+
+// PluginCommand runs an external plugin executable
+type PluginCommand struct {
+	config      *config.Config
+	plugin      plugin.Plugin
+	description string
+	version     string
+}
+
+// NewPluginCommand creates a command for a discovered plugin. An empty
+// description falls back to the plugin's path.
+func NewPluginCommand(cfg *config.Config, p plugin.Plugin, description, version string) *PluginCommand {
+	if description == "" {
+		description = fmt.Sprintf("External plugin (%s)", p.Path)
+	}
+	return &PluginCommand{
+		config:      cfg,
+		plugin:      p,
+		description: description,
+		version:     version,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *PluginCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        c.plugin.Name,
+		Description: c.description,
+		LongDescription: fmt.Sprintf(`%s
+
+Provided by the external plugin %s. Arguments are passed to the plugin
+unchanged; run "magellai %s --help" for its own help.`, c.description, c.plugin.Path, c.plugin.Name),
+		Category: command.CategoryCLI,
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *PluginCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	if c.plugin.Path == "" {
+		return fmt.Errorf("%w: %s", plugin.ErrPluginNotFound, c.plugin.Name)
+	}
+	return nil
+}
+
+// Execute runs the plugin with the command arguments
+func (c *PluginCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+
+	outputFormat, _ := exec.Data["outputFormat"].(string)
+	pctx := plugin.Context{
+		Name:         c.plugin.Name,
+		Version:      c.version,
+		Args:         exec.Args,
+		SessionID:    c.latestSessionID(exec),
+		OutputFormat: outputFormat,
+		Config:       redactSecrets(c.config.All()),
+	}
+	if pctx.Args == nil {
+		pctx.Args = []string{}
+	}
+
+	stdout, stderr := exec.Stdout, exec.Stderr
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	return c.plugin.Run(ctx, pctx, stdout, stderr)
+}
+
+// latestSessionID returns the most recent session's id, or an empty string
+// when there is none or storage is unavailable
+func (c *PluginCommand) latestSessionID(exec *command.ExecutionContext) string {
+	manager, err := openSessionManager(c.config, exec)
+	if err != nil {
+		logging.LogDebug("Plugin context has no session storage", "error", err)
+		return ""
+	}
+	if _, ok := exec.Data["session_manager"]; !ok {
+		defer func() {
+			if err := manager.Close(); err != nil {
+				logging.LogWarn("Failed to close session storage", "error", err)
+			}
+		}()
+	}
+
+	latest, err := latestSession(manager)
+	if err != nil || latest == nil {
+		return ""
+	}
+	return latest.ID
+}
+
+// redactSecrets returns a copy of a config map without secrets (keys that
+// config.IsSensitiveKey reports, and the secrets backend settings), so plugins
+// never receive credentials
+func redactSecrets(values map[string]interface{}) map[string]interface{} {
+	return redactSecretsUnder("", values)
+}
+
+// redactSecretsUnder redacts a config map whose keys are nested under prefix
+func redactSecretsUnder(prefix string, values map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		key := prefix + k
+		if config.IsSensitiveKey(key) || key == "secrets" || strings.HasPrefix(key, "secrets.") {
+			continue
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = redactSecretsUnder(key+".", nested)
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
@@ -0,0 +1,86 @@
+// ABOUTME: Tests for running external plugins as commands
+// ABOUTME: Verifies the JSON context a plugin receives and that API keys are withheld
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginCommand_Execute(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+
+	cfg := createTestConfig(t)
+	require.NoError(t, cfg.SetValue("provider.openai.api_key", "sk-secret"))
+
+	path := filepath.Join(t.TempDir(), "magellai-echo")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\ncat\n"), 0755))
+	cmd := NewPluginCommand(cfg, plugin.Plugin{Name: "echo", Path: path}, "", "1.2.3")
+
+	assert.Equal(t, "echo", cmd.Metadata().Name)
+	assert.Contains(t, cmd.Metadata().Description, path)
+	require.NoError(t, cmd.Validate())
+
+	manager := newAskSessionManager(t)
+	sess, err := manager.NewSession("plugin test")
+	require.NoError(t, err)
+
+	var stdout bytes.Buffer
+	exec := &command.ExecutionContext{
+		Args:   []string{"--flag", "value"},
+		Flags:  command.NewFlags(nil),
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Data: map[string]interface{}{
+			"session_manager": manager,
+			"outputFormat":    OutputFormatJSON,
+		},
+	}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+
+	var received plugin.Context
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &received))
+	assert.Equal(t, "echo", received.Name)
+	assert.Equal(t, "1.2.3", received.Version)
+	assert.Equal(t, []string{"--flag", "value"}, received.Args)
+	assert.Equal(t, sess.ID, received.SessionID)
+	assert.Equal(t, OutputFormatJSON, received.OutputFormat)
+	assert.NotEmpty(t, received.Config)
+	assert.NotContains(t, stdout.String(), "sk-secret")
+}
+
+func TestRedactSecrets(t *testing.T) {
+	redacted := redactSecrets(map[string]interface{}{
+		"provider.openai.api_key": "sk-flat",
+		"model.default":           "openai/gpt-4o",
+		"server.token":            "bearer",
+		"secrets":                 map[string]interface{}{"command": "pass show {key}"},
+		"aliases":                 map[string]interface{}{"token": "show tokens"},
+		"proxy":                   map[string]interface{}{"password": "hunter2", "url": "http://proxy"},
+		"provider": map[string]interface{}{
+			"anthropic": map[string]interface{}{"api_key": "sk-nested", "base_url": "https://example.com"},
+		},
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"model.default": "openai/gpt-4o",
+		"aliases":       map[string]interface{}{"token": "show tokens"},
+		"proxy":         map[string]interface{}{"url": "http://proxy"},
+		"provider": map[string]interface{}{
+			"anthropic": map[string]interface{}{"base_url": "https://example.com"},
+		},
+	}, redacted)
+}
@@ -52,7 +52,11 @@ func (p *ProfileCommand) Execute(ctx context.Context, exec *command.ExecutionCon
 		return p.createProfile(ctx, exec, exec.Args[1])
 	case "switch":
 		if len(exec.Args) < 2 {
-			return fmt.Errorf("profile switch: %w - name required", command.ErrMissingArgument)
+			name, err := p.chooseProfile(exec)
+			if err != nil {
+				return err
+			}
+			return p.switchProfile(ctx, exec, name)
 		}
 		return p.switchProfile(ctx, exec, exec.Args[1])
 	case "delete":
@@ -96,10 +100,12 @@ func (p *ProfileCommand) Metadata() *command.Metadata {
 
 Subcommands:
   list               List all available profiles
-  show [name]        Show profile details (current if none specified)
+  show [name]        Show profile details (current if none specified);
+                     --resolved includes values from extended profiles
   create <name>      Create a new profile
-  switch <name>      Switch to a different profile
-  delete <name>      Delete a profile
+  switch [name]      Switch to a different profile, chosen from a list
+                     in a terminal when no name is given
+  delete <name>      Delete a profile, after confirming in a terminal
   update <name> k=v  Update profile settings
   copy <src> <dst>   Copy a profile
   export <name>      Export profile configuration
@@ -109,6 +115,7 @@ Examples:
   profile                  # Show current profile
   profile list             # List all profiles
   profile show work        # Show work profile details
+  profile show work --resolved  # Include values work inherits via extends
   profile create fast      # Create new fast profile
   profile switch work      # Switch to work profile
   profile work             # Also switches to work profile
@@ -125,6 +132,18 @@ Examples:
 				Type:        command.FlagTypeString,
 				Default:     "text",
 			},
+			{
+				Name:        "resolved",
+				Description: "Show a profile merged with the profiles it extends (show subcommand)",
+				Type:        command.FlagTypeBool,
+				Default:     false,
+			},
+			{
+				Name:        command.FlagYes,
+				Short:       "y",
+				Description: "Delete without asking for confirmation (delete subcommand)",
+				Type:        command.FlagTypeBool,
+			},
 		},
 	}
 }
@@ -148,37 +167,43 @@ func (p *ProfileCommand) showCurrentProfile(ctx context.Context, exec *command.E
 	profileConfig, err := p.config.GetProfile(current)
 	if err != nil {
 		// Profile might not exist, just show basic info
-		exec.Data["output"] = fmt.Sprintf("Current profile: %s", current)
-		return nil
-	}
+		return setResultOutput(exec, p.getOutputFormat(exec),
+			fmt.Sprintf("Current profile: %s", current),
+			map[string]interface{}{"current": current})
+	}
+
+	lines := []string{fmt.Sprintf("Current profile: %s", current)}
+	lines = append(lines, profileFieldLines(profileConfig)...)
+	return setFormattedOutput(exec, p.getOutputFormat(exec), command.Result{
+		Text: strings.Join(lines, "\n"),
+		Value: map[string]interface{}{
+			"current":     current,
+			"provider":    profileConfig.Provider,
+			"model":       profileConfig.Model,
+			"description": profileConfig.Description,
+		},
+	})
+}
 
-	// Format output
-	outputFormat := p.getOutputFormat(exec)
-	switch outputFormat {
-	case "json":
-		// For JSON, just use text output for current profile showing
-		exec.Data["output"] = fmt.Sprintf("Current profile: %s", current)
-		return nil
-	default:
-		var output strings.Builder
-		output.WriteString(fmt.Sprintf("Current profile: %s\n", current))
-		if profileConfig.Provider != "" {
-			output.WriteString(fmt.Sprintf("  Provider: %s\n", profileConfig.Provider))
-		}
-		if profileConfig.Model != "" {
-			output.WriteString(fmt.Sprintf("  Model: %s\n", profileConfig.Model))
+// profileFieldLines returns the indented provider, model, and description
+// lines of a profile's text view, skipping empty fields
+func profileFieldLines(profile *config.ProfileConfig) []string {
+	var lines []string
+	for _, field := range []struct{ label, value string }{
+		{"Provider", profile.Provider},
+		{"Model", profile.Model},
+		{"Description", profile.Description},
+	} {
+		if field.value != "" {
+			lines = append(lines, fmt.Sprintf("  %s: %s", field.label, field.value))
 		}
-		if profileConfig.Description != "" {
-			output.WriteString(fmt.Sprintf("  Description: %s\n", profileConfig.Description))
-		}
-		exec.Data["output"] = output.String()
 	}
-
-	return nil
+	return lines
 }
 
-// listProfiles lists all available profiles
-func (p *ProfileCommand) listProfiles(ctx context.Context, exec *command.ExecutionContext) error {
+// profileNames returns the names of the configured profiles, or default
+// when there are none
+func (p *ProfileCommand) profileNames() []string {
 	// Get all profiles from the configuration
 	allConfig := p.config.All()
 	profiles := make([]string, 0)
@@ -210,6 +235,12 @@ func (p *ProfileCommand) listProfiles(ctx context.Context, exec *command.Executi
 	if len(profiles) == 0 {
 		profiles = append(profiles, "default")
 	}
+	return profiles
+}
+
+// listProfiles lists all available profiles
+func (p *ProfileCommand) listProfiles(ctx context.Context, exec *command.ExecutionContext) error {
+	profiles := p.profileNames()
 
 	current := p.config.GetString("profile.current")
 	if current == "" {
@@ -219,33 +250,35 @@ func (p *ProfileCommand) listProfiles(ctx context.Context, exec *command.Executi
 	// Sort profiles for consistent output
 	sort.Strings(profiles)
 
-	outputFormat := p.getOutputFormat(exec)
-	if outputFormat == "json" {
-		data := map[string]interface{}{
-			"profiles": profiles,
-			"current":  current,
-		}
-		jsonData, _ := json.MarshalIndent(data, "", "  ")
-		exec.Data["output"] = string(jsonData)
-		return nil
-	}
-
-	var output strings.Builder
-	output.WriteString("Available profiles:\n")
+	lines := []string{"Available profiles:"}
+	table := command.NewTable("NAME", "CURRENT")
 	for _, profile := range profiles {
 		if profile == current {
-			output.WriteString(fmt.Sprintf("  * %s (current)\n", profile))
+			lines = append(lines, fmt.Sprintf("  * %s (current)", profile))
 		} else {
-			output.WriteString(fmt.Sprintf("    %s\n", profile))
+			lines = append(lines, fmt.Sprintf("    %s", profile))
 		}
+		table.AddRow(profile, profile == current)
 	}
-	exec.Data["output"] = output.String()
-	return nil
+	return setFormattedOutput(exec, p.getOutputFormat(exec), command.Result{
+		Text:  strings.Join(lines, "\n"),
+		Table: table,
+		Value: map[string]interface{}{
+			"profiles": profiles,
+			"current":  current,
+		},
+	})
 }
 
-// showProfile shows details of a specific profile
+// showProfile shows details of a specific profile, as written or, with
+// --resolved, merged over the profiles it extends
 func (p *ProfileCommand) showProfile(ctx context.Context, exec *command.ExecutionContext, name string) error {
-	profileConfig, err := p.config.GetProfile(name)
+	resolved := exec.Flags.GetBool("resolved")
+	getProfile := p.config.GetProfileDefinition
+	if resolved {
+		getProfile = p.config.GetProfile
+	}
+	profileConfig, err := getProfile(name)
 	if err != nil {
 		return fmt.Errorf("failed to get profile '%s': %w", name, err)
 	}
@@ -253,49 +286,48 @@ func (p *ProfileCommand) showProfile(ctx context.Context, exec *command.Executio
 	current := p.config.GetString("profile.current")
 	isCurrent := (name == current) || (current == "" && name == "default")
 
-	outputFormat := p.getOutputFormat(exec)
-	switch outputFormat {
-	case "json":
-		data := map[string]interface{}{
-			"name":     name,
-			"current":  isCurrent,
-			"provider": profileConfig.Provider,
-			"model":    profileConfig.Model,
-			"settings": profileConfig.Settings,
-		}
-		if profileConfig.Description != "" {
-			data["description"] = profileConfig.Description
-		}
-		jsonData, _ := json.MarshalIndent(data, "", "  ")
-		exec.Data["output"] = string(jsonData)
-	default:
-		var output strings.Builder
-		output.WriteString(fmt.Sprintf("Profile: %s", name))
-		if isCurrent {
-			output.WriteString(" (current)")
-		}
-		output.WriteString("\n")
+	data := map[string]interface{}{
+		"name":     name,
+		"current":  isCurrent,
+		"provider": profileConfig.Provider,
+		"model":    profileConfig.Model,
+		"settings": profileConfig.Settings,
+	}
+	if profileConfig.Description != "" {
+		data["description"] = profileConfig.Description
+	}
 
-		if profileConfig.Description != "" {
-			output.WriteString(fmt.Sprintf("  Description: %s\n", profileConfig.Description))
-		}
-		if profileConfig.Provider != "" {
-			output.WriteString(fmt.Sprintf("  Provider: %s\n", profileConfig.Provider))
+	title := fmt.Sprintf("Profile: %s", name)
+	if isCurrent {
+		title += " (current)"
+	}
+	lines := []string{title}
+	if profileConfig.Extends != "" {
+		data["extends"] = profileConfig.Extends
+		data["resolved"] = resolved
+		if resolved {
+			lines = append(lines, fmt.Sprintf("  Extends: %s (settings below include inherited values)", profileConfig.Extends))
+		} else {
+			lines = append(lines, fmt.Sprintf("  Extends: %s (use --resolved to include inherited values)", profileConfig.Extends))
 		}
-		if profileConfig.Model != "" {
-			output.WriteString(fmt.Sprintf("  Model: %s\n", profileConfig.Model))
+	}
+	lines = append(lines, profileFieldLines(profileConfig)...)
+	if len(profileConfig.Settings) > 0 {
+		lines = append(lines, "  Settings:")
+		keys := make([]string, 0, len(profileConfig.Settings))
+		for key := range profileConfig.Settings {
+			keys = append(keys, key)
 		}
-
-		if len(profileConfig.Settings) > 0 {
-			output.WriteString("  Settings:\n")
-			for key, value := range profileConfig.Settings {
-				output.WriteString(fmt.Sprintf("    %s: %v\n", key, value))
-			}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = append(lines, fmt.Sprintf("    %s: %v", key, profileConfig.Settings[key]))
 		}
-		exec.Data["output"] = output.String()
 	}
 
-	return nil
+	return setFormattedOutput(exec, p.getOutputFormat(exec), command.Result{
+		Text:  strings.Join(lines, "\n"),
+		Value: data,
+	})
 }
 
 // createProfile creates a new profile
@@ -357,8 +389,32 @@ func (p *ProfileCommand) createProfile(ctx context.Context, exec *command.Execut
 	}
 
 	logging.LogInfo("Configuration profile created", "profile", name, "description", description)
-	exec.Data["output"] = fmt.Sprintf("Created profile: %s", name)
-	return nil
+	return setResultOutput(exec, p.getOutputFormat(exec),
+		fmt.Sprintf("Created profile: %s", name),
+		map[string]interface{}{"created": name, "profile": profileData})
+}
+
+// chooseProfile asks which profile to switch to. Without a terminal there
+// is nobody to ask, so a name is required.
+func (p *ProfileCommand) chooseProfile(exec *command.ExecutionContext) (string, error) {
+	prompter := command.PrompterFor(exec)
+	if !prompter.Interactive() {
+		return "", fmt.Errorf("profile switch: %w - name required", command.ErrMissingArgument)
+	}
+
+	profiles := p.profileNames()
+	sort.Strings(profiles)
+	current := -1
+	for i, name := range profiles {
+		if name == p.config.GetString("profile.current") {
+			current = i
+		}
+	}
+	choice, err := prompter.Select("Switch to profile:", profiles, current)
+	if err != nil {
+		return "", fmt.Errorf("profile switch: %w", err)
+	}
+	return profiles[choice], nil
 }
 
 // switchProfile switches to a different profile
@@ -385,8 +441,9 @@ func (p *ProfileCommand) switchProfile(ctx context.Context, exec *command.Execut
 	// Log the profile switch
 	logging.LogInfo("Profile switched", "from", currentProfile, "to", name)
 
-	exec.Data["output"] = fmt.Sprintf("Switched to profile: %s", name)
-	return nil
+	return setResultOutput(exec, p.getOutputFormat(exec),
+		fmt.Sprintf("Switched to profile: %s", name),
+		map[string]interface{}{"current": name, "previous": currentProfile})
 }
 
 // deleteProfile deletes a profile
@@ -406,9 +463,22 @@ func (p *ProfileCommand) deleteProfile(ctx context.Context, exec *command.Execut
 		return fmt.Errorf("cannot delete current profile '%s', switch to another profile first", name)
 	}
 
-	// TODO: Config package needs a way to delete keys
-	// For now, we'll return an error
-	return fmt.Errorf("profile deletion not implemented")
+	ok, err := command.PrompterFor(exec).Confirm(fmt.Sprintf("Delete profile '%s'?", name), false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		exec.Data["output"] = "Deletion canceled"
+		return nil
+	}
+
+	if err := p.config.PersistDelete(key); err != nil {
+		return fmt.Errorf("failed to delete profile: %w", err)
+	}
+
+	return setResultOutput(exec, p.getOutputFormat(exec),
+		fmt.Sprintf("Profile '%s' deleted", name),
+		map[string]string{"deleted": name})
 }
 
 // updateProfile updates profile settings
@@ -450,8 +520,9 @@ func (p *ProfileCommand) updateProfile(ctx context.Context, exec *command.Execut
 		}
 	}
 
-	exec.Data["output"] = fmt.Sprintf("Updated profile: %s", name)
-	return nil
+	return setResultOutput(exec, p.getOutputFormat(exec),
+		fmt.Sprintf("Updated profile: %s", name),
+		map[string]interface{}{"updated": name, "changes": updates})
 }
 
 // copyProfile copies a profile to a new name
@@ -487,8 +558,9 @@ func (p *ProfileCommand) copyProfile(ctx context.Context, exec *command.Executio
 	}
 
 	logging.LogInfo("Configuration profile copied", "source", source, "destination", destination)
-	exec.Data["output"] = fmt.Sprintf("Copied profile '%s' to '%s'", source, destination)
-	return nil
+	return setResultOutput(exec, p.getOutputFormat(exec),
+		fmt.Sprintf("Copied profile '%s' to '%s'", source, destination),
+		map[string]interface{}{"source": source, "destination": destination})
 }
 
 // exportProfile exports a profile configuration
@@ -541,12 +613,9 @@ func (p *ProfileCommand) importProfile(ctx context.Context, exec *command.Execut
 }
 
 // getOutputFormat gets the output format from flags or data
-func (p *ProfileCommand) getOutputFormat(exec *command.ExecutionContext) string {
+func (p *ProfileCommand) getOutputFormat(exec *command.ExecutionContext) command.OutputFormat {
 	if format := exec.Flags.GetString("format"); format != "" {
-		return format
-	}
-	if format, ok := exec.Data["outputFormat"].(string); ok && format != "" {
-		return format
+		return command.OutputFormat(format)
 	}
-	return "text"
+	return outputFormat(exec)
 }
@@ -15,6 +15,8 @@ import (
 )
 
 func TestProfileCommand_Execute(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
 	tests := []struct {
 		name           string
 		args           []string
@@ -50,7 +52,7 @@ func TestProfileCommand_Execute(t *testing.T) {
 			name:           "show current profile JSON",
 			args:           []string{},
 			outputFormat:   "json",
-			expectedOutput: `Current profile: default`,
+			expectedOutput: `"current": "default"`,
 		},
 
 		// List command
@@ -69,6 +71,24 @@ func TestProfileCommand_Execute(t *testing.T) {
 			flags:          map[string]interface{}{"format": "json"},
 			expectedOutput: `"current": "default"`,
 		},
+		{
+			name:           "list profiles YAML",
+			args:           []string{"list"},
+			outputFormat:   "yaml",
+			expectedOutput: "current: default",
+		},
+		{
+			name:           "list profiles table",
+			args:           []string{"list"},
+			outputFormat:   "table",
+			expectedOutput: "NAME      CURRENT\ncreative  false",
+		},
+		{
+			name:           "show current profile YAML",
+			args:           []string{},
+			outputFormat:   "yaml",
+			expectedOutput: "current: default",
+		},
 
 		// Show command
 		{
@@ -207,7 +227,7 @@ func TestProfileCommand_Execute(t *testing.T) {
 			setupConfig: func(c *config.Config) {
 				require.NoError(t, c.SetValue("profiles.test", map[string]interface{}{}))
 			},
-			expectedError: "profile deletion not implemented",
+			expectedOutput: "Profile 'test' deleted",
 		},
 		{
 			name:          "delete non-existent profile",
@@ -393,12 +413,45 @@ func TestProfileCommand_Metadata(t *testing.T) {
 	assert.Contains(t, meta.LongDescription, "import")
 
 	// Check flags
-	assert.Len(t, meta.Flags, 1)
+	assert.Len(t, meta.Flags, 3)
 	formatFlag := meta.Flags[0]
 	assert.Equal(t, "format", formatFlag.Name)
 	assert.Equal(t, "f", formatFlag.Short)
 	assert.Equal(t, command.FlagTypeString, formatFlag.Type)
 	assert.Equal(t, "text", formatFlag.Default)
+	assert.Equal(t, "resolved", meta.Flags[1].Name)
+	assert.Equal(t, command.FlagTypeBool, meta.Flags[1].Type)
+}
+
+func TestProfileCommand_ShowResolved(t *testing.T) {
+	cfg := createTestConfig(t)
+	require.NoError(t, cfg.SetValue("profiles.base", map[string]interface{}{
+		"provider": "openai",
+		"model":    "gpt-4o",
+		"settings": map[string]interface{}{"temperature": 0.2, "max_tokens": 1000},
+	}))
+	require.NoError(t, cfg.SetValue("profiles.work", map[string]interface{}{
+		"extends":  "base",
+		"model":    "gpt-4.1",
+		"settings": map[string]interface{}{"temperature": 0.7},
+	}))
+	cmd := NewProfileCommand(cfg)
+
+	// Without --resolved the profile is shown as written
+	exec := &command.ExecutionContext{Args: []string{"show", "work"}, Flags: command.NewFlags(nil), Data: map[string]interface{}{}}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	output := exec.Data["output"].(string)
+	assert.Contains(t, output, "Extends: base (use --resolved")
+	assert.NotContains(t, output, "Provider: openai")
+	assert.NotContains(t, output, "max_tokens")
+
+	exec = &command.ExecutionContext{Args: []string{"show", "work"}, Flags: command.NewFlags(map[string]interface{}{"resolved": true}),
+		Data: map[string]interface{}{}}
+	require.NoError(t, cmd.Execute(context.Background(), exec))
+	output = exec.Data["output"].(string)
+	assert.Contains(t, output, "Provider: openai")
+	assert.Contains(t, output, "Model: gpt-4.1")
+	assert.Contains(t, output, "    max_tokens: 1000\n    temperature: 0.7")
 }
 
 func TestProfileCommand_Validate(t *testing.T) {
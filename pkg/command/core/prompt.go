@@ -0,0 +1,229 @@
+// ABOUTME: Prompt command - Manages named, parameterized prompts stored in config
+// ABOUTME: Provides add, list, show, remove, and run with --var name=value substitution
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/templates"
+)
+
+// PromptCommand implements named prompts kept in the prompts config section
+type PromptCommand struct {
+	config *config.Config
+}
+
+// NewPromptCommand creates a new prompt command instance
+func NewPromptCommand(cfg *config.Config) *PromptCommand {
+	return &PromptCommand{
+		config: cfg,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *PromptCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "prompt",
+		Aliases:     []string{"prompts"},
+		Description: "Manage named prompts stored in config",
+		LongDescription: `The prompt command manages reusable prompts saved in the prompts section of
+the user config file, so they travel with the rest of your configuration.
+
+Subcommands:
+  list                         List all prompts
+  add <name> [content]         Add or replace a prompt (content from --file or stdin if omitted)
+  show <name>                  Show a prompt and its variables
+  remove <name>                Remove a prompt
+  run <name> [--var k=v ...]   Fill in the prompt and send it to the LLM
+
+Placeholders are written {{name}} or {{name|default}}. Variable values of the
+form @path read a file, and - reads standard input.
+
+Examples:
+  magellai prompt add summarize "Summarize this file in {{n|5}} bullets: {{file}}"
+  magellai prompt run summarize --var file=@README.md
+  magellai prompt run summarize --var n=3 --var file=@CHANGELOG.md`,
+		Category: command.CategoryShared,
+		Flags: []command.Flag{
+			{
+				Name:        "var",
+				Description: "Prompt variable as name=value (run, repeatable)",
+				Type:        command.FlagTypeStringSlice,
+			},
+			{
+				Name:        "description",
+				Short:       "d",
+				Description: "Prompt description (add)",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "file",
+				Short:       "f",
+				Description: "Read prompt content from a file (add)",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "model",
+				Short:       "m",
+				Description: "Model to use (run)",
+				Type:        command.FlagTypeString,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *PromptCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	return nil
+}
+
+// Execute runs the prompt command
+func (c *PromptCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+
+	if len(exec.Args) == 0 || exec.Args[0] == "list" {
+		return c.listPrompts(exec)
+	}
+
+	subcommand, args := exec.Args[0], exec.Args[1:]
+	if len(args) < 1 {
+		return fmt.Errorf("prompt %s: %w - name required", subcommand, command.ErrMissingArgument)
+	}
+	name := args[0]
+
+	switch subcommand {
+	case "add", "set":
+		return c.addPrompt(exec, name, strings.Join(args[1:], " "))
+	case "show", "get":
+		return c.showPrompt(exec, name)
+	case "remove", "delete", "rm":
+		return c.removePrompt(exec, name)
+	case "run":
+		return c.runPrompt(ctx, exec, name, args[1:])
+	default:
+		return fmt.Errorf("prompt: %w - invalid subcommand '%s'", command.ErrInvalidArguments, subcommand)
+	}
+}
+
+// listPrompts lists all prompts in config
+func (c *PromptCommand) listPrompts(exec *command.ExecutionContext) error {
+	prompts := templates.ConfigPrompts(c.config.Get(templates.PromptsKey))
+
+	text := "No prompts defined. Add one with: magellai prompt add <name> <content>"
+	if len(prompts) > 0 {
+		lines := []string{"Prompts:"}
+		for _, p := range prompts {
+			lines = append(lines, fmt.Sprintf("  %-16s %s", p.Name, strings.TrimSpace(p.Description+" ("+p.Describe()+")")))
+		}
+		text = strings.Join(lines, "\n")
+	}
+	return setOutput(exec, command.Result{
+		Text:  text,
+		Table: templateTable(prompts),
+		Value: map[string]interface{}{
+			"prompts": prompts,
+			"count":   len(prompts),
+		},
+	})
+}
+
+// addPrompt saves a prompt to the user config file
+func (c *PromptCommand) addPrompt(exec *command.ExecutionContext, name, content string) error {
+	if strings.ContainsAny(name, ". ") {
+		return fmt.Errorf("prompt add: %w - name cannot contain dots or spaces", command.ErrInvalidArguments)
+	}
+	if file := exec.Flags.GetString("file"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		content = string(data)
+	}
+	if content == "" && exec.Stdin != nil {
+		data, err := io.ReadAll(exec.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt from stdin: %w", err)
+		}
+		content = string(data)
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("prompt add: %w - content required", command.ErrMissingArgument)
+	}
+
+	key := templates.PromptKey(name)
+	if err := c.config.PersistValue(key+".content", content); err != nil {
+		return fmt.Errorf("failed to save prompt: %w", err)
+	}
+	description := exec.Flags.GetString("description")
+	if err := c.config.PersistValue(key+".description", description); err != nil {
+		return fmt.Errorf("failed to save prompt: %w", err)
+	}
+
+	p := &templates.Template{Name: name, Description: description, Content: content}
+	logging.LogInfo("Prompt saved", "name", name)
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("Prompt '%s' saved (variables: %s)", name, p.Describe()), p)
+}
+
+// showPrompt displays a prompt and its variables
+func (c *PromptCommand) showPrompt(exec *command.ExecutionContext, name string) error {
+	p, err := templates.ConfigPrompt(c.config.Get(templates.PromptsKey), name)
+	if err != nil {
+		return err
+	}
+	return setOutput(exec, command.Result{Text: templateText("Prompt", p), Value: p})
+}
+
+// removePrompt deletes a prompt from the user config file
+func (c *PromptCommand) removePrompt(exec *command.ExecutionContext, name string) error {
+	if _, err := templates.ConfigPrompt(c.config.Get(templates.PromptsKey), name); err != nil {
+		return err
+	}
+	if err := c.config.PersistDelete(templates.PromptKey(name)); err != nil {
+		return fmt.Errorf("failed to remove prompt: %w", err)
+	}
+	return setResultOutput(exec, outputFormat(exec),
+		fmt.Sprintf("Prompt '%s' removed", name),
+		map[string]string{"removed": name})
+}
+
+// runPrompt fills in a prompt from --var flags and name=value arguments and
+// sends it through the ask command
+func (c *PromptCommand) runPrompt(ctx context.Context, exec *command.ExecutionContext, name string, args []string) error {
+	p, err := templates.ConfigPrompt(c.config.Get(templates.PromptsKey), name)
+	if err != nil {
+		return err
+	}
+
+	varArgs, rest := templates.SplitVariableArgs(args)
+	if len(rest) > 0 {
+		return fmt.Errorf("prompt run: %w - unexpected arguments: %s (use --var name=value)",
+			command.ErrInvalidArguments, strings.Join(rest, " "))
+	}
+	vars, err := templates.ParseVariables(append(exec.Flags.GetStringSlice("var"), varArgs...), exec.Stdin)
+	if err != nil {
+		return err
+	}
+	prompt, err := p.Render(vars)
+	if err != nil {
+		return err
+	}
+
+	logging.LogInfo("Running prompt", "name", name, "promptLength", len(prompt))
+	askExec := *exec
+	askExec.Args = []string{prompt}
+	return NewAskCommand(c.config).Execute(ctx, &askExec)
+}
@@ -0,0 +1,119 @@
+// ABOUTME: Unit tests for the prompt command
+// ABOUTME: Tests add, list, show, run, and remove of named prompts in the user config
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptCommand_Execute(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	require.NoError(t, config.Init())
+	cfg := config.Manager
+
+	cmd := NewPromptCommand(cfg)
+	ctx := context.Background()
+
+	run := func(args []string, flags map[string]interface{}, stdin string) (*command.ExecutionContext, error) {
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Data:   make(map[string]interface{}),
+		}
+		if stdin != "" {
+			exec.Stdin = strings.NewReader(stdin)
+		}
+		return exec, cmd.Execute(ctx, exec)
+	}
+
+	exec, err := run([]string{"list"}, nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, exec.Data["output"], "No prompts defined")
+
+	exec, err = run([]string{"add", "summarize", "Summarize", "{{file}}", "in", "{{n|5}}", "bullets"},
+		map[string]interface{}{"description": "Summarize a file"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, `Prompt 'summarize' saved (variables: file, n="5")`, exec.Data["output"])
+
+	// Prompts are written to the user config file
+	data, err := os.ReadFile(config.UserConfigPath())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "summarize")
+
+	exec, err = run(nil, nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, exec.Data["output"], "summarize")
+	assert.Contains(t, exec.Data["output"], "Summarize a file")
+
+	exec = &command.ExecutionContext{
+		Args:  []string{"show", "summarize"},
+		Flags: command.NewFlags(nil),
+		Data:  map[string]interface{}{"outputFormat": OutputFormatJSON},
+	}
+	require.NoError(t, cmd.Execute(ctx, exec))
+	assert.Contains(t, exec.Data["output"], `"content": "Summarize {{file}} in {{n|5}} bullets"`)
+
+	_, err = run([]string{"add", "bad.name", "x"}, nil, "")
+	assert.True(t, errors.Is(err, command.ErrInvalidArguments))
+	_, err = run([]string{"run", "summarize", "stray"}, nil, "")
+	assert.True(t, errors.Is(err, command.ErrInvalidArguments))
+	_, err = run([]string{"run", "summarize"}, nil, "")
+	assert.True(t, errors.Is(err, templates.ErrMissingVariable))
+
+	_, err = run([]string{"remove", "summarize"}, nil, "")
+	require.NoError(t, err)
+	_, err = run([]string{"show", "summarize"}, nil, "")
+	assert.True(t, errors.Is(err, templates.ErrTemplateNotFound))
+	data, err = os.ReadFile(config.UserConfigPath())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "summarize")
+
+	_, err = run([]string{"bogus", "x"}, nil, "")
+	assert.True(t, errors.Is(err, command.ErrInvalidArguments))
+	_, err = run([]string{"add"}, nil, "")
+	assert.True(t, errors.Is(err, command.ErrMissingArgument))
+}
+
+func TestPromptCommand_Run(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	require.NoError(t, config.Init())
+	cfg := config.Manager
+	require.NoError(t, cfg.SetValue("model.default", "mock/test-model"))
+	require.NoError(t, cfg.SetValue("provider.mock.api_key", "mock-api-key"))
+
+	cmd := NewPromptCommand(cfg)
+	ctx := context.Background()
+
+	require.NoError(t, cmd.Execute(ctx, &command.ExecutionContext{
+		Args:  []string{"add", "greet", "Say hello to {{name}}"},
+		Flags: command.NewFlags(nil),
+		Data:  make(map[string]interface{}),
+	}))
+
+	var stdout bytes.Buffer
+	exec := &command.ExecutionContext{
+		Context: ctx,
+		Args:    []string{"run", "greet"},
+		Flags:   command.NewFlags(map[string]interface{}{"var": []string{"name=Ada"}}),
+		Stdout:  &stdout,
+		Stderr:  &bytes.Buffer{},
+		Data:    make(map[string]interface{}),
+		Config:  cfg,
+	}
+	require.NoError(t, cmd.Execute(ctx, exec))
+	assert.NotEmpty(t, stdout.String())
+}
@@ -0,0 +1,239 @@
+// ABOUTME: Run command - Executes multi-step workflows defined in YAML files
+// ABOUTME: Answers prompt steps with the configured providers and can save the run as a session
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/lexlapax/magellai/pkg/templates"
+	"github.com/lexlapax/magellai/pkg/workflow"
+)
+
+// RunCommand implements workflow execution
+type RunCommand struct {
+	config *config.Config
+}
+
+// NewRunCommand creates a new run command instance
+func NewRunCommand(cfg *config.Config) *RunCommand {
+	return &RunCommand{
+		config: cfg,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *RunCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "run",
+		Description: "Run a multi-step workflow file",
+		LongDescription: `The run command executes a workflow: a YAML file of prompt and shell steps
+run in order. Each step's output is available to later steps as {{step-name}},
+and shell steps also set {{step-name.exit_code}}.
+
+Workflow fields:
+  name, description    Describe the workflow
+  model, system        Defaults for prompt steps
+  vars                 Variables, overridden by --var name=value
+  max_steps            Limit on steps run, to stop goto loops (default 100)
+  steps                The steps
+
+Step fields:
+  name                 Variable name for the output (default stepN)
+  prompt | shell       The prompt to send or the command to run with sh
+  model, system        Per-step overrides
+  if                   Run only when the condition holds: a value, a == b,
+                       a != b, or a contains b; a leading ! negates
+  goto                 Step to run next, or end to stop
+  output               Extra variable name for the output
+  allow_failure        Continue when the step fails
+
+The output is the output of the last step that ran.
+
+Example workflow:
+  name: changelog
+  steps:
+    - name: log
+      shell: git log --oneline v1.0..HEAD
+    - name: notes
+      prompt: "Write release notes for these commits: {{log}}"
+
+Examples:
+  magellai run changelog.yaml
+  magellai run review.yaml --var file=@main.go --save`,
+		Category: command.CategoryCLI,
+		Flags: []command.Flag{
+			{
+				Name:        "var",
+				Description: "Workflow variable as name=value (repeatable)",
+				Type:        command.FlagTypeStringSlice,
+			},
+			{
+				Name:        "model",
+				Short:       "m",
+				Description: "Model for prompt steps that do not set one (provider/model format)",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "save",
+				Description: "Save the prompts and responses as a session",
+				Type:        command.FlagTypeBool,
+			},
+			{
+				Name:        "name",
+				Description: "Name for the saved session",
+				Type:        command.FlagTypeString,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *RunCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	return nil
+}
+
+// Execute runs the workflow
+func (c *RunCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+	if len(exec.Args) != 1 {
+		return fmt.Errorf("run: %w - workflow file required", command.ErrMissingArgument)
+	}
+	path := exec.Args[0]
+
+	wf, err := workflow.Load(path)
+	if err != nil {
+		return err
+	}
+	vars, err := templates.ParseVariables(exec.Flags.GetStringSlice("var"), exec.Stdin)
+	if err != nil {
+		return err
+	}
+
+	runner := &workflow.Runner{
+		Generate: c.generate,
+		Model:    exec.Flags.GetString("model"),
+	}
+	logging.LogInfo("Running workflow", "file", path, "name", wf.Name, "steps", len(wf.Steps))
+	started := time.Now()
+	result, runErr := runner.Run(ctx, wf, vars)
+
+	// Save whatever ran, so a failed run can still be inspected
+	if exec.Flags.GetBool("save") && result != nil {
+		s, err := c.saveSession(exec, path, wf, result, started)
+		if err != nil {
+			return err
+		}
+		exec.Data["session_id"] = s.ID
+		if exec.Stderr != nil && !exec.Flags.GetBool("quiet") {
+			fmt.Fprintf(exec.Stderr, "Saved workflow run as session %s\n", s.ID)
+		}
+	}
+	if runErr != nil {
+		return fmt.Errorf("workflow %s: %w", path, runErr)
+	}
+
+	exec.Data["workflow_result"] = result
+	if structuredOutputRequested(exec) {
+		return setOutput(exec, result)
+	}
+	exec.Data["output"] = result.Output
+	return nil
+}
+
+// generate answers a prompt step, resolving an empty model to the
+// configured default
+func (c *RunCommand) generate(ctx context.Context, model, system, prompt string) (string, error) {
+	if model == "" {
+		model = c.config.GetString("model.default")
+		if model == "" {
+			model = "openai/gpt-4o"
+		}
+	}
+	providerName, modelName := llm.ParseModelString(model)
+	provider, err := llm.NewProvider(providerName, modelName, c.config.GetProviderAPIKeyContext(ctx, providerName))
+	if err != nil {
+		return "", fmt.Errorf("failed to create provider: %w", err)
+	}
+
+	var messages []domain.Message
+	if system != "" {
+		messages = append(messages, domain.Message{Role: domain.MessageRoleSystem, Content: system})
+	}
+	messages = append(messages, domain.Message{Role: domain.MessageRoleUser, Content: prompt})
+
+	response, err := provider.GenerateMessage(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// saveSession stores the prompt steps of a run as a session
+func (c *RunCommand) saveSession(exec *command.ExecutionContext, path string, wf *workflow.Workflow, result *workflow.Result, started time.Time) (*domain.Session, error) {
+	s := domain.NewSession(storage.GenerateSessionID())
+	s.Name = exec.Flags.GetString("name")
+	if s.Name == "" {
+		s.Name = wf.Name
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	s.Created, s.Conversation.Created = started, started
+	s.Conversation.SystemPrompt = wf.System
+	s.Metadata["workflow"] = path
+	s.AddTag("workflow")
+
+	for _, step := range result.Steps {
+		if step.Kind != "prompt" || step.Skipped || step.Error != "" {
+			continue
+		}
+		if s.Conversation.Model == "" && step.Model != "" {
+			s.Conversation.SetModel(llm.ParseModelString(step.Model))
+		}
+		for _, m := range []struct {
+			role    domain.MessageRole
+			content string
+		}{{domain.MessageRoleUser, step.Input}, {domain.MessageRoleAssistant, step.Output}} {
+			message := domain.NewMessage(uuid.New().String(), m.role, m.content)
+			message.Metadata["workflow_step"] = step.Name
+			if step.Model != "" {
+				message.Metadata["model"] = step.Model
+			}
+			s.Conversation.AddMessage(*message)
+		}
+	}
+
+	manager, err := openSessionManager(c.config, exec)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := exec.Data["session_manager"]; !ok {
+		defer func() {
+			if err := manager.Close(); err != nil {
+				logging.LogWarn("Failed to close session storage", "error", err)
+			}
+		}()
+	}
+	if err := manager.StorageManager.SaveSession(s); err != nil {
+		return nil, fmt.Errorf("failed to save workflow session: %w", err)
+	}
+	logging.LogInfo("Saved workflow session", "id", s.ID, "messages", len(s.Conversation.Messages))
+	return s, nil
+}
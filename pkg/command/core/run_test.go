@@ -0,0 +1,93 @@
+// ABOUTME: Unit tests for the run command
+// ABOUTME: Tests workflow execution with the mock provider and saving runs as sessions
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWorkflow = `
+name: review
+system: Be brief.
+steps:
+  - name: file
+    shell: echo main.go
+  - name: review
+    prompt: "Review {{file}} for {{focus|bugs}}"
+  - name: skipped
+    if: "{{review}} contains nothing"
+    prompt: Unreachable
+`
+
+func TestRunCommand_Execute(t *testing.T) {
+	cfg := createTestConfig(t)
+	require.NoError(t, cfg.SetValue("model.default", "mock/test-model"))
+	require.NoError(t, cfg.SetValue("provider.mock.api_key", "mock-api-key"))
+
+	path := filepath.Join(t.TempDir(), "review.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testWorkflow), 0644))
+	cmd := NewRunCommand(cfg)
+	assert.Equal(t, "run", cmd.Metadata().Name)
+
+	t.Run("Text output", func(t *testing.T) {
+		exec := &command.ExecutionContext{
+			Args:  []string{path},
+			Flags: command.NewFlags(map[string]interface{}{"var": []string{"focus=style"}}),
+			Data:  make(map[string]interface{}),
+		}
+		require.NoError(t, cmd.Execute(context.Background(), exec))
+		assert.Equal(t, "This is a mock message response", exec.Data["output"])
+
+		result := exec.Data["workflow_result"].(*workflow.Result)
+		require.Len(t, result.Steps, 3)
+		assert.Equal(t, "Review main.go for style", result.Steps[1].Input)
+		assert.Equal(t, "Be brief.", result.Steps[1].System)
+		assert.True(t, result.Steps[2].Skipped)
+	})
+
+	t.Run("Save session", func(t *testing.T) {
+		var stderr bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   []string{path},
+			Flags:  command.NewFlags(map[string]interface{}{"save": true, "name": "nightly review"}),
+			Data:   make(map[string]interface{}),
+			Stderr: &stderr,
+		}
+		require.NoError(t, cmd.Execute(context.Background(), exec))
+		id, _ := exec.Data["session_id"].(string)
+		require.NotEmpty(t, id)
+		assert.Contains(t, stderr.String(), id)
+
+		manager, err := openSessionManager(cfg, nil)
+		require.NoError(t, err)
+		defer manager.Close()
+		s, err := manager.StorageManager.LoadSession(id)
+		require.NoError(t, err)
+		assert.Equal(t, "nightly review", s.Name)
+		assert.Equal(t, "Be brief.", s.Conversation.SystemPrompt)
+		require.Len(t, s.Conversation.Messages, 2)
+		assert.Equal(t, "Review main.go for bugs", s.Conversation.Messages[0].Content)
+		assert.Equal(t, "This is a mock message response", s.Conversation.Messages[1].Content)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		err := cmd.Execute(context.Background(), &command.ExecutionContext{Flags: command.NewFlags(nil)})
+		assert.ErrorIs(t, err, command.ErrMissingArgument)
+
+		err = cmd.Execute(context.Background(), &command.ExecutionContext{
+			Args:  []string{filepath.Join(t.TempDir(), "missing.yaml")},
+			Flags: command.NewFlags(nil),
+		})
+		assert.ErrorIs(t, err, workflow.ErrInvalidWorkflow)
+	})
+}
@@ -0,0 +1,131 @@
+// ABOUTME: Serve command - Runs magellai as an HTTP API server
+// ABOUTME: Exposes ask, session management, search, export, and OpenAI-compatible chat over HTTP
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/server"
+)
+
+// ServeCommand implements the HTTP API server
+type ServeCommand struct {
+	config   *config.Config
+	registry *command.Registry
+}
+
+// NewServeCommand creates a new serve command instance
+func NewServeCommand(cfg *config.Config, registry *command.Registry) *ServeCommand {
+	return &ServeCommand{
+		config:   cfg,
+		registry: registry,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *ServeCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "serve",
+		Description: "Run an HTTP API server",
+		LongDescription: `The serve command exposes magellai as a REST API:
+
+  GET    /health                   Liveness check
+  POST   /ask                      One-shot query {"prompt", "model", "system", "attachments", ...}
+  GET    /sessions                 List sessions
+  POST   /sessions                 Create a session {"name", "model", "tags"}
+  GET    /sessions/{id}            Show a session
+  PATCH  /sessions/{id}            Update name, model, or tags
+  DELETE /sessions/{id}            Delete a session
+  GET    /sessions/{id}/export     Export a session (?format=json|markdown|text)
+  GET    /search?q=<query>         Search sessions by content
+  POST   /v1/chat/completions      OpenAI-compatible chat completions (with streaming)
+  GET    /v1/models                Profiles and known models
+  GET    /metrics                  Prometheus metrics
+
+The OpenAI-compatible endpoint accepts a provider/model pair or a profile name
+as the model; a profile's fallbacks are tried in order when its model fails.
+
+/metrics reports API request counts and latencies, provider request counts,
+errors, latencies, and token usage, and storage operation timings in the
+Prometheus text format.
+
+Request bodies must be sent with "Content-Type: application/json". Attachments
+to /ask are sent inline as {"name", "mime_type", "data"} objects with base64
+data; the server never reads files named by a request.
+
+When a token is set (--token or server.token), requests must send
+"Authorization: Bearer <token>". Without a token the server only listens on
+loopback addresses such as 127.0.0.1.
+
+Examples:
+  magellai serve
+  magellai serve --addr 0.0.0.0:9000 --token secret`,
+		Category: command.CategoryCLI,
+		Flags: []command.Flag{
+			{
+				Name:        "addr",
+				Description: "Address to listen on (default server.address)",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "token",
+				Description: "Bearer token required on requests; needed to listen off loopback (default server.token)",
+				Type:        command.FlagTypeString,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *ServeCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	if c.registry == nil {
+		return fmt.Errorf("command registry not initialized")
+	}
+	return nil
+}
+
+// Execute starts the server and blocks until the context is cancelled
+func (c *ServeCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	addr := exec.Flags.GetString("addr")
+	if addr == "" {
+		addr = c.config.GetString("server.address")
+	}
+	if addr == "" {
+		addr = server.DefaultAddress
+	}
+	token := exec.Flags.GetString("token")
+	if token == "" {
+		token = c.config.GetString("server.token")
+	}
+
+	sessions, err := openSessionManager(c.config, exec)
+	if err != nil {
+		return err
+	}
+	defer sessions.Close()
+
+	srv, err := server.New(server.Options{
+		Config:   c.config,
+		Registry: c.registry,
+		Sessions: sessions,
+		Token:    token,
+		OnListen: func(listenAddr net.Addr) {
+			fmt.Fprintf(exec.Stdout, "Serving magellai API on http://%s (Ctrl-C to stop)\n", listenAddr)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.LogInfo("Starting API server", "address", addr, "auth", token != "")
+	return srv.ListenAndServe(ctx, addr)
+}
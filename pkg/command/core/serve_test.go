@@ -0,0 +1,50 @@
+// ABOUTME: Tests for the serve command
+// ABOUTME: Verifies metadata, validation, and startup and shutdown of the API server
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	_ "github.com/lexlapax/magellai/pkg/storage/filesystem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeCommand(t *testing.T) {
+	cfg := createTestConfig(t)
+
+	t.Run("metadata", func(t *testing.T) {
+		meta := NewServeCommand(cfg, command.NewRegistry()).Metadata()
+		assert.Equal(t, "serve", meta.Name)
+		assert.Equal(t, command.CategoryCLI, meta.Category)
+		require.Len(t, meta.Flags, 2)
+		assert.Equal(t, "addr", meta.Flags[0].Name)
+		assert.Equal(t, "token", meta.Flags[1].Name)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		assert.NoError(t, NewServeCommand(cfg, command.NewRegistry()).Validate())
+		assert.Error(t, NewServeCommand(cfg, nil).Validate())
+	})
+
+	t.Run("serves until cancelled", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("XDG_CONFIG_HOME", "")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var stdout bytes.Buffer
+		exec := &command.ExecutionContext{
+			Flags:  command.NewFlags(map[string]interface{}{"addr": "127.0.0.1:0"}),
+			Stdout: &stdout,
+		}
+		require.NoError(t, NewServeCommand(cfg, command.NewRegistry()).Execute(ctx, exec))
+		assert.Contains(t, stdout.String(), "Serving magellai API on http://127.0.0.1:")
+		assert.NotContains(t, stdout.String(), "127.0.0.1:0 ", "reports the bound port")
+	})
+}
@@ -0,0 +1,120 @@
+// ABOUTME: Session storage helpers shared by CLI commands
+// ABOUTME: Opens the configured session backend and resolves the session a command targets
+
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lexlapax/magellai/internal/configdir"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/repl/session"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// openSessionManager opens session storage as configured for the REPL. A
+// manager in exec.Data["session_manager"] is used instead when present (for
+// testing).
+func openSessionManager(cfg *config.Config, exec *command.ExecutionContext) (*session.SessionManager, error) {
+	if exec != nil {
+		if sm, ok := exec.Data["session_manager"].(*session.SessionManager); ok {
+			return sm, nil
+		}
+	}
+
+	storageType, storageConfig, err := sessionStorageConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := session.CreateStorageManager(storageType, storageConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage manager: %w", err)
+	}
+	if exec != nil {
+		manager.SetTraceContext(exec.Context)
+	}
+	return &session.SessionManager{StorageManager: manager}, nil
+}
+
+// sessionStorageConfig returns the configured session storage backend and its
+// settings
+func sessionStorageConfig(cfg *config.Config) (storage.BackendType, storage.Config, error) {
+	paths, err := configdir.GetPaths()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get config paths: %w", err)
+	}
+
+	storageType := storage.FileSystemBackend
+	if cfg.Exists("session.storage.type") {
+		storageType = storage.BackendType(cfg.GetString("session.storage.type"))
+	}
+	storageConfig := storage.Config{"base_dir": paths.Sessions}
+	if settings, ok := cfg.Get("session.storage.settings").(map[string]interface{}); ok {
+		for k, v := range settings {
+			storageConfig[k] = v
+		}
+	}
+	if cfg.GetBool("session.storage.read_only") {
+		storageConfig["read_only"] = true
+	}
+	if replicaType := cfg.GetString("session.storage.replica.type"); replicaType != "" {
+		storageConfig["replica_type"] = replicaType
+		storageConfig["replica_settings"] = cfg.Get("session.storage.replica.settings")
+	}
+	if cfg.Exists("session.snapshots.keep") {
+		storageConfig["snapshot_interval"] = cfg.Get("session.snapshots.interval")
+		storageConfig["snapshot_keep"] = cfg.Get("session.snapshots.keep")
+	}
+	return storageType, storageConfig, nil
+}
+
+// latestSession returns the most recently updated session, or nil if there are none
+func latestSession(manager *session.SessionManager) (*domain.Session, error) {
+	sessions, err := manager.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var latest *domain.SessionInfo
+	for _, info := range sessions {
+		if latest == nil || info.Updated.After(latest.Updated) {
+			latest = info
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	return manager.StorageManager.LoadSession(latest.ID)
+}
+
+// SessionIDs returns the IDs of the stored sessions, most recently updated
+// first. It is used for shell completion.
+func SessionIDs(cfg *config.Config) ([]string, error) {
+	manager, err := openSessionManager(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := manager.Close(); err != nil {
+			logging.LogWarn("Failed to close session storage", "error", err)
+		}
+	}()
+
+	sessions, err := manager.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+	ids := make([]string, len(sessions))
+	for i, info := range sessions {
+		ids[i] = info.ID
+	}
+	return ids, nil
+}
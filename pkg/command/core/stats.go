@@ -0,0 +1,162 @@
+// ABOUTME: Stats command that reports the execution telemetry of commands
+// ABOUTME: Summarizes runs, failures, durations, and token usage per command from the local stats store
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/command"
+)
+
+// StatsCommand reports command telemetry
+type StatsCommand struct {
+	store *command.StatsStore
+}
+
+// NewStatsCommand creates a stats command that reads the records in store
+func NewStatsCommand(store *command.StatsStore) *StatsCommand {
+	return &StatsCommand{store: store}
+}
+
+// Execute runs the stats command
+func (c *StatsCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+	if len(exec.Args) == 0 {
+		return c.showCommands(exec)
+	}
+
+	switch exec.Args[0] {
+	case "commands":
+		return c.showCommands(exec)
+	case "clear":
+		return c.clear(exec)
+	default:
+		return fmt.Errorf("stats: %w - unknown subcommand %q", command.ErrInvalidArguments, exec.Args[0])
+	}
+}
+
+// showCommands summarizes the recorded runs of each command
+func (c *StatsCommand) showCommands(exec *command.ExecutionContext) error {
+	records, err := c.store.Records()
+	if err != nil {
+		return fmt.Errorf("stats commands: %w", err)
+	}
+
+	since := exec.Flags.GetDuration("since")
+	name := exec.Flags.GetString("command")
+	if len(exec.Args) > 1 && name == "" {
+		name = exec.Args[1]
+	}
+	filtered := records[:0]
+	for _, rec := range records {
+		if since > 0 && time.Since(rec.Started) > since {
+			continue
+		}
+		if name != "" && rec.Command != name {
+			continue
+		}
+		filtered = append(filtered, rec)
+	}
+
+	summaries := command.SummarizeCommands(filtered)
+	exec.Data["stats"] = summaries
+	if structuredOutputRequested(exec) {
+		return printOutput(exec, map[string]interface{}{"commands": summaries, "runs": len(filtered)})
+	}
+	if len(summaries) == 0 {
+		return printOutput(exec, "No command runs recorded")
+	}
+
+	table := command.NewTable("COMMAND", "RUNS", "FAILURES", "AVG TIME", "TOTAL TIME", "TOKENS", "LAST RUN")
+	for _, s := range summaries {
+		tokens := "-"
+		if total := s.InputTokens + s.OutputTokens; total > 0 {
+			tokens = fmt.Sprintf("%d (%d in, %d out)", total, s.InputTokens, s.OutputTokens)
+		}
+		table.AddRow(s.Command, s.Runs, s.Failures, roundDuration(s.AverageDuration), roundDuration(s.TotalDuration),
+			tokens, s.LastRun.Local().Format("2006-01-02 15:04"))
+	}
+	return printOutput(exec, table)
+}
+
+// clear removes the recorded runs after confirmation
+func (c *StatsCommand) clear(exec *command.ExecutionContext) error {
+	ok, err := command.PrompterFor(exec).Confirm("Delete all recorded command stats?", false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintln(exec.Stdout, "Clear canceled")
+		return nil
+	}
+	if err := c.store.Clear(); err != nil {
+		return fmt.Errorf("stats clear: %w", err)
+	}
+	return printOutput(exec, "Command stats cleared")
+}
+
+// roundDuration rounds a duration for display
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second)
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Millisecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+// Metadata returns the command metadata
+func (c *StatsCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "stats",
+		Description: "Show command execution statistics",
+		LongDescription: `The stats command reports how often each command ran, how often it failed,
+how long it took, and the tokens it used. Runs are recorded in a local
+stats file while telemetry.enabled is set; nothing is sent anywhere.
+
+Subcommands:
+  commands [name]   Summarize runs per command (default)
+  clear             Delete all recorded runs
+
+Examples:
+  stats commands
+  stats commands ask --since 24h
+  stats clear --yes`,
+		Category: command.CategoryCLI,
+		Flags: []command.Flag{
+			{
+				Name:        "since",
+				Description: "Only count runs started within this duration",
+				Type:        command.FlagTypeDuration,
+			},
+			{
+				Name:        "command",
+				Description: "Only count runs of this command",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        command.FlagYes,
+				Short:       "y",
+				Description: "Clear without asking for confirmation",
+				Type:        command.FlagTypeBool,
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *StatsCommand) Validate() error {
+	if c.store == nil {
+		return fmt.Errorf("stats store not initialized")
+	}
+	return nil
+}
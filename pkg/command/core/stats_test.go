@@ -0,0 +1,83 @@
+// ABOUTME: Tests for the stats command
+// ABOUTME: Verifies summaries of recorded command runs, their filters, and clearing them
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsCommand_Metadata(t *testing.T) {
+	meta := NewStatsCommand(command.NewStatsStore("")).Metadata()
+	assert.Equal(t, "stats", meta.Name)
+	assert.Equal(t, command.CategoryCLI, meta.Category)
+	assert.Len(t, meta.Flags, 3)
+	assert.Error(t, NewStatsCommand(nil).Validate())
+}
+
+func TestStatsCommand_Execute(t *testing.T) {
+	store := command.NewStatsStore(filepath.Join(t.TempDir(), "commands.jsonl"))
+	cmd := NewStatsCommand(store)
+
+	run := func(args []string, flags, data map[string]interface{}) (string, error) {
+		var output bytes.Buffer
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdout: &output,
+			Data:   data,
+		}
+		err := cmd.Execute(context.Background(), exec)
+		return output.String(), err
+	}
+
+	output, err := run(nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "No command runs recorded\n", output)
+
+	now := time.Now()
+	require.NoError(t, store.Record(command.CommandRecord{Command: "ask", Started: now, Duration: 2 * time.Second, Success: true,
+		Usage: command.TokenUsage{InputTokens: 12, OutputTokens: 30, TotalTokens: 42}}))
+	require.NoError(t, store.Record(command.CommandRecord{Command: "ask", Started: now, Duration: time.Second, Error: "boom"}))
+	require.NoError(t, store.Record(command.CommandRecord{Command: "version", Started: now.Add(-48 * time.Hour), Duration: time.Millisecond, Success: true}))
+
+	output, err = run([]string{"commands"}, nil, nil)
+	require.NoError(t, err)
+	assert.Contains(t, output, "COMMAND")
+	assert.Regexp(t, `ask\s+2\s+1\s+1\.5s\s+3s\s+42 \(12 in, 30 out\)`, output)
+	assert.Contains(t, output, "version")
+
+	output, err = run([]string{"commands"}, map[string]interface{}{"since": "24h"}, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, output, "version")
+
+	output, err = run([]string{"commands", "version"}, nil, map[string]interface{}{"outputFormat": "json"})
+	require.NoError(t, err)
+	var result struct {
+		Commands []command.CommandSummary `json:"commands"`
+		Runs     int                      `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &result))
+	assert.Equal(t, 1, result.Runs)
+	require.Len(t, result.Commands, 1)
+	assert.Equal(t, "version", result.Commands[0].Command)
+
+	output, err = run([]string{"clear"}, map[string]interface{}{command.FlagYes: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Command stats cleared\n", output)
+	records, err := store.Records()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, err = run([]string{"bogus"}, nil, nil)
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+}
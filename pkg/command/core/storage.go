@@ -0,0 +1,354 @@
+// ABOUTME: Storage command - Maintenance of the session storage backend
+// ABOUTME: Provides fsck, which restores damaged session files, repair, which reconciles a replica, and gc
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/repl/session"
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/lexlapax/magellai/pkg/storage/filesystem"
+)
+
+// StorageCommand implements maintenance of session storage
+type StorageCommand struct {
+	config *config.Config
+}
+
+// NewStorageCommand creates a new storage command instance
+func NewStorageCommand(cfg *config.Config) *StorageCommand {
+	return &StorageCommand{
+		config: cfg,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *StorageCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "storage",
+		Description: "Check and repair session storage",
+		LongDescription: `The storage command maintains the session storage backend.
+
+Subcommands:
+  fsck     Check every session file of the filesystem backend
+  repair   Bring the replica up to date with the primary backend
+  gc       Delete data that no session refers to any more
+
+fsck reports session files that are empty, truncated or not valid sessions,
+such as files cut short by a crash in the middle of a write. A damaged file is
+restored from the newest crash recovery copy of its session, if there is one;
+the damaged file is kept next to it with a .corrupt suffix. Files without a
+copy are reported as unrecoverable and left in place.
+
+repair applies to storage with a replica (session.storage.replica.type), which
+mirrors every session write of the primary backend. A replica falls behind
+when a mirrored write fails; repair copies the sessions it is missing or has
+older copies of, and deletes the ones the primary no longer has.
+
+gc removes what deleted sessions left behind: in a SQLite database,
+conversations, messages, tags and search entries of sessions that no longer
+exist, and attachment content no message uses; in the filesystem backend,
+attachment files no session uses. The SQLite backend also does this on its
+own every session.storage.settings.gc_interval (24h by default).
+
+Examples:
+  magellai storage fsck
+  magellai storage fsck --dry-run
+  magellai storage fsck -o json
+  magellai storage repair --dry-run
+  magellai storage gc --dry-run`,
+		Category: command.CategoryCLI,
+		Flags: []command.Flag{
+			command.DryRunFlag,
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *StorageCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	return nil
+}
+
+// Execute runs the storage command
+func (c *StorageCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+
+	if len(exec.Args) == 0 {
+		return fmt.Errorf("storage: %w - subcommand required (fsck, repair, gc)", command.ErrMissingArgument)
+	}
+	switch exec.Args[0] {
+	case "fsck", "check":
+		return c.fsck(exec)
+	case "repair":
+		return c.repair(exec)
+	case "gc":
+		return c.gc(exec)
+	default:
+		return fmt.Errorf("storage: %w - invalid subcommand '%s'", command.ErrInvalidArguments, exec.Args[0])
+	}
+}
+
+// fsck checks the session files and restores damaged ones from recovery copies
+func (c *StorageCommand) fsck(exec *command.ExecutionContext) error {
+	storageType, storageConfig, err := sessionStorageConfig(c.config)
+	if err != nil {
+		return err
+	}
+	if storageType != storage.FileSystemBackend {
+		return fmt.Errorf("storage fsck: %w - only the filesystem backend can be checked (session.storage.type is %s)",
+			command.ErrInvalidArguments, storageType)
+	}
+	repair := !command.IsDryRun(exec)
+	if repair && storageConfig.ReadOnly() {
+		return fmt.Errorf("storage fsck: cannot repair read-only storage, use --dry-run: %w", storage.ErrReadOnly)
+	}
+
+	backend, err := filesystem.New(storageConfig)
+	if err != nil {
+		return fmt.Errorf("failed to open session storage: %w", err)
+	}
+	defer backend.Close()
+
+	files, err := session.ReadRecoveryFiles(session.DefaultAutoRecoveryConfig())
+	if err != nil {
+		logging.LogWarn("Failed to read recovery files", "error", err)
+	}
+	copies := make([]filesystem.RecoveryCopy, 0, len(files))
+	for _, file := range files {
+		copies = append(copies, filesystem.RecoveryCopy{
+			Source:  file.Path,
+			Saved:   file.State.Timestamp,
+			Session: file.State.ConversationData,
+		})
+	}
+
+	report, err := backend.(*filesystem.Backend).Check(filesystem.CheckOptions{Repair: repair, Copies: copies})
+	if err != nil {
+		return err
+	}
+	logging.LogInfo("Checked session storage", "checked", report.Checked, "damaged", len(report.Problems), "unrecoverable", report.Unrecoverable())
+
+	exec.Data["report"] = report
+	if !repair {
+		return command.ReportDryRun(exec, checkChanges(report)...)
+	}
+	if structuredOutputRequested(exec) {
+		return setOutput(exec, report)
+	}
+	exec.Data["output"] = formatCheckReport(report)
+	return nil
+}
+
+// formatCheckReport renders a storage check for the terminal
+func formatCheckReport(report *filesystem.CheckReport) string {
+	if len(report.Problems) == 0 {
+		return fmt.Sprintf("Checked %d session file(s): no problems found", report.Checked)
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Checked %d session file(s), %d damaged:\n", report.Checked, len(report.Problems)))
+	for _, p := range report.Problems {
+		output.WriteString(fmt.Sprintf("  %s  %s\n", p.SessionID, p.Problem))
+		switch p.Status {
+		case filesystem.FileRestored:
+			output.WriteString(fmt.Sprintf("    restored from %s (saved %s)\n", p.Source, p.Saved.Format("2006-01-02 15:04:05")))
+		case filesystem.FileRestorable:
+			output.WriteString(fmt.Sprintf("    can be restored from %s (saved %s)\n", p.Source, p.Saved.Format("2006-01-02 15:04:05")))
+		default:
+			output.WriteString(fmt.Sprintf("    unrecoverable: no copy found; the file is left at %s\n", p.Path))
+		}
+	}
+	return strings.TrimRight(output.String(), "\n")
+}
+
+// checkChanges lists what fsck would do about each damaged file
+func checkChanges(report *filesystem.CheckReport) []string {
+	changes := make([]string, 0, len(report.Problems))
+	for _, p := range report.Problems {
+		if p.Status == filesystem.FileUnrecoverable {
+			changes = append(changes, fmt.Sprintf("leave session %s (%s) at %s: no copy found", p.SessionID, p.Problem, p.Path))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("restore session %s (%s) from %s (saved %s)",
+			p.SessionID, p.Problem, p.Source, p.Saved.Format("2006-01-02 15:04:05")))
+	}
+	return changes
+}
+
+// repair reconciles the replica with the primary backend
+func (c *StorageCommand) repair(exec *command.ExecutionContext) error {
+	storageType, storageConfig, err := sessionStorageConfig(c.config)
+	if err != nil {
+		return err
+	}
+	if _, _, ok := storageConfig.Replica(); !ok {
+		return fmt.Errorf("storage repair: %w - no replica is configured (session.storage.replica.type)", command.ErrInvalidArguments)
+	}
+	dryRun := command.IsDryRun(exec)
+	if !dryRun && storageConfig.ReadOnly() {
+		return fmt.Errorf("storage repair: cannot repair read-only storage, use --dry-run: %w", storage.ErrReadOnly)
+	}
+
+	backend, err := storage.CreateBackend(storageType, storageConfig)
+	if err != nil {
+		return fmt.Errorf("failed to open session storage: %w", err)
+	}
+	defer backend.Close()
+
+	report, err := backend.(*storage.ReplicatingBackend).Repair(dryRun)
+	if err != nil {
+		return err
+	}
+	logging.LogInfo("Checked storage replica", "checked", report.Checked, "diverged", report.Diverged(), "repaired", report.Repaired)
+
+	exec.Data["report"] = report
+	if dryRun {
+		return command.ReportDryRun(exec, replicaChanges(report)...)
+	}
+	if structuredOutputRequested(exec) {
+		return setOutput(exec, report)
+	}
+	exec.Data["output"] = formatReplicaReport(report)
+	return nil
+}
+
+// formatReplicaReport renders a replica check for the terminal
+func formatReplicaReport(report *storage.ReplicaReport) string {
+	if report.Diverged() == 0 {
+		return fmt.Sprintf("Checked %d session(s): the replica is up to date", report.Checked)
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Checked %d session(s), %d differ in the replica:\n", report.Checked, report.Diverged()))
+	for _, group := range []struct {
+		ids           []string
+		fixed, broken string
+	}{
+		{report.Missing, "copied", "missing"},
+		{report.Stale, "updated", "out of date"},
+		{report.Extra, "deleted", "not in the primary"},
+	} {
+		for _, id := range group.ids {
+			status := group.broken
+			if report.Repaired {
+				status = group.fixed
+			}
+			output.WriteString(fmt.Sprintf("  %s  %s\n", id, status))
+		}
+	}
+	return strings.TrimRight(output.String(), "\n")
+}
+
+// replicaChanges lists what repair would do to the replica
+func replicaChanges(report *storage.ReplicaReport) []string {
+	changes := make([]string, 0, report.Diverged())
+	for _, id := range report.Missing {
+		changes = append(changes, fmt.Sprintf("copy session %s to the replica", id))
+	}
+	for _, id := range report.Stale {
+		changes = append(changes, fmt.Sprintf("update session %s in the replica", id))
+	}
+	for _, id := range report.Extra {
+		changes = append(changes, fmt.Sprintf("delete session %s from the replica", id))
+	}
+	return changes
+}
+
+// gc deletes data that no session refers to
+func (c *StorageCommand) gc(exec *command.ExecutionContext) error {
+	storageType, storageConfig, err := sessionStorageConfig(c.config)
+	if err != nil {
+		return err
+	}
+	dryRun := command.IsDryRun(exec)
+	if !dryRun && storageConfig.ReadOnly() {
+		return fmt.Errorf("storage gc: cannot collect garbage in read-only storage, use --dry-run: %w", storage.ErrReadOnly)
+	}
+
+	backend, err := storage.CreateBackend(storageType, storageConfig)
+	if err != nil {
+		return fmt.Errorf("failed to open session storage: %w", err)
+	}
+	defer backend.Close()
+
+	collector, ok := backend.(storage.GarbageCollector)
+	if !ok {
+		return fmt.Errorf("storage gc: %w - the %s backend does not support garbage collection", command.ErrInvalidArguments, storageType)
+	}
+	report, err := collector.CollectGarbage(dryRun)
+	if err != nil {
+		return err
+	}
+	logging.LogInfo("Collected storage garbage", "orphaned", report.Total(), "collected", report.Collected)
+
+	exec.Data["report"] = report
+	if dryRun {
+		return command.ReportDryRun(exec, gcChanges(report)...)
+	}
+	if structuredOutputRequested(exec) {
+		return setOutput(exec, report)
+	}
+	exec.Data["output"] = formatGCReport(report)
+	return nil
+}
+
+// formatGCReport renders a garbage collection for the terminal
+func formatGCReport(report *storage.GCReport) string {
+	if report.Total() == 0 {
+		return "No orphaned data found"
+	}
+
+	var output strings.Builder
+	if report.Collected {
+		output.WriteString("Deleted orphaned data:\n")
+	} else {
+		output.WriteString("Found orphaned data:\n")
+	}
+	for _, item := range gcItems(report) {
+		if item.count > 0 {
+			output.WriteString(fmt.Sprintf("  %-22s %d\n", item.label, item.count))
+		}
+	}
+	return strings.TrimRight(output.String(), "\n")
+}
+
+// gcItem is one kind of orphaned data in a garbage collection
+type gcItem struct {
+	label string
+	count int
+}
+
+// gcItems lists the kinds of orphaned data a garbage collection counts
+func gcItems(report *storage.GCReport) []gcItem {
+	return []gcItem{
+		{"conversations", report.Conversations},
+		{"messages", report.Messages},
+		{"tags", report.Tags},
+		{"snapshots", report.Snapshots},
+		{"search entries", report.SearchEntries},
+		{"attachment references", report.AttachmentRefs},
+		{"attachments", report.Attachments},
+	}
+}
+
+// gcChanges lists what gc would delete
+func gcChanges(report *storage.GCReport) []string {
+	var changes []string
+	for _, item := range gcItems(report) {
+		if item.count > 0 {
+			changes = append(changes, fmt.Sprintf("delete %d orphaned %s", item.count, item.label))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,213 @@
+// ABOUTME: Tests for the storage command
+// ABOUTME: Verifies fsck finds damaged session files and restores them from crash recovery files
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/configdir"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/repl/session"
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageCommand_Fsck(t *testing.T) {
+	cfg := createTestConfig(t)
+	paths, err := configdir.GetPaths()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(paths.Sessions, 0755))
+
+	// A session cut short by a crash, with a copy in the recovery file
+	saved := domain.NewSession("crashed")
+	saved.Name = "Before the crash"
+	saved.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "keep me"))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Sessions, "crashed.json"), []byte(`{"id": "crashed", "name": "Bef`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Sessions, "lost.json"), nil, 0644))
+
+	recoveryDir := session.DefaultAutoRecoveryConfig().RecoveryDirectory
+	require.NoError(t, os.MkdirAll(recoveryDir, 0755))
+	state, err := json.Marshal(session.RecoveryState{SessionID: saved.ID, ConversationData: saved, Timestamp: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(recoveryDir, "recovery.json"), state, 0600))
+
+	run := func(args []string, flags, data map[string]interface{}) (*command.ExecutionContext, error) {
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Data:   data,
+		}
+		err := NewStorageCommand(cfg).Execute(context.Background(), exec)
+		return exec, err
+	}
+
+	_, err = run(nil, nil, nil)
+	assert.ErrorIs(t, err, command.ErrMissingArgument)
+	_, err = run([]string{"defrag"}, nil, nil)
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+
+	exec, err := run([]string{"fsck"}, map[string]interface{}{command.FlagDryRun: true}, nil)
+	require.NoError(t, err)
+	output := exec.Stdout.(*bytes.Buffer).String()
+	assert.Contains(t, output, "Would restore session crashed (")
+	assert.Contains(t, output, "Would leave session lost (")
+	assert.Contains(t, output, "Dry run: nothing was changed")
+	assert.Len(t, exec.Data[command.DataKeyDryRun], 2)
+
+	exec, err = run([]string{"fsck"}, nil, map[string]interface{}{"outputFormat": command.OutputFormatJSON})
+	require.NoError(t, err)
+	var report struct {
+		Checked  int `json:"checked"`
+		Problems []struct {
+			SessionID string `json:"session_id"`
+			Status    string `json:"status"`
+		} `json:"problems"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(exec.Data["output"].(string)), &report))
+	assert.Equal(t, 2, report.Checked)
+	statuses := map[string]string{}
+	for _, p := range report.Problems {
+		statuses[p.SessionID] = p.Status
+	}
+	assert.Equal(t, map[string]string{"crashed": "restored", "lost": "unrecoverable"}, statuses)
+
+	manager, err := openSessionManager(cfg, nil)
+	require.NoError(t, err)
+	restored, err := manager.StorageManager.LoadSession("crashed")
+	require.NoError(t, err)
+	assert.Equal(t, "Before the crash", restored.Name)
+	require.Len(t, restored.Conversation.Messages, 1)
+
+	t.Run("read-only storage", func(t *testing.T) {
+		require.NoError(t, cfg.SetValue("session.storage.read_only", true))
+		defer func() { require.NoError(t, cfg.SetValue("session.storage.read_only", false)) }()
+
+		_, err := run([]string{"fsck"}, nil, nil)
+		assert.ErrorIs(t, err, storage.ErrReadOnly)
+		_, err = run([]string{"fsck"}, map[string]interface{}{command.FlagDryRun: true}, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("other backends", func(t *testing.T) {
+		require.NoError(t, cfg.SetValue("session.storage.type", "sqlite"))
+		defer func() { require.NoError(t, cfg.SetValue("session.storage.type", "filesystem")) }()
+
+		_, err := run([]string{"fsck"}, nil, nil)
+		assert.ErrorIs(t, err, command.ErrInvalidArguments)
+	})
+}
+
+func TestStorageCommand_Repair(t *testing.T) {
+	cfg := createTestConfig(t)
+	run := func(flags map[string]interface{}) (*command.ExecutionContext, error) {
+		exec := &command.ExecutionContext{
+			Args:   []string{"repair"},
+			Flags:  command.NewFlags(flags),
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Data:   map[string]interface{}{},
+		}
+		err := NewStorageCommand(cfg).Execute(context.Background(), exec)
+		return exec, err
+	}
+
+	_, err := run(nil)
+	assert.ErrorIs(t, err, command.ErrInvalidArguments, "no replica is configured")
+
+	require.NoError(t, cfg.SetValue("session.storage.replica.type", "filesystem"))
+	manager, err := openSessionManager(cfg, nil)
+	require.NoError(t, err)
+	s, err := manager.NewSession("mirrored")
+	require.NoError(t, err)
+	require.NoError(t, manager.Close())
+
+	// The replica loses its copy
+	paths, err := configdir.GetPaths()
+	require.NoError(t, err)
+	replicaFile := filepath.Join(paths.Sessions, "replica", s.ID+".json")
+	require.NoError(t, os.Remove(replicaFile))
+
+	exec, err := run(map[string]interface{}{command.FlagDryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"copy session " + s.ID + " to the replica"}, exec.Data[command.DataKeyDryRun])
+	assert.Contains(t, exec.Stdout.(*bytes.Buffer).String(), "Dry run: nothing was changed")
+	_, err = os.Stat(replicaFile)
+	assert.True(t, os.IsNotExist(err))
+
+	exec, err = run(nil)
+	require.NoError(t, err)
+	assert.Contains(t, exec.Data["output"], s.ID+"  copied")
+	_, err = os.Stat(replicaFile)
+	assert.NoError(t, err)
+
+	exec, err = run(nil)
+	require.NoError(t, err)
+	assert.Contains(t, exec.Data["output"], "the replica is up to date")
+}
+
+func TestStorageCommand_GC(t *testing.T) {
+	cfg := createTestConfig(t)
+	run := func(flags map[string]interface{}) (*command.ExecutionContext, error) {
+		exec := &command.ExecutionContext{
+			Args:   []string{"gc"},
+			Flags:  command.NewFlags(flags),
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Data:   map[string]interface{}{},
+		}
+		err := NewStorageCommand(cfg).Execute(context.Background(), exec)
+		return exec, err
+	}
+
+	exec, err := run(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "No orphaned data found", exec.Data["output"])
+
+	// A session whose file was removed behind the backend's back
+	manager, err := openSessionManager(cfg, nil)
+	require.NoError(t, err)
+	s, err := manager.NewSession("attached")
+	require.NoError(t, err)
+	msg := domain.NewMessage("msg-1", domain.MessageRoleUser, "see attached")
+	msg.AddAttachment(domain.Attachment{ID: "att-1", Type: domain.AttachmentTypeText, Name: "notes.txt", Content: []byte("notes")})
+	s.Conversation.AddMessage(*msg)
+	require.NoError(t, manager.StorageManager.SaveSession(s))
+	require.NoError(t, manager.Close())
+	paths, err := configdir.GetPaths()
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(paths.Sessions, s.ID+".json")))
+
+	exec, err = run(map[string]interface{}{command.FlagDryRun: true})
+	require.NoError(t, err)
+	output := exec.Stdout.(*bytes.Buffer).String()
+	assert.Contains(t, output, "Would delete 1 orphaned attachments")
+	assert.Contains(t, output, "Dry run: nothing was changed")
+
+	exec, err = run(nil)
+	require.NoError(t, err)
+	assert.Contains(t, exec.Data["output"], "Deleted orphaned data")
+	report := exec.Data["report"].(*storage.GCReport)
+	assert.Equal(t, 1, report.Attachments)
+
+	exec, err = run(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "No orphaned data found", exec.Data["output"])
+
+	require.NoError(t, cfg.SetValue("session.storage.read_only", true))
+	_, err = run(nil)
+	assert.ErrorIs(t, err, storage.ErrReadOnly)
+}
@@ -0,0 +1,283 @@
+// ABOUTME: Template command - Manages reusable prompt templates with variables
+// ABOUTME: Provides add, list, show, remove, render, and run for stored templates
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/templates"
+)
+
+// TemplateCommand implements prompt template management
+type TemplateCommand struct {
+	config *config.Config
+}
+
+// NewTemplateCommand creates a new template command instance
+func NewTemplateCommand(cfg *config.Config) *TemplateCommand {
+	return &TemplateCommand{
+		config: cfg,
+	}
+}
+
+// Metadata returns the command metadata
+func (c *TemplateCommand) Metadata() *command.Metadata {
+	return &command.Metadata{
+		Name:        "template",
+		Aliases:     []string{"templates", "tpl"},
+		Description: "Manage reusable prompt templates",
+		LongDescription: `The template command manages prompt templates with {{placeholders}}:
+
+Subcommands:
+  list                          List all templates
+  add <name> [content]          Add or replace a template (content from --file or stdin if omitted)
+  show <name>                   Show a template and its variables
+  remove <name>                 Remove a template
+  render <name> [var=value...]  Print the filled-in prompt
+  run <name> [var=value...]     Fill in the template and send it to the LLM
+
+Placeholders are written {{name}} or {{name|default}}. Variable values of the
+form @path read a file, and - reads standard input.
+
+Examples:
+  template add review "Review this {{lang|Go}} code: {{code}}"
+  template run review code=@main.go
+  git diff | template run review code=-
+  template render review lang=Python code=@app.py`,
+		Category: command.CategoryShared,
+		Flags: []command.Flag{
+			{
+				Name:        "description",
+				Short:       "d",
+				Description: "Template description (add)",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "file",
+				Short:       "f",
+				Description: "Read template content from a file (add)",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "model",
+				Short:       "m",
+				Description: "Model to use (run)",
+				Type:        command.FlagTypeString,
+			},
+			{
+				Name:        "output",
+				Short:       "o",
+				Description: "Output format (text|json)",
+				Type:        command.FlagTypeString,
+				Default:     "text",
+			},
+		},
+	}
+}
+
+// Validate checks if the command configuration is valid
+func (c *TemplateCommand) Validate() error {
+	if c.config == nil {
+		return fmt.Errorf("config manager not initialized")
+	}
+	return nil
+}
+
+// Execute runs the template command
+func (c *TemplateCommand) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+
+	store, err := c.store()
+	if err != nil {
+		return err
+	}
+
+	if len(exec.Args) == 0 {
+		return c.listTemplates(exec, store)
+	}
+
+	args := exec.Args[1:]
+	switch exec.Args[0] {
+	case "list":
+		return c.listTemplates(exec, store)
+	case "add", "set":
+		if len(args) < 1 {
+			return fmt.Errorf("template add: %w - name required", command.ErrMissingArgument)
+		}
+		return c.addTemplate(exec, store, args[0], strings.Join(args[1:], " "))
+	case "show", "get":
+		if len(args) < 1 {
+			return fmt.Errorf("template show: %w - name required", command.ErrMissingArgument)
+		}
+		return c.showTemplate(exec, store, args[0])
+	case "remove", "delete", "rm":
+		if len(args) < 1 {
+			return fmt.Errorf("template remove: %w - name required", command.ErrMissingArgument)
+		}
+		if err := store.Delete(args[0]); err != nil {
+			return err
+		}
+		exec.Data["output"] = fmt.Sprintf("Template '%s' removed", args[0])
+		return nil
+	case "render":
+		if len(args) < 1 {
+			return fmt.Errorf("template render: %w - name required", command.ErrMissingArgument)
+		}
+		prompt, err := c.render(exec, store, args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		exec.Data["output"] = prompt
+		return nil
+	case "run":
+		if len(args) < 1 {
+			return fmt.Errorf("template run: %w - name required", command.ErrMissingArgument)
+		}
+		return c.runTemplate(ctx, exec, store, args[0], args[1:])
+	default:
+		return fmt.Errorf("template: %w - invalid subcommand '%s'", command.ErrInvalidArguments, exec.Args[0])
+	}
+}
+
+// store returns the template store, honoring template.directory if set
+func (c *TemplateCommand) store() (*templates.Store, error) {
+	return templates.OpenStore(c.config.GetString("template.directory"))
+}
+
+// listTemplates lists all stored templates
+func (c *TemplateCommand) listTemplates(exec *command.ExecutionContext, store *templates.Store) error {
+	list, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("No templates defined (directory: %s)", store.Dir())
+	if len(list) > 0 {
+		lines := []string{"Templates:"}
+		for _, t := range list {
+			lines = append(lines,
+				fmt.Sprintf("  %-16s %s", t.Name, t.Description),
+				fmt.Sprintf("  %-16s variables: %s", "", t.Describe()))
+		}
+		text = strings.Join(lines, "\n")
+	}
+	return setFormattedOutput(exec, c.getOutputFormat(exec), command.Result{
+		Text:  text,
+		Table: templateTable(list),
+		Value: map[string]interface{}{
+			"templates": list,
+			"count":     len(list),
+		},
+	})
+}
+
+// getOutputFormat returns the format set by --output, or the global output
+// format
+func (c *TemplateCommand) getOutputFormat(exec *command.ExecutionContext) command.OutputFormat {
+	if format := exec.Flags.GetString("output"); format != "" {
+		return command.OutputFormat(format)
+	}
+	return outputFormat(exec)
+}
+
+// templateTable lists templates or prompts with their variables
+func templateTable(list []*templates.Template) *command.Table {
+	table := command.NewTable("NAME", "DESCRIPTION", "VARIABLES")
+	for _, t := range list {
+		table.AddRow(t.Name, t.Description, t.Describe())
+	}
+	return table
+}
+
+// templateText is the text view of a template or prompt: its name,
+// description, and variables over its content
+func templateText(kind string, t *templates.Template) string {
+	lines := []string{fmt.Sprintf("%s: %s", kind, t.Name)}
+	if t.Description != "" {
+		lines = append(lines, fmt.Sprintf("Description: %s", t.Description))
+	}
+	lines = append(lines, fmt.Sprintf("Variables: %s", t.Describe()), "", t.Content)
+	return strings.Join(lines, "\n")
+}
+
+// addTemplate creates or replaces a template
+func (c *TemplateCommand) addTemplate(exec *command.ExecutionContext, store *templates.Store, name, content string) error {
+	if file := exec.Flags.GetString("file"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read template file: %w", err)
+		}
+		content = string(data)
+	}
+	if content == "" && exec.Stdin != nil {
+		data, err := io.ReadAll(exec.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read template from stdin: %w", err)
+		}
+		content = string(data)
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("template add: %w - content required", command.ErrMissingArgument)
+	}
+
+	t := &templates.Template{
+		Name:        name,
+		Description: exec.Flags.GetString("description"),
+		Content:     content,
+	}
+	if err := store.Save(t); err != nil {
+		return err
+	}
+
+	logging.LogInfo("Template saved", "name", name)
+	return setFormattedOutput(exec, c.getOutputFormat(exec), command.Result{
+		Text:  fmt.Sprintf("Template '%s' saved (variables: %s)", name, t.Describe()),
+		Value: t,
+	})
+}
+
+// showTemplate displays a template and its variables
+func (c *TemplateCommand) showTemplate(exec *command.ExecutionContext, store *templates.Store, name string) error {
+	t, err := store.Get(name)
+	if err != nil {
+		return err
+	}
+
+	return setFormattedOutput(exec, c.getOutputFormat(exec), command.Result{Text: templateText("Template", t), Value: t})
+}
+
+// render fills in a stored template from name=value arguments
+func (c *TemplateCommand) render(exec *command.ExecutionContext, store *templates.Store, name string, args []string) (string, error) {
+	t, err := store.Get(name)
+	if err != nil {
+		return "", err
+	}
+	vars, err := templates.ParseVariables(args, exec.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return t.Render(vars)
+}
+
+// runTemplate renders a template and sends the result through the ask command
+func (c *TemplateCommand) runTemplate(ctx context.Context, exec *command.ExecutionContext, store *templates.Store, name string, args []string) error {
+	prompt, err := c.render(exec, store, name, args)
+	if err != nil {
+		return err
+	}
+
+	logging.LogInfo("Running template", "name", name, "promptLength", len(prompt))
+	askExec := *exec
+	askExec.Args = []string{prompt}
+	return NewAskCommand(c.config).Execute(ctx, &askExec)
+}
@@ -0,0 +1,118 @@
+// ABOUTME: Unit tests for the template command
+// ABOUTME: Tests add, list, show, render, run, and remove of prompt templates
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateCommand_Execute(t *testing.T) {
+	require.NoError(t, config.Init())
+	cfg := config.Manager
+	require.NoError(t, cfg.SetValue("template.directory", t.TempDir()))
+
+	cmd := NewTemplateCommand(cfg)
+	ctx := context.Background()
+
+	run := func(args []string, flags map[string]interface{}, stdin string) (*command.ExecutionContext, error) {
+		exec := &command.ExecutionContext{
+			Args:   args,
+			Flags:  command.NewFlags(flags),
+			Stdout: &bytes.Buffer{},
+			Stderr: &bytes.Buffer{},
+			Data:   make(map[string]interface{}),
+		}
+		if stdin != "" {
+			exec.Stdin = strings.NewReader(stdin)
+		}
+		return exec, cmd.Execute(ctx, exec)
+	}
+
+	exec, err := run([]string{"list"}, nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, exec.Data["output"], "No templates defined")
+
+	exec, err = run([]string{"add", "review", "Review", "{{code}}", "in", "{{lang|Go}}"},
+		map[string]interface{}{"description": "Code review"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, `Template 'review' saved (variables: code, lang="Go")`, exec.Data["output"])
+
+	// Content can come from stdin
+	_, err = run([]string{"add", "explain"}, nil, "Explain {{topic}}")
+	require.NoError(t, err)
+
+	exec, err = run(nil, nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, exec.Data["output"], "review")
+	assert.Contains(t, exec.Data["output"], "Code review")
+
+	exec, err = run([]string{"show", "review"}, map[string]interface{}{"output": "json"}, "")
+	require.NoError(t, err)
+	assert.Contains(t, exec.Data["output"], `"content": "Review {{code}} in {{lang|Go}}"`)
+
+	file := filepath.Join(t.TempDir(), "main.py")
+	require.NoError(t, os.WriteFile(file, []byte("print(1)"), 0644))
+	exec, err = run([]string{"render", "review", "code=@" + file, "lang=Python"}, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Review print(1) in Python", exec.Data["output"])
+
+	exec, err = run([]string{"render", "explain", "topic=-"}, nil, "piped topic")
+	require.NoError(t, err)
+	assert.Equal(t, "Explain piped topic", exec.Data["output"])
+
+	_, err = run([]string{"render", "review"}, nil, "")
+	assert.True(t, errors.Is(err, templates.ErrMissingVariable))
+
+	_, err = run([]string{"remove", "review"}, nil, "")
+	require.NoError(t, err)
+	_, err = run([]string{"show", "review"}, nil, "")
+	assert.True(t, errors.Is(err, templates.ErrTemplateNotFound))
+
+	_, err = run([]string{"bogus"}, nil, "")
+	assert.True(t, errors.Is(err, command.ErrInvalidArguments))
+	_, err = run([]string{"add"}, nil, "")
+	assert.True(t, errors.Is(err, command.ErrMissingArgument))
+}
+
+func TestTemplateCommand_Run(t *testing.T) {
+	require.NoError(t, config.Init())
+	cfg := config.Manager
+	require.NoError(t, cfg.SetValue("template.directory", t.TempDir()))
+	require.NoError(t, cfg.SetValue("model.default", "mock/test-model"))
+	require.NoError(t, cfg.SetValue("provider.mock.api_key", "mock-api-key"))
+
+	cmd := NewTemplateCommand(cfg)
+	ctx := context.Background()
+
+	require.NoError(t, cmd.Execute(ctx, &command.ExecutionContext{
+		Args:  []string{"add", "greet", "Say hello to {{name}}"},
+		Flags: command.NewFlags(nil),
+		Data:  make(map[string]interface{}),
+	}))
+
+	var stdout bytes.Buffer
+	exec := &command.ExecutionContext{
+		Context: ctx,
+		Args:    []string{"run", "greet", "name=Ada"},
+		Flags:   command.NewFlags(nil),
+		Stdout:  &stdout,
+		Stderr:  &bytes.Buffer{},
+		Data:    make(map[string]interface{}),
+		Config:  cfg,
+	}
+	require.NoError(t, cmd.Execute(ctx, exec))
+	assert.NotEmpty(t, stdout.String())
+}
@@ -5,7 +5,6 @@ package core
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/lexlapax/magellai/pkg/command"
@@ -40,34 +39,21 @@ func (c *VersionCommand) Execute(ctx context.Context, exec *command.ExecutionCon
 		exec.Data = make(map[string]interface{})
 	}
 
-	// Check for JSON output format
-	format := exec.Flags.GetString("format")
-	if format == "" {
-		if f, ok := exec.Data["outputFormat"].(string); ok {
-			format = f
-		}
+	// The --format flag overrides the global output format
+	format := outputFormat(exec)
+	if f := exec.Flags.GetString("format"); f != "" {
+		format = command.OutputFormat(f)
 	}
 
-	if format == "json" {
-		jsonData, err := json.MarshalIndent(c.info, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal version info: %w", err)
-		}
-		exec.Data["output"] = string(jsonData)
-	} else {
-		// Default text format
-		output := fmt.Sprintf("magellai version %s", c.info.Version)
-		if c.info.Commit != "" && c.info.Commit != "none" {
-			output += fmt.Sprintf(" (commit: %s", c.info.Commit)
-			if c.info.Date != "" && c.info.Date != "unknown" {
-				output += fmt.Sprintf(", built: %s", c.info.Date)
-			}
-			output += ")"
+	output := fmt.Sprintf("magellai version %s", c.info.Version)
+	if c.info.Commit != "" && c.info.Commit != "none" {
+		output += fmt.Sprintf(" (commit: %s", c.info.Commit)
+		if c.info.Date != "" && c.info.Date != "unknown" {
+			output += fmt.Sprintf(", built: %s", c.info.Date)
 		}
-		exec.Data["output"] = output
+		output += ")"
 	}
-
-	return nil
+	return setResultOutput(exec, format, output, c.info)
 }
 
 // Metadata returns the command metadata
@@ -89,7 +75,7 @@ Examples:
 			{
 				Name:        "format",
 				Type:        command.FlagTypeString,
-				Description: "Output format (text or json)",
+				Description: "Output format (text, json, or yaml)",
 			},
 		},
 	}
@@ -0,0 +1,157 @@
+// ABOUTME: Deprecation of commands and of old command names
+// ABOUTME: Maps renamed commands to their replacements and warns users who still use them
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// Deprecation describes a deprecated command or command name
+type Deprecation struct {
+	// Name is the deprecated command or name
+	Name string `json:"name"`
+	// ReplacedBy is the command to use instead
+	ReplacedBy string `json:"replaced_by,omitempty"`
+	// RemovalVersion is the release that removes the command or name
+	RemovalVersion string `json:"removal_version,omitempty"`
+	// Message says more about the deprecation
+	Message string `json:"message,omitempty"`
+}
+
+// Warning returns the message shown to users of a deprecated command
+func (d Deprecation) Warning() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q is deprecated", d.Name)
+	if d.RemovalVersion != "" {
+		fmt.Fprintf(&b, " and will be removed in %s", d.RemovalVersion)
+	}
+	if d.ReplacedBy != "" {
+		fmt.Fprintf(&b, "; use %q instead", d.ReplacedBy)
+	}
+	if d.Message != "" {
+		b.WriteString(": " + d.Message)
+	}
+	return b.String()
+}
+
+// Deprecation returns how the command is deprecated, or nil when it is not
+func (m *Metadata) Deprecation() *Deprecation {
+	if m.Deprecated == "" && m.ReplacedBy == "" && m.RemovalVersion == "" {
+		return nil
+	}
+	return &Deprecation{
+		Name:           m.Name,
+		ReplacedBy:     m.ReplacedBy,
+		RemovalVersion: m.RemovalVersion,
+		Message:        m.Deprecated,
+	}
+}
+
+// WarnDeprecated logs a deprecation and writes its warning to w
+func WarnDeprecated(w io.Writer, d *Deprecation) {
+	if d == nil {
+		return
+	}
+	logging.LogWarn("Deprecated command used", "name", d.Name, "replacedBy", d.ReplacedBy, "removalVersion", d.RemovalVersion)
+	if w != nil {
+		fmt.Fprintf(w, "Warning: %s\n", d.Warning())
+	}
+}
+
+// resolve looks up a command by name, warning on stderr, or the default
+// stderr when it is nil, if the name is a deprecated old name
+func (e *CommandExecutor) resolve(name string, stderr io.Writer) (Interface, error) {
+	cmd, deprecation, err := e.registry.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if stderr == nil {
+		stderr = e.defaultStderr
+	}
+	WarnDeprecated(stderr, deprecation)
+	return cmd, nil
+}
+
+// Deprecate keeps an old command name working after a command is renamed.
+// The old name runs newName's command and warns that it is deprecated,
+// optionally naming the release that removes it.
+func (r *Registry) Deprecate(oldName, newName, removalVersion string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	primary := newName
+	if alias, ok := r.aliases[newName]; ok {
+		primary = alias
+	}
+	if _, ok := r.commands[primary]; !ok {
+		return fmt.Errorf("%w: %s", ErrCommandNotFound, newName)
+	}
+	if _, ok := r.commands[oldName]; ok {
+		return fmt.Errorf("%w: %s", ErrCommandAlreadyRegistered, oldName)
+	}
+	if _, ok := r.aliases[oldName]; ok {
+		return fmt.Errorf("alias '%s' already registered", oldName)
+	}
+
+	r.aliases[oldName] = primary
+	r.deprecations[oldName] = Deprecation{Name: oldName, ReplacedBy: primary, RemovalVersion: removalVersion}
+	logging.LogDebug("Deprecated command name registered", "name", oldName, "replacedBy", primary)
+	return nil
+}
+
+// Resolve looks up a command like Get and also returns the deprecation of
+// the name used, which is nil unless it is a deprecated old name. Commands
+// that are deprecated themselves report it in their Metadata.
+func (r *Registry) Resolve(name string) (Interface, *Deprecation, error) {
+	cmd, err := r.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if d, ok := r.deprecations[name]; ok {
+		return cmd, &d, nil
+	}
+	return cmd, nil, nil
+}
+
+// Deprecations lists deprecated command names and deprecated commands,
+// sorted by name
+func (r *Registry) Deprecations() []Deprecation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deprecations := make([]Deprecation, 0, len(r.deprecations))
+	for _, d := range r.deprecations {
+		deprecations = append(deprecations, d)
+	}
+	for _, cmd := range r.commands {
+		if d := cmd.Metadata().Deprecation(); d != nil {
+			deprecations = append(deprecations, *d)
+		}
+	}
+	sort.Slice(deprecations, func(i, j int) bool { return deprecations[i].Name < deprecations[j].Name })
+	return deprecations
+}
+
+// DeprecatedNames returns the deprecated old names that map to a command
+func (r *Registry) DeprecatedNames(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var names []string
+	for old, d := range r.deprecations {
+		if d.ReplacedBy == name {
+			names = append(names, old)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
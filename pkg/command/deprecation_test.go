@@ -0,0 +1,82 @@
+// ABOUTME: Tests for command deprecation
+// ABOUTME: Verifies deprecated names, warnings, and deprecation listings
+
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecationWarning(t *testing.T) {
+	d := command.Deprecation{Name: "sessions", ReplacedBy: "history", RemovalVersion: "v1.0.0", Message: "sessions moved"}
+	assert.Equal(t, `"sessions" is deprecated and will be removed in v1.0.0; use "history" instead: sessions moved`, d.Warning())
+	assert.Equal(t, `"sessions" is deprecated`, command.Deprecation{Name: "sessions"}.Warning())
+
+	meta := &command.Metadata{Name: "old"}
+	assert.Nil(t, meta.Deprecation())
+	meta.ReplacedBy = "new"
+	require.NotNil(t, meta.Deprecation())
+	assert.Equal(t, "new", meta.Deprecation().ReplacedBy)
+}
+
+func TestRegistryDeprecate(t *testing.T) {
+	registry := command.NewRegistry()
+	ran := 0
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "history", Aliases: []string{"hist"}},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			ran++
+			return nil
+		},
+	)))
+
+	require.NoError(t, registry.Deprecate("sessions", "hist", "v1.0.0"))
+	assert.ErrorIs(t, registry.Deprecate("log", "missing", ""), command.ErrCommandNotFound)
+	assert.ErrorIs(t, registry.Deprecate("history", "hist", ""), command.ErrCommandAlreadyRegistered)
+	assert.Error(t, registry.Deprecate("sessions", "history", ""))
+
+	cmd, deprecation, err := registry.Resolve("sessions")
+	require.NoError(t, err)
+	assert.Equal(t, "history", cmd.Metadata().Name)
+	require.NotNil(t, deprecation)
+	assert.Equal(t, "history", deprecation.ReplacedBy)
+
+	_, deprecation, err = registry.Resolve("hist")
+	require.NoError(t, err)
+	assert.Nil(t, deprecation)
+
+	assert.NotContains(t, registry.Names(), "sessions")
+	assert.Equal(t, []string{"sessions"}, registry.DeprecatedNames("history"))
+	assert.Len(t, registry.Deprecations(), 1)
+
+	var stderr bytes.Buffer
+	executor := command.NewExecutor(registry, command.WithDefaultStreams(nil, &stderr, &stderr))
+	require.NoError(t, executor.ParseAndExecute(context.Background(), []string{"sessions"}))
+	require.NoError(t, executor.Execute(context.Background(), "history", &command.ExecutionContext{}))
+	assert.Equal(t, 2, ran)
+	assert.Equal(t, "Warning: \"sessions\" is deprecated and will be removed in v1.0.0; use \"history\" instead\n", stderr.String())
+}
+
+func TestDeprecatedCommandWarns(t *testing.T) {
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "chat-old", ReplacedBy: "chat", Deprecated: "use the chat command"},
+		func(ctx context.Context, exec *command.ExecutionContext) error { return nil },
+	)))
+
+	var stderr bytes.Buffer
+	executor := command.NewExecutor(registry)
+	err := executor.Execute(context.Background(), "chat-old", &command.ExecutionContext{Stderr: &stderr})
+	require.NoError(t, err)
+	assert.Contains(t, stderr.String(), `Warning: "chat-old" is deprecated; use "chat" instead`)
+
+	deprecations := registry.Deprecations()
+	require.Len(t, deprecations, 1)
+	assert.Equal(t, "chat-old", deprecations[0].Name)
+}
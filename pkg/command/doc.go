@@ -16,6 +16,12 @@ Key Components:
   - Discovery: Auto-discovery of commands using reflection
   - Constants: Shared constants for command names and categories
   - Flags: Common flag definitions and handling
+  - Formatter: Renders results as text, JSON, YAML, tables, or Markdown
+  - Prompter: Confirmations, choices, and masked input with non-interactive fallbacks
+  - Deprecation: Deprecated commands and old names mapped to their replacements
+  - Manifest: User commands declared in YAML or JSON files in the commands directory
+  - Telemetry: Hooks that record each command's duration, outcome, and token usage locally
+  - DryRun: The --dry-run convention that lets destructive commands report their changes instead
 
 The command system supports several advanced features:
   - Command categories and help text generation
@@ -23,6 +29,20 @@ The command system supports several advanced features:
   - Shared context across command invocations
   - Automatic command aliasing
   - Integration with both CLI and REPL environments
+  - Middleware and before/after hooks around every command
+  - Pipelines (cmd | cmd) and background jobs (cmd &) in ParseAndExecute
+
+Embedders and plugins add behavior such as logging or confirmation prompts
+with middleware instead of patching commands:
+
+	registry.AddExecutorOptions(command.WithMiddleware(func(next command.Handler) command.Handler {
+	    return func(ctx context.Context, cmd command.Interface, exec *command.ExecutionContext) error {
+	        start := time.Now()
+	        err := next(ctx, cmd, exec)
+	        log.Printf("%s took %s", cmd.Metadata().Name, time.Since(start))
+	        return err
+	    }
+	}))
 
 Usage:
 
@@ -0,0 +1,71 @@
+// ABOUTME: The --dry-run convention for destructive commands
+// ABOUTME: Commands that declare the flag report what they would change; the executor rejects it for the rest
+
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FlagDryRun is the flag that shows what a command would change without
+// changing anything
+const FlagDryRun = "dry-run"
+
+// DataKeyDryRun is the exec.Data key listing the changes a dry run found
+const DataKeyDryRun = "dry_run"
+
+// DryRunFlag is the standard --dry-run flag. A command that lists it in
+// its Metadata promises to make no changes when it is set.
+var DryRunFlag = Flag{
+	Name:        FlagDryRun,
+	Description: "Show what would change without changing anything",
+	Type:        FlagTypeBool,
+}
+
+// SupportsDryRun reports whether a command declares the --dry-run flag
+func (m *Metadata) SupportsDryRun() bool {
+	for _, flag := range m.Flags {
+		if flag.Name == FlagDryRun {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDryRun reports whether a command runs with --dry-run
+func IsDryRun(exec *ExecutionContext) bool {
+	return exec != nil && exec.Flags != nil && exec.Flags.GetBool(FlagDryRun)
+}
+
+// checkDryRun refuses --dry-run for commands that do not declare it, so
+// the flag never runs a command that would make its changes anyway
+func checkDryRun(meta *Metadata, exec *ExecutionContext) error {
+	if IsDryRun(exec) && !meta.SupportsDryRun() {
+		return fmt.Errorf("%w: %s does not support --%s", ErrDryRunUnsupported, meta.Name, FlagDryRun)
+	}
+	return nil
+}
+
+// ReportDryRun writes the changes a dry run found, one "Would ..." line
+// each, in the command's output format, and keeps them in exec.Data
+func ReportDryRun(exec *ExecutionContext, changes ...string) error {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+	exec.Data[DataKeyDryRun] = changes
+
+	var text strings.Builder
+	for _, change := range changes {
+		text.WriteString("Would " + change + "\n")
+	}
+	if len(changes) == 0 {
+		text.WriteString("Nothing would change\n")
+	}
+	text.WriteString("Dry run: nothing was changed")
+
+	return FormatterFor(exec).Write(exec.Stdout, Result{
+		Text:  text.String(),
+		Value: map[string]interface{}{"dry_run": true, "changes": changes},
+	})
+}
@@ -0,0 +1,72 @@
+// ABOUTME: Tests for the --dry-run convention
+// ABOUTME: Verifies the executor rejects it for commands that do not declare it and how dry runs are reported
+
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_DryRun(t *testing.T) {
+	registry := command.NewRegistry()
+	deleted := 0
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "prune", Flags: []command.Flag{command.DryRunFlag}},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			if command.IsDryRun(exec) {
+				return command.ReportDryRun(exec, "delete 3 sessions")
+			}
+			deleted++
+			return nil
+		},
+	)))
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "wipe"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			deleted++
+			return nil
+		},
+	)))
+
+	var out bytes.Buffer
+	executor := command.NewExecutor(registry, command.WithDefaultStreams(nil, &out, &out))
+	require.NoError(t, executor.ParseAndExecute(context.Background(), []string{"prune", "--dry-run"}))
+	assert.Equal(t, "Would delete 3 sessions\nDry run: nothing was changed\n", out.String())
+	assert.Zero(t, deleted)
+
+	err := executor.ParseAndExecute(context.Background(), []string{"wipe", "--dry-run"})
+	assert.ErrorIs(t, err, command.ErrDryRunUnsupported)
+	assert.Zero(t, deleted)
+
+	require.NoError(t, executor.ParseAndExecute(context.Background(), []string{"prune"}))
+	assert.Equal(t, 1, deleted)
+}
+
+func TestReportDryRun(t *testing.T) {
+	var out bytes.Buffer
+	exec := &command.ExecutionContext{Stdout: &out}
+	require.NoError(t, command.ReportDryRun(exec))
+	assert.Equal(t, "Nothing would change\nDry run: nothing was changed\n", out.String())
+
+	out.Reset()
+	exec = &command.ExecutionContext{
+		Stdout: &out,
+		Data:   map[string]interface{}{command.DataKeyOutputFormat: "json"},
+	}
+	require.NoError(t, command.ReportDryRun(exec, "set model.default: a -> b"))
+	var report struct {
+		DryRun  bool     `json:"dry_run"`
+		Changes []string `json:"changes"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.True(t, report.DryRun)
+	assert.Equal(t, []string{"set model.default: a -> b"}, report.Changes)
+	assert.Equal(t, []string{"set model.default: a -> b"}, exec.Data[command.DataKeyDryRun])
+}
@@ -33,6 +33,20 @@ var (
 	// ErrInvalidCategory indicates an invalid command category
 	ErrInvalidCategory = errors.New("invalid command category")
 
+	// ErrJobNotFound indicates no background job has the given number
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrJobNotRunning indicates a background job has already finished
+	ErrJobNotRunning = errors.New("job not running")
+
+	// ErrNonInteractive indicates a command needs an answer but has no
+	// terminal to prompt on
+	ErrNonInteractive = errors.New("cannot prompt without a terminal")
+
+	// ErrDryRunUnsupported indicates --dry-run was given to a command that
+	// cannot preview its changes
+	ErrDryRunUnsupported = errors.New("dry run not supported")
+
 	// ErrNotAvailableInContext indicates the command is not available in the current context
 	ErrNotAvailableInContext = errors.New("command not available in this context")
 )
@@ -10,6 +10,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lexlapax/magellai/internal/logging"
@@ -48,18 +49,26 @@ func (e *ValidationError) Error() string {
 
 // CommandExecutor handles command execution with validation and error handling
 type CommandExecutor struct {
+	mu            sync.RWMutex
 	registry      *Registry
 	defaultStdin  io.Reader
 	defaultStdout io.Writer
 	defaultStderr io.Writer
 	preExecute    []ExecutorHook
 	postExecute   []ExecutorHook
+	afterExecute  []AfterHook
+	middleware    []Middleware
 	sharedContext *SharedContext
+	flagDefaults  FlagDefaultsFunc
 }
 
 // ExecutorHook is a function that runs before or after command execution
 type ExecutorHook func(ctx context.Context, cmd Interface, exec *ExecutionContext) error
 
+// FlagDefaultsFunc returns the configured default flag values for a
+// command, keyed by flag name
+type FlagDefaultsFunc func(command string) map[string]interface{}
+
 // ExecutorOption configures the executor
 type ExecutorOption func(*CommandExecutor)
 
@@ -114,13 +123,21 @@ func WithSharedContext(shared *SharedContext) ExecutorOption {
 	}
 }
 
+// WithFlagDefaults sets where default flag values come from. Defaults
+// apply to flags the caller did not set.
+func WithFlagDefaults(defaults FlagDefaultsFunc) ExecutorOption {
+	return func(e *CommandExecutor) {
+		e.flagDefaults = defaults
+	}
+}
+
 // Execute runs a command by name with the given context
 func (e *CommandExecutor) Execute(ctx context.Context, name string, exec *ExecutionContext) error {
 	start := time.Now()
 	logging.LogDebug("Executing command", "name", name, "args", exec.Args)
 
 	// Get command from registry
-	cmd, err := e.registry.Get(name)
+	cmd, err := e.resolve(name, exec.Stderr)
 	if err != nil {
 		logging.LogError(err, "Command not found", "name", name)
 		return err
@@ -161,6 +178,14 @@ func (e *CommandExecutor) ExecuteCommand(ctx context.Context, cmd Interface, exe
 	if exec.SharedContext == nil {
 		exec.SharedContext = e.sharedContext
 	}
+	if exec.Flags == nil {
+		exec.Flags = NewFlags(nil)
+	}
+	e.applyFlagDefaults(meta, exec)
+	WarnDeprecated(exec.Stderr, meta.Deprecation())
+	if err := checkDryRun(meta, exec); err != nil {
+		return err
+	}
 
 	// Validate the command
 	logging.LogDebug("Validating command", "name", meta.Name)
@@ -170,8 +195,21 @@ func (e *CommandExecutor) ExecuteCommand(ctx context.Context, cmd Interface, exe
 	}
 	logging.LogDebug("Command validation successful", "name", meta.Name)
 
+	// Do not start a command whose caller already gave up
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrCommandCanceled, err)
+	}
+
+	return e.handler()(ctx, cmd, exec)
+}
+
+// runWithHooks runs a command between its pre-execution hooks and its
+// after and post-execution hooks
+func runWithHooks(ctx context.Context, cmd Interface, exec *ExecutionContext, pre []ExecutorHook, after []AfterHook, post []ExecutorHook) error {
+	meta := cmd.Metadata()
+
 	// Run pre-execution hooks
-	for i, hook := range e.preExecute {
+	for i, hook := range pre {
 		logging.LogDebug("Running pre-execution hook", "hookIndex", i, "command", meta.Name)
 		if err := hook(ctx, cmd, exec); err != nil {
 			logging.LogError(err, "Pre-execution hook failed", "hookIndex", i, "command", meta.Name)
@@ -189,8 +227,14 @@ func (e *CommandExecutor) ExecuteCommand(ctx context.Context, cmd Interface, exe
 		logging.LogDebug("Command execution completed successfully", "name", meta.Name)
 	}
 
+	// Run after hooks, each seeing the error so far
+	for i, hook := range after {
+		logging.LogDebug("Running after hook", "hookIndex", i, "command", meta.Name)
+		err = hook(ctx, cmd, exec, err)
+	}
+
 	// Run post-execution hooks (even if command failed)
-	for i, hook := range e.postExecute {
+	for i, hook := range post {
 		logging.LogDebug("Running post-execution hook", "hookIndex", i, "command", meta.Name)
 		if hookErr := hook(ctx, cmd, exec); hookErr != nil {
 			// If command succeeded but post-hook failed, return hook error
@@ -207,6 +251,72 @@ func (e *CommandExecutor) ExecuteCommand(ctx context.Context, cmd Interface, exe
 	return err
 }
 
+// applyFlagDefaults sets configured defaults for flags that were not
+// provided. Defaults may spell flag names with underscores, as in
+// max_tokens for --max-tokens.
+func (e *CommandExecutor) applyFlagDefaults(meta *Metadata, exec *ExecutionContext) {
+	if e.flagDefaults == nil {
+		return
+	}
+	defaults := e.flagDefaults(meta.Name)
+	if len(defaults) == 0 {
+		return
+	}
+
+	known := make(map[string]Flag, len(meta.Flags))
+	for _, flag := range meta.Flags {
+		known[flag.Name] = flag
+	}
+	for name, value := range defaults {
+		flag, ok := known[strings.ReplaceAll(name, "_", "-")]
+		if !ok {
+			logging.LogWarn("Ignoring default for unknown flag", "command", meta.Name, "flag", name)
+			continue
+		}
+		if exec.Flags.Has(flag.Name) {
+			continue
+		}
+		logging.LogDebug("Applying configured flag default", "command", meta.Name, "flag", flag.Name, "value", value)
+		exec.Flags.Set(flag.Name, defaultFlagValue(value, flag.Type))
+	}
+}
+
+// defaultFlagValue converts a value from a config file to the type of a
+// flag. Values that do not convert are left for validation to report.
+func defaultFlagValue(value interface{}, flagType FlagType) interface{} {
+	switch v := value.(type) {
+	case string:
+		if flagType == FlagTypeStringSlice {
+			return strings.Split(v, ",")
+		}
+		return parseValueWithType(v, flagType)
+	case float64:
+		if flagType == FlagTypeInt && v == float64(int(v)) {
+			return int(v)
+		}
+	case int:
+		if flagType == FlagTypeFloat {
+			return float64(v)
+		}
+	case int64:
+		if flagType == FlagTypeInt {
+			return int(v)
+		}
+		if flagType == FlagTypeFloat {
+			return float64(v)
+		}
+	case []interface{}:
+		if flagType == FlagTypeStringSlice {
+			values := make([]string, 0, len(v))
+			for _, elem := range v {
+				values = append(values, fmt.Sprint(elem))
+			}
+			return values
+		}
+	}
+	return value
+}
+
 // validateCommand validates command and execution context
 func (e *CommandExecutor) validateCommand(cmd Interface, exec *ExecutionContext) error {
 	// Validate the command itself
@@ -324,21 +434,52 @@ func (e *CommandExecutor) ExecuteWithArgs(ctx context.Context, name string, args
 	return e.Execute(ctx, name, exec)
 }
 
-// ParseAndExecute parses command line arguments and executes the command
+// ParseAndExecute parses command line arguments and executes the command.
+// Commands separated by | arguments run as a pipeline (see ExecutePipeline).
+// A final & or a --background argument runs the command line as a job
+// (see ExecuteBackground) and reports the job number.
 func (e *CommandExecutor) ParseAndExecute(ctx context.Context, args []string) error {
 	logging.LogDebug("Parsing and executing command", "argCount", len(args))
 
+	args, background := cutBackground(args)
 	if len(args) == 0 {
 		logging.LogError(ErrMissingArgument, "No command provided")
 		return ErrMissingArgument
 	}
+	if !background {
+		return e.parseAndExecute(ctx, args, nil)
+	}
+
+	// Report unknown commands now rather than as a failed job
+	if _, err := e.registry.Get(args[0]); err != nil {
+		return err
+	}
+	job := e.registry.Jobs().Start(ctx, args[0], args[1:], func(ctx context.Context, out io.Writer) error {
+		return e.parseAndExecute(ctx, args, out)
+	})
+	fmt.Fprintf(e.defaultStdout, "[%d] %s\n", job.ID(), strings.Join(args, " "))
+	return nil
+}
+
+// parseAndExecute runs a parsed command line, writing to stdout when it is
+// set and to the command's default output otherwise
+func (e *CommandExecutor) parseAndExecute(ctx context.Context, args []string, stdout io.Writer) error {
+	for _, arg := range args {
+		if arg == PipelineSeparator {
+			stages, err := SplitPipeline(args)
+			if err != nil {
+				return err
+			}
+			return e.executePipeline(ctx, stages, stdout)
+		}
+	}
 
 	// First argument is the command name
 	cmdName := args[0]
 	logging.LogDebug("Command name extracted", "name", cmdName)
 
 	// Get the command to check its flags
-	cmd, err := e.registry.Get(cmdName)
+	cmd, err := e.resolve(cmdName, stdout)
 	if err != nil {
 		logging.LogError(err, "Failed to get command from registry", "name", cmdName)
 		return err
@@ -359,10 +500,33 @@ func (e *CommandExecutor) ParseAndExecute(ctx context.Context, args []string) er
 		Flags: NewFlags(parsedFlags),
 		Data:  make(map[string]interface{}),
 	}
+	if stdout != nil {
+		exec.Stdin = strings.NewReader("")
+		exec.Stdout = stdout
+		exec.Stderr = stdout
+	}
 
 	return e.ExecuteCommand(ctx, cmd, exec)
 }
 
+// ParseArgs separates positional arguments from the flags meta declares.
+// Flags given by their short name are returned under their full name.
+func ParseArgs(args []string, meta *Metadata) ([]string, map[string]interface{}, error) {
+	positional, flags, err := parseArgsWithMetadata(args, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, flag := range meta.Flags {
+		if value, ok := flags[flag.Short]; ok && flag.Short != "" {
+			delete(flags, flag.Short)
+			if _, ok := flags[flag.Name]; !ok {
+				flags[flag.Name] = value
+			}
+		}
+	}
+	return positional, flags, nil
+}
+
 // parseArgsWithMetadata separates positional arguments from flags with knowledge of expected flags
 func parseArgsWithMetadata(args []string, meta *Metadata) ([]string, map[string]interface{}, error) {
 	positional := make([]string, 0)
@@ -278,6 +278,62 @@ func TestFlagValidation(t *testing.T) {
 	}
 }
 
+// TestFlagDefaults verifies configured defaults apply to flags that were not provided
+func TestFlagDefaults(t *testing.T) {
+	registry := command.NewRegistry()
+	registry.SetExecutorOptions(command.WithFlagDefaults(func(name string) map[string]interface{} {
+		if name != "test" {
+			return nil
+		}
+		return map[string]interface{}{
+			"stream":      true,
+			"max_tokens":  float64(2000),
+			"temperature": 1,
+			"tags":        []interface{}{"a", "b"},
+			"unknown":     "ignored",
+		}
+	}))
+
+	var got *command.Flags
+	cmd := command.NewSimpleCommand(
+		&command.Metadata{
+			Name: "test",
+			Flags: []command.Flag{
+				{Name: "stream", Type: command.FlagTypeBool},
+				{Name: "max-tokens", Type: command.FlagTypeInt},
+				{Name: "temperature", Type: command.FlagTypeFloat},
+				{Name: "tags", Type: command.FlagTypeStringSlice},
+			},
+		},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			got = exec.Flags
+			return nil
+		},
+	)
+	require.NoError(t, registry.Register(cmd))
+
+	t.Run("defaults fill unset flags", func(t *testing.T) {
+		exec := &command.ExecutionContext{Stdout: io.Discard, Stderr: io.Discard}
+		require.NoError(t, registry.GetExecutor().Execute(context.Background(), "test", exec))
+		assert.True(t, got.GetBool("stream"))
+		assert.Equal(t, 2000, got.GetInt("max-tokens"))
+		assert.Equal(t, 1.0, got.GetFloat("temperature"))
+		assert.Equal(t, []string{"a", "b"}, got.GetStringSlice("tags"))
+		assert.False(t, got.Has("unknown"))
+	})
+
+	t.Run("provided flags win", func(t *testing.T) {
+		exec := &command.ExecutionContext{
+			Flags:  command.NewFlags(map[string]interface{}{"max-tokens": 100}),
+			Stdout: io.Discard,
+			Stderr: io.Discard,
+		}
+		require.NoError(t, registry.GetExecutor().Execute(context.Background(), "test", exec))
+		assert.Equal(t, 100, got.GetInt("max-tokens"))
+		assert.True(t, got.GetBool("stream"))
+	})
+}
+
 // TestParseAndExecute verifies argument parsing and execution
 func TestParseAndExecute(t *testing.T) {
 	registry := command.NewRegistry()
@@ -474,3 +530,21 @@ func TestExecuteWithArgs(t *testing.T) {
 	)
 	assert.NoError(t, err)
 }
+
+// TestExecuteCanceledContext verifies commands do not start once their context is done
+func TestExecuteCanceledContext(t *testing.T) {
+	registry := command.NewRegistry()
+	ran := false
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "test"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			ran = true
+			return nil
+		})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := command.NewExecutor(registry).Execute(ctx, "test", &command.ExecutionContext{})
+	assert.ErrorIs(t, err, command.ErrCommandCanceled)
+	assert.False(t, ran)
+}
@@ -0,0 +1,428 @@
+// ABOUTME: Formatter that renders command results in the global output format
+// ABOUTME: Turns text, tables, and structured values into text, JSON, YAML, table, or Markdown
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormats lists the formats a Formatter renders
+var OutputFormats = []OutputFormat{
+	OutputFormatText,
+	OutputFormatJSON,
+	OutputFormatYAML,
+	OutputFormatTable,
+	OutputFormatMarkdown,
+}
+
+// ParseOutputFormat returns the output format named by s. An empty name is
+// the text format.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	if s == "" {
+		return OutputFormatText, nil
+	}
+	for _, format := range OutputFormats {
+		if strings.EqualFold(s, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown output format %q", ErrInvalidFlagValue, s)
+}
+
+// OutputFormatOf returns the global output format passed to a command in
+// exec.Data, or text when none was passed or it is unknown
+func OutputFormatOf(exec *ExecutionContext) OutputFormat {
+	if exec == nil || exec.Data == nil {
+		return OutputFormatText
+	}
+	var name string
+	switch v := exec.Data[DataKeyOutputFormat].(type) {
+	case string:
+		name = v
+	case OutputFormat:
+		name = string(v)
+	}
+	format, err := ParseOutputFormat(name)
+	if err != nil {
+		return OutputFormatText
+	}
+	return format
+}
+
+// Table is a result made of rows under column headings
+type Table struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// NewTable creates an empty table with the given column headings
+func NewTable(columns ...string) *Table {
+	return &Table{Columns: columns}
+}
+
+// AddRow appends a row, formatting each cell with fmt.Sprint
+func (t *Table) AddRow(cells ...interface{}) {
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		row[i] = fmt.Sprint(cell)
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// Records returns the rows as maps keyed by lower-case column name, the
+// shape a table takes in JSON and YAML
+func (t *Table) Records() []map[string]string {
+	records := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		record := make(map[string]string, len(t.Columns))
+		for i, column := range t.Columns {
+			key := strings.ReplaceAll(strings.ToLower(column), " ", "_")
+			if i < len(row) {
+				record[key] = row[i]
+			} else {
+				record[key] = ""
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Result is a command result with a view for people and one for programs
+type Result struct {
+	// Text is the message shown in the text and Markdown formats
+	Text string
+	// Table is the tabular view. It is shown in the table format, and in
+	// the text and Markdown formats when Text is empty.
+	Table *Table
+	// Value is the structured result shown in the JSON and YAML formats. The
+	// table format derives a table from it when Table is nil.
+	Value interface{}
+}
+
+// Formatter renders command results in one output format
+type Formatter struct {
+	format OutputFormat
+}
+
+// NewFormatter creates a formatter for an output format
+func NewFormatter(format OutputFormat) *Formatter {
+	if format == "" {
+		format = OutputFormatText
+	}
+	return &Formatter{format: format}
+}
+
+// FormatterFor creates a formatter for the output format of a command
+func FormatterFor(exec *ExecutionContext) *Formatter {
+	return NewFormatter(OutputFormatOf(exec))
+}
+
+// OutputFormat returns the formatter's output format
+func (f *Formatter) OutputFormat() OutputFormat {
+	return f.format
+}
+
+// Structured reports whether the format is meant for programs, JSON or YAML
+func (f *Formatter) Structured() bool {
+	return f.format == OutputFormatJSON || f.format == OutputFormatYAML
+}
+
+// Format renders v as a string. v is a Result, a *Table, a string, or any
+// value that marshals to JSON.
+func (f *Formatter) Format(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case Result:
+		return f.formatResult(v)
+	case *Result:
+		return f.formatResult(*v)
+	case *Table:
+		return f.formatResult(Result{Table: v})
+	case Table:
+		return f.formatResult(Result{Table: &v})
+	case string:
+		return f.formatResult(Result{Text: v, Value: v})
+	}
+	return f.formatResult(Result{Value: v})
+}
+
+// Write renders v to w, ending with a newline
+func (f *Formatter) Write(w io.Writer, v interface{}) error {
+	text, err := f.Format(v)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	_, err = io.WriteString(w, text)
+	return err
+}
+
+// formatResult renders the view of a result that suits the format
+func (f *Formatter) formatResult(r Result) (string, error) {
+	value := r.Value
+	if value == nil && r.Table != nil {
+		value = r.Table.Records()
+	}
+
+	switch f.format {
+	case OutputFormatJSON:
+		if value == nil {
+			value = r.Text
+		}
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON output: %w", err)
+		}
+		return string(data), nil
+	case OutputFormatYAML:
+		if value == nil {
+			value = r.Text
+		}
+		return formatYAML(value)
+	case OutputFormatTable:
+		table := r.Table
+		if table == nil && r.Value != nil {
+			derived, err := tableOf(r.Value)
+			if err != nil {
+				return "", err
+			}
+			table = derived
+		}
+		if table == nil {
+			return r.Text, nil
+		}
+		return formatTable(table), nil
+	case OutputFormatMarkdown:
+		if r.Text == "" && r.Table != nil {
+			return formatMarkdownTable(r.Table), nil
+		}
+	}
+
+	if r.Text != "" || value == nil {
+		return r.Text, nil
+	}
+	if r.Table != nil {
+		return formatTable(r.Table), nil
+	}
+	if s, ok := value.(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+	return formatYAML(value)
+}
+
+// formatYAML renders v as YAML. v goes through JSON first so its JSON
+// field names and encodings are used.
+func formatYAML(v interface{}) (string, error) {
+	generic, err := toGeneric(v, false)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(generic); err != nil {
+		return "", fmt.Errorf("failed to marshal YAML output: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to marshal YAML output: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// formatTable renders a table with aligned columns
+func formatTable(t *Table) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	if len(t.Columns) > 0 {
+		fmt.Fprintln(w, strings.Join(t.Columns, "\t"))
+	}
+	for _, row := range t.Rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// formatMarkdownTable renders a table as a Markdown table
+func formatMarkdownTable(t *Table) string {
+	escape := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(escape(t.Columns))
+	separators := make([]string, len(t.Columns))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	b.WriteString("|" + strings.Join(separators, "|") + "|\n")
+	for _, row := range t.Rows {
+		b.WriteString(escape(row))
+	}
+	return b.String()
+}
+
+// tableOf derives a table from a structured value. A list of objects has
+// a row per object and a column per field, an object has a row per field,
+// and anything else is a single cell.
+func tableOf(v interface{}) (*Table, error) {
+	generic, err := toGeneric(v, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch g := generic.(type) {
+	case []interface{}:
+		columns := fieldOrder(v)
+		if columns == nil {
+			columns = objectKeys(g)
+		}
+		if len(columns) == 0 {
+			table := NewTable("VALUE")
+			for _, item := range g {
+				table.Rows = append(table.Rows, []string{cellText(item)})
+			}
+			return table, nil
+		}
+		headings := make([]string, len(columns))
+		for i, column := range columns {
+			headings[i] = strings.ToUpper(column)
+		}
+		table := NewTable(headings...)
+		for _, item := range g {
+			object, _ := item.(map[string]interface{})
+			row := make([]string, len(columns))
+			for i, column := range columns {
+				row[i] = cellText(object[column])
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		return table, nil
+	case map[string]interface{}:
+		keys := fieldOrder(v)
+		if keys == nil {
+			keys = sortedKeys(g)
+		}
+		table := NewTable("KEY", "VALUE")
+		for _, key := range keys {
+			if value, ok := g[key]; ok {
+				table.Rows = append(table.Rows, []string{key, cellText(value)})
+			}
+		}
+		return table, nil
+	}
+	return &Table{Columns: []string{"VALUE"}, Rows: [][]string{{cellText(generic)}}}, nil
+}
+
+// toGeneric converts v to maps, slices, and scalars through JSON
+func toGeneric(v interface{}, useNumber bool) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if useNumber {
+		dec.UseNumber()
+	}
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+	return generic, nil
+}
+
+// fieldOrder returns the JSON field names of a struct, or of the elements
+// of a slice of structs, in declaration order. It returns nil for other
+// types.
+func fieldOrder(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// objectKeys returns the keys found in a list of objects, sorted
+func objectKeys(items []interface{}) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range object {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedKeys returns the keys of an object, sorted
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cellText renders one value for a table cell
+func cellText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number, bool:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,119 @@
+// ABOUTME: Tests for the output formatter
+// ABOUTME: Verifies rendering of results, tables, and values in each output format
+
+package command_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type formatItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Skip  string `json:"-"`
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, name := range []string{"text", "json", "yaml", "table", "markdown", "JSON"} {
+		_, err := command.ParseOutputFormat(name)
+		assert.NoError(t, err, name)
+	}
+
+	format, err := command.ParseOutputFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, command.OutputFormatText, format)
+
+	_, err = command.ParseOutputFormat("xml")
+	assert.ErrorIs(t, err, command.ErrInvalidFlagValue)
+}
+
+func TestOutputFormatOf(t *testing.T) {
+	assert.Equal(t, command.OutputFormatText, command.OutputFormatOf(nil))
+	assert.Equal(t, command.OutputFormatText, command.OutputFormatOf(&command.ExecutionContext{}))
+
+	exec := &command.ExecutionContext{Data: map[string]interface{}{command.DataKeyOutputFormat: "yaml"}}
+	assert.Equal(t, command.OutputFormatYAML, command.OutputFormatOf(exec))
+
+	exec.Data[command.DataKeyOutputFormat] = "bogus"
+	assert.Equal(t, command.OutputFormatText, command.OutputFormatOf(exec))
+}
+
+func TestFormatterTable(t *testing.T) {
+	table := command.NewTable("ID", "NAME")
+	table.AddRow(1, "first")
+	table.AddRow(22, "a|b")
+
+	tests := []struct {
+		format   command.OutputFormat
+		expected string
+	}{
+		{command.OutputFormatText, "ID  NAME\n1   first\n22  a|b\n"},
+		{command.OutputFormatTable, "ID  NAME\n1   first\n22  a|b\n"},
+		{command.OutputFormatMarkdown, "| ID | NAME |\n|---|---|\n| 1 | first |\n| 22 | a\\|b |\n"},
+		{command.OutputFormatJSON, "[\n  {\n    \"id\": \"1\",\n    \"name\": \"first\"\n  },\n  {\n    \"id\": \"22\",\n    \"name\": \"a|b\"\n  }\n]"},
+		{command.OutputFormatYAML, "- id: \"1\"\n  name: first\n- id: \"22\"\n  name: a|b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			out, err := command.NewFormatter(tt.format).Format(table)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+		})
+	}
+}
+
+func TestFormatterResult(t *testing.T) {
+	result := command.Result{
+		Text:  "2 items",
+		Value: []formatItem{{Name: "a", Count: 1}, {Name: "b", Count: 2}},
+	}
+
+	tests := []struct {
+		format   command.OutputFormat
+		expected string
+	}{
+		{command.OutputFormatText, "2 items"},
+		{command.OutputFormatMarkdown, "2 items"},
+		{command.OutputFormatTable, "NAME  COUNT\na     1\nb     2\n"},
+		{command.OutputFormatJSON, "[\n  {\n    \"name\": \"a\",\n    \"count\": 1\n  },\n  {\n    \"name\": \"b\",\n    \"count\": 2\n  }\n]"},
+		{command.OutputFormatYAML, "- count: 1\n  name: a\n- count: 2\n  name: b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			out, err := command.NewFormatter(tt.format).Format(result)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+		})
+	}
+}
+
+func TestFormatterValue(t *testing.T) {
+	value := map[string]interface{}{"model": "openai/gpt-4o", "tags": []string{"a", "b"}}
+
+	out, err := command.NewFormatter(command.OutputFormatTable).Format(value)
+	require.NoError(t, err)
+	assert.Equal(t, "KEY    VALUE\nmodel  openai/gpt-4o\ntags   [\"a\",\"b\"]\n", out)
+
+	out, err = command.NewFormatter(command.OutputFormatText).Format(value)
+	require.NoError(t, err)
+	assert.Equal(t, "model: openai/gpt-4o\ntags:\n  - a\n  - b\n", out)
+
+	out, err = command.NewFormatter(command.OutputFormatJSON).Format("done")
+	require.NoError(t, err)
+	assert.Equal(t, `"done"`, out)
+}
+
+func TestFormatterWrite(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, command.NewFormatter(command.OutputFormatText).Write(&buf, "done"))
+	assert.Equal(t, "done\n", buf.String())
+
+	f := command.FormatterFor(&command.ExecutionContext{Data: map[string]interface{}{"outputFormat": "json"}})
+	assert.Equal(t, command.OutputFormatJSON, f.OutputFormat())
+	assert.True(t, f.Structured())
+}
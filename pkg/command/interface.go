@@ -69,8 +69,14 @@ type Metadata struct {
 	// Hidden indicates if the command should be hidden from help
 	Hidden bool
 
-	// Deprecated marks the command as deprecated
+	// Deprecated marks the command as deprecated, with a message for users
 	Deprecated string
+
+	// ReplacedBy names the command that replaces a deprecated command
+	ReplacedBy string
+
+	// RemovalVersion is the release that removes a deprecated command
+	RemovalVersion string
 }
 
 // Category defines command availability across interfaces
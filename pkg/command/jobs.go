@@ -0,0 +1,264 @@
+// ABOUTME: Background jobs for long-running commands
+// ABOUTME: Runs commands asynchronously with captured output, status, and cancellation
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// BackgroundSuffix is the final argument that runs a command as a job
+const BackgroundSuffix = "&"
+
+// BackgroundFlag runs a command as a job
+const BackgroundFlag = "--background"
+
+// JobState is the state of a background job
+type JobState string
+
+const (
+	// JobRunning is a job that has not finished
+	JobRunning JobState = "running"
+	// JobDone is a job whose command succeeded
+	JobDone JobState = "done"
+	// JobFailed is a job whose command returned an error
+	JobFailed JobState = "failed"
+	// JobCanceled is a job that was canceled
+	JobCanceled JobState = "canceled"
+)
+
+// JobRunner runs the command of a job, writing its output to out
+type JobRunner func(ctx context.Context, out io.Writer) error
+
+// Job is a command running in the background
+type Job struct {
+	id      int
+	command string
+	args    []string
+	started time.Time
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu       sync.Mutex
+	state    JobState
+	finished time.Time
+	err      error
+	output   bytes.Buffer
+}
+
+// JobStatus is a snapshot of a job
+type JobStatus struct {
+	ID       int           `json:"id"`
+	Command  string        `json:"command"`
+	Args     []string      `json:"args,omitempty"`
+	State    JobState      `json:"state"`
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// CommandLine returns the job's command with its arguments
+func (s JobStatus) CommandLine() string {
+	return strings.TrimSpace(s.Command + " " + strings.Join(s.Args, " "))
+}
+
+// ID returns the job number
+func (j *Job) ID() int {
+	return j.id
+}
+
+// Status returns the job's current state
+func (j *Job) Status() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := JobStatus{
+		ID:       j.id,
+		Command:  j.command,
+		Args:     j.args,
+		State:    j.state,
+		Started:  j.started,
+		Finished: j.finished,
+	}
+	if j.state == JobRunning {
+		status.Duration = time.Since(j.started)
+	} else {
+		status.Duration = j.finished.Sub(j.started)
+	}
+	if j.err != nil {
+		status.Error = j.err.Error()
+	}
+	return status
+}
+
+// Output returns what the job has written so far
+func (j *Job) Output() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.output.String()
+}
+
+// Done returns a channel closed when the job finishes
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait blocks until the job finishes or ctx is done, and returns the
+// job's error
+func (j *Job) Wait(ctx context.Context) error {
+	select {
+	case <-j.done:
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		return j.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Write appends command output to the job, safe for concurrent use
+func (j *Job) Write(p []byte) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.output.Write(p)
+}
+
+// finish records the result of the job's command
+func (j *Job) finish(ctx context.Context, err error) {
+	j.mu.Lock()
+	j.finished = time.Now()
+	j.err = err
+	switch {
+	case ctx.Err() != nil && (err == nil || errors.Is(err, context.Canceled)):
+		j.state = JobCanceled
+		j.err = ErrCommandCanceled
+	case err != nil:
+		j.state = JobFailed
+	default:
+		j.state = JobDone
+	}
+	state := j.state
+	j.mu.Unlock()
+
+	j.cancel()
+	close(j.done)
+	logging.LogInfo("Background job finished", "id", j.id, "command", j.command, "state", state)
+}
+
+// JobManager keeps track of background jobs
+type JobManager struct {
+	mu   sync.Mutex
+	jobs map[int]*Job
+	next int
+}
+
+// NewJobManager creates an empty job manager
+func NewJobManager() *JobManager {
+	return &JobManager{jobs: make(map[int]*Job)}
+}
+
+// Start runs a command as a job. The job keeps ctx's values but not its
+// cancellation, so it outlives the caller until it finishes or is canceled.
+func (m *JobManager) Start(ctx context.Context, name string, args []string, run JobRunner) *Job {
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	m.mu.Lock()
+	m.next++
+	job := &Job{
+		id:      m.next,
+		command: name,
+		args:    append([]string(nil), args...),
+		started: time.Now(),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		state:   JobRunning,
+	}
+	m.jobs[job.id] = job
+	m.mu.Unlock()
+
+	logging.LogInfo("Starting background job", "id", job.id, "command", name, "args", args)
+	go func() {
+		err := run(jobCtx, job)
+		job.finish(jobCtx, err)
+	}()
+	return job
+}
+
+// Get returns a job by number
+func (m *JobManager) Get(id int) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrJobNotFound, id)
+	}
+	return job, nil
+}
+
+// List returns every job, oldest first
+func (m *JobManager) List() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].id < jobs[j].id })
+	return jobs
+}
+
+// Cancel stops a running job
+func (m *JobManager) Cancel(id int) error {
+	job, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	if state := job.Status().State; state != JobRunning {
+		return fmt.Errorf("%w: job %d is %s", ErrJobNotRunning, id, state)
+	}
+	logging.LogInfo("Canceling background job", "id", id)
+	job.cancel()
+	return nil
+}
+
+// ExecuteBackground starts a command as a job and returns without waiting
+// for it. The command's output goes to the job and its stdin is empty.
+func (e *CommandExecutor) ExecuteBackground(ctx context.Context, name string, exec *ExecutionContext) (*Job, error) {
+	cmd, err := e.registry.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	job := e.registry.Jobs().Start(ctx, cmd.Metadata().Name, exec.Args, func(ctx context.Context, out io.Writer) error {
+		exec.Stdin = strings.NewReader("")
+		exec.Stdout = out
+		exec.Stderr = out
+		exec.Context = ctx
+		return e.ExecuteCommand(ctx, cmd, exec)
+	})
+	return job, nil
+}
+
+// cutBackground removes a trailing & or a --background argument and
+// reports whether either was present
+func cutBackground(args []string) ([]string, bool) {
+	if n := len(args); n > 0 && args[n-1] == BackgroundSuffix {
+		return args[:n-1], true
+	}
+	for i, arg := range args {
+		if arg == BackgroundFlag {
+			return append(append([]string(nil), args[:i]...), args[i+1:]...), true
+		}
+	}
+	return args, false
+}
@@ -0,0 +1,98 @@
+// ABOUTME: Tests for background jobs
+// ABOUTME: Verifies starting jobs with & and --background, their output, states, and cancellation
+
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJobRegistry(t *testing.T) *command.Registry {
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "echo"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			_, err := fmt.Fprintln(exec.Stdout, exec.Args)
+			return err
+		})))
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "block"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})))
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "fail"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			return errors.New("boom")
+		})))
+	return registry
+}
+
+func waitJob(t *testing.T, job *command.Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := job.Wait(ctx)
+	require.NotErrorIs(t, err, context.DeadlineExceeded)
+	return err
+}
+
+func TestParseAndExecuteBackground(t *testing.T) {
+	registry := newJobRegistry(t)
+	var output bytes.Buffer
+	executor := command.NewExecutor(registry, command.WithDefaultStreams(nil, &output, io.Discard))
+
+	require.NoError(t, executor.ParseAndExecute(context.Background(), []string{"echo", "hello", "&"}))
+	assert.Equal(t, "[1] echo hello\n", output.String())
+
+	job, err := registry.Jobs().Get(1)
+	require.NoError(t, err)
+	require.NoError(t, waitJob(t, job))
+	assert.Equal(t, command.JobDone, job.Status().State)
+	assert.Equal(t, "[hello]\n", job.Output(), "job output is captured")
+	assert.Equal(t, "[1] echo hello\n", output.String())
+
+	require.NoError(t, executor.ParseAndExecute(context.Background(), []string{"fail", "--background"}))
+	job, err = registry.Jobs().Get(2)
+	require.NoError(t, err)
+	assert.EqualError(t, waitJob(t, job), "boom")
+	assert.Equal(t, command.JobFailed, job.Status().State)
+	assert.Equal(t, "boom", job.Status().Error)
+
+	err = executor.ParseAndExecute(context.Background(), []string{"missing", "&"})
+	assert.ErrorIs(t, err, command.ErrCommandNotFound)
+	assert.Len(t, registry.Jobs().List(), 2)
+}
+
+func TestCancelJob(t *testing.T) {
+	registry := newJobRegistry(t)
+	executor := command.NewExecutor(registry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job, err := executor.ExecuteBackground(ctx, "block", &command.ExecutionContext{})
+	require.NoError(t, err)
+	cancel()
+	select {
+	case <-job.Done():
+		t.Fatal("a job outlives the context that started it")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, command.JobRunning, job.Status().State)
+
+	require.NoError(t, registry.Jobs().Cancel(job.ID()))
+	assert.ErrorIs(t, waitJob(t, job), command.ErrCommandCanceled)
+	assert.Equal(t, command.JobCanceled, job.Status().State)
+
+	assert.ErrorIs(t, registry.Jobs().Cancel(job.ID()), command.ErrJobNotRunning)
+	assert.ErrorIs(t, registry.Jobs().Cancel(99), command.ErrJobNotFound)
+}
@@ -0,0 +1,362 @@
+// ABOUTME: Commands declared by manifest files in the user's commands directory
+// ABOUTME: Loads YAML or JSON manifests into commands with flags and help that run a command line or a program
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// ManifestExtensions are the file extensions read as command manifests
+var ManifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// Manifest declares a command in a YAML or JSON file:
+//
+//	name: review
+//	description: Review a diff
+//	aliases: [rv]
+//	category: shared            # shared (default), cli, or repl
+//	flags:
+//	  - name: model
+//	    short: m
+//	    type: string            # string, int, bool, float, duration, or string-slice
+//	    default: openai/gpt-4o
+//	    description: Model to review with
+//	run: "ask --model ${model} 'Review this diff: $1'"
+//
+// A command either runs a magellai command line, expanded like an alias
+// (see ExpandAlias) with the command's arguments and flags, or execs a
+// program. The run line is in CLI syntax; repl overrides it in the REPL,
+// where lines start with /, :, or !. An exec program, relative to the
+// manifest's directory, gets the arguments followed by the flags that are
+// set as --name=value, and the command's stdin outside the REPL.
+type Manifest struct {
+	Name            string         `json:"name" yaml:"name"`
+	Description     string         `json:"description" yaml:"description"`
+	LongDescription string         `json:"long_description,omitempty" yaml:"long_description"`
+	Aliases         []string       `json:"aliases,omitempty" yaml:"aliases"`
+	Category        string         `json:"category,omitempty" yaml:"category"`
+	Flags           []ManifestFlag `json:"flags,omitempty" yaml:"flags"`
+	Run             string         `json:"run,omitempty" yaml:"run"`
+	REPL            string         `json:"repl,omitempty" yaml:"repl"`
+	Exec            string         `json:"exec,omitempty" yaml:"exec"`
+
+	// Path is the manifest file the command was loaded from
+	Path string `json:"-" yaml:"-"`
+}
+
+// ManifestFlag declares a flag of a manifest command
+type ManifestFlag struct {
+	Name        string      `json:"name" yaml:"name"`
+	Short       string      `json:"short,omitempty" yaml:"short"`
+	Type        string      `json:"type,omitempty" yaml:"type"`
+	Description string      `json:"description,omitempty" yaml:"description"`
+	Default     interface{} `json:"default,omitempty" yaml:"default"`
+	Required    bool        `json:"required,omitempty" yaml:"required"`
+}
+
+// manifestFlagTypes maps manifest flag type names to flag types
+var manifestFlagTypes = map[string]FlagType{
+	"":             FlagTypeString,
+	"string":       FlagTypeString,
+	"int":          FlagTypeInt,
+	"bool":         FlagTypeBool,
+	"float":        FlagTypeFloat,
+	"duration":     FlagTypeDuration,
+	"string-slice": FlagTypeStringSlice,
+}
+
+// manifestCategories maps manifest category names to categories
+var manifestCategories = map[string]Category{
+	"":       CategoryShared,
+	"shared": CategoryShared,
+	"cli":    CategoryCLI,
+	"repl":   CategoryREPL,
+}
+
+// LoadManifests reads the command manifests in dir, sorted by command name.
+// A missing directory has no manifests. Manifests that cannot be read or
+// are invalid are skipped and returned as errors, and when several declare
+// the same name only the first file, by name, is used.
+func LoadManifests(dir string) ([]Manifest, []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{fmt.Errorf("failed to read commands directory: %w", err)}
+	}
+
+	var manifests []Manifest
+	var errs []error
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !isManifestFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		m, err := LoadManifest(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if first, ok := seen[m.Name]; ok {
+			errs = append(errs, fmt.Errorf("%w: %s: command %q is already declared in %s", ErrInvalidCommand, path, m.Name, first))
+			continue
+		}
+		seen[m.Name] = path
+		manifests = append(manifests, m)
+		logging.LogDebug("Loaded command manifest", "name", m.Name, "path", path)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Name < manifests[j].Name })
+	return manifests, errs
+}
+
+// LoadManifest reads and validates one command manifest
+func LoadManifest(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to read command manifest: %w", err)
+	}
+
+	var m Manifest
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &m)
+	} else {
+		err = yaml.Unmarshal(data, &m)
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%w: %s: %v", ErrInvalidCommand, path, err)
+	}
+	m.Path = path
+	if m.Exec != "" && !filepath.IsAbs(m.Exec) {
+		m.Exec = filepath.Join(filepath.Dir(path), m.Exec)
+	}
+	if err := m.Validate(); err != nil {
+		return Manifest{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Validate checks that a manifest declares a usable command
+func (m Manifest) Validate() error {
+	if m.Name == "" || strings.ContainsAny(m.Name, " \t/") {
+		return fmt.Errorf("%w: invalid command name %q", ErrInvalidCommand, m.Name)
+	}
+	if (m.Run == "") == (m.Exec == "") {
+		return fmt.Errorf("%w: %s: exactly one of run and exec is required", ErrInvalidCommand, m.Name)
+	}
+	if m.REPL != "" && m.Run == "" {
+		return fmt.Errorf("%w: %s: repl requires run", ErrInvalidCommand, m.Name)
+	}
+	if _, ok := manifestCategories[strings.ToLower(m.Category)]; !ok {
+		return fmt.Errorf("%w: %s: %q", ErrInvalidCategory, m.Name, m.Category)
+	}
+	for _, f := range m.Flags {
+		if f.Name == "" {
+			return fmt.Errorf("%w: %s: flag without a name", ErrInvalidCommand, m.Name)
+		}
+		if len(f.Short) > 1 {
+			return fmt.Errorf("%w: %s: short name of --%s must be one character", ErrInvalidCommand, m.Name, f.Name)
+		}
+		if _, ok := manifestFlagTypes[strings.ToLower(f.Type)]; !ok {
+			return fmt.Errorf("%w: %s: unknown type %q of --%s", ErrInvalidCommand, m.Name, f.Type, f.Name)
+		}
+	}
+	return nil
+}
+
+// Metadata returns the metadata of the command a manifest declares
+func (m Manifest) Metadata() *Metadata {
+	meta := &Metadata{
+		Name:            m.Name,
+		Aliases:         m.Aliases,
+		Description:     m.Description,
+		LongDescription: m.LongDescription,
+		Category:        manifestCategories[strings.ToLower(m.Category)],
+	}
+	if meta.Description == "" {
+		meta.Description = fmt.Sprintf("User command (%s)", m.Path)
+	}
+	if meta.LongDescription == "" {
+		meta.LongDescription = fmt.Sprintf("%s\n\nDeclared in %s.", meta.Description, m.Path)
+	}
+	for _, f := range m.Flags {
+		meta.Flags = append(meta.Flags, Flag{
+			Name:        f.Name,
+			Short:       f.Short,
+			Description: f.Description,
+			Type:        manifestFlagTypes[strings.ToLower(f.Type)],
+			Default:     f.Default,
+			Required:    f.Required,
+			Multiple:    strings.EqualFold(f.Type, "string-slice"),
+		})
+	}
+	return meta
+}
+
+// LineRunner runs a command line that a manifest command expanded to
+type LineRunner func(ctx context.Context, exec *ExecutionContext, words []string) error
+
+// ManifestCommand is a command declared by a manifest
+type ManifestCommand struct {
+	manifest Manifest
+	repl     bool
+	run      LineRunner
+}
+
+// Ensure ManifestCommand implements Interface
+var _ Interface = (*ManifestCommand)(nil)
+
+// NewManifestCommand creates the command a manifest declares. run runs its
+// expanded command line, in the REPL's syntax when repl is set.
+func NewManifestCommand(m Manifest, repl bool, run LineRunner) *ManifestCommand {
+	return &ManifestCommand{manifest: m, repl: repl, run: run}
+}
+
+// Manifest returns the manifest that declares the command
+func (c *ManifestCommand) Manifest() Manifest {
+	return c.manifest
+}
+
+// Metadata returns the command metadata
+func (c *ManifestCommand) Metadata() *Metadata {
+	return c.manifest.Metadata()
+}
+
+// Validate checks if the command configuration is valid
+func (c *ManifestCommand) Validate() error {
+	if err := c.manifest.Validate(); err != nil {
+		return err
+	}
+	if c.manifest.Run != "" && c.run == nil {
+		return fmt.Errorf("%w: %s: no runner for command lines", ErrInvalidCommand, c.manifest.Name)
+	}
+	return nil
+}
+
+// Execute runs the manifest's command line or program
+func (c *ManifestCommand) Execute(ctx context.Context, exec *ExecutionContext) error {
+	// The REPL passes flags along with the arguments
+	if exec.Flags == nil {
+		args, flags, err := ParseArgs(exec.Args, c.Metadata())
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.manifest.Name, err)
+		}
+		exec.Args, exec.Flags = args, NewFlags(flags)
+	}
+
+	if c.manifest.Exec != "" {
+		args := append(append([]string{}, exec.Args...), c.flagArgs(exec, nil)...)
+		return c.execProgram(ctx, exec, args)
+	}
+
+	line := c.manifest.Run
+	if c.repl && c.manifest.REPL != "" {
+		line = c.manifest.REPL
+	}
+	// Flags are parameters of the line, so only referenced ones are passed
+	args := append(append([]string{}, exec.Args...), c.flagArgs(exec, referencedFlags(line))...)
+	words, err := ExpandAlias(line, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.manifest.Name, err)
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("%s: %w - empty command line", c.manifest.Name, ErrInvalidCommand)
+	}
+	logging.LogDebug("Running manifest command", "name", c.manifest.Name, "line", words)
+	return c.run(ctx, exec, words)
+}
+
+// flagArgs returns the declared flags that are set or have a default as
+// --name=value arguments, limited to names when it is not nil
+func (c *ManifestCommand) flagArgs(exec *ExecutionContext, names map[string]bool) []string {
+	var args []string
+	for _, f := range c.manifest.Flags {
+		if names != nil && !names[f.Name] {
+			continue
+		}
+		v := f.Default
+		if exec.Flags.Has(f.Name) {
+			v = exec.Flags.Get(f.Name)
+		} else if f.Short != "" && exec.Flags.Has(f.Short) {
+			v = exec.Flags.Get(f.Short)
+		}
+		if v == nil {
+			continue
+		}
+
+		var value string
+		switch v := v.(type) {
+		case []string:
+			value = strings.Join(v, ",")
+		case []interface{}:
+			values := make([]string, len(v))
+			for i, elem := range v {
+				values[i] = fmt.Sprint(elem)
+			}
+			value = strings.Join(values, ",")
+		case time.Duration:
+			value = v.String()
+		default:
+			value = fmt.Sprint(v)
+		}
+		args = append(args, "--"+f.Name+"="+value)
+	}
+	return args
+}
+
+// execProgram runs the manifest's program with args
+func (c *ManifestCommand) execProgram(ctx context.Context, execCtx *ExecutionContext, args []string) error {
+	cmd := exec.CommandContext(ctx, c.manifest.Exec, args...)
+	cmd.WaitDelay = time.Second
+	// The REPL's own input is not the program's, unless piped from a command
+	if !c.repl || GetPipelineInput(execCtx) != nil {
+		cmd.Stdin = execCtx.Stdin
+	}
+	cmd.Stdout = execCtx.Stdout
+	cmd.Stderr = execCtx.Stderr
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	cmd.Env = append(os.Environ(),
+		"MAGELLAI_COMMAND="+c.manifest.Name,
+		"MAGELLAI_OUTPUT_FORMAT="+string(OutputFormatOf(execCtx)),
+	)
+
+	logging.LogDebug("Running manifest program", "name", c.manifest.Name, "path", c.manifest.Exec, "args", args)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", c.manifest.Name, err)
+	}
+	return nil
+}
+
+// isManifestFile reports whether a file name has a manifest extension
+func isManifestFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range ManifestExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
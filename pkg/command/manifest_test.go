@@ -0,0 +1,172 @@
+// ABOUTME: Tests for commands declared by manifest files
+// ABOUTME: Covers loading and validating manifests and running their command lines and programs
+
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func TestLoadManifests(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "review.yaml", `
+name: review
+description: Review a diff
+aliases: [rv]
+category: cli
+flags:
+  - name: model
+    short: m
+    default: openai/gpt-4o
+  - name: tags
+    type: string-slice
+run: "ask --model ${model} 'Review: $1'"
+`)
+	writeManifest(t, dir, "count.json", `{"name": "count", "exec": "count.sh", "flags": [{"name": "n", "type": "int"}]}`)
+	writeManifest(t, dir, "broken.yml", "name: broken\n")
+	writeManifest(t, dir, "twice.yaml", "name: review\nrun: version\n")
+	writeManifest(t, dir, "notes.txt", "name: notes\nrun: version\n")
+
+	manifests, errs := command.LoadManifests(dir)
+	require.Len(t, manifests, 2)
+	assert.Len(t, errs, 2)
+	for _, err := range errs {
+		assert.ErrorIs(t, err, command.ErrInvalidCommand)
+	}
+
+	count, review := manifests[0], manifests[1]
+	assert.Equal(t, filepath.Join(dir, "count.sh"), count.Exec)
+
+	meta := review.Metadata()
+	assert.Equal(t, "review", meta.Name)
+	assert.Equal(t, []string{"rv"}, meta.Aliases)
+	assert.Equal(t, command.CategoryCLI, meta.Category)
+	require.Len(t, meta.Flags, 2)
+	assert.Equal(t, "m", meta.Flags[0].Short)
+	assert.Equal(t, "openai/gpt-4o", meta.Flags[0].Default)
+	assert.Equal(t, command.FlagTypeStringSlice, meta.Flags[1].Type)
+	assert.Contains(t, meta.LongDescription, filepath.Join(dir, "review.yaml"))
+
+	manifests, errs = command.LoadManifests(filepath.Join(dir, "missing"))
+	assert.Empty(t, manifests)
+	assert.Empty(t, errs)
+}
+
+func TestManifestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest command.Manifest
+		wantErr  error
+	}{
+		{"valid", command.Manifest{Name: "x", Run: "version"}, nil},
+		{"no name", command.Manifest{Run: "version"}, command.ErrInvalidCommand},
+		{"space in name", command.Manifest{Name: "a b", Run: "version"}, command.ErrInvalidCommand},
+		{"run and exec", command.Manifest{Name: "x", Run: "version", Exec: "x.sh"}, command.ErrInvalidCommand},
+		{"repl without run", command.Manifest{Name: "x", REPL: "/model", Exec: "x.sh"}, command.ErrInvalidCommand},
+		{"bad category", command.Manifest{Name: "x", Run: "version", Category: "api"}, command.ErrInvalidCategory},
+		{"bad flag type", command.Manifest{Name: "x", Run: "version", Flags: []command.ManifestFlag{{Name: "n", Type: "list"}}}, command.ErrInvalidCommand},
+		{"long short name", command.Manifest{Name: "x", Run: "version", Flags: []command.ManifestFlag{{Name: "n", Short: "nn"}}}, command.ErrInvalidCommand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.manifest.Validate()
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManifestCommand_Run(t *testing.T) {
+	m := command.Manifest{
+		Name: "review",
+		Flags: []command.ManifestFlag{
+			{Name: "model", Short: "m", Default: "openai/gpt-4o"},
+			{Name: "loud", Type: "bool"},
+		},
+		Run:  "ask --model ${model} 'Review: $1'",
+		REPL: "/ask Review: $1",
+	}
+
+	var got []string
+	run := func(ctx context.Context, exec *command.ExecutionContext, words []string) error {
+		got = words
+		return nil
+	}
+
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(command.NewManifestCommand(m, false, run)))
+	executor := registry.GetExecutor()
+
+	require.NoError(t, executor.ParseAndExecute(context.Background(), []string{"review", "a.diff", "--loud"}))
+	assert.Equal(t, []string{"ask", "--model", "openai/gpt-4o", "Review: a.diff"}, got)
+
+	args, flags, err := command.ParseArgs([]string{"b.diff", "-m", "anthropic/claude"}, m.Metadata())
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(context.Background(), "review", &command.ExecutionContext{Args: args, Flags: command.NewFlags(flags)}))
+	assert.Equal(t, []string{"ask", "--model", "anthropic/claude", "Review: b.diff"}, got)
+
+	// The REPL passes flags with the arguments and uses the repl line
+	cmd := command.NewManifestCommand(m, true, run)
+	require.NoError(t, cmd.Execute(context.Background(), &command.ExecutionContext{Args: []string{"c.diff", "--model", "x"}}))
+	assert.Equal(t, []string{"/ask", "Review:", "c.diff"}, got)
+}
+
+func TestManifestCommand_Exec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+
+	dir := t.TempDir()
+	writeManifest(t, dir, "args.sh", "#!/bin/sh\necho \"$MAGELLAI_COMMAND: $*\"\ncat\n")
+	require.NoError(t, os.Chmod(filepath.Join(dir, "args.sh"), 0755))
+	writeManifest(t, dir, "args.yaml", `
+name: args
+exec: args.sh
+flags:
+  - name: times
+    type: int
+    default: 2
+  - name: label
+`)
+
+	m, err := command.LoadManifest(filepath.Join(dir, "args.yaml"))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	exec := &command.ExecutionContext{
+		Args:   []string{"one", "two"},
+		Flags:  command.NewFlags(map[string]interface{}{"label": "x"}),
+		Stdin:  bytes.NewBufferString("input\n"),
+		Stdout: &out,
+	}
+	require.NoError(t, command.NewManifestCommand(m, false, nil).Execute(context.Background(), exec))
+	assert.Equal(t, "args: one two --times=2 --label=x\ninput\n", out.String())
+}
+
+func TestParseArgs_ShortFlags(t *testing.T) {
+	meta := &command.Metadata{Flags: []command.Flag{
+		{Name: "model", Short: "m", Type: command.FlagTypeString},
+		{Name: "verbose", Short: "v", Type: command.FlagTypeBool},
+	}}
+	args, flags, err := command.ParseArgs([]string{"-m", "gpt", "file", "-v"}, meta)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file"}, args)
+	assert.Equal(t, map[string]interface{}{"model": "gpt", "verbose": true}, flags)
+}
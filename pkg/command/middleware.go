@@ -0,0 +1,78 @@
+// ABOUTME: Middleware and hook registration for the command executor
+// ABOUTME: Lets embedders and plugins run code before, after, or around every command
+
+package command
+
+import (
+	"context"
+)
+
+// Handler runs a command with its execution context
+type Handler func(ctx context.Context, cmd Interface, exec *ExecutionContext) error
+
+// Middleware wraps the handler that runs a command. It can act before and
+// after calling next, change the context or result, or not call next at
+// all to stop the command.
+type Middleware func(next Handler) Handler
+
+// AfterHook runs after a command with the error it returned, which is nil
+// on success. The hook's result replaces the command's error, so a hook
+// returns err unchanged to keep it.
+type AfterHook func(ctx context.Context, cmd Interface, exec *ExecutionContext, err error) error
+
+// WithMiddleware adds middleware. The first middleware added is the
+// outermost.
+func WithMiddleware(mw Middleware) ExecutorOption {
+	return func(e *CommandExecutor) {
+		e.middleware = append(e.middleware, mw)
+	}
+}
+
+// WithAfterHook adds a hook that runs after each command with its error
+func WithAfterHook(hook AfterHook) ExecutorOption {
+	return func(e *CommandExecutor) {
+		e.afterExecute = append(e.afterExecute, hook)
+	}
+}
+
+// Before adds a hook that runs after validation and before each command.
+// A hook error stops the command.
+func (e *CommandExecutor) Before(hook ExecutorHook) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.preExecute = append(e.preExecute, hook)
+}
+
+// After adds a hook that runs after each command with its error
+func (e *CommandExecutor) After(hook AfterHook) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.afterExecute = append(e.afterExecute, hook)
+}
+
+// Use adds middleware around each command, including its before and after
+// hooks. Middleware added first is the outermost.
+func (e *CommandExecutor) Use(mw Middleware) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.middleware = append(e.middleware, mw)
+}
+
+// handler returns the handler that runs a command through its hooks,
+// wrapped in the registered middleware
+func (e *CommandExecutor) handler() Handler {
+	e.mu.RLock()
+	pre := append([]ExecutorHook(nil), e.preExecute...)
+	after := append([]AfterHook(nil), e.afterExecute...)
+	post := append([]ExecutorHook(nil), e.postExecute...)
+	middleware := append([]Middleware(nil), e.middleware...)
+	e.mu.RUnlock()
+
+	h := func(ctx context.Context, cmd Interface, exec *ExecutionContext) error {
+		return runWithHooks(ctx, cmd, exec, pre, after, post)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,104 @@
+// ABOUTME: Tests for command executor middleware and hooks
+// ABOUTME: Covers ordering, stopping commands, replacing errors, and registry options
+
+package command_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRecordingCommand(order *[]string, err error) command.Interface {
+	return command.NewSimpleCommand(
+		&command.Metadata{Name: "test"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			*order = append(*order, "command")
+			return err
+		},
+	)
+}
+
+func recordingMiddleware(order *[]string, name string) command.Middleware {
+	return func(next command.Handler) command.Handler {
+		return func(ctx context.Context, cmd command.Interface, exec *command.ExecutionContext) error {
+			*order = append(*order, name+" before")
+			err := next(ctx, cmd, exec)
+			*order = append(*order, name+" after")
+			return err
+		}
+	}
+}
+
+// TestMiddlewareOrder verifies middleware wraps hooks and the command
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	executor := command.NewExecutor(command.NewRegistry(),
+		command.WithDefaultStreams(nil, io.Discard, io.Discard),
+		command.WithMiddleware(recordingMiddleware(&order, "outer")),
+	)
+	executor.Use(recordingMiddleware(&order, "inner"))
+	executor.Before(func(ctx context.Context, cmd command.Interface, exec *command.ExecutionContext) error {
+		order = append(order, "before")
+		return nil
+	})
+	executor.After(func(ctx context.Context, cmd command.Interface, exec *command.ExecutionContext, err error) error {
+		order = append(order, "after")
+		return err
+	})
+
+	err := executor.ExecuteCommand(context.Background(), newRecordingCommand(&order, nil), &command.ExecutionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"outer before", "inner before", "before", "command", "after", "inner after", "outer after",
+	}, order)
+}
+
+// TestMiddlewareStopsCommand verifies middleware can refuse to run a command
+func TestMiddlewareStopsCommand(t *testing.T) {
+	var order []string
+	denied := errors.New("not confirmed")
+	executor := command.NewExecutor(command.NewRegistry(), command.WithMiddleware(func(next command.Handler) command.Handler {
+		return func(ctx context.Context, cmd command.Interface, exec *command.ExecutionContext) error {
+			return denied
+		}
+	}))
+
+	err := executor.ExecuteCommand(context.Background(), newRecordingCommand(&order, nil), &command.ExecutionContext{})
+	assert.ErrorIs(t, err, denied)
+	assert.Empty(t, order)
+}
+
+// TestAfterHookError verifies after hooks see and can replace the command's error
+func TestAfterHookError(t *testing.T) {
+	var order []string
+	failed := errors.New("command failed")
+
+	var seen error
+	executor := command.NewExecutor(command.NewRegistry(), command.WithAfterHook(
+		func(ctx context.Context, cmd command.Interface, exec *command.ExecutionContext, err error) error {
+			seen = err
+			return nil
+		}))
+
+	err := executor.ExecuteCommand(context.Background(), newRecordingCommand(&order, failed), &command.ExecutionContext{})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, seen, failed)
+}
+
+// TestRegistryExecutorOptions verifies options added to a registry apply to its executors
+func TestRegistryExecutorOptions(t *testing.T) {
+	var order []string
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(newRecordingCommand(&order, nil)))
+	registry.SetExecutorOptions(command.WithDefaultStreams(nil, io.Discard, io.Discard))
+	registry.AddExecutorOptions(command.WithMiddleware(recordingMiddleware(&order, "logging")))
+
+	require.NoError(t, registry.GetExecutor().Execute(context.Background(), "test", &command.ExecutionContext{}))
+	assert.Equal(t, []string{"logging before", "command", "logging after"}, order)
+}
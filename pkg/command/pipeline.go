@@ -0,0 +1,122 @@
+// ABOUTME: Command pipelines that feed one command's results into the next
+// ABOUTME: Splits arguments on | and runs each stage with the previous stage's data and output
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// PipelineSeparator separates the commands of a pipeline
+const PipelineSeparator = "|"
+
+// DataKeyPipelineInput is the exec.Data key holding a *PipelineInput
+const DataKeyPipelineInput = "pipeline_input"
+
+// PipelineInput is what the previous stage of a pipeline produced
+type PipelineInput struct {
+	// Command is the name of the previous command
+	Command string
+	// Data is the previous command's exec.Data, its structured results
+	Data map[string]interface{}
+	// Text is what the previous command wrote to stdout
+	Text string
+}
+
+// GetPipelineInput returns the input from the previous stage of a pipeline,
+// or nil when the command is not running in one
+func GetPipelineInput(exec *ExecutionContext) *PipelineInput {
+	if exec == nil || exec.Data == nil {
+		return nil
+	}
+	input, _ := exec.Data[DataKeyPipelineInput].(*PipelineInput)
+	return input
+}
+
+// SetPipelineInput gives a command the results of the previous stage, in
+// exec.Data and as its stdin
+func SetPipelineInput(exec *ExecutionContext, input *PipelineInput) {
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+	exec.Data[DataKeyPipelineInput] = input
+	exec.Stdin = strings.NewReader(input.Text)
+}
+
+// SplitPipeline splits arguments into the commands of a pipeline at each
+// | argument. Arguments without one are a single stage.
+func SplitPipeline(args []string) ([][]string, error) {
+	stages := [][]string{{}}
+	for _, arg := range args {
+		if arg == PipelineSeparator {
+			stages = append(stages, []string{})
+			continue
+		}
+		stages[len(stages)-1] = append(stages[len(stages)-1], arg)
+	}
+	for i, stage := range stages {
+		if len(stage) == 0 {
+			return nil, fmt.Errorf("%w: empty command at position %d of pipeline", ErrInvalidArguments, i+1)
+		}
+	}
+	return stages, nil
+}
+
+// ExecutePipeline runs each command of a pipeline in turn. Every stage but
+// the last writes to a buffer, and the next stage gets that text on stdin
+// along with the previous exec.Data (see GetPipelineInput). The pipeline
+// stops at the first error.
+func (e *CommandExecutor) ExecutePipeline(ctx context.Context, stages [][]string) error {
+	return e.executePipeline(ctx, stages, nil)
+}
+
+// executePipeline runs a pipeline whose last stage writes to stdout when it
+// is set and to the command's default output otherwise
+func (e *CommandExecutor) executePipeline(ctx context.Context, stages [][]string, stdout io.Writer) error {
+	logging.LogDebug("Executing pipeline", "stages", len(stages))
+
+	var input *PipelineInput
+	for i, stage := range stages {
+		if len(stage) == 0 {
+			return fmt.Errorf("%w: empty command at position %d of pipeline", ErrInvalidArguments, i+1)
+		}
+		cmd, err := e.resolve(stage[0], stdout)
+		if err != nil {
+			return err
+		}
+		args, flags, err := parseArgsWithMetadata(stage[1:], cmd.Metadata())
+		if err != nil {
+			return fmt.Errorf("failed to parse arguments of %s: %w", stage[0], err)
+		}
+
+		exec := &ExecutionContext{
+			Args:  args,
+			Flags: NewFlags(flags),
+			Data:  make(map[string]interface{}),
+		}
+		if input != nil {
+			SetPipelineInput(exec, input)
+		}
+		var out bytes.Buffer
+		if i < len(stages)-1 {
+			exec.Stdout = &out
+		} else if stdout != nil {
+			exec.Stdout = stdout
+			exec.Stderr = stdout
+		}
+
+		if err := e.ExecuteCommand(ctx, cmd, exec); err != nil {
+			return fmt.Errorf("pipeline stage %d (%s): %w", i+1, stage[0], err)
+		}
+
+		delete(exec.Data, DataKeyPipelineInput)
+		input = &PipelineInput{Command: cmd.Metadata().Name, Data: exec.Data, Text: out.String()}
+	}
+	return nil
+}
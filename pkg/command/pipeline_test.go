@@ -0,0 +1,64 @@
+// ABOUTME: Tests for command pipelines
+// ABOUTME: Verifies splitting at | and passing data and output between stages
+
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPipeline(t *testing.T) {
+	stages, err := command.SplitPipeline([]string{"list", "--all", "|", "count"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"list", "--all"}, {"count"}}, stages)
+
+	stages, err = command.SplitPipeline([]string{"list"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"list"}}, stages)
+
+	for _, args := range [][]string{{"|", "count"}, {"list", "|"}, {"list", "|", "|", "count"}} {
+		_, err := command.SplitPipeline(args)
+		assert.ErrorIs(t, err, command.ErrInvalidArguments, args)
+	}
+}
+
+func TestExecutePipeline(t *testing.T) {
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "list"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			exec.Data["items"] = []string{"b", "a", "c"}
+			_, err := io.WriteString(exec.Stdout, "b\na\nc\n")
+			return err
+		})))
+	require.NoError(t, registry.Register(command.NewSimpleCommand(
+		&command.Metadata{Name: "count"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			input := command.GetPipelineInput(exec)
+			require.NotNil(t, input)
+			assert.Equal(t, "list", input.Command)
+			text, err := io.ReadAll(exec.Stdin)
+			require.NoError(t, err)
+			assert.Equal(t, input.Text, string(text))
+
+			items := input.Data["items"].([]string)
+			_, err = io.WriteString(exec.Stdout, strings.Repeat("*", len(items)))
+			return err
+		})))
+
+	var output bytes.Buffer
+	executor := command.NewExecutor(registry, command.WithDefaultStreams(nil, &output, io.Discard))
+	require.NoError(t, executor.ParseAndExecute(context.Background(), []string{"list", "|", "count"}))
+	assert.Equal(t, "***", output.String(), "only the last stage writes to stdout")
+
+	err := executor.ParseAndExecute(context.Background(), []string{"list", "|", "missing"})
+	assert.ErrorIs(t, err, command.ErrCommandNotFound)
+}
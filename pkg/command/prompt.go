@@ -0,0 +1,194 @@
+// ABOUTME: Interactive prompts for commands: confirmations, choices, and masked input
+// ABOUTME: Falls back to defaults or piped input when no terminal is attached
+
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// FlagYes is the flag that answers yes to every confirmation
+const FlagYes = "yes"
+
+// Prompter asks the user questions while a command runs. Without a
+// terminal nobody can answer, so each prompt falls back instead of waiting:
+// Confirm proceeds, as rm does when its input is not a terminal, Select
+// takes its default, and Secret reads a line of piped input.
+type Prompter struct {
+	in           *bufio.Reader
+	out          io.Writer
+	interactive  bool
+	assumeYes    bool
+	readPassword func(prompt string) ([]byte, error)
+}
+
+// NewPrompter creates a prompter that reads answers from in and writes
+// questions to out. A prompter that is not interactive never prompts.
+func NewPrompter(in io.Reader, out io.Writer, interactive bool) *Prompter {
+	reader, ok := in.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(in)
+	}
+	return &Prompter{in: reader, out: out, interactive: interactive}
+}
+
+// PrompterFor creates a prompter for a command. It prompts on stderr when
+// the command reads from a terminal, and answers yes to confirmations when
+// the command has --yes set.
+func PrompterFor(exec *ExecutionContext) *Prompter {
+	in := exec.Stdin
+	if in == nil {
+		in = os.Stdin
+	}
+	out := exec.Stderr
+	if out == nil {
+		out = os.Stderr
+	}
+
+	p := NewPrompter(in, out, IsInteractive(exec))
+	if p.interactive {
+		p.readPassword = readline.Password
+	}
+	if exec.Flags != nil {
+		p.assumeYes = exec.Flags.GetBool(FlagYes)
+	}
+	return p
+}
+
+// IsInteractive reports whether a command reads its input from a terminal
+func IsInteractive(exec *ExecutionContext) bool {
+	if exec != nil && exec.Stdin != nil && exec.Stdin != os.Stdin {
+		return false
+	}
+	// /dev/null is a character device too, so check for a terminal itself
+	return readline.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Interactive reports whether the prompter asks questions
+func (p *Prompter) Interactive() bool {
+	return p.interactive
+}
+
+// SetAssumeYes makes Confirm return true without asking
+func (p *Prompter) SetAssumeYes(yes bool) {
+	p.assumeYes = yes
+}
+
+// Confirm asks a yes/no question. An empty answer is def. It returns true
+// without asking under --yes or when there is no terminal.
+func (p *Prompter) Confirm(question string, def bool) (bool, error) {
+	if p.assumeYes || !p.interactive {
+		return true, nil
+	}
+
+	choices := "[y/N]"
+	if def {
+		choices = "[Y/n]"
+	}
+	for {
+		fmt.Fprintf(p.out, "%s %s: ", question, choices)
+		answer, err := p.readLine()
+		if err != nil {
+			fmt.Fprintln(p.out)
+			return def, nil
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.out, "Please answer y or n")
+	}
+}
+
+// Select asks for one of options and returns its index. The answer is a
+// number from the list or an option itself, and an empty answer is def.
+// Without a terminal it returns def, or ErrNonInteractive when def is
+// negative.
+func (p *Prompter) Select(question string, options []string, def int) (int, error) {
+	if len(options) == 0 {
+		return -1, fmt.Errorf("%w: nothing to choose from", ErrInvalidArguments)
+	}
+	if def >= len(options) {
+		def = -1
+	}
+	if !p.interactive {
+		if def < 0 {
+			return -1, fmt.Errorf("%w: %s", ErrNonInteractive, question)
+		}
+		return def, nil
+	}
+
+	fmt.Fprintln(p.out, question)
+	for i, option := range options {
+		marker := " "
+		if i == def {
+			marker = "*"
+		}
+		fmt.Fprintf(p.out, " %s %d) %s\n", marker, i+1, option)
+	}
+	for {
+		if def >= 0 {
+			fmt.Fprintf(p.out, "Choose 1-%d [%d]: ", len(options), def+1)
+		} else {
+			fmt.Fprintf(p.out, "Choose 1-%d: ", len(options))
+		}
+		answer, err := p.readLine()
+		if err != nil {
+			fmt.Fprintln(p.out)
+			return -1, fmt.Errorf("%w: no choice made", ErrCommandCanceled)
+		}
+		if answer == "" && def >= 0 {
+			return def, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+		for i, option := range options {
+			if answer == option {
+				return i, nil
+			}
+		}
+		fmt.Fprintf(p.out, "Please choose a number from 1 to %d\n", len(options))
+	}
+}
+
+// Secret reads a value without echoing it, such as a key or passphrase.
+// Without a terminal it reads a line of input instead.
+func (p *Prompter) Secret(prompt string) (string, error) {
+	if p.readPassword != nil {
+		secret, err := p.readPassword(prompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		return string(secret), nil
+	}
+	if p.interactive {
+		fmt.Fprint(p.out, prompt)
+	}
+	line, err := p.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readLine reads one trimmed answer, returning io.EOF when input ends
+// before one is given
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,111 @@
+// ABOUTME: Tests for interactive command prompts
+// ABOUTME: Verifies confirmations, choices, and secrets with and without a terminal
+
+package command_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrompterConfirm(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		def      bool
+		expected bool
+	}{
+		{"yes", "y\n", false, true},
+		{"no", "no\n", true, false},
+		{"empty uses default", "\n", true, true},
+		{"end of input uses default", "", false, false},
+		{"asks again after an invalid answer", "maybe\nYES\n", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := command.NewPrompter(strings.NewReader(tt.input), &out, true)
+			ok, err := p.Confirm("Delete session?", tt.def)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+			assert.Contains(t, out.String(), "Delete session?")
+		})
+	}
+
+	var out bytes.Buffer
+	p := command.NewPrompter(strings.NewReader("n\n"), &out, false)
+	ok, err := p.Confirm("Delete session?", false)
+	require.NoError(t, err)
+	assert.True(t, ok, "without a terminal a confirmation proceeds")
+	assert.Empty(t, out.String())
+
+	p = command.NewPrompter(strings.NewReader("n\n"), &out, true)
+	p.SetAssumeYes(true)
+	ok, err = p.Confirm("Delete session?", false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, out.String())
+}
+
+func TestPrompterSelect(t *testing.T) {
+	options := []string{"default", "work", "fast"}
+
+	var out bytes.Buffer
+	p := command.NewPrompter(strings.NewReader("7\nwork\n"), &out, true)
+	choice, err := p.Select("Switch to profile:", options, -1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, choice)
+	assert.Contains(t, out.String(), "3) fast")
+	assert.Contains(t, out.String(), "Please choose a number from 1 to 3")
+
+	p = command.NewPrompter(strings.NewReader("\n"), &out, true)
+	choice, err = p.Select("Switch to profile:", options, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, choice)
+
+	p = command.NewPrompter(strings.NewReader("3\n"), &out, true)
+	choice, err = p.Select("Switch to profile:", options, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, choice)
+
+	p = command.NewPrompter(strings.NewReader(""), &out, true)
+	_, err = p.Select("Switch to profile:", options, -1)
+	assert.ErrorIs(t, err, command.ErrCommandCanceled)
+
+	p = command.NewPrompter(strings.NewReader(""), &out, false)
+	choice, err = p.Select("Switch to profile:", options, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, choice)
+	_, err = p.Select("Switch to profile:", options, -1)
+	assert.ErrorIs(t, err, command.ErrNonInteractive)
+	_, err = p.Select("Switch to profile:", nil, 0)
+	assert.ErrorIs(t, err, command.ErrInvalidArguments)
+}
+
+func TestPrompterSecret(t *testing.T) {
+	var out bytes.Buffer
+	p := command.NewPrompter(strings.NewReader("sk-test\r\nnext\n"), &out, false)
+	secret, err := p.Secret("API key: ")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-test", secret)
+	assert.Empty(t, out.String())
+
+	secret, err = p.Secret("API key: ")
+	require.NoError(t, err)
+	assert.Equal(t, "next", secret)
+}
+
+func TestPrompterFor(t *testing.T) {
+	exec := &command.ExecutionContext{
+		Stdin: strings.NewReader("y\n"),
+		Flags: command.NewFlags(map[string]interface{}{command.FlagYes: true}),
+	}
+	assert.False(t, command.IsInteractive(exec))
+	p := command.PrompterFor(exec)
+	assert.False(t, p.Interactive())
+}
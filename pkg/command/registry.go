@@ -17,6 +17,10 @@ type Registry struct {
 	mu       sync.RWMutex
 	commands map[string]Interface
 	aliases  map[string]string // alias -> primary name mapping
+	execOpts []ExecutorOption  // options for executors from GetExecutor
+	jobs     *JobManager       // background jobs started by executors
+
+	deprecations map[string]Deprecation // deprecated old name -> replacement
 }
 
 // GlobalRegistry is the default command registry
@@ -26,8 +30,9 @@ var GlobalRegistry = NewRegistry()
 func NewRegistry() *Registry {
 	logging.LogDebug("Creating new command registry")
 	return &Registry{
-		commands: make(map[string]Interface),
-		aliases:  make(map[string]string),
+		commands:     make(map[string]Interface),
+		aliases:      make(map[string]string),
+		deprecations: make(map[string]Deprecation),
 	}
 }
 
@@ -137,9 +142,11 @@ func (r *Registry) Names() []string {
 		names = append(names, name)
 	}
 
-	// Add aliases
+	// Add aliases, leaving out deprecated old names
 	for alias := range r.aliases {
-		names = append(names, alias)
+		if _, deprecated := r.deprecations[alias]; !deprecated {
+			names = append(names, alias)
+		}
 	}
 
 	sort.Strings(names)
@@ -194,13 +201,42 @@ func (r *Registry) Clear() {
 
 	r.commands = make(map[string]Interface)
 	r.aliases = make(map[string]string)
+	r.deprecations = make(map[string]Deprecation)
 
 	logging.LogInfo("Command registry cleared")
 }
 
 // GetExecutor returns a command executor for this registry
 func (r *Registry) GetExecutor() *CommandExecutor {
-	return NewExecutor(r)
+	r.mu.RLock()
+	opts := r.execOpts
+	r.mu.RUnlock()
+	return NewExecutor(r, opts...)
+}
+
+// SetExecutorOptions sets the options of executors returned by GetExecutor
+func (r *Registry) SetExecutorOptions(opts ...ExecutorOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.execOpts = opts
+}
+
+// AddExecutorOptions adds options, such as middleware, to executors
+// returned by GetExecutor
+func (r *Registry) AddExecutorOptions(opts ...ExecutorOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.execOpts = append(append([]ExecutorOption(nil), r.execOpts...), opts...)
+}
+
+// Jobs returns the background jobs of commands run from this registry
+func (r *Registry) Jobs() *JobManager {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.jobs == nil {
+		r.jobs = NewJobManager()
+	}
+	return r.jobs
 }
 
 // MustRegister registers a command and panics on error
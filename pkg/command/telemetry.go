@@ -0,0 +1,235 @@
+// ABOUTME: Execution telemetry recorded by hooks around every command
+// ABOUTME: Stores each command's duration, outcome, and token usage in a local stats file and summarizes them
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// DataKeyUsage is the exec.Data key holding the *TokenUsage of a command
+const DataKeyUsage = "usage"
+
+// dataKeyTelemetryStart is the exec.Data key holding when a command started
+const dataKeyTelemetryStart = "telemetry_start"
+
+// TokenUsage counts the tokens a command used
+type TokenUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// AddUsage adds tokens used by a model request to a command's usage
+func AddUsage(exec *ExecutionContext, inputTokens, outputTokens int) {
+	if exec == nil {
+		return
+	}
+	if exec.Data == nil {
+		exec.Data = make(map[string]interface{})
+	}
+	usage, _ := exec.Data[DataKeyUsage].(*TokenUsage)
+	if usage == nil {
+		usage = &TokenUsage{}
+		exec.Data[DataKeyUsage] = usage
+	}
+	usage.InputTokens += inputTokens
+	usage.OutputTokens += outputTokens
+	usage.TotalTokens += inputTokens + outputTokens
+}
+
+// UsageOf returns the tokens a command used, which are zero when it made
+// no model requests
+func UsageOf(exec *ExecutionContext) TokenUsage {
+	if exec == nil || exec.Data == nil {
+		return TokenUsage{}
+	}
+	if usage, ok := exec.Data[DataKeyUsage].(*TokenUsage); ok && usage != nil {
+		return *usage
+	}
+	return TokenUsage{}
+}
+
+// CommandRecord is the telemetry of one command run
+type CommandRecord struct {
+	Command  string        `json:"command"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Usage    TokenUsage    `json:"usage"`
+}
+
+// CommandSummary totals the telemetry of one command
+type CommandSummary struct {
+	Command         string        `json:"command"`
+	Runs            int           `json:"runs"`
+	Failures        int           `json:"failures"`
+	TotalDuration   time.Duration `json:"total_duration"`
+	AverageDuration time.Duration `json:"average_duration"`
+	InputTokens     int           `json:"input_tokens"`
+	OutputTokens    int           `json:"output_tokens"`
+	LastRun         time.Time     `json:"last_run"`
+}
+
+// StatsStore keeps command telemetry in a local file, one JSON record per
+// line
+type StatsStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStatsStore creates a store that keeps records in path
+func NewStatsStore(path string) *StatsStore {
+	return &StatsStore{path: path}
+}
+
+// Path returns the file the store keeps records in
+func (s *StatsStore) Path() string {
+	return s.path
+}
+
+// Record appends a record to the store
+func (s *StatsStore) Record(rec CommandRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode command record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open stats file: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	return f.Close()
+}
+
+// Records returns the records in the store, oldest first. Lines that do
+// not decode are skipped.
+func (s *StatsStore) Records() ([]CommandRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open stats file: %w", err)
+	}
+	defer f.Close()
+
+	var records []CommandRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec CommandRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			logging.LogDebug("Skipping unreadable command record", "error", err)
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stats file: %w", err)
+	}
+	return records, nil
+}
+
+// Clear removes every record from the store
+func (s *StatsStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stats file: %w", err)
+	}
+	return nil
+}
+
+// SummarizeCommands totals records by command, most run first
+func SummarizeCommands(records []CommandRecord) []CommandSummary {
+	byCommand := make(map[string]*CommandSummary)
+	for _, rec := range records {
+		summary, ok := byCommand[rec.Command]
+		if !ok {
+			summary = &CommandSummary{Command: rec.Command}
+			byCommand[rec.Command] = summary
+		}
+		summary.Runs++
+		if !rec.Success {
+			summary.Failures++
+		}
+		summary.TotalDuration += rec.Duration
+		summary.InputTokens += rec.Usage.InputTokens
+		summary.OutputTokens += rec.Usage.OutputTokens
+		if rec.Started.After(summary.LastRun) {
+			summary.LastRun = rec.Started
+		}
+	}
+
+	summaries := make([]CommandSummary, 0, len(byCommand))
+	for _, summary := range byCommand {
+		summary.AverageDuration = summary.TotalDuration / time.Duration(summary.Runs)
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Runs != summaries[j].Runs {
+			return summaries[i].Runs > summaries[j].Runs
+		}
+		return summaries[i].Command < summaries[j].Command
+	})
+	return summaries
+}
+
+// WithTelemetry adds hooks that record every command's duration, outcome,
+// and token usage in store. A record that cannot be stored is logged and
+// does not fail the command.
+func WithTelemetry(store *StatsStore) ExecutorOption {
+	return func(e *CommandExecutor) {
+		e.preExecute = append(e.preExecute, func(ctx context.Context, cmd Interface, exec *ExecutionContext) error {
+			exec.Data[dataKeyTelemetryStart] = time.Now()
+			return nil
+		})
+		e.afterExecute = append(e.afterExecute, func(ctx context.Context, cmd Interface, exec *ExecutionContext, err error) error {
+			started, ok := exec.Data[dataKeyTelemetryStart].(time.Time)
+			if !ok {
+				return err
+			}
+			delete(exec.Data, dataKeyTelemetryStart)
+
+			rec := CommandRecord{
+				Command:  cmd.Metadata().Name,
+				Started:  started,
+				Duration: time.Since(started),
+				Success:  err == nil,
+				Usage:    UsageOf(exec),
+			}
+			if err != nil {
+				rec.Error = err.Error()
+			}
+			if recordErr := store.Record(rec); recordErr != nil {
+				logging.LogWarn("Failed to record command telemetry", "command", rec.Command, "error", recordErr)
+			}
+			return err
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// ABOUTME: Tests for execution telemetry
+// ABOUTME: Verifies token usage accounting, the stats store, summaries, and the recording hooks
+
+package command_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddUsage(t *testing.T) {
+	exec := &command.ExecutionContext{}
+	assert.Equal(t, command.TokenUsage{}, command.UsageOf(exec))
+
+	command.AddUsage(exec, 10, 5)
+	command.AddUsage(exec, 3, 2)
+	assert.Equal(t, command.TokenUsage{InputTokens: 13, OutputTokens: 7, TotalTokens: 20}, command.UsageOf(exec))
+}
+
+func TestStatsStore(t *testing.T) {
+	store := command.NewStatsStore(filepath.Join(t.TempDir(), "stats", "commands.jsonl"))
+
+	records, err := store.Records()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, store.Record(command.CommandRecord{Command: "ask", Started: started, Duration: time.Second, Success: true}))
+	require.NoError(t, store.Record(command.CommandRecord{Command: "ask", Started: started.Add(time.Hour), Duration: 3 * time.Second, Error: "boom"}))
+
+	// A damaged line does not hide the others
+	f, err := os.OpenFile(store.Path(), os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString("{not json\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	records, err = store.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "boom", records[1].Error)
+	assert.True(t, records[0].Started.Equal(started))
+
+	require.NoError(t, store.Clear())
+	records, err = store.Records()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+	require.NoError(t, store.Clear())
+}
+
+func TestSummarizeCommands(t *testing.T) {
+	now := time.Now()
+	summaries := command.SummarizeCommands([]command.CommandRecord{
+		{Command: "version", Started: now, Duration: time.Millisecond, Success: true},
+		{Command: "ask", Started: now.Add(-time.Hour), Duration: time.Second, Success: true, Usage: command.TokenUsage{InputTokens: 10, OutputTokens: 20}},
+		{Command: "ask", Started: now, Duration: 3 * time.Second, Usage: command.TokenUsage{InputTokens: 5, OutputTokens: 1}},
+	})
+	require.Len(t, summaries, 2)
+
+	ask := summaries[0]
+	assert.Equal(t, "ask", ask.Command)
+	assert.Equal(t, 2, ask.Runs)
+	assert.Equal(t, 1, ask.Failures)
+	assert.Equal(t, 4*time.Second, ask.TotalDuration)
+	assert.Equal(t, 2*time.Second, ask.AverageDuration)
+	assert.Equal(t, 15, ask.InputTokens)
+	assert.Equal(t, 21, ask.OutputTokens)
+	assert.True(t, ask.LastRun.Equal(now))
+	assert.Equal(t, "version", summaries[1].Command)
+}
+
+func TestWithTelemetry(t *testing.T) {
+	store := command.NewStatsStore(filepath.Join(t.TempDir(), "commands.jsonl"))
+	registry := command.NewRegistry()
+	registry.AddExecutorOptions(command.WithTelemetry(store))
+
+	require.NoError(t, registry.Register(command.NewSimpleCommand(&command.Metadata{Name: "ask"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			command.AddUsage(exec, 7, 3)
+			return nil
+		})))
+	require.NoError(t, registry.Register(command.NewSimpleCommand(&command.Metadata{Name: "fail"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			return errors.New("boom")
+		})))
+
+	executor := registry.GetExecutor()
+	require.NoError(t, executor.Execute(context.Background(), "ask", &command.ExecutionContext{}))
+	assert.Error(t, executor.Execute(context.Background(), "fail", &command.ExecutionContext{}))
+
+	records, err := store.Records()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "ask", records[0].Command)
+	assert.True(t, records[0].Success)
+	assert.Equal(t, command.TokenUsage{InputTokens: 7, OutputTokens: 3, TotalTokens: 10}, records[0].Usage)
+	assert.False(t, records[0].Started.IsZero())
+	assert.Equal(t, "fail", records[1].Command)
+	assert.False(t, records[1].Success)
+	assert.Equal(t, "boom", records[1].Error)
+}
@@ -0,0 +1,40 @@
+// ABOUTME: Tracing middleware that wraps every command in an OpenTelemetry span
+// ABOUTME: Provider and storage spans started by a command become children of its span
+
+package command
+
+import (
+	"context"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/tracing"
+)
+
+// WithTracing runs each command in a span named after it, recording its
+// outcome, duration, and token usage. The span's context replaces
+// exec.Context while the command runs.
+func WithTracing() ExecutorOption {
+	return WithMiddleware(func(next Handler) Handler {
+		return func(ctx context.Context, cmd Interface, exec *ExecutionContext) error {
+			name := cmd.Metadata().Name
+			started := time.Now()
+			ctx, span := tracing.Start(ctx, "command."+name, tracing.AttrCommand.String(name))
+
+			parent := exec.Context
+			exec.Context = ctx
+			err := next(ctx, cmd, exec)
+			exec.Context = parent
+
+			usage := UsageOf(exec)
+			span.SetAttributes(tracing.Latency(started))
+			if usage.TotalTokens > 0 {
+				span.SetAttributes(
+					tracing.AttrInputTokens.Int(usage.InputTokens),
+					tracing.AttrOutputTokens.Int(usage.OutputTokens),
+				)
+			}
+			tracing.End(span, err)
+			return err
+		}
+	})
+}
@@ -0,0 +1,66 @@
+// ABOUTME: Tests for the command tracing middleware
+// ABOUTME: Verifies that commands run in spans carrying their name, usage, and outcome
+
+package command_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWithTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	shutdown := tracing.Install(recorder, tracing.Config{Sampling: 1})
+	defer func() {
+		_ = shutdown(context.Background())
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	}()
+
+	registry := command.NewRegistry()
+	registry.AddExecutorOptions(command.WithTracing())
+
+	var inner trace.SpanContext
+	require.NoError(t, registry.Register(command.NewSimpleCommand(&command.Metadata{Name: "ask"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			_, span := tracing.Start(exec.Context, "llm.chat")
+			inner = span.SpanContext()
+			span.End()
+			command.AddUsage(exec, 7, 3)
+			return nil
+		})))
+	require.NoError(t, registry.Register(command.NewSimpleCommand(&command.Metadata{Name: "fail"},
+		func(ctx context.Context, exec *command.ExecutionContext) error {
+			return errors.New("boom")
+		})))
+
+	executor := registry.GetExecutor()
+	exec := &command.ExecutionContext{Context: context.Background()}
+	require.NoError(t, executor.Execute(context.Background(), "ask", exec))
+	assert.Equal(t, context.Background(), exec.Context, "the caller's context is restored")
+	assert.Error(t, executor.Execute(context.Background(), "fail", &command.ExecutionContext{}))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	ask := spans[1]
+	assert.Equal(t, "command.ask", ask.Name())
+	assert.Equal(t, ask.SpanContext().SpanID(), spans[0].Parent().SpanID(), "spans started by the command are its children")
+	assert.Equal(t, inner.SpanID(), spans[0].SpanContext().SpanID())
+	assert.Contains(t, ask.Attributes(), tracing.AttrCommand.String("ask"))
+	assert.Contains(t, ask.Attributes(), tracing.AttrInputTokens.Int(7))
+	assert.Contains(t, ask.Attributes(), tracing.AttrOutputTokens.Int(3))
+
+	fail := spans[2]
+	assert.Equal(t, "command.fail", fail.Name())
+	assert.Equal(t, codes.Error, fail.Status().Code)
+}
@@ -7,16 +7,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/confmap"
-	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/v2"
 	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/keyring"
+	"github.com/lexlapax/magellai/pkg/secrets"
 )
 
 const (
@@ -45,8 +46,15 @@ type Config struct {
 
 	// Configuration layers in order of precedence (lowest to highest)
 	defaults    map[string]interface{}
-	profile     string   // current profile name
-	loadedFiles []string // list of successfully loaded config files
+	profile     string                 // current profile name
+	loadedFiles []string               // list of successfully loaded config files
+	extraFiles  []string               // files loaded with LoadFile, reloaded by Reload
+	overrides   map[string]interface{} // values set at runtime, kept by Reload
+	sources     map[string]ValueSource // layer each value came from, by flattened key
+
+	// Resolver for secret:// references, built on first use
+	secretsMu sync.Mutex
+	resolver  *secrets.Resolver
 }
 
 // Manager is the global configuration manager instance
@@ -100,17 +108,18 @@ func (c *Config) Load(cmdlineOverrides map[string]interface{}) error {
 
 	// 1. Load defaults
 	logging.LogDebug("Loading default configuration")
-	if err := c.koanf.Load(confmap.Provider(c.defaults, "."), nil); err != nil {
+	if err := c.loadDefaults(); err != nil {
 		logging.LogError(err, "Failed to load defaults")
 		return fmt.Errorf("failed to load defaults: %w", err)
 	}
 
 	// 2. Load system config if exists
-	logging.LogDebug("Loading system configuration", "path", SystemConfigPath)
-	_ = c.loadFile(SystemConfigPath) // Ignore error if file doesn't exist
+	systemConfig := systemConfigFile()
+	logging.LogDebug("Loading system configuration", "path", systemConfig)
+	_ = c.loadFile(systemConfig) // Ignore error if file doesn't exist
 
 	// 3. Load user config
-	userConfig := expandPath(filepath.Join(UserConfigDir, UserConfigFile))
+	userConfig := UserConfigPath()
 	logging.LogDebug("Loading user configuration", "path", userConfig)
 	_ = c.loadFile(userConfig) // Ignore error if file doesn't exist
 
@@ -118,17 +127,12 @@ func (c *Config) Load(cmdlineOverrides map[string]interface{}) error {
 	logging.LogDebug("Searching for project configuration")
 	if projectConfig := c.findProjectConfig(); projectConfig != "" {
 		logging.LogInfo("Found project configuration", "path", projectConfig)
-		_ = c.loadFile(projectConfig) // Ignore error if file doesn't exist
+		_ = c.loadProjectFile(projectConfig) // Ignore error if file doesn't exist
 	}
 
 	// 5. Load environment variables
 	logging.LogDebug("Loading environment variables", "prefix", ConfigEnvPrefix)
-	if err := c.koanf.Load(env.Provider(ConfigEnvPrefix, ".", func(s string) string {
-		// Convert MAGELLAI_PROVIDER_API_KEY to provider.api_key
-		s = strings.ToLower(strings.TrimPrefix(s, ConfigEnvPrefix))
-		s = strings.ReplaceAll(s, "_", ".")
-		return s
-	}), nil); err != nil {
+	if err := c.loadEnv(); err != nil {
 		logging.LogError(err, "Failed to load environment variables")
 		return fmt.Errorf("failed to load environment variables: %w", err)
 	}
@@ -143,10 +147,16 @@ func (c *Config) Load(cmdlineOverrides map[string]interface{}) error {
 	// 6. Load command-line overrides (if provided)
 	if len(cmdlineOverrides) > 0 {
 		logging.LogDebug("Loading command-line configuration overrides")
-		if err := c.koanf.Load(confmap.Provider(cmdlineOverrides, "."), nil); err != nil {
+		overrides := koanf.New(".")
+		if err := overrides.Load(confmap.Provider(cmdlineOverrides, "."), nil); err != nil {
 			logging.LogError(err, "Failed to load command-line overrides")
 			return fmt.Errorf("failed to load command-line overrides: %w", err)
 		}
+		if err := c.koanf.Merge(overrides); err != nil {
+			logging.LogError(err, "Failed to load command-line overrides")
+			return fmt.Errorf("failed to load command-line overrides: %w", err)
+		}
+		c.recordSources(overrides, ValueSource{Layer: LayerFlag})
 	}
 
 	// Apply profile overrides if a profile is set
@@ -158,6 +168,7 @@ func (c *Config) Load(cmdlineOverrides map[string]interface{}) error {
 		}
 	}
 
+	c.maskSecrets()
 	duration := time.Since(start)
 	logging.LogInfo("Configuration loading completed successfully")
 	logging.LogDebug("Configuration load time", "duration", duration)
@@ -168,11 +179,31 @@ func (c *Config) Load(cmdlineOverrides map[string]interface{}) error {
 func (c *Config) LoadFile(path string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.loadFile(path)
+	if err := c.loadFile(path); err != nil {
+		return err
+	}
+	c.extraFiles = append(c.extraFiles, expandPath(path))
+	return nil
 }
 
+// userOnlyKeys are settings a project config file cannot set, because a
+// repository's .magellai.yaml is not trusted to run commands
+var userOnlyKeys = []string{"secrets.command"}
+
 // loadFile is the internal file loading method (not thread-safe)
 func (c *Config) loadFile(path string) error {
+	return c.loadConfigFile(path, false)
+}
+
+// loadProjectFile loads a project config file, ignoring userOnlyKeys (not
+// thread-safe)
+func (c *Config) loadProjectFile(path string) error {
+	return c.loadConfigFile(path, true)
+}
+
+// loadConfigFile loads a configuration file, dropping userOnlyKeys from an
+// untrusted one (not thread-safe)
+func (c *Config) loadConfigFile(path string, untrusted bool) error {
 	expandedPath := expandPath(path)
 
 	logging.LogDebug("Attempting to load configuration file", "path", path, "expandedPath", expandedPath)
@@ -183,8 +214,21 @@ func (c *Config) loadFile(path string) error {
 		return err
 	}
 
-	// Load the file
-	if err := c.koanf.Load(file.Provider(expandedPath), yaml.Parser()); err != nil {
+	// Parse the file and expand ${VAR} references before merging it
+	fileConfig, err := readConfigFile(expandedPath)
+	if err == nil {
+		if untrusted {
+			for _, key := range userOnlyKeys {
+				if fileConfig.Exists(key) {
+					logging.LogWarn("Ignoring setting only allowed in user or system config", "key", key, "path", expandedPath)
+					fileConfig.Delete(key)
+				}
+			}
+		}
+		err = c.koanf.Merge(fileConfig)
+		c.recordSources(fileConfig, ValueSource{Layer: LayerFile, Detail: expandedPath})
+	}
+	if err != nil {
 		logging.LogError(err, "Failed to load config file", "path", expandedPath)
 		return fmt.Errorf("failed to load config file %s: %w", path, err)
 	}
@@ -196,16 +240,55 @@ func (c *Config) loadFile(path string) error {
 	return nil
 }
 
+// readConfigFile parses a configuration file, expanding ${VAR} references
+// and decrypting encrypted values
+func readConfigFile(path string) (*koanf.Koanf, error) {
+	data, err := file.Provider(path).ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	values, err := parserFor(path).Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	interpolateValues(values, "")
+	decryptValues(values, path)
+	fileConfig := koanf.New(".")
+	if err := fileConfig.Load(confmap.Provider(values, ""), nil); err != nil {
+		return nil, err
+	}
+	return fileConfig, nil
+}
+
+// FileChanges returns the flattened values a configuration file would
+// change if it were loaded, without loading it
+func (c *Config) FileChanges(path string) (map[string]interface{}, error) {
+	expandedPath := expandPath(path)
+	fileConfig, err := readConfigFile(expandedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	changes := make(map[string]interface{})
+	for key, value := range fileConfig.All() {
+		if !c.koanf.Exists(key) || fmt.Sprint(c.koanf.Get(key)) != fmt.Sprint(value) {
+			changes[key] = value
+		}
+	}
+	return changes, nil
+}
+
 // findProjectConfig searches for a project config file starting from current directory
 func (c *Config) findProjectConfig() string {
 	logging.LogDebug("Searching for project configuration file", "startDir", c.currentDir, "filename", ProjectConfigFile)
 
 	dir := c.currentDir
 	for {
-		configPath := filepath.Join(dir, ProjectConfigFile)
-		logging.LogDebug("Checking for project config", "path", configPath)
+		logging.LogDebug("Checking for project config", "dir", dir)
 
-		if _, err := os.Stat(configPath); err == nil {
+		if configPath := findConfigFile(filepath.Join(dir, ProjectConfigFile)); configPath != "" {
 			logging.LogDebug("Found project config", "path", configPath)
 			return configPath
 		}
@@ -242,20 +325,19 @@ func (c *Config) SetProfile(profile string) error {
 func (c *Config) applyProfile(profile string) error {
 	logging.LogDebug("Applying profile configuration", "profile", profile)
 
-	profileKey := fmt.Sprintf("profiles.%s", profile)
-	if !c.koanf.Exists(profileKey) {
-		logging.LogWarn("Profile not found", "profile", profile)
-		return fmt.Errorf("%w: %s", ErrProfileNotFound, profile)
+	// Get profile config, including the profiles it extends
+	profileConfig, err := c.resolveProfile(profile)
+	if err != nil {
+		logging.LogWarn("Profile not resolved", "profile", profile, "error", err)
+		return err
 	}
 
-	// Get profile config
-	profileConfig := c.koanf.Cut(profileKey)
-
 	// Merge profile config over current config
 	if err := c.koanf.Merge(profileConfig); err != nil {
 		logging.LogError(err, "Failed to merge profile configuration", "profile", profile)
 		return fmt.Errorf("failed to apply profile '%s': %w", profile, err)
 	}
+	c.recordSources(profileConfig, ValueSource{Layer: LayerProfile, Detail: profile})
 
 	logging.LogDebug("Applied profile successfully", "profile", profile)
 	return nil
@@ -272,6 +354,17 @@ func expandPath(path string) string {
 	return path
 }
 
+// envKey converts an environment variable name to a config key, such as
+// MAGELLAI_PROVIDER_API_KEY to provider.api_key. Variables that are not
+// settings map to "", which skips them.
+func envKey(s string) string {
+	if s == EnvConfigPassphrase || s == keyring.DisableEnvVar {
+		return ""
+	}
+	s = strings.ToLower(strings.TrimPrefix(s, ConfigEnvPrefix))
+	return strings.ReplaceAll(s, "_", ".")
+}
+
 // loadProviderAPIKeys checks for provider-specific API keys in environment variables
 // and loads them into the configuration if the corresponding keys in config are empty
 func (c *Config) loadProviderAPIKeys() error {
@@ -283,15 +376,15 @@ func (c *Config) loadProviderAPIKeys() error {
 		// Only set if not already configured
 		if !c.koanf.Exists("provider.openai.api_key") || c.koanf.String("provider.openai.api_key") == "" {
 			logging.LogInfo("Using OpenAI API key from environment variable")
-			if err := c.koanf.Set("provider.openai.api_key", openAIKey); err != nil {
-				return fmt.Errorf("failed to set OpenAI API key: %w", err)
+			if err := c.setFromEnv("provider.openai.api_key", openAIKey, EnvOpenAIKey); err != nil {
+				return err
 			}
 
 			// If no default provider is set, use OpenAI
 			if !c.koanf.Exists("provider.default") || c.koanf.String("provider.default") == "" {
 				logging.LogInfo("Setting default provider to OpenAI based on available API key")
-				if err := c.koanf.Set("provider.default", "openai"); err != nil {
-					return fmt.Errorf("failed to set default provider: %w", err)
+				if err := c.setFromEnv("provider.default", "openai", EnvOpenAIKey); err != nil {
+					return err
 				}
 			}
 		}
@@ -303,15 +396,15 @@ func (c *Config) loadProviderAPIKeys() error {
 		// Only set if not already configured
 		if !c.koanf.Exists("provider.anthropic.api_key") || c.koanf.String("provider.anthropic.api_key") == "" {
 			logging.LogInfo("Using Anthropic API key from environment variable")
-			if err := c.koanf.Set("provider.anthropic.api_key", anthropicKey); err != nil {
-				return fmt.Errorf("failed to set Anthropic API key: %w", err)
+			if err := c.setFromEnv("provider.anthropic.api_key", anthropicKey, EnvAnthropicKey); err != nil {
+				return err
 			}
 
 			// If no default provider is set, use Anthropic
 			if !c.koanf.Exists("provider.default") || c.koanf.String("provider.default") == "" {
 				logging.LogInfo("Setting default provider to Anthropic based on available API key")
-				if err := c.koanf.Set("provider.default", "anthropic"); err != nil {
-					return fmt.Errorf("failed to set default provider: %w", err)
+				if err := c.setFromEnv("provider.default", "anthropic", EnvAnthropicKey); err != nil {
+					return err
 				}
 			}
 		}
@@ -323,15 +416,15 @@ func (c *Config) loadProviderAPIKeys() error {
 		// Only set if not already configured
 		if !c.koanf.Exists("provider.gemini.api_key") || c.koanf.String("provider.gemini.api_key") == "" {
 			logging.LogInfo("Using Gemini API key from environment variable")
-			if err := c.koanf.Set("provider.gemini.api_key", geminiKey); err != nil {
-				return fmt.Errorf("failed to set Gemini API key: %w", err)
+			if err := c.setFromEnv("provider.gemini.api_key", geminiKey, EnvGeminiKey); err != nil {
+				return err
 			}
 
 			// If no default provider is set, use Gemini
 			if !c.koanf.Exists("provider.default") || c.koanf.String("provider.default") == "" {
 				logging.LogInfo("Setting default provider to Gemini based on available API key")
-				if err := c.koanf.Set("provider.default", "gemini"); err != nil {
-					return fmt.Errorf("failed to set default provider: %w", err)
+				if err := c.setFromEnv("provider.default", "gemini", EnvGeminiKey); err != nil {
+					return err
 				}
 			}
 		}
@@ -340,9 +433,9 @@ func (c *Config) loadProviderAPIKeys() error {
 	return nil
 }
 
-// Watch registers a callback function to be called when configuration changes
-// Note: Currently registers the watcher but file watching is not implemented.
-// Future implementation will use fsnotify to monitor config file changes.
+// Watch registers a callback function to be called when configuration
+// changes, whether by SetValue or by Reload. WatchFiles reloads the
+// configuration when its files change.
 func (c *Config) Watch(callback func()) {
 	logging.LogDebug("Adding configuration watcher")
 
@@ -351,9 +444,6 @@ func (c *Config) Watch(callback func()) {
 
 	c.watchers = append(c.watchers, callback)
 	logging.LogDebug("Configuration watcher added", "totalWatchers", len(c.watchers))
-
-	// File watching will be implemented in a future release.
-	// Current watchers are only notified on explicit reload operations.
 }
 
 // notifyWatchers notifies all registered watchers of config changes
@@ -369,7 +459,7 @@ func (c *Config) GetPrimaryConfigFile() string {
 	defer c.mu.RUnlock()
 
 	// Try user config first
-	userConfig := expandPath(filepath.Join(UserConfigDir, UserConfigFile))
+	userConfig := UserConfigPath()
 	if _, err := os.Stat(userConfig); err == nil {
 		return userConfig
 	}
@@ -381,63 +471,95 @@ func (c *Config) GetPrimaryConfigFile() string {
 	}
 
 	// Fallback to system config
-	if _, err := os.Stat(SystemConfigPath); err == nil {
-		return SystemConfigPath
+	if systemConfig := findConfigFile(SystemConfigPath); systemConfig != "" {
+		return systemConfig
 	}
 
 	// Default to user config path even if it doesn't exist
 	return userConfig
 }
 
-// Reload reloads the configuration from all sources
+// Reload reloads the configuration from all sources. Files loaded with
+// LoadFile are read again, and values set with SetValue are kept. If a
+// config file cannot be parsed the previous configuration stays in effect.
 func (c *Config) Reload() error {
 	logging.LogInfo("Reloading configuration from all sources")
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Create a new koanf instance and store the old one
-	oldKoanf := c.koanf
+	// Create a new koanf instance and keep the old state for rollback
+	oldKoanf, oldFiles, oldSources := c.koanf, c.loadedFiles, c.sources
+	rollback := func() {
+		c.koanf, c.loadedFiles, c.sources = oldKoanf, oldFiles, oldSources
+	}
 	c.koanf = koanf.New(".")
+	c.loadedFiles = nil
+	c.sources = nil
 
 	// Load defaults first
 	if err := c.loadDefaults(); err != nil {
 		logging.LogError(err, "Failed to reload defaults")
-		c.koanf = oldKoanf
+		rollback()
 		return err
 	}
 
-	// Load system config if exists
-	_ = c.loadFile(SystemConfigPath)
-
-	// Load user config
-	userConfig := expandPath(filepath.Join(UserConfigDir, UserConfigFile))
-	_ = c.loadFile(userConfig)
-
-	// Load project config (search upward from current directory)
-	if projectConfig := c.findProjectConfig(); projectConfig != "" {
-		_ = c.loadFile(projectConfig)
+	// Load system, user, project, then explicitly loaded config files.
+	// Missing files are skipped, broken ones abort the reload.
+	paths := []string{systemConfigFile(), UserConfigPath()}
+	projectConfig := c.findProjectConfig()
+	if projectConfig != "" {
+		paths = append(paths, projectConfig)
+	}
+	for _, path := range append(paths, c.extraFiles...) {
+		load := c.loadFile
+		if path == projectConfig {
+			load = c.loadProjectFile
+		}
+		if err := load(path); err != nil && !os.IsNotExist(err) {
+			rollback()
+			return fmt.Errorf("%w: %w", ErrConfigLoadFailed, err)
+		}
 	}
 
 	// Load environment variables
-	if err := c.koanf.Load(env.Provider(ConfigEnvPrefix, ".", func(s string) string {
-		s = strings.ToLower(strings.TrimPrefix(s, ConfigEnvPrefix))
-		s = strings.ReplaceAll(s, "_", ".")
-		return s
-	}), nil); err != nil {
-		c.koanf = oldKoanf
+	if err := c.loadEnv(); err != nil {
+		rollback()
 		return fmt.Errorf("failed to load environment variables: %w", err)
 	}
+	if err := c.loadProviderAPIKeys(); err != nil {
+		logging.LogError(err, "Failed to load provider API keys from environment variables")
+	}
 
 	// Apply profile overrides if a profile is set
 	if c.profile != "" {
 		if err := c.applyProfile(c.profile); err != nil {
 			logging.LogError(err, "Failed to apply profile during reload", "profile", c.profile)
-			c.koanf = oldKoanf
+			rollback()
 			return fmt.Errorf("failed to apply profile: %w", err)
 		}
 	}
 
+	// Values set at runtime win over the files, as they did before. Parent
+	// keys sort before their children, so nested sets apply in order.
+	keys := make([]string, 0, len(c.overrides))
+	for key := range c.overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := c.koanf.Set(key, c.overrides[key]); err != nil {
+			logging.LogWarn("Failed to restore runtime setting", "key", key, "error", err)
+			continue
+		}
+		c.recordKeySource(key, ValueSource{Layer: LayerFlag})
+	}
+
+	c.secretsMu.Lock()
+	c.resolver = nil
+	c.secretsMu.Unlock()
+
+	c.maskSecrets()
 	c.notifyWatchers()
 	logging.LogInfo("Configuration reload completed successfully")
 	return nil
@@ -445,11 +567,25 @@ func (c *Config) Reload() error {
 
 // loadDefaults loads the default configuration
 func (c *Config) loadDefaults() error {
-	return c.koanf.Load(confmap.Provider(c.defaults, "."), nil)
+	defaults := koanf.New(".")
+	if err := defaults.Load(confmap.Provider(c.defaults, "."), nil); err != nil {
+		return err
+	}
+	c.recordSources(defaults, ValueSource{Layer: LayerDefault})
+	return c.koanf.Merge(defaults)
 }
 
 // DeleteKey deletes a configuration key
 func (c *Config) DeleteKey(key string) error {
+	if err := c.deleteKey(key); err != nil {
+		return err
+	}
+	publishConfigChanged(key, true, false)
+	return nil
+}
+
+// deleteKey removes a key from the loaded configuration
+func (c *Config) deleteKey(key string) error {
 	logging.LogInfo("Deleting configuration key", "key", key)
 
 	c.mu.Lock()
@@ -474,6 +610,7 @@ func (c *Config) DeleteKey(key string) error {
 	}
 
 	c.koanf = newKoanf
+	delete(c.overrides, key)
 	c.notifyWatchers()
 
 	logging.LogInfo("Successfully deleted configuration key", "key", key)
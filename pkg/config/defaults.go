@@ -19,6 +19,13 @@ func GetCompleteDefaultConfig() map[string]interface{} {
 		"log": map[string]interface{}{
 			"level":  "info", // Default to info level
 			"format": "text", // text or json
+			"file":   "",     // Log file; empty logs to stderr
+			"rotation": map[string]interface{}{
+				"size":     10,   // Rotate the log file beyond this many megabytes; 0 never
+				"interval": "0s", // Rotate the log file each interval, e.g. "24h"; 0s never
+				"keep":     5,    // Rotated files kept; 0 keeps all
+				"age":      "0s", // Delete rotated files older than this, e.g. "720h"; 0s never
+			},
 		},
 
 		// Provider configuration
@@ -100,16 +107,30 @@ func GetCompleteDefaultConfig() map[string]interface{} {
 			"max_age":     "0s", // 0 means no expiration
 			"compression": false,
 			"storage": map[string]interface{}{
-				"type": "filesystem",
+				"type":      "filesystem",
+				"read_only": false,
 				"settings": map[string]interface{}{
 					"base_dir": filepath.Join(configDir, "sessions"),
 				},
+				"replica": map[string]interface{}{
+					"type": "",
+				},
 			},
 			"auto_recovery": map[string]interface{}{
 				"enabled":  true,
 				"interval": "30s",
 				"max_age":  "24h",
 			},
+			"limits": map[string]interface{}{
+				"max_messages":         0,       // 0 means unlimited
+				"max_attachment_bytes": 0,       // 0 means unlimited
+				"warn_at":              0.8,     // Share of a limit at which the REPL starts warning
+				"on_exceed":            "block", // block or summarize
+			},
+			"snapshots": map[string]interface{}{
+				"interval": "15m", // Minimum time between snapshots taken on save; 0s takes none
+				"keep":     20,    // Snapshots kept per session; 0 turns snapshots off
+			},
 		},
 
 		// REPL configuration
@@ -117,8 +138,30 @@ func GetCompleteDefaultConfig() map[string]interface{} {
 			"colors": map[string]interface{}{
 				"enabled": true,
 			},
-			"prompt_style": "> ",
-			"multiline":    false,
+			"render": map[string]interface{}{
+				"markdown": true, // Render responses as markdown when stdout is a TTY
+			},
+			"prompt_style":    "> ",
+			"prompt":          "", // Prompt template, e.g. "{model} [{branch}] > "; empty uses prompt_style
+			"multiline":       false,
+			"keybindings":     "emacs",    // emacs or vi
+			"bracketed_paste": true,       // Keep multi-line pastes in one message
+			"status":          "response", // Token/cost status line: response, prompt, or off
+			"spinner":         true,       // Show elapsed time while waiting for a response
+			"workspace": map[string]interface{}{
+				"enabled": true, // Load .magellai/workspace.yaml when chat starts in a project
+			},
+			"shell": map[string]interface{}{
+				"enabled": true, // Allow !command in the REPL
+			},
+			"scripts": map[string]interface{}{
+				"enabled":   true, // Run Starlark hook and command scripts (*.star) from the scripts directory
+				"directory": filepath.Join(configDir, "scripts"),
+			},
+			"retrieval": map[string]interface{}{
+				"top_k": 4,     // Excerpts retrieved from the active /index
+				"auto":  false, // Retrieve for every message without /retrieve
+			},
 			"history_file": filepath.Join(configDir, ".repl_history"),
 			"auto_save": map[string]interface{}{
 				"enabled":  true,
@@ -134,6 +177,62 @@ func GetCompleteDefaultConfig() map[string]interface{} {
 			"disabled":  []string{}, // Explicitly disabled plugins
 		},
 
+		// Prompt template configuration
+		"template": map[string]interface{}{
+			"directory": filepath.Join(configDir, "templates"),
+		},
+
+		// Content moderation configuration
+		"moderation": map[string]interface{}{
+			"enabled":         false,
+			"provider":        "local", // local or openai
+			"action":          "warn",  // block or warn
+			"check_prompts":   true,
+			"check_responses": true,
+			"blocked_terms":   []string{}, // Terms flagged by the local moderator
+			"model":           "omni-moderation-latest",
+		},
+
+		// HTTP API server configuration
+		"server": map[string]interface{}{
+			"address": "127.0.0.1:8080",
+			"token":   "", // Bearer token required on requests when set; needed to serve off loopback
+		},
+
+		// Backends for secret://<backend>/<key> references, such as
+		// provider.openai.api_key: secret://keychain/openai
+		"secrets": map[string]interface{}{
+			"command": "", // Shell command for secret://command/<key>; {key} is replaced, quoted. User or system config only
+			"vault": map[string]interface{}{
+				"address":   "", // Defaults to VAULT_ADDR
+				"namespace": "", // Defaults to VAULT_NAMESPACE
+			},
+		},
+
+		// Command telemetry, kept locally and shown by magellai stats commands
+		"telemetry": map[string]interface{}{
+			"enabled": true,
+		},
+
+		// OpenTelemetry tracing of commands, provider calls, and storage operations
+		"tracing": map[string]interface{}{
+			"enabled":  false,
+			"endpoint": "",    // OTLP/HTTP collector; defaults to OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318
+			"insecure": false, // Use http instead of https for a host:port endpoint
+			"sampling": 1.0,   // Fraction of traces exported
+		},
+
+		// Audit log of session and config changes, shown by magellai audit log
+		"audit": map[string]interface{}{
+			"enabled": false,
+			"path":    "", // Defaults to ~/.config/magellai/audit/audit.jsonl
+		},
+
+		// Config file handling
+		"config": map[string]interface{}{
+			"watch": false, // Reload config files in a running chat when they change
+		},
+
 		// Profiles configuration
 		"profiles": map[string]interface{}{
 			"fast": map[string]interface{}{
@@ -192,6 +291,14 @@ func GenerateExampleConfig() string {
 log:
   level: info      # Options: debug, info, warn, error
   format: text     # Options: text, json
+  file: ""         # Log file, e.g. ~/.config/magellai/logs/magellai.log; empty logs to stderr
+  rotation:
+    size: 10       # Rotate the log file beyond this many megabytes; 0 never
+    interval: "0s" # Rotate the log file each interval, e.g. "24h" for daily; 0s never
+    keep: 5        # Rotated files kept; 0 keeps all
+    age: "0s"      # Delete rotated files older than this, e.g. "720h"; 0s never
+# Each log setting can also be set with MAGELLAI_LOG_FORMAT, MAGELLAI_LOG_FILE,
+# MAGELLAI_LOG_ROTATION_SIZE, and so on.
 
 # Provider configuration
 provider:
@@ -269,19 +376,51 @@ session:
   compression: false
   storage:
     type: filesystem
+    read_only: false  # Browse and export sessions without writing to storage
     settings:
       base_dir: "~/.config/magellai/sessions"
+    replica:
+      type: ""  # Mirror session writes to a second backend, e.g. filesystem
+      # settings:  # Defaults to replica/ or replica.db in the storage base_dir
+      #   base_dir: "/mnt/backup/magellai"
   auto_recovery:
     enabled: true
     interval: "30s"
     max_age: "24h"
+  limits:
+    max_messages: 0          # Messages per session; 0 means unlimited
+    max_attachment_bytes: 0  # Attachment bytes per session; 0 means unlimited
+    warn_at: 0.8             # Warn once a session reaches this share of a limit
+    on_exceed: block         # block refuses the message; summarize moves to a summary branch first
+  snapshots:
+    interval: "15m"  # Snapshot a session on save at most this often; "0s" only snapshots before /reset, /merge and /undo
+    keep: 20         # Snapshots kept per session; 0 turns snapshots off
 
 # REPL configuration
 repl:
   colors:
     enabled: true
+  render:
+    markdown: true  # Render responses as markdown when stdout is a TTY
   prompt_style: "> "
+  # Prompt template; placeholders: {model} {model_name} {provider} {session}
+  # {session_short} {session_name} {branch} {messages} {tokens}
+  prompt: ""  # e.g. "{model_name} [{branch}] {tokens} > "; empty uses prompt_style
   multiline: false
+  keybindings: emacs  # Options: emacs, vi (vi shows [I]/[N] mode in the prompt)
+  bracketed_paste: true  # Keep multi-line pastes in one message instead of sending each line
+  status: response  # Token/cost status line: response (after each reply), prompt, or off
+  spinner: true  # Show elapsed time (and tokens/sec when streaming) while waiting for a response
+  workspace:
+    enabled: true  # Load .magellai/workspace.yaml (context, system_prompt, model) in projects
+  shell:
+    enabled: true  # Allow !command (and !> to capture output) in the REPL
+  scripts:
+    enabled: true  # Run Starlark scripts (*.star) with hooks (on_message_send, on_response, on_command) and commands
+    directory: "~/.config/magellai/scripts"
+  retrieval:
+    top_k: 4  # Excerpts retrieved from the active /index
+    auto: false  # Retrieve for every message without /retrieve
   history_file: "~/.config/magellai/.repl_history"
   auto_save:
     enabled: true
@@ -294,6 +433,68 @@ plugin:
   enabled: []      # Explicitly enabled plugins
   disabled: []     # Explicitly disabled plugins
 
+# Prompt templates
+template:
+  directory: "~/.config/magellai/templates"
+
+# Content moderation
+moderation:
+  enabled: false
+  provider: local  # Options: local (keyword check), openai (moderation endpoint)
+  action: warn     # Options: block, warn
+  check_prompts: true
+  check_responses: true
+  blocked_terms: []  # Terms flagged by the local moderator
+  model: "omni-moderation-latest"
+
+# HTTP API server (magellai serve)
+server:
+  address: "127.0.0.1:8080"
+  token: ""  # Bearer token required on requests when set; needed to serve off loopback
+
+# Secrets - API keys can be references instead of plaintext:
+#   secret://env/OPENAI_API_KEY              environment variable
+#   secret://keychain/openai                 OS keychain (magellai keys set)
+#   secret://vault/secret/data/magellai#openai  HashiCorp Vault KV field
+#   secret://command/openai                  output of secrets.command
+secrets:
+  command: ""  # e.g. "pass show magellai/{key}"; ignored in project .magellai.yaml files
+  vault:
+    address: ""    # Defaults to VAULT_ADDR; the token comes from VAULT_TOKEN or ~/.vault-token
+    namespace: ""
+
+# Command telemetry - runs, durations, and token usage per command, kept in
+# ~/.config/magellai/stats and shown by "magellai stats commands". Nothing is sent anywhere.
+telemetry:
+  enabled: true
+
+# OpenTelemetry tracing - spans around commands, provider calls (model, tokens,
+# latency), and storage operations, exported over OTLP/HTTP. The standard
+# OTEL_EXPORTER_OTLP_* environment variables apply when endpoint is empty.
+tracing:
+  enabled: false
+  endpoint: ""      # e.g. localhost:4318 or https://collector.example.com:4318
+  insecure: false   # Use http instead of https for a host:port endpoint
+  sampling: 1.0     # Fraction of traces exported, from 0 to 1
+
+# Audit log - an append-only record of who created, deleted, merged, or exported
+# sessions and changed configuration, and when. Shown by "magellai audit log".
+# Config values are not recorded, only the keys changed.
+audit:
+  enabled: false
+  path: ""  # Defaults to ~/.config/magellai/audit/audit.jsonl
+
+# Config file handling
+config:
+  watch: false  # Reload config files in a running chat when they change
+
+# Per-command flag defaults, used when a flag is not given on the command line
+# commands:
+#   ask:
+#     defaults:
+#       stream: true
+#       max-tokens: 2000
+
 # Profiles - Named configurations for different use cases
 profiles:
   fast:
@@ -308,6 +509,7 @@ profiles:
     description: "High-quality responses, slower"
     provider: openai
     model: o3
+    # fallbacks: [anthropic/claude-3-7-sonnet-latest]  # Tried in order when the model fails
     settings:
       temperature: 0.7
       max_tokens: 4096
@@ -320,6 +522,12 @@ profiles:
       temperature: 0.9
       max_tokens: 4096
 
+  # A profile can extend another, overriding only what differs:
+  # brainstorm:
+  #   extends: creative
+  #   settings:
+  #     temperature: 1.0
+
 # Command aliases
 aliases:
   q: exit
@@ -25,6 +25,7 @@ func TestGetCompleteDefaultConfig(t *testing.T) {
 	assert.Contains(t, config, "session")
 	assert.Contains(t, config, "repl")
 	assert.Contains(t, config, "plugin")
+	assert.Contains(t, config, "moderation")
 	assert.Contains(t, config, "profiles")
 	assert.Contains(t, config, "aliases")
 	assert.Contains(t, config, "cli")
@@ -151,6 +152,8 @@ func TestReplConfigDefaults(t *testing.T) {
 
 	assert.Equal(t, "> ", replConfig["prompt_style"])
 	assert.Equal(t, false, replConfig["multiline"])
+	assert.Equal(t, "emacs", replConfig["keybindings"])
+	assert.Equal(t, "response", replConfig["status"])
 	assert.Equal(t, expectedHistoryFile, replConfig["history_file"])
 
 	autoSave, ok := replConfig["auto_save"].(map[string]interface{})
@@ -182,6 +185,19 @@ func TestPluginConfigDefaults(t *testing.T) {
 	assert.Empty(t, disabled)
 }
 
+func TestModerationConfigDefaults(t *testing.T) {
+	config := GetCompleteDefaultConfig()
+
+	moderationConfig, ok := config["moderation"].(map[string]interface{})
+	require.True(t, ok, "moderation config should be a map")
+
+	assert.Equal(t, false, moderationConfig["enabled"])
+	assert.Equal(t, "local", moderationConfig["provider"])
+	assert.Equal(t, "warn", moderationConfig["action"])
+	assert.Equal(t, true, moderationConfig["check_prompts"])
+	assert.Equal(t, true, moderationConfig["check_responses"])
+}
+
 func TestProfilesConfigDefaults(t *testing.T) {
 	config := GetCompleteDefaultConfig()
 
@@ -0,0 +1,116 @@
+// ABOUTME: Encrypted API keys and other sensitive values in config files
+// ABOUTME: Encrypts sensitive keys in place and decrypts enc: values when a file is loaded
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/secrets"
+)
+
+// EnvConfigPassphrase holds the passphrase for values encrypted with one.
+// It is not read as a config setting.
+const EnvConfigPassphrase = "MAGELLAI_CONFIG_PASSPHRASE"
+
+// sensitiveKeyNames are the last parts of keys whose values are secret
+var sensitiveKeyNames = []string{"api_key", "token", "password"}
+
+// IsSensitiveKey reports whether key holds a secret, such as
+// provider.openai.api_key or server.token. Alias and prompt names are not
+// secrets, whatever they are called.
+func IsSensitiveKey(key string) bool {
+	for _, section := range []string{"aliases.", "repl.aliases.", "prompts."} {
+		if strings.HasPrefix(key, section) {
+			return false
+		}
+	}
+	return containsString(sensitiveKeyNames, key[strings.LastIndex(key, ".")+1:])
+}
+
+// decryptValues decrypts every encrypted string value of a parsed config
+// file, in place. Values that cannot be decrypted are left encrypted.
+func decryptValues(values map[string]interface{}, path string) {
+	passphrase := os.Getenv(EnvConfigPassphrase)
+	var walk func(value interface{}, key string) interface{}
+	walk = func(value interface{}, key string) interface{} {
+		switch v := value.(type) {
+		case string:
+			if !secrets.IsEncrypted(v) {
+				return v
+			}
+			plaintext, err := secrets.Decrypt(v, passphrase)
+			if err != nil {
+				logging.LogWarn("Failed to decrypt config value", "path", path, "key", key, "error", err)
+				return v
+			}
+			return plaintext
+		case map[string]interface{}:
+			for name, item := range v {
+				v[name] = walk(item, joinKey(key, name))
+			}
+		case []interface{}:
+			for i, item := range v {
+				v[i] = walk(item, key)
+			}
+		}
+		return value
+	}
+	walk(values, "")
+}
+
+// joinKey joins a section and a key name with a dot
+func joinKey(section, name string) string {
+	if section == "" {
+		return name
+	}
+	return section + "." + name
+}
+
+// EncryptKeys encrypts the plaintext values of sensitive keys in the config
+// file at path and returns the keys it encrypted, sorted. Empty values,
+// ${VAR} and secret:// references, and encrypted values are left alone.
+// The file is only rewritten when a key was encrypted, which drops its
+// comments.
+func EncryptKeys(path string, enc *secrets.Encrypter) ([]string, error) {
+	path = expandPath(path)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, path)
+	}
+
+	var encrypted []string
+	err := updateConfigFile(path, func(k *koanf.Koanf) error {
+		keys := k.Keys()
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := k.Get(key).(string)
+			if !ok || !IsSensitiveKey(key) || value == "" || secrets.IsEncrypted(value) ||
+				secrets.IsReference(value) || strings.Contains(value, "${") {
+				continue
+			}
+			ciphertext, err := enc.Encrypt(value)
+			if err != nil {
+				return err
+			}
+			if err := k.Set(key, ciphertext); err != nil {
+				return err
+			}
+			encrypted = append(encrypted, key)
+		}
+		if len(encrypted) == 0 {
+			return errNoChange
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logging.LogInfo("Encrypted config keys", "path", path, "method", enc.Method(), "count", len(encrypted))
+	return encrypted, nil
+}
@@ -0,0 +1,91 @@
+// ABOUTME: Tests for encrypting sensitive keys in config files
+// ABOUTME: Verifies which keys are encrypted and that encrypted values decrypt on load
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/keyring"
+	"github.com/lexlapax/magellai/pkg/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSensitiveKey(t *testing.T) {
+	assert.True(t, IsSensitiveKey("provider.openai.api_key"))
+	assert.True(t, IsSensitiveKey("server.token"))
+	assert.True(t, IsSensitiveKey("session.storage.settings.password"))
+	assert.False(t, IsSensitiveKey("model.default"))
+	assert.False(t, IsSensitiveKey("aliases.token"), "alias names are not secrets")
+}
+
+func TestEncryptKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(EnvConfigPassphrase, "correct horse")
+	path := writeUserConfig(t, `provider:
+  openai:
+    api_key: sk-openai
+  anthropic:
+    api_key: ${ANTHROPIC_API_KEY}
+  gemini:
+    api_key: secret://keychain/gemini
+server:
+  token: bearer-token
+model:
+  default: openai/gpt-4o
+aliases:
+  token: ask hello
+`)
+
+	enc, err := secrets.NewPassphraseEncrypter("correct horse")
+	require.NoError(t, err)
+	keys, err := EncryptKeys(path, enc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"provider.openai.api_key", "server.token"}, keys)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-openai")
+	assert.NotContains(t, string(data), "bearer-token")
+	assert.Contains(t, string(data), "${ANTHROPIC_API_KEY}")
+	assert.Contains(t, string(data), "secret://keychain/gemini")
+
+	// Values decrypt transparently on load
+	require.NoError(t, Init())
+	assert.Equal(t, "sk-openai", Manager.GetString("provider.openai.api_key"))
+	assert.Equal(t, "bearer-token", Manager.GetString("server.token"))
+	assert.Equal(t, "ask hello", Manager.GetString("aliases.token"))
+	assert.False(t, Manager.Exists("config.passphrase"), "the passphrase is not a setting")
+
+	// Encrypting again leaves the file alone
+	keys, err = EncryptKeys(path, enc)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	again, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	// Without the passphrase the values stay encrypted
+	t.Setenv(EnvConfigPassphrase, "")
+	require.NoError(t, Init())
+	assert.True(t, secrets.IsEncrypted(Manager.GetString("provider.openai.api_key")))
+
+	_, err = EncryptKeys(path+".missing", enc)
+	assert.ErrorIs(t, err, ErrConfigNotFound)
+}
+
+func TestEncryptKeysWithKeyring(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer keyring.SetBackend(keyring.NewMemoryBackend())()
+	path := writeUserConfig(t, "provider:\n  openai:\n    api_key: sk-openai\n")
+
+	enc, err := secrets.NewKeyringEncrypter()
+	require.NoError(t, err)
+	_, err = EncryptKeys(path, enc)
+	require.NoError(t, err)
+
+	require.NoError(t, Init())
+	assert.Equal(t, "sk-openai", Manager.GetString("provider.openai.api_key"))
+}
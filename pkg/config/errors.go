@@ -25,6 +25,9 @@ var (
 	// ErrInvalidProfile indicates an invalid profile
 	ErrInvalidProfile = errors.New("invalid profile")
 
+	// ErrProfileCycle indicates profiles that extend each other in a loop
+	ErrProfileCycle = errors.New("profile inheritance cycle")
+
 	// ErrAliasNotFound indicates the alias was not found
 	ErrAliasNotFound = errors.New("alias not found")
 
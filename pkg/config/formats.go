@@ -0,0 +1,76 @@
+// ABOUTME: Config file formats - YAML, TOML, and JSON
+// ABOUTME: Picks a koanf parser by file extension and finds config files in any supported format
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/v2"
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// ConfigExtensions are the supported config file extensions, in the order
+// they are looked for when several files with the same name exist
+var ConfigExtensions = []string{".yaml", ".yml", ".toml", ".json"}
+
+// parserFor returns the parser for a config file, chosen by its
+// extension. Files with other extensions are read as YAML.
+func parserFor(path string) koanf.Parser {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return toml.Parser()
+	case ".json":
+		return json.Parser()
+	default:
+		return yaml.Parser()
+	}
+}
+
+// Encode encodes values in the format of the config file at path, chosen
+// by its extension as for loading
+func Encode(path string, values map[string]interface{}) ([]byte, error) {
+	return parserFor(path).Marshal(values)
+}
+
+// configCandidates returns path with each supported extension in place of
+// its own, such as config.yaml, config.yml, config.toml, and config.json
+func configCandidates(path string) []string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	candidates := make([]string, len(ConfigExtensions))
+	for i, ext := range ConfigExtensions {
+		candidates[i] = base + ext
+	}
+	return candidates
+}
+
+// findConfigFile returns the first existing file among the candidates for
+// path, or "" if there is none
+func findConfigFile(path string) string {
+	var found string
+	for _, candidate := range configCandidates(path) {
+		if _, err := os.Stat(candidate); err != nil {
+			continue
+		}
+		if found != "" {
+			logging.LogDebug("Ignoring config file, another format takes precedence", "path", candidate, "using", found)
+			continue
+		}
+		found = candidate
+	}
+	return found
+}
+
+// systemConfigFile returns the system config file in whichever supported
+// format exists, defaulting to SystemConfigPath
+func systemConfigFile() string {
+	if found := findConfigFile(SystemConfigPath); found != "" {
+		return found
+	}
+	return SystemConfigPath
+}
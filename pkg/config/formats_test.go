@@ -0,0 +1,113 @@
+// ABOUTME: Tests for loading and saving config files in YAML, TOML, and JSON
+// ABOUTME: Verifies parser selection, file discovery, precedence, and persistence per format
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFileFormats(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MAGELLAI_TEST_TOKEN", "from-env")
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"toml", "config.toml", "[model]\ndefault = \"openai/gpt-4o-mini\"\n\n[server]\ntoken = \"${MAGELLAI_TEST_TOKEN}\"\n\n[repl.auto_save]\ninterval = \"2m\"\n"},
+		{"json", "config.json", `{"model": {"default": "openai/gpt-4o-mini"}, "server": {"token": "${MAGELLAI_TEST_TOKEN}"}, "repl": {"auto_save": {"interval": "2m"}}}`},
+		{"yml", "config.yml", "model:\n  default: openai/gpt-4o-mini\nserver:\n  token: ${MAGELLAI_TEST_TOKEN}\nrepl:\n  auto_save:\n    interval: 2m\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, Init())
+			path := filepath.Join(dir, tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+
+			require.NoError(t, Manager.LoadFile(path))
+			assert.Equal(t, "openai/gpt-4o-mini", Manager.GetString("model.default"))
+			assert.Equal(t, "from-env", Manager.GetString("server.token"))
+			assert.Equal(t, "2m", Manager.GetString("repl.auto_save.interval"))
+			assert.True(t, Manager.GetBool("repl.auto_save.enabled"), "defaults are kept")
+		})
+	}
+
+	t.Run("reports parse errors", func(t *testing.T) {
+		require.NoError(t, Init())
+		path := filepath.Join(dir, "broken.toml")
+		require.NoError(t, os.WriteFile(path, []byte("[model\n"), 0600))
+		assert.Error(t, Manager.LoadFile(path))
+	})
+}
+
+func TestUserConfigFormats(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "magellai")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+
+	// Without a user config, config.yaml is the default
+	assert.Equal(t, filepath.Join(configDir, "config.yaml"), UserConfigPath())
+
+	tomlPath := filepath.Join(configDir, "config.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte("[log]\nlevel = \"debug\"\n"), 0600))
+	assert.Equal(t, tomlPath, UserConfigPath())
+
+	require.NoError(t, Init())
+	assert.Equal(t, "debug", Manager.GetString("log.level"))
+	assert.Contains(t, Manager.GetLoadedFiles(), tomlPath)
+
+	// Persisted values are written back as TOML
+	require.NoError(t, Manager.PersistValue("model.default", "anthropic/claude-3-5-haiku-latest"))
+	data, err := os.ReadFile(tomlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[model]")
+	assert.Contains(t, string(data), "default = 'anthropic/claude-3-5-haiku-latest'")
+	assert.Contains(t, string(data), "level = 'debug'")
+
+	// YAML takes precedence when several formats exist
+	yamlPath := filepath.Join(configDir, "config.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("log:\n  level: warn\n"), 0600))
+	assert.Equal(t, yamlPath, UserConfigPath())
+	require.NoError(t, Init())
+	assert.Equal(t, "warn", Manager.GetString("log.level"))
+}
+
+func TestProjectConfigFormats(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	sub := filepath.Join(project, "src", "pkg")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	path := filepath.Join(project, ".magellai.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"model": {"default": "gemini/gemini-2.0-flash"}}`), 0600))
+
+	c := &Config{currentDir: sub}
+	assert.Equal(t, path, c.findProjectConfig())
+}
+
+func TestEncode(t *testing.T) {
+	values := map[string]interface{}{
+		"model": map[string]interface{}{"default": "openai/gpt-4o"},
+	}
+	for _, file := range []string{"config.yaml", "config.toml", "config.json"} {
+		data, err := Encode(file, values)
+		require.NoError(t, err, file)
+		decoded, err := parserFor(file).Unmarshal(data)
+		require.NoError(t, err, file)
+		assert.Equal(t, values, decoded, file)
+	}
+
+	// The complete defaults encode in every format
+	for _, file := range []string{"config.toml", "config.json"} {
+		_, err := Encode(file, GetCompleteDefaultConfig())
+		assert.NoError(t, err, file)
+	}
+}
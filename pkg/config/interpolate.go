@@ -0,0 +1,74 @@
+// ABOUTME: Environment variable interpolation for values in config files
+// ABOUTME: Expands ${VAR} and ${VAR:-fallback} references when a config file is loaded
+
+package config
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// envRef matches ${VAR} and ${VAR:-fallback}, optionally escaped as $${...}
+var envRef = regexp.MustCompile(`\$(\$)?\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// uninterpolatedKeys are the sections left as written, because their
+// values use ${name} for their own parameters
+var uninterpolatedKeys = map[string]bool{
+	"aliases":      true,
+	"repl.aliases": true,
+}
+
+// interpolateEnv expands environment variable references in s. An unset or
+// empty variable expands to its fallback, or to nothing. $${VAR} is kept
+// as the literal ${VAR}.
+func interpolateEnv(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRef.FindStringSubmatch(ref)
+		if m[1] != "" {
+			return ref[1:]
+		}
+		if value := os.Getenv(m[2]); value != "" {
+			return value
+		}
+		if !strings.Contains(ref, ":-") {
+			logging.LogWarn("Config references an unset environment variable", "variable", m[2])
+		}
+		return m[3]
+	})
+}
+
+// interpolateValues expands environment variable references in every string
+// value of a parsed config file, in place
+func interpolateValues(values map[string]interface{}, prefix string) {
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if uninterpolatedKeys[path] {
+			continue
+		}
+		values[key] = interpolateValue(value, path)
+	}
+}
+
+// interpolateValue expands references in a single parsed value
+func interpolateValue(value interface{}, path string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return interpolateEnv(v)
+	case map[string]interface{}:
+		interpolateValues(v, path)
+	case []interface{}:
+		for i, item := range v {
+			v[i] = interpolateValue(item, path)
+		}
+	}
+	return value
+}
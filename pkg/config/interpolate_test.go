@@ -0,0 +1,67 @@
+// ABOUTME: Tests for environment variable interpolation in config files
+// ABOUTME: Verifies ${VAR}, fallbacks, escapes, and that alias definitions are left alone
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolateEnv(t *testing.T) {
+	t.Setenv("MAGELLAI_TEST_KEY", "sk-test")
+	t.Setenv("MAGELLAI_TEST_EMPTY", "")
+
+	tests := map[string]string{
+		"${MAGELLAI_TEST_KEY}":                 "sk-test",
+		"Bearer ${MAGELLAI_TEST_KEY}!":         "Bearer sk-test!",
+		"${MAGELLAI_TEST_UNSET:-fallback}":     "fallback",
+		"${MAGELLAI_TEST_EMPTY:-fallback}":     "fallback",
+		"${MAGELLAI_TEST_KEY:-fallback}":       "sk-test",
+		"${MAGELLAI_TEST_UNSET}":               "",
+		"${MAGELLAI_TEST_UNSET:-}":             "",
+		"$${MAGELLAI_TEST_KEY}":                "${MAGELLAI_TEST_KEY}",
+		"$MAGELLAI_TEST_KEY and $5":            "$MAGELLAI_TEST_KEY and $5",
+		"${MAGELLAI_TEST_UNSET:-~/.cache/a b}": "~/.cache/a b",
+	}
+	for input, want := range tests {
+		assert.Equal(t, want, interpolateEnv(input), input)
+	}
+}
+
+func TestLoadFileInterpolation(t *testing.T) {
+	t.Setenv("MAGELLAI_TEST_KEY", "sk-test")
+	t.Setenv("MAGELLAI_TEST_DIR", "/data")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+provider:
+  openai:
+    api_key: ${MAGELLAI_TEST_KEY}
+    base_url: ${MAGELLAI_TEST_URL:-https://example.com/v1}
+session:
+  storage:
+    settings:
+      base_dir: ${MAGELLAI_TEST_DIR}/sessions
+plugins:
+  paths:
+    - ${MAGELLAI_TEST_DIR}/plugins
+aliases:
+  review: ask --model ${model:-openai/gpt-4o} "$1"
+`), 0600))
+
+	c := &Config{koanf: koanf.New(".")}
+	require.NoError(t, c.LoadFile(path))
+
+	assert.Equal(t, "sk-test", c.GetString("provider.openai.api_key"))
+	assert.Equal(t, "https://example.com/v1", c.GetString("provider.openai.base_url"))
+	assert.Equal(t, "/data/sessions", c.GetString("session.storage.settings.base_dir"))
+	assert.Equal(t, []string{"/data/plugins"}, c.GetStringSlice("plugins.paths"))
+	assert.Equal(t, `ask --model ${model:-openai/gpt-4o} "$1"`, c.GetString("aliases.review"))
+	assert.Equal(t, []string{path}, c.GetLoadedFiles())
+}
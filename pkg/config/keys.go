@@ -0,0 +1,416 @@
+// ABOUTME: Catalogue of known configuration keys, their types, and allowed values
+// ABOUTME: Checks config files for unknown keys and mistyped values and suggests corrections
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+)
+
+// Value types of config keys
+const (
+	typeString   = "string"
+	typeBool     = "bool"
+	typeInt      = "integer"
+	typeNumber   = "number"
+	typeDuration = "duration"
+	typeList     = "list"
+)
+
+// Allowed values shared by validation and the key catalogue
+var (
+	validLogLevels          = []string{"debug", "info", "warn", "error"}
+	validLogFormats         = []string{"text", "json"}
+	validProviders          = []string{"openai", "anthropic", "gemini"}
+	validOutputFormats      = []string{"text", "json", "markdown"}
+	validKeybindings        = []string{"emacs", "vi"}
+	validStatusPlacements   = []string{"response", "prompt", "off"}
+	validModerationProvider = []string{"local", "openai"}
+	validModerationActions  = []string{"block", "warn"}
+	validLimitActions       = []string{"block", "summarize"}
+)
+
+// knownProviderNames are provider sections that are not typos, including
+// ones without validation of their own
+var knownProviderNames = []string{"openai", "anthropic", "gemini", "ollama", "mock"}
+
+// keySpec describes the value a known config key takes
+type keySpec struct {
+	Type    string
+	Allowed []string
+}
+
+// openSections hold user-defined names, so any key below them is accepted
+var openSections = []string{
+	"aliases",
+	"commands",
+	"repl.aliases",
+	"profiles",
+	"prompts",
+	"session.storage.settings",
+	"session.storage.replica.settings",
+}
+
+// extraKeys are known keys that have no default value
+var extraKeys = map[string]keySpec{
+	"defaults.system_prompt":           {Type: typeString},
+	"model.inventory_url":              {Type: typeString},
+	"output":                           {Type: typeString},
+	"profile.current":                  {Type: typeString},
+	"session.storage.settings.db_path": {Type: typeString},
+	"stream":                           {Type: typeBool},
+	"verbosity":                        {Type: typeString, Allowed: validLogLevels},
+}
+
+// allowedValues restricts known keys to a set of values
+var allowedValues = map[string][]string{
+	"log.level":                validLogLevels,
+	"log.format":               validLogFormats,
+	"provider.default":         validProviders,
+	"output.format":            validOutputFormats,
+	"repl.keybindings":         validKeybindings,
+	"repl.status":              validStatusPlacements,
+	"moderation.provider":      validModerationProvider,
+	"moderation.action":        validModerationActions,
+	"session.limits.on_exceed": validLimitActions,
+}
+
+// modelSettingKeys are the keys of each model.settings.<model> section
+var modelSettingKeys = map[string]keySpec{
+	"temperature":       {Type: typeNumber},
+	"max_tokens":        {Type: typeInt},
+	"top_p":             {Type: typeNumber},
+	"frequency_penalty": {Type: typeNumber},
+	"presence_penalty":  {Type: typeNumber},
+	"stop_sequences":    {Type: typeList},
+}
+
+var (
+	catalogueOnce sync.Once
+	knownKeys     map[string]keySpec // keys with provider names replaced by *
+	knownSections map[string]bool    // every prefix of a known key
+)
+
+// keyCatalogue returns the known keys and sections, built from the defaults
+func keyCatalogue() (map[string]keySpec, map[string]bool) {
+	catalogueOnce.Do(func() {
+		knownKeys = make(map[string]keySpec)
+		k := koanf.New(".")
+		_ = k.Load(confmap.Provider(GetCompleteDefaultConfig(), "."), nil)
+		for key, value := range k.All() {
+			if inOpenSection(key) || strings.HasPrefix(key, "model.settings.") {
+				continue
+			}
+			knownKeys[catalogueKey(key)] = keySpec{Type: typeOf(key, value), Allowed: allowedValues[key]}
+		}
+		for key, spec := range extraKeys {
+			knownKeys[key] = spec
+		}
+
+		knownSections = map[string]bool{"model.settings": true}
+		for _, section := range openSections {
+			knownSections[section] = true
+		}
+		for key := range knownKeys {
+			for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+				knownSections[key[:i]] = true
+			}
+		}
+	})
+	return knownKeys, knownSections
+}
+
+// catalogueKey replaces the provider name in provider.<name>.<key> with *,
+// since every provider takes the same kind of settings
+func catalogueKey(key string) string {
+	parts := strings.Split(key, ".")
+	if len(parts) > 2 && parts[0] == "provider" {
+		parts[1] = "*"
+	}
+	return strings.Join(parts, ".")
+}
+
+// typeOf returns the value type of a key from its default value
+func typeOf(key string, value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return typeBool
+	case int, int64:
+		return typeInt
+	case float64:
+		return typeNumber
+	case []string, []interface{}:
+		return typeList
+	}
+	last := key[strings.LastIndex(key, ".")+1:]
+	if last == "timeout" || last == "interval" || last == "max_age" {
+		return typeDuration
+	}
+	return typeString
+}
+
+// inOpenSection reports whether key is below a section of user-defined names
+func inOpenSection(key string) bool {
+	for _, section := range openSections {
+		if strings.HasPrefix(key, section+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupKey returns the spec of a known key. Keys in open sections are
+// known but have no spec.
+func lookupKey(key string) (keySpec, bool) {
+	if inOpenSection(key) {
+		return keySpec{}, true
+	}
+	if rest, ok := strings.CutPrefix(key, "model.settings."); ok {
+		// Model names can contain dots, so only the last part is checked
+		if strings.Contains(rest, ".extra.") || strings.HasSuffix(rest, ".extra") {
+			return keySpec{}, true
+		}
+		spec, ok := modelSettingKeys[rest[strings.LastIndex(rest, ".")+1:]]
+		return spec, ok && strings.Contains(rest, ".")
+	}
+	keys, _ := keyCatalogue()
+	spec, ok := keys[catalogueKey(key)]
+	return spec, ok
+}
+
+// fileKeyErrors checks the keys and values in a config file
+func fileKeyErrors(path string) []ValidationError {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	values, err := parserFor(path).Unmarshal(data)
+	if err != nil {
+		return []ValidationError{{File: path, Error: fmt.Sprintf("cannot parse file: %v", err)}}
+	}
+	interpolateValues(values, "")
+	k := koanf.New(".")
+	if err := k.Load(confmap.Provider(values, ""), nil); err != nil {
+		return []ValidationError{{File: path, Error: fmt.Sprintf("cannot load file: %v", err)}}
+	}
+
+	flat := k.All()
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errors []ValidationError
+	for _, key := range keys {
+		if verr, ok := checkKey(key, flat[key]); !ok {
+			verr.File = path
+			errors = append(errors, verr)
+		}
+	}
+	return errors
+}
+
+// checkKey checks one key and its value from a config file
+func checkKey(key string, value interface{}) (ValidationError, bool) {
+	if name, ok := providerName(key); ok {
+		if suggestion := closest(name, knownProviderNames); suggestion != "" {
+			return ValidationError{
+				Field:      key,
+				Value:      value,
+				Error:      fmt.Sprintf("unknown provider %q", name),
+				Suggestion: strings.Replace(key, name, suggestion, 1),
+			}, false
+		}
+	}
+
+	spec, known := lookupKey(key)
+	if !known {
+		_, sections := keyCatalogue()
+		if sections[key] {
+			return ValidationError{
+				Field:    key,
+				Value:    value,
+				Error:    "expected a section of settings, not a value",
+				Expected: "map",
+			}, false
+		}
+		return ValidationError{
+			Field:      key,
+			Value:      value,
+			Error:      "unknown key",
+			Suggestion: suggestKey(key),
+		}, false
+	}
+	if spec.Type == "" || matchesType(spec.Type, value) {
+		return ValidationError{}, true
+	}
+	return ValidationError{
+		Field:    key,
+		Value:    value,
+		Error:    "wrong type",
+		Expected: spec.Type,
+		Allowed:  spec.Allowed,
+	}, false
+}
+
+// providerName returns the name in provider.<name>.<key> when it is not a
+// known provider. Unknown names are allowed unless they look like a typo.
+func providerName(key string) (string, bool) {
+	parts := strings.Split(key, ".")
+	if len(parts) < 3 || parts[0] != "provider" {
+		return "", false
+	}
+	return parts[1], !containsString(knownProviderNames, parts[1])
+}
+
+// matchesType reports whether a value from a config file has the expected
+// type. Strings are accepted where they convert, as ${VAR} references
+// always produce strings.
+func matchesType(expected string, value interface{}) bool {
+	s, isString := value.(string)
+	switch expected {
+	case typeString:
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+		return true
+	case typeBool:
+		if isString {
+			_, err := strconv.ParseBool(s)
+			return err == nil
+		}
+		_, ok := value.(bool)
+		return ok
+	case typeInt:
+		switch v := value.(type) {
+		case int, int64:
+			return true
+		case float64:
+			return v == float64(int64(v))
+		}
+		if isString {
+			_, err := strconv.Atoi(s)
+			return err == nil
+		}
+		return false
+	case typeNumber:
+		switch value.(type) {
+		case int, int64, float64:
+			return true
+		}
+		if isString {
+			_, err := strconv.ParseFloat(s, 64)
+			return err == nil
+		}
+		return false
+	case typeDuration:
+		switch value.(type) {
+		case int, int64, float64:
+			return true
+		}
+		if isString {
+			_, err := time.ParseDuration(s)
+			return err == nil
+		}
+		return false
+	case typeList:
+		switch value.(type) {
+		case []interface{}, []string:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// suggestKey returns the known key closest to an unknown one, or ""
+func suggestKey(key string) string {
+	if strings.HasPrefix(key, "model.settings.") {
+		i := strings.LastIndex(key, ".")
+		names := make([]string, 0, len(modelSettingKeys))
+		for name := range modelSettingKeys {
+			names = append(names, name)
+		}
+		if best := closest(key[i+1:], names); best != "" {
+			return key[:i+1] + best
+		}
+		return ""
+	}
+
+	keys, _ := keyCatalogue()
+	parts := strings.Split(key, ".")
+
+	var candidates []string
+	for known := range keys {
+		// Compare provider settings under the provider name used
+		if strings.HasPrefix(known, "provider.*.") && len(parts) > 2 {
+			known = "provider." + parts[1] + known[len("provider.*"):]
+		}
+		candidates = append(candidates, known)
+	}
+	for _, section := range openSections {
+		depth := strings.Count(section, ".") + 1
+		if len(parts) > depth {
+			candidates = append(candidates, section+"."+strings.Join(parts[depth:], "."))
+		}
+	}
+	return closest(key, candidates)
+}
+
+// closest returns the candidate nearest to s by edit distance, if it is
+// close enough to be a likely typo
+func closest(s string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		d := editDistance(s, candidate)
+		if bestDistance < 0 || d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	limit := len(s) / 5
+	if limit < 2 {
+		limit = 2
+	}
+	if bestDistance <= 0 || bestDistance > limit {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the number of insertions, deletions, substitutions,
+// and transpositions of adjacent characters that turn a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
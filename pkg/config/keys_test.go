@@ -0,0 +1,136 @@
+// ABOUTME: Tests for the known key catalogue and per-file config validation
+// ABOUTME: Verifies unknown key detection, type checks, and did-you-mean suggestions
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("model", "model"))
+	assert.Equal(t, 1, editDistance("modle", "model"), "transpositions count once")
+	assert.Equal(t, 1, editDistance("mode", "model"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
+
+func TestClosest(t *testing.T) {
+	assert.Equal(t, "info", closest("inf", validLogLevels))
+	assert.Equal(t, "emacs", closest("emcas", validKeybindings))
+	assert.Equal(t, "", closest("verbose", validLogLevels), "too far to be a typo")
+	assert.Equal(t, "", closest("info", validLogLevels), "exact matches need no suggestion")
+}
+
+func TestCheckKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		value      interface{}
+		valid      bool
+		suggestion string
+		expected   string
+	}{
+		{"known key", "model.default", "openai/gpt-4o", true, "", ""},
+		{"misspelled section", "modle.default", "openai/gpt-4o", false, "model.default", ""},
+		{"misspelled leaf", "repl.keybinding", "vi", false, "repl.keybindings", ""},
+		{"unknown key", "completely.different", 1, false, "", ""},
+		{"wrong type", "provider.openai.max_retries", "three", false, "", typeInt},
+		{"string that converts", "repl.auto_save.enabled", "true", true, "", ""},
+		{"JSON integer", "provider.openai.max_retries", float64(3), true, "", ""},
+		{"bad duration", "provider.openai.timeout", "soon", false, "", typeDuration},
+		{"list", "plugin.path", "not-a-list", false, "", typeList},
+		{"value for a section", "model", "gpt-4o", false, "", "map"},
+		{"open section", "aliases.anything", "ask", true, "", ""},
+		{"profile settings", "profiles.work.settings.temperature", 0.2, true, "", ""},
+		{"model settings", "model.settings.openai/gpt-4.1.temperature", 0.2, true, "", ""},
+		{"misspelled model setting", "model.settings.openai/gpt-4o.temprature", 0.2, false, "model.settings.openai/gpt-4o.temperature", ""},
+		{"other provider", "provider.ollama.base_url", "http://localhost:11434", true, "", ""},
+		{"misspelled provider", "provider.opnai.api_key", "sk", false, "provider.openai.api_key", ""},
+		{"runtime key", "stream", true, true, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr, ok := checkKey(tt.key, tt.value)
+			assert.Equal(t, tt.valid, ok, verr.String())
+			if !tt.valid {
+				assert.Equal(t, tt.key, verr.Field)
+				assert.Equal(t, tt.suggestion, verr.Suggestion)
+				assert.Equal(t, tt.expected, verr.Expected)
+			}
+		})
+	}
+}
+
+func TestFileKeyErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("example config is valid", func(t *testing.T) {
+		path := filepath.Join(dir, "example.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(GenerateExampleConfig()), 0600))
+		assert.Empty(t, fileKeyErrors(path))
+	})
+
+	t.Run("reports problems with the file", func(t *testing.T) {
+		path := filepath.Join(dir, "config.toml")
+		require.NoError(t, os.WriteFile(path, []byte("[modle]\ndefault = \"openai/gpt-4o\"\n\n[repl]\nspinner = \"sometimes\"\n"), 0600))
+
+		errors := fileKeyErrors(path)
+		require.Len(t, errors, 2)
+		assert.Equal(t, "modle.default", errors[0].Field)
+		assert.Equal(t, path, errors[0].File)
+		assert.Equal(t, path+`: modle.default: unknown key; did you mean "model.default"?`, errors[0].String())
+		assert.Equal(t, path+`: repl.spinner: wrong type, expected bool, got "sometimes"`, errors[1].String())
+	})
+
+	t.Run("reports parse errors", func(t *testing.T) {
+		path := filepath.Join(dir, "broken.json")
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+		errors := fileKeyErrors(path)
+		require.Len(t, errors, 1)
+		assert.Contains(t, errors[0].Error, "cannot parse file")
+	})
+}
+
+func TestValidateReportsDetails(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeUserConfig(t, "modle:\n  default: openai/gpt-4o\nlog:\n  level: inf\n")
+	require.NoError(t, Init())
+
+	err := Manager.Validate()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrValidationFailed)
+	assert.Contains(t, err.Error(), path+`: modle.default: unknown key; did you mean "model.default"?`)
+	assert.Contains(t, err.Error(), `log.level: invalid log level, must be one of: [debug info warn error]; did you mean "info"?`)
+
+	var levelErr ValidationError
+	for _, verr := range Manager.ValidationErrors() {
+		if verr.Field == "log.level" {
+			levelErr = verr
+		}
+	}
+	assert.Equal(t, validLogLevels, levelErr.Allowed)
+}
+
+func TestValidateLogRotation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	writeUserConfig(t, "log:\n  file: ~/magellai.log\n  rotation:\n    interval: daily\n    keep: -1\n    age: 720h\n")
+	t.Setenv("MAGELLAI_LOG_ROTATION_SIZE", "25")
+	t.Setenv("MAGELLAI_TRACING_SAMPLING", "1.5")
+	require.NoError(t, Init())
+
+	assert.Equal(t, 25, Manager.GetInt("log.rotation.size"), "env vars set log settings")
+	fields := map[string]bool{}
+	for _, verr := range Manager.ValidationErrors() {
+		fields[verr.Field] = true
+	}
+	assert.True(t, fields["log.rotation.interval"])
+	assert.True(t, fields["log.rotation.keep"])
+	assert.False(t, fields["log.rotation.age"])
+	assert.False(t, fields["log.rotation.size"])
+	assert.True(t, fields["tracing.sampling"])
+}
@@ -0,0 +1,108 @@
+// ABOUTME: Persists individual configuration values to the user config file
+// ABOUTME: Updates keys in ~/.config/magellai/config.yaml (or .toml/.json) as well as the loaded configuration
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// UserConfigPath returns the path of the user config file: config.yaml,
+// config.yml, config.toml, or config.json, whichever exists, defaulting to
+// config.yaml
+func UserConfigPath() string {
+	path := expandPath(filepath.Join(UserConfigDir, UserConfigFile))
+	if found := findConfigFile(path); found != "" {
+		return found
+	}
+	return path
+}
+
+// PersistValue sets key in the loaded configuration and saves it to the
+// user config file, creating the file if needed. The file is rewritten, so
+// comments in it are not kept.
+func (c *Config) PersistValue(key string, value interface{}) error {
+	if err := c.updateUserConfig(func(k *koanf.Koanf) error {
+		return k.Set(key, value)
+	}); err != nil {
+		return err
+	}
+	if err := c.setValue(key, value); err != nil {
+		return err
+	}
+
+	// The user config file holds the value now, so reloads read it from there
+	c.mu.Lock()
+	delete(c.overrides, key)
+	c.recordKeySource(key, ValueSource{Layer: LayerFile, Detail: UserConfigPath()})
+	c.mu.Unlock()
+	publishConfigChanged(key, false, true)
+	return nil
+}
+
+// PersistDelete removes key from the loaded configuration and from the user
+// config file
+func (c *Config) PersistDelete(key string) error {
+	if err := c.updateUserConfig(func(k *koanf.Koanf) error {
+		k.Delete(key)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.koanf.Delete(key)
+	delete(c.overrides, key)
+	c.mu.Unlock()
+	c.notifyWatchers()
+	publishConfigChanged(key, true, true)
+	return nil
+}
+
+// errNoChange is returned by an update to leave a config file unwritten
+var errNoChange = errors.New("no change")
+
+// updateUserConfig loads the user config file, applies update, and writes it back
+func (c *Config) updateUserConfig(update func(*koanf.Koanf) error) error {
+	return updateConfigFile(UserConfigPath(), update)
+}
+
+// updateConfigFile loads the config file at path, applies update, and
+// writes it back in the same format. The file is created if needed, and
+// left alone if update returns errNoChange.
+func updateConfigFile(path string, update func(*koanf.Koanf) error) error {
+	k := koanf.New(".")
+	if _, err := os.Stat(path); err == nil {
+		if err := k.Load(file.Provider(path), parserFor(path)); err != nil {
+			return fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+	}
+
+	if err := update(k); err != nil {
+		if errors.Is(err, errNoChange) {
+			return nil
+		}
+		return fmt.Errorf("failed to update config file %s: %w", path, err)
+	}
+
+	data, err := k.Marshal(parserFor(path))
+	if err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+
+	logging.LogDebug("Updated config file", "path", path)
+	return nil
+}
@@ -0,0 +1,54 @@
+// ABOUTME: Tests for persisting configuration values to the user config file
+// ABOUTME: Verifies values are written, kept alongside existing keys, deleted, and announced as changes
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistValue(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := UserConfigPath()
+	assert.Equal(t, filepath.Join(home, ".config", "magellai", "config.yaml"), path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\n"), 0600))
+
+	require.NoError(t, Init())
+	cfg := Manager
+
+	var changes []domain.Event
+	unsubscribe := domain.DefaultEventBus.Subscribe(domain.EventConfigChanged, func(e domain.Event) { changes = append(changes, e) })
+	defer unsubscribe()
+
+	require.NoError(t, cfg.PersistValue("prompts.greet.content", "Hello {{name}}"))
+	assert.Equal(t, "Hello {{name}}", cfg.GetString("prompts.greet.content"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Hello {{name}}")
+	assert.Contains(t, string(data), "level: debug")
+
+	require.NoError(t, cfg.PersistDelete("prompts.greet"))
+	assert.False(t, cfg.Exists("prompts.greet.content"))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "greet")
+	assert.Contains(t, string(data), "level: debug")
+
+	// Each change is announced once, without its value
+	require.NoError(t, cfg.SetValue("stream", true))
+	require.Len(t, changes, 3)
+	assert.Equal(t, map[string]interface{}{"key": "prompts.greet.content", "removed": false, "persisted": true}, changes[0].Data)
+	assert.Equal(t, map[string]interface{}{"key": "prompts.greet", "removed": true, "persisted": true}, changes[1].Data)
+	assert.Equal(t, map[string]interface{}{"key": "stream", "removed": false, "persisted": false}, changes[2].Data)
+}
@@ -0,0 +1,84 @@
+// ABOUTME: Profile inheritance - profiles that extend other profiles
+// ABOUTME: Resolves a profile's extends chain into its effective settings, detecting cycles
+
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// GetProfile returns a profile's effective configuration, merged over the
+// profiles it extends. Extends is the profile's own extends setting.
+func (c *Config) GetProfile(name string) (*ProfileConfig, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	resolved, err := c.resolveProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	var profile ProfileConfig
+	if err := resolved.Unmarshal("", &profile); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal profile: %w", err)
+	}
+	profile.Extends = c.koanf.String(fmt.Sprintf("profiles.%s.extends", name))
+	return &profile, nil
+}
+
+// GetProfileDefinition returns a profile as written, without the settings
+// it inherits
+func (c *Config) GetProfileDefinition(name string) (*ProfileConfig, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key := fmt.Sprintf("profiles.%s", name)
+	if !c.koanf.Exists(key) {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	var profile ProfileConfig
+	if err := c.koanf.Unmarshal(key, &profile); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// resolveProfile merges a profile over the profiles it extends, so its own
+// values win and settings maps combine (not thread-safe)
+func (c *Config) resolveProfile(name string) (*koanf.Koanf, error) {
+	chain, err := c.profileChain(name)
+	if err != nil {
+		return nil, err
+	}
+	resolved := koanf.New(".")
+	for _, profile := range chain {
+		if err := resolved.Merge(c.koanf.Cut(fmt.Sprintf("profiles.%s", profile))); err != nil {
+			return nil, fmt.Errorf("failed to merge profile '%s': %w", profile, err)
+		}
+	}
+	resolved.Delete("extends")
+	return resolved, nil
+}
+
+// profileChain returns the profiles that name extends, most basic first
+// and ending with name itself (not thread-safe)
+func (c *Config) profileChain(name string) ([]string, error) {
+	var chain []string
+	for profile := name; profile != ""; profile = c.koanf.String(fmt.Sprintf("profiles.%s.extends", profile)) {
+		if slices.Contains(chain, profile) {
+			return nil, fmt.Errorf("%w: %s -> %s", ErrProfileCycle, strings.Join(chain, " -> "), profile)
+		}
+		if !c.koanf.Exists(fmt.Sprintf("profiles.%s", profile)) {
+			if profile == name {
+				return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+			}
+			return nil, fmt.Errorf("%w: %s (extended by %s)", ErrProfileNotFound, profile, chain[len(chain)-1])
+		}
+		chain = append(chain, profile)
+	}
+	slices.Reverse(chain)
+	return chain, nil
+}
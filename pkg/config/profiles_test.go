@@ -0,0 +1,98 @@
+// ABOUTME: Tests for profile inheritance through extends
+// ABOUTME: Verifies merged settings, cycle and missing-base errors, and applying extended profiles
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const inheritingProfiles = `profiles:
+  base:
+    description: Shared settings
+    provider: openai
+    model: gpt-4o
+    settings:
+      temperature: 0.2
+      max_tokens: 1000
+    fallbacks: [anthropic/claude-3-5-haiku-latest]
+  work:
+    extends: base
+    model: gpt-4.1
+    settings:
+      temperature: 0.7
+  focused:
+    extends: work
+    description: Work, but terse
+    settings:
+      max_tokens: 200
+  loop-a:
+    extends: loop-b
+  loop-b:
+    extends: loop-a
+  orphan:
+    extends: missing
+`
+
+func TestGetProfileInheritance(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	writeUserConfig(t, inheritingProfiles)
+	require.NoError(t, Init())
+
+	profile, err := Manager.GetProfile("focused")
+	require.NoError(t, err)
+	assert.Equal(t, "work", profile.Extends)
+	assert.Equal(t, "Work, but terse", profile.Description)
+	assert.Equal(t, "openai", profile.Provider, "inherited from base")
+	assert.Equal(t, "gpt-4.1", profile.Model, "inherited from work")
+	assert.Equal(t, 0.7, profile.Settings["temperature"])
+	assert.Equal(t, 200, profile.Settings["max_tokens"])
+	assert.Equal(t, []string{"anthropic/claude-3-5-haiku-latest"}, profile.Fallbacks)
+
+	definition, err := Manager.GetProfileDefinition("focused")
+	require.NoError(t, err)
+	assert.Empty(t, definition.Provider)
+	assert.Equal(t, map[string]interface{}{"max_tokens": 200}, definition.Settings)
+
+	_, err = Manager.GetProfile("loop-a")
+	assert.ErrorIs(t, err, ErrProfileCycle)
+	assert.Contains(t, err.Error(), "loop-a -> loop-b -> loop-a")
+
+	_, err = Manager.GetProfile("orphan")
+	assert.ErrorIs(t, err, ErrProfileNotFound)
+	assert.Contains(t, err.Error(), "missing (extended by orphan)")
+
+	_, err = Manager.GetProfile("nonexistent")
+	assert.ErrorIs(t, err, ErrProfileNotFound)
+}
+
+func TestSetProfileInheritance(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	writeUserConfig(t, inheritingProfiles)
+	require.NoError(t, Init())
+
+	require.NoError(t, Manager.SetProfile("work"))
+	assert.Equal(t, "openai", Manager.GetString("provider"))
+	assert.Equal(t, "gpt-4.1", Manager.GetString("model"))
+	assert.False(t, Manager.Exists("extends"))
+
+	assert.ErrorIs(t, Manager.SetProfile("loop-b"), ErrProfileCycle)
+}
+
+func TestValidateProfileInheritance(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	writeUserConfig(t, inheritingProfiles)
+	require.NoError(t, Init())
+
+	fields := map[string]ValidationError{}
+	for _, verr := range Manager.ValidationErrors() {
+		fields[verr.Field] = verr
+	}
+	assert.Contains(t, fields["profiles.loop-a.extends"].Error, "profile inheritance cycle")
+	assert.Contains(t, fields["profiles.orphan.extends"].Error, "profile not found: missing")
+	assert.NotContains(t, fields, "profiles.focused")
+	assert.NotContains(t, fields, "profiles.focused.extends")
+}
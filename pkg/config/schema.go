@@ -44,8 +44,19 @@ type Schema struct {
 
 // LogConfig represents logging configuration
 type LogConfig struct {
-	Level  string `koanf:"level"`
-	Format string `koanf:"format"`
+	Level    string            `koanf:"level"`
+	Format   string            `koanf:"format"`
+	File     string            `koanf:"file"`
+	Rotation LogRotationConfig `koanf:"rotation"`
+}
+
+// LogRotationConfig represents when the log file is rotated and how long
+// rotated files are kept
+type LogRotationConfig struct {
+	Size     int    `koanf:"size"`     // Megabytes
+	Interval string `koanf:"interval"` // Duration
+	Keep     int    `koanf:"keep"`
+	Age      string `koanf:"age"` // Duration
 }
 
 // ProviderConfig represents provider configuration
@@ -87,16 +98,40 @@ type OutputConfig struct {
 
 // SessionConfig represents session storage settings
 type SessionConfig struct {
-	Directory   string        `koanf:"directory"`
-	AutoSave    bool          `koanf:"autosave"`
-	MaxAge      time.Duration `koanf:"max_age"`
-	Compression bool          `koanf:"compression"`
-	Storage     StorageConfig `koanf:"storage"`
+	Directory   string          `koanf:"directory"`
+	AutoSave    bool            `koanf:"autosave"`
+	MaxAge      time.Duration   `koanf:"max_age"`
+	Compression bool            `koanf:"compression"`
+	Storage     StorageConfig   `koanf:"storage"`
+	Limits      LimitsConfig    `koanf:"limits"`
+	Snapshots   SnapshotsConfig `koanf:"snapshots"`
+}
+
+// LimitsConfig represents per-session size limits
+type LimitsConfig struct {
+	MaxMessages        int     `koanf:"max_messages"`         // 0 means unlimited
+	MaxAttachmentBytes int64   `koanf:"max_attachment_bytes"` // 0 means unlimited
+	WarnAt             float64 `koanf:"warn_at"`              // Share of a limit at which warnings start
+	OnExceed           string  `koanf:"on_exceed"`            // block or summarize
+}
+
+// SnapshotsConfig represents when sessions are snapshotted for point-in-time restore
+type SnapshotsConfig struct {
+	Interval time.Duration `koanf:"interval"` // Minimum time between snapshots taken on save; 0 takes none
+	Keep     int           `koanf:"keep"`     // Snapshots kept per session; 0 turns snapshots off
 }
 
 // StorageConfig represents storage backend configuration
 type StorageConfig struct {
-	Type     string                 `koanf:"type"`     // filesystem, sqlite, postgresql, etc.
+	Type     string                 `koanf:"type"`      // filesystem, sqlite, postgresql, etc.
+	ReadOnly bool                   `koanf:"read_only"` // Open storage without writing to it
+	Settings map[string]interface{} `koanf:"settings"`  // Backend-specific settings
+	Replica  ReplicaConfig          `koanf:"replica"`   // Backend session writes are mirrored to
+}
+
+// ReplicaConfig represents a secondary storage backend that mirrors writes
+type ReplicaConfig struct {
+	Type     string                 `koanf:"type"`     // Empty for no replica
 	Settings map[string]interface{} `koanf:"settings"` // Backend-specific settings
 }
 
@@ -111,9 +146,11 @@ type PluginConfig struct {
 // ProfileConfig represents a configuration profile
 type ProfileConfig struct {
 	Description string                 `koanf:"description"`
+	Extends     string                 `koanf:"extends"` // profile whose values this profile builds on
 	Provider    string                 `koanf:"provider"`
 	Model       string                 `koanf:"model"`
 	Settings    map[string]interface{} `koanf:"settings"`
+	Fallbacks   []string               `koanf:"fallbacks"` // provider/model pairs tried in order when the profile's model fails
 }
 
 // Provider-specific configurations
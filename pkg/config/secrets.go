@@ -0,0 +1,68 @@
+// ABOUTME: Resolves secret:// references in configuration values
+// ABOUTME: Builds the secrets resolver from the secrets section of the config and masks secret values in logs
+
+package config
+
+import (
+	"context"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/secrets"
+)
+
+// SecretResolver returns the resolver for secret:// references, configured
+// from secrets.command and secrets.vault.*
+func (c *Config) SecretResolver() *secrets.Resolver {
+	c.secretsMu.Lock()
+	defer c.secretsMu.Unlock()
+	if c.resolver == nil {
+		c.resolver = secrets.NewResolver(
+			secrets.EnvBackend{},
+			secrets.KeychainBackend{},
+			secrets.CommandBackend{Command: c.GetString("secrets.command")},
+			&secrets.VaultBackend{
+				Address:   c.GetString("secrets.vault.address"),
+				Namespace: c.GetString("secrets.vault.namespace"),
+			},
+		)
+	}
+	return c.resolver
+}
+
+// GetSecret returns the string value of key, resolving it when it is a
+// secret:// reference
+func (c *Config) GetSecret(key string) (string, error) {
+	return c.GetSecretContext(context.Background(), key)
+}
+
+// GetSecretContext is GetSecret with a context that cancels a lookup in
+// progress, such as a secrets command or a Vault request
+func (c *Config) GetSecretContext(ctx context.Context, key string) (string, error) {
+	value := c.GetString(key)
+	if !secrets.IsReference(value) {
+		return value, nil
+	}
+	resolved, err := c.SecretResolver().Resolve(ctx, value)
+	if err == nil {
+		logging.AddSecrets(resolved)
+	}
+	return resolved, err
+}
+
+// maskSecrets has the logger mask the values of every sensitive key, such
+// as provider API keys. The caller must hold c.mu.
+func (c *Config) maskSecrets() {
+	for key, value := range c.koanf.All() {
+		if text, ok := value.(string); ok && IsSensitiveKey(key) {
+			maskSecret(text)
+		}
+	}
+}
+
+// maskSecret has the logger mask a secret value. References to secrets
+// kept elsewhere and encrypted values are not secret themselves.
+func maskSecret(value string) {
+	if !secrets.IsReference(value) && !secrets.IsEncrypted(value) {
+		logging.AddSecrets(value)
+	}
+}
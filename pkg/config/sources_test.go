@@ -0,0 +1,56 @@
+// ABOUTME: Tests for tracking the source layer of configuration values
+// ABOUTME: Verifies default, file, env, profile, and flag attribution and the differs-from-default filter
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func originsByKey(origins []ValueOrigin) map[string]ValueOrigin {
+	byKey := make(map[string]ValueOrigin, len(origins))
+	for _, origin := range origins {
+		byKey[origin.Key] = origin
+	}
+	return byKey
+}
+
+func TestOrigins(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MAGELLAI_REPL_KEYBINDINGS", "vi")
+	t.Setenv(EnvGeminiKey, "gemini-key")
+	path := writeUserConfig(t, "model:\n  default: openai/gpt-4.1\nprofiles:\n  work:\n    log:\n      level: warn\n")
+	require.NoError(t, Init())
+
+	extra := filepath.Join(t.TempDir(), "extra.yaml")
+	require.NoError(t, os.WriteFile(extra, []byte("repl:\n  theme: light\n"), 0600))
+	require.NoError(t, Manager.LoadFile(extra))
+	require.NoError(t, Manager.SetProfile("work"))
+	require.NoError(t, Manager.SetValue("output.color", false))
+
+	origins := originsByKey(Manager.Origins(false))
+	assert.Equal(t, ValueSource{Layer: LayerFile, Detail: path}, origins["model.default"].Source)
+	assert.Equal(t, "openai/gpt-4o", origins["model.default"].Default)
+	assert.Equal(t, ValueSource{Layer: LayerFile, Detail: extra}, origins["repl.theme"].Source)
+	assert.Equal(t, ValueSource{Layer: LayerEnv, Detail: "MAGELLAI_REPL_KEYBINDINGS"}, origins["repl.keybindings"].Source)
+	assert.Equal(t, ValueSource{Layer: LayerEnv, Detail: EnvGeminiKey}, origins["provider.gemini.api_key"].Source)
+	assert.Equal(t, ValueSource{Layer: LayerProfile, Detail: "work"}, origins["log.level"].Source)
+	assert.Equal(t, ValueSource{Layer: LayerFlag}, origins["output.color"].Source)
+	assert.Equal(t, "flag", origins["output.color"].Source.String())
+	assert.NotContains(t, origins, "log.format", "values matching their defaults are left out")
+
+	all := originsByKey(Manager.Origins(true))
+	assert.Equal(t, ValueSource{Layer: LayerDefault}, all["log.format"].Source)
+	assert.False(t, all["log.format"].Differs())
+
+	// Sources survive a reload
+	require.NoError(t, Manager.Reload())
+	origins = originsByKey(Manager.Origins(false))
+	assert.Equal(t, ValueSource{Layer: LayerFile, Detail: extra}, origins["repl.theme"].Source)
+	assert.Equal(t, ValueSource{Layer: LayerFlag}, origins["output.color"].Source)
+}
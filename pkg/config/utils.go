@@ -4,6 +4,7 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -12,6 +13,9 @@ import (
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/rawbytes"
 	"github.com/knadh/koanf/v2"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/keyring"
 )
 
 // GetString returns a string value from the configuration
@@ -79,6 +83,15 @@ func (c *Config) All() map[string]interface{} {
 
 // SetValue sets a value with validation
 func (c *Config) SetValue(key string, value interface{}) error {
+	if err := c.setValue(key, value); err != nil {
+		return err
+	}
+	publishConfigChanged(key, false, false)
+	return nil
+}
+
+// setValue sets a value and remembers it as an override
+func (c *Config) setValue(key string, value interface{}) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -86,6 +99,16 @@ func (c *Config) SetValue(key string, value interface{}) error {
 	if err := c.koanf.Set(key, value); err != nil {
 		return fmt.Errorf("failed to set value for key '%s': %w", key, err)
 	}
+	if text, ok := value.(string); ok && IsSensitiveKey(key) {
+		maskSecret(text)
+	}
+
+	// Remember the value so a reload does not lose it
+	if c.overrides == nil {
+		c.overrides = make(map[string]interface{})
+	}
+	c.overrides[key] = value
+	c.recordKeySource(key, ValueSource{Layer: LayerFlag})
 
 	// Notify watchers
 	c.notifyWatchers()
@@ -93,6 +116,17 @@ func (c *Config) SetValue(key string, value interface{}) error {
 	return nil
 }
 
+// publishConfigChanged announces a changed key on the default event bus,
+// noting whether the change was saved to the user config file. Values are
+// left out, since they may be secrets.
+func publishConfigChanged(key string, removed, persisted bool) {
+	event := domain.NewEvent(domain.EventConfigChanged, "")
+	event.Data["key"] = key
+	event.Data["removed"] = removed
+	event.Data["persisted"] = persisted
+	domain.DefaultEventBus.Publish(event)
+}
+
 // GetProviderConfig returns the configuration for a specific provider
 func (c *Config) GetProviderConfig(provider string) (map[string]interface{}, error) {
 	key := fmt.Sprintf("provider.%s", provider)
@@ -144,19 +178,12 @@ func (c *Config) SetDefaultModel(model string) error {
 	return c.SetValue("model.default", model)
 }
 
-// GetProfile returns a specific profile configuration
-func (c *Config) GetProfile(name string) (*ProfileConfig, error) {
-	key := fmt.Sprintf("profiles.%s", name)
-	if !c.Exists(key) {
-		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
-	}
-
-	var profile ProfileConfig
-	if err := c.koanf.Unmarshal(key, &profile); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal profile: %w", err)
-	}
-
-	return &profile, nil
+// GetCommandDefaults returns the default flag values configured for a
+// command under commands.<name>.defaults
+func (c *Config) GetCommandDefaults(name string) map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.koanf.Cut(fmt.Sprintf("commands.%s.defaults", name)).Raw()
 }
 
 // GetSchema returns the entire configuration as a typed schema
@@ -172,17 +199,35 @@ func (c *Config) GetSchema() (*Schema, error) {
 	return &schema, nil
 }
 
-// GetProviderAPIKey returns the API key for a specific provider
+// GetProviderAPIKey returns the API key for a specific provider, checking the
+// OS keychain, then MAGELLAI_<PROVIDER>_API_KEY, then the configuration (which
+// includes the provider's standard environment variable)
 func (c *Config) GetProviderAPIKey(provider string) string {
-	// First check environment variable
+	return c.GetProviderAPIKeyContext(context.Background(), provider)
+}
+
+// GetProviderAPIKeyContext is GetProviderAPIKey with a context that cancels
+// resolving a secret:// reference
+func (c *Config) GetProviderAPIKeyContext(ctx context.Context, provider string) string {
+	// First check the OS keychain
+	if apiKey, err := keyring.Get(strings.ToLower(provider)); err == nil && apiKey != "" {
+		return apiKey
+	}
+
+	// Then check environment variable
 	envKey := fmt.Sprintf("%s%s_API_KEY", ConfigEnvPrefix, strings.ToUpper(provider))
 	if apiKey := os.Getenv(envKey); apiKey != "" {
 		return apiKey
 	}
 
-	// Then check config
+	// Then check config, resolving a secret:// reference
 	configKey := fmt.Sprintf("provider.%s.api_key", strings.ToLower(provider))
-	return c.GetString(configKey)
+	apiKey, err := c.GetSecretContext(ctx, configKey)
+	if err != nil {
+		logging.LogWarn("Failed to resolve API key secret", "provider", provider, "error", err)
+		return ""
+	}
+	return apiKey
 }
 
 // MergeProfile merges a profile's settings into the current configuration
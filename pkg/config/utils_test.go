@@ -287,6 +287,15 @@ func TestProfileOperations(t *testing.T) {
 	})
 }
 
+func TestGetCommandDefaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	writeUserConfig(t, "commands:\n  ask:\n    defaults:\n      stream: true\n      max-tokens: 2000\n")
+	require.NoError(t, Init())
+
+	assert.Equal(t, map[string]interface{}{"stream": true, "max-tokens": 2000}, Manager.GetCommandDefaults("ask"))
+	assert.Empty(t, Manager.GetCommandDefaults("chat"))
+}
+
 func TestConfigExportImport(t *testing.T) {
 	config := createTestConfig(t)
 
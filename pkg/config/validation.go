@@ -7,24 +7,103 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/storage"
 )
 
+// promptPlaceholder matches {name} placeholders in repl.prompt
+var promptPlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// validPromptPlaceholders are the placeholders the REPL fills in repl.prompt
+var validPromptPlaceholders = []string{
+	"model", "model_name", "provider", "session", "session_short",
+	"session_name", "branch", "messages", "tokens",
+}
+
 // ValidationError represents a configuration validation error
 type ValidationError struct {
-	Field string
-	Value interface{}
-	Error string
+	Field      string      `json:"field"`
+	Value      interface{} `json:"value,omitempty"`
+	Error      string      `json:"error"`
+	File       string      `json:"file,omitempty"`       // Config file the value came from, if checked per file
+	Expected   string      `json:"expected,omitempty"`   // Expected value type
+	Allowed    []string    `json:"allowed,omitempty"`    // Allowed values
+	Suggestion string      `json:"suggestion,omitempty"` // Likely intended key or value
+}
+
+// String formats the error with its key path and hints for fixing it
+func (e ValidationError) String() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File + ": ")
+	}
+	if e.Field != "" {
+		b.WriteString(e.Field + ": ")
+	}
+	b.WriteString(e.Error)
+	if e.Expected != "" {
+		b.WriteString(fmt.Sprintf(", expected %s", e.Expected))
+		if e.Value != nil {
+			b.WriteString(fmt.Sprintf(", got %#v", e.Value))
+		}
+	}
+	if len(e.Allowed) > 0 && !strings.Contains(e.Error, "must be one of") {
+		b.WriteString("; allowed values: " + strings.Join(e.Allowed, ", "))
+	}
+	if e.Suggestion != "" {
+		b.WriteString(fmt.Sprintf("; did you mean %q?", e.Suggestion))
+	}
+	return b.String()
+}
+
+// choiceError reports a value that is not one of the allowed values
+func choiceError(field, value, what string, allowed []string) ValidationError {
+	return ValidationError{
+		Field:      field,
+		Value:      value,
+		Error:      fmt.Sprintf("invalid %s, must be one of: %v", what, allowed),
+		Allowed:    allowed,
+		Suggestion: closest(value, allowed),
+	}
 }
 
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	logging.LogDebug("Starting configuration validation")
 
+	errors := c.ValidationErrors()
+	if len(errors) > 0 {
+		for _, err := range errors {
+			logging.LogWarn("Configuration validation error",
+				"field", err.Field,
+				"value", err.Value,
+				"error", err.Error)
+		}
+		logging.LogError(nil, "Configuration validation failed", "errorCount", len(errors))
+		lines := make([]string, len(errors))
+		for i, err := range errors {
+			lines[i] = err.String()
+		}
+		return fmt.Errorf("%w:\n  %s", ErrValidationFailed, strings.Join(lines, "\n  "))
+	}
+
+	logging.LogDebug("Configuration validation completed successfully")
+	return nil
+}
+
+// ValidationErrors returns every problem found in the configuration, without logging
+func (c *Config) ValidationErrors() []ValidationError {
 	var errors []ValidationError
 
+	// Check each loaded file for unknown keys and mistyped values
+	for _, file := range c.GetLoadedFiles() {
+		errors = append(errors, fileKeyErrors(file)...)
+	}
+
 	// Validate log configuration
 	if err := c.validateLogConfig(); err != nil {
 		errors = append(errors, err...)
@@ -50,48 +129,67 @@ func (c *Config) Validate() error {
 		errors = append(errors, err...)
 	}
 
-	// Validate profiles
-	if err := c.validateProfiles(); err != nil {
+	// Validate REPL configuration
+	if err := c.validateREPLConfig(); err != nil {
 		errors = append(errors, err...)
 	}
 
-	if len(errors) > 0 {
-		for _, err := range errors {
-			logging.LogWarn("Configuration validation error",
-				"field", err.Field,
-				"value", err.Value,
-				"error", err.Error)
-		}
-		logging.LogError(nil, "Configuration validation failed", "errorCount", len(errors))
-		return fmt.Errorf("configuration validation failed: %v", errors)
+	// Validate moderation configuration
+	if err := c.validateModerationConfig(); err != nil {
+		errors = append(errors, err...)
 	}
 
-	logging.LogDebug("Configuration validation completed successfully")
-	return nil
+	// Validate profiles
+	if err := c.validateProfiles(); err != nil {
+		errors = append(errors, err...)
+	}
+
+	return errors
 }
 
-// validateLogConfig validates logging configuration
+// validateLogConfig validates logging and tracing configuration
 func (c *Config) validateLogConfig() []ValidationError {
 	var errors []ValidationError
 
 	level := c.GetString("log.level")
-	validLevels := []string{"debug", "info", "warn", "error"}
-	if !containsString(validLevels, level) {
-		errors = append(errors, ValidationError{
-			Field: "log.level",
-			Value: level,
-			Error: fmt.Sprintf("invalid log level, must be one of: %v", validLevels),
-		})
+	if !containsString(validLogLevels, level) {
+		errors = append(errors, choiceError("log.level", level, "log level", validLogLevels))
 	}
 
 	format := c.GetString("log.format")
-	validFormats := []string{"text", "json"}
-	if !containsString(validFormats, format) {
-		errors = append(errors, ValidationError{
-			Field: "log.format",
-			Value: format,
-			Error: fmt.Sprintf("invalid log format, must be one of: %v", validFormats),
-		})
+	if !containsString(validLogFormats, format) {
+		errors = append(errors, choiceError("log.format", format, "log format", validLogFormats))
+	}
+
+	for _, key := range []string{"log.rotation.interval", "log.rotation.age"} {
+		if value := c.GetString(key); value != "" {
+			if d, err := time.ParseDuration(value); err != nil || d < 0 {
+				errors = append(errors, ValidationError{
+					Field: key,
+					Value: value,
+					Error: "must be a duration >= 0, such as 24h (0s turns it off)",
+				})
+			}
+		}
+	}
+	for _, key := range []string{"log.rotation.size", "log.rotation.keep"} {
+		if c.Exists(key) && c.GetInt(key) < 0 {
+			errors = append(errors, ValidationError{
+				Field: key,
+				Value: c.Get(key),
+				Error: "must be >= 0 (0 turns it off)",
+			})
+		}
+	}
+
+	if c.Exists("tracing.sampling") {
+		if sampling := c.GetFloat64("tracing.sampling"); sampling < 0 || sampling > 1 {
+			errors = append(errors, ValidationError{
+				Field: "tracing.sampling",
+				Value: c.Get("tracing.sampling"),
+				Error: "sampling must be between 0 and 1",
+			})
+		}
 	}
 
 	return errors
@@ -102,13 +200,8 @@ func (c *Config) validateProviderConfig() []ValidationError {
 	var errors []ValidationError
 
 	defaultProvider := c.GetString("provider.default")
-	validProviders := []string{"openai", "anthropic", "gemini"}
 	if defaultProvider != "" && !containsString(validProviders, defaultProvider) {
-		errors = append(errors, ValidationError{
-			Field: "provider.default",
-			Value: defaultProvider,
-			Error: fmt.Sprintf("invalid default provider, must be one of: %v", validProviders),
-		})
+		errors = append(errors, choiceError("provider.default", defaultProvider, "default provider", validProviders))
 	}
 
 	// Validate specific provider configurations if they exist
@@ -171,13 +264,8 @@ func (c *Config) validateOutputConfig() []ValidationError {
 	var errors []ValidationError
 
 	format := c.GetString("output.format")
-	validFormats := []string{"text", "json", "markdown"}
-	if !containsString(validFormats, format) {
-		errors = append(errors, ValidationError{
-			Field: "output.format",
-			Value: format,
-			Error: fmt.Sprintf("invalid output format, must be one of: %v", validFormats),
-		})
+	if !containsString(validOutputFormats, format) {
+		errors = append(errors, choiceError("output.format", format, "output format", validOutputFormats))
 	}
 
 	return errors
@@ -218,11 +306,17 @@ func (c *Config) validateSessionConfig() []ValidationError {
 		// Check if the storage backend is available
 		if !storage.IsBackendAvailable(storage.BackendType(storageType)) {
 			availableBackends := storage.GetAvailableBackends()
+			allowed := make([]string, len(availableBackends))
+			for i, backend := range availableBackends {
+				allowed[i] = string(backend)
+			}
 			errors = append(errors, ValidationError{
 				Field: "session.storage.type",
 				Value: storageType,
 				Error: fmt.Sprintf("storage backend '%s' is not available. Available backends: %v",
 					storageType, availableBackends),
+				Allowed:    allowed,
+				Suggestion: closest(storageType, allowed),
 			})
 		}
 
@@ -258,6 +352,63 @@ func (c *Config) validateSessionConfig() []ValidationError {
 		}
 	}
 
+	for _, key := range []string{"session.limits.max_messages", "session.limits.max_attachment_bytes"} {
+		if c.Exists(key) && c.GetInt(key) < 0 {
+			errors = append(errors, ValidationError{
+				Field: key,
+				Value: c.Get(key),
+				Error: "limit must be >= 0 (0 means unlimited)",
+			})
+		}
+	}
+	if c.Exists("session.limits.warn_at") {
+		if warnAt := c.GetFloat64("session.limits.warn_at"); warnAt <= 0 || warnAt > 1 {
+			errors = append(errors, ValidationError{
+				Field: "session.limits.warn_at",
+				Value: warnAt,
+				Error: "warn_at must be greater than 0 and at most 1",
+			})
+		}
+	}
+	onExceed := c.GetString("session.limits.on_exceed")
+	if onExceed != "" && !containsString(validLimitActions, onExceed) {
+		errors = append(errors, choiceError("session.limits.on_exceed", onExceed, "limit action", validLimitActions))
+	}
+
+	if c.Exists("session.snapshots.interval") {
+		interval := c.GetString("session.snapshots.interval")
+		if d, err := time.ParseDuration(interval); err != nil || d < 0 {
+			errors = append(errors, ValidationError{
+				Field: "session.snapshots.interval",
+				Value: interval,
+				Error: "interval must be a duration >= 0, such as 15m (0s snapshots only before destructive commands)",
+			})
+		}
+	}
+	if c.Exists("session.snapshots.keep") && c.GetInt("session.snapshots.keep") < 0 {
+		errors = append(errors, ValidationError{
+			Field: "session.snapshots.keep",
+			Value: c.Get("session.snapshots.keep"),
+			Error: "keep must be >= 0 (0 turns snapshots off)",
+		})
+	}
+
+	replicaType := c.GetString("session.storage.replica.type")
+	if replicaType != "" && !storage.IsBackendAvailable(storage.BackendType(replicaType)) {
+		availableBackends := storage.GetAvailableBackends()
+		allowed := make([]string, len(availableBackends))
+		for i, backend := range availableBackends {
+			allowed[i] = string(backend)
+		}
+		errors = append(errors, ValidationError{
+			Field:      "session.storage.replica.type",
+			Value:      replicaType,
+			Error:      fmt.Sprintf("storage backend '%s' is not available. Available backends: %v", replicaType, availableBackends),
+			Allowed:    allowed,
+			Suggestion: closest(replicaType, allowed),
+		})
+	}
+
 	return errors
 }
 
@@ -294,6 +445,58 @@ func (c *Config) validatePluginConfig() []ValidationError {
 	return errors
 }
 
+// validateREPLConfig validates interactive REPL configuration
+func (c *Config) validateREPLConfig() []ValidationError {
+	var errors []ValidationError
+
+	keybindings := c.GetString("repl.keybindings")
+	if keybindings != "" && !containsString(validKeybindings, keybindings) {
+		errors = append(errors, choiceError("repl.keybindings", keybindings, "keybindings", validKeybindings))
+	}
+
+	if prompt := c.GetString("repl.prompt"); prompt != "" {
+		for _, match := range promptPlaceholder.FindAllStringSubmatch(prompt, -1) {
+			if !containsString(validPromptPlaceholders, match[1]) {
+				errors = append(errors, ValidationError{
+					Field:      "repl.prompt",
+					Value:      prompt,
+					Error:      fmt.Sprintf("unknown placeholder {%s}, must be one of: %v", match[1], validPromptPlaceholders),
+					Allowed:    validPromptPlaceholders,
+					Suggestion: closest(match[1], validPromptPlaceholders),
+				})
+			}
+		}
+	}
+
+	status := c.GetString("repl.status")
+	if status != "" && !containsString(validStatusPlacements, status) {
+		errors = append(errors, choiceError("repl.status", status, "status placement", validStatusPlacements))
+	}
+
+	return errors
+}
+
+// validateModerationConfig validates content moderation configuration
+func (c *Config) validateModerationConfig() []ValidationError {
+	var errors []ValidationError
+
+	if !c.GetBool("moderation.enabled") {
+		return errors
+	}
+
+	provider := c.GetString("moderation.provider")
+	if provider != "" && !containsString(validModerationProvider, provider) {
+		errors = append(errors, choiceError("moderation.provider", provider, "moderation provider", validModerationProvider))
+	}
+
+	action := c.GetString("moderation.action")
+	if action != "" && !containsString(validModerationActions, action) {
+		errors = append(errors, choiceError("moderation.action", action, "moderation action", validModerationActions))
+	}
+
+	return errors
+}
+
 // validateProfiles validates profile configurations
 func (c *Config) validateProfiles() []ValidationError {
 	var errors []ValidationError
@@ -320,24 +523,22 @@ func (c *Config) validateProfile(name string) []ValidationError {
 
 	profile, err := c.GetProfile(name)
 	if err != nil {
+		field := fmt.Sprintf("profiles.%s", name)
+		var value interface{}
+		if extends := c.GetString(field + ".extends"); extends != "" {
+			field, value = field+".extends", extends
+		}
 		errors = append(errors, ValidationError{
-			Field: fmt.Sprintf("profiles.%s", name),
-			Value: nil,
+			Field: field,
+			Value: value,
 			Error: err.Error(),
 		})
 		return errors
 	}
 
 	// Validate provider if specified
-	if profile.Provider != "" {
-		validProviders := []string{"openai", "anthropic", "gemini"}
-		if !containsString(validProviders, profile.Provider) {
-			errors = append(errors, ValidationError{
-				Field: fmt.Sprintf("profiles.%s.provider", name),
-				Value: profile.Provider,
-				Error: fmt.Sprintf("invalid provider, must be one of: %v", validProviders),
-			})
-		}
+	if profile.Provider != "" && !containsString(validProviders, profile.Provider) {
+		errors = append(errors, choiceError(fmt.Sprintf("profiles.%s.provider", name), profile.Provider, "provider", validProviders))
 	}
 
 	// Validate model format if specified
@@ -352,6 +553,17 @@ func (c *Config) validateProfile(name string) []ValidationError {
 		}
 	}
 
+	// Fallbacks must name both provider and model
+	for i, fallback := range profile.Fallbacks {
+		if pair := ParseProviderModel(fallback); pair.Provider == "" || pair.Model == "" {
+			errors = append(errors, ValidationError{
+				Field: fmt.Sprintf("profiles.%s.fallbacks[%d]", name, i),
+				Value: fallback,
+				Error: "fallback must be in provider/model format",
+			})
+		}
+	}
+
 	return errors
 }
 
@@ -70,6 +70,76 @@ func TestValidateStorageBackend(t *testing.T) {
 			expectError: true,
 			errorField:  "session.storage.type",
 		},
+		{
+			name: "session limits",
+			config: func(t *testing.T) map[string]interface{} {
+				return map[string]interface{}{
+					"session": map[string]interface{}{
+						"limits": map[string]interface{}{"max_messages": 200, "warn_at": 0.9, "on_exceed": "summarize"},
+					},
+				}
+			},
+			expectError: false,
+		},
+		{
+			name: "negative session limit",
+			config: func(t *testing.T) map[string]interface{} {
+				return map[string]interface{}{
+					"session": map[string]interface{}{
+						"limits": map[string]interface{}{"max_attachment_bytes": -1},
+					},
+				}
+			},
+			expectError: true,
+			errorField:  "session.limits.max_attachment_bytes",
+		},
+		{
+			name: "warn_at above 1",
+			config: func(t *testing.T) map[string]interface{} {
+				return map[string]interface{}{
+					"session": map[string]interface{}{
+						"limits": map[string]interface{}{"warn_at": 80},
+					},
+				}
+			},
+			expectError: true,
+			errorField:  "session.limits.warn_at",
+		},
+		{
+			name: "unknown limit action",
+			config: func(t *testing.T) map[string]interface{} {
+				return map[string]interface{}{
+					"session": map[string]interface{}{
+						"limits": map[string]interface{}{"on_exceed": "truncate"},
+					},
+				}
+			},
+			expectError: true,
+			errorField:  "session.limits.on_exceed",
+		},
+		{
+			name: "snapshots",
+			config: func(t *testing.T) map[string]interface{} {
+				return map[string]interface{}{
+					"session": map[string]interface{}{
+						"snapshots": map[string]interface{}{"interval": "1h", "keep": 5},
+					},
+				}
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid snapshot interval",
+			config: func(t *testing.T) map[string]interface{} {
+				return map[string]interface{}{
+					"session": map[string]interface{}{
+						"snapshots": map[string]interface{}{"interval": "often"},
+					},
+				}
+			},
+			expectError: true,
+			errorField:  "session.snapshots.interval",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,159 @@
+// ABOUTME: Reloads configuration when its files change on disk
+// ABOUTME: Watches config directories with fsnotify and reports which settings changed
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// watchDebounce is how long to wait for further writes before reloading,
+// since editors often save a file in several steps
+const watchDebounce = 200 * time.Millisecond
+
+// FileChange describes a reload triggered by a change to a config file
+type FileChange struct {
+	Path string   // File whose change triggered the reload
+	Keys []string // Settings whose values changed, sorted
+	Err  error    // Reload error; the previous configuration stays in effect
+}
+
+// WatchFiles reloads the configuration whenever one of its files changes and
+// calls onChange with the settings that changed. The user and project config
+// files are watched even if they do not exist yet. Reloads that change
+// nothing are not reported. The returned function stops watching.
+func (c *Config) WatchFiles(onChange func(FileChange)) (func() error, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch config files: %w", err)
+	}
+
+	// Watch directories rather than files: editors that save by renaming
+	// a new file into place would otherwise end the watch
+	files := make(map[string]bool)
+	for _, path := range c.watchPaths() {
+		files[path] = true
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			logging.LogDebug("Not watching config directory", "dir", filepath.Dir(path), "error", err)
+		}
+	}
+	if len(watcher.WatchList()) == 0 {
+		watcher.Close()
+		return nil, fmt.Errorf("%w: no config directories to watch", ErrConfigNotFound)
+	}
+	logging.LogInfo("Watching configuration files", "dirs", watcher.WatchList())
+
+	done := make(chan struct{})
+	go func() {
+		var reload <-chan time.Time
+		var changed string
+		for {
+			select {
+			case <-done:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod || !files[absPath(event.Name)] {
+					continue
+				}
+				logging.LogDebug("Config file changed", "path", event.Name, "op", event.Op.String())
+				changed = absPath(event.Name)
+				reload = time.After(watchDebounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logging.LogWarn("Config file watcher error", "error", err)
+			case <-reload:
+				reload = nil
+				if change := c.reloadFor(changed); change.Err != nil || len(change.Keys) > 0 {
+					onChange(change)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() error {
+		var err error
+		once.Do(func() {
+			close(done)
+			err = watcher.Close()
+		})
+		return err
+	}, nil
+}
+
+// watchPaths returns the absolute paths of the files that make up the
+// configuration, loaded or not
+func (c *Config) watchPaths() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Every supported format is watched, so a config file created or
+	// converted later is picked up too
+	paths := configCandidates(SystemConfigPath)
+	paths = append(paths, configCandidates(expandPath(filepath.Join(UserConfigDir, UserConfigFile)))...)
+	project := filepath.Join(c.currentDir, ProjectConfigFile)
+	if found := c.findProjectConfig(); found != "" {
+		project = found
+	}
+	paths = append(paths, configCandidates(project)...)
+	paths = append(paths, c.loadedFiles...)
+	paths = append(paths, c.extraFiles...)
+
+	for i, path := range paths {
+		paths[i] = absPath(path)
+	}
+	return paths
+}
+
+// reloadFor reloads the configuration after path changed and reports
+// which settings differ
+func (c *Config) reloadFor(path string) FileChange {
+	before := c.All()
+	if err := c.Reload(); err != nil {
+		logging.LogWarn("Failed to reload changed config file", "path", path, "error", err)
+		return FileChange{Path: path, Err: err}
+	}
+	keys := changedKeys(before, c.All())
+	logging.LogInfo("Configuration reloaded after file change", "path", path, "changed", len(keys))
+	return FileChange{Path: path, Keys: keys}
+}
+
+// changedKeys returns the sorted keys whose values differ between two
+// flattened configurations
+func changedKeys(before, after map[string]interface{}) []string {
+	var keys []string
+	for key, value := range after {
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, value) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// absPath returns the absolute form of path, or the cleaned path if the
+// working directory is unknown
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
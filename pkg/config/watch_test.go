@@ -0,0 +1,105 @@
+// ABOUTME: Tests for reloading configuration when config files change
+// ABOUTME: Verifies reload semantics, change detection, and fsnotify-driven reloads
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeUserConfig writes the user config file under a temporary HOME
+func writeUserConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := UserConfigPath()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestReload(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeUserConfig(t, "model:\n  default: openai/gpt-4o\n")
+	require.NoError(t, Init())
+	cfg := Manager
+
+	extra := filepath.Join(t.TempDir(), "extra.yaml")
+	require.NoError(t, os.WriteFile(extra, []byte("log:\n  level: debug\n"), 0600))
+	require.NoError(t, cfg.LoadFile(extra))
+	require.NoError(t, cfg.SetValue("stream", false))
+
+	writeUserConfig(t, "model:\n  default: anthropic/claude-3-5-haiku-latest\n")
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, "anthropic/claude-3-5-haiku-latest", cfg.GetString("model.default"))
+	assert.Equal(t, "debug", cfg.GetString("log.level"), "files loaded with LoadFile are reloaded")
+	assert.False(t, cfg.GetBool("stream"), "runtime values are kept")
+	assert.ElementsMatch(t, []string{path, extra}, cfg.GetLoadedFiles())
+
+	// A broken file leaves the previous configuration in effect
+	writeUserConfig(t, "model: [unclosed\n")
+	err := cfg.Reload()
+	assert.ErrorIs(t, err, ErrConfigLoadFailed)
+	assert.Equal(t, "anthropic/claude-3-5-haiku-latest", cfg.GetString("model.default"))
+	assert.ElementsMatch(t, []string{path, extra}, cfg.GetLoadedFiles())
+}
+
+func TestChangedKeys(t *testing.T) {
+	before := map[string]interface{}{"a": 1, "b": "x", "c": []interface{}{"y"}}
+	after := map[string]interface{}{"a": 1, "b": "z", "c": []interface{}{"y"}, "d": true}
+	assert.Equal(t, []string{"b", "d"}, changedKeys(before, after))
+	assert.Equal(t, []string{"b", "d"}, changedKeys(after, before))
+	assert.Empty(t, changedKeys(before, before))
+}
+
+func TestWatchFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeUserConfig(t, "model:\n  default: openai/gpt-4o\n")
+	require.NoError(t, Init())
+	cfg := Manager
+
+	changes := make(chan FileChange, 10)
+	stop, err := cfg.WatchFiles(func(change FileChange) {
+		changes <- change
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, stop())
+		assert.NoError(t, stop(), "stopping twice is harmless")
+	}()
+
+	next := func() FileChange {
+		t.Helper()
+		select {
+		case change := <-changes:
+			return change
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for config reload")
+			return FileChange{}
+		}
+	}
+
+	writeUserConfig(t, "model:\n  default: openai/gpt-4o-mini\naliases:\n  r: ask --model fast\n")
+	change := next()
+	require.NoError(t, change.Err)
+	assert.Equal(t, path, change.Path)
+	assert.Equal(t, []string{"aliases.r", "model.default"}, change.Keys)
+	assert.Equal(t, "openai/gpt-4o-mini", cfg.GetString("model.default"))
+
+	// Editors that save by renaming a new file into place are picked up too
+	tmp := path + ".swp"
+	require.NoError(t, os.WriteFile(tmp, []byte("model:\n  default: gemini/gemini-2.0-flash\n"), 0600))
+	require.NoError(t, os.Rename(tmp, path))
+	change = next()
+	require.NoError(t, change.Err)
+	assert.Equal(t, "gemini/gemini-2.0-flash", cfg.GetString("model.default"))
+
+	writeUserConfig(t, "model: [unclosed\n")
+	change = next()
+	assert.ErrorIs(t, change.Err, ErrConfigLoadFailed)
+	assert.Equal(t, "gemini/gemini-2.0-flash", cfg.GetString("model.default"))
+}
@@ -0,0 +1,102 @@
+// ABOUTME: Reference documentation generated from the CLI command tree
+// ABOUTME: Defines the command model rendered to markdown and man pages
+
+// Package docgen renders reference documentation for the CLI. The command
+// tree is built from the parser's model (and command metadata), so generated
+// docs always match the flags and arguments the binary actually accepts.
+package docgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format is an output format for generated docs
+type Format string
+
+const (
+	// FormatMarkdown renders markdown reference pages
+	FormatMarkdown Format = "markdown"
+	// FormatMan renders roff man pages (section 1)
+	FormatMan Format = "man"
+)
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatMarkdown, "md":
+		return FormatMarkdown, nil
+	case FormatMan:
+		return FormatMan, nil
+	}
+	return "", fmt.Errorf("unknown docs format %q (expected man or markdown)", name)
+}
+
+// Command describes one command for documentation
+type Command struct {
+	Name        string // Full command path, e.g. "magellai history export"
+	Usage       string // Usage line
+	Help        string // One-line description
+	Detail      string // Long description
+	Aliases     []string
+	Args        []Arg
+	Flags       []Flag
+	Subcommands []*Command
+}
+
+// Arg describes a positional argument
+type Arg struct {
+	Name     string
+	Help     string
+	Required bool
+}
+
+// Flag describes a flag
+type Flag struct {
+	Name        string // Long name without dashes
+	Short       string // Short name without the dash
+	Placeholder string // Value placeholder; empty for boolean flags
+	Help        string
+	Default     string
+}
+
+// Spec returns the flag as shown in docs, e.g. "-f, --format=FORMAT"
+func (f Flag) Spec() string {
+	spec := "--" + f.Name
+	if f.Placeholder != "" {
+		spec += "=" + f.Placeholder
+	}
+	if f.Short != "" {
+		spec = "-" + f.Short + ", " + spec
+	}
+	return spec
+}
+
+// FileName returns the base file name for a command's page, e.g.
+// "magellai-history-export"
+func (c *Command) FileName() string {
+	return strings.Join(strings.Fields(c.Name), "-")
+}
+
+// Walk calls fn for c and every command below it, depth first
+func (c *Command) Walk(fn func(*Command)) {
+	fn(c)
+	for _, sub := range c.Subcommands {
+		sub.Walk(fn)
+	}
+}
+
+// Pages splits the tree into the pages written to a directory: the root
+// command listing the top-level commands, and one page per top-level command
+// that includes its subcommands
+func Pages(root *Command) []*Command {
+	index := *root
+	index.Subcommands = make([]*Command, len(root.Subcommands))
+	for i, sub := range root.Subcommands {
+		// Without a usage line, renderers list the command but skip its details
+		index.Subcommands[i] = &Command{Name: sub.Name, Help: sub.Help}
+	}
+
+	pages := []*Command{&index}
+	return append(pages, root.Subcommands...)
+}
@@ -0,0 +1,99 @@
+// ABOUTME: Tests for CLI reference doc rendering
+// ABOUTME: Verifies markdown and man output, page splitting, and escaping
+
+package docgen
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTree returns a small command tree
+func testTree() *Command {
+	return &Command{
+		Name:  "tool",
+		Usage: "tool <command> [flags]",
+		Help:  "A test tool",
+		Flags: []Flag{{Name: "output", Short: "o", Placeholder: "OUTPUT", Help: "Output format", Default: "text"}},
+		Subcommands: []*Command{
+			{
+				Name:   "tool history",
+				Usage:  "tool history <command>",
+				Help:   "Manage history",
+				Detail: "Long history help.\n.starts with a dot",
+				Subcommands: []*Command{
+					{
+						Name:  "tool history export",
+						Usage: "tool history export [<session-id> ...] [flags]",
+						Help:  "Export sessions",
+						Args:  []Arg{{Name: "session-id", Help: "Sessions | to export"}},
+						Flags: []Flag{{Name: "all", Help: "Export every session"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, RenderMarkdown(&buf, testTree()))
+	out := buf.String()
+
+	assert.Contains(t, out, "# tool\n")
+	assert.Contains(t, out, "## tool history\n")
+	assert.Contains(t, out, "### tool history export\n")
+	assert.Contains(t, out, "| `-o, --output=OUTPUT` | `text` | Output format |")
+	assert.Contains(t, out, "| `session-id` | no | Sessions \\| to export |")
+	assert.Contains(t, out, "| `--all` |  | Export every session |")
+}
+
+func TestRenderMan(t *testing.T) {
+	var buf bytes.Buffer
+	history := testTree().Subcommands[0]
+	require.NoError(t, RenderMan(&buf, history, ManOptions{Version: "1.0", Date: "2024-01-02"}))
+	out := buf.String()
+
+	assert.Contains(t, out, `.TH "TOOL-HISTORY" 1 "2024-01-02" "magellai 1.0" "User Commands"`)
+	assert.Contains(t, out, "tool\\-history \\- Manage history")
+	assert.Contains(t, out, "\\&.starts with a dot")
+	assert.Contains(t, out, ".SH COMMANDS\n.TP\n.B tool history export [<session\\-id> ...] [flags]")
+	assert.Contains(t, out, ".B \\-\\-all\nExport every session")
+}
+
+func TestPages(t *testing.T) {
+	root := testTree()
+	pages := Pages(root)
+	require.Len(t, pages, 2)
+
+	assert.Equal(t, "tool", pages[0].FileName())
+	assert.Equal(t, "tool-history", pages[1].FileName())
+	require.Len(t, pages[0].Subcommands, 1)
+	assert.Empty(t, pages[0].Subcommands[0].Usage, "index page only lists commands")
+	assert.NotEmpty(t, root.Subcommands[0].Usage, "the tree is not modified")
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderMarkdown(&buf, pages[0]))
+	assert.NotContains(t, buf.String(), "## tool history")
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("md")
+	require.NoError(t, err)
+	assert.Equal(t, FormatMarkdown, format)
+
+	format, err = ParseFormat("MAN")
+	require.NoError(t, err)
+	assert.Equal(t, FormatMan, format)
+
+	_, err = ParseFormat("html")
+	assert.Error(t, err)
+}
+
+func TestFlag_Spec(t *testing.T) {
+	assert.Equal(t, "--all", Flag{Name: "all"}.Spec())
+	assert.Equal(t, "-f, --format=FORMAT", Flag{Name: "format", Short: "f", Placeholder: "FORMAT"}.Spec())
+}
@@ -0,0 +1,102 @@
+// ABOUTME: Man page renderer for CLI reference docs
+// ABOUTME: Writes section 1 roff pages with synopsis, options, and commands
+
+package docgen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ManOptions sets the page footer fields
+type ManOptions struct {
+	Version string
+	Date    string // e.g. "2024-01-02"
+}
+
+// RenderMan writes a section 1 man page for cmd. Subcommands are described
+// in a COMMANDS section, including their own flags.
+func RenderMan(w io.Writer, cmd *Command, opts ManOptions) error {
+	var b strings.Builder
+	title := strings.ToUpper(cmd.FileName())
+	fmt.Fprintf(&b, ".TH %q 1 %q %q \"User Commands\"\n", title, opts.Date, "magellai "+opts.Version)
+
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s", roff(cmd.FileName()))
+	if cmd.Help != "" {
+		fmt.Fprintf(&b, " \\- %s", roff(cmd.Help))
+	}
+	b.WriteString("\n")
+
+	if cmd.Usage != "" {
+		fmt.Fprintf(&b, ".SH SYNOPSIS\n.B %s\n", roff(cmd.Usage))
+	}
+	if cmd.Detail != "" && cmd.Detail != cmd.Help {
+		fmt.Fprintf(&b, ".SH DESCRIPTION\n.nf\n%s\n.fi\n", roff(strings.TrimSpace(cmd.Detail)))
+	}
+	writeManArgs(&b, "ARGUMENTS", cmd.Args)
+	writeManFlags(&b, "OPTIONS", cmd.Flags)
+
+	if len(cmd.Subcommands) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		cmd.Walk(func(sub *Command) {
+			if sub == cmd || sub.Usage == "" {
+				return
+			}
+			fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", roff(sub.Usage), roff(sub.Help))
+			for _, arg := range sub.Args {
+				fmt.Fprintf(&b, ".br\n.I %s\n%s\n", roff(arg.Name), roff(arg.Help))
+			}
+			for _, flag := range sub.Flags {
+				fmt.Fprintf(&b, ".br\n.B %s\n%s\n", roff(flag.Spec()), roff(flagHelp(flag)))
+			}
+		})
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeManArgs writes a section listing positional arguments
+func writeManArgs(b *strings.Builder, section string, args []Arg) {
+	if len(args) == 0 {
+		return
+	}
+	fmt.Fprintf(b, ".SH %s\n", section)
+	for _, arg := range args {
+		fmt.Fprintf(b, ".TP\n.I %s\n%s\n", roff(arg.Name), roff(arg.Help))
+	}
+}
+
+// writeManFlags writes a section listing flags
+func writeManFlags(b *strings.Builder, section string, flags []Flag) {
+	if len(flags) == 0 {
+		return
+	}
+	fmt.Fprintf(b, ".SH %s\n", section)
+	for _, flag := range flags {
+		fmt.Fprintf(b, ".TP\n.B %s\n%s\n", roff(flag.Spec()), roff(flagHelp(flag)))
+	}
+}
+
+// flagHelp appends the default value to a flag's help
+func flagHelp(flag Flag) string {
+	if flag.Default == "" {
+		return flag.Help
+	}
+	return fmt.Sprintf("%s (default: %s)", flag.Help, flag.Default)
+}
+
+// roff escapes text for roff, including lines that would start a request
+func roff(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
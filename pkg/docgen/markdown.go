@@ -0,0 +1,80 @@
+// ABOUTME: Markdown renderer for CLI reference docs
+// ABOUTME: Writes usage, arguments, flags, and subcommands as markdown sections
+
+package docgen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RenderMarkdown writes a markdown reference for cmd and every command
+// below it
+func RenderMarkdown(w io.Writer, cmd *Command) error {
+	var b strings.Builder
+	writeMarkdown(&b, cmd, 1)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdown writes one command at a heading level, then its subcommands
+func writeMarkdown(b *strings.Builder, cmd *Command, level int) {
+	heading := strings.Repeat("#", min(level, 6))
+	fmt.Fprintf(b, "%s %s\n\n", heading, cmd.Name)
+	if cmd.Help != "" {
+		fmt.Fprintf(b, "%s\n\n", cmd.Help)
+	}
+	if cmd.Usage != "" {
+		fmt.Fprintf(b, "```\n%s\n```\n\n", cmd.Usage)
+	}
+	if len(cmd.Aliases) > 0 {
+		fmt.Fprintf(b, "Aliases: %s\n\n", strings.Join(cmd.Aliases, ", "))
+	}
+	if cmd.Detail != "" && cmd.Detail != cmd.Help {
+		fmt.Fprintf(b, "```\n%s\n```\n\n", strings.TrimSpace(cmd.Detail))
+	}
+
+	if len(cmd.Args) > 0 {
+		b.WriteString("**Arguments**\n\n| Argument | Required | Description |\n| --- | --- | --- |\n")
+		for _, arg := range cmd.Args {
+			required := "no"
+			if arg.Required {
+				required = "yes"
+			}
+			fmt.Fprintf(b, "| `%s` | %s | %s |\n", arg.Name, required, escapeTable(arg.Help))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(cmd.Flags) > 0 {
+		b.WriteString("**Flags**\n\n| Flag | Default | Description |\n| --- | --- | --- |\n")
+		for _, flag := range cmd.Flags {
+			def := ""
+			if flag.Default != "" {
+				def = "`" + flag.Default + "`"
+			}
+			fmt.Fprintf(b, "| `%s` | %s | %s |\n", flag.Spec(), def, escapeTable(flag.Help))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(cmd.Subcommands) > 0 {
+		b.WriteString("**Commands**\n\n| Command | Description |\n| --- | --- |\n")
+		for _, sub := range cmd.Subcommands {
+			fmt.Fprintf(b, "| `%s` | %s |\n", sub.Name, escapeTable(sub.Help))
+		}
+		b.WriteString("\n")
+		for _, sub := range cmd.Subcommands {
+			if sub.Usage != "" {
+				writeMarkdown(b, sub, level+1)
+			}
+		}
+	}
+}
+
+// escapeTable makes text safe for a single markdown table cell
+func escapeTable(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -3,17 +3,50 @@
 
 package domain
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
 // Attachment represents multimodal content attached to a message.
 type Attachment struct {
 	ID       string                 `json:"id"`
 	Type     AttachmentType         `json:"type"`
 	Content  []byte                 `json:"content,omitempty"`
+	Hash     string                 `json:"hash,omitempty"` // Content hash when the content is stored separately
 	FilePath string                 `json:"file_path,omitempty"`
 	URL      string                 `json:"url,omitempty"`
 	Name     string                 `json:"name,omitempty"`
 	MimeType string                 `json:"mime_type,omitempty"`
 	Size     int64                  `json:"size,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	loader ContentLoader
+}
+
+// ContentLoader loads attachment content that is stored separately, by hash.
+type ContentLoader func(hash string) ([]byte, error)
+
+// contentHashPrefix names the hash function used for content hashes
+const contentHashPrefix = "sha256:"
+
+// ContentHash returns the hash attachment content is stored under, in the
+// form sha256:<hex>.
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return contentHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// IsContentHash reports whether s is a well-formed content hash.
+func IsContentHash(s string) bool {
+	digest, ok := strings.CutPrefix(s, contentHashPrefix)
+	if !ok || len(digest) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
 }
 
 // AttachmentType represents the type of attachment.
@@ -41,7 +74,30 @@ func NewAttachment(id string, attachmentType AttachmentType) *Attachment {
 func (a *Attachment) IsValid() bool {
 	return a.ID != "" &&
 		a.Type.IsValid() &&
-		(len(a.Content) > 0 || a.FilePath != "" || a.URL != "")
+		(len(a.Content) > 0 || a.Hash != "" || a.FilePath != "" || a.URL != "")
+}
+
+// SetContentLoader arranges for content stored separately to be loaded the
+// first time it is needed.
+func (a *Attachment) SetContentLoader(loader ContentLoader) {
+	a.loader = loader
+}
+
+// LoadContent returns the attachment content, loading it by hash if it is
+// stored separately and has not been loaded yet.
+func (a *Attachment) LoadContent() ([]byte, error) {
+	if len(a.Content) > 0 || a.Hash == "" {
+		return a.Content, nil
+	}
+	if a.loader == nil {
+		return nil, fmt.Errorf("no loader for attachment content %s", a.Hash)
+	}
+	content, err := a.loader(a.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attachment content %s: %w", a.Hash, err)
+	}
+	a.Content = content
+	return content, nil
 }
 
 // HasContent returns true if the attachment has content data.
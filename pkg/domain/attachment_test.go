@@ -189,3 +189,56 @@ func TestAttachmentType(t *testing.T) {
 		})
 	}
 }
+
+func TestContentHash(t *testing.T) {
+	hash := ContentHash([]byte("hello"))
+	if hash != "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("Unexpected hash %s", hash)
+	}
+	if !IsContentHash(hash) {
+		t.Errorf("Expected %s to be a content hash", hash)
+	}
+	for _, s := range []string{"", "2cf24dba", "sha256:xyz", "md5:" + hash[7:]} {
+		if IsContentHash(s) {
+			t.Errorf("Expected %q not to be a content hash", s)
+		}
+	}
+}
+
+func TestAttachment_LoadContent(t *testing.T) {
+	content := []byte("stored separately")
+	att := Attachment{ID: "att-1", Type: AttachmentTypeText, Hash: ContentHash(content)}
+	if !att.IsValid() {
+		t.Error("Expected attachment with only a hash to be valid")
+	}
+
+	if _, err := att.LoadContent(); err == nil {
+		t.Error("Expected error loading content without a loader")
+	}
+
+	loads := 0
+	att.SetContentLoader(func(hash string) ([]byte, error) {
+		loads++
+		if hash != att.Hash {
+			t.Errorf("Expected hash %s, got %s", att.Hash, hash)
+		}
+		return content, nil
+	})
+	for i := 0; i < 2; i++ {
+		loaded, err := att.LoadContent()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(loaded) != string(content) {
+			t.Errorf("Expected content %q, got %q", content, loaded)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("Expected content to be loaded once, got %d loads", loads)
+	}
+
+	inline := Attachment{ID: "att-2", Type: AttachmentTypeText, Content: []byte("inline")}
+	if loaded, err := inline.LoadContent(); err != nil || string(loaded) != "inline" {
+		t.Errorf("Expected inline content, got %q, %v", loaded, err)
+	}
+}
@@ -0,0 +1,73 @@
+// ABOUTME: Domain types for citations including Citation and TextSpan
+// ABOUTME: Core business entities for the sources an assistant message draws on
+
+package domain
+
+import "fmt"
+
+// Citation identifies a source an assistant message draws on, such as an
+// excerpt found by retrieval or a page returned by a search tool.
+type Citation struct {
+	URL        string    `json:"url,omitempty"`
+	FilePath   string    `json:"file_path,omitempty"`
+	Title      string    `json:"title,omitempty"`
+	Span       *TextSpan `json:"span,omitempty"`
+	Confidence float64   `json:"confidence,omitempty"`
+}
+
+// TextSpan locates the cited text within its source by line range.
+type TextSpan struct {
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+}
+
+// NewFileCitation creates a citation of lines in a file.
+func NewFileCitation(path string, startLine, endLine int, confidence float64) *Citation {
+	return &Citation{
+		FilePath:   path,
+		Span:       &TextSpan{StartLine: startLine, EndLine: endLine},
+		Confidence: confidence,
+	}
+}
+
+// NewURLCitation creates a citation of a web page.
+func NewURLCitation(url, title string, confidence float64) *Citation {
+	return &Citation{
+		URL:        url,
+		Title:      title,
+		Confidence: confidence,
+	}
+}
+
+// Source returns the URL or file path the citation refers to.
+func (c *Citation) Source() string {
+	if c.URL != "" {
+		return c.URL
+	}
+	return c.FilePath
+}
+
+// IsValid validates the citation fields.
+func (c *Citation) IsValid() bool {
+	return c.Source() != "" &&
+		c.Confidence >= 0 && c.Confidence <= 1 &&
+		(c.Span == nil || (c.Span.StartLine > 0 && c.Span.EndLine >= c.Span.StartLine))
+}
+
+// String returns the source with its line range, e.g. docs/intro.md:10-24.
+func (c *Citation) String() string {
+	if c.Span == nil {
+		return c.Source()
+	}
+	return fmt.Sprintf("%s:%d-%d", c.Source(), c.Span.StartLine, c.Span.EndLine)
+}
+
+// Clone creates a deep copy of the citation.
+func (c *Citation) Clone() Citation {
+	clone := *c
+	if c.Span != nil {
+		span := *c.Span
+		clone.Span = &span
+	}
+	return clone
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "testing"
+
+func TestCitation(t *testing.T) {
+	file := NewFileCitation("docs/intro.md", 10, 24, 0.82)
+	if !file.IsValid() {
+		t.Error("Expected file citation to be valid")
+	}
+	if file.String() != "docs/intro.md:10-24" {
+		t.Errorf("Expected docs/intro.md:10-24, got %s", file.String())
+	}
+
+	web := NewURLCitation("https://example.com/a", "Example", 0.5)
+	if !web.IsValid() || web.Source() != "https://example.com/a" || web.String() != "https://example.com/a" {
+		t.Errorf("Unexpected URL citation %+v", web)
+	}
+
+	invalid := []Citation{
+		{},
+		{FilePath: "a.md", Confidence: 1.5},
+		{FilePath: "a.md", Span: &TextSpan{StartLine: 5, EndLine: 2}},
+	}
+	for _, c := range invalid {
+		if c.IsValid() {
+			t.Errorf("Expected citation %+v to be invalid", c)
+		}
+	}
+
+	msg := NewMessage("msg-1", MessageRoleAssistant, "Answer")
+	msg.AddCitation(*file)
+	clone := msg.Clone()
+	clone.Citations[0].Span.EndLine = 99
+	if msg.Citations[0].Span.EndLine != 24 {
+		t.Error("Expected clone to deep copy citation spans")
+	}
+}
@@ -102,6 +102,21 @@ func (c *Conversation) ClearMessages() {
 	c.Updated = time.Now()
 }
 
+// RemoveLastExchange removes the most recent user message and every message
+// after it, returning the removed messages. It returns nil if there is no
+// user message.
+func (c *Conversation) RemoveLastExchange() []Message {
+	for i := len(c.Messages) - 1; i >= 0; i-- {
+		if c.Messages[i].Role == MessageRoleUser {
+			removed := append([]Message{}, c.Messages[i:]...)
+			c.Messages = c.Messages[:i]
+			c.Updated = time.Now()
+			return removed
+		}
+	}
+	return nil
+}
+
 // IsEmpty returns true if the conversation has no messages.
 func (c *Conversation) IsEmpty() bool {
 	return len(c.Messages) == 0
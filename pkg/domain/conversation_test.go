@@ -156,3 +156,31 @@ func TestConversationClearMessages(t *testing.T) {
 		t.Error("Conversation should be empty after clearing")
 	}
 }
+
+func TestConversationRemoveLastExchange(t *testing.T) {
+	conv := NewConversation("test")
+
+	if removed := conv.RemoveLastExchange(); removed != nil {
+		t.Error("Expected nothing removed from empty conversation")
+	}
+
+	conv.AddMessage(*NewMessage("1", MessageRoleUser, "First"))
+	conv.AddMessage(*NewMessage("2", MessageRoleAssistant, "First reply"))
+	conv.AddMessage(*NewMessage("3", MessageRoleUser, "Second"))
+	conv.AddMessage(*NewMessage("4", MessageRoleAssistant, "Second reply"))
+
+	removed := conv.RemoveLastExchange()
+	if len(removed) != 2 || removed[0].ID != "3" || removed[1].ID != "4" {
+		t.Errorf("Expected last exchange removed, got %v", removed)
+	}
+	if len(conv.Messages) != 2 || conv.Messages[1].ID != "2" {
+		t.Errorf("Expected first exchange to remain, got %v", conv.Messages)
+	}
+
+	// A trailing user message without a reply is removed on its own
+	conv.AddMessage(*NewMessage("5", MessageRoleUser, "Unanswered"))
+	removed = conv.RemoveLastExchange()
+	if len(removed) != 1 || removed[0].ID != "5" {
+		t.Errorf("Expected unanswered message removed, got %v", removed)
+	}
+}
@@ -0,0 +1,83 @@
+// ABOUTME: Message-level diff between two conversations
+// ABOUTME: Reports messages added, removed, changed, or kept between sessions and branches
+
+package domain
+
+// DiffOp is the kind of change to a message
+type DiffOp string
+
+// DiffOp constants describe how a message differs between conversations.
+const (
+	DiffEqual   DiffOp = "equal"
+	DiffAdded   DiffOp = "added"
+	DiffRemoved DiffOp = "removed"
+	DiffChanged DiffOp = "changed"
+)
+
+// MessageDiff is one entry of a conversation diff. Old is set for equal,
+// removed, and changed entries; New for equal, added, and changed entries.
+// Indexes are zero-based positions in each conversation, or -1.
+type MessageDiff struct {
+	Op       DiffOp
+	Old      *Message
+	New      *Message
+	OldIndex int
+	NewIndex int
+}
+
+// DiffMessages compares two message lists by role and content. A message
+// removed and replaced by one with the same role at the same place is
+// reported as changed.
+func DiffMessages(oldMessages, newMessages []Message) []MessageDiff {
+	same := func(i, j int) bool {
+		return oldMessages[i].Role == newMessages[j].Role && oldMessages[i].Content == newMessages[j].Content
+	}
+
+	// Longest common subsequence table, filled from the end
+	n, m := len(oldMessages), len(newMessages)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if same(i, j) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diffs []MessageDiff
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && same(i, j):
+			diffs = append(diffs, MessageDiff{Op: DiffEqual, Old: &oldMessages[i], New: &newMessages[j], OldIndex: i, NewIndex: j})
+			i++
+			j++
+		case i < n && j < m && oldMessages[i].Role == newMessages[j].Role && lcs[i+1][j+1] == lcs[i][j]:
+			diffs = append(diffs, MessageDiff{Op: DiffChanged, Old: &oldMessages[i], New: &newMessages[j], OldIndex: i, NewIndex: j})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			diffs = append(diffs, MessageDiff{Op: DiffAdded, New: &newMessages[j], OldIndex: -1, NewIndex: j})
+			j++
+		default:
+			diffs = append(diffs, MessageDiff{Op: DiffRemoved, Old: &oldMessages[i], OldIndex: i, NewIndex: -1})
+			i++
+		}
+	}
+	return diffs
+}
+
+// HasChanges reports whether a diff contains anything but equal messages
+func HasChanges(diffs []MessageDiff) bool {
+	for _, d := range diffs {
+		if d.Op != DiffEqual {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+// ABOUTME: Tests for the message-level conversation diff
+// ABOUTME: Verifies added, removed, changed, and equal messages are detected
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffMessages(t *testing.T) {
+	msg := func(role MessageRole, content string) Message {
+		return Message{Role: role, Content: content}
+	}
+	ops := func(diffs []MessageDiff) []DiffOp {
+		var result []DiffOp
+		for _, d := range diffs {
+			result = append(result, d.Op)
+		}
+		return result
+	}
+
+	base := []Message{
+		msg(MessageRoleUser, "hi"),
+		msg(MessageRoleAssistant, "hello"),
+	}
+
+	t.Run("identical", func(t *testing.T) {
+		diffs := DiffMessages(base, base)
+		assert.Equal(t, []DiffOp{DiffEqual, DiffEqual}, ops(diffs))
+		assert.False(t, HasChanges(diffs))
+	})
+
+	t.Run("appended messages", func(t *testing.T) {
+		extended := append(append([]Message{}, base...), msg(MessageRoleUser, "more"), msg(MessageRoleAssistant, "sure"))
+		diffs := DiffMessages(base, extended)
+		assert.Equal(t, []DiffOp{DiffEqual, DiffEqual, DiffAdded, DiffAdded}, ops(diffs))
+		assert.Equal(t, 2, diffs[2].NewIndex)
+		assert.Equal(t, -1, diffs[2].OldIndex)
+		assert.True(t, HasChanges(diffs))
+	})
+
+	t.Run("changed and removed", func(t *testing.T) {
+		branch := []Message{msg(MessageRoleUser, "hi"), msg(MessageRoleAssistant, "hey there")}
+		diffs := DiffMessages(base, branch)
+		assert.Equal(t, []DiffOp{DiffEqual, DiffChanged}, ops(diffs))
+		assert.Equal(t, "hello", diffs[1].Old.Content)
+		assert.Equal(t, "hey there", diffs[1].New.Content)
+
+		diffs = DiffMessages(base, base[:1])
+		assert.Equal(t, []DiffOp{DiffEqual, DiffRemoved}, ops(diffs))
+	})
+
+	t.Run("role change is not a change of content", func(t *testing.T) {
+		diffs := DiffMessages(base[:1], []Message{msg(MessageRoleSystem, "hi")})
+		assert.Equal(t, []DiffOp{DiffAdded, DiffRemoved}, ops(diffs))
+	})
+}
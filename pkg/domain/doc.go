@@ -28,6 +28,12 @@ Key types:
   - Message: Individual message within a conversation
   - Conversation: Manages the state of an interactive conversation
   - Attachment: Multimodal content attached to messages
+  - ToolCall/ToolResult: Tools a model calls and the results sent back to it
+  - Citation: Sources an assistant message draws on
+  - Usage: Tokens, cost, and latency of each response and their session total
+  - SessionTemplate: Preconfigured starting point for new sessions
+  - Event/EventBus: Session and message changes that other features subscribe to
+  - SearchQuery: Parsed search text with tag, model, role and date filters
   - Provider/Model: LLM provider and model configurations
 */
 package domain
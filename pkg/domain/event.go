@@ -0,0 +1,158 @@
+// ABOUTME: Domain events and a lightweight publish/subscribe event bus
+// ABOUTME: Lets features react to session and message changes without depending on each other
+
+package domain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// EventType names something that happened to a session or the configuration.
+type EventType string
+
+// EventType constants define the domain events.
+const (
+	EventSessionCreated  EventType = "session.created"  // A session was saved for the first time
+	EventSessionSaved    EventType = "session.saved"    // An existing session was saved again
+	EventSessionDeleted  EventType = "session.deleted"  // A session was deleted
+	EventSessionBranched EventType = "session.branched" // A branch was saved for the first time; Data holds parent_id and branch_point
+	EventSessionMerged   EventType = "session.merged"   // Sessions were merged; Data holds source_id, target_id and merged_count
+	EventSessionExported EventType = "session.exported" // A session was exported; Data holds format
+	EventMessageAdded    EventType = "message.added"    // A new message was added to a session
+	EventConfigChanged   EventType = "config.changed"   // A configuration key was set or removed; Data holds key, removed and persisted
+)
+
+// Event describes a change to a session.
+type Event struct {
+	Type      EventType
+	SessionID string
+	Session   *Session               // The session after the change; nil when deleted
+	Message   *Message               // A copy of the added message, for EventMessageAdded
+	Data      map[string]interface{} // Details specific to the event type
+	Time      time.Time
+}
+
+// NewEvent creates an event of the given type for a session.
+func NewEvent(eventType EventType, sessionID string) Event {
+	return Event{
+		Type:      eventType,
+		SessionID: sessionID,
+		Data:      make(map[string]interface{}),
+		Time:      time.Now(),
+	}
+}
+
+// EventHandler is called with each event it is subscribed to.
+type EventHandler func(Event)
+
+// EventBus delivers events to subscribed handlers. Handlers are called
+// synchronously, in the order they subscribed, so they should return quickly
+// and hand slow work to a goroutine. A panicking handler is logged and does
+// not stop delivery to the others.
+type EventBus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers []eventSubscription
+}
+
+// eventSubscription is a handler registered for one event type, or all
+// types when eventType is empty
+type eventSubscription struct {
+	id        int
+	eventType EventType
+	handler   EventHandler
+}
+
+// NewEventBus creates an event bus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// DefaultEventBus is the bus domain events are published on.
+var DefaultEventBus = NewEventBus()
+
+// Subscribe registers a handler for events of the given type. It returns a
+// function that removes the subscription.
+func (b *EventBus) Subscribe(eventType EventType, handler EventHandler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.handlers = append(b.handlers, eventSubscription{id: id, eventType: eventType, handler: handler})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, sub := range b.handlers {
+			if sub.id == id {
+				b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// SubscribeAll registers a handler for every event. It returns a function
+// that removes the subscription.
+func (b *EventBus) SubscribeAll(handler EventHandler) func() {
+	return b.Subscribe("", handler)
+}
+
+// Publish delivers an event to the handlers subscribed to its type.
+func (b *EventBus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	// Handlers may subscribe or unsubscribe while the event is delivered
+	b.mu.RLock()
+	var handlers []EventHandler
+	for _, sub := range b.handlers {
+		if sub.eventType == "" || sub.eventType == event.Type {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		deliverEvent(handler, event)
+	}
+}
+
+// HasSubscribers returns true if any handler would receive events of the given type.
+func (b *EventBus) HasSubscribers(eventType EventType) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, sub := range b.handlers {
+		if sub.eventType == "" || sub.eventType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishMessageAdded announces a new message in a session on the default
+// event bus.
+func PublishMessageAdded(sessionID string, message Message) {
+	if !DefaultEventBus.HasSubscribers(EventMessageAdded) {
+		return
+	}
+	event := NewEvent(EventMessageAdded, sessionID)
+	event.Message = &message
+	DefaultEventBus.Publish(event)
+}
+
+// deliverEvent calls a handler, recovering from a panic in it
+func deliverEvent(handler EventHandler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.LogError(fmt.Errorf("%v", r), "Event handler panicked", "event", event.Type, "session_id", event.SessionID)
+		}
+	}()
+	handler(event)
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestEventBus_SubscribePublish(t *testing.T) {
+	bus := NewEventBus()
+
+	var created, all []Event
+	unsubscribeCreated := bus.Subscribe(EventSessionCreated, func(e Event) { created = append(created, e) })
+	unsubscribeAll := bus.SubscribeAll(func(e Event) { all = append(all, e) })
+
+	if !bus.HasSubscribers(EventSessionDeleted) {
+		t.Error("Expected the catch-all handler to count as a subscriber")
+	}
+
+	bus.Publish(NewEvent(EventSessionCreated, "session-1"))
+	bus.Publish(NewEvent(EventSessionDeleted, "session-1"))
+
+	if len(created) != 1 || created[0].SessionID != "session-1" {
+		t.Errorf("Expected one created event for session-1, got %v", created)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected the catch-all handler to get 2 events, got %d", len(all))
+	}
+	if created[0].Time.IsZero() {
+		t.Error("Expected the event to have a time")
+	}
+
+	unsubscribeCreated()
+	unsubscribeCreated() // Unsubscribing twice is harmless
+	bus.Publish(NewEvent(EventSessionCreated, "session-2"))
+	if len(created) != 1 {
+		t.Errorf("Expected no events after unsubscribing, got %d", len(created))
+	}
+	if len(all) != 3 {
+		t.Errorf("Expected the remaining handler to get the event, got %d events", len(all))
+	}
+
+	unsubscribeAll()
+	if bus.HasSubscribers(EventSessionCreated) {
+		t.Error("Expected no subscribers after unsubscribing")
+	}
+}
+
+func TestEventBus_PanickingHandler(t *testing.T) {
+	bus := NewEventBus()
+	delivered := false
+	bus.SubscribeAll(func(Event) { panic("broken handler") })
+	bus.SubscribeAll(func(Event) { delivered = true })
+
+	bus.Publish(NewEvent(EventMessageAdded, "session-1"))
+	if !delivered {
+		t.Error("Expected delivery to continue after a handler panicked")
+	}
+}
+
+func TestEventBus_SubscribeFromHandler(t *testing.T) {
+	bus := NewEventBus()
+	var mu sync.Mutex
+	count := 0
+	bus.SubscribeAll(func(Event) {
+		bus.SubscribeAll(func(Event) {
+			mu.Lock()
+			count++
+			mu.Unlock()
+		})
+	})
+
+	bus.Publish(NewEvent(EventSessionSaved, "session-1"))
+	if count != 0 {
+		t.Errorf("Expected a handler added during delivery to miss that event, got %d calls", count)
+	}
+	bus.Publish(NewEvent(EventSessionSaved, "session-1"))
+	if count != 1 {
+		t.Errorf("Expected the new handler to get the next event, got %d calls", count)
+	}
+}
+
+func TestPublishMessageAdded(t *testing.T) {
+	var got []Event
+	unsubscribe := DefaultEventBus.Subscribe(EventMessageAdded, func(e Event) { got = append(got, e) })
+	defer unsubscribe()
+
+	PublishMessageAdded("session-1", *NewMessage("msg-1", MessageRoleUser, "hello"))
+
+	if len(got) != 1 {
+		t.Fatalf("Expected one event, got %d", len(got))
+	}
+	if got[0].SessionID != "session-1" || got[0].Message == nil || got[0].Message.Content != "hello" {
+		t.Errorf("Unexpected event %+v", got[0])
+	}
+}
@@ -14,9 +14,23 @@ type Message struct {
 	Content     string                 `json:"content"`
 	Timestamp   time.Time              `json:"timestamp"`
 	Attachments []Attachment           `json:"attachments,omitempty"`
+	ToolCalls   []ToolCall             `json:"tool_calls,omitempty"`
+	ToolResults []ToolResult           `json:"tool_results,omitempty"`
+	Revisions   []MessageRevision      `json:"revisions,omitempty"`
+	Citations   []Citation             `json:"citations,omitempty"`
+	Usage       *Usage                 `json:"usage,omitempty"`
+	Pinned      bool                   `json:"pinned,omitempty"` // Never dropped when fitting the context window
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// MessageRevision is an earlier version of a message's content, kept when
+// the message is edited.
+type MessageRevision struct {
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	RevisedAt time.Time `json:"revised_at"`
+}
+
 // MessageRole represents the role of a message sender.
 type MessageRole string
 
@@ -25,6 +39,7 @@ const (
 	MessageRoleUser      MessageRole = "user"
 	MessageRoleAssistant MessageRole = "assistant"
 	MessageRoleSystem    MessageRole = "system"
+	MessageRoleTool      MessageRole = "tool"
 )
 
 // NewMessage creates a new message with the given parameters.
@@ -55,12 +70,69 @@ func (m *Message) RemoveAttachment(attachmentID string) {
 	m.Attachments = attachments
 }
 
+// Revise replaces the message content, keeping the previous content as the
+// latest revision.
+func (m *Message) Revise(content string) {
+	now := time.Now()
+	m.Revisions = append(m.Revisions, MessageRevision{
+		Content:   m.Content,
+		Timestamp: m.Timestamp,
+		RevisedAt: now,
+	})
+	m.Content = content
+	m.Timestamp = now
+}
+
+// IsRevised returns true if the message was edited.
+func (m *Message) IsRevised() bool {
+	return len(m.Revisions) > 0
+}
+
+// OriginalContent returns the content the message was first written with.
+func (m *Message) OriginalContent() string {
+	if len(m.Revisions) > 0 {
+		return m.Revisions[0].Content
+	}
+	return m.Content
+}
+
+// AddCitation adds a source the message draws on.
+func (m *Message) AddCitation(citation Citation) {
+	m.Citations = append(m.Citations, citation)
+}
+
+// AddToolCall adds a tool call requested by the model to the message.
+func (m *Message) AddToolCall(call ToolCall) {
+	m.ToolCalls = append(m.ToolCalls, call)
+}
+
+// AddToolResult adds the result of a tool call to the message.
+func (m *Message) AddToolResult(result ToolResult) {
+	m.ToolResults = append(m.ToolResults, result)
+}
+
+// HasToolUse returns true if the message carries tool calls or results.
+func (m *Message) HasToolUse() bool {
+	return len(m.ToolCalls) > 0 || len(m.ToolResults) > 0
+}
+
 // IsValid validates the message fields.
 func (m *Message) IsValid() bool {
-	return m.ID != "" &&
-		m.Role != "" &&
-		(m.Role == MessageRoleUser || m.Role == MessageRoleAssistant || m.Role == MessageRoleSystem) &&
-		(m.Content != "" || len(m.Attachments) > 0) // Message must have content or attachments
+	if m.ID == "" || !m.Role.IsValid() {
+		return false
+	}
+	for i := range m.ToolCalls {
+		if !m.ToolCalls[i].IsValid() {
+			return false
+		}
+	}
+	for i := range m.ToolResults {
+		if !m.ToolResults[i].IsValid() {
+			return false
+		}
+	}
+	// Message must have content, attachments, or tool use
+	return m.Content != "" || len(m.Attachments) > 0 || m.HasToolUse()
 }
 
 // String returns the message role as a string.
@@ -70,7 +142,7 @@ func (r MessageRole) String() string {
 
 // IsValid checks if the message role is valid.
 func (r MessageRole) IsValid() bool {
-	return r == MessageRoleUser || r == MessageRoleAssistant || r == MessageRoleSystem
+	return r == MessageRoleUser || r == MessageRoleAssistant || r == MessageRoleSystem || r == MessageRoleTool
 }
 
 // Clone creates a deep copy of the message.
@@ -80,6 +152,7 @@ func (m *Message) Clone() Message {
 		Role:        m.Role,
 		Content:     m.Content,
 		Timestamp:   m.Timestamp,
+		Pinned:      m.Pinned,
 		Attachments: make([]Attachment, len(m.Attachments)),
 		Metadata:    make(map[string]interface{}),
 	}
@@ -87,6 +160,29 @@ func (m *Message) Clone() Message {
 	// Deep copy attachments
 	copy(clone.Attachments, m.Attachments)
 
+	// Deep copy tool use
+	for _, call := range m.ToolCalls {
+		clone.ToolCalls = append(clone.ToolCalls, call.Clone())
+	}
+	for _, result := range m.ToolResults {
+		clone.ToolResults = append(clone.ToolResults, result.Clone())
+	}
+
+	// Copy revisions
+	if len(m.Revisions) > 0 {
+		clone.Revisions = append([]MessageRevision{}, m.Revisions...)
+	}
+
+	// Deep copy citations
+	for _, citation := range m.Citations {
+		clone.Citations = append(clone.Citations, citation.Clone())
+	}
+
+	if m.Usage != nil {
+		usage := *m.Usage
+		clone.Usage = &usage
+	}
+
 	// Deep copy metadata
 	for k, v := range m.Metadata {
 		clone.Metadata[k] = v
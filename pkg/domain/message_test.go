@@ -167,3 +167,34 @@ func TestMessageRole(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageRevise(t *testing.T) {
+	msg := NewMessage("msg-1", MessageRoleUser, "first")
+	written := msg.Timestamp
+
+	if msg.IsRevised() || msg.OriginalContent() != "first" {
+		t.Error("Expected a new message to have no revisions")
+	}
+
+	msg.Revise("second")
+	msg.Revise("third")
+
+	if msg.Content != "third" {
+		t.Errorf("Expected content 'third', got %s", msg.Content)
+	}
+	if len(msg.Revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(msg.Revisions))
+	}
+	if msg.Revisions[0].Content != "first" || !msg.Revisions[0].Timestamp.Equal(written) {
+		t.Errorf("Expected the first revision to keep the original text and time, got %+v", msg.Revisions[0])
+	}
+	if msg.OriginalContent() != "first" {
+		t.Errorf("Expected original content 'first', got %s", msg.OriginalContent())
+	}
+
+	clone := msg.Clone()
+	clone.Revisions[0].Content = "changed"
+	if msg.Revisions[0].Content != "first" {
+		t.Error("Expected clone to copy revisions")
+	}
+}
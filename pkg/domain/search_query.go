@@ -0,0 +1,279 @@
+// ABOUTME: Structured search queries with field filters and exact phrases
+// ABOUTME: Parses queries like `tag:work role:assistant "exact phrase"` and matches sessions against them
+
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidSearchQuery is returned for a filter with a value it cannot use.
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+
+// SearchQuery is a parsed search query. Text is matched case-insensitively:
+// a name, system prompt, tag or message matches when it contains every term
+// and phrase. Filters narrow the sessions that are searched.
+//
+//	word             the text contains word
+//	"exact phrase"   the text contains the phrase
+//	tag:work         the session has the tag (repeat for several tags)
+//	model:gpt-4o     the session uses the model, with or without its provider
+//	provider:openai  the session uses the provider
+//	role:assistant   only messages with the role match
+//	before:DATE      the session was created before DATE (2006-01-02 or RFC 3339)
+//	after:DATE       the session was created on or after DATE
+//
+// A filter value may be quoted, as in tag:"deep work". A word with a prefix
+// that is not a filter, such as http://example.com, is searched as text.
+type SearchQuery struct {
+	Terms    []string // lowercased words the text must contain
+	Phrases  []string // lowercased phrases the text must contain
+	Tags     []string
+	Model    string
+	Provider string
+	Role     MessageRole
+	Before   time.Time // zero when not set
+	After    time.Time // zero when not set
+}
+
+// searchFilters are the prefixes recognised as filters
+var searchFilters = map[string]bool{
+	"tag":      true,
+	"model":    true,
+	"provider": true,
+	"role":     true,
+	"before":   true,
+	"after":    true,
+}
+
+// ParseSearchQuery parses a search query. An unterminated quote runs to the
+// end of the query.
+func ParseSearchQuery(query string) (*SearchQuery, error) {
+	q := &SearchQuery{}
+	for _, token := range tokenizeSearchQuery(query) {
+		if token.quoted {
+			if token.text != "" {
+				q.Phrases = append(q.Phrases, strings.ToLower(token.text))
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(token.text, ":")
+		key = strings.ToLower(key)
+		if !found || value == "" || !searchFilters[key] {
+			q.Terms = append(q.Terms, strings.ToLower(token.text))
+			continue
+		}
+		if err := q.setFilter(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// setFilter applies one key:value filter
+func (q *SearchQuery) setFilter(key, value string) error {
+	switch key {
+	case "tag":
+		q.Tags = append(q.Tags, value)
+	case "model":
+		q.Model = value
+	case "provider":
+		q.Provider = value
+	case "role":
+		role := MessageRole(strings.ToLower(value))
+		switch role {
+		case MessageRoleUser, MessageRoleAssistant, MessageRoleSystem, MessageRoleTool:
+			q.Role = role
+		default:
+			return fmt.Errorf("%w: unknown role %q", ErrInvalidSearchQuery, value)
+		}
+	case "before", "after":
+		t, err := parseSearchDate(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s:%s: %v", ErrInvalidSearchQuery, key, value, err)
+		}
+		if key == "before" {
+			q.Before = t
+		} else {
+			q.After = t
+		}
+	}
+	return nil
+}
+
+// parseSearchDate accepts a date in local time or an RFC 3339 timestamp
+func parseSearchDate(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("use a date like 2025-01-31 or an RFC 3339 timestamp")
+	}
+	return t, nil
+}
+
+// searchToken is a word or quoted phrase of a query. A filter with a quoted
+// value, such as tag:"deep work", is a single unquoted token.
+type searchToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeSearchQuery splits a query on whitespace, keeping quoted text together
+func tokenizeSearchQuery(query string) []searchToken {
+	var (
+		tokens  []searchToken
+		current strings.Builder
+		inQuote bool
+		quoted  bool // the token started with a quote
+		started bool
+	)
+	flush := func() {
+		if started {
+			tokens = append(tokens, searchToken{text: current.String(), quoted: quoted})
+		}
+		current.Reset()
+		started, quoted = false, false
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			if !started {
+				quoted = true
+			}
+			started = true
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			flush()
+		default:
+			started = true
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// HasText returns true if the query has terms or phrases to match.
+func (q *SearchQuery) HasText() bool {
+	return len(q.Terms) > 0 || len(q.Phrases) > 0
+}
+
+// TextPatterns returns the lowercased terms and phrases the text must contain.
+func (q *SearchQuery) TextPatterns() []string {
+	patterns := make([]string, 0, len(q.Terms)+len(q.Phrases))
+	patterns = append(patterns, q.Terms...)
+	return append(patterns, q.Phrases...)
+}
+
+// Highlight returns the text to centre snippets on: the first phrase, or the
+// first term.
+func (q *SearchQuery) Highlight() string {
+	if len(q.Phrases) > 0 {
+		return q.Phrases[0]
+	}
+	if len(q.Terms) > 0 {
+		return q.Terms[0]
+	}
+	return ""
+}
+
+// MatchesText returns true if content contains every term and phrase.
+func (q *SearchQuery) MatchesText(content string) bool {
+	lower := strings.ToLower(content)
+	for _, pattern := range q.TextPatterns() {
+		if !strings.Contains(lower, pattern) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesField returns true if a session name, system prompt or tag matches
+// the text of the query. Fields never match a query limited to a role.
+func (q *SearchQuery) MatchesField(content string) bool {
+	return q.HasText() && q.Role == "" && q.MatchesText(content)
+}
+
+// MatchesMessage returns true if a message matches the role and text of the
+// query. Without text, every message with the role matches.
+func (q *SearchQuery) MatchesMessage(msg Message) bool {
+	if q.Role != "" && msg.Role != q.Role {
+		return false
+	}
+	if !q.HasText() {
+		return q.Role != ""
+	}
+	return q.MatchesText(msg.Content)
+}
+
+// MatchesSession returns true if a session passes the tag, model, provider
+// and date filters.
+func (q *SearchQuery) MatchesSession(info *SessionInfo) bool {
+	for _, tag := range q.Tags {
+		found := false
+		for _, sessionTag := range info.Tags {
+			if strings.EqualFold(sessionTag, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if q.Model != "" && !matchesModel(info, q.Model) {
+		return false
+	}
+	if q.Provider != "" && !strings.EqualFold(info.Provider, q.Provider) {
+		return false
+	}
+	if !q.Before.IsZero() && !info.Created.Before(q.Before) {
+		return false
+	}
+	if !q.After.IsZero() && info.Created.Before(q.After) {
+		return false
+	}
+	return true
+}
+
+// matchesModel returns true if a session uses the model, named as stored,
+// as provider/model, or without the provider prefix stored with it
+func matchesModel(info *SessionInfo, model string) bool {
+	if strings.EqualFold(info.Model, model) || strings.EqualFold(info.Provider+"/"+info.Model, model) {
+		return true
+	}
+	_, name, found := strings.Cut(info.Model, "/")
+	return found && strings.EqualFold(name, model)
+}
+
+// FilterMatches returns the matches reported for a session found by filters
+// alone, when the query has no text or role: the tags it was filtered by, or
+// else its name.
+func (q *SearchQuery) FilterMatches(info *SessionInfo) []SearchMatch {
+	if q.HasText() || q.Role != "" {
+		return nil
+	}
+
+	var matches []SearchMatch
+	for _, tag := range q.Tags {
+		for _, sessionTag := range info.Tags {
+			if strings.EqualFold(sessionTag, tag) {
+				matches = append(matches, NewSearchMatch(SearchMatchTypeTag, "", sessionTag, "Tag", -1))
+				break
+			}
+		}
+	}
+	if len(matches) == 0 {
+		matches = append(matches, NewSearchMatch(SearchMatchTypeName, "", info.Name, "Session Name", -1))
+	}
+	return matches
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	q, err := ParseSearchQuery(`tag:work model:gpt-4o before:2025-01-01 "Exact Phrase" role:assistant Hello tag:"deep work" http://x`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(q.Terms, []string{"hello", "http://x"}) {
+		t.Errorf("Expected terms [hello http://x], got %v", q.Terms)
+	}
+	if !reflect.DeepEqual(q.Phrases, []string{"exact phrase"}) {
+		t.Errorf("Expected phrase [exact phrase], got %v", q.Phrases)
+	}
+	if !reflect.DeepEqual(q.Tags, []string{"work", "deep work"}) {
+		t.Errorf("Expected tags [work, deep work], got %v", q.Tags)
+	}
+	if q.Model != "gpt-4o" || q.Role != MessageRoleAssistant {
+		t.Errorf("Expected model gpt-4o and role assistant, got %q and %q", q.Model, q.Role)
+	}
+	if want := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local); !q.Before.Equal(want) {
+		t.Errorf("Expected before %v, got %v", want, q.Before)
+	}
+	if !q.After.IsZero() {
+		t.Errorf("Expected no after filter, got %v", q.After)
+	}
+	if q.Highlight() != "exact phrase" {
+		t.Errorf("Expected the phrase to be highlighted, got %q", q.Highlight())
+	}
+
+	for _, query := range []string{"role:robot", "before:yesterday", "after:2025-13-01"} {
+		if _, err := ParseSearchQuery(query); !errors.Is(err, ErrInvalidSearchQuery) {
+			t.Errorf("Expected ErrInvalidSearchQuery for %q, got %v", query, err)
+		}
+	}
+
+	// Plain queries keep working as before
+	q, _ = ParseSearchQuery("python code")
+	if !q.MatchesText("Some Python code here") || q.MatchesText("python only") {
+		t.Errorf("Expected every word to be required, got %+v", q)
+	}
+}
+
+func TestSearchQueryMatching(t *testing.T) {
+	info := &SessionInfo{
+		Name:     "Work notes",
+		Created:  time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		Model:    "openai/gpt-4o",
+		Provider: "openai",
+		Tags:     []string{"Work", "go"},
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"tag:work", true},
+		{"tag:work tag:go", true},
+		{"tag:work tag:rust", false},
+		{"model:gpt-4o", true},
+		{"model:openai/gpt-4o", true},
+		{"model:gpt-4", false},
+		{"provider:OpenAI", true},
+		{"provider:anthropic", false},
+		{"before:2025-01-01", true},
+		{"before:2024-01-01", false},
+		{"after:2024-01-01", true},
+		{"after:2024-06-02", false},
+	}
+	for _, tt := range tests {
+		q, err := ParseSearchQuery(tt.query)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tt.query, err)
+		}
+		if got := q.MatchesSession(info); got != tt.want {
+			t.Errorf("MatchesSession(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	q, _ := ParseSearchQuery(`role:assistant "go code"`)
+	if !q.MatchesMessage(*NewMessage("m1", MessageRoleAssistant, "Here is the Go code")) {
+		t.Error("Expected assistant message with the phrase to match")
+	}
+	if q.MatchesMessage(*NewMessage("m2", MessageRoleUser, "Here is the Go code")) {
+		t.Error("Expected user message not to match role:assistant")
+	}
+	if q.MatchesField("go code") {
+		t.Error("Expected fields not to match a query limited to a role")
+	}
+
+	q, _ = ParseSearchQuery("role:user")
+	if !q.MatchesMessage(*NewMessage("m3", MessageRoleUser, "anything")) {
+		t.Error("Expected a role filter without text to match every message of the role")
+	}
+
+	q, _ = ParseSearchQuery("tag:go")
+	matches := q.FilterMatches(info)
+	if len(matches) != 1 || matches[0].Type != SearchMatchTypeTag || matches[0].Content != "go" {
+		t.Errorf("Expected a tag match for a filter-only query, got %+v", matches)
+	}
+	q, _ = ParseSearchQuery("go")
+	if matches := q.FilterMatches(info); matches != nil {
+		t.Errorf("Expected no filter matches for a text query, got %+v", matches)
+	}
+}
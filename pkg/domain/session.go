@@ -4,7 +4,10 @@
 package domain
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/lexlapax/magellai/internal/logging"
@@ -21,6 +24,7 @@ type Session struct {
 	Updated      time.Time              `json:"updated"`
 	Tags         []string               `json:"tags,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	ExpiresAt    *time.Time             `json:"expires_at,omitempty"` // Ephemeral sessions are deleted after this time
 
 	// Branching support
 	ParentID    string   `json:"parent_id,omitempty"`    // ID of the parent session if this is a branch
@@ -46,6 +50,8 @@ type SessionInfo struct {
 	BranchName string `json:"branch_name,omitempty"`
 	ChildCount int    `json:"child_count,omitempty"`
 	IsBranch   bool   `json:"is_branch,omitempty"`
+
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 // NewSession creates a new session with the given ID.
@@ -67,6 +73,33 @@ func (s *Session) UpdateTimestamp() {
 	s.Updated = time.Now()
 }
 
+// SetExpiry makes the session ephemeral: it expires ttl from now. A ttl of
+// zero or less clears the expiry.
+func (s *Session) SetExpiry(ttl time.Duration) {
+	if ttl <= 0 {
+		s.ExpiresAt = nil
+	} else {
+		expiresAt := time.Now().Add(ttl)
+		s.ExpiresAt = &expiresAt
+	}
+	s.UpdateTimestamp()
+}
+
+// IsEphemeral returns true if the session has an expiry time.
+func (s *Session) IsEphemeral() bool {
+	return s.ExpiresAt != nil
+}
+
+// IsExpired returns true if the session's expiry time has passed at now.
+func (s *Session) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// IsExpired returns true if the session's expiry time has passed at now.
+func (i *SessionInfo) IsExpired(now time.Time) bool {
+	return i.ExpiresAt != nil && !now.Before(*i.ExpiresAt)
+}
+
 // AddTag adds a tag to the session if it doesn't already exist.
 func (s *Session) AddTag(tag string) {
 	for _, t := range s.Tags {
@@ -98,6 +131,7 @@ func (s *Session) ToSessionInfo() *SessionInfo {
 		Created:    s.Created,
 		Updated:    s.Updated,
 		Tags:       s.Tags,
+		ExpiresAt:  s.ExpiresAt,
 		ParentID:   s.ParentID,
 		BranchName: s.BranchName,
 		ChildCount: len(s.ChildIDs),
@@ -133,6 +167,7 @@ func (s *Session) CreateBranch(branchID string, branchName string, messageIndex
 		BranchName:  branchName,
 		ChildIDs:    []string{},
 		Metadata:    make(map[string]interface{}),
+		ExpiresAt:   s.ExpiresAt, // Branches of an ephemeral session expire with it
 	}
 
 	// Create the conversation with messages up to the branch point
@@ -158,7 +193,7 @@ func (s *Session) CreateBranch(branchID string, branchName string, messageIndex
 
 	// Copy messages up to the branch point
 	for i := 0; i < messageIndex && i < len(s.Conversation.Messages); i++ {
-		msgCopy := s.Conversation.Messages[i]
+		msgCopy := s.Conversation.Messages[i].Clone()
 		msgCopy.ID = generateMessageID() // Generate new ID for the copy
 		branch.Conversation.Messages = append(branch.Conversation.Messages, msgCopy)
 	}
@@ -177,6 +212,26 @@ func (s *Session) CreateBranch(branchID string, branchName string, messageIndex
 	return branch, nil
 }
 
+// ForkAt creates a child session holding copies of the messages before
+// messageIndex. Like a branch, the fork is linked to this session; it also
+// keeps a copy of the session metadata. It is named after this session until
+// renamed.
+func (s *Session) ForkAt(messageIndex int) (*Session, error) {
+	label := s.Name
+	if label == "" {
+		label = s.ID
+	}
+
+	fork, err := s.CreateBranch(NewSessionID(), fmt.Sprintf("%s (fork at %d)", label, messageIndex), messageIndex)
+	if err != nil {
+		return nil, err
+	}
+	if s.Metadata != nil {
+		fork.Metadata = copyMap(s.Metadata)
+	}
+	return fork, nil
+}
+
 // AddChild adds a child branch ID to this session.
 func (s *Session) AddChild(childID string) {
 	// Check if child ID already exists
@@ -253,6 +308,17 @@ func copyMap(src map[string]interface{}) map[string]interface{} {
 	return dst
 }
 
+// NewSessionID generates a unique session ID from the current time and a
+// random suffix.
+func NewSessionID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		// Fall back to the timestamp alone if random generation fails
+		return fmt.Sprintf("%s-%08d", time.Now().Format("20060102-150405-000000000"), time.Now().UnixNano()%100000000)
+	}
+	return fmt.Sprintf("%s-%08s", time.Now().Format("20060102-150405-000000000"), hex.EncodeToString(b))
+}
+
 // Helper function to generate a new message ID
 func generateMessageID() string {
 	// This is a placeholder. In practice, you'd use a proper ID generation method.
@@ -153,6 +153,48 @@ func TestSession_CreateBranch(t *testing.T) {
 	}
 }
 
+func TestSession_ForkAt(t *testing.T) {
+	parent := NewSession("parent-1")
+	parent.Name = "Trip"
+	parent.Metadata["project"] = "travel"
+	parent.Conversation.AddMessage(Message{ID: "msg-1", Role: MessageRoleUser, Content: "Plan a trip", Timestamp: time.Now()})
+	parent.Conversation.AddMessage(Message{ID: "msg-2", Role: MessageRoleAssistant, Content: "Where to?", Timestamp: time.Now()})
+
+	fork, err := parent.ForkAt(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fork.ID == "" || fork.ID == parent.ID {
+		t.Errorf("expected a new session ID, got %q", fork.ID)
+	}
+	if fork.ParentID != parent.ID || fork.BranchPoint != 1 {
+		t.Errorf("expected fork of %s at 1, got %s at %d", parent.ID, fork.ParentID, fork.BranchPoint)
+	}
+	if fork.Name != "Trip (fork at 1)" {
+		t.Errorf("expected fork name 'Trip (fork at 1)', got %q", fork.Name)
+	}
+	if len(fork.Conversation.Messages) != 1 || fork.Conversation.Messages[0].Content != "Plan a trip" {
+		t.Errorf("expected the first message to be copied, got %+v", fork.Conversation.Messages)
+	}
+	if fork.Metadata["project"] != "travel" {
+		t.Errorf("expected metadata to be copied, got %v", fork.Metadata)
+	}
+	if len(parent.ChildIDs) != 1 || parent.ChildIDs[0] != fork.ID {
+		t.Errorf("expected parent children [%s], got %v", fork.ID, parent.ChildIDs)
+	}
+
+	// The fork's metadata is its own
+	fork.Metadata["project"] = "work"
+	if parent.Metadata["project"] != "travel" {
+		t.Error("expected fork metadata to be independent of the parent")
+	}
+
+	if _, err := parent.ForkAt(3); err == nil {
+		t.Error("expected error for index past the end")
+	}
+}
+
 func TestSession_BranchManagement(t *testing.T) {
 	session := NewSession("test-session")
 
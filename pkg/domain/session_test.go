@@ -119,3 +119,38 @@ func TestSessionUpdateTimestamp(t *testing.T) {
 		t.Error("Updated timestamp should be after original")
 	}
 }
+
+func TestSessionExpiry(t *testing.T) {
+	session := NewSession("test")
+	if session.IsEphemeral() || session.IsExpired(time.Now()) {
+		t.Error("New session should not expire")
+	}
+
+	session.SetExpiry(time.Hour)
+	if !session.IsEphemeral() {
+		t.Fatal("Session should be ephemeral after SetExpiry")
+	}
+	if session.IsExpired(time.Now()) {
+		t.Error("Session should not be expired yet")
+	}
+	if !session.IsExpired(time.Now().Add(2 * time.Hour)) {
+		t.Error("Session should be expired after its expiry time")
+	}
+	if !session.ToSessionInfo().IsExpired(time.Now().Add(2 * time.Hour)) {
+		t.Error("Session info should carry the expiry time")
+	}
+
+	session.Conversation.AddMessage(*NewMessage("msg-1", MessageRoleUser, "secret"))
+	branch, err := session.CreateBranch("branch", "branch", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if branch.ExpiresAt == nil || !branch.ExpiresAt.Equal(*session.ExpiresAt) {
+		t.Error("Branch should expire with its session")
+	}
+
+	session.SetExpiry(0)
+	if session.IsEphemeral() {
+		t.Error("SetExpiry(0) should clear the expiry")
+	}
+}
@@ -0,0 +1,63 @@
+// ABOUTME: Domain type for point-in-time copies of a session
+// ABOUTME: Snapshots record a session as it was so it can be rolled back later
+
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reasons a snapshot is taken
+const (
+	SnapshotReasonInterval      = "interval"       // The session was saved and the last snapshot was old enough
+	SnapshotReasonBeforeReset   = "before reset"   // The conversation was about to be cleared
+	SnapshotReasonBeforeMerge   = "before merge"   // Another session was about to be merged in
+	SnapshotReasonBeforeUndo    = "before undo"    // The last exchange was about to be removed
+	SnapshotReasonBeforeRestore = "before restore" // The session was about to be rolled back
+)
+
+// SessionSnapshot is a copy of a session as it was at one point in time.
+// Listings leave Session nil; it is set when a snapshot is loaded.
+type SessionSnapshot struct {
+	ID           string    `json:"id"`
+	SessionID    string    `json:"session_id"`
+	Created      time.Time `json:"created"`
+	Reason       string    `json:"reason"`
+	MessageCount int       `json:"message_count"`
+	Session      *Session  `json:"session,omitempty"`
+}
+
+// NewSessionSnapshot records the current state of session. The snapshot
+// refers to session rather than copying it, so it must be saved before the
+// session changes again.
+func NewSessionSnapshot(session *Session, reason string) *SessionSnapshot {
+	now := time.Now()
+	count := 0
+	if session.Conversation != nil {
+		count = len(session.Conversation.Messages)
+	}
+	return &SessionSnapshot{
+		ID:           fmt.Sprintf("snap_%d", now.UnixNano()),
+		SessionID:    session.ID,
+		Created:      now,
+		Reason:       reason,
+		MessageCount: count,
+		Session:      session,
+	}
+}
+
+// SnapshotAt returns the newest of snapshots taken at or before t, or nil if
+// every snapshot is newer.
+func SnapshotAt(snapshots []*SessionSnapshot, t time.Time) *SessionSnapshot {
+	var found *SessionSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Created.After(t) {
+			continue
+		}
+		if found == nil || snapshot.Created.After(found.Created) {
+			found = snapshot
+		}
+	}
+	return found
+}
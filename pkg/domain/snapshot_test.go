@@ -0,0 +1,57 @@
+// ABOUTME: Tests for session snapshot domain types
+// ABOUTME: Ensures snapshots record their session and the right one is found for a point in time
+
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSessionSnapshot(t *testing.T) {
+	session := NewSession("snap-test")
+	session.Conversation.AddMessage(*NewMessage("msg-1", MessageRoleUser, "hello"))
+
+	snapshot := NewSessionSnapshot(session, SnapshotReasonBeforeReset)
+	if snapshot.SessionID != "snap-test" {
+		t.Errorf("SessionID = %q, want %q", snapshot.SessionID, "snap-test")
+	}
+	if snapshot.MessageCount != 1 {
+		t.Errorf("MessageCount = %d, want 1", snapshot.MessageCount)
+	}
+	if snapshot.Reason != SnapshotReasonBeforeReset {
+		t.Errorf("Reason = %q, want %q", snapshot.Reason, SnapshotReasonBeforeReset)
+	}
+	if snapshot.Session != session {
+		t.Error("snapshot should refer to the session")
+	}
+}
+
+func TestSnapshotAt(t *testing.T) {
+	base := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	snapshots := []*SessionSnapshot{
+		{ID: "noon", Created: base},
+		{ID: "two", Created: base.Add(2 * time.Hour)},
+		{ID: "one", Created: base.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{base.Add(-time.Minute), ""},
+		{base, "noon"},
+		{base.Add(90 * time.Minute), "one"},
+		{base.Add(24 * time.Hour), "two"},
+	}
+	for _, tt := range tests {
+		got := SnapshotAt(snapshots, tt.at)
+		gotID := ""
+		if got != nil {
+			gotID = got.ID
+		}
+		if gotID != tt.want {
+			t.Errorf("SnapshotAt(%s) = %q, want %q", tt.at.Format(time.RFC3339), gotID, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// ABOUTME: Domain type for session templates that preconfigure new sessions
+// ABOUTME: Holds the system prompt, model, opening messages, and tags a session starts with
+
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SessionTemplate is a reusable starting point for new sessions.
+type SessionTemplate struct {
+	Name         string    `json:"name"`
+	Description  string    `json:"description,omitempty"`
+	SystemPrompt string    `json:"system_prompt,omitempty"`
+	Model        string    `json:"model,omitempty"` // provider/model
+	Messages     []Message `json:"messages,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	Created      time.Time `json:"created"`
+	Updated      time.Time `json:"updated"`
+}
+
+// MetadataKeyTemplate is the session metadata key naming the template a
+// session was started from.
+const MetadataKeyTemplate = "template"
+
+// NewSessionTemplate creates an empty template with the given name.
+func NewSessionTemplate(name string) *SessionTemplate {
+	now := time.Now()
+	return &SessionTemplate{
+		Name:     name,
+		Messages: []Message{},
+		Tags:     []string{},
+		Created:  now,
+		Updated:  now,
+	}
+}
+
+// NewSessionTemplateFrom creates a template that starts sessions the way the
+// given session is now: same system prompt, model, messages, and tags.
+func NewSessionTemplateFrom(name string, s *Session) *SessionTemplate {
+	t := NewSessionTemplate(name)
+	t.Tags = append(t.Tags, s.Tags...)
+	if s.Conversation != nil {
+		t.SystemPrompt = s.Conversation.SystemPrompt
+		t.Model = s.Conversation.Model
+		for _, msg := range s.Conversation.Messages {
+			t.Messages = append(t.Messages, msg.Clone())
+		}
+	}
+	return t
+}
+
+// IsValid validates the template name and messages. Names are used as file
+// names, so they may not contain path separators.
+func (t *SessionTemplate) IsValid() bool {
+	if t.Name == "" || t.Name == "." || t.Name == ".." || strings.ContainsAny(t.Name, `/\`) {
+		return false
+	}
+	for _, msg := range t.Messages {
+		if !msg.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply configures a session from the template. The template's messages are
+// copied with new IDs, its tags are added, and the session's metadata records
+// the template name.
+func (t *SessionTemplate) Apply(s *Session) {
+	if s.Conversation == nil {
+		s.Conversation = NewConversation(s.ID)
+	}
+	if t.SystemPrompt != "" {
+		s.Conversation.SystemPrompt = t.SystemPrompt
+	}
+	if t.Model != "" {
+		s.Conversation.Model = t.Model
+		if provider, _, ok := strings.Cut(t.Model, "/"); ok {
+			s.Conversation.Provider = provider
+		}
+	}
+
+	now := time.Now()
+	for i, msg := range t.Messages {
+		copied := msg.Clone()
+		copied.ID = fmt.Sprintf("%s-%d", generateMessageID(), i)
+		copied.Timestamp = now
+		s.Conversation.AddMessage(copied)
+	}
+	for _, tag := range t.Tags {
+		s.AddTag(tag)
+	}
+
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]interface{})
+	}
+	s.Metadata[MetadataKeyTemplate] = t.Name
+	s.UpdateTimestamp()
+}
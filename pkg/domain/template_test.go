@@ -0,0 +1,69 @@
+package domain
+
+import "testing"
+
+func TestSessionTemplateApply(t *testing.T) {
+	template := NewSessionTemplate("review")
+	template.SystemPrompt = "You review Go code"
+	template.Model = "anthropic/claude-3-haiku"
+	template.Tags = []string{"code", "review"}
+	template.Messages = append(template.Messages,
+		*NewMessage("msg-1", MessageRoleUser, "Be strict"),
+		*NewMessage("msg-2", MessageRoleAssistant, "Understood"))
+
+	if !template.IsValid() {
+		t.Fatal("Expected template to be valid")
+	}
+
+	session := NewSession("session-1")
+	session.Tags = []string{"code"}
+	template.Apply(session)
+
+	conv := session.Conversation
+	if conv.SystemPrompt != "You review Go code" {
+		t.Errorf("Expected system prompt to be set, got %q", conv.SystemPrompt)
+	}
+	if conv.Model != "anthropic/claude-3-haiku" || conv.Provider != "anthropic" {
+		t.Errorf("Expected model anthropic/claude-3-haiku, got %s (%s)", conv.Model, conv.Provider)
+	}
+	if len(conv.Messages) != 2 || conv.Messages[1].Content != "Understood" {
+		t.Fatalf("Expected template messages to be copied, got %+v", conv.Messages)
+	}
+	if conv.Messages[0].ID == "msg-1" || conv.Messages[0].ID == conv.Messages[1].ID {
+		t.Errorf("Expected copied messages to get new unique IDs, got %s and %s", conv.Messages[0].ID, conv.Messages[1].ID)
+	}
+	if len(session.Tags) != 2 {
+		t.Errorf("Expected tags [code review], got %v", session.Tags)
+	}
+	if session.Metadata[MetadataKeyTemplate] != "review" {
+		t.Errorf("Expected template name in metadata, got %v", session.Metadata[MetadataKeyTemplate])
+	}
+
+	// The session does not share messages with the template
+	conv.Messages[0].Content = "changed"
+	if template.Messages[0].Content != "Be strict" {
+		t.Error("Expected template messages to be unchanged")
+	}
+}
+
+func TestNewSessionTemplateFrom(t *testing.T) {
+	session := NewSession("session-1")
+	session.Tags = []string{"work"}
+	session.Conversation.SystemPrompt = "Be brief"
+	session.Conversation.Model = "openai/gpt-4o"
+	session.Conversation.AddMessage(*NewMessage("msg-1", MessageRoleUser, "Hello"))
+
+	template := NewSessionTemplateFrom("brief", session)
+	if template.SystemPrompt != "Be brief" || template.Model != "openai/gpt-4o" {
+		t.Errorf("Expected system prompt and model to be captured, got %+v", template)
+	}
+	if len(template.Messages) != 1 || len(template.Tags) != 1 {
+		t.Errorf("Expected 1 message and 1 tag, got %d and %d", len(template.Messages), len(template.Tags))
+	}
+
+	for _, name := range []string{"", "..", "a/b", `a\\b`} {
+		if (&SessionTemplate{Name: name}).IsValid() {
+			t.Errorf("Expected name %q to be invalid", name)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// ABOUTME: Domain types for tool use including ToolCall, ToolResult, and ToolStatus
+// ABOUTME: Core business entities for the tools a model calls and the results sent back to it
+
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ToolCall represents a model's request to run a tool.
+type ToolCall struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Status    ToolStatus             `json:"status,omitempty"`
+}
+
+// ToolResult represents the outcome of a tool call, sent back to the model.
+type ToolResult struct {
+	ToolCallID string                 `json:"tool_call_id"`
+	Name       string                 `json:"name,omitempty"`
+	Content    string                 `json:"content,omitempty"`
+	Status     ToolStatus             `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ToolStatus represents the state of a tool call or the outcome of its result.
+type ToolStatus string
+
+// ToolStatus constants define the possible tool states.
+const (
+	ToolStatusPending ToolStatus = "pending"
+	ToolStatusSuccess ToolStatus = "success"
+	ToolStatusError   ToolStatus = "error"
+)
+
+// NewToolCall creates a new pending tool call with the given parameters.
+func NewToolCall(id, name string, arguments map[string]interface{}) *ToolCall {
+	if arguments == nil {
+		arguments = make(map[string]interface{})
+	}
+	return &ToolCall{
+		ID:        id,
+		Name:      name,
+		Arguments: arguments,
+		Status:    ToolStatusPending,
+	}
+}
+
+// NewToolResult creates a successful result of the tool call.
+func NewToolResult(call ToolCall, content string) *ToolResult {
+	return &ToolResult{
+		ToolCallID: call.ID,
+		Name:       call.Name,
+		Content:    content,
+		Status:     ToolStatusSuccess,
+	}
+}
+
+// NewToolError creates a failed result of the tool call.
+func NewToolError(call ToolCall, err error) *ToolResult {
+	return &ToolResult{
+		ToolCallID: call.ID,
+		Name:       call.Name,
+		Status:     ToolStatusError,
+		Error:      err.Error(),
+	}
+}
+
+// IsValid validates the tool call fields.
+func (c *ToolCall) IsValid() bool {
+	return c.ID != "" && c.Name != "" && (c.Status == "" || c.Status.IsValid())
+}
+
+// ArgumentsJSON returns the arguments as a JSON object.
+func (c *ToolCall) ArgumentsJSON() string {
+	if len(c.Arguments) == 0 {
+		return "{}"
+	}
+	data, err := json.Marshal(c.Arguments)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// String returns the call as name(arguments).
+func (c *ToolCall) String() string {
+	return fmt.Sprintf("%s(%s)", c.Name, c.ArgumentsJSON())
+}
+
+// Clone creates a deep copy of the tool call.
+func (c *ToolCall) Clone() ToolCall {
+	clone := *c
+	clone.Arguments = copyMap(c.Arguments)
+	return clone
+}
+
+// IsValid validates the tool result fields.
+func (r *ToolResult) IsValid() bool {
+	return r.ToolCallID != "" && r.Status.IsValid() && r.Status != ToolStatusPending
+}
+
+// IsError returns true if the tool call failed.
+func (r *ToolResult) IsError() bool {
+	return r.Status == ToolStatusError
+}
+
+// Text returns what the model is told about the result: its content, or
+// the error when the call failed.
+func (r *ToolResult) Text() string {
+	if r.IsError() {
+		return "Error: " + r.Error
+	}
+	return r.Content
+}
+
+// Clone creates a deep copy of the tool result.
+func (r *ToolResult) Clone() ToolResult {
+	clone := *r
+	clone.Metadata = copyMap(r.Metadata)
+	return clone
+}
+
+// String returns the tool status as a string.
+func (s ToolStatus) String() string {
+	return string(s)
+}
+
+// IsValid checks if the tool status is valid.
+func (s ToolStatus) IsValid() bool {
+	return s == ToolStatusPending || s == ToolStatusSuccess || s == ToolStatusError
+}
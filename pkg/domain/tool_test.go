@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestNewToolCall(t *testing.T) {
+	call := NewToolCall("call_1", "get_weather", nil)
+
+	if call.Status != ToolStatusPending {
+		t.Errorf("Expected status %s, got %s", ToolStatusPending, call.Status)
+	}
+	if call.Arguments == nil {
+		t.Error("Expected arguments map to be initialized")
+	}
+	if !call.IsValid() {
+		t.Error("Expected tool call to be valid")
+	}
+	if call.String() != "get_weather({})" {
+		t.Errorf("Expected get_weather({}), got %s", call.String())
+	}
+}
+
+func TestToolResults(t *testing.T) {
+	call := NewToolCall("call_1", "get_weather", map[string]interface{}{"city": "Paris"})
+
+	result := NewToolResult(*call, "18C and sunny")
+	if result.ToolCallID != "call_1" || result.Name != "get_weather" {
+		t.Errorf("Expected result for call_1 get_weather, got %s %s", result.ToolCallID, result.Name)
+	}
+	if result.IsError() || result.Text() != "18C and sunny" {
+		t.Errorf("Expected successful result, got %+v", result)
+	}
+
+	failed := NewToolError(*call, errors.New("service down"))
+	if !failed.IsError() || failed.Text() != "Error: service down" {
+		t.Errorf("Expected failed result, got %+v", failed)
+	}
+
+	pending := ToolResult{ToolCallID: "call_1", Status: ToolStatusPending}
+	if pending.IsValid() {
+		t.Error("Expected a pending result to be invalid")
+	}
+}
+
+func TestMessageToolUse(t *testing.T) {
+	call := NewToolCall("call_1", "get_weather", map[string]interface{}{"city": "Paris"})
+
+	assistant := NewMessage("msg-1", MessageRoleAssistant, "")
+	if assistant.IsValid() {
+		t.Error("Expected a message without content or tool use to be invalid")
+	}
+	assistant.AddToolCall(*call)
+	if !assistant.IsValid() || !assistant.HasToolUse() {
+		t.Error("Expected a message with a tool call to be valid")
+	}
+
+	tool := NewMessage("msg-2", MessageRoleTool, "")
+	tool.AddToolResult(*NewToolResult(*call, "18C and sunny"))
+	if !tool.IsValid() {
+		t.Error("Expected a tool message with a result to be valid")
+	}
+
+	// Clones do not share arguments
+	clone := assistant.Clone()
+	clone.ToolCalls[0].Arguments["city"] = "Rome"
+	if assistant.ToolCalls[0].Arguments["city"] != "Paris" {
+		t.Error("Expected clone to deep copy tool call arguments")
+	}
+
+	// Tool use survives a JSON round trip
+	data, err := json.Marshal(tool)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	if decoded.Role != MessageRoleTool || len(decoded.ToolResults) != 1 || decoded.ToolResults[0].Content != "18C and sunny" {
+		t.Errorf("Expected tool result to round trip, got %+v", decoded)
+	}
+}
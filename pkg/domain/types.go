@@ -43,6 +43,9 @@ const (
 	ExportFormatMarkdown ExportFormat = "markdown"
 	ExportFormatText     ExportFormat = "text"
 	ExportFormatHTML     ExportFormat = "html"
+	ExportFormatJSONL    ExportFormat = "jsonl"
+	ExportFormatCSV      ExportFormat = "csv"
+	ExportFormatOrg      ExportFormat = "org"
 )
 
 // BranchTree represents the hierarchical structure of session branches.
@@ -226,5 +229,6 @@ func (f ExportFormat) String() string {
 // IsValid checks if the export format is valid.
 func (f ExportFormat) IsValid() bool {
 	return f == ExportFormatJSON || f == ExportFormatMarkdown ||
-		f == ExportFormatText || f == ExportFormatHTML
+		f == ExportFormatText || f == ExportFormatHTML || f == ExportFormatJSONL ||
+		f == ExportFormatCSV || f == ExportFormatOrg
 }
@@ -0,0 +1,73 @@
+// ABOUTME: Domain value object for the resources a model request used
+// ABOUTME: Tracks tokens, cost, latency, and the provider and model of each response and their totals
+
+package domain
+
+import "time"
+
+// Usage records the tokens, cost, and time a model request took. It is
+// attached to the assistant message the request produced.
+type Usage struct {
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	CachedTokens     int           `json:"cached_tokens,omitempty"`
+	Cost             float64       `json:"cost,omitempty"`
+	CostKnown        bool          `json:"cost_known,omitempty"`
+	Latency          time.Duration `json:"latency,omitempty"`
+	Provider         string        `json:"provider,omitempty"`
+	Model            string        `json:"model,omitempty"`
+	Estimated        bool          `json:"estimated,omitempty"` // Token counts were estimated from the text
+	Requests         int           `json:"requests,omitempty"`  // Number of requests in a total
+}
+
+// TotalTokens returns the prompt and completion tokens together.
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// IsZero returns true if no usage was recorded.
+func (u Usage) IsZero() bool {
+	return u.TotalTokens() == 0 && u.CachedTokens == 0 && u.Cost == 0 && u.Latency == 0
+}
+
+// Add adds another request's usage to a total. The provider and model are
+// kept only while every request used the same one.
+func (u *Usage) Add(other Usage) {
+	if u.Requests == 0 {
+		u.Provider, u.Model = other.Provider, other.Model
+	} else {
+		if u.Provider != other.Provider {
+			u.Provider = ""
+		}
+		if u.Model != other.Model {
+			u.Model = ""
+		}
+	}
+
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.CachedTokens += other.CachedTokens
+	u.Cost += other.Cost
+	u.CostKnown = u.CostKnown || other.CostKnown
+	u.Latency += other.Latency
+	u.Estimated = u.Estimated || other.Estimated
+	if other.Requests > 0 {
+		u.Requests += other.Requests
+	} else {
+		u.Requests++
+	}
+}
+
+// TotalUsage adds up the usage recorded on the session's messages.
+func (s *Session) TotalUsage() Usage {
+	var total Usage
+	if s.Conversation == nil {
+		return total
+	}
+	for _, msg := range s.Conversation.Messages {
+		if msg.Usage != nil {
+			total.Add(*msg.Usage)
+		}
+	}
+	return total
+}
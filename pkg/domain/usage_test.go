@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsageAdd(t *testing.T) {
+	var total Usage
+	total.Add(Usage{PromptTokens: 100, CompletionTokens: 20, Cost: 0.01, CostKnown: true, Latency: time.Second, Provider: "openai", Model: "gpt-4o"})
+	total.Add(Usage{PromptTokens: 50, CompletionTokens: 10, CachedTokens: 40, Latency: time.Second, Provider: "openai", Model: "gpt-4o", Estimated: true})
+
+	if total.Requests != 2 || total.TotalTokens() != 180 || total.CachedTokens != 40 {
+		t.Errorf("Expected 2 requests and 180 tokens, got %+v", total)
+	}
+	if total.Provider != "openai" || total.Model != "gpt-4o" {
+		t.Errorf("Expected shared provider and model to be kept, got %s/%s", total.Provider, total.Model)
+	}
+	if !total.CostKnown || !total.Estimated || total.Latency != 2*time.Second {
+		t.Errorf("Expected cost, estimate, and latency to be combined, got %+v", total)
+	}
+
+	total.Add(Usage{PromptTokens: 1, Provider: "anthropic", Model: "claude-3-haiku"})
+	if total.Provider != "" || total.Model != "" {
+		t.Errorf("Expected mixed provider and model to be cleared, got %s/%s", total.Provider, total.Model)
+	}
+}
+
+func TestSessionTotalUsage(t *testing.T) {
+	session := NewSession("session-1")
+	if usage := session.TotalUsage(); !usage.IsZero() || usage.Requests != 0 {
+		t.Errorf("Expected no usage, got %+v", usage)
+	}
+
+	session.Conversation.AddMessage(*NewMessage("msg-1", MessageRoleUser, "Hello"))
+	reply := NewMessage("msg-2", MessageRoleAssistant, "Hi")
+	reply.Usage = &Usage{PromptTokens: 10, CompletionTokens: 2}
+	session.Conversation.AddMessage(*reply)
+
+	if usage := session.TotalUsage(); usage.Requests != 1 || usage.TotalTokens() != 12 {
+		t.Errorf("Expected 1 request and 12 tokens, got %+v", usage)
+	}
+
+	// Clones do not share usage
+	clone := reply.Clone()
+	clone.Usage.PromptTokens = 99
+	if reply.Usage.PromptTokens != 10 {
+		t.Error("Expected clone to copy usage")
+	}
+}
@@ -0,0 +1,139 @@
+// ABOUTME: Parser for ChatGPT data exports
+// ABOUTME: Follows each conversation's message tree from the current node back to the root
+
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// chatGPTConversation is one entry in a ChatGPT conversations.json
+type chatGPTConversation struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	Title          string                 `json:"title"`
+	CreateTime     float64                `json:"create_time"`
+	UpdateTime     float64                `json:"update_time"`
+	CurrentNode    string                 `json:"current_node"`
+	Mapping        map[string]chatGPTNode `json:"mapping"`
+}
+
+// chatGPTNode is one node of a ChatGPT conversation tree
+type chatGPTNode struct {
+	ID       string          `json:"id"`
+	Parent   string          `json:"parent"`
+	Children []string        `json:"children"`
+	Message  *chatGPTMessage `json:"message"`
+}
+
+// chatGPTMessage is the message held by a tree node
+type chatGPTMessage struct {
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+		Text        string            `json:"text"`
+	} `json:"content"`
+}
+
+// parseChatGPT converts a ChatGPT conversations.json into sessions
+func parseChatGPT(data []byte) ([]*domain.Session, error) {
+	var conversations []chatGPTConversation
+	if err := json.Unmarshal(data, &conversations); err != nil {
+		return nil, fmt.Errorf("%w: chatgpt: %v", ErrInvalidExport, err)
+	}
+
+	sessions := make([]*domain.Session, 0, len(conversations))
+	for _, conv := range conversations {
+		sourceID := conv.ConversationID
+		if sourceID == "" {
+			sourceID = conv.ID
+		}
+		s := newSession(conv.Title, sourceID, unixTime(conv.CreateTime), unixTime(conv.UpdateTime))
+
+		for _, node := range chatGPTThread(conv) {
+			msg := node.Message
+			if msg == nil {
+				continue
+			}
+			role := domain.MessageRole(msg.Author.Role)
+			if role != domain.MessageRoleUser && role != domain.MessageRoleAssistant && role != domain.MessageRoleSystem {
+				// Tool calls and browsing results are not part of the conversation
+				continue
+			}
+			addMessage(s, role, chatGPTText(msg), unixTime(msg.CreateTime))
+		}
+
+		finish(s, conv.UpdateTime > 0)
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// chatGPTThread returns the nodes on the path from the root to the current
+// node, which is the branch the user last saw. Without a current node the
+// first child is followed from the root.
+func chatGPTThread(conv chatGPTConversation) []chatGPTNode {
+	var thread []chatGPTNode
+	if conv.CurrentNode != "" {
+		seen := make(map[string]bool)
+		for id := conv.CurrentNode; id != "" && !seen[id]; {
+			node, ok := conv.Mapping[id]
+			if !ok {
+				break
+			}
+			seen[id] = true
+			thread = append(thread, node)
+			id = node.Parent
+		}
+		for i, j := 0, len(thread)-1; i < j; i, j = i+1, j-1 {
+			thread[i], thread[j] = thread[j], thread[i]
+		}
+		return thread
+	}
+
+	var id string
+	for nodeID, node := range conv.Mapping {
+		if node.Parent == "" {
+			id = nodeID
+			break
+		}
+	}
+	seen := make(map[string]bool)
+	for id != "" && !seen[id] {
+		node, ok := conv.Mapping[id]
+		if !ok {
+			break
+		}
+		seen[id] = true
+		thread = append(thread, node)
+		id = ""
+		if len(node.Children) > 0 {
+			id = node.Children[0]
+		}
+	}
+	return thread
+}
+
+// chatGPTText joins the text parts of a message, skipping images and other
+// non-text parts
+func chatGPTText(msg *chatGPTMessage) string {
+	var parts []string
+	for _, raw := range msg.Content.Parts {
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 0 {
+		return msg.Content.Text
+	}
+	return strings.Join(parts, "\n")
+}
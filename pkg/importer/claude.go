@@ -0,0 +1,71 @@
+// ABOUTME: Parser for Claude data exports
+// ABOUTME: Maps human and assistant chat messages to domain messages
+
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// claudeConversation is one entry in a Claude conversations.json
+type claudeConversation struct {
+	UUID         string          `json:"uuid"`
+	Name         string          `json:"name"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	ChatMessages []claudeMessage `json:"chat_messages"`
+}
+
+// claudeMessage is one message of a Claude conversation
+type claudeMessage struct {
+	Text      string    `json:"text"`
+	Sender    string    `json:"sender"`
+	CreatedAt time.Time `json:"created_at"`
+	Content   []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// parseClaude converts a Claude conversations.json into sessions
+func parseClaude(data []byte) ([]*domain.Session, error) {
+	var conversations []claudeConversation
+	if err := json.Unmarshal(data, &conversations); err != nil {
+		return nil, fmt.Errorf("%w: claude: %v", ErrInvalidExport, err)
+	}
+
+	sessions := make([]*domain.Session, 0, len(conversations))
+	for _, conv := range conversations {
+		s := newSession(conv.Name, conv.UUID, conv.CreatedAt, conv.UpdatedAt)
+		for _, msg := range conv.ChatMessages {
+			role := domain.MessageRoleAssistant
+			if msg.Sender == "human" {
+				role = domain.MessageRoleUser
+			}
+			addMessage(s, role, claudeText(msg), msg.CreatedAt)
+		}
+		finish(s, !conv.UpdatedAt.IsZero())
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// claudeText returns a message's text, joining its text content blocks when
+// the plain text field is empty
+func claudeText(msg claudeMessage) string {
+	if msg.Text != "" {
+		return msg.Text
+	}
+	var parts []string
+	for _, block := range msg.Content {
+		if block.Type == "text" && block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,14 @@
+// ABOUTME: Error definitions for the importer package
+// ABOUTME: Reports unknown formats and exports that cannot be parsed
+
+package importer
+
+import "errors"
+
+var (
+	// ErrUnknownFormat is returned for an import format that is not supported
+	ErrUnknownFormat = errors.New("unknown import format")
+
+	// ErrInvalidExport is returned when an export file cannot be parsed
+	ErrInvalidExport = errors.New("invalid conversation export")
+)
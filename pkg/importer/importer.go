@@ -0,0 +1,186 @@
+// ABOUTME: Converts conversation exports from other tools into domain sessions
+// ABOUTME: Reads ChatGPT and Claude data exports, OpenAI-style messages JSON, and markdown transcripts
+
+// Package importer converts conversations exported from other chat tools
+// into domain.Sessions, keeping each message's role and timestamp.
+//
+// ChatGPT and Claude data exports are zip archives holding a
+// conversations.json file; either the archive or the extracted file can be
+// imported. The openai format is a single conversation in the OpenAI chat
+// API shape, {"messages": [{"role": "user", "content": "..."}]}, or just the
+// messages array. The markdown format is a transcript with a heading per
+// message naming its role, as magellai's markdown export writes.
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// Format names the kind of export being imported
+type Format string
+
+const (
+	// FormatChatGPT is a ChatGPT data export (conversations.json)
+	FormatChatGPT Format = "chatgpt"
+	// FormatClaude is a Claude data export (conversations.json)
+	FormatClaude Format = "claude"
+	// FormatOpenAI is a single conversation as OpenAI chat messages JSON
+	FormatOpenAI Format = "openai"
+	// FormatMarkdown is a single conversation as a markdown transcript
+	FormatMarkdown Format = "markdown"
+)
+
+// Formats lists the supported import formats
+var Formats = []Format{FormatChatGPT, FormatClaude, FormatOpenAI, FormatMarkdown}
+
+// conversationsFile is the file holding conversations in a data export archive
+const conversationsFile = "conversations.json"
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("%w: %q (expected %s)", ErrUnknownFormat, name, strings.Join(names, ", "))
+}
+
+// Import parses an export in the given format into new sessions. data may
+// be a zip archive, in which case conversations.json (or, for the openai
+// and markdown formats, the first .json or .md file) is read from it.
+func Import(format Format, data []byte) ([]*domain.Session, error) {
+	if isZip(data) {
+		var err error
+		if data, err = readFromZip(format, data); err != nil {
+			return nil, err
+		}
+	}
+
+	var sessions []*domain.Session
+	var err error
+	switch format {
+	case FormatChatGPT:
+		sessions, err = parseChatGPT(data)
+	case FormatClaude:
+		sessions, err = parseClaude(data)
+	case FormatOpenAI:
+		sessions, err = parseOpenAI(data)
+	case FormatMarkdown:
+		sessions, err = parseMarkdown(data)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range sessions {
+		// Set directly, since AddTag would bump the imported Updated time
+		s.Tags = append(s.Tags, "imported")
+		s.Metadata["imported_from"] = string(format)
+	}
+	return sessions, nil
+}
+
+// isZip reports whether data starts with the zip local file header
+func isZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// readFromZip returns the conversations file from an export archive
+func readFromZip(format Format, data []byte) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+		if name == conversationsFile || (format == FormatOpenAI && strings.HasSuffix(name, ".json")) ||
+			(format == FormatMarkdown && strings.HasSuffix(name, ".md")) {
+			rc, err := file.Open()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+	}
+	return nil, fmt.Errorf("%w: no %s in archive", ErrInvalidExport, conversationsFile)
+}
+
+// newSession creates a session for an imported conversation, keeping its
+// original timestamps when known
+func newSession(name, sourceID string, created, updated time.Time) *domain.Session {
+	s := domain.NewSession(storage.GenerateSessionID())
+	s.Name = name
+	if !created.IsZero() {
+		s.Created = created
+		s.Conversation.Created = created
+	}
+	if !updated.IsZero() {
+		s.Updated = updated
+		s.Conversation.Updated = updated
+	}
+	if sourceID != "" {
+		s.Metadata["source_id"] = sourceID
+	}
+	return s
+}
+
+// addMessage appends a message unless its content is empty, falling back to
+// the previous message's time when the timestamp is unknown
+func addMessage(s *domain.Session, role domain.MessageRole, content string, timestamp time.Time) {
+	if strings.TrimSpace(content) == "" {
+		return
+	}
+	if timestamp.IsZero() {
+		timestamp = s.Created
+		if n := len(s.Conversation.Messages); n > 0 {
+			timestamp = s.Conversation.Messages[n-1].Timestamp
+		}
+	}
+	s.Conversation.Messages = append(s.Conversation.Messages, domain.Message{
+		ID:        uuid.New().String(),
+		Role:      role,
+		Content:   content,
+		Timestamp: timestamp,
+		Metadata:  make(map[string]interface{}),
+	})
+}
+
+// finish sets the session's updated time from its last message when the
+// export did not provide one
+func finish(s *domain.Session, updatedKnown bool) {
+	if updatedKnown {
+		return
+	}
+	if n := len(s.Conversation.Messages); n > 0 {
+		s.Updated = s.Conversation.Messages[n-1].Timestamp
+		s.Conversation.Updated = s.Updated
+	}
+}
+
+// unixTime converts fractional Unix seconds, as ChatGPT exports use
+func unixTime(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	sec := int64(seconds)
+	return time.Unix(sec, int64((seconds-float64(sec))*1e9)).UTC()
+}
@@ -0,0 +1,245 @@
+// ABOUTME: Tests for converting external conversation exports into sessions
+// ABOUTME: Covers ChatGPT trees, Claude messages, OpenAI messages JSON, and zip archives
+
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chatGPTExport = `[{
+  "title": "Trip planning",
+  "conversation_id": "conv-1",
+  "create_time": 1700000000.5,
+  "update_time": 1700000100,
+  "current_node": "a2",
+  "mapping": {
+    "root": {"id": "root", "parent": "", "children": ["sys"], "message": null},
+    "sys": {"id": "sys", "parent": "root", "children": ["u1"], "message": {"author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]}}},
+    "u1": {"id": "u1", "parent": "sys", "children": ["a1", "a2"], "message": {"author": {"role": "user"}, "create_time": 1700000010, "content": {"content_type": "text", "parts": ["Where should I go?"]}}},
+    "a1": {"id": "a1", "parent": "u1", "children": [], "message": {"author": {"role": "assistant"}, "create_time": 1700000020, "content": {"content_type": "text", "parts": ["Discarded branch"]}}},
+    "a2": {"id": "a2", "parent": "u1", "children": [], "message": {"author": {"role": "assistant"}, "create_time": 1700000030, "content": {"content_type": "text", "parts": ["Try Lisbon.", {"asset_pointer": "file-1"}]}}}
+  }
+}]`
+
+const claudeExport = `[{
+  "uuid": "claude-1",
+  "name": "Recipe ideas",
+  "created_at": "2024-03-01T10:00:00Z",
+  "updated_at": "2024-03-01T10:05:00Z",
+  "chat_messages": [
+    {"sender": "human", "text": "Something with lentils?", "created_at": "2024-03-01T10:00:00Z"},
+    {"sender": "assistant", "text": "", "content": [{"type": "text", "text": "Try dal."}], "created_at": "2024-03-01T10:01:00Z"}
+  ]
+}]`
+
+func TestImport_ChatGPT(t *testing.T) {
+	sessions, err := Import(FormatChatGPT, []byte(chatGPTExport))
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	s := sessions[0]
+	assert.Equal(t, "Trip planning", s.Name)
+	assert.Equal(t, "conv-1", s.Metadata["source_id"])
+	assert.Equal(t, "chatgpt", s.Metadata["imported_from"])
+	assert.Contains(t, s.Tags, "imported")
+	assert.Equal(t, time.Unix(1700000000, 5e8).UTC(), s.Created)
+	assert.Equal(t, time.Unix(1700000100, 0).UTC(), s.Updated)
+
+	messages := s.Conversation.Messages
+	require.Len(t, messages, 2, "empty system message and other branches are dropped")
+	assert.Equal(t, domain.MessageRoleUser, messages[0].Role)
+	assert.Equal(t, "Where should I go?", messages[0].Content)
+	assert.Equal(t, time.Unix(1700000010, 0).UTC(), messages[0].Timestamp)
+	assert.Equal(t, domain.MessageRoleAssistant, messages[1].Role)
+	assert.Equal(t, "Try Lisbon.", messages[1].Content)
+}
+
+func TestImport_Claude(t *testing.T) {
+	sessions, err := Import(FormatClaude, []byte(claudeExport))
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	s := sessions[0]
+	assert.Equal(t, "Recipe ideas", s.Name)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), s.Created)
+	require.Len(t, s.Conversation.Messages, 2)
+	assert.Equal(t, domain.MessageRoleUser, s.Conversation.Messages[0].Role)
+	assert.Equal(t, domain.MessageRoleAssistant, s.Conversation.Messages[1].Role)
+	assert.Equal(t, "Try dal.", s.Conversation.Messages[1].Content)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 1, 0, 0, time.UTC), s.Conversation.Messages[1].Timestamp)
+}
+
+func TestImport_OpenAI(t *testing.T) {
+	t.Run("object with metadata", func(t *testing.T) {
+		sessions, err := Import(FormatOpenAI, []byte(`{
+  "title": "Haiku", "model": "gpt-4o", "created": 1700000000,
+  "messages": [
+    {"role": "system", "content": "Be terse."},
+    {"role": "user", "content": [{"type": "text", "text": "A haiku"}, {"type": "image_url", "image_url": {"url": "x"}}]},
+    {"role": "tool", "content": "ignored"},
+    {"role": "assistant", "content": "Leaves fall"}
+  ]
+}`))
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+
+		s := sessions[0]
+		assert.Equal(t, "Haiku", s.Name)
+		assert.Equal(t, "gpt-4o", s.Conversation.Model)
+		assert.Equal(t, "Be terse.", s.Conversation.SystemPrompt)
+		assert.Equal(t, time.Unix(1700000000, 0).UTC(), s.Created)
+		require.Len(t, s.Conversation.Messages, 2)
+		assert.Equal(t, "A haiku", s.Conversation.Messages[0].Content)
+		assert.Equal(t, s.Created, s.Conversation.Messages[0].Timestamp)
+	})
+
+	t.Run("bare messages array", func(t *testing.T) {
+		sessions, err := Import(FormatOpenAI, []byte(`[{"role": "user", "content": "hi"}]`))
+		require.NoError(t, err)
+		require.Len(t, sessions[0].Conversation.Messages, 1)
+	})
+
+	t.Run("no messages", func(t *testing.T) {
+		_, err := Import(FormatOpenAI, []byte(`{"messages": []}`))
+		assert.ErrorIs(t, err, ErrInvalidExport)
+	})
+}
+
+func TestImport_Markdown(t *testing.T) {
+	t.Run("filesystem export", func(t *testing.T) {
+		sessions, err := Import(FormatMarkdown, []byte(`# Session: Go questions
+
+**ID:** 20240301-100000-000000000-abcdef12
+**Created:** 2024-03-01T10:00:00Z
+**Updated:** 2024-03-01T10:05:00Z
+Tags: work, go
+
+## System Prompt
+
+You are a Go expert.
+
+## Conversation
+
+### User
+
+How do I read a file?
+
+**Attachments:**
+- notes.txt (text/plain)
+
+### Assistant
+
+Use os.ReadFile:
+
+`+"```go\n# not a heading\n### User\ndata, err := os.ReadFile(path)\n```"+`
+
+**Sources:**
+- [Go docs](https://pkg.go.dev/os)
+
+`))
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+
+		s := sessions[0]
+		assert.Equal(t, "Go questions", s.Name)
+		assert.Equal(t, "20240301-100000-000000000-abcdef12", s.Metadata["source_id"])
+		assert.Equal(t, "markdown", s.Metadata["imported_from"])
+		assert.Equal(t, []string{"work", "go", "imported"}, s.Tags)
+		assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), s.Created)
+		assert.Equal(t, time.Date(2024, 3, 1, 10, 5, 0, 0, time.UTC), s.Updated)
+		assert.Equal(t, "You are a Go expert.", s.Conversation.SystemPrompt)
+
+		messages := s.Conversation.Messages
+		require.Len(t, messages, 2, "headings in code blocks do not start messages")
+		assert.Equal(t, domain.MessageRoleUser, messages[0].Role)
+		assert.Equal(t, "How do I read a file?", messages[0].Content)
+		assert.Equal(t, domain.MessageRoleAssistant, messages[1].Role)
+		assert.Equal(t, "Use os.ReadFile:\n\n```go\n# not a heading\n### User\ndata, err := os.ReadFile(path)\n```", messages[1].Content)
+	})
+
+	t.Run("sqlite export with message times", func(t *testing.T) {
+		sessions, err := Import(FormatMarkdown, []byte(`# Session: Dinner
+
+ID: s-1
+Created: 2024-03-01T10:00:00Z
+Updated: 2024-03-01T10:05:00Z
+
+## Conversation
+### User
+*2024-03-01T10:01:00Z*
+
+Something with lentils?
+
+### Assistant
+*2024-03-01T10:02:00Z*
+
+Try dal.
+
+`))
+		require.NoError(t, err)
+		messages := sessions[0].Conversation.Messages
+		require.Len(t, messages, 2)
+		assert.Equal(t, "Something with lentils?", messages[0].Content)
+		assert.Equal(t, time.Date(2024, 3, 1, 10, 1, 0, 0, time.UTC), messages[0].Timestamp)
+		assert.Equal(t, time.Date(2024, 3, 1, 10, 2, 0, 0, time.UTC), messages[1].Timestamp)
+	})
+
+	t.Run("hand-kept notes", func(t *testing.T) {
+		sessions, err := Import(FormatMarkdown, []byte("Notes from last week.\n\n## System\nBe terse.\n\n## Human:\nA haiku\n\n## **Claude**\nLeaves fall\n"))
+		require.NoError(t, err)
+
+		s := sessions[0]
+		assert.Empty(t, s.Name)
+		assert.Equal(t, "Be terse.", s.Conversation.SystemPrompt)
+		require.Len(t, s.Conversation.Messages, 2)
+		assert.Equal(t, domain.MessageRoleUser, s.Conversation.Messages[0].Role)
+		assert.Equal(t, "Leaves fall", s.Conversation.Messages[1].Content)
+	})
+
+	t.Run("no role headings", func(t *testing.T) {
+		_, err := Import(FormatMarkdown, []byte("# Just a title\n\nSome text.\n"))
+		assert.ErrorIs(t, err, ErrInvalidExport)
+	})
+}
+
+func TestImport_Zip(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, err := archive.Create("export/users.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`[]`))
+	require.NoError(t, err)
+	w, err = archive.Create("export/conversations.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(claudeExport))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	sessions, err := Import(FormatClaude, buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "Recipe ideas", sessions[0].Name)
+
+	_, err = Import(FormatChatGPT, []byte("PK\x03\x04garbage"))
+	assert.ErrorIs(t, err, ErrInvalidExport)
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("ChatGPT")
+	require.NoError(t, err)
+	assert.Equal(t, FormatChatGPT, format)
+
+	_, err = ParseFormat("gemini")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = Import(FormatChatGPT, []byte(`{"not": "a list"}`))
+	assert.ErrorIs(t, err, ErrInvalidExport)
+}
@@ -0,0 +1,215 @@
+// ABOUTME: Parser for conversations kept as markdown transcripts
+// ABOUTME: Turns role headings back into messages, reading magellai's markdown exports and hand-kept notes
+
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// markdownRoles maps the text of a role heading to its role; the aliases
+// cover transcripts copied from chat tools
+var markdownRoles = map[string]domain.MessageRole{
+	"user":      domain.MessageRoleUser,
+	"human":     domain.MessageRoleUser,
+	"you":       domain.MessageRoleUser,
+	"me":        domain.MessageRoleUser,
+	"assistant": domain.MessageRoleAssistant,
+	"ai":        domain.MessageRoleAssistant,
+	"model":     domain.MessageRoleAssistant,
+	"bot":       domain.MessageRoleAssistant,
+	"chatgpt":   domain.MessageRoleAssistant,
+	"claude":    domain.MessageRoleAssistant,
+	"system":    domain.MessageRoleSystem,
+	"tool":      domain.MessageRoleTool,
+}
+
+// markdownAnnotations label the lists a markdown export adds after a
+// message's content. They only name attachments, tool calls and sources,
+// which cannot be restored from a transcript, so they are dropped.
+var markdownAnnotations = map[string]bool{
+	"attachments:":  true,
+	"tool calls:":   true,
+	"tool results:": true,
+	"sources:":      true,
+}
+
+// markdownMessage is a message being read from a transcript
+type markdownMessage struct {
+	role      domain.MessageRole
+	timestamp time.Time
+	lines     []string
+}
+
+// parseMarkdown converts a markdown transcript into a session. Each heading
+// naming a role, such as "### User" or "## Assistant", starts a message that
+// runs to the next one. A leading "# Title" names the session, and the
+// ID, Created, Updated and Tags lines and the "System Prompt" section of a
+// magellai export are read back.
+func parseMarkdown(data []byte) ([]*domain.Session, error) {
+	var (
+		title, sourceID         string
+		created, updated        time.Time
+		tags, systemLines       []string
+		messages                []*markdownMessage
+		inSystemPrompt, inFence bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		// Headings inside code blocks are content
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence {
+			if level, text, ok := markdownHeading(trimmed); ok {
+				name := strings.ToLower(strings.TrimSuffix(text, ":"))
+				if role, isRole := markdownRoles[name]; isRole {
+					messages = append(messages, &markdownMessage{role: role})
+					inSystemPrompt = false
+					continue
+				}
+				if len(messages) == 0 {
+					switch {
+					case level == 1 && title == "":
+						title = strings.TrimSpace(strings.TrimPrefix(text, "Session:"))
+						continue
+					case name == "system prompt":
+						inSystemPrompt = true
+						continue
+					case name == "conversation":
+						inSystemPrompt = false
+						continue
+					}
+				}
+			}
+		}
+
+		switch {
+		case len(messages) > 0:
+			current := messages[len(messages)-1]
+			// The sqlite export puts the time of a message in italics under
+			// its heading
+			if current.timestamp.IsZero() && len(trimBlankLines(current.lines)) == 0 {
+				if ts, ok := markdownTimestamp(trimmed); ok {
+					current.timestamp = ts
+					continue
+				}
+			}
+			current.lines = append(current.lines, line)
+		case inSystemPrompt:
+			systemLines = append(systemLines, line)
+		default:
+			key, value, ok := markdownField(trimmed)
+			if !ok {
+				continue
+			}
+			switch key {
+			case "id":
+				sourceID = value
+			case "created":
+				created, _ = time.Parse(time.RFC3339, value)
+			case "updated":
+				updated, _ = time.Parse(time.RFC3339, value)
+			case "tags":
+				for _, tag := range strings.Split(value, ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						tags = append(tags, tag)
+					}
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: markdown: %v", ErrInvalidExport, err)
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("%w: markdown: no role headings such as \"## User\" or \"## Assistant\"", ErrInvalidExport)
+	}
+
+	s := newSession(title, sourceID, created, updated)
+	s.Tags = append(s.Tags, tags...)
+	s.Conversation.SystemPrompt = strings.Join(trimBlankLines(systemLines), "\n")
+	for _, msg := range messages {
+		content := strings.Join(stripAnnotations(trimBlankLines(msg.lines)), "\n")
+		if msg.role == domain.MessageRoleSystem && len(s.Conversation.Messages) == 0 && s.Conversation.SystemPrompt == "" {
+			// A leading system message is the conversation's system prompt
+			s.Conversation.SystemPrompt = content
+			continue
+		}
+		addMessage(s, msg.role, content, msg.timestamp)
+	}
+	finish(s, !updated.IsZero())
+	return []*domain.Session{s}, nil
+}
+
+// markdownHeading returns the level and text of an ATX heading
+func markdownHeading(line string) (int, string, bool) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level == len(line) || line[level] != ' ' {
+		return 0, "", false
+	}
+	text := strings.TrimSpace(strings.TrimRight(line[level:], "#"))
+	return level, strings.Trim(text, "*_"), true
+}
+
+// markdownField reads a "Key: value" or "**Key:** value" line from the
+// header of an export
+func markdownField(line string) (string, string, bool) {
+	line = strings.Replace(line, ":**", "**:", 1)
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", false
+	}
+	key = strings.ToLower(strings.Trim(key, "*_ "))
+	value = strings.TrimSpace(strings.Trim(strings.TrimSpace(value), "*"))
+	return key, value, key != "" && value != ""
+}
+
+// markdownTimestamp reads an RFC 3339 time written in italics
+func markdownTimestamp(line string) (time.Time, bool) {
+	if len(line) < 3 || !strings.HasPrefix(line, "*") || !strings.HasSuffix(line, "*") {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(time.RFC3339, strings.Trim(line, "*"))
+	return ts, err == nil
+}
+
+// stripAnnotations drops the lists an export appends to a message, which
+// are a label line followed by list items
+func stripAnnotations(lines []string) []string {
+	end := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if markdownAnnotations[strings.ToLower(strings.Trim(trimmed, "*"))] {
+			end = i
+		} else if trimmed != "" && !strings.HasPrefix(trimmed, "- ") {
+			break
+		}
+	}
+	return trimBlankLines(lines[:end])
+}
+
+// trimBlankLines drops blank lines at the start and end
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
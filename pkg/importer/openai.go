@@ -0,0 +1,94 @@
+// ABOUTME: Parser for conversations in the OpenAI chat messages format
+// ABOUTME: Accepts a messages array or an object with messages, model, and title
+
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// openAIConversation is a conversation in the OpenAI chat request shape
+type openAIConversation struct {
+	Title    string          `json:"title"`
+	Model    string          `json:"model"`
+	Created  int64           `json:"created"`
+	Messages []openAIMessage `json:"messages"`
+}
+
+// openAIMessage is one chat message; content is a string or content parts
+type openAIMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// parseOpenAI converts one OpenAI-style conversation into a session
+func parseOpenAI(data []byte) ([]*domain.Session, error) {
+	var conv openAIConversation
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &conv.Messages); err != nil {
+			return nil, fmt.Errorf("%w: openai: %v", ErrInvalidExport, err)
+		}
+	} else if err := json.Unmarshal(data, &conv); err != nil {
+		return nil, fmt.Errorf("%w: openai: %v", ErrInvalidExport, err)
+	}
+	if len(conv.Messages) == 0 {
+		return nil, fmt.Errorf("%w: openai: no messages", ErrInvalidExport)
+	}
+
+	var created time.Time
+	if conv.Created > 0 {
+		created = time.Unix(conv.Created, 0).UTC()
+	}
+	s := newSession(conv.Title, "", created, created)
+	if conv.Model != "" {
+		s.Conversation.Model = conv.Model
+	}
+
+	for _, msg := range conv.Messages {
+		role := domain.MessageRole(msg.Role)
+		switch role {
+		case domain.MessageRoleUser, domain.MessageRoleAssistant:
+		case domain.MessageRoleSystem, "developer":
+			role = domain.MessageRoleSystem
+		default:
+			continue
+		}
+		text := openAIText(msg.Content)
+		if role == domain.MessageRoleSystem && len(s.Conversation.Messages) == 0 && s.Conversation.SystemPrompt == "" {
+			// A leading system message is the conversation's system prompt
+			s.Conversation.SystemPrompt = text
+			continue
+		}
+		addMessage(s, role, text, time.Time{})
+	}
+	return []*domain.Session{s}, nil
+}
+
+// openAIText returns message content given as a string or as text parts
+func openAIText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Type == "text" && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,15 @@
+// ABOUTME: Error definitions for the keyring package
+// ABOUTME: Provides standard errors for OS keychain access
+
+package keyring
+
+import "errors"
+
+// Keyring-specific errors
+var (
+	// ErrNotFound indicates no secret is stored for the account
+	ErrNotFound = errors.New("secret not found in keyring")
+
+	// ErrUnsupported indicates no usable keyring is available on this system
+	ErrUnsupported = errors.New("no supported keyring on this system")
+)
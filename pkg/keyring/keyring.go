@@ -0,0 +1,172 @@
+// ABOUTME: OS keychain storage for provider API keys
+// ABOUTME: Wraps the platform backend with a lookup cache and a swappable backend for tests
+
+package keyring
+
+import (
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// Service is the keychain service name secrets are stored under
+const Service = "magellai"
+
+// DisableEnvVar turns off keychain lookups when set to a non-empty value,
+// for CI and other headless environments
+const DisableEnvVar = "MAGELLAI_NO_KEYRING"
+
+// Backend stores secrets by account name
+type Backend interface {
+	// Name identifies the keychain, for messages
+	Name() string
+	// Get returns the secret for account, or ErrNotFound
+	Get(account string) (string, error)
+	// Set stores or replaces the secret for account
+	Set(account, secret string) error
+	// Delete removes the secret for account, or returns ErrNotFound
+	Delete(account string) error
+}
+
+var (
+	mu      sync.Mutex
+	backend Backend = platformBackend()
+	cache           = map[string]string{}
+	misses          = map[string]bool{}
+)
+
+// SetBackend replaces the keychain backend and returns a function restoring
+// the previous one
+func SetBackend(b Backend) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	previous := backend
+	backend = b
+	cache = map[string]string{}
+	misses = map[string]bool{}
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		backend = previous
+		cache = map[string]string{}
+		misses = map[string]bool{}
+	}
+}
+
+// Name returns the name of the active keychain
+func Name() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return backend.Name()
+}
+
+// Get returns the secret stored for account. Lookups, including misses, are
+// cached for the life of the process.
+func Get(account string) (string, error) {
+	if os.Getenv(DisableEnvVar) != "" {
+		return "", ErrUnsupported
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if secret, ok := cache[account]; ok {
+		return secret, nil
+	}
+	if misses[account] {
+		return "", ErrNotFound
+	}
+
+	secret, err := backend.Get(account)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnsupported) {
+			misses[account] = true
+			return "", ErrNotFound
+		}
+		logging.LogDebug("Keyring lookup failed", "keyring", backend.Name(), "account", account, "error", err)
+		return "", err
+	}
+	cache[account] = secret
+	return secret, nil
+}
+
+// Set stores the secret for account
+func Set(account, secret string) error {
+	if os.Getenv(DisableEnvVar) != "" {
+		return ErrUnsupported
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := backend.Set(account, secret); err != nil {
+		return err
+	}
+	cache[account] = secret
+	delete(misses, account)
+	logging.LogInfo("Stored secret in keyring", "keyring", backend.Name(), "account", account)
+	return nil
+}
+
+// Delete removes the secret for account
+func Delete(account string) error {
+	if os.Getenv(DisableEnvVar) != "" {
+		return ErrUnsupported
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := backend.Delete(account); err != nil {
+		return err
+	}
+	delete(cache, account)
+	misses[account] = true
+	logging.LogInfo("Removed secret from keyring", "keyring", backend.Name(), "account", account)
+	return nil
+}
+
+// MemoryBackend keeps secrets in memory, for tests
+type MemoryBackend struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// NewMemoryBackend creates an empty in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{secrets: map[string]string{}}
+}
+
+// Name implements Backend
+func (m *MemoryBackend) Name() string {
+	return "memory"
+}
+
+// Get implements Backend
+func (m *MemoryBackend) Get(account string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	secret, ok := m.secrets[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set implements Backend
+func (m *MemoryBackend) Set(account, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[account] = secret
+	return nil
+}
+
+// Delete implements Backend
+func (m *MemoryBackend) Delete(account string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.secrets[account]; !ok {
+		return ErrNotFound
+	}
+	delete(m.secrets, account)
+	return nil
+}
@@ -0,0 +1,72 @@
+// ABOUTME: macOS Keychain backend using the security command-line tool
+// ABOUTME: Passes secrets on stdin in hex so they never appear in process arguments
+
+//go:build darwin
+
+package keyring
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// securityNotFound is the exit status security uses for a missing item
+const securityNotFound = 44
+
+// keychainBackend stores secrets as generic passwords in the login keychain
+type keychainBackend struct{}
+
+func platformBackend() Backend {
+	return keychainBackend{}
+}
+
+// Name implements Backend
+func (keychainBackend) Name() string {
+	return "macOS Keychain"
+}
+
+// Get implements Backend
+func (keychainBackend) Get(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", Service, "-a", account, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// Set implements Backend
+func (keychainBackend) Set(account, secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		Service, account, hex.EncodeToString([]byte(secret))))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("security add-generic-password: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Delete implements Backend
+func (keychainBackend) Delete(account string) error {
+	if err := exec.Command("security", "delete-generic-password", "-s", Service, "-a", account).Run(); err != nil {
+		return securityError(err)
+	}
+	return nil
+}
+
+// securityError maps security exit statuses to keyring errors
+func securityError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+		return ErrNotFound
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return ErrUnsupported
+	}
+	return fmt.Errorf("security: %w", err)
+}
@@ -0,0 +1,57 @@
+// ABOUTME: Tests for keyring secret storage
+// ABOUTME: Verifies the lookup cache, backend swapping, and the disable switch
+
+package keyring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingBackend counts lookups that reach the backend
+type countingBackend struct {
+	*MemoryBackend
+	gets int
+}
+
+func (c *countingBackend) Get(account string) (string, error) {
+	c.gets++
+	return c.MemoryBackend.Get(account)
+}
+
+func TestKeyring(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	backend := &countingBackend{MemoryBackend: NewMemoryBackend()}
+	restore := SetBackend(backend)
+	defer restore()
+
+	assert.Equal(t, "memory", Name())
+
+	_, err := Get("openai")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = Get("openai")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 1, backend.gets, "misses are cached")
+
+	require.NoError(t, Set("openai", "sk-test"))
+	key, err := Get("openai")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-test", key)
+	assert.Equal(t, 1, backend.gets, "stored keys are served from the cache")
+
+	require.NoError(t, Delete("openai"))
+	_, err = Get("openai")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, Delete("openai"), ErrNotFound)
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv(DisableEnvVar, "1")
+		require.NoError(t, backend.Set("anthropic", "sk-ant"))
+
+		_, err := Get("anthropic")
+		assert.ErrorIs(t, err, ErrUnsupported)
+		assert.ErrorIs(t, Set("anthropic", "x"), ErrUnsupported)
+	})
+}
@@ -0,0 +1,83 @@
+// ABOUTME: Secret Service backend (GNOME Keyring, KWallet) using secret-tool
+// ABOUTME: Passes secrets on stdin so they never appear in process arguments
+
+//go:build !darwin && !windows
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// secretServiceBackend stores secrets through the freedesktop Secret Service
+type secretServiceBackend struct{}
+
+func platformBackend() Backend {
+	return secretServiceBackend{}
+}
+
+// Name implements Backend
+func (secretServiceBackend) Name() string {
+	return "Secret Service"
+}
+
+// Get implements Backend
+func (secretServiceBackend) Get(account string) (string, error) {
+	out, err := secretTool(nil, "lookup", "service", Service, "account", account)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) == 0 {
+			// secret-tool exits 1 without a message when nothing matches
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	if out == "" {
+		return "", ErrNotFound
+	}
+	return out, nil
+}
+
+// Set implements Backend
+func (secretServiceBackend) Set(account, secret string) error {
+	_, err := secretTool(strings.NewReader(secret),
+		"store", "--label", fmt.Sprintf("%s %s API key", Service, account),
+		"service", Service, "account", account)
+	return err
+}
+
+// Delete implements Backend
+func (b secretServiceBackend) Delete(account string) error {
+	// secret-tool clear succeeds when nothing matches, so check first
+	if _, err := b.Get(account); err != nil {
+		return err
+	}
+	_, err := secretTool(nil, "clear", "service", Service, "account", account)
+	return err
+}
+
+// secretTool runs secret-tool and returns its trimmed output
+func secretTool(stdin *strings.Reader, args ...string) (string, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return "", fmt.Errorf("%w: secret-tool not found (install libsecret-tools)", ErrUnsupported)
+	}
+
+	cmd := exec.Command(path, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+			return "", fmt.Errorf("secret-tool %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
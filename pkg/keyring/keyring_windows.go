@@ -0,0 +1,133 @@
+// ABOUTME: Windows Credential Manager backend using the advapi32 credential API
+// ABOUTME: Stores each secret as a generic credential named magellai:<account>
+
+//go:build windows
+
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// credential mirrors the Win32 CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credentialBackend stores secrets in the Windows Credential Manager
+type credentialBackend struct{}
+
+func platformBackend() Backend {
+	return credentialBackend{}
+}
+
+// Name implements Backend
+func (credentialBackend) Name() string {
+	return "Windows Credential Manager"
+}
+
+// Get implements Backend
+func (credentialBackend) Get(account string) (string, error) {
+	target, err := syscall.UTF16PtrFromString(targetName(account))
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	r, _, callErr := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		return "", credentialError("CredRead", callErr)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	return string(blob), nil
+}
+
+// Set implements Backend
+func (credentialBackend) Set(account, secret string) error {
+	target, err := syscall.UTF16PtrFromString(targetName(account))
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(secret)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		UserName:           user,
+		Persist:            credPersistLocalMachine,
+		CredentialBlobSize: uint32(len(blob)),
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	if r, _, callErr := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); r == 0 {
+		return credentialError("CredWrite", callErr)
+	}
+	return nil
+}
+
+// Delete implements Backend
+func (credentialBackend) Delete(account string) error {
+	target, err := syscall.UTF16PtrFromString(targetName(account))
+	if err != nil {
+		return err
+	}
+	if r, _, callErr := procCredDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0); r == 0 {
+		return credentialError("CredDelete", callErr)
+	}
+	return nil
+}
+
+// targetName is the credential name for an account
+func targetName(account string) string {
+	return Service + ":" + account
+}
+
+// credentialError maps Win32 errors to keyring errors
+func credentialError(op string, err error) error {
+	if errors.Is(err, errorNotFound) {
+		return ErrNotFound
+	}
+	if errors.Is(err, syscall.ERROR_PROC_NOT_FOUND) {
+		return ErrUnsupported
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
@@ -5,9 +5,11 @@ package llm
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	llmdomain "github.com/lexlapax/go-llms/pkg/llm/domain"
+	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/domain"
 )
 
@@ -19,13 +21,14 @@ func ToLLMMessage(msg *domain.Message) llmdomain.Message {
 	llmMsg := llmdomain.Message{
 		Role: role,
 	}
+	text := messageText(msg)
 
 	// Convert simple text content
-	if msg.Content != "" && len(msg.Attachments) == 0 {
+	if text != "" && len(msg.Attachments) == 0 {
 		llmMsg.Content = []llmdomain.ContentPart{
 			{
 				Type: llmdomain.ContentTypeText,
-				Text: msg.Content,
+				Text: text,
 			},
 		}
 		return llmMsg
@@ -35,16 +38,16 @@ func ToLLMMessage(msg *domain.Message) llmdomain.Message {
 	llmMsg.Content = make([]llmdomain.ContentPart, 0)
 
 	// Add text content first if present
-	if msg.Content != "" {
+	if text != "" {
 		llmMsg.Content = append(llmMsg.Content, llmdomain.ContentPart{
 			Type: llmdomain.ContentTypeText,
-			Text: msg.Content,
+			Text: text,
 		})
 	}
 
 	// Add attachments
-	for _, att := range msg.Attachments {
-		if part := attachmentToLLMContentPart(&att); part != nil {
+	for i := range msg.Attachments {
+		if part := attachmentToLLMContentPart(&msg.Attachments[i]); part != nil {
 			llmMsg.Content = append(llmMsg.Content, *part)
 		}
 	}
@@ -110,6 +113,31 @@ func FromLLMMessages(messages []llmdomain.Message) []domain.Message {
 	return domainMessages
 }
 
+// messageText returns the text sent for a message. go-llms messages carry
+// no tool call parts, so tool calls and results are rendered as text after
+// the content, and providers read the first text part of a tool message.
+func messageText(msg *domain.Message) string {
+	if !msg.HasToolUse() {
+		return msg.Content
+	}
+
+	parts := make([]string, 0, 1+len(msg.ToolCalls)+len(msg.ToolResults))
+	if msg.Content != "" {
+		parts = append(parts, msg.Content)
+	}
+	for _, call := range msg.ToolCalls {
+		parts = append(parts, fmt.Sprintf("[tool call %s] %s", call.ID, call.String()))
+	}
+	for _, result := range msg.ToolResults {
+		if msg.Role == domain.MessageRoleTool && len(msg.ToolResults) == 1 {
+			parts = append(parts, result.Text())
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("[tool result %s] %s", result.ToolCallID, result.Text()))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 // Role conversion helpers
 
 func toDomainRole(role llmdomain.Role) domain.MessageRole {
@@ -121,8 +149,7 @@ func toDomainRole(role llmdomain.Role) domain.MessageRole {
 	case llmdomain.RoleSystem:
 		return domain.MessageRoleSystem
 	case llmdomain.RoleTool:
-		// Tool role doesn't exist in domain, map to assistant
-		return domain.MessageRoleAssistant
+		return domain.MessageRoleTool
 	default:
 		return domain.MessageRole(role)
 	}
@@ -134,6 +161,11 @@ func attachmentToLLMContentPart(att *domain.Attachment) *llmdomain.ContentPart {
 	if att == nil {
 		return nil
 	}
+	// Content stored separately is loaded on first use
+	content, err := att.LoadContent()
+	if err != nil {
+		logging.LogWarn("Failed to load attachment content", "id", att.ID, "error", err)
+	}
 	switch att.Type {
 	case domain.AttachmentTypeImage:
 		return &llmdomain.ContentPart{
@@ -141,7 +173,7 @@ func attachmentToLLMContentPart(att *domain.Attachment) *llmdomain.ContentPart {
 			Image: &llmdomain.ImageContent{
 				Source: llmdomain.SourceInfo{
 					Type:      llmdomain.SourceTypeBase64,
-					Data:      string(att.Content), // Assume base64 encoded
+					Data:      string(content), // Assume base64 encoded
 					MediaType: att.MimeType,
 				},
 			},
@@ -149,14 +181,14 @@ func attachmentToLLMContentPart(att *domain.Attachment) *llmdomain.ContentPart {
 	case domain.AttachmentTypeText:
 		return &llmdomain.ContentPart{
 			Type: llmdomain.ContentTypeText,
-			Text: string(att.Content),
+			Text: string(content),
 		}
 	case domain.AttachmentTypeFile:
 		return &llmdomain.ContentPart{
 			Type: llmdomain.ContentTypeFile,
 			File: &llmdomain.FileContent{
 				FileName: att.Name,
-				FileData: string(att.Content), // Assume base64 encoded
+				FileData: string(content), // Assume base64 encoded
 				MimeType: att.MimeType,
 			},
 		}
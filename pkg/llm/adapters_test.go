@@ -182,6 +182,38 @@ func TestToLLMMessage(t *testing.T) {
 	}
 }
 
+func TestToLLMMessage_ToolUse(t *testing.T) {
+	call := domain.NewToolCall("call_1", "get_weather", map[string]interface{}{"city": "Paris"})
+	assistant := &domain.Message{ID: "m1", Role: domain.MessageRoleAssistant, Content: "Let me check."}
+	assistant.AddToolCall(*call)
+
+	got := ToLLMMessage(assistant)
+	if len(got.Content) != 1 {
+		t.Fatalf("expected 1 content part, got %d", len(got.Content))
+	}
+	want := "Let me check.\n\n[tool call call_1] get_weather({\"city\":\"Paris\"})"
+	if got.Content[0].Text != want {
+		t.Errorf("expected %q, got %q", want, got.Content[0].Text)
+	}
+
+	tool := &domain.Message{ID: "m2", Role: domain.MessageRoleTool}
+	tool.AddToolResult(*domain.NewToolResult(*call, "18C and sunny"))
+	got = ToLLMMessage(tool)
+	if got.Role != llmdomain.RoleTool {
+		t.Errorf("expected tool role, got %s", got.Role)
+	}
+	if len(got.Content) != 1 || got.Content[0].Text != "18C and sunny" {
+		t.Errorf("expected the result text, got %+v", got.Content)
+	}
+
+	failed := &domain.Message{ID: "m3", Role: domain.MessageRoleTool}
+	failed.AddToolResult(*domain.NewToolError(*call, fmt.Errorf("service down")))
+	got = ToLLMMessage(failed)
+	if len(got.Content) != 1 || got.Content[0].Text != "Error: service down" {
+		t.Errorf("expected the error text, got %+v", got.Content)
+	}
+}
+
 func TestFromLLMMessage(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -242,7 +274,7 @@ func TestFromLLMMessage(t *testing.T) {
 			},
 		},
 		{
-			name: "tool role mapped to assistant",
+			name: "tool role preserved",
 			llmMsg: llmdomain.Message{
 				Role: llmdomain.RoleTool,
 				Content: []llmdomain.ContentPart{
@@ -252,10 +284,10 @@ func TestFromLLMMessage(t *testing.T) {
 					},
 				},
 			},
-			wantRole: domain.MessageRoleAssistant,
+			wantRole: domain.MessageRoleTool,
 			checkFunc: func(t *testing.T, msg *domain.Message) {
-				if msg.Role != domain.MessageRoleAssistant {
-					t.Errorf("expected assistant role, got %s", msg.Role)
+				if msg.Content != "Tool response" {
+					t.Errorf("expected 'Tool response', got %s", msg.Content)
 				}
 			},
 		},
@@ -688,9 +720,9 @@ func TestRoleConversion(t *testing.T) {
 			domainRole: domain.MessageRoleSystem,
 		},
 		{
-			name:       "tool role maps to assistant",
+			name:       "tool role maps to tool",
 			llmRole:    llmdomain.RoleTool,
-			domainRole: domain.MessageRoleAssistant,
+			domainRole: domain.MessageRoleTool,
 		},
 		{
 			name:       "unknown role preserved",
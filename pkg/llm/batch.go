@@ -0,0 +1,459 @@
+// ABOUTME: Bulk prompt submission via concurrent requests or the OpenAI Batch API
+// ABOUTME: Reads and writes JSONL batch files and reports job progress
+
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// BatchRequest is a single prompt in a batch input file
+type BatchRequest struct {
+	ID     string `json:"id"`
+	Prompt string `json:"prompt"`
+	System string `json:"system,omitempty"`
+}
+
+// BatchResult is a single line in a batch output file
+type BatchResult struct {
+	ID       string `json:"id"`
+	Prompt   string `json:"prompt"`
+	Content  string `json:"content,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// BatchProgress reports job status while a batch runs
+type BatchProgress struct {
+	Status    string
+	Completed int
+	Failed    int
+	Total     int
+}
+
+// BatchProgressFunc receives progress updates
+type BatchProgressFunc func(BatchProgress)
+
+// ReadBatchRequests parses a JSONL batch input. Each line is either an object
+// with id/prompt/system fields or a bare JSON string used as the prompt.
+// Missing IDs default to the 1-based line number.
+func ReadBatchRequests(r io.Reader) ([]BatchRequest, error) {
+	var requests []BatchRequest
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var req BatchRequest
+		if strings.HasPrefix(text, "\"") {
+			if err := json.Unmarshal([]byte(text), &req.Prompt); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidBatchInput, line, err)
+			}
+		} else if err := json.Unmarshal([]byte(text), &req); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidBatchInput, line, err)
+		}
+
+		if req.Prompt == "" {
+			return nil, fmt.Errorf("%w: line %d: prompt is required", ErrInvalidBatchInput, line)
+		}
+		if req.ID == "" {
+			req.ID = fmt.Sprintf("%d", line)
+		}
+		requests = append(requests, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch input: %w", err)
+	}
+
+	return requests, nil
+}
+
+// WriteBatchResults writes results as JSONL
+func WriteBatchResults(w io.Writer, results []BatchResult) error {
+	encoder := json.NewEncoder(w)
+	for _, result := range results {
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("failed to write batch result: %w", err)
+		}
+	}
+	return nil
+}
+
+// buildBatchMessages converts a batch request into conversation messages
+func buildBatchMessages(req BatchRequest, defaultSystem string) []domain.Message {
+	var messages []domain.Message
+	system := req.System
+	if system == "" {
+		system = defaultSystem
+	}
+	if system != "" {
+		messages = append(messages, domain.Message{Role: domain.MessageRoleSystem, Content: system})
+	}
+	return append(messages, domain.Message{Role: domain.MessageRoleUser, Content: req.Prompt})
+}
+
+// RunConcurrentBatch sends each request through the provider with at most
+// concurrency requests in flight. Individual failures are recorded in the
+// result rather than aborting the batch. Results keep the input order.
+func RunConcurrentBatch(ctx context.Context, provider Provider, requests []BatchRequest, concurrency int, defaultSystem string, progress BatchProgressFunc, opts ...ProviderOption) []BatchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	info := provider.GetModelInfo()
+	results := make([]BatchResult, len(requests))
+	sem := make(chan struct{}, concurrency)
+
+	var mu sync.Mutex
+	state := BatchProgress{Status: "in_progress", Total: len(requests)}
+
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		wg.Add(1)
+		go func(i int, req BatchRequest) {
+			defer wg.Done()
+
+			result := BatchResult{ID: req.ID, Prompt: req.Prompt, Provider: info.Provider, Model: info.Model}
+
+			select {
+			case sem <- struct{}{}:
+				resp, err := provider.GenerateMessage(ctx, buildBatchMessages(req, defaultSystem), opts...)
+				<-sem
+				if err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Content = resp.Content
+				}
+			case <-ctx.Done():
+				result.Error = ctx.Err().Error()
+			}
+			results[i] = result
+
+			mu.Lock()
+			if result.Error != "" {
+				state.Failed++
+				logging.LogWarn("Batch request failed", "id", req.ID, "error", result.Error)
+			} else {
+				state.Completed++
+			}
+			snapshot := state
+			mu.Unlock()
+
+			if progress != nil {
+				progress(snapshot)
+			}
+		}(i, req)
+	}
+	wg.Wait()
+
+	if progress != nil {
+		state.Status = "completed"
+		progress(state)
+	}
+
+	return results
+}
+
+// OpenAIBatchClient submits jobs to the OpenAI Batch API
+type OpenAIBatchClient struct {
+	apiKey       string
+	baseURL      string
+	client       *http.Client
+	PollInterval time.Duration
+}
+
+// NewOpenAIBatchClient creates a Batch API client
+func NewOpenAIBatchClient(apiKey, baseURL string) *OpenAIBatchClient {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	return &OpenAIBatchClient{
+		apiKey:       apiKey,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		client:       &http.Client{Timeout: 60 * time.Second},
+		PollInterval: 30 * time.Second,
+	}
+}
+
+// OpenAIBatchJob mirrors the Batch API job object
+type OpenAIBatchJob struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id"`
+	ErrorFileID   string `json:"error_file_id"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+}
+
+// Run uploads the requests, creates a batch job, waits for it to finish, and
+// returns results in input order
+func (c *OpenAIBatchClient) Run(ctx context.Context, model string, requests []BatchRequest, defaultSystem string, progress BatchProgressFunc, opts ...ProviderOption) ([]BatchResult, error) {
+	input, err := c.buildInputFile(model, requests, defaultSystem, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	fileID, err := c.uploadFile(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	logging.LogInfo("Uploaded batch input file", "fileID", fileID, "requests", len(requests))
+
+	job, err := c.createJob(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	logging.LogInfo("Created batch job", "batchID", job.ID)
+
+	job, err = c.Wait(ctx, job.ID, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.collectResults(ctx, job, model, requests)
+}
+
+// buildInputFile encodes requests in the Batch API JSONL input format
+func (c *OpenAIBatchClient) buildInputFile(model string, requests []BatchRequest, defaultSystem string, opts ...ProviderOption) ([]byte, error) {
+	options := &providerConfig{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, req := range requests {
+		var messages []map[string]string
+		for _, msg := range buildBatchMessages(req, defaultSystem) {
+			messages = append(messages, map[string]string{"role": string(msg.Role), "content": msg.Content})
+		}
+
+		body := map[string]interface{}{
+			"model":    model,
+			"messages": messages,
+		}
+		if options.temperature != nil {
+			body["temperature"] = *options.temperature
+		}
+		if options.maxTokens != nil {
+			body["max_tokens"] = *options.maxTokens
+		}
+
+		if err := encoder.Encode(map[string]interface{}{
+			"custom_id": req.ID,
+			"method":    http.MethodPost,
+			"url":       "/v1/chat/completions",
+			"body":      body,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to encode batch request %s: %w", req.ID, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// uploadFile uploads the batch input and returns its file ID
+func (c *OpenAIBatchClient) uploadFile(ctx context.Context, data []byte) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("purpose", "batch"); err != nil {
+		return "", fmt.Errorf("failed to build upload request: %w", err)
+	}
+	part, err := writer.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to build upload request: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", fmt.Errorf("failed to build upload request: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to build upload request: %w", err)
+	}
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/files", writer.FormDataContentType(), &body, &file); err != nil {
+		return "", fmt.Errorf("failed to upload batch input: %w", err)
+	}
+	return file.ID, nil
+}
+
+// createJob starts a batch job for an uploaded input file
+func (c *OpenAIBatchClient) createJob(ctx context.Context, fileID string) (*OpenAIBatchJob, error) {
+	payload, err := json.Marshal(map[string]string{
+		"input_file_id":     fileID,
+		"endpoint":          "/v1/chat/completions",
+		"completion_window": "24h",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode batch job: %w", err)
+	}
+
+	var job OpenAIBatchJob
+	if err := c.do(ctx, http.MethodPost, "/batches", "application/json", bytes.NewReader(payload), &job); err != nil {
+		return nil, fmt.Errorf("failed to create batch job: %w", err)
+	}
+	return &job, nil
+}
+
+// GetJob fetches the current state of a batch job
+func (c *OpenAIBatchClient) GetJob(ctx context.Context, batchID string) (*OpenAIBatchJob, error) {
+	var job OpenAIBatchJob
+	if err := c.do(ctx, http.MethodGet, "/batches/"+batchID, "", nil, &job); err != nil {
+		return nil, fmt.Errorf("failed to get batch job %s: %w", batchID, err)
+	}
+	return &job, nil
+}
+
+// Wait polls a batch job until it reaches a terminal state
+func (c *OpenAIBatchClient) Wait(ctx context.Context, batchID string, progress BatchProgressFunc) (*OpenAIBatchJob, error) {
+	for {
+		job, err := c.GetJob(ctx, batchID)
+		if err != nil {
+			return nil, err
+		}
+
+		if progress != nil {
+			progress(BatchProgress{
+				Status:    job.Status,
+				Completed: job.RequestCounts.Completed,
+				Failed:    job.RequestCounts.Failed,
+				Total:     job.RequestCounts.Total,
+			})
+		}
+
+		switch job.Status {
+		case "completed":
+			return job, nil
+		case "failed", "expired", "cancelled":
+			return nil, fmt.Errorf("%w: batch %s ended with status %s", ErrBatchFailed, batchID, job.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.PollInterval):
+		}
+	}
+}
+
+// collectResults downloads output and error files and maps them back to requests
+func (c *OpenAIBatchClient) collectResults(ctx context.Context, job *OpenAIBatchJob, model string, requests []BatchRequest) ([]BatchResult, error) {
+	type outputLine struct {
+		CustomID string `json:"custom_id"`
+		Response struct {
+			StatusCode int `json:"status_code"`
+			Body       struct {
+				Choices []struct {
+					Message struct {
+						Content string `json:"content"`
+					} `json:"message"`
+				} `json:"choices"`
+				Error *struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"body"`
+		} `json:"response"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	byID := make(map[string]outputLine)
+	for _, fileID := range []string{job.OutputFileID, job.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		var content bytes.Buffer
+		if err := c.do(ctx, http.MethodGet, "/files/"+fileID+"/content", "", nil, &content); err != nil {
+			return nil, fmt.Errorf("failed to download batch results: %w", err)
+		}
+		scanner := bufio.NewScanner(&content)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			var line outputLine
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				logging.LogWarn("Skipping malformed batch output line", "error", err)
+				continue
+			}
+			byID[line.CustomID] = line
+		}
+	}
+
+	results := make([]BatchResult, len(requests))
+	for i, req := range requests {
+		result := BatchResult{ID: req.ID, Prompt: req.Prompt, Provider: ProviderOpenAI, Model: model}
+		line, ok := byID[req.ID]
+		switch {
+		case !ok:
+			result.Error = "no result returned"
+		case line.Error != nil:
+			result.Error = line.Error.Message
+		case line.Response.Body.Error != nil:
+			result.Error = line.Response.Body.Error.Message
+		case len(line.Response.Body.Choices) > 0:
+			result.Content = line.Response.Body.Choices[0].Message.Content
+		default:
+			result.Error = fmt.Sprintf("empty response (status %d)", line.Response.StatusCode)
+		}
+		results[i] = result
+	}
+
+	return results, nil
+}
+
+// do performs an API request and decodes the response into out. When out is a
+// *bytes.Buffer the raw body is copied instead of decoded.
+func (c *OpenAIBatchClient) do(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%w: %s %s returned %d: %s", ErrProviderError, method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if buf, ok := out.(*bytes.Buffer); ok {
+		_, err = io.Copy(buf, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// ABOUTME: Tests for bulk prompt submission
+// ABOUTME: Covers JSONL parsing, concurrent batches, and the OpenAI Batch API client
+
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBatchRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []BatchRequest
+		wantErr bool
+	}{
+		{
+			name:  "objects and strings",
+			input: "{\"id\":\"a\",\"prompt\":\"first\"}\n\n\"second\"\n{\"prompt\":\"third\",\"system\":\"be brief\"}\n",
+			want: []BatchRequest{
+				{ID: "a", Prompt: "first"},
+				{ID: "3", Prompt: "second"},
+				{ID: "4", Prompt: "third", System: "be brief"},
+			},
+		},
+		{
+			name:    "missing prompt",
+			input:   `{"id":"a"}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed line",
+			input:   `{"id":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadBatchRequests(strings.NewReader(tt.input))
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrInvalidBatchInput))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriteBatchResults(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteBatchResults(&buf, []BatchResult{
+		{ID: "1", Prompt: "p1", Content: "c1", Provider: "mock", Model: "m"},
+		{ID: "2", Prompt: "p2", Error: "boom", Provider: "mock", Model: "m"},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var second BatchResult
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "boom", second.Error)
+	assert.Empty(t, second.Content)
+}
+
+func TestRunConcurrentBatch(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	provider := &mockProvider{
+		modelInfo: ModelInfo{Provider: "mock", Model: "test"},
+		generateMessageFunc: func(ctx context.Context, messages []domain.Message, opts ...ProviderOption) (*Response, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			last := messages[len(messages)-1]
+			if last.Content == "fail" {
+				return nil, fmt.Errorf("provider failure")
+			}
+			if messages[0].Role != domain.MessageRoleSystem {
+				return nil, fmt.Errorf("missing system prompt")
+			}
+			return &Response{Content: "echo " + last.Content}, nil
+		},
+	}
+
+	requests := []BatchRequest{
+		{ID: "1", Prompt: "one"},
+		{ID: "2", Prompt: "fail"},
+		{ID: "3", Prompt: "three"},
+		{ID: "4", Prompt: "four"},
+		{ID: "5", Prompt: "five"},
+	}
+
+	var updates []BatchProgress
+	var progressMu sync.Mutex
+	results := RunConcurrentBatch(context.Background(), provider, requests, 2, "system", func(p BatchProgress) {
+		progressMu.Lock()
+		updates = append(updates, p)
+		progressMu.Unlock()
+	})
+
+	require.Len(t, results, 5)
+	assert.Equal(t, "echo one", results[0].Content)
+	assert.Equal(t, "provider failure", results[1].Error)
+	assert.Equal(t, "echo five", results[4].Content)
+	assert.Equal(t, "mock", results[0].Provider)
+	assert.LessOrEqual(t, maxInFlight, 2)
+
+	final := updates[len(updates)-1]
+	assert.Equal(t, "completed", final.Status)
+	assert.Equal(t, 4, final.Completed)
+	assert.Equal(t, 1, final.Failed)
+}
+
+func TestOpenAIBatchClientRun(t *testing.T) {
+	var polls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/files":
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			assert.Equal(t, "batch", r.FormValue("purpose"))
+			file, _, err := r.FormFile("file")
+			require.NoError(t, err)
+			data, _ := io.ReadAll(file)
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			assert.Len(t, lines, 2)
+			assert.Contains(t, lines[0], `"custom_id":"a"`)
+			assert.Contains(t, lines[0], `"url":"/v1/chat/completions"`)
+			_, _ = w.Write([]byte(`{"id":"file-in"}`))
+
+		case r.Method == http.MethodPost && r.URL.Path == "/batches":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "file-in", body["input_file_id"])
+			_, _ = w.Write([]byte(`{"id":"batch-1","status":"validating"}`))
+
+		case r.Method == http.MethodGet && r.URL.Path == "/batches/batch-1":
+			polls++
+			status := "in_progress"
+			if polls > 1 {
+				status = "completed"
+			}
+			fmt.Fprintf(w, `{"id":"batch-1","status":%q,"output_file_id":"file-out","error_file_id":"file-err","request_counts":{"total":2,"completed":1,"failed":1}}`, status)
+
+		case r.URL.Path == "/files/file-out/content":
+			_, _ = w.Write([]byte(`{"custom_id":"a","response":{"status_code":200,"body":{"choices":[{"message":{"content":"answer a"}}]}}}` + "\n"))
+
+		case r.URL.Path == "/files/file-err/content":
+			_, _ = w.Write([]byte(`{"custom_id":"b","error":{"message":"bad request"}}` + "\n"))
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewOpenAIBatchClient("key", server.URL)
+	client.PollInterval = time.Millisecond
+
+	var statuses []string
+	results, err := client.Run(context.Background(), "gpt-4o-mini",
+		[]BatchRequest{{ID: "a", Prompt: "question a"}, {ID: "b", Prompt: "question b"}},
+		"", func(p BatchProgress) { statuses = append(statuses, p.Status) },
+		WithTemperature(0.2))
+	require.NoError(t, err)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, "answer a", results[0].Content)
+	assert.Equal(t, "bad request", results[1].Error)
+	assert.Equal(t, []string{"in_progress", "completed"}, statuses)
+}
+
+func TestOpenAIBatchClientFailedJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"batch-1","status":"expired"}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIBatchClient("key", server.URL)
+	_, err := client.Wait(context.Background(), "batch-1", nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBatchFailed))
+}
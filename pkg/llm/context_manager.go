@@ -171,6 +171,13 @@ func (m *ContextManager) applyPrioritization(messages []domain.Message) []domain
 		}
 	}
 
+	// Keep pinned messages
+	for i, msg := range conversation {
+		if msg.Pinned {
+			keepIndices[i] = true
+		}
+	}
+
 	// Calculate importance scores for middle messages
 	importance := m.calculateImportance(conversation)
 
@@ -425,6 +432,11 @@ func (t *EstimatedTokenCounter) CountMessageTokens(messages []domain.Message) in
 		for _, att := range msg.Attachments {
 			switch att.Type {
 			case domain.AttachmentTypeText:
+				if len(att.Content) == 0 && att.Hash != "" {
+					// Content not loaded yet; estimate from its size
+					total += int(float64(att.Size) / t.charactersPerToken)
+					continue
+				}
 				total += t.CountTokens(string(att.Content))
 			case domain.AttachmentTypeImage:
 				total += 500 // Rough estimate for image tokens
@@ -256,6 +256,21 @@ func TestApplyPrioritization(t *testing.T) {
 			},
 			expectedRoles: []string{"user", "user"},
 		},
+		{
+			name: "keeps pinned messages",
+			messages: []domain.Message{
+				{Role: "user", Content: "First"},
+				{Role: "assistant", Content: "Response 1"},
+				{Role: "user", Content: "Pinned", Pinned: true},
+				{Role: "assistant", Content: "Response 2"},
+				{Role: "user", Content: "Last"},
+			},
+			config: PriorityConfig{
+				KeepFirstN: 1,
+				KeepLastN:  1,
+			},
+			expectedRoles: []string{"user", "user", "user"}, // First, pinned, and last
+		},
 		{
 			name:          "empty messages",
 			messages:      []domain.Message{},
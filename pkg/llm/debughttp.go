@@ -0,0 +1,299 @@
+// ABOUTME: Capture of provider HTTP exchanges for debugging, enabled by --debug-http
+// ABOUTME: Writes each request and response to a JSON file with API keys redacted and attachments truncated
+
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+const (
+	// maxCapturedBody caps how much of a response, or of a request that is
+	// not JSON, is written to a capture
+	maxCapturedBody = 1 << 20
+	// attachmentPrefixLength is how much of a truncated attachment is kept
+	attachmentPrefixLength = 32
+	// minAttachmentLength is the length from which a base64 string is
+	// treated as attachment data
+	minAttachmentLength = 256
+	// redacted replaces secrets in captures
+	redacted = "[REDACTED]"
+)
+
+// secretHeaders are request headers that carry credentials
+var secretHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key", "Api-Key", "Proxy-Authorization"}
+
+// secretParams are query parameters that carry credentials
+var secretParams = []string{"key", "api_key", "apikey", "access_token"}
+
+var (
+	debugHTTPMu  sync.Mutex
+	debugHTTPDir string
+	captureSeq   atomic.Int64
+)
+
+// SetDebugHTTPDir makes providers created afterwards write their HTTP
+// requests and responses to files in dir. An empty dir turns capture off. It
+// returns a function restoring the previous setting.
+func SetDebugHTTPDir(dir string) func() {
+	debugHTTPMu.Lock()
+	defer debugHTTPMu.Unlock()
+	previous := debugHTTPDir
+	debugHTTPDir = dir
+	return func() {
+		debugHTTPMu.Lock()
+		defer debugHTTPMu.Unlock()
+		debugHTTPDir = previous
+	}
+}
+
+// DebugHTTPDir returns where provider HTTP exchanges are captured, or "" when
+// capture is off
+func DebugHTTPDir() string {
+	debugHTTPMu.Lock()
+	defer debugHTTPMu.Unlock()
+	return debugHTTPDir
+}
+
+// enableDebugHTTP routes a provider's requests through a capturing client
+// when capture is on and the provider accepts an HTTP client
+func enableDebugHTTP(provider interface{}, providerType, apiKey string) {
+	dir := DebugHTTPDir()
+	if dir == "" {
+		return
+	}
+	settable, ok := provider.(interface{ SetHTTPClient(*http.Client) })
+	if !ok {
+		return
+	}
+	settable.SetHTTPClient(&http.Client{Transport: &captureTransport{
+		base:     http.DefaultTransport,
+		dir:      dir,
+		provider: providerType,
+		secrets:  []string{apiKey},
+	}})
+	logging.LogInfo("Capturing provider HTTP traffic", "provider", providerType, "dir", dir)
+}
+
+// HTTPCapture is one provider HTTP exchange as written to disk
+type HTTPCapture struct {
+	Time       time.Time    `json:"time"`
+	Provider   string       `json:"provider"`
+	Method     string       `json:"method"`
+	URL        string       `json:"url"`
+	DurationMS int64        `json:"duration_ms"`
+	Request    CapturedBody `json:"request"`
+	Response   CapturedBody `json:"response,omitempty"`
+	Status     int          `json:"status,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// CapturedBody is the headers and body of a request or response. JSON
+// bodies are kept as JSON; others, such as event streams, as text.
+type CapturedBody struct {
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      interface{}       `json:"body,omitempty"`
+	Truncated bool              `json:"truncated,omitempty"`
+}
+
+// captureTransport writes each exchange it carries to a file
+type captureTransport struct {
+	base     http.RoundTripper
+	dir      string
+	provider string
+	secrets  []string
+}
+
+// RoundTrip sends the request and captures it with its response. The
+// response is written once its body has been read and closed, so streamed
+// responses are captured whole without being delayed.
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	capture := &HTTPCapture{
+		Time:     time.Now(),
+		Provider: t.provider,
+		Method:   req.Method,
+		URL:      t.redactURL(req.URL),
+	}
+	capture.Request.Headers = t.redactHeaders(req.Header)
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		capture.Request.Body, capture.Request.Truncated = t.sanitizeBody(body)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		capture.DurationMS = time.Since(capture.Time).Milliseconds()
+		capture.Error = t.redact(err.Error())
+		t.write(capture)
+		return nil, err
+	}
+
+	capture.Status = resp.StatusCode
+	capture.Response.Headers = t.redactHeaders(resp.Header)
+	resp.Body = &capturingBody{ReadCloser: resp.Body, done: func(body []byte, truncated bool, readErr error) {
+		capture.DurationMS = time.Since(capture.Time).Milliseconds()
+		capture.Response.Body, capture.Response.Truncated = t.sanitizeBody(body)
+		capture.Response.Truncated = capture.Response.Truncated || truncated
+		if readErr != nil && readErr != io.EOF {
+			capture.Error = t.redact(readErr.Error())
+		}
+		t.write(capture)
+	}}
+	return resp, nil
+}
+
+// write saves a capture, logging rather than failing the request when it
+// cannot
+func (t *captureTransport) write(capture *HTTPCapture) {
+	name := fmt.Sprintf("%s-%s-%04d.json", capture.Time.Format("20060102-150405.000"), t.provider, captureSeq.Add(1))
+	data, err := json.MarshalIndent(capture, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(t.dir, 0700); err == nil {
+			err = os.WriteFile(filepath.Join(t.dir, name), data, 0600)
+		}
+	}
+	if err != nil {
+		logging.LogWarn("Failed to write HTTP capture", "dir", t.dir, "error", err)
+	}
+}
+
+// redact replaces the provider's API keys in s
+func (t *captureTransport) redact(s string) string {
+	for _, secret := range t.secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
+
+func (t *captureTransport) redactURL(u *url.URL) string {
+	redactedURL := *u
+	query := redactedURL.Query()
+	for _, param := range secretParams {
+		if query.Has(param) {
+			query.Set(param, redacted)
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+	return t.redact(redactedURL.String())
+}
+
+func (t *captureTransport) redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name := range header {
+		headers[name] = t.redact(header.Get(name))
+	}
+	for _, name := range secretHeaders {
+		if _, ok := headers[http.CanonicalHeaderKey(name)]; ok {
+			headers[http.CanonicalHeaderKey(name)] = redacted
+		}
+	}
+	return headers
+}
+
+// sanitizeBody redacts API keys from a body and truncates attachment data
+// in it. Bodies that are not JSON are cut at maxCapturedBody. It reports
+// whether the body was cut.
+func (t *captureTransport) sanitizeBody(body []byte) (interface{}, bool) {
+	var parsed interface{}
+	if json.Unmarshal(body, &parsed) == nil {
+		return t.sanitizeJSON(parsed), false
+	}
+	truncated := false
+	if len(body) > maxCapturedBody {
+		body, truncated = body[:maxCapturedBody], true
+	}
+	return t.redact(string(body)), truncated
+}
+
+// sanitizeJSON walks a decoded JSON value, redacting API keys and
+// shortening strings that hold base64 or data URL attachments
+func (t *captureTransport) sanitizeJSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = t.sanitizeJSON(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = t.sanitizeJSON(item)
+		}
+		return value
+	case string:
+		if isAttachmentData(value) {
+			return fmt.Sprintf("%s...[%d bytes truncated]", value[:attachmentPrefixLength], len(value)-attachmentPrefixLength)
+		}
+		return t.redact(value)
+	default:
+		return v
+	}
+}
+
+// isAttachmentData reports whether s looks like encoded file content
+func isAttachmentData(s string) bool {
+	if len(s) < minAttachmentLength {
+		return false
+	}
+	if strings.HasPrefix(s, "data:") {
+		return true
+	}
+	for _, r := range s {
+		isBase64 := (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') ||
+			r == '+' || r == '/' || r == '=' || r == '-' || r == '_'
+		if !isBase64 {
+			return false
+		}
+	}
+	return true
+}
+
+// capturingBody records a response body as it is read and reports it once,
+// when the body is closed
+type capturingBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	truncated bool
+	readErr   error
+	once      sync.Once
+	done      func(body []byte, truncated bool, readErr error)
+}
+
+func (b *capturingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := maxCapturedBody - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+		b.truncated = b.truncated || n > room
+	} else if n > 0 {
+		b.truncated = true
+	}
+	if err != nil {
+		b.readErr = err
+	}
+	return n, err
+}
+
+func (b *capturingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.buf.Bytes(), b.truncated, b.readErr) })
+	return err
+}
@@ -0,0 +1,100 @@
+// ABOUTME: Unit tests for provider HTTP capture
+// ABOUTME: Checks that captures redact API keys, truncate attachments, and record streamed responses
+
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/go-llms/pkg/llm/provider"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readCaptures returns the captures written to dir, oldest first
+func readCaptures(t *testing.T, dir string) []HTTPCapture {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	var captures []HTTPCapture
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var capture HTTPCapture
+		require.NoError(t, json.Unmarshal(data, &capture))
+		captures = append(captures, capture)
+	}
+	return captures
+}
+
+func TestDebugHTTP_ProviderRequests(t *testing.T) {
+	const apiKey = "sk-test-0123456789"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":{"message":"Incorrect API key provided: `+apiKey+`"}}`)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	defer SetDebugHTTPDir(dir)()
+	p, err := NewProvider(ProviderOpenAI, "gpt-4o", apiKey)
+	require.NoError(t, err)
+	p.(*providerAdapter).provider.(*provider.OpenAIProvider).SetBaseURL(ts.URL)
+
+	image := strings.Repeat("iVBORw0KGgo", 100)
+	message := domain.NewMessage("1", domain.MessageRoleUser, "describe this")
+	message.Attachments = []domain.Attachment{{Type: domain.AttachmentTypeImage, Content: []byte(image), MimeType: "image/png"}}
+	_, err = p.GenerateMessage(context.Background(), []domain.Message{*message})
+	require.Error(t, err)
+
+	captures := readCaptures(t, dir)
+	require.Len(t, captures, 1)
+	capture := captures[0]
+	assert.Equal(t, ProviderOpenAI, capture.Provider)
+	assert.Equal(t, http.MethodPost, capture.Method)
+	assert.Equal(t, http.StatusBadRequest, capture.Status)
+	assert.Equal(t, redacted, capture.Request.Headers["Authorization"])
+
+	data, err := json.Marshal(capture)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), apiKey, "the API key is redacted everywhere")
+	assert.NotContains(t, string(data), base64.StdEncoding.EncodeToString([]byte(image))[attachmentPrefixLength:], "attachments are truncated")
+	assert.Contains(t, string(data), "bytes truncated")
+	assert.Contains(t, string(data), "describe this")
+	assert.Contains(t, string(data), "Incorrect API key provided: "+redacted)
+}
+
+func TestDebugHTTP_StreamAndQueryKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"text\":\"hel\"}\n\ndata: {\"text\":\"lo\"}\n\n")
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: &captureTransport{base: http.DefaultTransport, dir: dir, provider: "gemini", secrets: []string{"secret-key"}}}
+	resp, err := client.Get(ts.URL + "/v1/models/gemini:streamGenerateContent?alt=sse&key=secret-key")
+	require.NoError(t, err)
+	assert.Empty(t, readCaptures(t, dir), "the capture waits for the body to be read")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Contains(t, string(body), "lo", "the response is passed through unchanged")
+
+	captures := readCaptures(t, dir)
+	require.Len(t, captures, 1)
+	assert.NotContains(t, captures[0].URL, "secret-key")
+	assert.Contains(t, captures[0].URL, "alt=sse")
+	assert.Equal(t, string(body), captures[0].Response.Body)
+}
@@ -51,4 +51,19 @@ var (
 
 	// ErrProviderError indicates a generic provider error
 	ErrProviderError = errors.New("provider error")
+
+	// ErrContentBlocked indicates content was rejected by the moderation stage
+	ErrContentBlocked = errors.New("content blocked by moderation")
+
+	// ErrInvalidModerationConfig indicates invalid moderation settings
+	ErrInvalidModerationConfig = errors.New("invalid moderation configuration")
+
+	// ErrInvalidBatchInput indicates a malformed batch input file
+	ErrInvalidBatchInput = errors.New("invalid batch input")
+
+	// ErrBatchFailed indicates a batch job did not complete
+	ErrBatchFailed = errors.New("batch job failed")
+
+	// ErrEmptyResponse indicates the provider returned no content
+	ErrEmptyResponse = errors.New("empty response")
 )
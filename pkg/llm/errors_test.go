@@ -93,6 +93,31 @@ func TestErrorDefinitions(t *testing.T) {
 			err:  ErrProviderError,
 			msg:  "provider error",
 		},
+		{
+			name: "ErrContentBlocked",
+			err:  ErrContentBlocked,
+			msg:  "content blocked by moderation",
+		},
+		{
+			name: "ErrInvalidModerationConfig",
+			err:  ErrInvalidModerationConfig,
+			msg:  "invalid moderation configuration",
+		},
+		{
+			name: "ErrInvalidBatchInput",
+			err:  ErrInvalidBatchInput,
+			msg:  "invalid batch input",
+		},
+		{
+			name: "ErrBatchFailed",
+			err:  ErrBatchFailed,
+			msg:  "batch job failed",
+		},
+		{
+			name: "ErrEmptyResponse",
+			err:  ErrEmptyResponse,
+			msg:  "empty response",
+		},
 	}
 
 	for _, tt := range tests {
@@ -121,6 +146,11 @@ func TestErrorTypes(t *testing.T) {
 		ErrPartialResponse,
 		ErrProviderTimeout,
 		ErrProviderError,
+		ErrContentBlocked,
+		ErrInvalidModerationConfig,
+		ErrInvalidBatchInput,
+		ErrBatchFailed,
+		ErrEmptyResponse,
 	}
 
 	for i, err := range errs {
@@ -184,6 +214,11 @@ func TestErrorMessages(t *testing.T) {
 		ErrPartialResponse,
 		ErrProviderTimeout,
 		ErrProviderError,
+		ErrContentBlocked,
+		ErrInvalidModerationConfig,
+		ErrInvalidBatchInput,
+		ErrBatchFailed,
+		ErrEmptyResponse,
 	}
 
 	for _, err := range allErrors {
@@ -213,6 +248,11 @@ func TestErrorStringsNotEmpty(t *testing.T) {
 		ErrPartialResponse,
 		ErrProviderTimeout,
 		ErrProviderError,
+		ErrContentBlocked,
+		ErrInvalidModerationConfig,
+		ErrInvalidBatchInput,
+		ErrBatchFailed,
+		ErrEmptyResponse,
 	}
 
 	for _, err := range allErrors {
@@ -0,0 +1,74 @@
+// ABOUTME: Tracing spans and metrics around provider calls
+// ABOUTME: Records the provider, model, token usage, latency, and outcome of each request
+
+package llm
+
+import (
+	"context"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/metrics"
+	"github.com/lexlapax/magellai/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// providerCall is one instrumented provider request
+type providerCall struct {
+	provider  string
+	model     string
+	operation string
+	span      trace.Span
+	started   time.Time
+	messages  []domain.Message
+}
+
+// startCall starts instrumenting a provider call of the given operation,
+// such as chat or stream
+func (p *providerAdapter) startCall(ctx context.Context, operation string, messages []domain.Message) (context.Context, *providerCall) {
+	ctx, span := tracing.Start(ctx, "llm."+operation,
+		tracing.AttrProvider.String(p.name),
+		tracing.AttrModel.String(p.model),
+	)
+	return ctx, &providerCall{
+		provider:  p.name,
+		model:     p.model,
+		operation: operation,
+		span:      span,
+		started:   time.Now(),
+		messages:  messages,
+	}
+}
+
+// end records the call's latency, token usage, and outcome. Usage the
+// provider did not report is estimated from the messages and output.
+func (c *providerCall) end(output string, usage *Usage, err error) {
+	metrics.ProviderRequests.Inc(c.provider, c.model, c.operation)
+	metrics.ProviderRequestDuration.Observe(time.Since(c.started).Seconds(), c.provider, c.model, c.operation)
+	c.span.SetAttributes(tracing.Latency(c.started))
+	if err != nil {
+		metrics.ProviderErrors.Inc(c.provider, c.model, c.operation)
+		tracing.End(c.span, err)
+		return
+	}
+
+	estimated := usage == nil || usage.InputTokens+usage.OutputTokens == 0
+	inputTokens, outputTokens := 0, 0
+	if estimated {
+		counter := NewEstimatedTokenCounter()
+		inputTokens, outputTokens = counter.CountMessageTokens(c.messages), counter.CountTokens(output)
+	} else {
+		inputTokens, outputTokens = usage.InputTokens, usage.OutputTokens
+	}
+	metrics.ProviderTokens.Add(float64(inputTokens), c.provider, c.model, "input")
+	metrics.ProviderTokens.Add(float64(outputTokens), c.provider, c.model, "output")
+
+	c.span.SetAttributes(
+		tracing.AttrInputTokens.Int(inputTokens),
+		tracing.AttrOutputTokens.Int(outputTokens),
+	)
+	if estimated {
+		c.span.SetAttributes(tracing.AttrEstimated.Bool(true))
+	}
+	tracing.End(c.span, nil)
+}
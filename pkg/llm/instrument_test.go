@@ -0,0 +1,76 @@
+// ABOUTME: Unit tests for provider call spans and metrics
+// ABOUTME: Checks the provider, model, token, and error attributes and counters recorded for each call
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	llmdomain "github.com/lexlapax/go-llms/pkg/llm/domain"
+	"github.com/lexlapax/go-llms/pkg/llm/provider"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/metrics"
+	"github.com/lexlapax/magellai/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestProviderAdapter_Instrumentation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	shutdown := tracing.Install(recorder, tracing.Config{Sampling: 1})
+	defer func() {
+		_ = shutdown(context.Background())
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	}()
+
+	fail := false
+	mock := provider.NewMockProvider().WithGenerateMessageFunc(
+		func(ctx context.Context, messages []llmdomain.Message, options ...llmdomain.Option) (llmdomain.Response, error) {
+			if fail {
+				return llmdomain.Response{}, errors.New("rate limited")
+			}
+			return llmdomain.Response{Content: "the answer is forty two"}, nil
+		})
+	adapter := &providerAdapter{provider: mock, name: "mock", model: "test-model"}
+	messages := []domain.Message{*domain.NewMessage("1", domain.MessageRoleUser, "what is the answer?")}
+
+	requests := metrics.ProviderRequests.Value("mock", "test-model", "chat")
+	failures := metrics.ProviderErrors.Value("mock", "test-model", "chat")
+	outputTokens := metrics.ProviderTokens.Value("mock", "test-model", "output")
+
+	_, err := adapter.GenerateMessage(context.Background(), messages)
+	require.NoError(t, err)
+	fail = true
+	_, err = adapter.GenerateMessage(context.Background(), messages)
+	require.Error(t, err)
+
+	assert.Equal(t, requests+2, metrics.ProviderRequests.Value("mock", "test-model", "chat"))
+	assert.Equal(t, failures+1, metrics.ProviderErrors.Value("mock", "test-model", "chat"))
+	assert.Greater(t, metrics.ProviderTokens.Value("mock", "test-model", "output"), outputTokens)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	ok := spans[0]
+	assert.Equal(t, "llm.chat", ok.Name())
+	attrs := ok.Attributes()
+	assert.Contains(t, attrs, tracing.AttrProvider.String("mock"))
+	assert.Contains(t, attrs, tracing.AttrModel.String("test-model"))
+	assert.Contains(t, attrs, tracing.AttrEstimated.Bool(true), "the mock reports no usage")
+	for _, attr := range attrs {
+		switch attr.Key {
+		case tracing.AttrInputTokens, tracing.AttrOutputTokens:
+			assert.Positive(t, attr.Value.AsInt64(), attr.Key)
+		}
+	}
+
+	failed := spans[1]
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Equal(t, "rate limited", failed.Status().Description)
+}
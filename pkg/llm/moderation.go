@@ -0,0 +1,403 @@
+// ABOUTME: Content moderation stage applied to outgoing prompts and incoming responses
+// ABOUTME: Supports a local keyword check or the OpenAI moderation endpoint with block/warn actions
+
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// ModerationAction determines what happens when content is flagged
+type ModerationAction string
+
+const (
+	// ModerationActionBlock rejects flagged content
+	ModerationActionBlock ModerationAction = "block"
+	// ModerationActionWarn lets flagged content through with a warning
+	ModerationActionWarn ModerationAction = "warn"
+)
+
+// ModerationStage identifies where in the request flow content was checked
+type ModerationStage string
+
+const (
+	// ModerationStagePrompt is the outgoing user prompt
+	ModerationStagePrompt ModerationStage = "prompt"
+	// ModerationStageResponse is the incoming model response
+	ModerationStageResponse ModerationStage = "response"
+)
+
+// ModerationMetadataKey is the session metadata key holding the moderation audit trail
+const ModerationMetadataKey = "moderation_audit"
+
+// DefaultOpenAIModerationModel is the model used for the OpenAI moderation endpoint
+const DefaultOpenAIModerationModel = "omni-moderation-latest"
+
+// ModerationResult is the outcome of a single moderation check
+type ModerationResult struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// Moderator checks a piece of text for disallowed content
+type Moderator interface {
+	// Name returns the moderator identifier used in audit records
+	Name() string
+	// Moderate checks the given text
+	Moderate(ctx context.Context, text string) (*ModerationResult, error)
+}
+
+// ModerationRecord is an audit entry describing one moderation check
+type ModerationRecord struct {
+	Stage      ModerationStage  `json:"stage"`
+	Moderator  string           `json:"moderator"`
+	Flagged    bool             `json:"flagged"`
+	Categories []string         `json:"categories,omitempty"`
+	Action     ModerationAction `json:"action"`
+	Blocked    bool             `json:"blocked"`
+	Timestamp  time.Time        `json:"timestamp"`
+}
+
+// ToMap converts the record into a generic map suitable for session metadata
+func (r ModerationRecord) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"stage":     string(r.Stage),
+		"moderator": r.Moderator,
+		"flagged":   r.Flagged,
+		"action":    string(r.Action),
+		"blocked":   r.Blocked,
+		"timestamp": r.Timestamp.Format(time.RFC3339),
+	}
+	if len(r.Categories) > 0 {
+		categories := make([]interface{}, len(r.Categories))
+		for i, c := range r.Categories {
+			categories[i] = c
+		}
+		m["categories"] = categories
+	}
+	return m
+}
+
+// AppendModerationRecord adds a record to the audit trail stored in metadata
+func AppendModerationRecord(metadata map[string]interface{}, record ModerationRecord) {
+	if metadata == nil {
+		return
+	}
+	metadata[ModerationMetadataKey] = append(moderationTrail(metadata), record.ToMap())
+}
+
+// MergeModerationTrail appends the audit trail in src to the one in dst
+func MergeModerationTrail(dst, src map[string]interface{}) {
+	trail := moderationTrail(src)
+	if dst == nil || len(trail) == 0 {
+		return
+	}
+	dst[ModerationMetadataKey] = append(moderationTrail(dst), trail...)
+}
+
+// moderationTrail returns the audit trail stored in metadata. Metadata loaded
+// from storage holds the trail as []interface{}, so both forms are handled.
+func moderationTrail(metadata map[string]interface{}) []interface{} {
+	var trail []interface{}
+	switch existing := metadata[ModerationMetadataKey].(type) {
+	case []interface{}:
+		trail = existing
+	case []map[string]interface{}:
+		for _, entry := range existing {
+			trail = append(trail, entry)
+		}
+	}
+	return trail
+}
+
+// ModerationConfig configures the moderation stage
+type ModerationConfig struct {
+	Enabled        bool             // Whether moderation is active
+	Provider       string           // "local" or "openai"
+	Action         ModerationAction // block or warn
+	CheckPrompts   bool             // Moderate outgoing prompts
+	CheckResponses bool             // Moderate incoming responses
+	BlockedTerms   []string         // Terms used by the local moderator
+	APIKey         string           // API key for the OpenAI moderator
+	BaseURL        string           // Base URL for the OpenAI moderator
+	Model          string           // Moderation model for the OpenAI moderator
+}
+
+// ModerationConfigSource is the subset of configuration access needed to load moderation settings
+type ModerationConfigSource interface {
+	GetString(key string) string
+	GetBool(key string) bool
+	Get(key string) interface{}
+}
+
+// LoadModerationConfig reads the moderation.* settings from configuration
+func LoadModerationConfig(cfg ModerationConfigSource) ModerationConfig {
+	mc := ModerationConfig{
+		Enabled:        cfg.GetBool("moderation.enabled"),
+		Provider:       cfg.GetString("moderation.provider"),
+		Action:         ModerationAction(cfg.GetString("moderation.action")),
+		CheckPrompts:   cfg.GetBool("moderation.check_prompts"),
+		CheckResponses: cfg.GetBool("moderation.check_responses"),
+		Model:          cfg.GetString("moderation.model"),
+		APIKey:         cfg.GetString("provider.openai.api_key"),
+		BaseURL:        cfg.GetString("provider.openai.base_url"),
+	}
+
+	// Resolve the key like the provider does, including secret:// references
+	if keys, ok := cfg.(interface{ GetProviderAPIKey(string) string }); ok {
+		mc.APIKey = keys.GetProviderAPIKey("openai")
+	}
+
+	switch terms := cfg.Get("moderation.blocked_terms").(type) {
+	case []string:
+		mc.BlockedTerms = terms
+	case []interface{}:
+		for _, t := range terms {
+			if s, ok := t.(string); ok {
+				mc.BlockedTerms = append(mc.BlockedTerms, s)
+			}
+		}
+	}
+
+	return mc
+}
+
+// ModerationPolicy applies a moderator to prompts and responses
+type ModerationPolicy struct {
+	Moderator      Moderator
+	Action         ModerationAction
+	CheckPrompts   bool
+	CheckResponses bool
+}
+
+// NewModerationPolicy builds a policy from configuration.
+// It returns nil when moderation is disabled.
+func NewModerationPolicy(cfg ModerationConfig) (*ModerationPolicy, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	action := cfg.Action
+	switch action {
+	case "":
+		action = ModerationActionWarn
+	case ModerationActionBlock, ModerationActionWarn:
+	default:
+		return nil, fmt.Errorf("%w: unknown moderation action %q", ErrInvalidModerationConfig, action)
+	}
+
+	var moderator Moderator
+	switch strings.ToLower(cfg.Provider) {
+	case "", "local":
+		moderator = NewKeywordModerator(cfg.BlockedTerms)
+	case ProviderOpenAI:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("%w: openai moderation requires an API key", ErrAPIKeyMissing)
+		}
+		moderator = NewOpenAIModerator(cfg.APIKey, cfg.BaseURL, cfg.Model)
+	default:
+		return nil, fmt.Errorf("%w: unknown moderation provider %q", ErrInvalidModerationConfig, cfg.Provider)
+	}
+
+	return &ModerationPolicy{
+		Moderator:      moderator,
+		Action:         action,
+		CheckPrompts:   cfg.CheckPrompts,
+		CheckResponses: cfg.CheckResponses,
+	}, nil
+}
+
+// Applies reports whether the policy checks the given stage
+func (p *ModerationPolicy) Applies(stage ModerationStage) bool {
+	if p == nil || p.Moderator == nil {
+		return false
+	}
+	switch stage {
+	case ModerationStagePrompt:
+		return p.CheckPrompts
+	case ModerationStageResponse:
+		return p.CheckResponses
+	}
+	return false
+}
+
+// Blocks reports whether flagged content at the given stage would be rejected
+func (p *ModerationPolicy) Blocks(stage ModerationStage) bool {
+	return p.Applies(stage) && p.Action == ModerationActionBlock
+}
+
+// Check moderates text at the given stage. It returns nil when the stage is not checked.
+// When content is flagged and the action is block, the returned error wraps ErrContentBlocked.
+func (p *ModerationPolicy) Check(ctx context.Context, stage ModerationStage, text string) (*ModerationRecord, error) {
+	if !p.Applies(stage) {
+		return nil, nil
+	}
+
+	result, err := p.Moderator.Moderate(ctx, text)
+	if err != nil {
+		logging.LogError(err, "Moderation check failed", "stage", stage, "moderator", p.Moderator.Name())
+		return nil, fmt.Errorf("moderation check failed: %w", err)
+	}
+
+	record := &ModerationRecord{
+		Stage:      stage,
+		Moderator:  p.Moderator.Name(),
+		Flagged:    result.Flagged,
+		Categories: result.Categories,
+		Action:     p.Action,
+		Timestamp:  time.Now(),
+	}
+
+	if !result.Flagged {
+		logging.LogDebug("Moderation check passed", "stage", stage, "moderator", record.Moderator)
+		return record, nil
+	}
+
+	logging.LogWarn("Content flagged by moderation", "stage", stage, "moderator", record.Moderator,
+		"categories", strings.Join(result.Categories, ","), "action", p.Action)
+
+	if p.Action == ModerationActionBlock {
+		record.Blocked = true
+		return record, fmt.Errorf("%w: %s flagged for %s", ErrContentBlocked, stage, formatCategories(result.Categories))
+	}
+
+	return record, nil
+}
+
+// formatCategories renders flagged categories for user-facing messages
+func formatCategories(categories []string) string {
+	if len(categories) == 0 {
+		return "unspecified content"
+	}
+	return strings.Join(categories, ", ")
+}
+
+// KeywordModerator is a local moderator that flags text containing blocked terms
+type KeywordModerator struct {
+	terms []string
+}
+
+// NewKeywordModerator creates a local keyword moderator. Terms are matched case-insensitively.
+func NewKeywordModerator(terms []string) *KeywordModerator {
+	normalized := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if t := strings.ToLower(strings.TrimSpace(term)); t != "" {
+			normalized = append(normalized, t)
+		}
+	}
+	return &KeywordModerator{terms: normalized}
+}
+
+// Name implements Moderator
+func (k *KeywordModerator) Name() string {
+	return "local"
+}
+
+// Moderate implements Moderator
+func (k *KeywordModerator) Moderate(ctx context.Context, text string) (*ModerationResult, error) {
+	lower := strings.ToLower(text)
+	result := &ModerationResult{}
+	for _, term := range k.terms {
+		if strings.Contains(lower, term) {
+			result.Flagged = true
+			result.Categories = append(result.Categories, "blocked_term:"+term)
+		}
+	}
+	return result, nil
+}
+
+// OpenAIModerator checks text using the OpenAI moderation endpoint
+type OpenAIModerator struct {
+	apiKey  string
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAIModerator creates a moderator backed by the OpenAI moderation endpoint
+func NewOpenAIModerator(apiKey, baseURL, model string) *OpenAIModerator {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	if model == "" {
+		model = DefaultOpenAIModerationModel
+	}
+	return &OpenAIModerator{
+		apiKey:  apiKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name implements Moderator
+func (o *OpenAIModerator) Name() string {
+	return ProviderOpenAI
+}
+
+// openAIModerationResponse mirrors the relevant parts of the moderation API response
+type openAIModerationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// Moderate implements Moderator
+func (o *OpenAIModerator) Moderate(ctx context.Context, text string) (*ModerationResult, error) {
+	body, err := json.Marshal(map[string]string{
+		"model": o.model,
+		"input": text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode moderation request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%w: moderation endpoint returned %d: %s", ErrProviderError, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var parsed openAIModerationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	result := &ModerationResult{}
+	for _, r := range parsed.Results {
+		if r.Flagged {
+			result.Flagged = true
+		}
+		for category, flagged := range r.Categories {
+			if flagged {
+				result.Categories = append(result.Categories, category)
+			}
+		}
+	}
+	sort.Strings(result.Categories)
+
+	return result, nil
+}
@@ -0,0 +1,285 @@
+// ABOUTME: Tests for the content moderation stage
+// ABOUTME: Covers the keyword and OpenAI moderators, policy actions, and audit records
+
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapConfigSource map[string]interface{}
+
+func (m mapConfigSource) GetString(key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func (m mapConfigSource) GetBool(key string) bool {
+	b, _ := m[key].(bool)
+	return b
+}
+
+func (m mapConfigSource) Get(key string) interface{} {
+	return m[key]
+}
+
+func TestKeywordModerator(t *testing.T) {
+	moderator := NewKeywordModerator([]string{"Secret", "  ", "forbidden"})
+
+	tests := []struct {
+		name       string
+		text       string
+		flagged    bool
+		categories []string
+	}{
+		{"clean text", "hello world", false, nil},
+		{"case insensitive", "this is SECRET", true, []string{"blocked_term:secret"}},
+		{"multiple terms", "secret and forbidden", true, []string{"blocked_term:secret", "blocked_term:forbidden"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := moderator.Moderate(context.Background(), tt.text)
+			require.NoError(t, err)
+			assert.Equal(t, tt.flagged, result.Flagged)
+			assert.Equal(t, tt.categories, result.Categories)
+		})
+	}
+}
+
+func TestOpenAIModerator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/moderations", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, DefaultOpenAIModerationModel, body["model"])
+
+		flagged := body["input"] == "bad"
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{
+				{
+					"flagged": flagged,
+					"categories": map[string]bool{
+						"violence":   flagged,
+						"harassment": flagged,
+						"sexual":     false,
+					},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	moderator := NewOpenAIModerator("test-key", server.URL, "")
+
+	result, err := moderator.Moderate(context.Background(), "bad")
+	require.NoError(t, err)
+	assert.True(t, result.Flagged)
+	assert.Equal(t, []string{"harassment", "violence"}, result.Categories)
+
+	result, err = moderator.Moderate(context.Background(), "fine")
+	require.NoError(t, err)
+	assert.False(t, result.Flagged)
+	assert.Empty(t, result.Categories)
+}
+
+func TestOpenAIModeratorHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	moderator := NewOpenAIModerator("bad-key", server.URL, "")
+	_, err := moderator.Moderate(context.Background(), "text")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrProviderError))
+}
+
+func TestNewModerationPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       ModerationConfig
+		wantNil   bool
+		wantErr   error
+		wantName  string
+		wantBlock bool
+	}{
+		{
+			name:    "disabled",
+			cfg:     ModerationConfig{Enabled: false},
+			wantNil: true,
+		},
+		{
+			name:     "defaults to local warn",
+			cfg:      ModerationConfig{Enabled: true, CheckPrompts: true},
+			wantName: "local",
+		},
+		{
+			name:      "local block",
+			cfg:       ModerationConfig{Enabled: true, Provider: "local", Action: ModerationActionBlock, CheckPrompts: true},
+			wantName:  "local",
+			wantBlock: true,
+		},
+		{
+			name:     "openai",
+			cfg:      ModerationConfig{Enabled: true, Provider: "openai", APIKey: "key", CheckPrompts: true},
+			wantName: "openai",
+		},
+		{
+			name:    "openai without key",
+			cfg:     ModerationConfig{Enabled: true, Provider: "openai"},
+			wantErr: ErrAPIKeyMissing,
+		},
+		{
+			name:    "unknown provider",
+			cfg:     ModerationConfig{Enabled: true, Provider: "unknown"},
+			wantErr: ErrInvalidModerationConfig,
+		},
+		{
+			name:    "unknown action",
+			cfg:     ModerationConfig{Enabled: true, Action: "ignore"},
+			wantErr: ErrInvalidModerationConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewModerationPolicy(tt.cfg)
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErr))
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, policy)
+				return
+			}
+			require.NotNil(t, policy)
+			assert.Equal(t, tt.wantName, policy.Moderator.Name())
+			assert.Equal(t, tt.wantBlock, policy.Blocks(ModerationStagePrompt))
+		})
+	}
+}
+
+func TestModerationPolicyCheck(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("nil policy is a no-op", func(t *testing.T) {
+		var policy *ModerationPolicy
+		record, err := policy.Check(ctx, ModerationStagePrompt, "anything")
+		assert.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("unchecked stage is skipped", func(t *testing.T) {
+		policy := &ModerationPolicy{
+			Moderator:    NewKeywordModerator([]string{"bad"}),
+			Action:       ModerationActionBlock,
+			CheckPrompts: true,
+		}
+		record, err := policy.Check(ctx, ModerationStageResponse, "bad")
+		assert.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("warn lets flagged content through", func(t *testing.T) {
+		policy := &ModerationPolicy{
+			Moderator:    NewKeywordModerator([]string{"bad"}),
+			Action:       ModerationActionWarn,
+			CheckPrompts: true,
+		}
+		record, err := policy.Check(ctx, ModerationStagePrompt, "bad words")
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.True(t, record.Flagged)
+		assert.False(t, record.Blocked)
+		assert.Equal(t, ModerationActionWarn, record.Action)
+	})
+
+	t.Run("block rejects flagged content", func(t *testing.T) {
+		policy := &ModerationPolicy{
+			Moderator:      NewKeywordModerator([]string{"bad"}),
+			Action:         ModerationActionBlock,
+			CheckResponses: true,
+		}
+		record, err := policy.Check(ctx, ModerationStageResponse, "bad words")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrContentBlocked))
+		require.NotNil(t, record)
+		assert.True(t, record.Blocked)
+		assert.Equal(t, ModerationStageResponse, record.Stage)
+	})
+
+	t.Run("clean content passes", func(t *testing.T) {
+		policy := &ModerationPolicy{
+			Moderator:    NewKeywordModerator([]string{"bad"}),
+			Action:       ModerationActionBlock,
+			CheckPrompts: true,
+		}
+		record, err := policy.Check(ctx, ModerationStagePrompt, "good words")
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.False(t, record.Flagged)
+	})
+}
+
+func TestAppendModerationRecord(t *testing.T) {
+	metadata := map[string]interface{}{}
+	record := ModerationRecord{
+		Stage:      ModerationStagePrompt,
+		Moderator:  "local",
+		Flagged:    true,
+		Categories: []string{"blocked_term:bad"},
+		Action:     ModerationActionWarn,
+	}
+
+	AppendModerationRecord(metadata, record)
+	AppendModerationRecord(metadata, record)
+
+	trail, ok := metadata[ModerationMetadataKey].([]interface{})
+	require.True(t, ok)
+	assert.Len(t, trail, 2)
+
+	entry, ok := trail[0].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "prompt", entry["stage"])
+	assert.Equal(t, true, entry["flagged"])
+
+	// Nil metadata is ignored
+	AppendModerationRecord(nil, record)
+
+	// Trails merge into metadata that already holds one
+	dst := map[string]interface{}{ModerationMetadataKey: []map[string]interface{}{record.ToMap()}}
+	MergeModerationTrail(dst, metadata)
+	assert.Len(t, dst[ModerationMetadataKey], 3)
+}
+
+func TestLoadModerationConfig(t *testing.T) {
+	src := mapConfigSource{
+		"moderation.enabled":         true,
+		"moderation.provider":        "local",
+		"moderation.action":          "block",
+		"moderation.check_prompts":   true,
+		"moderation.check_responses": false,
+		"moderation.blocked_terms":   []interface{}{"one", "two", 3},
+	}
+
+	cfg := LoadModerationConfig(src)
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, "local", cfg.Provider)
+	assert.Equal(t, ModerationActionBlock, cfg.Action)
+	assert.True(t, cfg.CheckPrompts)
+	assert.False(t, cfg.CheckResponses)
+	assert.Equal(t, []string{"one", "two"}, cfg.BlockedTerms)
+}
@@ -13,6 +13,7 @@ import (
 	schemadomain "github.com/lexlapax/go-llms/pkg/schema/domain"
 	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/keyring"
 )
 
 // SanitizeAPIKey creates a sanitized version of an API key for logging
@@ -112,7 +113,15 @@ func NewProvider(providerType, model string, apiKey ...string) (Provider, error)
 		key = apiKey[0]
 	}
 
-	// If key is empty, try to get it from environment variables
+	// If key is empty, try the OS keychain
+	if key == "" && providerType != ProviderMock && providerType != ProviderOllama {
+		if secret, err := keyring.Get(providerType); err == nil && secret != "" {
+			key = secret
+			logging.LogInfo("Using API key from keychain", "provider", providerType)
+		}
+	}
+
+	// Then try environment variables
 	if key == "" {
 		key = getAPIKeyFromEnv(providerType)
 		if key != "" {
@@ -120,11 +129,13 @@ func NewProvider(providerType, model string, apiKey ...string) (Provider, error)
 		}
 	}
 
+	logging.AddSecrets(key)
+
 	// For non-mock providers, verify that we have an API key
 	if key == "" && providerType != ProviderMock {
 		envVarName := getEnvVarNameForProvider(providerType)
-		return nil, fmt.Errorf("API key required for provider %s. Set %s environment variable or provide it in configuration",
-			providerType, envVarName)
+		return nil, fmt.Errorf("%w: API key required for provider %s. Set %s environment variable or provide it in configuration",
+			ErrAPIKeyMissing, providerType, envVarName)
 	}
 
 	// Create underlying go-llms provider
@@ -143,6 +154,7 @@ func NewProvider(providerType, model string, apiKey ...string) (Provider, error)
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", providerType)
 	}
+	enableDebugHTTP(llmProvider, providerType, key)
 
 	return &providerAdapter{
 		provider: llmProvider,
@@ -183,14 +195,18 @@ func (p *providerAdapter) GenerateMessage(ctx context.Context, messages []domain
 	llmOptions := buildLLMOptions(config)
 
 	// Generate response
+	ctx, call := p.startCall(ctx, "chat", messages)
 	llmResp, err := p.provider.GenerateMessage(ctx, llmMessages, llmOptions...)
 	if err != nil {
+		call.end("", nil, err)
 		logging.LogError(err, "Failed to generate message", "provider", p.name)
 		return nil, err
 	}
 
 	// Convert response
-	return convertLLMResponse(&llmResp), nil
+	resp := convertLLMResponse(&llmResp)
+	call.end(resp.Content, resp.Usage, nil)
+	return resp, nil
 }
 
 // GenerateWithSchema produces structured output conforming to a schema
@@ -205,7 +221,13 @@ func (p *providerAdapter) GenerateWithSchema(ctx context.Context, prompt string,
 	llmOptions := buildLLMOptions(config)
 
 	// Generate with schema
+	ctx, call := p.startCall(ctx, "structured", []domain.Message{*domain.NewMessage("", domain.MessageRoleUser, prompt)})
 	result, err := p.provider.GenerateWithSchema(ctx, prompt, schema, llmOptions...)
+	var output string
+	if err == nil {
+		output = fmt.Sprint(result)
+	}
+	call.end(output, nil, err)
 	if err != nil {
 		logging.LogError(err, "Failed to generate with schema")
 		return nil, err
@@ -238,21 +260,26 @@ func (p *providerAdapter) StreamMessage(ctx context.Context, messages []domain.M
 	llmOptions := buildLLMOptions(config)
 
 	// Create stream
+	ctx, call := p.startCall(ctx, "stream", messages)
 	llmStream, err := p.provider.StreamMessage(ctx, llmMessages, llmOptions...)
 	if err != nil {
+		call.end("", nil, err)
 		return nil, err
 	}
 
-	// Convert stream
+	// Convert stream; the call ends when the stream does
 	outStream := make(chan StreamChunk)
 	go func() {
 		defer close(outStream)
+		var output strings.Builder
 		for chunk := range llmStream {
+			output.WriteString(chunk.Text)
 			outStream <- StreamChunk{
 				Content: chunk.Text,
 				Done:    chunk.Finished,
 			}
 		}
+		call.end(output.String(), nil, ctx.Err())
 	}()
 
 	return outStream, nil
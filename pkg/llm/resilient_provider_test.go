@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -17,11 +18,11 @@ type mockProvider struct {
 	generateMessageFunc func(context.Context, []domain.Message, ...ProviderOption) (*Response, error)
 	streamFunc          func(context.Context, string, ...ProviderOption) (<-chan StreamChunk, error)
 	modelInfo           ModelInfo
-	callCount           int
+	callCount           atomic.Int64
 }
 
 func (m *mockProvider) Generate(ctx context.Context, prompt string, options ...ProviderOption) (string, error) {
-	m.callCount++
+	m.callCount.Add(1)
 	if m.generateFunc != nil {
 		return m.generateFunc(ctx, prompt, options...)
 	}
@@ -29,7 +30,7 @@ func (m *mockProvider) Generate(ctx context.Context, prompt string, options ...P
 }
 
 func (m *mockProvider) GenerateMessage(ctx context.Context, messages []domain.Message, options ...ProviderOption) (*Response, error) {
-	m.callCount++
+	m.callCount.Add(1)
 	if m.generateMessageFunc != nil {
 		return m.generateMessageFunc(ctx, messages, options...)
 	}
@@ -37,12 +38,12 @@ func (m *mockProvider) GenerateMessage(ctx context.Context, messages []domain.Me
 }
 
 func (m *mockProvider) GenerateWithSchema(ctx context.Context, prompt string, schema *schemadomain.Schema, options ...ProviderOption) (interface{}, error) {
-	m.callCount++
+	m.callCount.Add(1)
 	return map[string]string{"result": "mock"}, nil
 }
 
 func (m *mockProvider) Stream(ctx context.Context, prompt string, options ...ProviderOption) (<-chan StreamChunk, error) {
-	m.callCount++
+	m.callCount.Add(1)
 	if m.streamFunc != nil {
 		return m.streamFunc(ctx, prompt, options...)
 	}
@@ -53,7 +54,7 @@ func (m *mockProvider) Stream(ctx context.Context, prompt string, options ...Pro
 }
 
 func (m *mockProvider) StreamMessage(ctx context.Context, messages []domain.Message, options ...ProviderOption) (<-chan StreamChunk, error) {
-	m.callCount++
+	m.callCount.Add(1)
 	ch := make(chan StreamChunk, 1)
 	ch <- StreamChunk{Content: "mock"}
 	close(ch)
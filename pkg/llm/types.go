@@ -53,6 +53,7 @@ type Usage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
 	TotalTokens  int `json:"total_tokens"`
+	CachedTokens int `json:"cached_tokens,omitempty"` // Input tokens served from the provider's prompt cache
 }
 
 // PromptParams maps to go-llms domain.Option
@@ -0,0 +1,45 @@
+// ABOUTME: The metrics magellai records about API requests, provider calls, and storage operations
+// ABOUTME: Recorded in every process and exposed on /metrics by magellai serve
+
+package metrics
+
+// Default holds the metrics below
+var Default = NewRegistry()
+
+// API server requests, by route pattern rather than path so session IDs
+// do not create a series each
+var (
+	HTTPRequests = Default.NewCounter("magellai_http_requests_total",
+		"API requests handled.", "method", "route", "status")
+	HTTPRequestDuration = Default.NewHistogram("magellai_http_request_duration_seconds",
+		"Time taken to handle API requests.", nil, "method", "route")
+)
+
+// Provider calls. The error rate of a provider is its errors divided by its
+// requests.
+var (
+	ProviderRequests = Default.NewCounter("magellai_provider_requests_total",
+		"Requests sent to LLM providers.", "provider", "model", "operation")
+	ProviderErrors = Default.NewCounter("magellai_provider_errors_total",
+		"Requests to LLM providers that failed.", "provider", "model", "operation")
+	ProviderRequestDuration = Default.NewHistogram("magellai_provider_request_duration_seconds",
+		"Latency of requests to LLM providers.", nil, "provider", "model", "operation")
+	ProviderTokens = Default.NewCounter("magellai_provider_tokens_total",
+		"Tokens used by LLM providers, reported or estimated.", "provider", "model", "type")
+)
+
+// Storage operations of the session storage manager
+var (
+	StorageOperations = Default.NewCounter("magellai_storage_operations_total",
+		"Session storage operations.", "backend", "operation", "result")
+	StorageOperationDuration = Default.NewHistogram("magellai_storage_operation_duration_seconds",
+		"Time taken by session storage operations.", nil, "backend", "operation")
+)
+
+// Result returns the result label of an operation that returned err
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
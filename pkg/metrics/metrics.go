@@ -0,0 +1,248 @@
+// ABOUTME: In-process counters and histograms exposed in the Prometheus text format
+// ABOUTME: Collectors are registered on a Registry, which serves them over HTTP for scraping
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType is the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of latency histograms
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// collector is a metric family that can write itself in the text format
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds the metrics exposed together on one endpoint
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter registers a counter partitioned by the given labels
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{family: family{name: name, help: help, labels: labels}, values: map[string]float64{}}
+	r.register(c)
+	return c
+}
+
+// NewHistogram registers a histogram partitioned by the given labels.
+// Buckets are the sorted upper bounds of its buckets; nil uses
+// DefaultBuckets.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	h := &Histogram{family: family{name: name, help: help, labels: labels}, buckets: buckets, series: map[string]*histogramSeries{}}
+	r.register(h)
+	return h
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes every metric in the Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(buf)
+	}
+	return buf.Flush()
+}
+
+// Handler serves the registry's metrics for scraping
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_ = r.Write(w)
+	})
+}
+
+// family holds what the series of one metric have in common
+type family struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	labels []string
+}
+
+// key joins label values into the key of their series. Missing values are
+// empty and extra ones are ignored.
+func (f *family) key(values []string) string {
+	parts := make([]string, len(f.labels))
+	copy(parts, values)
+	return strings.Join(parts, "\xff")
+}
+
+// labelPairs formats the labels of a series, plus any extra pair such as a
+// histogram's le
+func (f *family) labelPairs(key string, extra ...string) string {
+	var pairs []string
+	if len(f.labels) > 0 {
+		values := strings.Split(key, "\xff")
+		for i, label := range f.labels {
+			pairs = append(pairs, label+`="`+escapeLabel(values[i])+`"`)
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (f *family) writeHeader(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, kind)
+}
+
+// Counter is a value that only goes up, such as a number of requests
+type Counter struct {
+	family
+	values map[string]float64
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series with the given
+// label values
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[c.key(labelValues)] += v
+}
+
+// Value returns the current value of the series with the given label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[c.key(labelValues)]
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w, "counter")
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(key), formatFloat(c.values[key]))
+	}
+}
+
+// Histogram counts observations, such as latencies, in buckets
+type Histogram struct {
+	family
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+// histogramSeries holds the observations of one set of label values
+type histogramSeries struct {
+	counts []uint64 // Observations in each bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Observe records v in the series with the given label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := h.key(labelValues)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// Count returns the number of observations in the series with the given
+// label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.series[h.key(labelValues)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w, "histogram")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(key, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(key, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(key), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(key), s.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+
+func escapeHelp(v string) string { return helpEscaper.Replace(v) }
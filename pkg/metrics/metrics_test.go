@@ -0,0 +1,80 @@
+// ABOUTME: Tests for counters, histograms, and the Prometheus text output
+// ABOUTME: Checks series labelling, cumulative buckets, escaping, and the scrape handler
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounter("test_requests_total", "Requests handled.", "method", "status")
+
+	requests.Inc("GET", "200")
+	requests.Inc("GET", "200")
+	requests.Add(3, "POST", "500")
+	requests.Add(-1, "POST", "500")
+	assert.Equal(t, 2.0, requests.Value("GET", "200"))
+	assert.Equal(t, 3.0, requests.Value("POST", "500"))
+	assert.Equal(t, 0.0, requests.Value("PUT", "200"))
+
+	var out strings.Builder
+	require.NoError(t, registry.Write(&out))
+	assert.Equal(t, `# HELP test_requests_total Requests handled.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",status="200"} 2
+test_requests_total{method="POST",status="500"} 3
+`, out.String())
+}
+
+func TestHistogram(t *testing.T) {
+	registry := NewRegistry()
+	latency := registry.NewHistogram("test_seconds", "Latency.", []float64{0.1, 1}, "route")
+
+	latency.Observe(0.05, "/ask")
+	latency.Observe(0.5, "/ask")
+	latency.Observe(1, "/ask")
+	latency.Observe(2, "/ask")
+	assert.Equal(t, uint64(4), latency.Count("/ask"))
+	assert.Equal(t, uint64(0), latency.Count("/other"))
+
+	var out strings.Builder
+	require.NoError(t, registry.Write(&out))
+	assert.Equal(t, `# HELP test_seconds Latency.
+# TYPE test_seconds histogram
+test_seconds_bucket{route="/ask",le="0.1"} 1
+test_seconds_bucket{route="/ask",le="1"} 3
+test_seconds_bucket{route="/ask",le="+Inf"} 4
+test_seconds_sum{route="/ask"} 3.55
+test_seconds_count{route="/ask"} 4
+`, out.String())
+}
+
+func TestEscaping(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("test_total", "Line one\nline two.", "model")
+	counter.Inc(`say "hi" \ bye`)
+
+	var out strings.Builder
+	require.NoError(t, registry.Write(&out))
+	assert.Contains(t, out.String(), `# HELP test_total Line one\nline two.`)
+	assert.Contains(t, out.String(), `test_total{model="say \"hi\" \\ bye"} 1`)
+}
+
+func TestHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("test_total", "Unlabelled.").Inc()
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "version=0.0.4")
+	assert.Contains(t, rec.Body.String(), "\ntest_total 1\n")
+}
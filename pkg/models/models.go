@@ -68,15 +68,19 @@ type Inventory struct {
 
 // LoadInventory loads the models.json file from the root directory
 func LoadInventory(rootPath string) (*Inventory, error) {
-	filePath := filepath.Join(rootPath, "models.json")
+	return LoadInventoryFile(filepath.Join(rootPath, "models.json"))
+}
+
+// LoadInventoryFile loads an inventory from the named file, whatever its name
+func LoadInventoryFile(filePath string) (*Inventory, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read models.json: %w", err)
+		return nil, fmt.Errorf("failed to read models inventory: %w", err)
 	}
 
 	var inventory Inventory
 	if err := json.Unmarshal(data, &inventory); err != nil {
-		return nil, fmt.Errorf("failed to parse models.json: %w", err)
+		return nil, fmt.Errorf("failed to parse models inventory %s: %w", filePath, err)
 	}
 
 	return &inventory, nil
@@ -126,6 +130,25 @@ func (inv *Inventory) GetModelsByProvider(provider string) []Model {
 	return result
 }
 
+// CanRead reports whether the model accepts input of a media type (text,
+// image, audio, video, or file)
+func (m *Model) CanRead(mediaType string) bool {
+	switch mediaType {
+	case "text":
+		return m.Capabilities.Text.Read
+	case "image":
+		return m.Capabilities.Image.Read
+	case "audio":
+		return m.Capabilities.Audio.Read
+	case "video":
+		return m.Capabilities.Video.Read
+	case "file":
+		return m.Capabilities.File.Read
+	default:
+		return false
+	}
+}
+
 // GetModelsWithCapability returns models that have a specific capability
 func (inv *Inventory) GetModelsWithCapability(mediaType string, operation string) []Model {
 	var result []Model
@@ -197,6 +197,22 @@ func TestGetModelsWithCapability(t *testing.T) {
 	assert.Len(t, functionModels, 3)
 }
 
+func TestModel_CanRead(t *testing.T) {
+	model := &Model{
+		Capabilities: Capabilities{
+			Text:  MediaCapability{Read: true, Write: true},
+			Image: MediaCapability{Read: true},
+			Audio: MediaCapability{Write: true},
+		},
+	}
+
+	assert.True(t, model.CanRead("text"))
+	assert.True(t, model.CanRead("image"))
+	assert.False(t, model.CanRead("audio"), "write-only media cannot be read")
+	assert.False(t, model.CanRead("video"))
+	assert.False(t, model.CanRead("unknown"))
+}
+
 func TestGetLastUpdated(t *testing.T) {
 	inventory := &Inventory{
 		Metadata: Metadata{
@@ -0,0 +1,129 @@
+// ABOUTME: Cost estimation helpers built on the models inventory pricing data
+// ABOUTME: Locates models.json at runtime and computes estimated request costs
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InventoryEnvVar names an inventory file to load instead of searching for models.json
+const InventoryEnvVar = "MAGELLAI_MODELS_FILE"
+
+// ErrInventoryNotFound indicates no models.json could be located
+var ErrInventoryNotFound = errors.New("models inventory not found")
+
+// InventorySearchPaths returns the directories searched for models.json, in order:
+// the user config directory, where "model update" installs newer inventories,
+// then the current directory and its parents, then the directory containing the
+// running executable.
+func InventorySearchPaths() []string {
+	var paths []string
+
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "magellai"))
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		dir := cwd
+		for {
+			paths = append(paths, dir)
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		paths = append(paths, filepath.Dir(exe))
+	}
+
+	return paths
+}
+
+// LoadDefaultInventory loads the file named by MAGELLAI_MODELS_FILE, or else
+// models.json from the first directory in InventorySearchPaths that has one
+func LoadDefaultInventory() (*Inventory, error) {
+	if file := os.Getenv(InventoryEnvVar); file != "" {
+		return LoadInventoryFile(file)
+	}
+
+	for _, dir := range InventorySearchPaths() {
+		if _, err := os.Stat(filepath.Join(dir, "models.json")); err == nil {
+			return LoadInventory(dir)
+		}
+	}
+
+	return nil, ErrInventoryNotFound
+}
+
+// CostEstimate describes the estimated cost of a request
+type CostEstimate struct {
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	InputCost    float64 `json:"input_cost"`
+	OutputCost   float64 `json:"output_cost"`
+	TotalCost    float64 `json:"total_cost"`
+	PricingKnown bool    `json:"pricing_known"`
+}
+
+// EstimateCost computes the cost of a request with the given token counts.
+// When outputTokens is zero or negative, the model's maximum output is assumed.
+func (m *Model) EstimateCost(inputTokens, outputTokens int) *CostEstimate {
+	if outputTokens <= 0 {
+		outputTokens = m.MaxOutputTokens
+	}
+	return m.Cost(inputTokens, outputTokens)
+}
+
+// Cost computes the cost of a request that used exactly the given token counts
+func (m *Model) Cost(inputTokens, outputTokens int) *CostEstimate {
+	est := &CostEstimate{
+		Provider:     m.Provider,
+		Model:        m.Name,
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+		PricingKnown: m.Pricing.InputPer1kTokens > 0 || m.Pricing.OutputPer1kTokens > 0,
+	}
+	est.InputCost = float64(inputTokens) / 1000 * m.Pricing.InputPer1kTokens
+	est.OutputCost = float64(outputTokens) / 1000 * m.Pricing.OutputPer1kTokens
+	est.TotalCost = est.InputCost + est.OutputCost
+	return est
+}
+
+// Format renders the estimate as human-readable text
+func (e *CostEstimate) Format() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Model:          %s/%s\n", e.Provider, e.Model)
+	fmt.Fprintf(&sb, "Input tokens:   ~%d\n", e.InputTokens)
+	fmt.Fprintf(&sb, "Output tokens:  up to %d\n", e.OutputTokens)
+	if !e.PricingKnown {
+		sb.WriteString("Estimated cost: pricing unavailable for this model\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "Input cost:     $%.6f\n", e.InputCost)
+	fmt.Fprintf(&sb, "Output cost:    up to $%.6f\n", e.OutputCost)
+	fmt.Fprintf(&sb, "Estimated cost: up to $%.6f\n", e.TotalCost)
+	return sb.String()
+}
+
+// EstimateRequestCost estimates the cost of a request against the named model.
+// Models missing from the inventory (or a nil inventory) yield an estimate without pricing.
+func (inv *Inventory) EstimateRequestCost(provider, name string, inputTokens, outputTokens int) *CostEstimate {
+	var model *Model
+	if inv != nil {
+		model = inv.GetModel(provider, name)
+	}
+	if model == nil {
+		model = &Model{Provider: provider, Name: name}
+	}
+	return model.EstimateCost(inputTokens, outputTokens)
+}
@@ -0,0 +1,138 @@
+// ABOUTME: Tests for cost estimation helpers
+// ABOUTME: Validates pricing math, unknown-model handling, and inventory discovery
+
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelEstimateCost(t *testing.T) {
+	model := &Model{
+		Provider:        "openai",
+		Name:            "gpt-4o",
+		MaxOutputTokens: 4096,
+		Pricing: Pricing{
+			InputPer1kTokens:  0.005,
+			OutputPer1kTokens: 0.015,
+		},
+	}
+
+	tests := []struct {
+		name         string
+		input        int
+		output       int
+		wantOutput   int
+		wantTotal    float64
+		pricingKnown bool
+	}{
+		{"explicit output tokens", 1000, 500, 500, 0.005 + 0.0075, true},
+		{"defaults to max output", 2000, 0, 4096, 0.01 + 4.096*0.015, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := model.EstimateCost(tt.input, tt.output)
+			assert.Equal(t, tt.input, est.InputTokens)
+			assert.Equal(t, tt.wantOutput, est.OutputTokens)
+			assert.InDelta(t, tt.wantTotal, est.TotalCost, 1e-9)
+			assert.Equal(t, tt.pricingKnown, est.PricingKnown)
+		})
+	}
+}
+
+func TestModelCost(t *testing.T) {
+	model := &Model{
+		MaxOutputTokens: 4096,
+		Pricing:         Pricing{InputPer1kTokens: 0.005, OutputPer1kTokens: 0.015},
+	}
+
+	// Cost uses the exact counts, even when no output was produced
+	est := model.Cost(2000, 0)
+	assert.Equal(t, 0, est.OutputTokens)
+	assert.InDelta(t, 0.01, est.TotalCost, 1e-9)
+}
+
+func TestInventoryEstimateRequestCost(t *testing.T) {
+	inv := &Inventory{
+		Models: []Model{
+			{Provider: "openai", Name: "gpt-4o", Pricing: Pricing{InputPer1kTokens: 0.005, OutputPer1kTokens: 0.015}},
+		},
+	}
+
+	est := inv.EstimateRequestCost("openai", "gpt-4o", 1000, 1000)
+	assert.True(t, est.PricingKnown)
+	assert.InDelta(t, 0.02, est.TotalCost, 1e-9)
+
+	unknown := inv.EstimateRequestCost("mock", "test", 100, 10)
+	assert.False(t, unknown.PricingKnown)
+	assert.Zero(t, unknown.TotalCost)
+	assert.Contains(t, unknown.Format(), "pricing unavailable")
+
+	var nilInv *Inventory
+	est = nilInv.EstimateRequestCost("openai", "gpt-4o", 10, 10)
+	assert.False(t, est.PricingKnown)
+}
+
+func TestCostEstimateFormat(t *testing.T) {
+	est := &CostEstimate{
+		Provider:     "openai",
+		Model:        "gpt-4o",
+		InputTokens:  100,
+		OutputTokens: 200,
+		InputCost:    0.0005,
+		OutputCost:   0.003,
+		TotalCost:    0.0035,
+		PricingKnown: true,
+	}
+
+	out := est.Format()
+	assert.Contains(t, out, "openai/gpt-4o")
+	assert.Contains(t, out, "~100")
+	assert.Contains(t, out, "$0.003500")
+}
+
+func TestLoadDefaultInventoryFromEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	data, err := json.Marshal(&Inventory{
+		Metadata: Metadata{Version: "9.9.9"},
+		Models:   []Model{{Provider: "openai", Name: "gpt-4o"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "models.json"), data, 0644))
+
+	t.Setenv(InventoryEnvVar, filepath.Join(tmpDir, "models.json"))
+
+	inv, err := LoadDefaultInventory()
+	require.NoError(t, err)
+	assert.Equal(t, "9.9.9", inv.GetLatestVersion())
+}
+
+func TestLoadDefaultInventoryFromEnvAnyName(t *testing.T) {
+	tmpDir := t.TempDir()
+	data, err := json.Marshal(&Inventory{Metadata: Metadata{Version: "1.2.3"}})
+	require.NoError(t, err)
+	file := filepath.Join(tmpDir, "custom-inventory.json")
+	require.NoError(t, os.WriteFile(file, data, 0644))
+
+	t.Setenv(InventoryEnvVar, file)
+
+	inv, err := LoadDefaultInventory()
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", inv.GetLatestVersion())
+}
+
+func TestInventorySearchPathsUserConfigFirst(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	paths := InventorySearchPaths()
+	require.NotEmpty(t, paths)
+	assert.Equal(t, filepath.Join(home, ".config", "magellai"), paths[0])
+}
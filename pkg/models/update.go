@@ -0,0 +1,138 @@
+// ABOUTME: Downloads and installs newer models.json inventories
+// ABOUTME: Validates a fetched inventory and installs it into the user config directory with a backup
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultInventoryURL is where the project publishes the latest models.json
+const DefaultInventoryURL = "https://raw.githubusercontent.com/lexlapax/magellai/main/models.json"
+
+// SchemaVersion is the models.json schema this build understands
+const SchemaVersion = "1"
+
+// maxInventorySize caps how much of a downloaded inventory is read
+const maxInventorySize = 10 << 20
+
+// ErrInvalidInventory indicates a models.json that fails validation
+var ErrInvalidInventory = errors.New("invalid models inventory")
+
+// UserInventoryPath returns where an updated models.json is installed. The
+// directory is one of InventorySearchPaths.
+func UserInventoryPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "magellai", "models.json"), nil
+}
+
+// ParseInventory parses and validates models.json data
+func ParseInventory(data []byte) (*Inventory, error) {
+	var inventory Inventory
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInventory, err)
+	}
+	if err := inventory.Validate(); err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+// Validate checks that the inventory uses a supported schema and that
+// every model has a provider and name
+func (inv *Inventory) Validate() error {
+	if v := inv.Metadata.SchemaVersion; v != "" && v != SchemaVersion {
+		return fmt.Errorf("%w: unsupported schema version %q (expected %s)", ErrInvalidInventory, v, SchemaVersion)
+	}
+	if len(inv.Models) == 0 {
+		return fmt.Errorf("%w: no models", ErrInvalidInventory)
+	}
+	for i, m := range inv.Models {
+		if m.Provider == "" || m.Name == "" {
+			return fmt.Errorf("%w: model %d has no provider or name", ErrInvalidInventory, i+1)
+		}
+	}
+	return nil
+}
+
+// DownloadInventory fetches models.json from url and validates it
+func DownloadInventory(ctx context.Context, url string) ([]byte, *Inventory, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid inventory URL: %w", err)
+	}
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download models inventory: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to download models inventory: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxInventorySize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download models inventory: %w", err)
+	}
+	if len(data) > maxInventorySize {
+		return nil, nil, fmt.Errorf("%w: larger than %d bytes", ErrInvalidInventory, maxInventorySize)
+	}
+	inventory, err := ParseInventory(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, inventory, nil
+}
+
+// InstallInventory writes models.json data to path, first copying any
+// existing file to path.bak. It returns the backup path, or "" when there
+// was nothing to back up.
+func InstallInventory(data []byte, path string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create inventory directory: %w", err)
+	}
+
+	backup := ""
+	if old, err := os.ReadFile(path); err == nil {
+		backup = path + ".bak"
+		if err := os.WriteFile(backup, old, 0644); err != nil {
+			return "", fmt.Errorf("failed to back up models inventory: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read current models inventory: %w", err)
+	}
+
+	// Write beside the target and rename, so a failed write never leaves a
+	// truncated inventory
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".models-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to install models inventory: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to install models inventory: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to install models inventory: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", fmt.Errorf("failed to install models inventory: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to install models inventory: %w", err)
+	}
+	return backup, nil
+}
@@ -0,0 +1,95 @@
+// ABOUTME: Tests for downloading and installing models inventories
+// ABOUTME: Verifies validation, HTTP errors, and backups of the previous inventory
+
+package models
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInventoryJSON = `{
+  "_metadata": {"version": "2.0.0", "schema_version": "1"},
+  "models": [{"provider": "openai", "name": "gpt-5"}]
+}`
+
+func TestParseInventory(t *testing.T) {
+	inv, err := ParseInventory([]byte(testInventoryJSON))
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", inv.GetLatestVersion())
+
+	invalid := map[string]string{
+		"not json":      "<html>",
+		"no models":     `{"_metadata": {"version": "2.0.0"}, "models": []}`,
+		"missing name":  `{"models": [{"provider": "openai"}]}`,
+		"future schema": `{"_metadata": {"schema_version": "2"}, "models": [{"provider": "openai", "name": "gpt-5"}]}`,
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseInventory([]byte(data))
+			assert.ErrorIs(t, err, ErrInvalidInventory)
+		})
+	}
+}
+
+func TestRepositoryInventoryIsValid(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "models.json"))
+	require.NoError(t, err)
+	_, err = ParseInventory(data)
+	assert.NoError(t, err)
+}
+
+func TestDownloadInventory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/models.json":
+			_, _ = w.Write([]byte(testInventoryJSON))
+		case "/broken.json":
+			_, _ = w.Write([]byte(`{"models": []}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	data, inv, err := DownloadInventory(context.Background(), server.URL+"/models.json")
+	require.NoError(t, err)
+	assert.Equal(t, testInventoryJSON, string(data))
+	assert.Len(t, inv.Models, 1)
+
+	_, _, err = DownloadInventory(context.Background(), server.URL+"/broken.json")
+	assert.ErrorIs(t, err, ErrInvalidInventory)
+
+	_, _, err = DownloadInventory(context.Background(), server.URL+"/missing.json")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestInstallInventory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "magellai", "models.json")
+
+	backup, err := InstallInventory([]byte("first"), path)
+	require.NoError(t, err)
+	assert.Empty(t, backup)
+
+	backup, err = InstallInventory([]byte("second"), path)
+	require.NoError(t, err)
+	assert.Equal(t, path+".bak", backup)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+	data, err = os.ReadFile(backup)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary files left behind")
+}
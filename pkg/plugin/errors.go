@@ -0,0 +1,14 @@
+// ABOUTME: Error definitions for the plugin package
+// ABOUTME: Reports failed and missing external plugin commands
+
+package plugin
+
+import "errors"
+
+var (
+	// ErrPluginFailed is returned when a plugin exits with an error
+	ErrPluginFailed = errors.New("plugin failed")
+
+	// ErrPluginNotFound is returned when no executable provides a plugin
+	ErrPluginNotFound = errors.New("plugin not found")
+)
@@ -0,0 +1,179 @@
+// ABOUTME: Git-style external plugin commands discovered on PATH and in the plugins directory
+// ABOUTME: Finds magellai-<name> executables and runs them with a JSON context on stdin
+
+// Package plugin finds external commands in the style of git: any executable
+// named magellai-<name> in the plugins directory (~/.config/magellai/plugins)
+// or on PATH becomes the subcommand "magellai <name>".
+//
+// A plugin is run with the user's arguments unchanged, and receives a Context
+// as JSON on stdin:
+//
+//	{"name": "hello", "version": "1.0.0", "args": ["--loud"], "session_id": "...", "config": {...}}
+//
+// Its stdout and stderr go straight to the terminal. The first line a plugin
+// prints for --help is shown as its description in magellai's help.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// Prefix is the executable name prefix that marks a plugin
+const Prefix = "magellai-"
+
+// HelpTimeout bounds how long a plugin may take to print its --help
+const HelpTimeout = 2 * time.Second
+
+// windowsExecExts are the extensions treated as executable on Windows
+var windowsExecExts = []string{".exe", ".bat", ".cmd", ".com"}
+
+// Plugin is one magellai-<name> executable
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Context is the JSON sent to a plugin on stdin
+type Context struct {
+	Name         string                 `json:"name"`
+	Version      string                 `json:"version,omitempty"`
+	Args         []string               `json:"args"`
+	SessionID    string                 `json:"session_id,omitempty"`
+	OutputFormat string                 `json:"output_format,omitempty"`
+	Config       map[string]interface{} `json:"config"`
+}
+
+// SearchPath returns dirs followed by the directories on PATH, the order in
+// which plugins are looked up
+func SearchPath(dirs ...string) []string {
+	return append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+}
+
+// Discover finds the plugins in dirs, sorted by name. When several
+// directories provide the same plugin, the first one wins. Missing or
+// unreadable directories are skipped.
+func Discover(dirs []string) []Plugin {
+	seen := make(map[string]bool)
+	var plugins []Plugin
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+			logging.LogDebug("Discovered plugin", "name", name, "path", path)
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// Help runs the plugin with --help and returns the first line it prints, or
+// an empty string if it fails or takes longer than HelpTimeout
+func (p Plugin) Help(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, HelpTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.Path, "--help")
+	cmd.WaitDelay = time.Second
+	out, err := cmd.Output()
+	if err != nil {
+		logging.LogDebug("Plugin help failed", "plugin", p.Name, "error", err)
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Run executes the plugin with pctx.Args, writing pctx as JSON to its stdin
+func (p Plugin) Run(ctx context.Context, pctx Context, stdout, stderr io.Writer) error {
+	input, err := json.Marshal(pctx)
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin context: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, p.Path, pctx.Args...)
+	// Don't wait on pipes held open by children of a killed plugin
+	cmd.WaitDelay = time.Second
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	logging.LogDebug("Running plugin", "plugin", p.Name, "path", p.Path, "args", pctx.Args)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrPluginFailed, p.Name, err)
+	}
+	return nil
+}
+
+// pluginName returns the plugin name for an executable file name
+func pluginName(file string) (string, bool) {
+	if !strings.HasPrefix(file, Prefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(file, Prefix)
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		for _, e := range windowsExecExts {
+			if ext == e {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+				break
+			}
+		}
+	}
+	if name == "" || strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	return name, true
+}
+
+// isExecutable reports whether path is a regular file the user can run,
+// following symlinks
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(path))
+		for _, e := range windowsExecExts {
+			if ext == e {
+				return true
+			}
+		}
+		return false
+	}
+	return info.Mode()&0111 != 0
+}
@@ -0,0 +1,94 @@
+// ABOUTME: Tests for external plugin discovery and execution
+// ABOUTME: Uses small shell scripts as magellai-<name> plugins
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePlugin writes an executable shell script into dir
+func writePlugin(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755))
+	return path
+}
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+
+	first, second := t.TempDir(), t.TempDir()
+	helloPath := writePlugin(t, first, "magellai-hello", "echo first\n")
+	writePlugin(t, second, "magellai-hello", "echo second\n")
+	writePlugin(t, second, "magellai-backup", "echo backup\n")
+	writePlugin(t, second, "other-tool", "echo other\n")
+	require.NoError(t, os.WriteFile(filepath.Join(second, "magellai-notes"), []byte("not executable"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(second, "magellai-dir"), 0755))
+
+	plugins := Discover([]string{first, "", filepath.Join(first, "missing"), second})
+	require.Len(t, plugins, 2)
+	assert.Equal(t, "backup", plugins[0].Name)
+	assert.Equal(t, "hello", plugins[1].Name)
+	assert.Equal(t, helloPath, plugins[1].Path, "earlier directories win")
+}
+
+func TestSearchPath(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin"+string(os.PathListSeparator)+"/bin")
+	assert.Equal(t, []string{"/plugins", "/usr/bin", "/bin"}, SearchPath("/plugins"))
+}
+
+func TestPlugin_Help(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+
+	dir := t.TempDir()
+	p := Plugin{Name: "hello", Path: writePlugin(t, dir, "magellai-hello", `[ "$1" = "--help" ] && printf '\n  Say hello\n\nusage: hello\n'`)}
+	assert.Equal(t, "Say hello", p.Help(context.Background()))
+
+	failing := Plugin{Name: "broken", Path: writePlugin(t, dir, "magellai-broken", "exit 1\n")}
+	assert.Empty(t, failing.Help(context.Background()))
+}
+
+func TestPlugin_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+
+	dir := t.TempDir()
+	p := Plugin{Name: "echo", Path: writePlugin(t, dir, "magellai-echo", `echo "args: $*" >&2
+cat
+`)}
+
+	var stdout, stderr bytes.Buffer
+	pctx := Context{
+		Name:      "echo",
+		Args:      []string{"one", "--two"},
+		SessionID: "session-1",
+		Config:    map[string]interface{}{"model.default": "openai/gpt-4o"},
+	}
+	require.NoError(t, p.Run(context.Background(), pctx, &stdout, &stderr))
+	assert.Equal(t, "args: one --two\n", stderr.String())
+
+	var received Context
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &received))
+	assert.Equal(t, pctx, received)
+
+	failing := Plugin{Name: "broken", Path: writePlugin(t, dir, "magellai-broken", "exit 3\n")}
+	err := failing.Run(context.Background(), Context{Name: "broken"}, &stdout, &stderr)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrPluginFailed)
+	assert.Contains(t, err.Error(), "exit status 3")
+}
@@ -60,6 +60,24 @@ func createFileAttachmentFromPath(filePath string) (domain.Attachment, error) {
 	return attachment, nil
 }
 
+// addPendingAttachment queues an attachment for the next message and returns
+// the number of pending attachments
+func (r *REPL) addPendingAttachment(attachment domain.Attachment) int {
+	// Store pending attachments in the session metadata
+	if r.session.Metadata == nil {
+		r.session.Metadata = make(map[string]interface{})
+	}
+
+	pendingAttachments, ok := r.session.Metadata["pending_attachments"].([]domain.Attachment)
+	if !ok {
+		pendingAttachments = []domain.Attachment{}
+	}
+
+	pendingAttachments = append(pendingAttachments, attachment)
+	r.session.Metadata["pending_attachments"] = pendingAttachments
+	return len(pendingAttachments)
+}
+
 // getAttachmentDisplayName returns a display name for an attachment
 func getAttachmentDisplayName(att domain.Attachment) string {
 	if att.FilePath != "" {
@@ -0,0 +1,77 @@
+// ABOUTME: Attachment preview and model support checks for /attach
+// ABOUTME: Shows size, MIME type, and image dimensions, refusing types the model can't read
+
+package repl
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF for image dimensions
+	_ "image/jpeg" // Register JPEG for image dimensions
+	_ "image/png"  // Register PNG for image dimensions
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// attachmentPreview describes a file about to be attached
+type attachmentPreview struct {
+	Size      int64
+	MimeType  string
+	Width     int
+	Height    int
+	Supported *bool // nil when the model's capabilities are unknown
+}
+
+// previewAttachment inspects an attachment's file and checks that the
+// current model accepts its type
+func (r *REPL) previewAttachment(att domain.Attachment) attachmentPreview {
+	preview := attachmentPreview{MimeType: att.MimeType}
+
+	if file, err := os.Open(att.FilePath); err == nil {
+		defer file.Close()
+		if info, err := file.Stat(); err == nil {
+			preview.Size = info.Size()
+		}
+
+		// Infer a more specific type than the generic one from the extension
+		if preview.MimeType == "" || preview.MimeType == "application/octet-stream" {
+			if byExt := mime.TypeByExtension(filepath.Ext(att.FilePath)); byExt != "" {
+				preview.MimeType = byExt
+			} else {
+				head := make([]byte, 512)
+				n, _ := file.Read(head)
+				preview.MimeType = http.DetectContentType(head[:n])
+				_, _ = file.Seek(0, 0)
+			}
+		}
+
+		if att.Type == domain.AttachmentTypeImage {
+			if config, _, err := image.DecodeConfig(file); err == nil {
+				preview.Width, preview.Height = config.Width, config.Height
+			}
+		}
+	}
+
+	if model := r.currentModel(); model != nil {
+		supported := model.CanRead(string(att.Type))
+		preview.Supported = &supported
+	}
+	return preview
+}
+
+// String renders the preview as a single line
+func (p attachmentPreview) String() string {
+	parts := []string{formatBytes(p.Size), p.MimeType}
+	if p.Width > 0 && p.Height > 0 {
+		parts = append(parts, fmt.Sprintf("%dx%d", p.Width, p.Height))
+	}
+	if p.Supported == nil {
+		parts = append(parts, "model support unknown")
+	}
+	return strings.Join(parts, ", ")
+}
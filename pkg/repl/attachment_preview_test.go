@@ -0,0 +1,66 @@
+// ABOUTME: Tests for attachment preview and model support checks
+// ABOUTME: Verifies size, MIME type, image dimensions, and refusal of unsupported types
+
+package repl
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPL_attachPreview(t *testing.T) {
+	inventoryFile, err := filepath.Abs(filepath.Join("..", "..", "models.json"))
+	require.NoError(t, err)
+	t.Setenv(models.InventoryEnvVar, inventoryFile)
+
+	dir := t.TempDir()
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 64, 32))))
+	imagePath := filepath.Join(dir, "shot.png")
+	require.NoError(t, os.WriteFile(imagePath, img.Bytes(), 0644))
+	audioPath := filepath.Join(dir, "voice.mp3")
+	require.NoError(t, os.WriteFile(audioPath, []byte("ID3"), 0644))
+	notesPath := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(notesPath, []byte("some notes"), 0644))
+
+	t.Run("image with known model", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+		repl.session.Conversation.Model = "openai/gpt-4o"
+
+		require.NoError(t, repl.attachFile([]string{imagePath}))
+		assert.Contains(t, output.String(), "image/png")
+		assert.Contains(t, output.String(), "64x32")
+		assert.NotContains(t, output.String(), "support unknown")
+	})
+
+	t.Run("unsupported type is refused", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+		repl.session.Conversation.Model = "openai/gpt-4o"
+
+		err := repl.attachFile([]string{audioPath})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not accept audio attachments")
+		_, pending := repl.session.Metadata["pending_attachments"]
+		assert.False(t, pending)
+	})
+
+	t.Run("unknown model", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+		repl.session.Conversation.Model = "mock/unknown"
+
+		require.NoError(t, repl.attachFile([]string{notesPath}))
+		assert.Contains(t, output.String(), "10 B, text/plain")
+		assert.Contains(t, output.String(), "model support unknown")
+	})
+}
@@ -74,9 +74,11 @@ func RegisterREPLCommands(repl *REPL, registry *command.Registry) error {
 				Category:    command.CategoryREPL,
 			},
 			handler: func(r *REPL, args []string) error {
-				// Save session before exiting
-				if err := r.manager.SaveSession(r.session); err != nil {
-					fmt.Fprintf(r.writer, "Warning: Failed to save session: %v\n", err)
+				// Save session before exiting, unless storage is read-only
+				if !r.manager.ReadOnly() {
+					if err := r.manager.SaveSession(r.session); err != nil {
+						fmt.Fprintf(r.writer, "Warning: Failed to save session: %v\n", err)
+					}
 				}
 				fmt.Fprintln(r.writer, "Goodbye!")
 				return io.EOF // Signal to exit
@@ -93,12 +95,34 @@ func RegisterREPLCommands(repl *REPL, registry *command.Registry) error {
 						Description: "Session name",
 						Type:        command.FlagTypeString,
 					},
+					{
+						Name:        "template",
+						Description: "Save the session as a session template with this name",
+						Type:        command.FlagTypeString,
+					},
 				},
 			},
 			handler: func(r *REPL, args []string) error {
 				return r.saveSession(args)
 			},
 		},
+		{
+			meta: &command.Metadata{
+				Name:        "new",
+				Description: "Start a new session, optionally from a session template",
+				Category:    command.CategoryREPL,
+				Flags: []command.Flag{
+					{
+						Name:        "template",
+						Description: "Session template to start from (lists templates when no name is given)",
+						Type:        command.FlagTypeString,
+					},
+				},
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdNew(args)
+			},
+		},
 		{
 			meta: &command.Metadata{
 				Name:        "load",
@@ -290,6 +314,248 @@ func RegisterREPLCommands(repl *REPL, registry *command.Registry) error {
 				}
 			},
 		},
+		{
+			meta: &command.Metadata{
+				Name:        "estimate",
+				Description: "Estimate tokens and cost of the next request",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdEstimate(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "copy",
+				Description: "Copy the last response or its first code block to the clipboard",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdCopy(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "pipe",
+				Description: "Send the last response to a shell command",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdPipe(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "paste",
+				Description: "Attach the clipboard image to the next message",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdPaste(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "workspace",
+				Description: "Show or reload the project workspace",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdWorkspace(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "index",
+				Description: "Index a directory for retrieval",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdIndex(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "retrieve",
+				Description: "Retrieve relevant excerpts from the active index",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdRetrieve(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "summarize",
+				Description: "Summarize older messages on a new branch to save context",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdSummarize(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "alias",
+				Description: "List or define command aliases",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdAlias(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "unalias",
+				Description: "Remove a command alias",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdUnalias(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "scripts",
+				Description: "List or reload user hook scripts",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdScripts(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "compare",
+				Description: "Send a prompt to two models and pick a branch to continue",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdCompare(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "diff",
+				Description: "Show message differences between two sessions",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdDiff(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "stats",
+				Description: "Show statistics for the current session",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdStats(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "rename",
+				Description: "Rename the current session",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdRename(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "edit",
+				Description: "Edit a user message and regenerate from it on a new branch",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdEdit(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "revisions",
+				Description: "Show the earlier versions of an edited message",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdRevisions(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "ephemeral",
+				Description: "Delete the session automatically after a duration",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdEphemeral(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "pin",
+				Description: "Pin a message so it is always kept in the context",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdPin(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "unpin",
+				Description: "Unpin a pinned message",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdUnpin(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "undo",
+				Description: "Remove the last user message and its response",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdUndo(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "redo",
+				Description: "Restore the exchange removed by /undo",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdRedo(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "template",
+				Aliases:     []string{"tpl"},
+				Description: "List, show, or use prompt templates",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdTemplate(args)
+			},
+		},
+		{
+			meta: &command.Metadata{
+				Name:        "prompt",
+				Aliases:     []string{"prompts"},
+				Description: "List, show, or run named prompts from config",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.cmdPrompt(args)
+			},
+		},
 		// Colon commands (registered with : prefix)
 		{
 			meta: &command.Metadata{
@@ -312,6 +578,16 @@ func RegisterREPLCommands(repl *REPL, registry *command.Registry) error {
 				return r.toggleStreaming(args)
 			},
 		},
+		{
+			meta: &command.Metadata{
+				Name:        ":render",
+				Description: "Toggle markdown rendering of responses",
+				Category:    command.CategoryREPL,
+			},
+			handler: func(r *REPL, args []string) error {
+				return r.toggleRender(args)
+			},
+		},
 		{
 			meta: &command.Metadata{
 				Name:        ":temperature",
@@ -462,6 +738,7 @@ func RegisterREPLCommands(repl *REPL, registry *command.Registry) error {
 				Name:        "merge",
 				Description: "Merge another session into current session",
 				Category:    command.CategoryREPL,
+				Flags:       []command.Flag{command.DryRunFlag},
 			},
 			handler: func(r *REPL, args []string) error {
 				return r.cmdMerge(args)
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/command"
@@ -18,6 +19,14 @@ import (
 
 // saveSession saves the current session
 func (r *REPL) saveSession(args []string) error {
+	// --template saves the session as a template instead
+	if templateName, rest, found := cutTemplateFlag(args); found {
+		if templateName == "" || len(rest) > 0 {
+			return fmt.Errorf("usage: /save --template <name>")
+		}
+		return r.saveSessionTemplate(templateName)
+	}
+
 	// If a name is provided, update the session name
 	if len(args) > 0 {
 		r.session.Name = strings.Join(args, " ")
@@ -31,6 +40,39 @@ func (r *REPL) saveSession(args []string) error {
 	return nil
 }
 
+// cmdRename renames the current session and saves it
+func (r *REPL) cmdRename(args []string) error {
+	name := strings.TrimSpace(strings.Join(args, " "))
+	if name == "" {
+		return fmt.Errorf("usage: /rename <new name>")
+	}
+
+	oldName := r.session.Name
+	r.session.Name = name
+	r.session.Updated = time.Now()
+	if err := r.manager.SaveSession(r.session); err != nil {
+		r.session.Name = oldName
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	r.lastSaveTime = time.Now()
+	r.sharedContext.Set(command.SharedContextSessionName, name)
+
+	// Keep the recovery snapshot in step with the new name
+	if r.autoRecovery != nil {
+		if err := r.autoRecovery.SaveRecoveryState(); err != nil {
+			logging.LogWarn("Failed to save recovery state after rename", "error", err)
+		}
+	}
+
+	logging.LogInfo("Renamed session", "id", r.session.ID, "old_name", oldName, "new_name", name)
+	if oldName == "" {
+		fmt.Fprintf(r.writer, "Session %s renamed to '%s'\n", r.session.ID, name)
+	} else {
+		fmt.Fprintf(r.writer, "Session renamed from '%s' to '%s'\n", oldName, name)
+	}
+	return nil
+}
+
 // loadSession loads a previous session
 func (r *REPL) loadSession(args []string) error {
 	if len(args) == 0 {
@@ -55,6 +97,7 @@ func (r *REPL) loadSession(args []string) error {
 
 // resetConversation clears the conversation history
 func (r *REPL) resetConversation() error {
+	r.snapshotBefore(r.session, domain.SnapshotReasonBeforeReset)
 	r.session.Conversation.Messages = []domain.Message{}
 	fmt.Fprintln(r.writer, "Conversation reset.")
 	return nil
@@ -158,6 +201,29 @@ func (r *REPL) toggleStreaming(args []string) error {
 	return nil
 }
 
+// toggleRender toggles markdown rendering of assistant responses
+func (r *REPL) toggleRender(args []string) error {
+	enabled := !r.renderMarkdown
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "on", "true", "yes":
+			enabled = true
+		case "off", "false", "no":
+			enabled = false
+		default:
+			return fmt.Errorf("invalid value: %s (use on/off)", args[0])
+		}
+	}
+
+	r.renderMarkdown = enabled
+	if enabled {
+		fmt.Fprintln(r.writer, "Markdown rendering: on")
+	} else {
+		fmt.Fprintln(r.writer, "Markdown rendering: off")
+	}
+	return nil
+}
+
 // setVerbosity sets the logging verbosity level
 func (r *REPL) setVerbosity(args []string) error {
 	if len(args) == 0 {
@@ -361,21 +427,17 @@ func (r *REPL) attachFile(args []string) error {
 	}
 	logging.LogDebug("Created attachment", "type", attachment.Type, "mimeType", attachment.MimeType, "filePath", attachment.FilePath)
 
-	// Store pending attachments in the session metadata
-	if r.session.Metadata == nil {
-		r.session.Metadata = make(map[string]interface{})
-	}
-
-	pendingAttachments, ok := r.session.Metadata["pending_attachments"].([]domain.Attachment)
-	if !ok {
-		pendingAttachments = []domain.Attachment{}
+	// Refuse attachments the current model can't read
+	preview := r.previewAttachment(attachment)
+	if preview.Supported != nil && !*preview.Supported {
+		return fmt.Errorf("%s does not accept %s attachments (%s); switch models with :model",
+			r.session.Conversation.Model, attachment.Type, filePath)
 	}
 
-	pendingAttachments = append(pendingAttachments, attachment)
-	r.session.Metadata["pending_attachments"] = pendingAttachments
+	pendingCount := r.addPendingAttachment(attachment)
 
-	fmt.Fprintf(r.writer, "File attached: %s\n", filePath)
-	logging.LogInfo("File attached", "path", filePath, "pendingCount", len(pendingAttachments))
+	fmt.Fprintf(r.writer, "File attached: %s (%s)\n", filePath, preview)
+	logging.LogInfo("File attached", "path", filePath, "pendingCount", pendingCount)
 	return nil
 }
 
@@ -485,6 +547,12 @@ func (r *REPL) showHistory() error {
 	fmt.Fprintln(r.writer, "Conversation history:")
 	for i, msg := range r.session.Conversation.Messages {
 		role := title(string(msg.Role))
+		if msg.IsRevised() {
+			role += fmt.Sprintf(" (edited, %d earlier revision(s))", len(msg.Revisions))
+		}
+		if msg.Pinned {
+			role += " (pinned)"
+		}
 		fmt.Fprintf(r.writer, "\n%d. %s:\n%s\n", i+1, role, msg.Content)
 
 		if len(msg.Attachments) > 0 {
@@ -498,6 +566,19 @@ func (r *REPL) showHistory() error {
 				}
 			}
 		}
+		for _, call := range msg.ToolCalls {
+			fmt.Fprintf(r.writer, "Tool call %s: %s\n", call.ID, call.String())
+		}
+		for _, result := range msg.ToolResults {
+			fmt.Fprintf(r.writer, "Tool result %s (%s): %s\n", result.ToolCallID, result.Status, result.Text())
+		}
+		if len(msg.Citations) > 0 {
+			sources := make([]string, 0, len(msg.Citations))
+			for _, citation := range msg.Citations {
+				sources = append(sources, citation.String())
+			}
+			fmt.Fprintf(r.writer, "Sources: %s\n", strings.Join(sources, ", "))
+		}
 	}
 	return nil
 }
@@ -564,7 +645,7 @@ func (r *REPL) setConfig(args []string) error {
 // exportSession exports the current session
 func (r *REPL) exportSession(args []string) error {
 	if len(args) == 0 {
-		return fmt.Errorf("export format required: json or markdown")
+		return fmt.Errorf("export format required: json, markdown, org, text, jsonl or csv")
 	}
 
 	format := strings.ToLower(args[0])
@@ -576,8 +657,11 @@ func (r *REPL) exportSession(args []string) error {
 		// Generate default filename
 		timestamp := r.session.Created.Format("20060102-150405")
 		ext := format
-		if format == "markdown" {
+		switch format {
+		case "markdown":
 			ext = "md"
+		case "text":
+			ext = "txt"
 		}
 		filename = fmt.Sprintf("session_%s.%s", timestamp, ext)
 	}
@@ -38,14 +38,14 @@ func (r *REPL) cmdBranch(args []string) error {
 		return errors.New("no active session")
 	}
 
-	// Generate new branch ID
-	branchID := r.manager.GenerateSessionID()
-
-	// Create the branch
-	branch, err := currentSession.CreateBranch(branchID, branchName, messageIndex)
+	// Fork the session and name the fork after the branch
+	branch, err := currentSession.ForkAt(messageIndex)
 	if err != nil {
 		return fmt.Errorf("failed to create branch: %v", err)
 	}
+	branch.Name = branchName
+	branch.BranchName = branchName
+	branchID := branch.ID
 
 	// Save both the parent and the new branch
 	if err := r.manager.SaveSession(currentSession); err != nil {
@@ -232,6 +232,37 @@ func (r *REPL) cmdSwitch(args []string) error {
 	}
 	fmt.Fprintf(r.writer, "Messages: %d\n", len(branch.Conversation.Messages))
 
+	// Show how the conversation differs from the one left behind
+	if currentSession != nil && currentSession.ID != branch.ID {
+		fmt.Fprintln(r.writer)
+		r.writeSessionDiff(r.writer, currentSession, branch)
+	}
+
+	return nil
+}
+
+// previewMerge shows what merging sourceID into target would change
+// without saving anything
+func (r *REPL) previewMerge(target *domain.Session, sourceID string, options domain.MergeOptions) error {
+	source, err := r.manager.StorageManager.LoadSession(sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to load source session: %w", err)
+	}
+	// Merge into a copy so the target is left as it is
+	targetCopy := *target
+	targetCopy.Conversation = target.Conversation.Clone()
+	targetCopy.ChildIDs = append([]string{}, target.ChildIDs...)
+	merged, result, err := targetCopy.ExecuteMerge(source, options)
+	if err != nil {
+		return fmt.Errorf("failed to merge sessions: %w", err)
+	}
+
+	fmt.Fprintf(r.writer, "Would merge %d messages from %s into %s\n", result.MergedCount, sourceID, target.ID)
+	if options.CreateBranch {
+		fmt.Fprintln(r.writer, "Would create a new branch")
+	}
+	r.writeSessionDiff(r.writer, target, merged)
+	fmt.Fprintln(r.writer, "Dry run: nothing was changed")
 	return nil
 }
 
@@ -244,7 +275,7 @@ func (r *REPL) hasUnsavedChanges() bool {
 // cmdMerge merges two sessions
 func (r *REPL) cmdMerge(args []string) error {
 	if len(args) < 1 {
-		return fmt.Errorf("usage: /merge <source_session_id> [--type <continuation|rebase>] [--create-branch] [--branch-name <name>]")
+		return fmt.Errorf("usage: /merge <source_session_id> [--type <continuation|rebase>] [--create-branch] [--dry-run] [--branch-name <name>]")
 	}
 
 	// Parse arguments
@@ -254,6 +285,7 @@ func (r *REPL) cmdMerge(args []string) error {
 	// Default options
 	mergeType := domain.MergeTypeContinuation
 	createBranch := false
+	dryRun := false
 	branchName := ""
 	mergePoint := len(r.session.Conversation.Messages)
 
@@ -274,6 +306,8 @@ func (r *REPL) cmdMerge(args []string) error {
 			}
 		case "--create-branch":
 			createBranch = true
+		case "--dry-run":
+			dryRun = true
 		case "--branch-name":
 			if i+1 < len(args) {
 				i++
@@ -293,6 +327,23 @@ func (r *REPL) cmdMerge(args []string) error {
 		BranchName:   branchName,
 	}
 
+	// Keep the target as it was to show what the merge changed
+	before := r.session
+	if targetID != r.session.ID {
+		if loaded, err := r.manager.StorageManager.LoadSession(targetID); err == nil {
+			before = loaded
+		}
+	}
+
+	if dryRun {
+		return r.previewMerge(before, sourceID, options)
+	}
+
+	// A merge into a new branch leaves the target as it was
+	if !options.CreateBranch {
+		r.snapshotBefore(before, domain.SnapshotReasonBeforeMerge)
+	}
+
 	// Perform the merge
 	logging.LogInfo("Starting session merge operation",
 		"source_id", sourceID,
@@ -321,18 +372,30 @@ func (r *REPL) cmdMerge(args []string) error {
 	// Display results
 	fmt.Fprintf(r.writer, "Successfully merged %d messages from %s into %s\n", result.MergedCount, sourceID, targetID)
 
+	mergedID := targetID
+	if result.NewBranchID != "" {
+		mergedID = result.NewBranchID
+	}
+	if merged, err := r.manager.StorageManager.LoadSession(mergedID); err != nil {
+		logging.LogWarn("Failed to load merged session for diff", "session_id", mergedID, "error", err)
+	} else {
+		r.writeSessionDiff(r.writer, before, merged)
+		// Continue with the merged conversation rather than the stale copy
+		if mergedID == r.session.ID {
+			r.session = merged
+		}
+	}
+
 	if result.NewBranchID != "" {
 		fmt.Fprintf(r.writer, "Created new branch: %s\n", result.NewBranchID)
 
 		// Ask if user wants to switch to the new branch
-		fmt.Fprint(r.writer, "Switch to new branch? (y/n): ")
-		var response string
-		if _, err := fmt.Fscanln(r.reader, &response); err != nil {
+		switchBranch, err := r.confirm("Switch to new branch?")
+		if err != nil {
 			logging.LogWarn("Failed to read user response", "error", err)
 			return nil
 		}
-
-		if response == "y" || response == "yes" {
+		if switchBranch {
 			return r.cmdSwitch([]string{result.NewBranchID})
 		}
 	}
@@ -60,6 +60,19 @@ func TestCmdMerge(t *testing.T) {
 			expectError: true,
 			expectedMsg: "usage: /merge",
 		},
+		{
+			name:        "Dry run",
+			args:        []string{sourceSession.ID, "--dry-run"},
+			expectError: false,
+			expectedMsg: "Would merge 1 messages",
+			checkResult: func(t *testing.T) {
+				assert.Contains(t, output.String(), "Dry run: nothing was changed")
+				assert.Equal(t, 0, backend.GetCallCount("MergeSessions"))
+				unchanged, err := manager.StorageManager.LoadSession(targetSession.ID)
+				require.NoError(t, err)
+				assert.Len(t, unchanged.Conversation.Messages, 1)
+			},
+		},
 		{
 			name:        "Basic merge",
 			args:        []string{sourceSession.ID},
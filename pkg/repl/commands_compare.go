@@ -0,0 +1,211 @@
+// ABOUTME: Model comparison command for REPL
+// ABOUTME: Sends one prompt to two models on separate branches and continues with the chosen one
+
+package repl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/ui"
+)
+
+// newCompareProvider creates the providers compared by /compare
+var newCompareProvider = llm.NewProvider
+
+// comparison is one side of a /compare
+type comparison struct {
+	model    string
+	provider llm.Provider
+	branch   *domain.Session
+	response *llm.Response
+	latency  time.Duration
+	err      error
+}
+
+// cmdCompare runs the same prompt on two models.
+//
+//	/compare <modelA> <modelB> <prompt>  send prompt to both models
+//	/compare <modelA> <modelB>           re-run the last user message on both
+//
+// Each model answers on its own branch; the user then picks which branch to
+// continue. The current session is left unchanged.
+func (r *REPL) cmdCompare(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: /compare <provider/modelA> <provider/modelB> [prompt]")
+	}
+
+	// Work out the prompt and where the branches start
+	messages := r.session.Conversation.Messages
+	prompt := strings.Join(args[2:], " ")
+	branchPoint := len(messages)
+	var attachments []domain.Attachment
+	if prompt == "" {
+		index := lastUserMessageIndex(messages)
+		if index < 0 {
+			return fmt.Errorf("%w: no prompt given and no user message to re-run", ErrInvalidMessageIndex)
+		}
+		prompt = messages[index].Content
+		attachments = messages[index].Attachments
+		branchPoint = index
+	} else if pending, ok := r.session.Metadata["pending_attachments"].([]domain.Attachment); ok {
+		attachments = pending
+		delete(r.session.Metadata, "pending_attachments")
+	}
+
+	sides := make([]*comparison, 2)
+	for i, model := range args[:2] {
+		providerName, modelName := llm.ParseModelString(model)
+		if !strings.Contains(model, "/") {
+			return fmt.Errorf("invalid model format %q, expected provider/model (e.g., openai/gpt-4o)", model)
+		}
+		provider, err := newCompareProvider(providerName, modelName)
+		if err != nil {
+			return fmt.Errorf("failed to create provider for %s: %w", model, err)
+		}
+		sides[i] = &comparison{model: model, provider: provider}
+	}
+
+	// Branch once per model, each with the prompt as its last message
+	parent := r.session
+	for i, side := range sides {
+		branchName := fmt.Sprintf("compare-%d-%s", i+1, side.model)
+		branch, err := parent.CreateBranch(r.manager.GenerateSessionID(), branchName, branchPoint)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrBranchOperationFailed, err)
+		}
+		branch.Conversation.Model = side.model
+		branch.Conversation.Provider, _ = llm.ParseModelString(side.model)
+		AddMessageToConversation(branch.Conversation, "user", prompt, attachments)
+		side.branch = branch
+	}
+
+	// Ask both models at once
+	fmt.Fprintf(r.writer, "Comparing %s and %s...\n", sides[0].model, sides[1].model)
+	ctx := r.beginGeneration()
+	r.generateComparisons(ctx, sides)
+	cancelled := ctx.Err() != nil
+	r.endGeneration()
+	if cancelled {
+		return ErrGenerationCancelled
+	}
+
+	succeeded := 0
+	for i, side := range sides {
+		fmt.Fprintf(r.writer, "\n=== [%d] %s ===\n", i+1, side.model)
+		if side.err != nil {
+			logging.LogWarn("Comparison model failed", "model", side.model, "error", side.err)
+			fmt.Fprintf(r.writer, "Error: %v\n", side.err)
+			continue
+		}
+		content := side.response.Content
+		if r.renderMarkdown {
+			content = strings.TrimRight(ui.RenderMarkdown(content), "\n")
+		}
+		fmt.Fprintf(r.writer, "%s\n", content)
+
+		usage := r.recordUsage(side.model, GetHistory(side.branch.Conversation),
+			side.response.Content, side.response.Usage, side.latency)
+		AddMessageToConversation(side.branch.Conversation, "assistant", side.response.Content, nil)
+		messages := side.branch.Conversation.Messages
+		messages[len(messages)-1].Usage = usage
+		if err := r.manager.SaveSession(side.branch); err != nil {
+			return fmt.Errorf("failed to save comparison branch: %w", err)
+		}
+		succeeded++
+	}
+	if succeeded == 0 {
+		return fmt.Errorf("both models failed")
+	}
+	if err := r.manager.SaveSession(parent); err != nil {
+		return fmt.Errorf("failed to update parent session: %w", err)
+	}
+
+	fmt.Fprintln(r.writer)
+	for i, side := range sides {
+		if side.err == nil {
+			fmt.Fprintf(r.writer, "[%d] branch '%s' (ID: %s)\n", i+1, side.branch.Name, side.branch.ID)
+		}
+	}
+	return r.chooseComparison(sides)
+}
+
+// generateComparisons sends each branch's conversation to its model concurrently
+func (r *REPL) generateComparisons(ctx context.Context, sides []*comparison) {
+	var opts []llm.ProviderOption
+	if temp := r.session.Conversation.Temperature; temp > 0 {
+		opts = append(opts, llm.WithTemperature(temp))
+	}
+	if maxTokens := r.session.Conversation.MaxTokens; maxTokens > 0 {
+		opts = append(opts, llm.WithMaxTokens(maxTokens))
+	}
+
+	var wg sync.WaitGroup
+	for _, side := range sides {
+		wg.Add(1)
+		go func(side *comparison) {
+			defer wg.Done()
+			messages := GetHistory(side.branch.Conversation)
+			started := time.Now()
+			side.response, side.err = side.provider.GenerateMessage(ctx, messages, opts...)
+			side.latency = time.Since(started)
+			if side.err == nil {
+				logging.LogInfo("Comparison response received", "model", side.model, "length", len(side.response.Content))
+			}
+		}(side)
+	}
+	wg.Wait()
+}
+
+// chooseComparison asks which branch to continue and switches to it
+func (r *REPL) chooseComparison(sides []*comparison) error {
+	const question = "Continue with which branch? [1/2, Enter to stay]: "
+	var line string
+	var err error
+	if r.readline != nil {
+		r.readline.SetPrompt(question)
+		line, err = r.readline.ReadLine()
+		r.readline.SetPrompt(r.currentPrompt())
+	} else {
+		fmt.Fprint(r.writer, question)
+		line, err = r.reader.ReadString('\n')
+	}
+	if err != nil && line == "" {
+		fmt.Fprintln(r.writer)
+		return nil
+	}
+
+	choice := strings.TrimSpace(line)
+	var chosen *comparison
+	switch choice {
+	case "1":
+		chosen = sides[0]
+	case "2":
+		chosen = sides[1]
+	case "":
+		fmt.Fprintf(r.writer, "Staying on the current session; both branches are saved (see /branches).\n")
+		return nil
+	default:
+		return fmt.Errorf("invalid choice %q, use /switch <branch_id> to continue a branch", choice)
+	}
+	if chosen.err != nil {
+		return fmt.Errorf("cannot continue with %s: it failed", chosen.model)
+	}
+
+	r.session = chosen.branch
+	r.provider = chosen.provider
+	providerName, _ := llm.ParseModelString(chosen.model)
+	r.sharedContext.Set(command.SharedContextModel, chosen.model)
+	r.sharedContext.Set(command.SharedContextProvider, providerName)
+
+	logging.LogInfo("Continuing comparison branch", "branch_id", chosen.branch.ID, "model", chosen.model)
+	fmt.Fprintf(r.writer, "Continuing with %s on branch '%s' (ID: %s)\n", chosen.model, chosen.branch.Name, chosen.branch.ID)
+	return nil
+}
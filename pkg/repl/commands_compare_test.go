@@ -0,0 +1,95 @@
+// ABOUTME: Tests for the /compare command
+// ABOUTME: Verifies branching, concurrent generation, labeled output, and branch selection
+
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPL_cmdCompare(t *testing.T) {
+	original := newCompareProvider
+	t.Cleanup(func() { newCompareProvider = original })
+	newCompareProvider = func(providerType, model string, apiKey ...string) (llm.Provider, error) {
+		provider := newMockProvider()
+		provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+			if model == "broken" {
+				return nil, errors.New("model unavailable")
+			}
+			return &llm.Response{Content: model + " says " + messages[len(messages)-1].Content}, nil
+		}
+		return provider, nil
+	}
+
+	newREPL := func(t *testing.T, answer string) (*REPL, func() string) {
+		repl, output, cleanup := setupTestREPL(t)
+		t.Cleanup(cleanup)
+		repl.reader = bufio.NewReader(strings.NewReader(answer))
+		addTestMessage(repl.session.Conversation, "user", "first question", nil)
+		addTestMessage(repl.session.Conversation, "assistant", "first answer", nil)
+		return repl, output.String
+	}
+
+	t.Run("continues with the chosen branch", func(t *testing.T) {
+		repl, output := newREPL(t, "2\n")
+		parent := repl.session
+
+		require.NoError(t, repl.cmdCompare([]string{"mock/a", "mock/b", "hello"}))
+
+		assert.Contains(t, output(), "=== [1] mock/a ===")
+		assert.Contains(t, output(), "a says hello")
+		assert.Contains(t, output(), "=== [2] mock/b ===")
+		assert.Contains(t, output(), "b says hello")
+
+		require.NotEqual(t, parent.ID, repl.session.ID)
+		assert.Equal(t, parent.ID, repl.session.ParentID)
+		assert.Equal(t, "mock/b", repl.session.Conversation.Model)
+		messages := repl.session.Conversation.Messages
+		require.Len(t, messages, 4)
+		assert.Equal(t, "b says hello", messages[3].Content)
+		assert.Len(t, parent.Conversation.Messages, 2, "original session is unchanged")
+		assert.Len(t, parent.ChildIDs, 2)
+	})
+
+	t.Run("re-runs the last user message", func(t *testing.T) {
+		repl, output := newREPL(t, "1\n")
+
+		require.NoError(t, repl.cmdCompare([]string{"mock/a", "mock/b"}))
+		assert.Contains(t, output(), "b says first question")
+		require.Len(t, repl.session.Conversation.Messages, 2)
+		assert.Equal(t, "a says first question", repl.session.Conversation.Messages[1].Content)
+	})
+
+	t.Run("enter stays on the current session", func(t *testing.T) {
+		repl, output := newREPL(t, "\n")
+		parent := repl.session
+
+		require.NoError(t, repl.cmdCompare([]string{"mock/a", "mock/b", "hi"}))
+		assert.Equal(t, parent.ID, repl.session.ID)
+		assert.Contains(t, output(), "both branches are saved")
+	})
+
+	t.Run("one failing model", func(t *testing.T) {
+		repl, output := newREPL(t, "2\n")
+
+		err := repl.cmdCompare([]string{"mock/a", "mock/broken", "hi"})
+		assert.Error(t, err)
+		assert.Contains(t, output(), "model unavailable")
+		assert.Contains(t, output(), "a says hi")
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		repl, _ := newREPL(t, "")
+		assert.Error(t, repl.cmdCompare([]string{"mock/a"}))
+		assert.Error(t, repl.cmdCompare([]string{"a", "mock/b", "hi"}))
+	})
+}
@@ -0,0 +1,100 @@
+// ABOUTME: Clipboard copy command for REPL
+// ABOUTME: Copies an assistant response, or its first code block, to the system clipboard
+
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/util/clipboard"
+)
+
+// copyToClipboard writes text to the system clipboard; replaced in tests
+var copyToClipboard = clipboard.WriteText
+
+// cmdCopy copies a message to the clipboard.
+//
+//	/copy            copy the last assistant response
+//	/copy <n>        copy message n as numbered by /history
+//	/copy [n] code   copy only the first fenced code block
+func (r *REPL) cmdCopy(args []string) error {
+	messages := r.session.Conversation.Messages
+
+	index := lastAssistantMessageIndex(messages)
+	codeOnly, explicit := false, false
+	for _, arg := range args {
+		if arg == "code" {
+			codeOnly = true
+			continue
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("usage: /copy [n] [code]")
+		}
+		index, explicit = n-1, true
+	}
+	if index < 0 || index >= len(messages) {
+		if !explicit {
+			return fmt.Errorf("%w: no assistant response to copy", ErrInvalidMessageIndex)
+		}
+		return fmt.Errorf("%w: %d (use /history to list messages)", ErrInvalidMessageIndex, index+1)
+	}
+
+	text := messages[index].Content
+	what := fmt.Sprintf("message %d", index+1)
+	if codeOnly {
+		code, ok := firstCodeBlock(text)
+		if !ok {
+			return fmt.Errorf("message %d has no code block", index+1)
+		}
+		text = code
+		what = fmt.Sprintf("code block from message %d", index+1)
+	}
+
+	if err := copyToClipboard(text); err != nil {
+		logging.LogError(err, "Failed to copy to clipboard")
+		return fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+
+	logging.LogDebug("Copied to clipboard", "message_index", index, "code_only", codeOnly, "length", len(text))
+	fmt.Fprintf(r.writer, "Copied %s to clipboard (%d characters)\n", what, len(text))
+	return nil
+}
+
+// lastAssistantMessageIndex returns the index of the most recent assistant message, or -1
+func lastAssistantMessageIndex(messages []domain.Message) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == domain.MessageRoleAssistant {
+			return i
+		}
+	}
+	return -1
+}
+
+// firstCodeBlock returns the body of the first fenced code block in text
+func firstCodeBlock(text string) (string, bool) {
+	var body []string
+	fence := ""
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) {
+			return strings.Join(body, "\n"), true
+		}
+		body = append(body, line)
+	}
+	if fence != "" {
+		// Unterminated block runs to the end of the message
+		return strings.Join(body, "\n"), true
+	}
+	return "", false
+}
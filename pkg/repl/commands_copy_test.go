@@ -0,0 +1,67 @@
+// ABOUTME: Tests for the REPL clipboard copy command
+// ABOUTME: Verifies message selection and code block extraction
+
+package repl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdCopy(t *testing.T) {
+	var copied string
+	original := copyToClipboard
+	copyToClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+	defer func() { copyToClipboard = original }()
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	conv := repl.session.Conversation
+	addTestMessage(conv, "user", "write hello", nil)
+	addTestMessage(conv, "assistant", "Here:\n```go\nfmt.Println(\"hello\")\n```\nDone.", nil)
+	addTestMessage(conv, "user", "thanks", nil)
+	addTestMessage(conv, "assistant", "You're welcome", nil)
+
+	require.NoError(t, repl.handleCommand("/copy"))
+	assert.Equal(t, "You're welcome", copied)
+	assert.Contains(t, output.String(), "Copied message 4 to clipboard")
+
+	require.NoError(t, repl.handleCommand("/copy 2"))
+	assert.Contains(t, copied, "Here:")
+
+	require.NoError(t, repl.handleCommand("/copy 2 code"))
+	assert.Equal(t, "fmt.Println(\"hello\")", copied)
+
+	err := repl.handleCommand("/copy code")
+	assert.ErrorContains(t, err, "no code block")
+
+	err = repl.handleCommand("/copy 9")
+	assert.ErrorIs(t, err, ErrInvalidMessageIndex)
+}
+
+func TestCmdCopyNoResponse(t *testing.T) {
+	repl, _, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	err := repl.handleCommand("/copy")
+	assert.ErrorIs(t, err, ErrInvalidMessageIndex)
+}
+
+func TestFirstCodeBlock(t *testing.T) {
+	code, ok := firstCodeBlock("text\n~~~\na\nb\n~~~\n```\nc\n```")
+	assert.True(t, ok)
+	assert.Equal(t, "a\nb", code)
+
+	code, ok = firstCodeBlock("```sh\nunterminated")
+	assert.True(t, ok)
+	assert.Equal(t, "unterminated", code)
+
+	_, ok = firstCodeBlock("no code here")
+	assert.False(t, ok)
+}
@@ -0,0 +1,211 @@
+// ABOUTME: Edit-and-resubmit command for REPL
+// ABOUTME: Rewrites an earlier user message on a new branch, keeping its prior revisions, and regenerates from that point
+
+package repl
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// pendingRevisionsKey is the session metadata key holding the revisions of
+// an edited message until it is resubmitted
+const pendingRevisionsKey = "pending_revisions"
+
+// cmdEdit edits a user message and re-runs the conversation from it.
+//
+//	/edit            edit the last user message
+//	/edit <n>        edit message n as numbered by /history
+//	/edit [n] <text> replace the message with text directly
+//
+// The current session keeps its original messages; the edited conversation
+// continues on a new branch created just before the edited message.
+func (r *REPL) cmdEdit(args []string) error {
+	messages := r.session.Conversation.Messages
+
+	index := lastUserMessageIndex(messages)
+	if index < 0 {
+		return fmt.Errorf("%w: no user message to edit", ErrInvalidMessageIndex)
+	}
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			index = n - 1
+			args = args[1:]
+		}
+	}
+	if index < 0 || index >= len(messages) {
+		return fmt.Errorf("%w: %d (use /history to list messages)", ErrInvalidMessageIndex, index+1)
+	}
+	original := messages[index]
+	if original.Role != domain.MessageRoleUser {
+		return fmt.Errorf("%w: message %d is not a user message", ErrInvalidMessageIndex, index+1)
+	}
+
+	edited := strings.Join(args, " ")
+	if edited == "" {
+		var err error
+		if edited, err = r.editText(original.Content); err != nil {
+			return err
+		}
+	}
+	edited = strings.TrimSpace(edited)
+	if edited == "" || edited == strings.TrimSpace(original.Content) {
+		fmt.Fprintln(r.writer, "Message unchanged, nothing to resubmit.")
+		return nil
+	}
+
+	// Fork the conversation just before the edited message
+	parent := r.session
+	branchName := fmt.Sprintf("edit-%d", index+1)
+	branch, err := parent.CreateBranch(r.manager.GenerateSessionID(), branchName, index)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBranchOperationFailed, err)
+	}
+	if err := r.manager.SaveSession(parent); err != nil {
+		return fmt.Errorf("failed to update parent session: %w", err)
+	}
+	if err := r.manager.SaveSession(branch); err != nil {
+		return fmt.Errorf("failed to save new branch: %w", err)
+	}
+
+	logging.LogInfo("Editing message on new branch",
+		"parent_id", parent.ID,
+		"branch_id", branch.ID,
+		"message_index", index,
+		"dropped_messages", len(messages)-index)
+
+	r.session = branch
+	fmt.Fprintf(r.writer, "Editing message %d on new branch '%s' (ID: %s); original kept in %s\n",
+		index+1, branchName, branch.ID, parent.ID)
+
+	// Keep the original message's attachments with the edited text
+	if len(original.Attachments) > 0 {
+		r.session.Metadata["pending_attachments"] = append([]domain.Attachment{}, original.Attachments...)
+	}
+
+	// Keep the original text as a revision of the edited message
+	revised := original.Clone()
+	revised.Revise(edited)
+	r.session.Metadata[pendingRevisionsKey] = revised.Revisions
+
+	return r.processMessage(edited)
+}
+
+// cmdRevisions lists the earlier versions of an edited message.
+//
+//	/revisions       the last edited message
+//	/revisions <n>   message n as numbered by /history
+func (r *REPL) cmdRevisions(args []string) error {
+	messages := r.session.Conversation.Messages
+
+	index := -1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(messages) {
+			return fmt.Errorf("%w: %s (use /history to list messages)", ErrInvalidMessageIndex, args[0])
+		}
+		index = n - 1
+	} else {
+		for i := len(messages) - 1; i >= 0; i-- {
+			if messages[i].IsRevised() {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			fmt.Fprintln(r.writer, "No edited messages in this session.")
+			return nil
+		}
+	}
+
+	msg := messages[index]
+	if !msg.IsRevised() {
+		fmt.Fprintf(r.writer, "Message %d has not been edited.\n", index+1)
+		return nil
+	}
+
+	fmt.Fprintf(r.writer, "Message %d has %d earlier revision(s):\n", index+1, len(msg.Revisions))
+	for i, rev := range msg.Revisions {
+		fmt.Fprintf(r.writer, "\n%d. %s (replaced %s):\n%s\n", i+1,
+			rev.Timestamp.Format("2006-01-02 15:04:05"), rev.RevisedAt.Format("2006-01-02 15:04:05"), rev.Content)
+	}
+	fmt.Fprintf(r.writer, "\nCurrent (%s):\n%s\n", msg.Timestamp.Format("2006-01-02 15:04:05"), msg.Content)
+	return nil
+}
+
+// lastUserMessageIndex returns the index of the most recent user message, or -1
+func lastUserMessageIndex(messages []domain.Message) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == domain.MessageRoleUser {
+			return i
+		}
+	}
+	return -1
+}
+
+// editText lets the user revise text, in $VISUAL/$EDITOR when running in a
+// terminal with an editor configured, or inline otherwise
+func (r *REPL) editText(text string) (string, error) {
+	if editor := editorCommand(); editor != "" && r.isTerminal {
+		return r.editInEditor(editor, text)
+	}
+
+	if r.readline != nil {
+		return r.readline.ReadLineWithDefault(text)
+	}
+
+	fmt.Fprintf(r.writer, "Current message:\n%s\nNew message: ", text)
+	line, err := r.readInput()
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited message: %w", err)
+	}
+	return line, nil
+}
+
+// editInEditor opens text in an external editor and returns the saved result
+func (r *REPL) editInEditor(editor, text string) (string, error) {
+	file, err := os.CreateTemp("", "magellai-edit-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	logging.LogDebug("Opening editor", "editor", editor, "file", file.Name())
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to open editor: %w", err)
+	}
+
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited message: %w", err)
+	}
+	return string(data), nil
+}
+
+// editorCommand returns the user's preferred editor, if any
+func editorCommand() string {
+	if editor := os.Getenv("VISUAL"); editor != "" {
+		return editor
+	}
+	return os.Getenv("EDITOR")
+}
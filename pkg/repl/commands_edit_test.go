@@ -0,0 +1,140 @@
+// ABOUTME: Tests for the REPL edit-and-resubmit command
+// ABOUTME: Verifies branching, regeneration, and editor and inline editing
+
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupEditREPL returns a REPL with a two-exchange conversation and an echoing provider
+func setupEditREPL(t *testing.T) (*REPL, *bytes.Buffer) {
+	repl, output, cleanup := setupTestREPL(t)
+	t.Cleanup(cleanup)
+
+	provider := newMockProvider()
+	provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+		return &llm.Response{Content: "re: " + messages[len(messages)-1].Content}, nil
+	}
+	repl.provider = provider
+
+	addTestMessage(repl.session.Conversation, "user", "capital of Frnace?", nil)
+	addTestMessage(repl.session.Conversation, "assistant", "Unclear", nil)
+	addTestMessage(repl.session.Conversation, "user", "and Spain?", nil)
+	addTestMessage(repl.session.Conversation, "assistant", "Madrid", nil)
+	return repl, output
+}
+
+func TestCmdEdit(t *testing.T) {
+	t.Run("inline text edits message and branches", func(t *testing.T) {
+		repl, output := setupEditREPL(t)
+		parent := repl.session
+
+		require.NoError(t, repl.handleCommand("/edit 1 capital of France?"))
+
+		assert.NotEqual(t, parent.ID, repl.session.ID)
+		assert.Equal(t, parent.ID, repl.session.ParentID)
+		assert.Equal(t, 0, repl.session.BranchPoint)
+		assert.Len(t, parent.Conversation.Messages, 4, "original conversation is preserved")
+
+		msgs := repl.session.Conversation.Messages
+		require.Len(t, msgs, 2)
+		assert.Equal(t, "capital of France?", msgs[0].Content)
+		assert.Equal(t, "re: capital of France?", msgs[1].Content)
+		assert.Contains(t, output.String(), "new branch 'edit-1'")
+
+		// The edited message keeps the text it replaced
+		require.Len(t, msgs[0].Revisions, 1)
+		assert.Equal(t, "capital of Frnace?", msgs[0].OriginalContent())
+		_, hasPending := repl.session.Metadata[pendingRevisionsKey]
+		assert.False(t, hasPending)
+	})
+
+	t.Run("edits accumulate revisions", func(t *testing.T) {
+		repl, output := setupEditREPL(t)
+
+		require.NoError(t, repl.handleCommand("/edit 1 capital of France?"))
+		require.NoError(t, repl.handleCommand("/edit 1 capital of Spain?"))
+
+		msg := repl.session.Conversation.Messages[0]
+		assert.Equal(t, "capital of Spain?", msg.Content)
+		require.Len(t, msg.Revisions, 2)
+		assert.Equal(t, "capital of Frnace?", msg.Revisions[0].Content)
+		assert.Equal(t, "capital of France?", msg.Revisions[1].Content)
+
+		output.Reset()
+		require.NoError(t, repl.handleCommand("/revisions"))
+		assert.Contains(t, output.String(), "Message 1 has 2 earlier revision(s)")
+		assert.Contains(t, output.String(), "capital of Frnace?")
+		assert.Contains(t, output.String(), "Current (")
+
+		output.Reset()
+		require.NoError(t, repl.handleCommand("/revisions 2"))
+		assert.Contains(t, output.String(), "Message 2 has not been edited")
+		assert.True(t, errors.Is(repl.cmdRevisions([]string{"9"}), ErrInvalidMessageIndex))
+	})
+
+	t.Run("defaults to last user message", func(t *testing.T) {
+		repl, _ := setupEditREPL(t)
+
+		require.NoError(t, repl.handleCommand("/edit and Portugal?"))
+
+		msgs := repl.session.Conversation.Messages
+		require.Len(t, msgs, 4)
+		assert.Equal(t, "Unclear", msgs[1].Content)
+		assert.Equal(t, "and Portugal?", msgs[2].Content)
+	})
+
+	t.Run("external editor", func(t *testing.T) {
+		repl, _ := setupEditREPL(t)
+		repl.isTerminal = true
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", "sed -i s/Frnace/France/")
+
+		require.NoError(t, repl.handleCommand("/edit 1"))
+		assert.Equal(t, "capital of France?", repl.session.Conversation.Messages[0].Content)
+	})
+
+	t.Run("inline prompt without editor", func(t *testing.T) {
+		repl, output := setupEditREPL(t)
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", "")
+		repl.reader = bufio.NewReader(bytes.NewBufferString("and Italy?\n"))
+
+		require.NoError(t, repl.handleCommand("/edit"))
+		assert.Contains(t, output.String(), "Current message:\nand Spain?")
+		assert.Equal(t, "and Italy?", repl.session.Conversation.Messages[2].Content)
+	})
+
+	t.Run("unchanged message is not resubmitted", func(t *testing.T) {
+		repl, output := setupEditREPL(t)
+		sessionID := repl.session.ID
+
+		require.NoError(t, repl.handleCommand("/edit 3 and Spain?"))
+		assert.Equal(t, sessionID, repl.session.ID)
+		assert.Contains(t, output.String(), "Message unchanged")
+	})
+
+	t.Run("invalid targets", func(t *testing.T) {
+		repl, _ := setupEditREPL(t)
+
+		for _, cmd := range []string{"/edit 2 text", "/edit 9 text", "/edit 0 text"} {
+			err := repl.cmdEdit(strings.Fields(cmd)[1:])
+			assert.True(t, errors.Is(err, ErrInvalidMessageIndex), cmd)
+		}
+
+		empty, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+		assert.True(t, errors.Is(empty.cmdEdit(nil), ErrInvalidMessageIndex))
+	})
+}
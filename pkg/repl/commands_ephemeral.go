@@ -0,0 +1,56 @@
+// ABOUTME: Ephemeral session command for REPL
+// ABOUTME: Sets or clears the time after which the session is deleted automatically
+
+package repl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// cmdEphemeral shows or sets the session's expiry.
+//
+//	/ephemeral        show when the session expires
+//	/ephemeral <ttl>  delete the session ttl from now (e.g. 24h, 90m)
+//	/ephemeral off    keep the session
+//
+// Expired sessions are deleted the next time the REPL starts.
+func (r *REPL) cmdEphemeral(args []string) error {
+	if len(args) == 0 {
+		if !r.session.IsEphemeral() {
+			fmt.Fprintln(r.writer, "Session does not expire. Use /ephemeral <duration> (e.g. 24h) to delete it automatically.")
+			return nil
+		}
+		fmt.Fprintf(r.writer, "Session expires at %s (in %s)\n",
+			r.session.ExpiresAt.Format("2006-01-02 15:04:05"), time.Until(*r.session.ExpiresAt).Round(time.Minute))
+		return nil
+	}
+
+	var ttl time.Duration
+	if args[0] != "off" {
+		var err error
+		ttl, err = time.ParseDuration(args[0])
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid duration %q: use e.g. 24h, 90m, or off", args[0])
+		}
+	}
+
+	previous := r.session.ExpiresAt
+	r.session.SetExpiry(ttl)
+	if err := r.manager.SaveSession(r.session); err != nil {
+		r.session.ExpiresAt = previous
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	r.lastSaveTime = time.Now()
+
+	if ttl == 0 {
+		logging.LogInfo("Cleared session expiry", "id", r.session.ID)
+		fmt.Fprintln(r.writer, "Session will no longer expire.")
+		return nil
+	}
+	logging.LogInfo("Set session expiry", "id", r.session.ID, "expiresAt", r.session.ExpiresAt)
+	fmt.Fprintf(r.writer, "Session will be deleted after %s\n", r.session.ExpiresAt.Format("2006-01-02 15:04:05"))
+	return nil
+}
@@ -0,0 +1,70 @@
+// ABOUTME: Tests for the REPL ephemeral session command and option
+// ABOUTME: Verifies setting and clearing expiry and deleting expired sessions at startup
+
+package repl
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdEphemeral(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	require.NoError(t, repl.handleCommand("/ephemeral"))
+	assert.Contains(t, output.String(), "Session does not expire.")
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/ephemeral 24h"))
+	require.NotNil(t, repl.session.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *repl.session.ExpiresAt, time.Minute)
+	assert.Contains(t, output.String(), "Session will be deleted after")
+
+	saved, err := repl.manager.StorageManager.LoadSession(repl.session.ID)
+	require.NoError(t, err)
+	assert.True(t, saved.IsEphemeral())
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/ephemeral"))
+	assert.Contains(t, output.String(), "Session expires at")
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/ephemeral off"))
+	assert.False(t, repl.session.IsEphemeral())
+	assert.Contains(t, output.String(), "Session will no longer expire.")
+
+	assert.Error(t, repl.cmdEphemeral([]string{"soon"}))
+	assert.Error(t, repl.cmdEphemeral([]string{"-1h"}))
+}
+
+func TestNewREPL_Ephemeral(t *testing.T) {
+	storageDir := t.TempDir()
+	newREPL := func(ephemeral time.Duration) (*REPL, error) {
+		return NewREPL(&REPLOptions{
+			Config:     setupTestConfig(),
+			StorageDir: storageDir,
+			Ephemeral:  ephemeral,
+			Reader:     bytes.NewBufferString(""),
+			Writer:     &bytes.Buffer{},
+		})
+	}
+
+	first, err := newREPL(time.Hour)
+	require.NoError(t, err)
+	require.True(t, first.session.IsEphemeral())
+
+	// Expire the session; the next REPL deletes it at startup
+	past := time.Now().Add(-time.Minute)
+	first.session.ExpiresAt = &past
+	require.NoError(t, first.manager.SaveSession(first.session))
+
+	_, err = newREPL(0)
+	require.NoError(t, err)
+	_, err = first.manager.StorageManager.LoadSession(first.session.ID)
+	assert.Error(t, err)
+}
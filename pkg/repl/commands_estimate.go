@@ -0,0 +1,44 @@
+// ABOUTME: Cost estimation command for REPL
+// ABOUTME: Estimates tokens and cost of the pending request before it is sent
+
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
+)
+
+// cmdEstimate prints the estimated token usage and cost of the next request.
+// The estimate covers the conversation history, pending attachments, and the
+// optional message given as arguments.
+func (r *REPL) cmdEstimate(args []string) error {
+	messages := GetHistory(r.session.Conversation)
+
+	pending := domain.Message{Role: domain.MessageRoleUser, Content: strings.Join(args, " ")}
+	if r.session.Metadata != nil {
+		if attachments, ok := r.session.Metadata["pending_attachments"].([]domain.Attachment); ok {
+			pending.Attachments = attachments
+		}
+	}
+	if pending.Content != "" || len(pending.Attachments) > 0 {
+		messages = append(messages, pending)
+	}
+
+	inputTokens := llm.NewEstimatedTokenCounter().CountMessageTokens(messages)
+
+	inventory, err := models.LoadDefaultInventory()
+	if err != nil {
+		logging.LogWarn("Models inventory unavailable, pricing will be omitted", "error", err)
+	}
+
+	providerName, modelName := llm.ParseModelString(r.session.Conversation.Model)
+	estimate := inventory.EstimateRequestCost(providerName, modelName, inputTokens, r.session.Conversation.MaxTokens)
+
+	fmt.Fprint(r.writer, estimate.Format())
+	return nil
+}
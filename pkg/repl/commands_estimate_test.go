@@ -0,0 +1,45 @@
+// ABOUTME: Tests for the REPL cost estimation command
+// ABOUTME: Verifies estimates for known and unknown models
+
+package repl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdEstimate(t *testing.T) {
+	inventoryFile, err := filepath.Abs(filepath.Join("..", "..", "models.json"))
+	require.NoError(t, err)
+	t.Setenv(models.InventoryEnvVar, inventoryFile)
+
+	t.Run("known model includes pricing", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		repl.session.Conversation.Model = "openai/gpt-4o"
+		repl.session.Conversation.MaxTokens = 100
+
+		err := repl.handleCommand("/estimate how much will this cost")
+		require.NoError(t, err)
+
+		out := output.String()
+		assert.Contains(t, out, "openai/gpt-4o")
+		assert.Contains(t, out, "Output tokens:  up to 100")
+		assert.Contains(t, out, "Estimated cost: up to $")
+		assert.Empty(t, repl.session.Conversation.Messages, "estimate must not send anything")
+	})
+
+	t.Run("unknown model omits pricing", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		err := repl.handleCommand("/estimate hello")
+		require.NoError(t, err)
+		assert.Contains(t, output.String(), "pricing unavailable")
+	})
+}
@@ -0,0 +1,287 @@
+// ABOUTME: Local retrieval commands for REPL
+// ABOUTME: Builds directory indexes and injects relevant excerpts into prompts
+
+package repl
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/retrieval"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// defaultRetrievalTopK is the number of excerpts retrieved when not configured
+const defaultRetrievalTopK = 4
+
+// cmdIndex builds, loads, or lists retrieval indexes.
+//
+//	/index                 show the active index
+//	/index <dir> [name]    index a directory and make it active
+//	/index use <name>      load a stored index
+//	/index list            list stored indexes
+//	/index remove <name>   delete a stored index
+func (r *REPL) cmdIndex(args []string) error {
+	if len(args) == 0 {
+		return r.showIndex()
+	}
+
+	switch args[0] {
+	case "list":
+		return r.listIndexes()
+	case "use", "load":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /index use <name>")
+		}
+		return r.useIndex(args[1])
+	case "remove", "rm", "delete":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /index remove <name>")
+		}
+		return r.removeIndex(args[1])
+	}
+
+	dir := args[0]
+	name := filepath.Base(filepath.Clean(dir))
+	if abs, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(abs)
+	}
+	if len(args) > 1 {
+		name = args[1]
+	}
+	return r.buildIndex(dir, name)
+}
+
+// indexStore returns the storage backend's index store
+func (r *REPL) indexStore() (storage.IndexStore, error) {
+	store, ok := r.manager.IndexStore()
+	if !ok {
+		return nil, fmt.Errorf("the storage backend does not support indexes")
+	}
+	return store, nil
+}
+
+// buildIndex indexes a directory, persists it, and makes it active
+func (r *REPL) buildIndex(dir, name string) error {
+	store, err := r.indexStore()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(r.writer, "Indexing %s...\n", dir)
+	idx, err := retrieval.Build(name, dir, retrieval.BuildOptions{})
+	if err != nil {
+		return err
+	}
+	if err := retrieval.Save(store, idx); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	r.ragIndex = idx
+	fmt.Fprintf(r.writer, "Index '%s' built: %d files, %d chunks. Use /retrieve <query> or /retrieve auto on.\n",
+		idx.Name, idx.Files, len(idx.Chunks))
+	return nil
+}
+
+// useIndex loads a stored index and makes it active
+func (r *REPL) useIndex(name string) error {
+	store, err := r.indexStore()
+	if err != nil {
+		return err
+	}
+	idx, err := retrieval.Load(store, name)
+	if err != nil {
+		return err
+	}
+
+	r.ragIndex = idx
+	fmt.Fprintf(r.writer, "Using index '%s' (%s, %d chunks)\n", idx.Name, idx.Root, len(idx.Chunks))
+	return nil
+}
+
+// listIndexes prints the stored indexes
+func (r *REPL) listIndexes() error {
+	store, err := r.indexStore()
+	if err != nil {
+		return err
+	}
+	names, err := store.ListIndexes()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(r.writer, "No indexes. Create one with /index <dir>.")
+		return nil
+	}
+
+	fmt.Fprintln(r.writer, "Indexes:")
+	for _, name := range names {
+		marker := " "
+		if r.ragIndex != nil && r.ragIndex.Name == name {
+			marker = "*"
+		}
+		fmt.Fprintf(r.writer, " %s %s\n", marker, name)
+	}
+	return nil
+}
+
+// removeIndex deletes a stored index
+func (r *REPL) removeIndex(name string) error {
+	store, err := r.indexStore()
+	if err != nil {
+		return err
+	}
+	if err := store.DeleteIndex(name); err != nil {
+		return err
+	}
+	if r.ragIndex != nil && r.ragIndex.Name == name {
+		r.ragIndex = nil
+	}
+	fmt.Fprintf(r.writer, "Index '%s' removed\n", name)
+	return nil
+}
+
+// showIndex prints the active index and retrieval mode
+func (r *REPL) showIndex() error {
+	if r.ragIndex == nil {
+		fmt.Fprintln(r.writer, "No active index. Create one with /index <dir> or load one with /index use <name>.")
+		return nil
+	}
+	idx := r.ragIndex
+	fmt.Fprintf(r.writer, "Index:     %s\n", idx.Name)
+	fmt.Fprintf(r.writer, "Root:      %s\n", idx.Root)
+	fmt.Fprintf(r.writer, "Contents:  %d files, %d chunks\n", idx.Files, len(idx.Chunks))
+	fmt.Fprintf(r.writer, "Built:     %s\n", idx.Created.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(r.writer, "Auto:      %v\n", r.retrieveAuto)
+	return nil
+}
+
+// cmdRetrieve searches the active index.
+//
+//	/retrieve <query>      show the best matches and attach them to the next message
+//	/retrieve auto on|off  retrieve for every message automatically
+func (r *REPL) cmdRetrieve(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: /retrieve <query> | /retrieve auto on|off")
+	}
+
+	if args[0] == "auto" && len(args) <= 2 {
+		if len(args) == 1 {
+			r.retrieveAuto = !r.retrieveAuto
+		} else {
+			switch args[1] {
+			case "on", "true", "1":
+				r.retrieveAuto = true
+			case "off", "false", "0":
+				r.retrieveAuto = false
+			default:
+				return fmt.Errorf("usage: /retrieve auto on|off")
+			}
+		}
+		if !r.retrieveAuto {
+			fmt.Fprintln(r.writer, "Automatic retrieval disabled")
+			return nil
+		}
+		fmt.Fprintln(r.writer, "Automatic retrieval enabled")
+		if r.ragIndex == nil {
+			fmt.Fprintln(r.writer, "No active index yet; create one with /index <dir>.")
+		}
+		return nil
+	}
+
+	if r.ragIndex == nil {
+		return fmt.Errorf("no active index (use /index <dir> first)")
+	}
+
+	results := r.ragIndex.Search(strings.Join(args, " "), r.retrievalTopK())
+	if len(results) == 0 {
+		fmt.Fprintln(r.writer, "No relevant excerpts found.")
+		return nil
+	}
+
+	fmt.Fprintln(r.writer, "Relevant excerpts (attached to your next message):")
+	for _, result := range results {
+		fmt.Fprintf(r.writer, "  %.3f  %s:%d-%d\n", result.Score, result.Source, result.StartLine, result.EndLine)
+	}
+	r.addPendingAttachment(retrievalAttachment(results))
+	return nil
+}
+
+// retrieveForMessage returns retrieved context for a message when automatic
+// retrieval is on
+func (r *REPL) retrieveForMessage(message string) (domain.Attachment, bool) {
+	if !r.retrieveAuto || r.ragIndex == nil {
+		return domain.Attachment{}, false
+	}
+
+	results := r.ragIndex.Search(message, r.retrievalTopK())
+	if len(results) == 0 {
+		return domain.Attachment{}, false
+	}
+
+	sources := make([]string, 0, len(results))
+	for _, result := range results {
+		sources = append(sources, fmt.Sprintf("%s:%d-%d", result.Source, result.StartLine, result.EndLine))
+	}
+	logging.LogDebug("Retrieved context", "index", r.ragIndex.Name, "results", len(results))
+	fmt.Fprintf(r.writer, "Retrieved: %s\n", strings.Join(sources, ", "))
+	return retrievalAttachment(results), true
+}
+
+// retrievalTopK returns how many excerpts to retrieve
+func (r *REPL) retrievalTopK() int {
+	switch v := r.config.Get("repl.retrieval.top_k").(type) {
+	case int:
+		if v > 0 {
+			return v
+		}
+	case int64:
+		if v > 0 {
+			return int(v)
+		}
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	case string:
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultRetrievalTopK
+}
+
+// citationsKey is the attachment metadata key holding the citations of
+// retrieved excerpts, which the response to the message is given
+const citationsKey = "citations"
+
+// retrievalAttachment wraps retrieved excerpts as a text attachment that
+// carries a citation of each excerpt
+func retrievalAttachment(results []retrieval.Result) domain.Attachment {
+	citations := make([]domain.Citation, 0, len(results))
+	for _, result := range results {
+		citations = append(citations, *domain.NewFileCitation(result.Source, result.StartLine, result.EndLine, result.Score))
+	}
+	return domain.Attachment{
+		Type:     domain.AttachmentTypeText,
+		Content:  []byte(retrieval.FormatContext(results)),
+		Name:     "retrieved-context",
+		MimeType: "text/plain",
+		Metadata: map[string]interface{}{citationsKey: citations},
+	}
+}
+
+// attachmentCitations returns the citations carried by attachments
+func attachmentCitations(attachments []domain.Attachment) []domain.Citation {
+	var citations []domain.Citation
+	for _, att := range attachments {
+		if cited, ok := att.Metadata[citationsKey].([]domain.Citation); ok {
+			citations = append(citations, cited...)
+		}
+	}
+	return citations
+}
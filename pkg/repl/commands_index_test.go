@@ -0,0 +1,91 @@
+// ABOUTME: Tests for the REPL retrieval commands
+// ABOUTME: Verifies indexing, persistence, manual and automatic retrieval
+
+package repl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdIndexAndRetrieve(t *testing.T) {
+	docs := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "deploy.md"),
+		[]byte("# Deploy\n\nRun make release to deploy the server.\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "style.md"),
+		[]byte("# Style\n\nUse tabs for indentation.\n"), 0o644))
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	require.NoError(t, repl.handleCommand("/index "+docs+" docs"))
+	assert.Contains(t, output.String(), "Index 'docs' built: 2 files")
+	require.NotNil(t, repl.ragIndex)
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/retrieve how do I deploy"))
+	assert.Contains(t, output.String(), "deploy.md:1-3")
+	pending := repl.session.Metadata["pending_attachments"].([]domain.Attachment)
+	require.Len(t, pending, 1)
+	assert.Contains(t, string(pending[0].Content), "make release")
+
+	// The index is persisted and can be loaded again
+	repl.ragIndex = nil
+	require.NoError(t, repl.handleCommand("/index use docs"))
+	require.NotNil(t, repl.ragIndex)
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/index list"))
+	assert.Contains(t, output.String(), "* docs")
+
+	require.NoError(t, repl.handleCommand("/index remove docs"))
+	assert.Nil(t, repl.ragIndex)
+	assert.Error(t, repl.handleCommand("/index use docs"))
+}
+
+func TestAutoRetrieval(t *testing.T) {
+	docs := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "style.md"),
+		[]byte("Use tabs for indentation.\n"), 0o644))
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	var sent []domain.Message
+	provider := newMockProvider()
+	provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+		sent = messages
+		return &llm.Response{Content: "Tabs."}, nil
+	}
+	repl.provider = provider
+
+	require.NoError(t, repl.handleCommand("/index "+docs))
+	require.NoError(t, repl.handleCommand("/retrieve auto on"))
+	assert.True(t, repl.retrieveAuto)
+
+	require.NoError(t, repl.processMessage("tabs or spaces for indentation?"))
+	assert.Contains(t, output.String(), "Retrieved: style.md:1-1")
+	last := sent[len(sent)-1]
+	require.Len(t, last.Attachments, 1)
+	assert.Contains(t, string(last.Attachments[0].Content), "Use tabs")
+
+	// The response cites the retrieved excerpt
+	messages := repl.session.Conversation.Messages
+	response := messages[len(messages)-1]
+	require.Len(t, response.Citations, 1)
+	assert.Equal(t, "style.md:1-1", response.Citations[0].String())
+	assert.Greater(t, response.Citations[0].Confidence, 0.0)
+
+	require.NoError(t, repl.handleCommand("/retrieve auto off"))
+	require.NoError(t, repl.processMessage("unrelated zebra question"))
+	assert.Empty(t, sent[len(sent)-1].Attachments)
+	messages = repl.session.Conversation.Messages
+	assert.Empty(t, messages[len(messages)-1].Citations)
+}
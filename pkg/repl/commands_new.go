@@ -0,0 +1,160 @@
+// ABOUTME: REPL commands for starting sessions and session templates
+// ABOUTME: Implements /new, optionally from a template, and saving the session as a template
+
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/repl/session"
+)
+
+// cmdNew starts a new session, optionally from a session template. With
+// --template and no template name it lists the templates.
+func (r *REPL) cmdNew(args []string) error {
+	templateName, rest, hasTemplate := cutTemplateFlag(args)
+	if hasTemplate && templateName == "" {
+		return r.listSessionTemplates()
+	}
+
+	var template *domain.SessionTemplate
+	if templateName != "" {
+		var err error
+		if template, err = loadSessionTemplate(r.manager.StorageManager, templateName); err != nil {
+			return err
+		}
+	}
+
+	name := strings.Join(rest, " ")
+	if name == "" && template != nil {
+		name = template.Name
+	}
+	if name == "" {
+		name = "Interactive Chat"
+	}
+
+	newSession, err := r.manager.NewSession(name)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	newSession.Conversation.Model = r.session.Conversation.Model
+	newSession.Conversation.Provider = r.session.Conversation.Provider
+	if template != nil {
+		template.Apply(newSession)
+		if err := r.manager.SaveSession(newSession); err != nil {
+			return fmt.Errorf("failed to save session: %w", err)
+		}
+	}
+
+	// Save the current session before leaving it
+	if err := r.manager.SaveSession(r.session); err != nil {
+		fmt.Fprintf(r.writer, "Warning: Failed to save current session: %v\n", err)
+	}
+
+	previousModel := r.session.Conversation.Model
+	r.session = newSession
+	r.sharedContext.Set(command.SharedContextSessionID, newSession.ID)
+	r.sharedContext.Set(command.SharedContextSessionName, newSession.Name)
+	if model := newSession.Conversation.Model; model != previousModel {
+		if err := r.switchModel([]string{model}); err != nil {
+			return err
+		}
+	}
+
+	logging.LogInfo("Started new session", "id", newSession.ID, "name", newSession.Name, "template", templateName)
+	if template != nil {
+		fmt.Fprintf(r.writer, "Started session '%s' (ID: %s) from template '%s' with %d messages\n",
+			newSession.Name, newSession.ID, template.Name, len(newSession.Conversation.Messages))
+		return nil
+	}
+	fmt.Fprintf(r.writer, "Started session '%s' (ID: %s)\n", newSession.Name, newSession.ID)
+	return nil
+}
+
+// saveSessionTemplate saves the current session's system prompt, model,
+// messages, and tags as a session template
+func (r *REPL) saveSessionTemplate(name string) error {
+	store, ok := r.manager.StorageManager.TemplateStore()
+	if !ok {
+		return fmt.Errorf("session storage does not support templates")
+	}
+
+	template := domain.NewSessionTemplateFrom(name, r.session)
+	if existing, err := store.GetTemplate(name); err == nil {
+		template.Created = existing.Created
+	}
+	if err := store.SaveTemplate(template); err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+
+	logging.LogInfo("Saved session template", "name", name, "messages", len(template.Messages))
+	fmt.Fprintf(r.writer, "Template saved: %s (%d messages)\n", name, len(template.Messages))
+	return nil
+}
+
+// listSessionTemplates shows the stored session templates
+func (r *REPL) listSessionTemplates() error {
+	store, ok := r.manager.StorageManager.TemplateStore()
+	if !ok {
+		return fmt.Errorf("session storage does not support templates")
+	}
+	templates, err := store.ListTemplates()
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		fmt.Fprintln(r.writer, "No session templates. Save one with /save --template <name>")
+		return nil
+	}
+
+	fmt.Fprintln(r.writer, "Session templates:")
+	for _, t := range templates {
+		details := []string{fmt.Sprintf("%d messages", len(t.Messages))}
+		if t.Model != "" {
+			details = append(details, t.Model)
+		}
+		if len(t.Tags) > 0 {
+			details = append(details, "tags: "+strings.Join(t.Tags, ", "))
+		}
+		fmt.Fprintf(r.writer, "  %s (%s)\n", t.Name, strings.Join(details, "; "))
+	}
+	return nil
+}
+
+// loadSessionTemplate returns the named session template from storage
+func loadSessionTemplate(manager *session.StorageManager, name string) (*domain.SessionTemplate, error) {
+	store, ok := manager.TemplateStore()
+	if !ok {
+		return nil, fmt.Errorf("session storage does not support templates")
+	}
+	template, err := store.GetTemplate(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+	return template, nil
+}
+
+// cutTemplateFlag removes --template <name> from args. found reports whether
+// the flag was given, even without a name.
+func cutTemplateFlag(args []string) (name string, rest []string, found bool) {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--template":
+			found = true
+			if i+1 < len(args) {
+				name = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(args[i], "--template="):
+			found = true
+			name = strings.TrimPrefix(args[i], "--template=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return name, rest, found
+}
@@ -0,0 +1,90 @@
+// ABOUTME: Tests for the /new command and session templates
+// ABOUTME: Verifies saving the session as a template and starting sessions from one
+
+package repl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdNew_Templates(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	require.NoError(t, repl.handleCommand("/new --template"))
+	assert.Contains(t, output.String(), "No session templates")
+
+	// Save the current setup as a template
+	repl.session.Conversation.SystemPrompt = "You review Go code"
+	repl.session.AddTag("code")
+	addTestMessage(repl.session.Conversation, "user", "Be strict", nil)
+	addTestMessage(repl.session.Conversation, "assistant", "Understood", nil)
+	require.NoError(t, repl.handleCommand("/save --template review"))
+	assert.Contains(t, output.String(), "Template saved: review (2 messages)")
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/new --template"))
+	assert.Contains(t, output.String(), "review (2 messages")
+
+	// Start a new session from it
+	previous := repl.session
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/new --template review Code review"))
+	assert.Contains(t, output.String(), "from template 'review' with 2 messages")
+	assert.NotEqual(t, previous.ID, repl.session.ID)
+	assert.Equal(t, "Code review", repl.session.Name)
+	assert.Equal(t, "You review Go code", repl.session.Conversation.SystemPrompt)
+	assert.Equal(t, []string{"code"}, repl.session.Tags)
+	assert.Equal(t, "review", repl.session.Metadata[domain.MetadataKeyTemplate])
+	require.Len(t, repl.session.Conversation.Messages, 2)
+
+	// The new session is saved with the template applied
+	saved, err := repl.manager.StorageManager.LoadSession(repl.session.ID)
+	require.NoError(t, err)
+	assert.Len(t, saved.Conversation.Messages, 2)
+
+	// A plain /new starts an empty session
+	require.NoError(t, repl.handleCommand("/new"))
+	assert.Empty(t, repl.session.Conversation.Messages)
+	assert.Equal(t, "Interactive Chat", repl.session.Name)
+
+	assert.Error(t, repl.handleCommand("/new --template missing"))
+	assert.Error(t, repl.handleCommand("/save --template"))
+}
+
+func TestNewREPL_Template(t *testing.T) {
+	storageDir := t.TempDir()
+	newREPL := func(sessionID, template string) (*REPL, error) {
+		return NewREPL(&REPLOptions{
+			Config:     setupTestConfig(),
+			StorageDir: storageDir,
+			SessionID:  sessionID,
+			Template:   template,
+			Reader:     bytes.NewBufferString(""),
+			Writer:     &bytes.Buffer{},
+		})
+	}
+
+	first, err := newREPL("", "")
+	require.NoError(t, err)
+	store, ok := first.manager.StorageManager.TemplateStore()
+	require.True(t, ok)
+	template := domain.NewSessionTemplate("brief")
+	template.SystemPrompt = "Be brief"
+	require.NoError(t, store.SaveTemplate(template))
+
+	started, err := newREPL("", "brief")
+	require.NoError(t, err)
+	assert.Equal(t, "Be brief", started.session.Conversation.SystemPrompt)
+	assert.Equal(t, "brief", started.session.Metadata[domain.MetadataKeyTemplate])
+
+	_, err = newREPL(first.session.ID, "brief")
+	assert.Error(t, err)
+	_, err = newREPL("", "missing")
+	assert.Error(t, err)
+}
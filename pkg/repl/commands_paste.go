@@ -0,0 +1,97 @@
+// ABOUTME: Clipboard image paste for REPL
+// ABOUTME: Attaches clipboard images and image paths dropped or pasted into the prompt
+
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/util/clipboard"
+)
+
+// readClipboardImage reads PNG data from the system clipboard; replaced in tests
+var readClipboardImage = clipboard.ReadImage
+
+// cmdPaste saves the clipboard image to a temp file and attaches it to the next message
+func (r *REPL) cmdPaste(args []string) error {
+	data, err := readClipboardImage()
+	if errors.Is(err, clipboard.ErrEmpty) {
+		return fmt.Errorf("no image on the clipboard")
+	}
+	if err != nil {
+		logging.LogError(err, "Failed to read clipboard image")
+		return fmt.Errorf("failed to read clipboard: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "magellai-paste-*.png")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save pasted image: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to save pasted image: %w", err)
+	}
+
+	attachment, err := createFileAttachmentFromPath(file.Name())
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+	pendingCount := r.addPendingAttachment(attachment)
+
+	fmt.Fprintf(r.writer, "Pasted image attached: %s (%d bytes)\n", file.Name(), len(data))
+	logging.LogInfo("Clipboard image attached", "path", file.Name(), "size", len(data), "pendingCount", pendingCount)
+	return nil
+}
+
+// attachPastedImage attaches input that is nothing but the path of an image
+// file, as terminals insert when an image is dropped or pasted into them.
+// It reports whether the input was consumed.
+func (r *REPL) attachPastedImage(input string) bool {
+	if !r.isTerminal {
+		return false
+	}
+
+	path := unquotePastedPath(input)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp":
+	default:
+		return false
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return false
+	}
+
+	attachment, err := createFileAttachmentFromPath(path)
+	if err != nil {
+		logging.LogWarn("Failed to attach pasted image", "path", path, "error", err)
+		return false
+	}
+	pendingCount := r.addPendingAttachment(attachment)
+
+	fmt.Fprintf(r.writer, "Image attached: %s (type your message to send it)\n", path)
+	logging.LogInfo("Pasted image path attached", "path", path, "pendingCount", pendingCount)
+	return true
+}
+
+// unquotePastedPath undoes the quoting terminals apply to dropped file paths
+func unquotePastedPath(input string) string {
+	path := strings.TrimSpace(input)
+	if len(path) >= 2 && (path[0] == '\'' || path[0] == '"') && path[len(path)-1] == path[0] {
+		return path[1 : len(path)-1]
+	}
+	if strings.HasPrefix(path, "file://") {
+		if unescaped, err := url.PathUnescape(strings.TrimPrefix(path, "file://")); err == nil {
+			return unescaped
+		}
+	}
+	return strings.ReplaceAll(path, `\ `, " ")
+}
@@ -0,0 +1,62 @@
+// ABOUTME: Tests for clipboard image paste in the REPL
+// ABOUTME: Verifies /paste and automatic attachment of pasted image paths
+
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/util/clipboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdPaste(t *testing.T) {
+	image := []byte("\x89PNG\r\n\x1a\nimage")
+	original := readClipboardImage
+	defer func() { readClipboardImage = original }()
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	readClipboardImage = func() ([]byte, error) { return image, nil }
+	require.NoError(t, repl.handleCommand("/paste"))
+	assert.Contains(t, output.String(), "Pasted image attached")
+
+	pending := repl.session.Metadata["pending_attachments"].([]domain.Attachment)
+	require.Len(t, pending, 1)
+	assert.Equal(t, domain.AttachmentTypeImage, pending[0].Type)
+	assert.Equal(t, "image/png", pending[0].MimeType)
+	defer os.Remove(pending[0].FilePath)
+
+	readClipboardImage = func() ([]byte, error) { return nil, clipboard.ErrEmpty }
+	assert.ErrorContains(t, repl.handleCommand("/paste"), "no image on the clipboard")
+}
+
+func TestAttachPastedImage(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "screen shot.png")
+	require.NoError(t, os.WriteFile(imagePath, []byte("\x89PNG\r\n\x1a\n"), 0o644))
+	textPath := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(textPath, []byte("notes"), 0o644))
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	// Only interactive terminals receive dropped files
+	assert.False(t, repl.attachPastedImage(imagePath))
+
+	repl.isTerminal = true
+	assert.True(t, repl.attachPastedImage("'"+imagePath+"'"))
+	assert.Contains(t, output.String(), "Image attached")
+	assert.True(t, repl.attachPastedImage(filepath.Join(dir, `screen\ shot.png`)))
+	assert.True(t, repl.attachPastedImage("file://"+filepath.Join(dir, "screen%20shot.png")))
+	assert.Len(t, repl.session.Metadata["pending_attachments"], 3)
+
+	assert.False(t, repl.attachPastedImage(textPath))
+	assert.False(t, repl.attachPastedImage(filepath.Join(dir, "missing.png")))
+	assert.False(t, repl.attachPastedImage("describe "+imagePath))
+}
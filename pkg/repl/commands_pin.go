@@ -0,0 +1,79 @@
+// ABOUTME: Message pinning commands for REPL
+// ABOUTME: Pins messages so they are always kept when the context is trimmed to fit the model
+
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// cmdPin pins message n as numbered by /history, or lists pinned messages
+func (r *REPL) cmdPin(args []string) error {
+	if len(args) == 0 {
+		return r.listPinned()
+	}
+	return r.setPinned(args, true)
+}
+
+// cmdUnpin unpins message n as numbered by /history
+func (r *REPL) cmdUnpin(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: /unpin <n>")
+	}
+	return r.setPinned(args, false)
+}
+
+// setPinned pins or unpins the message numbered by args[0] and saves the session
+func (r *REPL) setPinned(args []string, pinned bool) error {
+	messages := r.session.Conversation.Messages
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(messages) {
+		return fmt.Errorf("%w: %s (use /history to list messages)", ErrInvalidMessageIndex, args[0])
+	}
+
+	msg := &messages[n-1]
+	action := "Pinned"
+	if !pinned {
+		action = "Unpinned"
+	}
+	if msg.Pinned == pinned {
+		fmt.Fprintf(r.writer, "Message %d is already %s.\n", n, strings.ToLower(action))
+		return nil
+	}
+
+	msg.Pinned = pinned
+	r.session.Updated = time.Now()
+	if err := r.manager.SaveSession(r.session); err != nil {
+		msg.Pinned = !pinned
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	r.lastSaveTime = time.Now()
+
+	logging.LogInfo(action+" message", "sessionID", r.session.ID, "index", n-1, "id", msg.ID)
+	fmt.Fprintf(r.writer, "%s message %d: %s\n", action, n, truncateForDisplay(msg.Content, 60))
+	return nil
+}
+
+// listPinned shows the pinned messages of the session
+func (r *REPL) listPinned() error {
+	count := 0
+	for i, msg := range r.session.Conversation.Messages {
+		if !msg.Pinned {
+			continue
+		}
+		if count == 0 {
+			fmt.Fprintln(r.writer, "Pinned messages:")
+		}
+		count++
+		fmt.Fprintf(r.writer, "  %d. %s: %s\n", i+1, title(string(msg.Role)), truncateForDisplay(msg.Content, 60))
+	}
+	if count == 0 {
+		fmt.Fprintln(r.writer, "No pinned messages. Pin one with /pin <n> (numbered as in /history).")
+	}
+	return nil
+}
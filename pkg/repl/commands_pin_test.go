@@ -0,0 +1,88 @@
+// ABOUTME: Tests for the REPL pin and unpin commands
+// ABOUTME: Verifies pinning, unpinning, listing, and keeping pinned messages when summarizing
+
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdPinUnpin(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	addTestMessage(repl.session.Conversation, "user", "Always answer in French", nil)
+	addTestMessage(repl.session.Conversation, "assistant", "D'accord", nil)
+
+	require.NoError(t, repl.handleCommand("/pin"))
+	assert.Contains(t, output.String(), "No pinned messages.")
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/pin 1"))
+	assert.True(t, repl.session.Conversation.Messages[0].Pinned)
+	assert.Contains(t, output.String(), "Pinned message 1: Always answer in French")
+
+	// The pin is saved with the session
+	saved, err := repl.manager.StorageManager.LoadSession(repl.session.ID)
+	require.NoError(t, err)
+	assert.True(t, saved.Conversation.Messages[0].Pinned)
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/pin"))
+	assert.Contains(t, output.String(), "1. User: Always answer in French")
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/history"))
+	assert.Contains(t, output.String(), "User (pinned):")
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/unpin 1"))
+	assert.False(t, repl.session.Conversation.Messages[0].Pinned)
+	assert.Contains(t, output.String(), "Unpinned message 1")
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/unpin 1"))
+	assert.Contains(t, output.String(), "Message 1 is already unpinned.")
+
+	assert.ErrorIs(t, repl.cmdPin([]string{"3"}), ErrInvalidMessageIndex)
+	assert.ErrorIs(t, repl.cmdUnpin([]string{"x"}), ErrInvalidMessageIndex)
+	assert.Error(t, repl.cmdUnpin(nil))
+}
+
+func TestCmdSummarizeKeepsPinned(t *testing.T) {
+	repl, _, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	var request []domain.Message
+	provider := newMockProvider()
+	provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+		request = messages
+		return &llm.Response{Content: "Earlier small talk."}, nil
+	}
+	repl.provider = provider
+
+	conv := repl.session.Conversation
+	addTestMessage(conv, "user", "Always answer in French", nil)
+	addTestMessage(conv, "assistant", "D'accord", nil)
+	addTestMessage(conv, "user", "Bonjour", nil)
+	addTestMessage(conv, "assistant", "Salut", nil)
+	conv.Messages[0].Pinned = true
+
+	require.NoError(t, repl.handleCommand("/summarize 3"))
+
+	require.Len(t, request, 2)
+	assert.NotContains(t, request[1].Content, "Always answer in French")
+
+	messages := repl.session.Conversation.Messages
+	require.Len(t, messages, 3)
+	assert.Equal(t, domain.MessageRoleSystem, messages[0].Role)
+	assert.Equal(t, "Always answer in French", messages[1].Content)
+	assert.True(t, messages[1].Pinned)
+	assert.Equal(t, "Salut", messages[2].Content)
+}
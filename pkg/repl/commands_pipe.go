@@ -0,0 +1,63 @@
+// ABOUTME: Pipe command for REPL
+// ABOUTME: Sends the last assistant response or pipeline output to a shell command's standard input
+
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// cmdPipe runs a shell command with the last assistant response on stdin and
+// shows its output, e.g. /pipe jq . or /pipe wc -w. After | in a command
+// pipeline it gets the previous command's output, e.g. /history | /pipe wc -l
+func (r *REPL) cmdPipe(args []string) error {
+	line := strings.TrimSpace(strings.Join(args, " "))
+	if line == "" {
+		return fmt.Errorf("usage: /pipe <command>")
+	}
+	if !r.config.GetBool("repl.shell.enabled") {
+		return fmt.Errorf("shell commands are disabled (set repl.shell.enabled to true)")
+	}
+
+	// In a pipeline, the previous command's output is sent instead
+	var content string
+	if r.pipelineInput != nil {
+		content = r.pipelineInput.Text
+	} else {
+		response := GetLastAssistantMessage(r.session.Conversation)
+		if response == nil {
+			return fmt.Errorf("%w: no assistant response to pipe", ErrInvalidMessageIndex)
+		}
+		content = response.Content
+	}
+
+	ctx := r.beginGeneration()
+	defer r.endGeneration()
+
+	name, shellArgs := shellCommand()
+	cmd := exec.CommandContext(ctx, name, append(shellArgs, line)...)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = r.writer
+	cmd.Stderr = r.writer
+
+	logging.LogInfo("Piping response to command", "command", line, "length", len(content))
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return ErrGenerationCancelled
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		fmt.Fprintf(r.writer, "[exit status %d]\n", exitErr.ExitCode())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run command: %w", err)
+	}
+	return nil
+}
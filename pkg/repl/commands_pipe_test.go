@@ -0,0 +1,41 @@
+// ABOUTME: Tests for the REPL pipe command
+// ABOUTME: Verifies the last response is sent to the command's stdin
+
+package repl
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdPipe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	t.Setenv("SHELL", "/bin/sh")
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	assert.ErrorContains(t, repl.handleCommand("/pipe cat"), "shell commands are disabled")
+	repl.config.(*testConfig).values["repl.shell.enabled"] = true
+
+	assert.ErrorIs(t, repl.handleCommand("/pipe cat"), ErrInvalidMessageIndex)
+
+	conv := repl.session.Conversation
+	addTestMessage(conv, "user", "list", nil)
+	addTestMessage(conv, "assistant", "banana\napple\ncherry", nil)
+
+	require.NoError(t, repl.handleCommand("/pipe sort | head -n 1"))
+	assert.Contains(t, output.String(), "apple\n")
+	assert.NotContains(t, output.String(), "banana")
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/pipe grep -q durian"))
+	assert.Contains(t, output.String(), "[exit status 1]")
+
+	assert.Error(t, repl.handleCommand("/pipe"))
+}
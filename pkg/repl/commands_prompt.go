@@ -0,0 +1,91 @@
+// ABOUTME: Named prompt commands for REPL
+// ABOUTME: Lists, shows, and sends prompts from the prompts config section
+
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/templates"
+)
+
+// cmdPrompt handles /prompt list|show|run
+func (r *REPL) cmdPrompt(args []string) error {
+	raw := r.config.Get(templates.PromptsKey)
+
+	if len(args) == 0 || args[0] == "list" {
+		prompts := templates.ConfigPrompts(raw)
+		if len(prompts) == 0 {
+			fmt.Fprintf(r.writer, "No prompts defined. Add one with: magellai prompt add <name> <content>\n")
+			return nil
+		}
+		fmt.Fprintln(r.writer, "Prompts:")
+		for _, p := range prompts {
+			fmt.Fprintf(r.writer, "  %-16s %s\n", p.Name, strings.TrimSpace(p.Description+" ("+p.Describe()+")"))
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "show":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /prompt show <name>")
+		}
+		p, err := templates.ConfigPrompt(raw, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.writer, "Prompt: %s\n", p.Name)
+		if p.Description != "" {
+			fmt.Fprintf(r.writer, "Description: %s\n", p.Description)
+		}
+		fmt.Fprintf(r.writer, "Variables: %s\n\n%s\n", p.Describe(), p.Content)
+		return nil
+
+	case "run", "use":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /prompt run <name> [--var name=value ...]")
+		}
+		p, err := templates.ConfigPrompt(raw, args[1])
+		if err != nil {
+			return err
+		}
+		// Standard input belongs to the REPL, so "-" values are not supported here
+		vars, err := templates.ParseVariables(promptVarArgs(args[2:]), nil)
+		if err != nil {
+			return err
+		}
+		prompt, err := p.Render(vars)
+		if err != nil {
+			return err
+		}
+
+		logging.LogInfo("Running prompt", "name", p.Name, "promptLength", len(prompt))
+		fmt.Fprintf(r.writer, "Using prompt '%s'\n", p.Name)
+		return r.processMessage(prompt)
+
+	default:
+		return fmt.Errorf("unknown prompt subcommand: %s (use list, show, or run)", args[0])
+	}
+}
+
+// promptVarArgs collects name=value variables given either bare or after
+// --var, as in the CLI
+func promptVarArgs(args []string) []string {
+	var vars []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--var" && i+1 < len(args):
+			i++
+			vars = append(vars, args[i])
+		case strings.HasPrefix(arg, "--var="):
+			vars = append(vars, strings.TrimPrefix(arg, "--var="))
+		default:
+			vars = append(vars, arg)
+		}
+	}
+	return vars
+}
@@ -0,0 +1,53 @@
+// ABOUTME: Tests for the REPL named prompt commands
+// ABOUTME: Verifies listing, showing, and sending prompts from config
+
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdPrompt(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	require.NoError(t, repl.handleCommand("/prompt"))
+	assert.Contains(t, output.String(), "No prompts defined")
+
+	repl.config.(*testConfig).values["prompts"] = map[string]interface{}{
+		"summarize": map[string]interface{}{
+			"content":     "Summarize {{text}} in {{n|5}} bullets",
+			"description": "Summarize text",
+		},
+	}
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/prompt list"))
+	assert.Contains(t, output.String(), "summarize")
+	assert.Contains(t, output.String(), `n="5"`)
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/prompt show summarize"))
+	assert.Contains(t, output.String(), "Summarize {{text}}")
+
+	var sent string
+	provider := newMockProvider()
+	provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+		sent = messages[len(messages)-1].Content
+		return &llm.Response{Content: "- done"}, nil
+	}
+	repl.provider = provider
+
+	require.NoError(t, repl.handleCommand("/prompt run summarize --var text=notes n=3"))
+	assert.Equal(t, "Summarize notes in 3 bullets", sent)
+
+	assert.Error(t, repl.handleCommand("/prompt run summarize"), "missing variable")
+	assert.Error(t, repl.handleCommand("/prompt run nope text=x"))
+	assert.Error(t, repl.handleCommand("/prompt frobnicate"))
+}
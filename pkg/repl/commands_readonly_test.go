@@ -0,0 +1,75 @@
+// ABOUTME: Tests for the REPL with read-only session storage
+// ABOUTME: Verifies sessions can be browsed and exported while every write is refused
+
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewREPL_ReadOnly(t *testing.T) {
+	storageDir := t.TempDir()
+	writable, err := NewREPL(&REPLOptions{
+		Config:     setupTestConfig(),
+		StorageDir: storageDir,
+		Reader:     bytes.NewBufferString(""),
+		Writer:     &bytes.Buffer{},
+	})
+	require.NoError(t, err)
+	writable.session.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "shared question"))
+	require.NoError(t, writable.manager.SaveSession(writable.session))
+	before := listFiles(t, storageDir)
+
+	output := &bytes.Buffer{}
+	repl, err := NewREPL(&REPLOptions{
+		Config:     setupTestConfig(),
+		StorageDir: storageDir,
+		SessionID:  writable.session.ID,
+		ReadOnly:   true,
+		Reader:     bytes.NewBufferString(""),
+		Writer:     output,
+	})
+	require.NoError(t, err)
+	assert.True(t, repl.manager.ReadOnly())
+	assert.False(t, repl.autoSave)
+	assert.Nil(t, repl.autoRecovery)
+
+	// Browsing and exporting work
+	require.NoError(t, repl.handleCommand("/export json "+filepath.Join(t.TempDir(), "out.json")))
+
+	// Writes are refused with a clear error
+	err = repl.handleCommand("/save")
+	assert.ErrorIs(t, err, storage.ErrReadOnly)
+	assert.Contains(t, err.Error(), "storage is read-only")
+	err = repl.handleCommand("/rename Renamed")
+	assert.ErrorIs(t, err, storage.ErrReadOnly)
+
+	// Exiting does not save
+	output.Reset()
+	repl.saveOnInterruptExit()
+	assert.NotContains(t, output.String(), "Failed to save")
+
+	assert.Equal(t, before, listFiles(t, storageDir))
+}
+
+// listFiles returns the names and sizes of the files in dir
+func listFiles(t *testing.T, dir string) map[string]int64 {
+	t.Helper()
+	files := make(map[string]int64)
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		files[path] = info.Size()
+		return nil
+	}))
+	return files
+}
@@ -5,11 +5,16 @@ package repl
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
 )
 
+// confirm asks a yes/no question, reading the answer from the REPL input
+func (r *REPL) confirm(question string) (bool, error) {
+	return command.NewPrompter(r.reader, r.writer, true).Confirm(question, false)
+}
+
 // cmdRecover manually triggers recovery operations
 func (r *REPL) cmdRecover(args []string) error {
 	if r.autoRecovery == nil {
@@ -99,14 +104,11 @@ func (r *REPL) saveRecoveryState() error {
 // clearRecoveryState clears any existing recovery state
 func (r *REPL) clearRecoveryState() error {
 	// Ask for confirmation
-	fmt.Fprint(r.writer, "Are you sure you want to clear the recovery state? (y/n): ")
-	response, err := r.reader.ReadString('\n')
+	ok, err := r.confirm("Are you sure you want to clear the recovery state?")
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
-
-	response = strings.TrimSpace(strings.ToLower(response))
-	if response != "y" && response != "yes" {
+	if !ok {
 		fmt.Fprintln(r.writer, "Cancelled")
 		return nil
 	}
@@ -139,28 +141,22 @@ func (r *REPL) restoreFromRecovery() error {
 	fmt.Fprintf(r.writer, "  Last Saved: %s\n", state.Timestamp.Format("2006-01-02 15:04:05"))
 
 	// Ask for confirmation
-	fmt.Fprint(r.writer, "Restore this session? (y/n): ")
-	response, err := r.reader.ReadString('\n')
+	ok, err := r.confirm("Restore this session?")
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
-
-	response = strings.TrimSpace(strings.ToLower(response))
-	if response != "y" && response != "yes" {
+	if !ok {
 		fmt.Fprintln(r.writer, "Cancelled")
 		return nil
 	}
 
 	// Save current session if needed
 	if r.session != nil && r.hasUnsavedChanges() {
-		fmt.Fprint(r.writer, "Save current session before restoring? (y/n): ")
-		saveResponse, err := r.reader.ReadString('\n')
+		save, err := r.confirm("Save current session before restoring?")
 		if err != nil {
-			return fmt.Errorf("failed to read response: %w", err)
+			return err
 		}
-
-		saveResponse = strings.TrimSpace(strings.ToLower(saveResponse))
-		if saveResponse == "y" || saveResponse == "yes" {
+		if save {
 			if err := r.manager.SaveSession(r.session); err != nil {
 				logging.LogWarn("Failed to save current session", "error", err)
 			}
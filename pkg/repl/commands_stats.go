@@ -0,0 +1,118 @@
+// ABOUTME: Session statistics command for REPL
+// ABOUTME: Summarizes messages, tokens, cost, attachments, branches, and age of the session
+
+package repl
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+)
+
+// cmdStats prints statistics for the current session. Token counts for the
+// conversation are estimated from its text; usage reported by providers is
+// shown separately for requests made since chat started.
+func (r *REPL) cmdStats(args []string) error {
+	conv := r.session.Conversation
+	counter := llm.NewEstimatedTokenCounter()
+
+	roles := map[domain.MessageRole]int{}
+	inputTokens, outputTokens := 0, 0
+	attachments := 0
+	var attachmentBytes int64
+	for _, msg := range conv.Messages {
+		roles[msg.Role]++
+		tokens := counter.CountMessageTokens([]domain.Message{msg})
+		if msg.Role == domain.MessageRoleAssistant {
+			outputTokens += tokens
+		} else {
+			inputTokens += tokens
+		}
+		for _, att := range msg.Attachments {
+			attachments++
+			attachmentBytes += attachmentSize(att)
+		}
+	}
+
+	fmt.Fprintf(r.writer, "Session: %s\n", sessionLabel(r.session))
+	fmt.Fprintf(r.writer, "Model: %s\n", conv.Model)
+	fmt.Fprintf(r.writer, "Age: %s (created %s)\n", formatAge(time.Since(r.session.Created)), r.session.Created.Format("2006-01-02 15:04"))
+	fmt.Fprintf(r.writer, "Messages: %d (user %d, assistant %d, system %d)\n",
+		len(conv.Messages), roles[domain.MessageRoleUser], roles[domain.MessageRoleAssistant], roles[domain.MessageRoleSystem])
+	fmt.Fprintf(r.writer, "Tokens: ~%s in, ~%s out (estimated)\n", formatTokenCount(inputTokens), formatTokenCount(outputTokens))
+
+	if model := r.currentModel(); model != nil {
+		if cost := model.Cost(inputTokens, outputTokens); cost.PricingKnown {
+			fmt.Fprintf(r.writer, "Estimated cost: $%.4f\n", cost.TotalCost)
+		}
+	}
+	if r.usage.InputTokens > 0 || r.usage.OutputTokens > 0 {
+		fmt.Fprintf(r.writer, "Since chat started: %s in, %s out",
+			formatTokenCount(r.usage.InputTokens), formatTokenCount(r.usage.OutputTokens))
+		if r.usage.PricingKnown {
+			fmt.Fprintf(r.writer, ", $%.4f", r.usage.Cost)
+		}
+		fmt.Fprintln(r.writer)
+	}
+	if usage := r.session.TotalUsage(); usage.Requests > 0 {
+		fmt.Fprintf(r.writer, "Recorded usage: %d request(s), %s in, %s out",
+			usage.Requests, formatTokenCount(usage.PromptTokens), formatTokenCount(usage.CompletionTokens))
+		if usage.CostKnown {
+			fmt.Fprintf(r.writer, ", $%.4f", usage.Cost)
+		}
+		fmt.Fprintf(r.writer, ", %s average latency\n", (usage.Latency / time.Duration(usage.Requests)).Round(time.Millisecond))
+	}
+
+	fmt.Fprintf(r.writer, "Attachments: %d (%s)\n", attachments, formatBytes(attachmentBytes))
+	fmt.Fprintf(r.writer, "Branches: %d\n", len(r.session.ChildIDs))
+	if r.session.IsBranch() {
+		fmt.Fprintf(r.writer, "Branch of: %s (at message %d)\n", r.session.ParentID, r.session.BranchPoint)
+	}
+	return nil
+}
+
+// attachmentSize returns the size of an attachment in bytes
+func attachmentSize(att domain.Attachment) int64 {
+	switch {
+	case att.Size > 0:
+		return att.Size
+	case len(att.Content) > 0:
+		return int64(len(att.Content))
+	case att.FilePath != "":
+		if info, err := os.Stat(att.FilePath); err == nil {
+			return info.Size()
+		}
+	}
+	return 0
+}
+
+// formatBytes renders a byte count compactly (512 B, 1.5 KB, 2.0 MB)
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// formatAge renders a duration to the nearest useful unit (45s, 12m, 3h 5m, 2d 4h)
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
@@ -0,0 +1,65 @@
+// ABOUTME: Tests for the /stats command
+// ABOUTME: Verifies message, token, attachment, branch, and age statistics
+
+package repl
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPL_cmdStats(t *testing.T) {
+	inventoryFile, err := filepath.Abs(filepath.Join("..", "..", "models.json"))
+	require.NoError(t, err)
+	t.Setenv(models.InventoryEnvVar, inventoryFile)
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	repl.session.Created = time.Now().Add(-26 * time.Hour)
+	repl.session.Conversation.Model = "openai/gpt-4o"
+	conv := repl.session.Conversation
+	addTestMessage(conv, "system", "Be brief", nil)
+	addTestMessage(conv, "user", "Describe this", []domain.Attachment{
+		{ID: "a1", Type: domain.AttachmentTypeText, Content: make([]byte, 2048)},
+	})
+	addTestMessage(conv, "assistant", "It is a file of zeros.", nil)
+	_, err = repl.session.CreateBranch("branch-1", "alt", 1)
+	require.NoError(t, err)
+
+	require.NoError(t, repl.handleCommand("/stats"))
+
+	out := output.String()
+	assert.Contains(t, out, "Age: 1d 2h")
+	assert.Contains(t, out, "Messages: 3 (user 1, assistant 1, system 1)")
+	assert.Contains(t, out, "Tokens: ~")
+	assert.Contains(t, out, "Estimated cost: $")
+	assert.Contains(t, out, "Attachments: 1 (2.0 KB)")
+	assert.Contains(t, out, "Branches: 1")
+	assert.NotContains(t, out, "Since chat started")
+	assert.NotContains(t, out, "Recorded usage")
+
+	output.Reset()
+	conv.Messages[2].Usage = &domain.Usage{PromptTokens: 1200, CompletionTokens: 300, Latency: 1500 * time.Millisecond}
+	require.NoError(t, repl.handleCommand("/stats"))
+	assert.Contains(t, output.String(), "Recorded usage: 1 request(s), 1.2k in, 300 out, 1.5s average latency")
+}
+
+func TestFormatAge(t *testing.T) {
+	assert.Equal(t, "45s", formatAge(45*time.Second))
+	assert.Equal(t, "12m", formatAge(12*time.Minute))
+	assert.Equal(t, "3h 5m", formatAge(3*time.Hour+5*time.Minute))
+	assert.Equal(t, "2d 4h", formatAge(52*time.Hour))
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KB", formatBytes(1536))
+	assert.Equal(t, "2.0 MB", formatBytes(2<<20))
+}
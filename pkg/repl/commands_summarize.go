@@ -0,0 +1,145 @@
+// ABOUTME: Conversation summarization command for REPL
+// ABOUTME: Replaces older messages with a model-written summary on a new branch
+
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+)
+
+// summarizeKeepRecent is how many recent messages /summarize keeps verbatim by default
+const summarizeKeepRecent = 4
+
+// summarizePrompt instructs the model how to summarize the conversation
+const summarizePrompt = `Summarize the conversation below so it can replace the original messages as context for continuing it.
+Keep facts, decisions, open questions, names, file names, code identifiers and user preferences.
+Write it as concise notes in the third person. Do not add anything that was not said.`
+
+// cmdSummarize compresses the start of the conversation.
+//
+//	/summarize      summarize all but the most recent messages
+//	/summarize <n>  summarize the first n messages
+//
+// The summary replaces the summarized messages as a system message on a new
+// branch; the current session keeps the full conversation. Pinned messages
+// are kept verbatim after the summary.
+func (r *REPL) cmdSummarize(args []string) error {
+	messages := r.session.Conversation.Messages
+
+	count := max(len(messages)-summarizeKeepRecent, 0)
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("usage: /summarize [n]")
+		}
+		count = n
+	}
+	if count > len(messages) || count < 0 {
+		return fmt.Errorf("%w: %d (conversation has %d messages)", ErrInvalidMessageIndex, count, len(messages))
+	}
+	if count < 2 {
+		fmt.Fprintln(r.writer, "Not enough messages to summarize.")
+		return nil
+	}
+
+	var summarized, pinned []domain.Message
+	for _, msg := range messages[:count] {
+		if msg.Pinned {
+			pinned = append(pinned, msg)
+		} else {
+			summarized = append(summarized, msg)
+		}
+	}
+	if len(summarized) < 2 {
+		fmt.Fprintln(r.writer, "Not enough unpinned messages to summarize.")
+		return nil
+	}
+
+	fmt.Fprintf(r.writer, "Summarizing %d messages...\n", count)
+	summary, err := r.summarizeMessages(summarized)
+	if err != nil {
+		return err
+	}
+
+	counter := llm.NewEstimatedTokenCounter()
+	before := counter.CountMessageTokens(GetHistory(r.session.Conversation))
+
+	// Branch with the summary in place of the summarized messages
+	parent := r.session
+	branchName := fmt.Sprintf("summary-%d", count)
+	branch, err := parent.CreateBranch(r.manager.GenerateSessionID(), branchName, 0)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBranchOperationFailed, err)
+	}
+	summaryMessage := NewMessage(string(domain.MessageRoleSystem), "Summary of the earlier conversation:\n\n"+summary, nil)
+	summaryMessage.Metadata["summarized_messages"] = count
+	branch.Conversation.AddMessage(summaryMessage)
+	for _, msg := range append(pinned, messages[count:]...) {
+		msg.ID = uuid.New().String()
+		branch.Conversation.AddMessage(msg)
+	}
+
+	if err := r.manager.SaveSession(parent); err != nil {
+		return fmt.Errorf("failed to update parent session: %w", err)
+	}
+	if err := r.manager.SaveSession(branch); err != nil {
+		return fmt.Errorf("failed to save new branch: %w", err)
+	}
+	r.session = branch
+
+	after := counter.CountMessageTokens(GetHistory(branch.Conversation))
+	logging.LogInfo("Summarized conversation",
+		"parent_id", parent.ID, "branch_id", branch.ID,
+		"summarized", count, "tokens_before", before, "tokens_after", after)
+
+	fmt.Fprintf(r.writer, "Summarized %d messages on new branch '%s' (ID: %s); original kept in %s\n",
+		count, branchName, branch.ID, parent.ID)
+	if before > 0 {
+		fmt.Fprintf(r.writer, "Context: ~%d -> ~%d tokens (saved ~%d, %.0f%%)\n",
+			before, after, before-after, float64(before-after)/float64(before)*100)
+	}
+	return nil
+}
+
+// summarizeMessages asks the model for a summary of messages
+func (r *REPL) summarizeMessages(messages []domain.Message) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&transcript, "%s: %s\n\n", title(string(msg.Role)), msg.Content)
+		for _, att := range msg.Attachments {
+			fmt.Fprintf(&transcript, "[attachment: %s]\n\n", getDomainAttachmentDisplayName(att))
+		}
+	}
+
+	request := []domain.Message{
+		NewMessage(string(domain.MessageRoleSystem), summarizePrompt, nil),
+		NewMessage(string(domain.MessageRoleUser), transcript.String(), nil),
+	}
+
+	ctx := r.beginGeneration()
+	defer r.endGeneration()
+
+	started := time.Now()
+	resp, err := r.provider.GenerateMessage(ctx, request)
+	if err != nil && ctx.Err() != nil {
+		return "", ErrGenerationCancelled
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	r.recordUsage(r.session.Conversation.Model, request, resp.Content, resp.Usage, time.Since(started))
+
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return "", fmt.Errorf("failed to summarize conversation: empty summary")
+	}
+	return summary, nil
+}
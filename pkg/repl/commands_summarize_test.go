@@ -0,0 +1,73 @@
+// ABOUTME: Tests for the REPL summarize command
+// ABOUTME: Verifies the summary branch, kept messages, and savings report
+
+package repl
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdSummarize(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	var request []domain.Message
+	provider := newMockProvider()
+	provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+		request = messages
+		return &llm.Response{Content: "User is planning a trip to Paris."}, nil
+	}
+	repl.provider = provider
+
+	conv := repl.session.Conversation
+	long := strings.Repeat("Paris has many museums worth visiting. ", 20)
+	for i := 0; i < 3; i++ {
+		addTestMessage(conv, "user", "Tell me about Paris", nil)
+		addTestMessage(conv, "assistant", long, nil)
+	}
+	parent := repl.session
+
+	require.NoError(t, repl.handleCommand("/summarize"))
+
+	require.Len(t, request, 2)
+	assert.Equal(t, domain.MessageRoleSystem, request[0].Role)
+	assert.Contains(t, request[1].Content, "User: Tell me about Paris")
+
+	require.NotSame(t, parent, repl.session)
+	assert.Equal(t, parent.ID, repl.session.ParentID)
+	assert.Len(t, parent.Conversation.Messages, 6, "original conversation is kept")
+
+	messages := repl.session.Conversation.Messages
+	require.Len(t, messages, 1+summarizeKeepRecent)
+	assert.Equal(t, domain.MessageRoleSystem, messages[0].Role)
+	assert.Contains(t, messages[0].Content, "planning a trip to Paris")
+	assert.Equal(t, "Tell me about Paris", messages[1].Content)
+
+	out := output.String()
+	assert.Contains(t, out, "Summarized 2 messages on new branch 'summary-2'")
+	assert.Contains(t, out, "saved ~")
+}
+
+func TestCmdSummarizeCount(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	conv := repl.session.Conversation
+	addTestMessage(conv, "user", "Hi", nil)
+	addTestMessage(conv, "assistant", "Hello", nil)
+
+	require.NoError(t, repl.handleCommand("/summarize"))
+	assert.Contains(t, output.String(), "Not enough messages")
+
+	require.NoError(t, repl.handleCommand("/summarize 2"))
+	assert.Len(t, repl.session.Conversation.Messages, 1)
+
+	assert.ErrorIs(t, repl.handleCommand("/summarize 9"), ErrInvalidMessageIndex)
+}
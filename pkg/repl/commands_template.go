@@ -0,0 +1,85 @@
+// ABOUTME: Prompt template commands for REPL
+// ABOUTME: Lists, shows, and sends stored templates filled in with variables
+
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/templates"
+)
+
+// cmdTemplate handles /template list|show|use
+func (r *REPL) cmdTemplate(args []string) error {
+	store, err := templates.OpenStore(r.config.GetString("template.directory"))
+	if err != nil {
+		return fmt.Errorf("failed to open templates: %w", err)
+	}
+
+	if len(args) == 0 || args[0] == "list" {
+		return r.listTemplates(store)
+	}
+
+	switch args[0] {
+	case "show":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /template show <name>")
+		}
+		t, err := store.Get(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.writer, "Template: %s\n", t.Name)
+		if t.Description != "" {
+			fmt.Fprintf(r.writer, "Description: %s\n", t.Description)
+		}
+		fmt.Fprintf(r.writer, "Variables: %s\n\n%s\n", t.Describe(), t.Content)
+		return nil
+
+	case "use", "run":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: /template use <name> [var=value ...]")
+		}
+		t, err := store.Get(args[1])
+		if err != nil {
+			return err
+		}
+		// Standard input belongs to the REPL, so "-" values are not supported here
+		vars, err := templates.ParseVariables(args[2:], nil)
+		if err != nil {
+			return err
+		}
+		prompt, err := t.Render(vars)
+		if err != nil {
+			return err
+		}
+
+		logging.LogInfo("Using template", "name", t.Name, "promptLength", len(prompt))
+		fmt.Fprintf(r.writer, "Using template '%s'\n", t.Name)
+		return r.processMessage(prompt)
+
+	default:
+		return fmt.Errorf("unknown template subcommand: %s (use list, show, or use)", args[0])
+	}
+}
+
+// listTemplates prints the stored templates
+func (r *REPL) listTemplates(store *templates.Store) error {
+	list, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Fprintf(r.writer, "No templates defined. Add one with: magellai template add <name> <content>\n")
+		return nil
+	}
+
+	fmt.Fprintln(r.writer, "Templates:")
+	for _, t := range list {
+		line := fmt.Sprintf("  %-16s %s", t.Name, strings.TrimSpace(t.Description+" ("+t.Describe()+")"))
+		fmt.Fprintln(r.writer, line)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// ABOUTME: Tests for the REPL prompt template commands
+// ABOUTME: Verifies listing, showing, and sending filled-in templates
+
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdTemplate(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	repl.config.(*testConfig).values["template.directory"] = dir
+
+	require.NoError(t, repl.handleCommand("/template"))
+	assert.Contains(t, output.String(), "No templates defined")
+
+	store := templates.NewStore(dir)
+	require.NoError(t, store.Save(&templates.Template{
+		Name:        "translate",
+		Description: "Translate text",
+		Content:     "Translate '{{text}}' into {{language|French}}",
+	}))
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/template list"))
+	assert.Contains(t, output.String(), "translate")
+	assert.Contains(t, output.String(), `language="French"`)
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/template show translate"))
+	assert.Contains(t, output.String(), "Translate '{{text}}'")
+
+	var sent string
+	provider := newMockProvider()
+	provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+		sent = messages[len(messages)-1].Content
+		return &llm.Response{Content: "Bonjour"}, nil
+	}
+	repl.provider = provider
+
+	require.NoError(t, repl.handleCommand("/template use translate text=hello"))
+	assert.Equal(t, "Translate 'hello' into French", sent)
+	assert.Len(t, repl.session.Conversation.Messages, 2)
+
+	assert.Error(t, repl.handleCommand("/template use translate"), "missing variable")
+	assert.Error(t, repl.handleCommand("/template use nope text=x"))
+	assert.Error(t, repl.handleCommand("/template frobnicate"))
+}
@@ -1,12 +1,16 @@
 package repl
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/lexlapax/magellai/pkg/command"
 	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/ui"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -35,6 +39,25 @@ func TestREPL_saveSession(t *testing.T) {
 	assert.Contains(t, output.String(), "Session saved:")
 }
 
+func TestREPL_cmdRename(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	require.Error(t, repl.cmdRename(nil))
+
+	repl.session.Name = "Old Name"
+	require.NoError(t, repl.handleCommand("/rename Project Notes"))
+	assert.Equal(t, "Project Notes", repl.session.Name)
+	assert.Contains(t, output.String(), "renamed from 'Old Name' to 'Project Notes'")
+	name, _ := repl.sharedContext.GetString(command.SharedContextSessionName)
+	assert.Equal(t, "Project Notes", name)
+	assert.False(t, repl.hasUnsavedChanges())
+
+	saved, err := repl.manager.StorageManager.LoadSession(repl.session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Project Notes", saved.Name)
+}
+
 func TestREPL_loadSession(t *testing.T) {
 	repl, output, cleanup := setupTestREPL(t)
 	defer cleanup()
@@ -311,3 +334,53 @@ func TestREPL_listAttachments(t *testing.T) {
 	assert.Contains(t, outputStr, "file1.txt")
 	assert.Contains(t, outputStr, "image.png")
 }
+
+func TestREPL_toggleRender(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	require.NoError(t, repl.handleCommand(":render on"))
+	assert.True(t, repl.renderMarkdown)
+	require.NoError(t, repl.handleCommand(":render off"))
+	assert.False(t, repl.renderMarkdown)
+	require.NoError(t, repl.handleCommand(":render"))
+	assert.True(t, repl.renderMarkdown)
+	assert.Error(t, repl.handleCommand(":render sideways"))
+
+	assert.Contains(t, output.String(), "Markdown rendering: off")
+}
+
+func TestREPL_renderMarkdownResponse(t *testing.T) {
+	response := "# Result\n\n- item\n"
+
+	for _, stream := range []bool{false, true} {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+		repl.config.(*testConfig).values["stream"] = stream
+
+		provider := newMockProvider()
+		provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+			return &llm.Response{Content: response}, nil
+		}
+		provider.streamFunc = func(ctx context.Context, messages []domain.Message) (<-chan llm.StreamChunk, error) {
+			ch := make(chan llm.StreamChunk, 2)
+			ch <- llm.StreamChunk{Content: "# Res"}
+			ch <- llm.StreamChunk{Content: "ult\n\n- item\n"}
+			close(ch)
+			return ch, nil
+		}
+		repl.provider = provider
+
+		repl.renderMarkdown = true
+		require.NoError(t, repl.processMessage("render please"))
+		assert.Contains(t, output.String(), ui.ColorBold+ui.ColorBrightMagenta+"Result", "stream=%v", stream)
+		assert.NotContains(t, output.String(), "# Result", "stream=%v", stream)
+
+		// Raw text is printed once rendering is off, and history keeps the source
+		output.Reset()
+		repl.renderMarkdown = false
+		require.NoError(t, repl.processMessage("again"))
+		assert.Contains(t, output.String(), "# Result", "stream=%v", stream)
+		assert.Equal(t, response, repl.session.Conversation.Messages[1].Content)
+	}
+}
@@ -0,0 +1,64 @@
+// ABOUTME: Undo and redo commands for REPL
+// ABOUTME: Removes the most recent exchange with a one-level redo buffer
+
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// undoneExchange holds the messages removed by the last /undo
+type undoneExchange struct {
+	sessionID string
+	messages  []domain.Message
+}
+
+// cmdUndo removes the most recent user message and its response
+func (r *REPL) cmdUndo(args []string) error {
+	r.snapshotBefore(r.session, domain.SnapshotReasonBeforeUndo)
+	removed := r.session.Conversation.RemoveLastExchange()
+	if removed == nil {
+		fmt.Fprintln(r.writer, "Nothing to undo.")
+		return nil
+	}
+
+	// Only the latest undo can be redone
+	r.undone = &undoneExchange{sessionID: r.session.ID, messages: removed}
+	r.session.Updated = r.session.Conversation.Updated
+
+	logging.LogInfo("Undid last exchange", "sessionID", r.session.ID, "removed", len(removed))
+	fmt.Fprintf(r.writer, "Removed last exchange (%d messages): %s\n", len(removed), truncateForDisplay(removed[0].Content, 60))
+	fmt.Fprintln(r.writer, "Use /redo to restore it.")
+	return nil
+}
+
+// cmdRedo restores the exchange removed by the last /undo
+func (r *REPL) cmdRedo(args []string) error {
+	if r.undone == nil || r.undone.sessionID != r.session.ID {
+		fmt.Fprintln(r.writer, "Nothing to redo.")
+		return nil
+	}
+
+	for _, msg := range r.undone.messages {
+		r.session.Conversation.AddMessage(msg)
+	}
+	r.session.Updated = r.session.Conversation.Updated
+
+	logging.LogInfo("Redid last exchange", "sessionID", r.session.ID, "restored", len(r.undone.messages))
+	fmt.Fprintf(r.writer, "Restored %d messages.\n", len(r.undone.messages))
+	r.undone = nil
+	return nil
+}
+
+// truncateForDisplay shortens text to limit runes for single-line display
+func truncateForDisplay(text string, limit int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit]) + "..."
+}
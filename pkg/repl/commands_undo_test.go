@@ -0,0 +1,77 @@
+// ABOUTME: Tests for the REPL undo and redo commands
+// ABOUTME: Verifies exchange removal, one-level redo, and redo invalidation
+
+package repl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdUndoRedo(t *testing.T) {
+	t.Run("undo then redo restores exchange", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		addTestMessage(repl.session.Conversation, "user", "first", nil)
+		addTestMessage(repl.session.Conversation, "assistant", "reply one", nil)
+		addTestMessage(repl.session.Conversation, "user", "typo'd question", nil)
+		addTestMessage(repl.session.Conversation, "assistant", "confused reply", nil)
+
+		require.NoError(t, repl.handleCommand("/undo"))
+		require.Len(t, repl.session.Conversation.Messages, 2)
+		assert.Contains(t, output.String(), "Removed last exchange (2 messages): typo'd question")
+
+		require.NoError(t, repl.handleCommand("/redo"))
+		require.Len(t, repl.session.Conversation.Messages, 4)
+		assert.Equal(t, "confused reply", repl.session.Conversation.Messages[3].Content)
+
+		// The redo buffer holds one level only
+		output.Reset()
+		require.NoError(t, repl.handleCommand("/redo"))
+		assert.Contains(t, output.String(), "Nothing to redo.")
+	})
+
+	t.Run("only the latest undo can be redone", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		addTestMessage(repl.session.Conversation, "user", "first", nil)
+		addTestMessage(repl.session.Conversation, "assistant", "reply one", nil)
+		addTestMessage(repl.session.Conversation, "user", "second", nil)
+		addTestMessage(repl.session.Conversation, "assistant", "reply two", nil)
+
+		require.NoError(t, repl.handleCommand("/undo"))
+		require.NoError(t, repl.handleCommand("/undo"))
+		assert.Empty(t, repl.session.Conversation.Messages)
+
+		require.NoError(t, repl.handleCommand("/redo"))
+		require.Len(t, repl.session.Conversation.Messages, 2)
+		assert.Equal(t, "first", repl.session.Conversation.Messages[0].Content)
+	})
+
+	t.Run("new message clears redo", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		addTestMessage(repl.session.Conversation, "user", "bad prompt", nil)
+		addTestMessage(repl.session.Conversation, "assistant", "bad reply", nil)
+
+		require.NoError(t, repl.handleCommand("/undo"))
+		require.NoError(t, repl.processMessage("better prompt"))
+		require.NoError(t, repl.handleCommand("/redo"))
+
+		assert.Contains(t, output.String(), "Nothing to redo.")
+		assert.Equal(t, "better prompt", repl.session.Conversation.Messages[0].Content)
+	})
+
+	t.Run("nothing to undo", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		require.NoError(t, repl.handleCommand("/undo"))
+		assert.Contains(t, output.String(), "Nothing to undo.")
+	})
+}
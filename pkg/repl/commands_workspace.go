@@ -0,0 +1,121 @@
+// ABOUTME: Project workspace support for REPL
+// ABOUTME: Applies .magellai/workspace.yaml context, system prompt, and model to the session
+
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/workspace"
+)
+
+// findWorkspace returns the workspace enclosing the working directory, or nil
+// when there is none or workspace loading is disabled
+func findWorkspace(cfg ConfigInterface, writer io.Writer) *workspace.Workspace {
+	if !cfg.GetBool("repl.workspace.enabled") {
+		return nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		logging.LogWarn("Failed to get working directory for workspace lookup", "error", err)
+		return nil
+	}
+
+	ws, err := workspace.Find(dir)
+	if errors.Is(err, workspace.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		logging.LogWarn("Failed to load workspace", "error", err)
+		fmt.Fprintf(writer, "Warning: %v\n", err)
+		return nil
+	}
+	return ws
+}
+
+// applyWorkspace sets the session system prompt from the workspace prompt and
+// context files
+func (r *REPL) applyWorkspace(ws *workspace.Workspace) error {
+	prompt, loaded, err := ws.Prompt()
+	if err != nil {
+		return err
+	}
+
+	r.workspace = ws
+	if prompt != "" {
+		r.session.Conversation.SystemPrompt = prompt
+	}
+	if r.session.Metadata == nil {
+		r.session.Metadata = make(map[string]interface{})
+	}
+	r.session.Metadata["workspace"] = ws.Root
+
+	logging.LogInfo("Applied workspace", "name", ws.Name, "root", ws.Root, "contextFiles", len(loaded))
+	fmt.Fprintf(r.writer, "Workspace '%s' loaded (%d context files)\n", ws.Name, len(loaded))
+	return nil
+}
+
+// cmdWorkspace shows or reloads the project workspace
+func (r *REPL) cmdWorkspace(args []string) error {
+	if len(args) == 0 {
+		return r.showWorkspace()
+	}
+
+	switch args[0] {
+	case "show":
+		return r.showWorkspace()
+	case "reload":
+		var ws *workspace.Workspace
+		var err error
+		if r.workspace != nil {
+			ws, err = workspace.Load(r.workspace.Path)
+		} else {
+			var dir string
+			if dir, err = os.Getwd(); err == nil {
+				ws, err = workspace.Find(dir)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := r.applyWorkspace(ws); err != nil {
+			return err
+		}
+		if ws.Model != "" && ws.Model != r.session.Conversation.Model {
+			return r.switchModel([]string{ws.Model})
+		}
+		return nil
+	default:
+		return fmt.Errorf("usage: /workspace [show|reload]")
+	}
+}
+
+// showWorkspace prints the active workspace
+func (r *REPL) showWorkspace() error {
+	if r.workspace == nil {
+		fmt.Fprintf(r.writer, "No workspace loaded (create %s/%s in your project)\n", workspace.Dir, workspace.FileName)
+		return nil
+	}
+
+	ws := r.workspace
+	fmt.Fprintf(r.writer, "Workspace: %s\n", ws.Name)
+	fmt.Fprintf(r.writer, "File:      %s\n", ws.Path)
+	if ws.Model != "" {
+		fmt.Fprintf(r.writer, "Model:     %s\n", ws.Model)
+	}
+	files, err := ws.ContextFiles()
+	if err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(r.writer, "Context:   %s\n", strings.Join(files, "\n           "))
+	}
+	return nil
+}
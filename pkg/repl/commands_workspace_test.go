@@ -0,0 +1,69 @@
+// ABOUTME: Tests for project workspace support in the REPL
+// ABOUTME: Verifies workspace loading at startup and /workspace reload
+
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPLWorkspace(t *testing.T) {
+	root := t.TempDir()
+	wsFile := filepath.Join(root, workspace.Dir, workspace.FileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(wsFile), 0o755))
+	require.NoError(t, os.WriteFile(wsFile, []byte(`
+model: mock/workspace-model
+system_prompt: You are helping with the demo project.
+context:
+  - NOTES.md
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "NOTES.md"), []byte("Use tabs."), 0o644))
+
+	sub := filepath.Join(root, "src")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	t.Chdir(sub)
+
+	cfg := setupTestConfig()
+	cfg.values["repl.workspace.enabled"] = true
+	output := &bytes.Buffer{}
+	repl, err := NewREPL(&REPLOptions{
+		Config:     cfg,
+		StorageDir: t.TempDir(),
+		Reader:     bytes.NewBufferString(""),
+		Writer:     output,
+	})
+	require.NoError(t, err)
+	repl.provider = newMockProvider()
+
+	assert.Equal(t, "mock/workspace-model", repl.session.Conversation.Model)
+	assert.Contains(t, repl.session.Conversation.SystemPrompt, "You are helping with the demo project.")
+	assert.Contains(t, repl.session.Conversation.SystemPrompt, "Use tabs.")
+	assert.Contains(t, output.String(), "(1 context files)")
+
+	// Reload picks up edits to the workspace and its context files
+	require.NoError(t, os.WriteFile(filepath.Join(root, "NOTES.md"), []byte("Use spaces."), 0o644))
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/workspace reload"))
+	assert.Contains(t, repl.session.Conversation.SystemPrompt, "Use spaces.")
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/workspace"))
+	assert.Contains(t, output.String(), "Model:     mock/workspace-model")
+	assert.Contains(t, output.String(), "NOTES.md")
+}
+
+func TestREPLWorkspaceDisabled(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	assert.Nil(t, repl.workspace)
+	require.NoError(t, repl.handleCommand("/workspace"))
+	assert.Contains(t, output.String(), "No workspace loaded")
+}
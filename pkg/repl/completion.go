@@ -0,0 +1,63 @@
+// ABOUTME: Context-aware tab completion of REPL command arguments
+// ABOUTME: Completes file paths, session IDs, model names, and tags from live state
+
+package repl
+
+import (
+	"sort"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/ui"
+)
+
+// argumentCompleters returns the argument completers for commands that take
+// files, sessions, models, or tags
+func (r *REPL) argumentCompleters() map[string]ui.ArgumentCompleter {
+	return map[string]ui.ArgumentCompleter{
+		"/attach":  ui.CompleteFilePaths,
+		":attach":  ui.CompleteFilePaths,
+		"/index":   ui.CompleteFilePaths,
+		"/load":    r.completeSessionIDs,
+		"/switch":  r.completeSessionIDs,
+		"/merge":   r.completeSessionIDs,
+		":model":   r.completeModelNames,
+		"/compare": r.completeModelNames,
+		"/untag":   r.completeTags,
+	}
+}
+
+// completeSessionIDs lists stored session IDs, most recently updated first
+func (r *REPL) completeSessionIDs(string) []string {
+	sessions, err := r.manager.StorageManager.ListSessions()
+	if err != nil {
+		logging.LogDebug("Failed to list sessions for completion", "error", err)
+		return nil
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Updated.After(sessions[j].Updated) })
+
+	ids := make([]string, 0, len(sessions))
+	for _, info := range sessions {
+		ids = append(ids, info.ID)
+	}
+	return ids
+}
+
+// completeModelNames lists provider/model names from the models inventory
+func (r *REPL) completeModelNames(string) []string {
+	inventory := r.modelInventory()
+	if inventory == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(inventory.Models))
+	for _, model := range inventory.Models {
+		names = append(names, model.Provider+"/"+model.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeTags lists the current session's tags
+func (r *REPL) completeTags(string) []string {
+	return append([]string{}, r.session.Tags...)
+}
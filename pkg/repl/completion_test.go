@@ -0,0 +1,41 @@
+// ABOUTME: Tests for REPL argument completion
+// ABOUTME: Verifies session, model, and tag candidates come from live state
+
+package repl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPL_argumentCompleters(t *testing.T) {
+	inventoryFile, err := filepath.Abs(filepath.Join("..", "..", "models.json"))
+	require.NoError(t, err)
+	t.Setenv(models.InventoryEnvVar, inventoryFile)
+
+	repl, _, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	completers := repl.argumentCompleters()
+	for _, cmd := range []string{"/attach", "/load", "/switch", ":model", "/untag"} {
+		assert.Contains(t, completers, cmd)
+	}
+
+	t.Run("session IDs", func(t *testing.T) {
+		require.NoError(t, repl.manager.SaveSession(repl.session))
+		assert.Contains(t, completers["/load"](""), repl.session.ID)
+	})
+
+	t.Run("model names", func(t *testing.T) {
+		assert.Contains(t, completers[":model"](""), "openai/gpt-4o")
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		repl.session.AddTag("work")
+		assert.Equal(t, []string{"work"}, completers["/untag"](""))
+	})
+}
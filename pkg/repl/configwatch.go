@@ -0,0 +1,121 @@
+// ABOUTME: Applies config file changes to a running REPL when config.watch is enabled
+// ABOUTME: Prints a notice for each reload and follows changes to the default model
+
+package repl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/llm"
+)
+
+// maxNotifiedKeys is how many changed settings a reload notice lists
+const maxNotifiedKeys = 5
+
+// fileWatcher is implemented by configs that reload themselves when their
+// files change
+type fileWatcher interface {
+	WatchFiles(onChange func(config.FileChange)) (func() error, error)
+}
+
+// startConfigWatch starts reloading config files as they change, if
+// config.watch is set. It returns a function that stops watching.
+func (r *REPL) startConfigWatch() func() {
+	if !r.config.GetBool("config.watch") {
+		return func() {}
+	}
+	watcher, ok := r.config.(fileWatcher)
+	if !ok {
+		logging.LogDebug("Config does not support file watching")
+		return func() {}
+	}
+	stop, err := watcher.WatchFiles(r.onConfigChange)
+	if err != nil {
+		logging.LogWarn("Failed to watch config files", "error", err)
+		return func() {}
+	}
+	return func() {
+		if err := stop(); err != nil {
+			logging.LogWarn("Failed to stop watching config files", "error", err)
+		}
+	}
+}
+
+// onConfigChange reports a reload and marks it to be applied before the
+// next input is handled. It runs on the watcher's goroutine.
+func (r *REPL) onConfigChange(change config.FileChange) {
+	if change.Err != nil {
+		r.notify(r.colorFormatter.FormatWarning(fmt.Sprintf("Config file %s not reloaded: %v", change.Path, change.Err)))
+		return
+	}
+
+	keys := change.Keys
+	more := ""
+	if len(keys) > maxNotifiedKeys {
+		more = fmt.Sprintf(" (+%d more)", len(keys)-maxNotifiedKeys)
+		keys = keys[:maxNotifiedKeys]
+	}
+	r.notify(r.colorFormatter.FormatInfo(fmt.Sprintf("Config reloaded from %s: %s%s", change.Path, strings.Join(keys, ", "), more)))
+	r.configChanged.Store(true)
+}
+
+// notify prints a message while the prompt may be waiting for input
+func (r *REPL) notify(message string) {
+	var w io.Writer = r.writer
+	if r.readline != nil {
+		// Readline's stdout redraws the prompt below the message
+		w = r.readline.Instance.Stdout()
+	}
+	fmt.Fprintf(w, "\n%s\n", message)
+}
+
+// applyConfigChanges picks up settings the REPL keeps copies of after a
+// reload. Aliases and profile settings are read from the config when used,
+// so they need nothing here.
+func (r *REPL) applyConfigChanges() {
+	if !r.configChanged.Swap(false) {
+		return
+	}
+	r.promptTemplate = r.config.GetString("repl.prompt")
+
+	// Follow the default model unless the session picked its own
+	defaultModel := r.config.GetString("model.default")
+	if r.configModel == "" || defaultModel == r.configModel || r.session.Conversation.Model != r.configModel {
+		return
+	}
+	if err := r.useConfigModel(defaultModel); err != nil {
+		logging.LogWarn("Failed to switch to the new default model", "model", defaultModel, "error", err)
+		fmt.Fprintf(r.writer, "Warning: default model changed to %s but it could not be used: %v\n", defaultModel, err)
+		return
+	}
+	fmt.Fprintf(r.writer, "Default model changed, now using: %s\n", defaultModel)
+}
+
+// useConfigModel switches the session to the config's default model
+func (r *REPL) useConfigModel(model string) error {
+	providerType, modelName, ok := strings.Cut(model, "/")
+	if !ok {
+		return fmt.Errorf("invalid model format, expected provider/model")
+	}
+	var apiKey string
+	if keys, ok := r.config.(apiKeySource); ok {
+		apiKey = keys.GetProviderAPIKeyContext(r.baseContext(), providerType)
+	}
+	provider, err := llm.NewProvider(providerType, modelName, apiKey)
+	if err != nil {
+		return fmt.Errorf("failed to create provider: %w", err)
+	}
+
+	r.provider = provider
+	r.configModel = model
+	r.session.Conversation.Model = model
+	r.session.Conversation.Provider = providerType
+	r.sharedContext.Set(command.SharedContextModel, model)
+	r.sharedContext.Set(command.SharedContextProvider, providerType)
+	return nil
+}
@@ -0,0 +1,78 @@
+// ABOUTME: Tests for applying config file reloads to a running REPL
+// ABOUTME: Verifies reload notices and following changes to the default model
+
+package repl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lexlapax/magellai/pkg/config"
+)
+
+func TestREPL_onConfigChange(t *testing.T) {
+	t.Run("lists changed settings", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		repl.onConfigChange(config.FileChange{
+			Path: "/home/me/.config/magellai/config.yaml",
+			Keys: []string{"a", "b", "c", "d", "e", "f", "g"},
+		})
+		assert.Contains(t, output.String(), "Config reloaded from /home/me/.config/magellai/config.yaml: a, b, c, d, e (+2 more)")
+		assert.True(t, repl.configChanged.Load())
+	})
+
+	t.Run("reports reload errors", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		repl.onConfigChange(config.FileChange{Path: "config.yaml", Err: errors.New("bad yaml")})
+		assert.Contains(t, output.String(), "Config file config.yaml not reloaded: bad yaml")
+		assert.False(t, repl.configChanged.Load())
+	})
+}
+
+func TestREPL_applyConfigChanges(t *testing.T) {
+	t.Run("follows the default model", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+		assert.Equal(t, "mock/test-model", repl.configModel)
+
+		repl.config.(*testConfig).values["model.default"] = "mock/other-model"
+		repl.config.(*testConfig).values["repl.prompt"] = "{model}> "
+		repl.applyConfigChanges()
+		assert.Equal(t, "mock/test-model", repl.session.Conversation.Model, "nothing applies until a reload is reported")
+
+		repl.configChanged.Store(true)
+		repl.applyConfigChanges()
+		assert.Equal(t, "mock/other-model", repl.session.Conversation.Model)
+		assert.Equal(t, "mock/other-model", repl.configModel)
+		assert.Equal(t, "{model}> ", repl.promptTemplate)
+		assert.Contains(t, output.String(), "Default model changed, now using: mock/other-model")
+		assert.False(t, repl.configChanged.Load())
+	})
+
+	t.Run("keeps an explicitly chosen model", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		repl.session.Conversation.Model = "mock/chosen-model"
+		repl.config.(*testConfig).values["model.default"] = "mock/other-model"
+		repl.configChanged.Store(true)
+		repl.applyConfigChanges()
+		assert.Equal(t, "mock/chosen-model", repl.session.Conversation.Model)
+	})
+}
+
+func TestREPL_startConfigWatch(t *testing.T) {
+	repl, _, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	// Disabled by default, and test configs cannot watch files
+	repl.startConfigWatch()()
+	repl.config.(*testConfig).values["config.watch"] = true
+	repl.startConfigWatch()()
+}
@@ -49,6 +49,7 @@ func GetHistory(conv *domain.Conversation) []domain.Message {
 func AddMessageToConversation(conv *domain.Conversation, role, content string, attachments []domain.Attachment) {
 	msg := NewMessage(role, content, attachments)
 	conv.AddMessage(msg)
+	domain.PublishMessageAdded(conv.ID, msg)
 }
 
 // ResetConversation clears all messages from a conversation
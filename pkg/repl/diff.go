@@ -0,0 +1,179 @@
+// ABOUTME: Conversation diff display for REPL
+// ABOUTME: Shows colored message diffs for /diff, branch switches, and merges
+
+package repl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/ui"
+)
+
+// diffPreviewLines caps the lines shown for each added or removed message
+const diffPreviewLines = 6
+
+// lineOp is one line of a line-level diff: ' ', '-', or '+'
+type lineOp struct {
+	op   byte
+	text string
+}
+
+// cmdDiff compares two sessions message by message.
+//
+//	/diff <id>        compare the current session with another
+//	/diff <idA> <idB> compare two sessions
+func (r *REPL) cmdDiff(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: /diff <sessionA> [sessionB]")
+	}
+
+	oldSession := r.session
+	if len(args) == 2 {
+		var err error
+		if oldSession, err = r.manager.StorageManager.LoadSession(args[0]); err != nil {
+			return fmt.Errorf("failed to load session %s: %w", args[0], err)
+		}
+	}
+	newSession, err := r.manager.StorageManager.LoadSession(args[len(args)-1])
+	if err != nil {
+		return fmt.Errorf("failed to load session %s: %w", args[len(args)-1], err)
+	}
+
+	r.writeSessionDiff(r.writer, oldSession, newSession)
+	return nil
+}
+
+// writeSessionDiff prints the message diff from one session to another
+func (r *REPL) writeSessionDiff(w io.Writer, oldSession, newSession *domain.Session) {
+	diffs := domain.DiffMessages(oldSession.Conversation.Messages, newSession.Conversation.Messages)
+	if !domain.HasChanges(diffs) {
+		fmt.Fprintf(w, "No differences between %s and %s\n", sessionLabel(oldSession), sessionLabel(newSession))
+		return
+	}
+
+	colors := r.colorFormatter
+	if colors == nil {
+		colors = ui.NewColorFormatter(false, nil)
+	}
+	red := func(s string) string { return colors.Format(ui.ColorRed, s) }
+	green := func(s string) string { return colors.Format(ui.ColorGreen, s) }
+	cyan := func(s string) string { return colors.Format(ui.ColorCyan, s) }
+	dim := func(s string) string { return colors.Format(ui.ColorDim, s) }
+
+	fmt.Fprintln(w, red("--- "+sessionLabel(oldSession)))
+	fmt.Fprintln(w, green("+++ "+sessionLabel(newSession)))
+
+	added, removed, changed, unchanged := 0, 0, 0, 0
+	flushUnchanged := func() {
+		if unchanged > 0 {
+			fmt.Fprintln(w, dim(fmt.Sprintf("  ... %d unchanged %s", unchanged, pluralize("message", unchanged))))
+			unchanged = 0
+		}
+	}
+
+	for _, d := range diffs {
+		switch d.Op {
+		case domain.DiffEqual:
+			unchanged++
+		case domain.DiffRemoved:
+			flushUnchanged()
+			removed++
+			fmt.Fprintln(w, cyan(fmt.Sprintf("@@ message %d removed (%s) @@", d.OldIndex+1, d.Old.Role)))
+			for _, line := range previewLines(d.Old.Content) {
+				fmt.Fprintln(w, red("- "+line))
+			}
+		case domain.DiffAdded:
+			flushUnchanged()
+			added++
+			fmt.Fprintln(w, cyan(fmt.Sprintf("@@ message %d added (%s) @@", d.NewIndex+1, d.New.Role)))
+			for _, line := range previewLines(d.New.Content) {
+				fmt.Fprintln(w, green("+ "+line))
+			}
+		case domain.DiffChanged:
+			flushUnchanged()
+			changed++
+			fmt.Fprintln(w, cyan(fmt.Sprintf("@@ message %d changed (%s) @@", d.NewIndex+1, d.New.Role)))
+			for _, line := range diffLines(strings.Split(d.Old.Content, "\n"), strings.Split(d.New.Content, "\n")) {
+				switch line.op {
+				case '-':
+					fmt.Fprintln(w, red("- "+line.text))
+				case '+':
+					fmt.Fprintln(w, green("+ "+line.text))
+				default:
+					fmt.Fprintln(w, "  "+line.text)
+				}
+			}
+		}
+	}
+	flushUnchanged()
+
+	fmt.Fprintf(w, "%d added, %d removed, %d changed\n", added, removed, changed)
+}
+
+// sessionLabel names a session for diff headers
+func sessionLabel(s *domain.Session) string {
+	name := s.Name
+	if s.BranchName != "" {
+		name = s.BranchName
+	}
+	if name == "" {
+		return s.ID
+	}
+	return fmt.Sprintf("%s (%s)", name, s.ID)
+}
+
+// previewLines returns the first lines of content, noting how many were cut
+func previewLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	if len(lines) <= diffPreviewLines {
+		return lines
+	}
+	cut := len(lines) - diffPreviewLines
+	return append(lines[:diffPreviewLines:diffPreviewLines], fmt.Sprintf("... (%d more %s)", cut, pluralize("line", cut)))
+}
+
+// pluralize adds an s to word unless n is 1
+func pluralize(word string, n int) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// diffLines computes a line-level diff using the longest common subsequence
+func diffLines(a, b []string) []lineOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []lineOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, lineOp{' ', a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, lineOp{'+', b[j]})
+			j++
+		default:
+			ops = append(ops, lineOp{'-', a[i]})
+			i++
+		}
+	}
+	return ops
+}
@@ -0,0 +1,68 @@
+// ABOUTME: Tests for conversation diff display
+// ABOUTME: Verifies /diff output and the diff shown when switching branches
+
+package repl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPL_cmdDiff(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	conv := repl.session.Conversation
+	addTestMessage(conv, "user", "What is Go?", nil)
+	addTestMessage(conv, "assistant", "A language.\nIt is compiled.", nil)
+	require.NoError(t, repl.manager.SaveSession(repl.session))
+
+	branch, err := repl.session.CreateBranch(repl.manager.GenerateSessionID(), "alt", 1)
+	require.NoError(t, err)
+	addTestMessage(branch.Conversation, "assistant", "A language.\nIt is garbage collected.", nil)
+	addTestMessage(branch.Conversation, "user", "Thanks", nil)
+	require.NoError(t, repl.manager.SaveSession(branch))
+
+	t.Run("current session against a branch", func(t *testing.T) {
+		output.Reset()
+		require.NoError(t, repl.cmdDiff([]string{branch.ID}))
+
+		out := output.String()
+		assert.Contains(t, out, "+++ alt ("+branch.ID+")")
+		assert.Contains(t, out, "... 1 unchanged message")
+		assert.Contains(t, out, "@@ message 2 changed (assistant) @@")
+		assert.Contains(t, out, "  A language.")
+		assert.Contains(t, out, "- It is compiled.")
+		assert.Contains(t, out, "+ It is garbage collected.")
+		assert.Contains(t, out, "@@ message 3 added (user) @@")
+		assert.Contains(t, out, "1 added, 0 removed, 1 changed")
+	})
+
+	t.Run("identical sessions", func(t *testing.T) {
+		output.Reset()
+		require.NoError(t, repl.cmdDiff([]string{repl.session.ID, repl.session.ID}))
+		assert.Contains(t, output.String(), "No differences")
+	})
+
+	t.Run("switch shows the diff", func(t *testing.T) {
+		output.Reset()
+		require.NoError(t, repl.cmdSwitch([]string{branch.ID}))
+		assert.Contains(t, output.String(), "1 added, 0 removed, 1 changed")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		assert.Error(t, repl.cmdDiff(nil))
+		assert.Error(t, repl.cmdDiff([]string{"missing"}))
+	})
+}
+
+func TestPreviewLines(t *testing.T) {
+	long := strings.Repeat("line\n", 9) + "last"
+	lines := previewLines(long)
+	require.Len(t, lines, diffPreviewLines+1)
+	assert.Equal(t, "... (4 more lines)", lines[diffPreviewLines])
+	assert.Equal(t, []string{"short"}, previewLines("short"))
+}
@@ -48,4 +48,13 @@ var (
 
 	// ErrCommandNotFound indicates a command was not found
 	ErrCommandNotFound = errors.New("command not found")
+
+	// ErrGenerationCancelled indicates the user cancelled an in-flight generation
+	ErrGenerationCancelled = errors.New("generation cancelled")
+
+	// ErrInvalidMessageIndex indicates a message number that does not refer to a usable message
+	ErrInvalidMessageIndex = errors.New("invalid message index")
+
+	// ErrSessionLimitExceeded indicates a message would take the session past its size limits
+	ErrSessionLimitExceeded = errors.New("session limit exceeded")
 )
@@ -87,6 +87,21 @@ func TestErrorConstants(t *testing.T) {
 			err:      ErrCommandNotFound,
 			expected: "command not found",
 		},
+		{
+			name:     "ErrGenerationCancelled",
+			err:      ErrGenerationCancelled,
+			expected: "generation cancelled",
+		},
+		{
+			name:     "ErrInvalidMessageIndex",
+			err:      ErrInvalidMessageIndex,
+			expected: "invalid message index",
+		},
+		{
+			name:     "ErrSessionLimitExceeded",
+			err:      ErrSessionLimitExceeded,
+			expected: "session limit exceeded",
+		},
 	}
 
 	for _, tt := range tests {
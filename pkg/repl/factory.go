@@ -17,8 +17,16 @@ func init() {
 			PromptStyle: opts.PromptStyle,
 			SessionID:   opts.SessionID,
 			Model:       opts.Model,
+			Template:    opts.Template,
+			Ephemeral:   opts.Ephemeral,
+			ReadOnly:    opts.ReadOnly,
+			RCFile:      opts.RCFile,
+			NoRC:        opts.NoRC,
+			Script:      opts.Script,
+			Quiet:       opts.Quiet,
 			Writer:      opts.Writer,
 			Reader:      opts.Reader,
+			Context:     opts.Context,
 		}
 
 		// Create internal REPL
@@ -0,0 +1,64 @@
+// ABOUTME: Fenced code block continuation for REPL input
+// ABOUTME: Keeps reading lines after an opening ``` until the block is closed
+
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/ui"
+)
+
+// continuationPrompt is shown while a fenced block is open
+const continuationPrompt = "... "
+
+// hasOpenFence reports whether text opens a ``` fenced block it doesn't close
+func hasOpenFence(text string) bool {
+	open := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			open = !open
+		}
+	}
+	return open
+}
+
+// readFencedBlock continues input that opened a fenced block, reading lines
+// until the fence is closed. Ctrl-C discards the whole message; end of input
+// sends what was typed.
+func (r *REPL) readFencedBlock(input string) (string, error) {
+	lines := []string{input}
+	for hasOpenFence(strings.Join(lines, "\n")) {
+		line, err := r.readContinuationLine()
+		if errors.Is(err, ui.ErrInterrupt) {
+			logging.LogDebug("Interrupt discarded fenced block", "lines", len(lines))
+			return "", nil
+		}
+		if err == io.EOF {
+			lines = append(lines, line)
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// readContinuationLine reads one line of a fenced block
+func (r *REPL) readContinuationLine() (string, error) {
+	if r.readline != nil {
+		r.readline.SetPrompt(continuationPrompt)
+		defer r.readline.SetPrompt(r.currentPrompt())
+		return r.readline.ReadLine()
+	}
+
+	fmt.Fprint(r.writer, continuationPrompt)
+	line, err := r.reader.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
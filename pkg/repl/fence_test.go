@@ -0,0 +1,44 @@
+// ABOUTME: Tests for fenced code block continuation
+// ABOUTME: Verifies open fences are detected and continued until closed
+
+package repl
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasOpenFence(t *testing.T) {
+	assert.False(t, hasOpenFence("plain text"))
+	assert.True(t, hasOpenFence("look at this:\n```go"))
+	assert.True(t, hasOpenFence("```go"))
+	assert.False(t, hasOpenFence("```go\nfmt.Println()\n```"))
+	assert.True(t, hasOpenFence("```\na\n```\n```python"))
+}
+
+func TestREPL_readFencedBlock(t *testing.T) {
+	t.Run("reads until the fence closes", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+		repl.reader = bufio.NewReader(strings.NewReader("func main() {\n}\n```\nnot read\n"))
+
+		message, err := repl.readFencedBlock("```go")
+		require.NoError(t, err)
+		assert.Equal(t, "```go\nfunc main() {\n}\n```", message)
+		assert.Contains(t, output.String(), continuationPrompt)
+	})
+
+	t.Run("end of input sends what was typed", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+		repl.reader = bufio.NewReader(strings.NewReader("x := 1"))
+
+		message, err := repl.readFencedBlock("```go")
+		require.NoError(t, err)
+		assert.Equal(t, "```go\nx := 1", message)
+	})
+}
@@ -0,0 +1,101 @@
+// ABOUTME: Inline @file references in REPL messages
+// ABOUTME: Attaches referenced files and replaces the tokens with short references
+
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// maxInlineFileSize is the largest text file inlined as fenced code; larger
+// files are sent as regular file attachments
+const maxInlineFileSize = 256 * 1024
+
+// fileRefPattern matches @path tokens at the start of the input or after whitespace
+var fileRefPattern = regexp.MustCompile(`(^|\s)@(\S+)`)
+
+// expandFileReferences replaces @path tokens that name existing files with a
+// short [file: path] reference and returns attachments carrying the files.
+// Text files are inlined as fenced code; other files are attached as-is.
+// Tokens that do not name a file (such as @mentions) are left untouched.
+func expandFileReferences(message string) (string, []domain.Attachment, error) {
+	var attachments []domain.Attachment
+	var firstErr error
+
+	expanded := fileRefPattern.ReplaceAllStringFunc(message, func(match string) string {
+		if firstErr != nil {
+			return match
+		}
+		groups := fileRefPattern.FindStringSubmatch(match)
+		prefix, token := groups[1], groups[2]
+
+		path, trailing := resolveFileRef(token)
+		if path == "" {
+			return match
+		}
+
+		attachment, err := fileRefAttachment(path)
+		if err != nil {
+			firstErr = fmt.Errorf("failed to attach @%s: %w", path, err)
+			return match
+		}
+		attachments = append(attachments, attachment)
+		logging.LogDebug("Expanded file reference", "path", path, "type", attachment.Type)
+		return fmt.Sprintf("%s[file: %s]%s", prefix, path, trailing)
+	})
+	if firstErr != nil {
+		return message, nil, firstErr
+	}
+	return expanded, attachments, nil
+}
+
+// resolveFileRef returns the file a token names, allowing trailing sentence
+// punctuation after the path, and that punctuation. It returns "" when the
+// token is not an existing regular file.
+func resolveFileRef(token string) (string, string) {
+	for trimmed := token; trimmed != ""; trimmed = trimmed[:len(trimmed)-1] {
+		if info, err := os.Stat(trimmed); err == nil && !info.IsDir() {
+			return trimmed, token[len(trimmed):]
+		}
+		if !strings.ContainsRune(".,;:!?)'\"", rune(trimmed[len(trimmed)-1])) {
+			break
+		}
+	}
+	return "", ""
+}
+
+// fileRefAttachment builds the attachment for a referenced file
+func fileRefAttachment(path string) (domain.Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+
+	if len(data) > maxInlineFileSize || !isTextContent(data) {
+		return createFileAttachmentFromPath(path)
+	}
+
+	lang := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	content := fmt.Sprintf("Content of %s:\n\n```%s\n%s\n```", path, lang, strings.TrimRight(string(data), "\n"))
+	return domain.Attachment{
+		Type:     domain.AttachmentTypeText,
+		Content:  []byte(content),
+		FilePath: path,
+		Name:     filepath.Base(path),
+		MimeType: "text/plain",
+	}, nil
+}
+
+// isTextContent reports whether data looks like UTF-8 text
+func isTextContent(data []byte) bool {
+	return utf8.Valid(data) && !bytes.ContainsRune(data, 0)
+}
@@ -0,0 +1,77 @@
+// ABOUTME: Tests for inline @file references in REPL messages
+// ABOUTME: Verifies text inlining, binary attachment, and untouched mentions
+
+package repl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandFileReferences(t *testing.T) {
+	dir := t.TempDir()
+	goFile := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(goFile, []byte("package main\n"), 0o644))
+	binFile := filepath.Join(dir, "data.bin")
+	require.NoError(t, os.WriteFile(binFile, []byte{0x00, 0x01, 0x02}, 0o644))
+
+	t.Run("text file inlined as fenced code", func(t *testing.T) {
+		message, attachments, err := expandFileReferences("review @" + goFile + ", please")
+		require.NoError(t, err)
+		assert.Equal(t, "review [file: "+goFile+"], please", message)
+		require.Len(t, attachments, 1)
+		assert.Equal(t, domain.AttachmentTypeText, attachments[0].Type)
+		assert.Contains(t, string(attachments[0].Content), "```go\npackage main\n```")
+	})
+
+	t.Run("binary file attached", func(t *testing.T) {
+		message, attachments, err := expandFileReferences("@" + binFile)
+		require.NoError(t, err)
+		assert.Equal(t, "[file: "+binFile+"]", message)
+		require.Len(t, attachments, 1)
+		assert.Equal(t, domain.AttachmentTypeFile, attachments[0].Type)
+	})
+
+	t.Run("mentions and missing files untouched", func(t *testing.T) {
+		input := "ask @alice about @" + filepath.Join(dir, "missing.go") + " or user@example.com"
+		message, attachments, err := expandFileReferences(input)
+		require.NoError(t, err)
+		assert.Equal(t, input, message)
+		assert.Empty(t, attachments)
+	})
+}
+
+func TestProcessMessageFileReference(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	require.NoError(t, os.WriteFile(notes, []byte("# Notes\n"), 0o644))
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	var sent []domain.Message
+	provider := newMockProvider()
+	provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+		sent = messages
+		return &llm.Response{Content: "Summary"}, nil
+	}
+	repl.provider = provider
+
+	require.NoError(t, repl.processMessage("summarize @"+notes))
+	assert.Contains(t, output.String(), "Attached "+notes)
+
+	stored := repl.session.Conversation.Messages[0]
+	assert.Equal(t, "summarize [file: "+notes+"]", stored.Content)
+	require.Len(t, stored.Attachments, 1)
+	assert.Contains(t, string(stored.Attachments[0].Content), "# Notes")
+
+	last := sent[len(sent)-1]
+	require.Len(t, last.Attachments, 1)
+}
@@ -0,0 +1,105 @@
+// ABOUTME: Ctrl-C handling for the REPL
+// ABOUTME: First press cancels generation or clears input; a quick second press exits
+
+package repl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// doubleInterruptWindow is how soon a second Ctrl-C must follow the first to exit
+const doubleInterruptWindow = 2 * time.Second
+
+// interruptAction describes how the REPL responds to a Ctrl-C
+type interruptAction int
+
+const (
+	// interruptCancelled means an in-flight generation was cancelled
+	interruptCancelled interruptAction = iota
+	// interruptHint means the user was told how to exit
+	interruptHint
+	// interruptExit means the REPL should exit
+	interruptExit
+)
+
+// baseContext returns the context the REPL was started with
+func (r *REPL) baseContext() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// beginGeneration returns a cancellable context for an LLM request.
+// A Ctrl-C while the request runs cancels the context instead of exiting,
+// as does canceling the REPL's own context.
+func (r *REPL) beginGeneration() context.Context {
+	ctx, cancel := context.WithCancel(r.baseContext())
+
+	r.interruptMu.Lock()
+	r.cancelGeneration = cancel
+	r.interruptMu.Unlock()
+
+	return ctx
+}
+
+// endGeneration releases the context created by beginGeneration
+func (r *REPL) endGeneration() {
+	r.interruptMu.Lock()
+	defer r.interruptMu.Unlock()
+
+	if r.cancelGeneration != nil {
+		r.cancelGeneration()
+		r.cancelGeneration = nil
+	}
+}
+
+// handleInterrupt decides what a Ctrl-C does: cancel the running generation,
+// or exit when it closely follows a previous Ctrl-C, or otherwise print a hint
+func (r *REPL) handleInterrupt() interruptAction {
+	r.interruptMu.Lock()
+	defer r.interruptMu.Unlock()
+
+	now := time.Now()
+	defer func() { r.lastInterrupt = now }()
+
+	if r.cancelGeneration != nil {
+		logging.LogInfo("Interrupt received, cancelling generation")
+		r.cancelGeneration()
+		r.cancelGeneration = nil
+		return interruptCancelled
+	}
+
+	if !r.lastInterrupt.IsZero() && now.Sub(r.lastInterrupt) < doubleInterruptWindow {
+		logging.LogInfo("Second interrupt received, exiting")
+		return interruptExit
+	}
+
+	return interruptHint
+}
+
+// printInterruptHint tells the user how to exit after a single Ctrl-C
+func (r *REPL) printInterruptHint() {
+	hint := "(press Ctrl-C again to exit, or type /exit)"
+	if r.colorFormatter.Enabled() {
+		hint = r.colorFormatter.FormatInfo(hint)
+	}
+	fmt.Fprintf(r.writer, "\n%s\n", hint)
+}
+
+// saveOnInterruptExit persists the session before exiting on double Ctrl-C
+func (r *REPL) saveOnInterruptExit() {
+	if r.manager.ReadOnly() {
+		fmt.Fprintln(r.writer, "\nGoodbye!")
+		return
+	}
+	if err := r.manager.SaveSession(r.session); err != nil {
+		logging.LogError(err, "Failed to save session on interrupt exit")
+		fmt.Fprintf(r.writer, "Warning: Failed to save session: %v\n", err)
+	}
+	fmt.Fprintln(r.writer, "\nGoodbye!")
+}
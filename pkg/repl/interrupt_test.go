@@ -0,0 +1,138 @@
+// ABOUTME: Tests for REPL Ctrl-C handling
+// ABOUTME: Verifies generation cancellation and double-press exit detection
+
+package repl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleInterrupt(t *testing.T) {
+	t.Run("single press prints hint, double press exits", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		assert.Equal(t, interruptHint, repl.handleInterrupt())
+		assert.Equal(t, interruptExit, repl.handleInterrupt())
+	})
+
+	t.Run("slow second press only hints", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		assert.Equal(t, interruptHint, repl.handleInterrupt())
+		repl.lastInterrupt = time.Now().Add(-2 * doubleInterruptWindow)
+		assert.Equal(t, interruptHint, repl.handleInterrupt())
+	})
+
+	t.Run("press during generation cancels it", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		ctx := repl.beginGeneration()
+		assert.Equal(t, interruptCancelled, repl.handleInterrupt())
+		assert.Error(t, ctx.Err())
+		repl.endGeneration()
+	})
+}
+
+func TestProcessMessageCancelled(t *testing.T) {
+	t.Run("non-streaming", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		started := make(chan struct{})
+		provider := newMockProvider()
+		provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		repl.provider = provider
+
+		go func() {
+			<-started
+			repl.handleInterrupt()
+		}()
+
+		err := repl.processMessage("long question")
+		require.ErrorIs(t, err, ErrGenerationCancelled)
+		assert.Len(t, repl.session.Conversation.Messages, 1)
+	})
+
+	t.Run("streaming keeps partial response", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+		repl.config.(*testConfig).values["stream"] = true
+
+		provider := newMockProvider()
+		provider.streamFunc = func(ctx context.Context, messages []domain.Message) (<-chan llm.StreamChunk, error) {
+			ch := make(chan llm.StreamChunk)
+			go func() {
+				ch <- llm.StreamChunk{Content: "partial"}
+				repl.handleInterrupt()
+				<-ctx.Done()
+			}()
+			return ch, nil
+		}
+		repl.provider = provider
+
+		err := repl.processMessage("long question")
+		require.ErrorIs(t, err, ErrGenerationCancelled)
+		require.Len(t, repl.session.Conversation.Messages, 2)
+		assert.Equal(t, "partial", repl.session.Conversation.Messages[1].Content)
+	})
+}
+
+func TestREPLContext(t *testing.T) {
+	type ctxKey struct{}
+
+	t.Run("requests and commands use the REPL's context", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+		repl.ctx = context.WithValue(context.Background(), ctxKey{}, "caller")
+
+		provider := newMockProvider()
+		provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+			assert.Equal(t, "caller", ctx.Value(ctxKey{}))
+			return &llm.Response{Content: "ok"}, nil
+		}
+		repl.provider = provider
+		require.NoError(t, repl.processMessage("question"))
+
+		var seen interface{}
+		require.NoError(t, repl.registry.Register(command.NewSimpleCommand(
+			&command.Metadata{Name: "ctxcheck"},
+			func(ctx context.Context, exec *command.ExecutionContext) error {
+				seen = ctx.Value(ctxKey{})
+				return nil
+			})))
+		require.NoError(t, repl.handleCommand("/ctxcheck"))
+		assert.Equal(t, "caller", seen)
+	})
+
+	t.Run("canceling the REPL's context cancels the generation", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+		ctx, cancel := context.WithCancel(context.Background())
+		repl.ctx = ctx
+
+		provider := newMockProvider()
+		provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+			cancel()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		repl.provider = provider
+
+		assert.ErrorIs(t, repl.processMessage("long question"), ErrGenerationCancelled)
+	})
+}
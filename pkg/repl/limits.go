@@ -0,0 +1,172 @@
+// ABOUTME: Per-session size limits on message count and attachment bytes
+// ABOUTME: Warns as a session nears its limits and blocks or summarizes when a message would exceed them
+
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// What the REPL does when a message would take a session past a limit
+const (
+	limitActionBlock     = "block"     // Refuse the message
+	limitActionSummarize = "summarize" // Summarize the session onto a new branch, then send it
+)
+
+// defaultLimitWarnAt is the share of a limit at which warnings start
+const defaultLimitWarnAt = 0.8
+
+// sessionLimits are the session.limits settings. A zero limit is unlimited.
+type sessionLimits struct {
+	maxMessages        int
+	maxAttachmentBytes int64
+	warnAt             float64
+	onExceed           string
+}
+
+// sessionSize is how much of its limits a session uses
+type sessionSize struct {
+	messages        int
+	attachmentBytes int64
+}
+
+// sessionLimits reads the limits from config
+func (r *REPL) sessionLimits() sessionLimits {
+	limits := sessionLimits{warnAt: defaultLimitWarnAt, onExceed: limitActionBlock}
+	if n, ok := r.configNumber("session.limits.max_messages"); ok && n > 0 {
+		limits.maxMessages = int(n)
+	}
+	if n, ok := r.configNumber("session.limits.max_attachment_bytes"); ok && n > 0 {
+		limits.maxAttachmentBytes = int64(n)
+	}
+	if n, ok := r.configNumber("session.limits.warn_at"); ok && n > 0 && n <= 1 {
+		limits.warnAt = n
+	}
+	if action := r.config.GetString("session.limits.on_exceed"); action == limitActionSummarize {
+		limits.onExceed = action
+	}
+	return limits
+}
+
+// configNumber reads a numeric config value, which may be given as a string
+func (r *REPL) configNumber(key string) (float64, bool) {
+	switch v := r.config.Get(key).(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// enabled reports whether any limit is set
+func (l sessionLimits) enabled() bool {
+	return l.maxMessages > 0 || l.maxAttachmentBytes > 0
+}
+
+// exceeded describes the limits size is over, or returns "" if it is within them
+func (l sessionLimits) exceeded(size sessionSize) string {
+	var over []string
+	if l.maxMessages > 0 && size.messages > l.maxMessages {
+		over = append(over, fmt.Sprintf("%d messages (limit %d)", size.messages, l.maxMessages))
+	}
+	if l.maxAttachmentBytes > 0 && size.attachmentBytes > l.maxAttachmentBytes {
+		over = append(over, fmt.Sprintf("%s of attachments (limit %s)", formatBytes(size.attachmentBytes), formatBytes(l.maxAttachmentBytes)))
+	}
+	return strings.Join(over, " and ")
+}
+
+// nearing describes the limits size has reached warnAt of
+func (l sessionLimits) nearing(size sessionSize) string {
+	var near []string
+	if l.maxMessages > 0 && float64(size.messages) >= l.warnAt*float64(l.maxMessages) {
+		near = append(near, fmt.Sprintf("%d of %d messages", size.messages, l.maxMessages))
+	}
+	if l.maxAttachmentBytes > 0 && float64(size.attachmentBytes) >= l.warnAt*float64(l.maxAttachmentBytes) {
+		near = append(near, fmt.Sprintf("%s of %s of attachments", formatBytes(size.attachmentBytes), formatBytes(l.maxAttachmentBytes)))
+	}
+	return strings.Join(near, " and ")
+}
+
+// sizeOf returns the size of a conversation
+func sizeOf(conv *domain.Conversation) sessionSize {
+	size := sessionSize{messages: len(conv.Messages)}
+	for _, msg := range conv.Messages {
+		size.attachmentBytes += attachmentBytes(msg.Attachments)
+	}
+	return size
+}
+
+// attachmentBytes returns the size of attachments, counting content that
+// is stored separately and not loaded by its recorded size
+func attachmentBytes(attachments []domain.Attachment) int64 {
+	var total int64
+	for _, att := range attachments {
+		if len(att.Content) > 0 {
+			total += int64(len(att.Content))
+		} else {
+			total += att.Size
+		}
+	}
+	return total
+}
+
+// enforceSessionLimits checks that a message with attachments, and the
+// response to it, fit within the session's limits. When they do not, the
+// message is refused or, with on_exceed set to summarize, the session is
+// summarized onto a new branch first.
+func (r *REPL) enforceSessionLimits(attachments []domain.Attachment) error {
+	limits := r.sessionLimits()
+	if !limits.enabled() {
+		return nil
+	}
+	next := func() sessionSize {
+		size := sizeOf(r.session.Conversation)
+		size.messages += 2
+		size.attachmentBytes += attachmentBytes(attachments)
+		return size
+	}
+
+	over := limits.exceeded(next())
+	if over == "" {
+		return nil
+	}
+	if limits.onExceed == limitActionSummarize {
+		logging.LogInfo("Session limit reached, summarizing", "session_id", r.session.ID, "exceeded", over)
+		fmt.Fprintf(r.writer, "This message would take the session to %s.\n", over)
+		if err := r.cmdSummarize(nil); err != nil {
+			return fmt.Errorf("%w: %s, and summarizing failed: %v", ErrSessionLimitExceeded, over, err)
+		}
+		if over = limits.exceeded(next()); over == "" {
+			return nil
+		}
+	}
+	logging.LogInfo("Session limit reached", "session_id", r.session.ID, "exceeded", over)
+	return fmt.Errorf("%w: this message would take the session to %s; start a new session with /new or shorten this one with /summarize",
+		ErrSessionLimitExceeded, over)
+}
+
+// warnSessionLimits warns when the session is close to its limits
+func (r *REPL) warnSessionLimits() {
+	limits := r.sessionLimits()
+	if !limits.enabled() {
+		return
+	}
+	if near := limits.nearing(sizeOf(r.session.Conversation)); near != "" {
+		action := "messages that go past the limit will be refused"
+		if limits.onExceed == limitActionSummarize {
+			action = "a message that goes past the limit summarizes it onto a new branch first"
+		}
+		fmt.Fprintf(r.writer, "Warning: this session has %s; %s.\n", near, action)
+	}
+}
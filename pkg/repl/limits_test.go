@@ -0,0 +1,81 @@
+// ABOUTME: Tests for per-session size limits in the REPL
+// ABOUTME: Verifies warnings near a limit, refusing messages past it, and summarizing instead
+
+package repl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionLimits_Messages(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+	require.NoError(t, repl.config.SetValue("session.limits.max_messages", 4))
+
+	conv := repl.session.Conversation
+	addTestMessage(conv, "user", "Hi", nil)
+	addTestMessage(conv, "assistant", "Hello", nil)
+
+	// The exchange fills the session, so the REPL warns
+	require.NoError(t, repl.processMessage("How are you?"))
+	assert.Len(t, conv.Messages, 4)
+	assert.Contains(t, output.String(), "Warning: this session has 4 of 4 messages; messages that go past the limit will be refused")
+
+	// The next one is refused and anything pending for it is kept
+	attachment := domain.Attachment{ID: "att-1", Type: domain.AttachmentTypeText, Name: "notes.txt", Content: []byte("notes")}
+	repl.session.Metadata["pending_attachments"] = []domain.Attachment{attachment}
+	err := repl.processMessage("One more")
+	assert.ErrorIs(t, err, ErrSessionLimitExceeded)
+	assert.Contains(t, err.Error(), "6 messages (limit 4)")
+	assert.Len(t, conv.Messages, 4)
+	assert.Len(t, repl.session.Metadata["pending_attachments"], 1)
+}
+
+func TestSessionLimits_AttachmentBytes(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+	require.NoError(t, repl.config.SetValue("session.limits.max_attachment_bytes", "1000"))
+
+	small := domain.Attachment{ID: "att-1", Type: domain.AttachmentTypeText, Name: "small.txt", Content: []byte(strings.Repeat("a", 900))}
+	repl.session.Metadata["pending_attachments"] = []domain.Attachment{small}
+	require.NoError(t, repl.processMessage("Read this"))
+	assert.Contains(t, output.String(), "900 B of 1000 B of attachments")
+	assert.NotContains(t, repl.session.Metadata, "pending_attachments")
+
+	// Stored content that is not loaded counts by its recorded size
+	large := domain.Attachment{ID: "att-2", Type: domain.AttachmentTypeText, Name: "large.txt", Hash: domain.ContentHash([]byte("x")), Size: 200}
+	repl.session.Metadata["pending_attachments"] = []domain.Attachment{large}
+	err := repl.processMessage("And this")
+	assert.ErrorIs(t, err, ErrSessionLimitExceeded)
+	assert.Contains(t, err.Error(), "1.1 KB of attachments (limit 1000 B)")
+}
+
+func TestSessionLimits_Summarize(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+	require.NoError(t, repl.config.SetValue("session.limits.max_messages", 8))
+	require.NoError(t, repl.config.SetValue("session.limits.on_exceed", "summarize"))
+
+	for i := 0; i < 4; i++ {
+		addTestMessage(repl.session.Conversation, "user", "Tell me about Paris", nil)
+		addTestMessage(repl.session.Conversation, "assistant", "Paris has many museums.", nil)
+	}
+	parent := repl.session
+
+	require.NoError(t, repl.processMessage("And Lyon?"))
+
+	// The session moved to a summary branch before the message was sent
+	require.NotSame(t, parent, repl.session)
+	assert.Len(t, parent.Conversation.Messages, 8)
+	messages := repl.session.Conversation.Messages
+	require.Len(t, messages, 1+summarizeKeepRecent+2)
+	assert.Equal(t, domain.MessageRoleSystem, messages[0].Role)
+	assert.Equal(t, "And Lyon?", messages[len(messages)-2].Content)
+	assert.Contains(t, output.String(), "This message would take the session to 10 messages (limit 8)")
+	assert.Contains(t, output.String(), "Summarized 4 messages")
+}
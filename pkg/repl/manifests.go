@@ -0,0 +1,51 @@
+// ABOUTME: Commands declared by manifests in the commands directory, as REPL commands
+// ABOUTME: Registers them with the REPL registry and runs their command lines as REPL lines
+
+package repl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/configdir"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+)
+
+// registerManifestCommands registers the commands declared in the commands
+// directory that are available in the REPL, reporting manifests that could
+// not be loaded or registered to w
+func (r *REPL) registerManifestCommands(w io.Writer) {
+	paths, err := configdir.GetPaths()
+	if err != nil {
+		logging.LogWarn("Failed to locate commands directory", "error", err)
+		return
+	}
+
+	manifests, warnings := command.LoadManifests(paths.Commands)
+	for _, m := range manifests {
+		if m.Metadata().Category == command.CategoryCLI {
+			continue
+		}
+		if err := r.registry.Register(command.NewManifestCommand(m, true, r.runManifestLine)); err != nil {
+			warnings = append(warnings, fmt.Errorf("%s: %w", m.Path, err))
+		}
+	}
+	for _, err := range warnings {
+		logging.LogWarn("Failed to load command manifest", "error", err)
+		fmt.Fprintf(w, "Warning: %v\n", err)
+	}
+}
+
+// runManifestLine runs the line a manifest command expanded to. Lines
+// that do not start with /, : or ! are slash commands.
+func (r *REPL) runManifestLine(_ context.Context, _ *command.ExecutionContext, words []string) error {
+	line := strings.Join(words, " ")
+	if !strings.HasPrefix(line, "/") && !strings.HasPrefix(line, ":") && !strings.HasPrefix(line, "!") {
+		line = "/" + line
+	}
+	logging.LogDebug("Running manifest command line", "line", line)
+	return r.runLine(line)
+}
@@ -143,6 +143,7 @@ func (r *REPL) ConfigureForNonInteractiveMode(mode NonInteractiveMode) {
 	}
 
 	// Set simple prompt for non-interactive mode
+	r.promptTemplate = ""
 	if mode.IsPipedInput || mode.IsPipedOutput {
 		r.promptStyle = "" // No prompt when piped
 	} else {
@@ -0,0 +1,69 @@
+// ABOUTME: Command pipelines for REPL
+// ABOUTME: Runs /commands separated by | with each getting the previous command's output
+
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+)
+
+// splitCommandPipeline splits a command line at each | that is followed by
+// another /command, such as /history | /pipe grep -i error. Other | are
+// left alone, so /pipe jq . | head stays one command.
+func splitCommandPipeline(line string) []string {
+	var stages []string
+	var quote rune
+	start := 0
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '|' && strings.HasPrefix(strings.TrimSpace(line[i+1:]), "/"):
+			stages = append(stages, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+	return append(stages, strings.TrimSpace(line[start:]))
+}
+
+// runPipeline runs each stage of a pipeline in turn. The output of every
+// stage but the last is captured and handed to the next stage, as stdin
+// and command.PipelineInput for registry commands and as the text /pipe
+// sends on.
+func (r *REPL) runPipeline(stages []string) error {
+	logging.LogDebug("Running command pipeline", "stages", len(stages))
+
+	writer := r.writer
+	defer func() { r.writer = writer }()
+
+	var input *command.PipelineInput
+	for i, stage := range stages {
+		parts := strings.Fields(stage)
+		if len(parts) == 0 {
+			return fmt.Errorf("empty command at position %d of pipeline", i+1)
+		}
+		name := strings.TrimPrefix(parts[0], "/")
+
+		var out bytes.Buffer
+		r.writer = writer
+		if i < len(stages)-1 {
+			r.writer = &out
+		}
+
+		data, err := r.dispatchCommand(name, parts[1:], input)
+		if err != nil {
+			return fmt.Errorf("pipeline stage %d (/%s): %w", i+1, name, err)
+		}
+		input = &command.PipelineInput{Command: name, Data: data, Text: out.String()}
+	}
+	return nil
+}
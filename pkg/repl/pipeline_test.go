@@ -0,0 +1,53 @@
+// ABOUTME: Tests for REPL command pipelines
+// ABOUTME: Verifies splitting at | and handing each command's output to the next
+
+package repl
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommandPipeline(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"/history", []string{"/history"}},
+		{"/history | /pipe wc -l", []string{"/history", "/pipe wc -l"}},
+		{"/sessions|/pipe sort|/pipe head -n 1", []string{"/sessions", "/pipe sort", "/pipe head -n 1"}},
+		{"/pipe jq . | head", []string{"/pipe jq . | head"}},
+		{`/system "a | /b" | /pipe cat`, []string{`/system "a | /b"`, "/pipe cat"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, splitCommandPipeline(tt.line), tt.line)
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	t.Setenv("SHELL", "/bin/sh")
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+	repl.config.(*testConfig).values["repl.shell.enabled"] = true
+
+	conv := repl.session.Conversation
+	addTestMessage(conv, "user", "name a fruit", nil)
+	addTestMessage(conv, "assistant", "durian", nil)
+	output.Reset()
+
+	require.NoError(t, repl.handleCommand("/history | /pipe grep -c durian"))
+	assert.Equal(t, "1\n", output.String(), "only the last command writes to the REPL")
+	assert.Nil(t, repl.pipelineInput)
+
+	output.Reset()
+	err := repl.handleCommand("/history | /nosuchcommand")
+	assert.ErrorContains(t, err, "pipeline stage 2 (/nosuchcommand)")
+	assert.Empty(t, output.String())
+}
@@ -0,0 +1,85 @@
+// ABOUTME: Configurable REPL prompt rendered from a template
+// ABOUTME: Fills placeholders such as {model} and {tokens} from the session state
+
+package repl
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lexlapax/magellai/pkg/llm"
+)
+
+// promptPlaceholder matches {name} placeholders in repl.prompt
+var promptPlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// promptValues returns the values available to the prompt template
+func (r *REPL) promptValues() map[string]string {
+	conv := r.session.Conversation
+	providerName, modelName := llm.ParseModelString(conv.Model)
+
+	branch := r.session.BranchName
+	if branch == "" {
+		branch = "main"
+	}
+	short := r.session.ID
+	if len(short) > 8 {
+		short = short[:8]
+	}
+
+	values := map[string]string{
+		"model":         conv.Model,
+		"model_name":    modelName,
+		"provider":      providerName,
+		"session":       r.session.ID,
+		"session_short": short,
+		"session_name":  r.session.Name,
+		"branch":        branch,
+		"messages":      strconv.Itoa(len(conv.Messages)),
+	}
+	return values
+}
+
+// renderPrompt returns the input prompt. With repl.prompt set it is rendered
+// from the template, placeholder values highlighted; otherwise the fixed
+// prompt style is used.
+func (r *REPL) renderPrompt() string {
+	if r.promptTemplate == "" {
+		if r.colorFormatter.Enabled() {
+			return r.colorFormatter.FormatPrompt(r.promptStyle)
+		}
+		return r.promptStyle
+	}
+
+	values := r.promptValues()
+	var prompt strings.Builder
+	last := 0
+	for _, match := range promptPlaceholder.FindAllStringSubmatchIndex(r.promptTemplate, -1) {
+		name := r.promptTemplate[match[2]:match[3]]
+		value, ok := values[name]
+		if !ok && name == "tokens" {
+			// Counted only when used, since it walks the whole history
+			value, ok = formatTokenCount(llm.NewEstimatedTokenCounter().CountMessageTokens(GetHistory(r.session.Conversation))), true
+		}
+		if !ok {
+			continue
+		}
+		prompt.WriteString(r.formatPromptText(r.promptTemplate[last:match[0]]))
+		if r.colorFormatter.Enabled() {
+			value = r.colorFormatter.FormatHighlight(value)
+		}
+		prompt.WriteString(value)
+		last = match[1]
+	}
+	prompt.WriteString(r.formatPromptText(r.promptTemplate[last:]))
+	return prompt.String()
+}
+
+// formatPromptText colors literal prompt text
+func (r *REPL) formatPromptText(text string) string {
+	if text == "" || !r.colorFormatter.Enabled() {
+		return text
+	}
+	return r.colorFormatter.FormatPrompt(text)
+}
@@ -0,0 +1,50 @@
+// ABOUTME: Tests for the configurable REPL prompt template
+// ABOUTME: Verifies placeholders, unknown names, colors, and the fixed-style fallback
+
+package repl
+
+import (
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestREPL_renderPrompt(t *testing.T) {
+	repl, _, cleanup := setupTestREPL(t)
+	defer cleanup()
+	repl.session.ID = "0123456789abcdef"
+	repl.session.Conversation.Model = "openai/gpt-4o"
+	addTestMessage(repl.session.Conversation, "user", "hello there", nil)
+
+	t.Run("fixed style without a template", func(t *testing.T) {
+		repl.promptStyle = "> "
+		assert.Equal(t, "> ", repl.renderPrompt())
+	})
+
+	t.Run("placeholders", func(t *testing.T) {
+		repl.promptTemplate = "{model_name}@{provider} {session_short} [{branch}] {messages} > "
+		assert.Equal(t, "gpt-4o@openai 01234567 [main] 1 > ", repl.renderPrompt())
+
+		repl.session.BranchName = "alt"
+		assert.Equal(t, "gpt-4o@openai 01234567 [alt] 1 > ", repl.renderPrompt())
+	})
+
+	t.Run("tokens and unknown placeholders", func(t *testing.T) {
+		repl.promptTemplate = "{tokens} {nope}> "
+		prompt := repl.renderPrompt()
+		assert.NotContains(t, prompt, "{tokens}")
+		assert.Contains(t, prompt, "{nope}> ")
+	})
+
+	t.Run("colors", func(t *testing.T) {
+		repl.colorFormatter = ui.NewColorFormatter(true, nil)
+		defer func() { repl.colorFormatter = ui.NewColorFormatter(false, nil) }()
+
+		repl.promptTemplate = "{model} > "
+		prompt := repl.renderPrompt()
+		assert.Contains(t, prompt, repl.colorFormatter.FormatHighlight("openai/gpt-4o"))
+		assert.Contains(t, prompt, repl.colorFormatter.FormatPrompt(" > "))
+		assert.Equal(t, "openai/gpt-4o > ", ui.StripColors(prompt))
+	})
+}
@@ -0,0 +1,182 @@
+// ABOUTME: REPL startup commands file and command aliases
+// ABOUTME: Runs ~/.config/magellai/replrc at startup and expands user-defined /aliases
+
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+)
+
+// runRCFile executes the commands in the startup file, one per line. Blank
+// lines and lines starting with # are ignored. Command output is suppressed;
+// errors are reported with their line number and do not stop the file.
+func (r *REPL) runRCFile() {
+	if r.rcFile == "" {
+		return
+	}
+
+	file, err := os.Open(r.rcFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		logging.LogWarn("Failed to open replrc", "path", r.rcFile, "error", err)
+		fmt.Fprintf(r.writer, "Warning: failed to read %s: %v\n", r.rcFile, err)
+		return
+	}
+	defer file.Close()
+
+	writer := r.writer
+	r.writer = io.Discard
+	defer func() { r.writer = writer }()
+
+	count := 0
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := r.runLine(line); err != nil {
+			logging.LogWarn("replrc command failed", "path", r.rcFile, "line", lineNum, "error", err)
+			fmt.Fprintf(writer, "%s:%d: %v\n", r.rcFile, lineNum, err)
+			continue
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(writer, "Warning: failed to read %s: %v\n", r.rcFile, err)
+	}
+
+	logging.LogInfo("Ran replrc", "path", r.rcFile, "commands", count)
+}
+
+// runLine executes a single REPL command line. Plain messages are rejected
+// so startup files and aliases cannot send prompts by accident.
+func (r *REPL) runLine(line string) error {
+	switch {
+	case strings.HasPrefix(line, "/"):
+		return r.handleCommand(line)
+	case strings.HasPrefix(line, ":"):
+		return r.handleSpecialCommand(line)
+	case strings.HasPrefix(line, "!"):
+		return r.runShell(line)
+	default:
+		return fmt.Errorf("not a command: %q (lines must start with /, : or !)", line)
+	}
+}
+
+// expandAlias runs the alias for a /name command, reporting whether one was
+// found. Aliases defined with /alias come first, then repl.aliases from
+// config, whose definitions may leave out the leading slash.
+func (r *REPL) expandAlias(name string, args []string) (bool, error) {
+	expansion, ok := r.aliases[name]
+	if !ok {
+		expansion, ok = r.configAlias(name)
+	}
+	if !ok || r.expandingAlias {
+		return false, nil
+	}
+
+	// Aliases expand once; an alias naming another alias is not expanded again
+	r.expandingAlias = true
+	defer func() { r.expandingAlias = false }()
+
+	words, err := command.ExpandAlias(expansion, args)
+	if err != nil {
+		return true, fmt.Errorf("alias /%s: %w", name, err)
+	}
+	line := strings.Join(words, " ")
+	logging.LogDebug("Expanding alias", "alias", name, "line", line)
+	return true, r.runLine(line)
+}
+
+// configAlias returns the repl.aliases definition for name, if any
+func (r *REPL) configAlias(name string) (string, bool) {
+	if r.config == nil {
+		return "", false
+	}
+	aliases, _ := r.config.Get("repl.aliases").(map[string]interface{})
+	expansion, _ := aliases[name].(string)
+	expansion = strings.TrimSpace(expansion)
+	if expansion == "" {
+		return "", false
+	}
+	if !strings.HasPrefix(expansion, "/") && !strings.HasPrefix(expansion, ":") && !strings.HasPrefix(expansion, "!") {
+		expansion = "/" + expansion
+	}
+	return expansion, true
+}
+
+// cmdAlias lists or defines command aliases.
+//
+//	/alias                      list aliases
+//	/alias <name>               show an alias
+//	/alias <name> <command...>  define an alias, e.g. /alias t :temperature
+//
+// Definitions may use $1, $@ and ${flag} parameters, e.g. /alias tr /ask Translate to ${lang:-French}: $*
+func (r *REPL) cmdAlias(args []string) error {
+	if len(args) == 0 {
+		if len(r.aliases) == 0 {
+			fmt.Fprintln(r.writer, "No aliases defined.")
+			return nil
+		}
+		names := make([]string, 0, len(r.aliases))
+		for name := range r.aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(r.writer, "Aliases:")
+		for _, name := range names {
+			fmt.Fprintf(r.writer, "  /%-12s %s\n", name, r.aliases[name])
+		}
+		return nil
+	}
+
+	name := strings.TrimPrefix(args[0], "/")
+	if len(args) == 1 {
+		expansion, ok := r.aliases[name]
+		if !ok {
+			return fmt.Errorf("%w: alias /%s", ErrCommandNotFound, name)
+		}
+		fmt.Fprintf(r.writer, "/%s = %s\n", name, expansion)
+		return nil
+	}
+
+	if _, err := r.registry.Get(name); err == nil {
+		return fmt.Errorf("cannot alias /%s: it is a built-in command", name)
+	}
+	expansion := strings.Join(args[1:], " ")
+	if !strings.HasPrefix(expansion, "/") && !strings.HasPrefix(expansion, ":") && !strings.HasPrefix(expansion, "!") {
+		return fmt.Errorf("alias must expand to a command starting with /, : or !")
+	}
+
+	if r.aliases == nil {
+		r.aliases = make(map[string]string)
+	}
+	r.aliases[name] = expansion
+	fmt.Fprintf(r.writer, "Alias /%s = %s\n", name, expansion)
+	return nil
+}
+
+// cmdUnalias removes a command alias
+func (r *REPL) cmdUnalias(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: /unalias <name>")
+	}
+	name := strings.TrimPrefix(args[0], "/")
+	if _, ok := r.aliases[name]; !ok {
+		return fmt.Errorf("%w: alias /%s", ErrCommandNotFound, name)
+	}
+	delete(r.aliases, name)
+	fmt.Fprintf(r.writer, "Alias /%s removed\n", name)
+	return nil
+}
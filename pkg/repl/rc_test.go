@@ -0,0 +1,100 @@
+// ABOUTME: Tests for the REPL startup commands file and command aliases
+// ABOUTME: Verifies replrc execution, error reporting, and alias expansion
+
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPL_runRCFile(t *testing.T) {
+	t.Run("runs commands and reports errors by line", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		rc := filepath.Join(t.TempDir(), "replrc")
+		require.NoError(t, os.WriteFile(rc, []byte(`# startup commands
+:temperature 0.5
+:system Be brief
+
+/alias t :temperature
+hello there
+/nosuchcommand
+`), 0644))
+		repl.rcFile = rc
+
+		repl.runRCFile()
+
+		assert.Equal(t, 0.5, repl.session.Conversation.Temperature)
+		assert.Equal(t, "Be brief", repl.session.Conversation.SystemPrompt)
+		assert.Equal(t, ":temperature", repl.aliases["t"])
+		assert.Contains(t, output.String(), rc+":6: not a command")
+		assert.Contains(t, output.String(), rc+":7:")
+		assert.NotContains(t, output.String(), "Temperature set")
+		assert.Equal(t, output, repl.writer, "writer is restored")
+	})
+
+	t.Run("missing or disabled file is ignored", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		repl.rcFile = filepath.Join(t.TempDir(), "missing")
+		repl.runRCFile()
+		repl.rcFile = ""
+		repl.runRCFile()
+		assert.Empty(t, output.String())
+	})
+}
+
+func TestREPL_aliases(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	require.NoError(t, repl.handleCommand("/alias t :temperature"))
+	require.NoError(t, repl.handleCommand("/t 0.3"))
+	assert.Equal(t, 0.3, repl.session.Conversation.Temperature)
+
+	output.Reset()
+	require.NoError(t, repl.handleCommand("/alias"))
+	assert.Contains(t, output.String(), "/t")
+	assert.Contains(t, output.String(), ":temperature")
+
+	assert.Error(t, repl.handleCommand("/alias help :temperature"), "built-in commands cannot be aliased")
+	assert.Error(t, repl.handleCommand("/alias x hello"), "aliases must expand to commands")
+
+	// An alias that names itself is not expanded again
+	require.NoError(t, repl.handleCommand("/alias loop /loop"))
+	assert.Error(t, repl.handleCommand("/loop"))
+
+	require.NoError(t, repl.handleCommand("/unalias t"))
+	assert.Error(t, repl.handleCommand("/t 0.3"))
+	assert.Error(t, repl.handleCommand("/unalias t"))
+}
+
+func TestREPL_parameterizedAliases(t *testing.T) {
+	repl, _, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	require.NoError(t, repl.handleCommand("/alias temp :temperature ${1:-0.7}"))
+	require.NoError(t, repl.handleCommand("/temp"))
+	assert.Equal(t, 0.7, repl.session.Conversation.Temperature)
+	require.NoError(t, repl.handleCommand("/temp 0.2"))
+	assert.Equal(t, 0.2, repl.session.Conversation.Temperature)
+
+	require.NoError(t, repl.handleCommand("/alias need :temperature $1"))
+	assert.Error(t, repl.handleCommand("/need"), "missing $1")
+
+	// repl.aliases from config are expanded too
+	repl.config.(*testConfig).values["repl.aliases"] = map[string]interface{}{
+		"cold": ":temperature ${value:-0.1}",
+	}
+	require.NoError(t, repl.handleCommand("/cold"))
+	assert.Equal(t, 0.1, repl.session.Conversation.Temperature)
+	require.NoError(t, repl.handleCommand("/cold --value 0.4"))
+	assert.Equal(t, 0.4, repl.session.Conversation.Temperature)
+}
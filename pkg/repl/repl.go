@@ -13,18 +13,27 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/lexlapax/magellai/internal/configdir"
 	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/command"
 	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/keyring"
 	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
 	"github.com/lexlapax/magellai/pkg/repl/session"
+	"github.com/lexlapax/magellai/pkg/retrieval"
+	"github.com/lexlapax/magellai/pkg/scripting"
 	"github.com/lexlapax/magellai/pkg/storage"
 	_ "github.com/lexlapax/magellai/pkg/storage/filesystem" // Register filesystem backend
 	_ "github.com/lexlapax/magellai/pkg/storage/sqlite"     // Register SQLite backend
 	"github.com/lexlapax/magellai/pkg/ui"
+	"github.com/lexlapax/magellai/pkg/util/stringutil"
+	"github.com/lexlapax/magellai/pkg/workspace"
 )
 
 // ConfigInterface defines the minimal interface needed for configuration
@@ -44,7 +53,9 @@ type REPL struct {
 	manager        *session.SessionManager
 	reader         *bufio.Reader
 	writer         io.Writer
+	ctx            context.Context // Parent of every request and command the REPL runs
 	promptStyle    string
+	promptTemplate string // repl.prompt template; empty uses promptStyle
 	multiline      bool
 	exitOnEOF      bool
 	autoSave       bool
@@ -58,6 +69,28 @@ type REPL struct {
 	colorFormatter *ui.ColorFormatter     // Color formatter for output
 	nonInteractive NonInteractiveMode     // Non-interactive mode detection
 	sharedContext  *command.SharedContext // Shared context for command state preservation
+	moderation     *llm.ModerationPolicy  // Optional moderation stage (nil when disabled)
+	renderMarkdown bool                   // Render assistant responses as terminal markdown
+	undone         *undoneExchange        // Exchange removed by the last /undo, for /redo
+	usage          sessionUsage           // Token usage and cost accumulated this session
+	workspace      *workspace.Workspace   // Project workspace, if chat started inside one
+	ragIndex       *retrieval.Index       // Active retrieval index, if any
+	retrieveAuto   bool                   // Retrieve excerpts for every message
+	rcFile         string                 // Startup commands file run by Run, if any
+	aliases        map[string]string      // User-defined /command aliases
+	expandingAlias bool                   // Set while an alias runs, to stop recursion
+	pipelineInput  *command.PipelineInput // Previous stage's output while a pipeline stage runs
+	scripts        *scripting.Engine      // User hook scripts, nil when disabled
+	script         string                 // Batch script run by Run instead of the prompt loop ("-" for stdin)
+	quiet          bool                   // Skip the welcome banner
+	inventory      *models.Inventory      // Models inventory for pricing (loaded lazily)
+	inventoryOnce  sync.Once              // Guards lazy inventory loading
+	configModel    string                 // model.default the session follows, empty if chosen explicitly
+	configChanged  atomic.Bool            // Set when config files were reloaded since the last input
+
+	interruptMu      sync.Mutex         // Guards Ctrl-C state
+	cancelGeneration context.CancelFunc // Cancels the in-flight generation, if any
+	lastInterrupt    time.Time          // Time of the previous Ctrl-C
 }
 
 // REPLOptions contains options for creating a new REPL
@@ -65,10 +98,24 @@ type REPLOptions struct {
 	Config      ConfigInterface
 	StorageDir  string
 	PromptStyle string
-	SessionID   string // Optional: resume existing session
-	Model       string // Optional: override default model
+	SessionID   string        // Optional: resume existing session
+	Model       string        // Optional: override default model
+	Template    string        // Optional: start the new session from a session template
+	Ephemeral   time.Duration // Optional: delete the session this long after it starts
+	ReadOnly    bool          // Open session storage without writing to it
+	RCFile      string        // Optional: startup commands file (default ~/.config/magellai/replrc)
+	NoRC        bool          // Skip the startup commands file
+	Script      string        // Optional: file of commands and prompts to run non-interactively ("-" for stdin)
+	Quiet       bool          // Skip the welcome banner
 	Writer      io.Writer
 	Reader      io.Reader
+	Context     context.Context // Optional: parent of every request; canceling it stops the REPL
+}
+
+// apiKeySource is implemented by configs that resolve provider API keys
+// from the keychain, environment, and secret:// references
+type apiKeySource interface {
+	GetProviderAPIKeyContext(ctx context.Context, provider string) string
 }
 
 // NewREPL creates a new REPL instance
@@ -81,6 +128,9 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 	if opts.Reader == nil {
 		opts.Reader = os.Stdin
 	}
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
 	if opts.PromptStyle == "" {
 		opts.PromptStyle = "> "
 	}
@@ -115,6 +165,18 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 			}
 		}
 	}
+	readOnly := opts.ReadOnly || cfg.GetBool("session.storage.read_only")
+	if readOnly {
+		storageConfig["read_only"] = true
+	}
+	if replicaType := cfg.GetString("session.storage.replica.type"); replicaType != "" {
+		storageConfig["replica_type"] = replicaType
+		storageConfig["replica_settings"] = cfg.Get("session.storage.replica.settings")
+	}
+	if cfg.Exists("session.snapshots.keep") {
+		storageConfig["snapshot_interval"] = cfg.Get("session.snapshots.interval")
+		storageConfig["snapshot_keep"] = cfg.Get("session.snapshots.keep")
+	}
 
 	// Create storage using the new storage package
 	backend, err := session.CreateStorageManager(storage.BackendType(storageType), storage.Config(storageConfig))
@@ -127,10 +189,22 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 	logging.LogDebug("Creating session manager")
 	manager := &session.SessionManager{StorageManager: backend}
 
+	// Delete ephemeral sessions past their expiry, and sessions older than session.max_age
+	var maxAge time.Duration
+	if cfg.Exists("session.max_age") {
+		if duration, err := time.ParseDuration(cfg.GetString("session.max_age")); err == nil {
+			maxAge = duration
+		}
+	}
+	if _, err := backend.PurgeExpiredSessions(maxAge); err != nil {
+		logging.LogWarn("Failed to delete expired sessions", "error", err)
+	}
+
 	var currentSession *domain.Session
 
-	// Check for crash recovery first if no specific session is requested
-	if opts.SessionID == "" {
+	// Check for crash recovery first if no specific session is requested.
+	// Read-only storage leaves the recovery files alone.
+	if opts.SessionID == "" && opts.Script == "" && !readOnly {
 		// Create auto-recovery manager to check for recoverable sessions
 		tempAutoRecovery, err := session.NewAutoRecoveryManager(session.DefaultAutoRecoveryConfig(), backend)
 		if err == nil {
@@ -140,13 +214,8 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 				fmt.Fprintf(opts.Writer, "Session ID: %s\n", recoveryState.SessionID)
 				fmt.Fprintf(opts.Writer, "Session Name: %s\n", recoveryState.SessionName)
 				fmt.Fprintf(opts.Writer, "Last saved: %s\n", recoveryState.Timestamp.Format("2006-01-02 15:04:05"))
-				fmt.Fprint(opts.Writer, "Recover this session? (y/n): ")
-
-				reader := bufio.NewReader(opts.Reader)
-				response, _ := reader.ReadString('\n')
-				response = strings.TrimSpace(strings.ToLower(response))
-
-				if response == "y" || response == "yes" {
+				ok, _ := command.NewPrompter(opts.Reader, opts.Writer, true).Confirm("Recover this session?", false)
+				if ok {
 					currentSession, err = tempAutoRecovery.RecoverSession(recoveryState)
 					if err != nil {
 						logging.LogWarn("Failed to recover session", "error", err)
@@ -168,6 +237,10 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 		}
 	}
 
+	if opts.SessionID != "" && opts.Template != "" {
+		return nil, fmt.Errorf("cannot start a resumed session from a template")
+	}
+
 	if opts.SessionID != "" {
 		// Resume existing session
 		logging.LogInfo("Resuming existing session", "sessionID", opts.SessionID)
@@ -186,8 +259,35 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 		}
 	}
 
+	// Start the new session from a template
+	var templateModel string
+	if opts.Template != "" {
+		template, err := loadSessionTemplate(backend, opts.Template)
+		if err != nil {
+			return nil, err
+		}
+		logging.LogInfo("Starting session from template", "template", template.Name)
+		template.Apply(currentSession)
+		templateModel = template.Model
+	}
+	if opts.Ephemeral > 0 {
+		currentSession.SetExpiry(opts.Ephemeral)
+		logging.LogInfo("Session is ephemeral", "id", currentSession.ID, "expiresAt", currentSession.ExpiresAt)
+	}
+
+	// A project workspace can set the default model
+	ws := findWorkspace(cfg, opts.Writer)
+
 	// Override model if specified
 	modelStr := cfg.GetString("model.default")
+	if ws != nil && ws.Model != "" {
+		logging.LogDebug("Using workspace model", "model", ws.Model)
+		modelStr = ws.Model
+	}
+	if templateModel != "" {
+		logging.LogDebug("Using template model", "model", templateModel)
+		modelStr = templateModel
+	}
 	if opts.Model != "" {
 		logging.LogDebug("Overriding model from options", "model", opts.Model)
 		modelStr = opts.Model
@@ -204,10 +304,16 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 	modelName := parts[1]
 	logging.LogDebug("Parsed model configuration", "provider", providerType, "model", modelName)
 
-	// Get API key from config for the provider
+	// Get API key for the provider from the OS keychain, then config
 	apiKeyPath := fmt.Sprintf("provider.%s.api_key", providerType)
-	apiKey := cfg.GetString(apiKeyPath)
-	logging.LogDebug("Getting API key from config", "provider", providerType, "keyPath", apiKeyPath)
+	var apiKey string
+	if keys, ok := cfg.(apiKeySource); ok {
+		// Also checks the environment and resolves secret:// references
+		apiKey = keys.GetProviderAPIKeyContext(opts.Context, providerType)
+	} else if apiKey, err = keyring.Get(providerType); err != nil || apiKey == "" {
+		apiKey = cfg.GetString(apiKeyPath)
+	}
+	logging.LogDebug("Getting API key", "provider", providerType, "keyPath", apiKeyPath, "found", apiKey != "")
 
 	// Create provider
 	logging.LogInfo("Creating LLM provider", "provider", providerType, "model", modelName)
@@ -217,11 +323,18 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 		return nil, fmt.Errorf("failed to create provider: %w", err)
 	}
 
+	// Set up the optional moderation stage
+	moderation, err := llm.NewModerationPolicy(llm.LoadModerationConfig(cfg))
+	if err != nil {
+		logging.LogError(err, "Failed to configure moderation")
+		return nil, fmt.Errorf("failed to configure moderation: %w", err)
+	}
+
 	// Update session with model
 	currentSession.Conversation.Model = modelStr
 	currentSession.Conversation.Provider = providerType
 
-	autoSave := cfg.GetBool("repl.auto_save.enabled")
+	autoSave := cfg.GetBool("repl.auto_save.enabled") && !readOnly
 
 	// Detect non-interactive mode
 	nonInteractive := DetectNonInteractiveMode(opts.Reader, opts.Writer)
@@ -233,15 +346,23 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 		manager:        manager,
 		reader:         bufio.NewReader(opts.Reader),
 		writer:         opts.Writer,
+		ctx:            opts.Context,
 		promptStyle:    opts.PromptStyle,
+		promptTemplate: cfg.GetString("repl.prompt"),
 		exitOnEOF:      true,
 		autoSave:       autoSave,
 		lastSaveTime:   time.Now(),
 		registry:       command.NewRegistry(),
 		cmdHistory:     make([]string, 0),
-		isTerminal:     ui.IsTerminal() && !nonInteractive.IsNonInteractive,
+		isTerminal:     ui.IsTerminal() && !nonInteractive.IsNonInteractive && opts.Script == "",
 		nonInteractive: nonInteractive,
 		sharedContext:  command.NewSharedContext(),
+		moderation:     moderation,
+		script:         opts.Script,
+		quiet:          opts.Quiet,
+	}
+	if opts.Model == "" && templateModel == "" && (ws == nil || ws.Model == "") {
+		repl.configModel = modelStr
 	}
 
 	// Initialize shared context with current session state
@@ -260,6 +381,35 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 	enableColors := repl.isTerminal && cfg.GetBool("repl.colors.enabled")
 	repl.colorFormatter = ui.NewColorFormatter(enableColors, nil)
 
+	// Render markdown only when writing to a terminal
+	repl.renderMarkdown = repl.isTerminal && cfg.GetBool("repl.render.markdown")
+
+	// Retrieve from the active index for every message when enabled
+	repl.retrieveAuto = cfg.GetBool("repl.retrieval.auto")
+
+	// Load user hook scripts
+	repl.loadScripts(opts.Writer)
+
+	// Run the startup commands file unless disabled
+	if !opts.NoRC {
+		repl.rcFile = opts.RCFile
+		if repl.rcFile == "" {
+			if paths, err := configdir.GetPaths(); err == nil {
+				repl.rcFile = paths.ReplRC
+			}
+		}
+	}
+
+	// Apply workspace context to new sessions; resumed sessions keep theirs
+	if ws != nil && opts.SessionID == "" {
+		if err := repl.applyWorkspace(ws); err != nil {
+			logging.LogWarn("Failed to apply workspace", "error", err)
+			fmt.Fprintf(opts.Writer, "Warning: failed to apply workspace: %v\n", err)
+		}
+	} else if ws != nil {
+		repl.workspace = ws
+	}
+
 	// Configure for non-interactive mode if needed
 	repl.ConfigureForNonInteractiveMode(nonInteractive)
 
@@ -268,6 +418,7 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 		logging.LogError(err, "Failed to register REPL commands")
 		return nil, fmt.Errorf("failed to register REPL commands: %w", err)
 	}
+	repl.registerManifestCommands(opts.Writer)
 
 	// Initialize readline if in terminal mode
 	if repl.isTerminal {
@@ -281,22 +432,19 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 			commands = append(commands, meta.Aliases...)
 		}
 
-		historyFile := ""
-		if opts.StorageDir != "" {
+		// Input history is kept per user; fall back to the storage directory
+		historyFile := stringutil.ExpandPath(cfg.GetString("repl.history_file"))
+		if historyFile == "" && opts.StorageDir != "" {
 			historyFile = filepath.Join(opts.StorageDir, ".repl_history")
 		}
 
-		// Use colored prompt if colors are enabled
-		prompt := repl.promptStyle
-		if repl.colorFormatter.Enabled() {
-			prompt = repl.colorFormatter.FormatPrompt(prompt)
-		}
-
 		readlineConfig := &ui.ReadlineConfig{
-			Prompt:           prompt,
+			Prompt:           repl.renderPrompt(),
 			HistoryFile:      historyFile,
 			EnableCompletion: true,
 			MultilineMode:    repl.multiline,
+			Keybindings:      cfg.GetString("repl.keybindings"),
+			BracketedPaste:   cfg.GetBool("repl.bracketed_paste"),
 		}
 
 		readlineInterface, err := ui.NewReadlineInterface(readlineConfig)
@@ -308,6 +456,7 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 			// Update completer with actual command names
 			if completer, ok := repl.readline.Instance.Config.AutoComplete.(*ui.ReplCompleter); ok {
 				completer.Commands = commands
+				completer.Arguments = repl.argumentCompleters()
 			}
 		}
 	}
@@ -327,6 +476,11 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 		logging.LogInfo("Auto-save enabled", "interval", duration)
 	}
 
+	if readOnly {
+		logging.LogInfo("Session storage is read-only; auto-save and auto-recovery are off")
+		return repl, nil
+	}
+
 	// Initialize auto-recovery
 	autoRecoveryConfig := session.DefaultAutoRecoveryConfig()
 	if cfg.Exists("session.auto_recovery") {
@@ -360,12 +514,21 @@ func NewREPL(opts *REPLOptions) (*REPL, error) {
 	return repl, nil
 }
 
+// readOnlyNotice is shown at startup when session storage is read-only
+const readOnlyNotice = "Storage is read-only: this session will not be saved."
+
 // Run starts the REPL loop
 func (r *REPL) Run() error {
 	logging.LogInfo("Starting REPL session", "sessionID", r.session.ID, "model", r.session.Conversation.Model)
 
+	// Scripts run without the welcome banner and exit when done
+	if r.script != "" {
+		r.runRCFile()
+		return r.runScript(r.script)
+	}
+
 	// Print welcome message only in interactive mode
-	if !r.nonInteractive.IsNonInteractive {
+	if !r.nonInteractive.IsNonInteractive && !r.quiet {
 		if r.colorFormatter.Enabled() {
 			fmt.Fprintf(r.writer, "%s (type %s for commands)\n",
 				r.colorFormatter.FormatInfo("magellai chat - Interactive LLM chat"),
@@ -373,16 +536,27 @@ func (r *REPL) Run() error {
 			fmt.Fprintf(r.writer, "%s: %s\n",
 				r.colorFormatter.FormatInfo("Model"),
 				r.colorFormatter.FormatHighlight(r.session.Conversation.Model))
-			fmt.Fprintf(r.writer, "%s: %s\n\n",
+			fmt.Fprintf(r.writer, "%s: %s\n",
 				r.colorFormatter.FormatInfo("Session"),
 				r.colorFormatter.FormatHighlight(r.session.ID))
+			if r.manager.ReadOnly() {
+				fmt.Fprintf(r.writer, "%s\n", r.colorFormatter.FormatWarning(readOnlyNotice))
+			}
+			fmt.Fprintln(r.writer)
 		} else {
 			fmt.Fprintf(r.writer, "magellai chat - Interactive LLM chat (type /help for commands)\n")
 			fmt.Fprintf(r.writer, "Model: %s\n", r.session.Conversation.Model)
-			fmt.Fprintf(r.writer, "Session: %s\n\n", r.session.ID)
+			fmt.Fprintf(r.writer, "Session: %s\n", r.session.ID)
+			if r.manager.ReadOnly() {
+				fmt.Fprintf(r.writer, "%s\n", readOnlyNotice)
+			}
+			fmt.Fprintln(r.writer)
 		}
 	}
 
+	// Apply startup commands before any input is processed
+	r.runRCFile()
+
 	// Process piped input if in non-interactive mode
 	if r.nonInteractive.IsPipedInput {
 		logging.LogInfo("Processing piped input in non-interactive mode")
@@ -404,27 +578,48 @@ func (r *REPL) Run() error {
 		}
 	}
 
-	// Setup signal handler for graceful shutdown
+	// Setup signal handler. Ctrl-C cancels the current generation or prints an
+	// exit hint; only a second Ctrl-C in quick succession exits. SIGTERM saves
+	// recovery state and exits immediately.
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
 
 	go func() {
-		sig := <-sigChan
-		logging.LogInfo("Received signal, saving recovery state", "signal", sig)
+		for sig := range sigChan {
+			if sig == os.Interrupt {
+				switch r.handleInterrupt() {
+				case interruptCancelled:
+					continue
+				case interruptHint:
+					r.printInterruptHint()
+					continue
+				case interruptExit:
+					r.saveOnInterruptExit()
+					os.Exit(0)
+				}
+			}
 
-		// Force save recovery state
-		if r.autoRecovery != nil {
-			if err := r.autoRecovery.ForceRecoverySave(); err != nil {
-				logging.LogError(err, "Failed to save recovery state on signal")
-			} else {
-				logging.LogInfo("Recovery state saved successfully")
+			logging.LogInfo("Received signal, saving recovery state", "signal", sig)
+
+			// Force save recovery state
+			if r.autoRecovery != nil {
+				if err := r.autoRecovery.ForceRecoverySave(); err != nil {
+					logging.LogError(err, "Failed to save recovery state on signal")
+				} else {
+					logging.LogInfo("Recovery state saved successfully")
+				}
 			}
-		}
 
-		// Exit
-		os.Exit(0)
+			// Exit
+			os.Exit(0)
+		}
 	}()
 
+	// Reload config files as they change, if enabled
+	stopConfigWatch := r.startConfigWatch()
+	defer stopConfigWatch()
+
 	// Cleanup function to stop auto-save and auto-recovery
 	defer func() {
 		if r.autoSave {
@@ -447,24 +642,42 @@ func (r *REPL) Run() error {
 
 	// Main REPL loop
 	for {
+		// Stop when the caller cancels the REPL
+		if err := r.baseContext().Err(); err != nil {
+			logging.LogInfo("REPL context done, exiting", "error", err)
+			return err
+		}
+
 		// Read input
 		logging.LogDebug("Reading user input")
 		var input string
 		var err error
 
 		if r.readline != nil {
-			// Use readline for input
+			// Use readline for input; the prompt may show changing session state
+			r.readline.SetPrompt(r.currentPrompt())
 			input, err = r.readline.ReadLine()
 		} else {
 			// Fallback to standard input
-			prompt := r.promptStyle
-			if r.colorFormatter.Enabled() {
-				prompt = r.colorFormatter.FormatPrompt(prompt)
-			}
-			fmt.Fprint(r.writer, prompt)
+			fmt.Fprint(r.writer, r.currentPrompt())
 			input, err = r.readInput()
 		}
 
+		if errors.Is(err, ui.ErrInterrupt) {
+			// Ctrl-C at the prompt: a non-empty line is simply discarded,
+			// an empty line counts toward the double Ctrl-C exit
+			if strings.TrimSpace(input) != "" {
+				logging.LogDebug("Interrupt cleared input line")
+				continue
+			}
+			if r.handleInterrupt() == interruptExit {
+				r.saveOnInterruptExit()
+				return nil
+			}
+			r.printInterruptHint()
+			continue
+		}
+
 		if err != nil {
 			if err == io.EOF && r.exitOnEOF {
 				logging.LogInfo("EOF received, exiting REPL")
@@ -475,6 +688,17 @@ func (r *REPL) Run() error {
 			return fmt.Errorf("read error: %w", err)
 		}
 
+		// Apply config file changes made while waiting for input
+		r.applyConfigChanges()
+
+		// Keep reading until an opened ``` block is closed
+		if hasOpenFence(input) {
+			if input, err = r.readFencedBlock(input); err != nil {
+				logging.LogError(err, "Read error")
+				return fmt.Errorf("read error: %w", err)
+			}
+		}
+
 		// Skip empty input
 		input = strings.TrimSpace(input)
 		if input == "" {
@@ -483,6 +707,27 @@ func (r *REPL) Run() error {
 		}
 		logging.LogDebug("Processing user input", "inputLength", len(input))
 
+		// A dropped or pasted image path becomes a pending attachment
+		if r.attachPastedImage(input) {
+			continue
+		}
+
+		// Run shell commands (! prefix)
+		if strings.HasPrefix(input, "!") {
+			logging.LogDebug("Processing shell command", "command", input)
+			if err := r.runShell(input); errors.Is(err, ErrGenerationCancelled) {
+				fmt.Fprintln(r.writer, "\nCommand cancelled.")
+			} else if err != nil {
+				logging.LogError(err, "Shell command error", "command", input)
+				if r.colorFormatter.Enabled() {
+					fmt.Fprintf(r.writer, "%s: %v\n", r.colorFormatter.FormatError("Error"), err)
+				} else {
+					fmt.Fprintf(r.writer, "Error: %v\n", err)
+				}
+			}
+			continue
+		}
+
 		// Check for commands
 		if strings.HasPrefix(input, "/") {
 			logging.LogDebug("Processing command", "command", input)
@@ -513,7 +758,9 @@ func (r *REPL) Run() error {
 
 		// Process as conversation
 		logging.LogDebug("Processing message", "messageLength", len(input))
-		if err := r.processMessage(input); err != nil {
+		if err := r.processMessage(input); errors.Is(err, ErrGenerationCancelled) {
+			fmt.Fprintln(r.writer, "\nGeneration cancelled.")
+		} else if err != nil {
 			logging.LogError(err, "Message processing error")
 			if r.colorFormatter.Enabled() {
 				fmt.Fprintf(r.writer, "%s: %v\n", r.colorFormatter.FormatError("Error"), err)
@@ -559,20 +806,71 @@ func (r *REPL) readInput() (string, error) {
 // processMessage processes a user message and generates a response
 func (r *REPL) processMessage(message string) error {
 	logging.LogDebug("Processing message", "message", message)
-	// Get pending attachments
+	// Get pending attachments; they are cleared once the message is accepted
 	var attachments []domain.Attachment
 	if r.session.Metadata != nil {
 		if pending, ok := r.session.Metadata["pending_attachments"].([]domain.Attachment); ok {
 			attachments = pending
 			logging.LogDebug("Found pending attachments", "count", len(attachments))
-			// Clear pending attachments
-			delete(r.session.Metadata, "pending_attachments")
 		}
 	}
+	revisions, _ := r.session.Metadata[pendingRevisionsKey].([]domain.MessageRevision)
+	pendingSession := r.session
+
+	// Attach files referenced inline as @path
+	message, fileRefs, err := expandFileReferences(message)
+	if err != nil {
+		return err
+	}
+	for _, att := range fileRefs {
+		fmt.Fprintf(r.writer, "Attached %s\n", att.FilePath)
+	}
+	attachments = append(attachments, fileRefs...)
+
+	// Add excerpts from the active index when automatic retrieval is on
+	if retrieved, ok := r.retrieveForMessage(message); ok {
+		attachments = append(attachments, retrieved)
+	}
+
+	// Refuse the message, keeping what is pending for it, if the session
+	// has no room for it; summarizing may move to a new branch
+	if err := r.enforceSessionLimits(attachments); err != nil {
+		return err
+	}
+	delete(pendingSession.Metadata, "pending_attachments")
+	delete(pendingSession.Metadata, pendingRevisionsKey)
+
+	// Create a cancellable context so Ctrl-C stops the generation
+	ctx := r.beginGeneration()
+	defer r.endGeneration()
+
+	// Let scripts rewrite or cancel the message
+	event := r.scriptEvent(scripting.HookMessageSend)
+	event.Message = message
+	if reply := r.runScriptHook(ctx, event); reply.Cancel {
+		return nil
+	} else if reply.Message != "" {
+		message = reply.Message
+	}
+
+	// Moderate the outgoing prompt before it enters the conversation
+	if err := r.moderate(ctx, llm.ModerationStagePrompt, message); err != nil {
+		return err
+	}
+
+	// A new message makes the undone exchange impossible to restore in order
+	r.undone = nil
+
+	// Sources retrieved for the message are cited by the response
+	citations := attachmentCitations(attachments)
 
 	// Add user message to conversation
 	logging.LogDebug("Adding user message to conversation", "attachmentCount", len(attachments))
 	AddMessageToConversation(r.session.Conversation, "user", message, attachments)
+	if len(revisions) > 0 {
+		messages := r.session.Conversation.Messages
+		messages[len(messages)-1].Revisions = revisions
+	}
 
 	// Save recovery state after user message
 	if r.autoRecovery != nil {
@@ -597,41 +895,104 @@ func (r *REPL) processMessage(message string) error {
 		opts = append(opts, llm.WithMaxTokens(maxTokens))
 	}
 
-	// Create context
-	ctx := context.Background()
+	// Latency is measured from the request to the complete response
+	started := time.Now()
 
-	// Use streaming if enabled
-	if r.config.GetBool("stream") {
+	// Use streaming if enabled. Blocking response moderation needs the full
+	// response before anything is printed, so it disables streaming.
+	if r.config.GetBool("stream") && !r.moderation.Blocks(llm.ModerationStageResponse) {
 		logging.LogDebug("Using streaming mode")
 		// Start response
 		fmt.Fprint(r.writer, "\n")
 
-		// Stream response chunks
+		// The spinner runs until the first output is written
+		spinner := r.startSpinner()
+		defer spinner.Stop()
+		out := spinner.Writer(r.writer)
+		counter := llm.NewEstimatedTokenCounter()
+
+		// Stream response chunks. The markdown renderer writes complete lines
+		// as they arrive, so rendered output still streams.
 		var fullResponse strings.Builder
+		var renderer *ui.MarkdownRenderer
+		if r.renderMarkdown {
+			renderer = ui.NewMarkdownRenderer(out)
+		}
 		stream, err := r.provider.StreamMessage(ctx, messages, opts...)
+		if err != nil && ctx.Err() != nil {
+			return ErrGenerationCancelled
+		}
 		if err != nil {
 			logging.LogError(err, "Failed to start stream")
 			return fmt.Errorf("failed to start stream: %w", err)
 		}
 
-		for chunk := range stream {
-			if chunk.Error != nil {
-				logging.LogError(chunk.Error, "Stream error")
-				return fmt.Errorf("stream error: %w", chunk.Error)
-			}
-			content := chunk.Content
-			if r.colorFormatter.Enabled() {
-				content = r.colorFormatter.FormatAssistantMessage(content)
+	streamLoop:
+		for {
+			select {
+			case <-ctx.Done():
+				// Keep whatever was generated before the cancel
+				logging.LogInfo("Stream cancelled", "partialLength", fullResponse.Len())
+				if renderer != nil {
+					_ = renderer.Flush()
+				}
+				if fullResponse.Len() > 0 {
+					AddMessageToConversation(r.session.Conversation, "assistant", fullResponse.String(), nil)
+				}
+				return ErrGenerationCancelled
+			case chunk, ok := <-stream:
+				if !ok {
+					break streamLoop
+				}
+				if chunk.Error != nil {
+					if ctx.Err() != nil {
+						continue
+					}
+					logging.LogError(chunk.Error, "Stream error")
+					return fmt.Errorf("stream error: %w", chunk.Error)
+				}
+				fullResponse.WriteString(chunk.Content)
+				spinner.AddTokens(counter.CountTokens(chunk.Content))
+				if renderer != nil {
+					_, _ = renderer.Write([]byte(chunk.Content))
+					continue
+				}
+				content := chunk.Content
+				if r.colorFormatter.Enabled() {
+					content = r.colorFormatter.FormatAssistantMessage(content)
+				}
+				fmt.Fprint(out, content)
 			}
-			fmt.Fprint(r.writer, content)
-			fullResponse.WriteString(chunk.Content)
 		}
 		logging.LogDebug("Stream completed", "responseLength", fullResponse.Len())
 
-		fmt.Fprintln(r.writer, "")
+		if renderer != nil {
+			if err := renderer.Flush(); err != nil {
+				logging.LogWarn("Failed to render response", "error", err)
+			}
+		}
+
+		fmt.Fprintln(out, "")
+
+		// Moderate the completed response. Streaming only runs when response
+		// moderation warns, so flagged output has already been shown and is
+		// reported after the fact rather than blocked.
+		if err := r.moderate(ctx, llm.ModerationStageResponse, fullResponse.String()); err != nil {
+			r.discardPendingMessage()
+			return err
+		}
 
 		// Add assistant message to conversation
 		AddMessageToConversation(r.session.Conversation, "assistant", fullResponse.String(), nil)
+		reply := r.lastMessage()
+		reply.Citations = citations
+		reply.Usage = r.recordUsage(r.session.Conversation.Model, messages, fullResponse.String(), nil, time.Since(started))
+		r.printStatusLine()
+
+		// Notify scripts of the response
+		responseEvent := r.scriptEvent(scripting.HookResponse)
+		responseEvent.Response = fullResponse.String()
+		r.runScriptHook(ctx, responseEvent)
 
 		// Trigger recovery save after message
 		if r.autoRecovery != nil {
@@ -644,21 +1005,44 @@ func (r *REPL) processMessage(message string) error {
 	} else {
 		logging.LogDebug("Using non-streaming mode")
 		// Non-streaming response
+		spinner := r.startSpinner()
 		resp, err := r.provider.GenerateMessage(ctx, messages, opts...)
+		spinner.Stop()
+		if err != nil && ctx.Err() != nil {
+			logging.LogInfo("Generation cancelled")
+			return ErrGenerationCancelled
+		}
 		if err != nil {
 			logging.LogError(err, "Failed to generate message")
 			return fmt.Errorf("failed to generate response: %w", err)
 		}
 
+		// Moderate the response before printing it
+		if err := r.moderate(ctx, llm.ModerationStageResponse, resp.Content); err != nil {
+			r.discardPendingMessage()
+			return err
+		}
+
 		// Print response
 		content := resp.Content
-		if r.colorFormatter.Enabled() {
+		if r.renderMarkdown {
+			content = strings.TrimRight(ui.RenderMarkdown(content), "\n")
+		} else if r.colorFormatter.Enabled() {
 			content = r.colorFormatter.FormatAssistantMessage(content)
 		}
 		fmt.Fprintf(r.writer, "\n%s\n\n", content)
 
 		// Add assistant message to conversation
 		AddMessageToConversation(r.session.Conversation, "assistant", resp.Content, nil)
+		reply := r.lastMessage()
+		reply.Citations = citations
+		reply.Usage = r.recordUsage(r.session.Conversation.Model, messages, resp.Content, resp.Usage, time.Since(started))
+		r.printStatusLine()
+
+		// Notify scripts of the response
+		responseEvent := r.scriptEvent(scripting.HookResponse)
+		responseEvent.Response = resp.Content
+		r.runScriptHook(ctx, responseEvent)
 
 		// Trigger recovery save after message
 		if r.autoRecovery != nil {
@@ -670,48 +1054,131 @@ func (r *REPL) processMessage(message string) error {
 		}
 	}
 
+	r.warnSessionLimits()
 	return nil
 }
 
+// lastMessage returns the last message in the conversation so it can be
+// annotated after it is added
+func (r *REPL) lastMessage() *domain.Message {
+	messages := r.session.Conversation.Messages
+	return &messages[len(messages)-1]
+}
+
+// discardPendingMessage removes the user message whose response was rejected,
+// so the conversation holds no prompt without an answer
+func (r *REPL) discardPendingMessage() {
+	messages := r.session.Conversation.Messages
+	if len(messages) > 0 && messages[len(messages)-1].Role == domain.MessageRoleUser {
+		r.session.Conversation.Messages = messages[:len(messages)-1]
+	}
+}
+
+// moderate runs the moderation stage on text and records flagged results in
+// session metadata
+func (r *REPL) moderate(ctx context.Context, stage llm.ModerationStage, text string) error {
+	record, err := r.moderation.Check(ctx, stage, text)
+	if record != nil && record.Flagged {
+		if r.session.Metadata == nil {
+			r.session.Metadata = make(map[string]interface{})
+		}
+		llm.AppendModerationRecord(r.session.Metadata, *record)
+
+		if !record.Blocked {
+			warning := fmt.Sprintf("Warning: %s flagged by moderation (%s)", stage, strings.Join(record.Categories, ", "))
+			if r.colorFormatter.Enabled() {
+				warning = r.colorFormatter.FormatWarning(warning)
+			}
+			fmt.Fprintln(r.writer, warning)
+		}
+	}
+	return err
+}
+
 // handleCommand handles REPL commands (starting with /)
 func (r *REPL) handleCommand(cmd string) error {
 	logging.LogDebug("Handling command", "cmd", cmd)
 
 	// Add to command history
-	r.cmdHistory = append(r.cmdHistory, cmd)
+	r.addCommandHistory(cmd)
+
+	if stages := splitCommandPipeline(cmd); len(stages) > 1 {
+		return r.runPipeline(stages)
+	}
 
 	parts := strings.Fields(cmd)
 	if len(parts) == 0 {
 		return nil
 	}
 
-	commandName := strings.TrimPrefix(parts[0], "/")
-	args := parts[1:]
+	_, err := r.dispatchCommand(strings.TrimPrefix(parts[0], "/"), parts[1:], nil)
+	return err
+}
+
+// dispatchCommand runs a command by name. input is the previous stage of
+// a pipeline, or nil. It returns the exec.Data of registry commands.
+func (r *REPL) dispatchCommand(commandName string, args []string, input *command.PipelineInput) (map[string]interface{}, error) {
 	logging.LogDebug("Parsed command", "command", commandName, "argCount", len(args))
 
+	// Let scripts observe or cancel the command
+	event := r.scriptEvent(scripting.HookCommand)
+	event.Command = commandName
+	event.Args = args
+	if reply := r.runScriptHook(r.baseContext(), event); reply.Cancel {
+		return nil, nil
+	}
+
+	// Aliases run inside a pipeline stage keep its input
+	if input != nil {
+		r.pipelineInput = input
+		defer func() { r.pipelineInput = nil }()
+	}
+
 	// Look up command in registry
-	cmdInterface, err := r.registry.Get(commandName)
+	cmdInterface, deprecation, err := r.registry.Resolve(commandName)
 	if err != nil {
+		// User-defined aliases and script commands come before legacy commands
+		if found, err := r.expandAlias(commandName, args); found {
+			return nil, err
+		}
+		if found, err := r.runScriptCommand(commandName, args); found {
+			return nil, err
+		}
+
 		// Command not found in registry, check legacy commands
 		commandName = "/" + commandName
-		return r.handleLegacyCommand(commandName, args)
+		return nil, r.handleLegacyCommand(commandName, args)
 	}
 
+	command.WarnDeprecated(r.writer, deprecation)
+	command.WarnDeprecated(r.writer, cmdInterface.Metadata().Deprecation())
+
 	// Create execution context with shared context
 	execCtx := CreateCommandContextWithShared(args, r.reader, r.writer, r.writer, r.sharedContext)
 	execCtx.Config = r.config
+	if input != nil {
+		command.SetPipelineInput(execCtx, input)
+	}
 
 	// Execute the command
-	ctx := context.Background()
+	ctx := r.baseContext()
 	if err := cmdInterface.Execute(ctx, execCtx); err != nil {
 		// Handle special exit case
 		if errors.Is(err, io.EOF) {
 			os.Exit(0)
 		}
-		return err
+		return nil, err
 	}
 
-	return nil
+	return execCtx.Data, nil
+}
+
+// addCommandHistory records a command, skipping a repeat of the previous one
+func (r *REPL) addCommandHistory(cmd string) {
+	if n := len(r.cmdHistory); n > 0 && r.cmdHistory[n-1] == cmd {
+		return
+	}
+	r.cmdHistory = append(r.cmdHistory, cmd)
 }
 
 // handleLegacyCommand handles commands not yet migrated to the registry
@@ -728,7 +1195,7 @@ func (r *REPL) handleSpecialCommand(cmd string) error {
 	logging.LogDebug("Handling special command", "cmd", cmd)
 
 	// Add to command history
-	r.cmdHistory = append(r.cmdHistory, cmd)
+	r.addCommandHistory(cmd)
 
 	parts := strings.Fields(cmd)
 	if len(parts) == 0 {
@@ -751,7 +1218,7 @@ func (r *REPL) handleSpecialCommand(cmd string) error {
 	execCtx.Config = r.config
 
 	// Execute the command
-	ctx := context.Background()
+	ctx := r.baseContext()
 	return cmdInterface.Execute(ctx, execCtx)
 }
 
@@ -766,19 +1233,21 @@ magellai chat - Interactive LLM chat
 COMMANDS:
   /help              Show this help message
   /exit, /quit       Exit the chat session
-  /save [name]       Save the current session
+  /save [name]       Save the current session (--template <name> saves it as a session template)
+  /new [name]        Start a new session (--template <name> starts from a session template)
+  /rename <name>     Rename the current session
   /load <id>         Load a previous session
   /reset             Clear the conversation history
   /model             Show current model
   /system [prompt]   Set or show system prompt
   /history           Show conversation history
   /sessions          List all sessions
-  /search <query>    Search sessions by content
+  /search <query>    Search sessions (tag:work, model:, role:, before:, "phrase")
   /attach <file>     Attach a file to the next message
   /attachments       List current attachments
   /config show       Display current configuration
   /config set <k> <v> Set configuration value
-  /export <fmt> [f]  Export session (json, markdown)
+  /export <fmt> [f]  Export session (json, markdown, org, text, jsonl, csv)
   /tags              List tags for current session
   /tag <tag>         Add a tag to current session
   /untag <tag>       Remove a tag from current session
@@ -789,7 +1258,37 @@ COMMANDS:
   /branches          List all branches of current session
   /tree              Show session branch tree
   /switch <id>       Switch to a different branch
-  /merge <source_id> Merge another session into current
+  /merge <source_id> Merge another session into current (--dry-run previews it)
+  /diff <a> [b]      Show message differences between sessions (default: current vs a)
+  /estimate [msg]    Estimate tokens and cost of the next request
+  /stats             Show message, token, cost, attachment, and branch statistics
+  /edit [n] [text]   Edit user message n (default last) and regenerate on a new branch
+  /revisions [n]     Show the earlier versions of edited message n (default last edited)
+  /ephemeral [ttl|off] Delete the session automatically after ttl (e.g. 24h)
+  /pin [n]           Pin message n so it is always kept in the context (no n lists pinned)
+  /unpin <n>         Unpin message n
+  /undo              Remove the last user message and its response
+  /redo              Restore the exchange removed by /undo
+  /summarize [n]     Replace the first n (default all but recent) messages with a summary on a new branch
+  /compare <a> <b> [prompt]  Ask two models on separate branches and pick one to continue
+  /copy [n] [code]   Copy the last (or nth) response, or its first code block
+  /pipe <command>    Send the last response to a shell command's stdin
+  /cmd | /cmd        Send one command's output to the next (e.g. /history | /pipe grep -i error)
+  /paste             Attach the image on the clipboard to the next message
+  /workspace [reload] Show or reload the project workspace (.magellai/workspace.yaml)
+  /alias [name cmd]  List aliases or define one (e.g. /alias t :temperature)
+                     Definitions may use $1, $@ and ${flag} (e.g. /alias r /ask Review: $1)
+  /unalias <name>    Remove an alias
+  /scripts [reload]  List or reload user scripts (~/.config/magellai/scripts)
+  /index <dir> [name] Index a directory for retrieval (/index list|use|remove)
+  /retrieve <query>  Attach the most relevant indexed excerpts to the next message
+  /retrieve auto on|off  Retrieve excerpts for every message automatically
+  /template [list]   List prompt templates
+  /template show <n> Show a template and its variables
+  /template use <n> [var=value ...]  Send a filled-in template (@file reads a file)
+  /prompt [list]     List named prompts from config
+  /prompt show <n>   Show a prompt and its variables
+  /prompt run <n> [--var name=value ...]  Send a filled-in prompt
 
 SPECIAL COMMANDS:
   :model <name>         Switch to a different model
@@ -804,8 +1303,18 @@ SPECIAL COMMANDS:
   :attach-list       List all pending attachments
   :system [prompt]   Set or show system prompt
   :multiline         Toggle multi-line input mode
-
-Type your message and press Enter to send.
+  :render on/off     Toggle markdown rendering of responses
+
+SHELL:
+  !<command>         Run a shell command and show its output
+  !><command>        Run a shell command and add its output to the next message
+
+Commands in ~/.config/magellai/replrc run at startup (skip with chat --no-rc).
+Commands declared in ~/.config/magellai/commands run as /<name>.
+Type your message and press Enter to send. Use @path/to/file in a message to attach a file.
+Pasted text is sent as one message; a line opening a code fence (three backticks) continues until it is closed.
+Press Ctrl-R to search earlier input, Up/Down to step through it.
+Press Ctrl-C to cancel a response or clear the line; press it twice to exit.
 `)
 	return nil
 }
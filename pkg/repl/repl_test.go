@@ -247,6 +247,85 @@ func TestREPL_processMessageWithAttachments(t *testing.T) {
 	assert.False(t, exists)
 }
 
+func TestREPL_processMessageModeration(t *testing.T) {
+	t.Run("warn records audit and keeps message", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		repl.moderation = &llm.ModerationPolicy{
+			Moderator:      llm.NewKeywordModerator([]string{"forbidden"}),
+			Action:         llm.ModerationActionWarn,
+			CheckPrompts:   true,
+			CheckResponses: true,
+		}
+
+		err := repl.processMessage("a forbidden topic")
+		require.NoError(t, err)
+
+		assert.Len(t, repl.session.Conversation.Messages, 2)
+		assert.Contains(t, output.String(), "Warning: prompt flagged by moderation")
+
+		trail, ok := repl.session.Metadata[llm.ModerationMetadataKey].([]interface{})
+		require.True(t, ok)
+		assert.Len(t, trail, 2) // prompt and response checks
+	})
+
+	t.Run("block rejects prompt", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		repl.moderation = &llm.ModerationPolicy{
+			Moderator:    llm.NewKeywordModerator([]string{"forbidden"}),
+			Action:       llm.ModerationActionBlock,
+			CheckPrompts: true,
+		}
+
+		err := repl.processMessage("a forbidden topic")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, llm.ErrContentBlocked)
+		assert.Empty(t, repl.session.Conversation.Messages)
+
+		trail, ok := repl.session.Metadata[llm.ModerationMetadataKey].([]interface{})
+		require.True(t, ok)
+		require.Len(t, trail, 1)
+		entry := trail[0].(map[string]interface{})
+		assert.Equal(t, true, entry["blocked"])
+	})
+
+	t.Run("block rejects response", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		repl.moderation = &llm.ModerationPolicy{
+			Moderator:      llm.NewKeywordModerator([]string{"mock response"}),
+			Action:         llm.ModerationActionBlock,
+			CheckResponses: true,
+		}
+
+		err := repl.processMessage("Hello")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, llm.ErrContentBlocked)
+		assert.Empty(t, repl.session.Conversation.Messages)
+		assert.NotContains(t, output.String(), "Mock response to")
+	})
+
+	t.Run("passing checks are not recorded", func(t *testing.T) {
+		repl, _, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		repl.moderation = &llm.ModerationPolicy{
+			Moderator:      llm.NewKeywordModerator([]string{"forbidden"}),
+			Action:         llm.ModerationActionBlock,
+			CheckPrompts:   true,
+			CheckResponses: true,
+		}
+
+		require.NoError(t, repl.processMessage("Hello"))
+		assert.Len(t, repl.session.Conversation.Messages, 2)
+		assert.NotContains(t, repl.session.Metadata, llm.ModerationMetadataKey)
+	})
+}
+
 func TestREPL_handleCommand_Help(t *testing.T) {
 	repl, output, cleanup := setupTestREPL(t)
 	defer cleanup()
@@ -424,3 +503,13 @@ func TestREPL_readInput_MultiLine(t *testing.T) {
 	// Should combine lines
 	assert.Equal(t, "Line 1\nLine 2", text)
 }
+
+func TestREPL_addCommandHistory(t *testing.T) {
+	repl, _, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	for _, cmd := range []string{"/help", "/help", ":model", "/help"} {
+		repl.addCommandHistory(cmd)
+	}
+	assert.Equal(t, []string{"/help", ":model", "/help"}, repl.cmdHistory)
+}
@@ -0,0 +1,159 @@
+// ABOUTME: Batch execution of REPL scripts for automation
+// ABOUTME: Runs commands and prompts from a file or stdin and reports each step as JSON
+
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// scriptStep is one command or prompt of a script
+type scriptStep struct {
+	Line  int
+	Input string
+}
+
+// scriptResult is the JSON line written for each script step
+type scriptResult struct {
+	Step     int    `json:"step"`
+	Line     int    `json:"line"`
+	Type     string `json:"type"` // command or message
+	Input    string `json:"input"`
+	OK       bool   `json:"ok"`
+	Output   string `json:"output,omitempty"`
+	Response string `json:"response,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Session  string `json:"session"`
+	Error    string `json:"error,omitempty"`
+}
+
+// parseScript splits a script into steps. Lines starting with /, : or ! are
+// commands; other lines are prompts, with consecutive lines joined into one
+// message until a blank line or command. A ``` fence keeps blank lines and
+// command-like lines inside the message. Lines starting with # are comments.
+func parseScript(r io.Reader) ([]scriptStep, error) {
+	var steps []scriptStep
+	var message []string
+	messageLine := 0
+
+	flush := func() {
+		if len(message) > 0 {
+			steps = append(steps, scriptStep{Line: messageLine, Input: strings.Join(message, "\n")})
+			message = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		inFence := len(message) > 0 && hasOpenFence(strings.Join(message, "\n"))
+
+		switch trimmed := strings.TrimSpace(line); {
+		case inFence:
+			message = append(message, line)
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "/") || strings.HasPrefix(trimmed, ":") || strings.HasPrefix(trimmed, "!"):
+			flush()
+			steps = append(steps, scriptStep{Line: lineNum, Input: trimmed})
+		default:
+			if len(message) == 0 {
+				messageLine = lineNum
+			}
+			message = append(message, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+	flush()
+	return steps, nil
+}
+
+// runScript executes a script non-interactively and writes one JSON object
+// per step. It stops at the first failing step and returns its error.
+func (r *REPL) runScript(path string) error {
+	var input io.Reader = r.reader
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open script: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	steps, err := parseScript(input)
+	if err != nil {
+		return err
+	}
+	logging.LogInfo("Running script", "path", path, "steps", len(steps))
+
+	out := r.writer
+	encoder := json.NewEncoder(out)
+	defer func() { r.writer = out }()
+
+	for i, step := range steps {
+		result := scriptResult{Step: i + 1, Line: step.Line, Input: step.Input, Type: "message"}
+
+		// Capture what the step prints so it can be reported as JSON
+		var captured bytes.Buffer
+		r.writer = &captured
+		if isCommandLine(step.Input) {
+			result.Type = "command"
+			err = r.runLine(step.Input)
+		} else {
+			err = r.processMessage(step.Input)
+			if err == nil {
+				if msg := GetLastAssistantMessage(r.session.Conversation); msg != nil {
+					result.Response = msg.Content
+				}
+			}
+		}
+		r.writer = out
+
+		result.OK = err == nil
+		result.Output = strings.TrimSpace(captured.String())
+		if result.Type == "message" && result.Output == strings.TrimSpace(result.Response) {
+			result.Output = ""
+		}
+		result.Model = r.session.Conversation.Model
+		result.Session = r.session.ID
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if encErr := encoder.Encode(result); encErr != nil {
+			return fmt.Errorf("failed to write script output: %w", encErr)
+		}
+
+		if err != nil {
+			logging.LogWarn("Script step failed", "line", step.Line, "error", err)
+			return fmt.Errorf("script line %d: %w", step.Line, err)
+		}
+	}
+
+	// Keep the conversation the script produced
+	if r.manager.ReadOnly() {
+		return nil
+	}
+	if err := r.manager.SaveSession(r.session); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// isCommandLine reports whether a line is a REPL command rather than a prompt
+func isCommandLine(line string) bool {
+	return strings.HasPrefix(line, "/") || strings.HasPrefix(line, ":") || strings.HasPrefix(line, "!")
+}
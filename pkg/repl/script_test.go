@@ -0,0 +1,89 @@
+// ABOUTME: Tests for running REPL scripts non-interactively
+// ABOUTME: Verifies script parsing, JSON step output, and stopping on errors
+
+package repl
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScript(t *testing.T) {
+	steps, err := parseScript(strings.NewReader(`# setup
+:temperature 0.2
+first line
+second line
+
+/history
+` + "```" + `
+/not a command
+
+inside fence
+` + "```" + `
+`))
+	require.NoError(t, err)
+	require.Len(t, steps, 4)
+	assert.Equal(t, scriptStep{Line: 2, Input: ":temperature 0.2"}, steps[0])
+	assert.Equal(t, scriptStep{Line: 3, Input: "first line\nsecond line"}, steps[1])
+	assert.Equal(t, scriptStep{Line: 6, Input: "/history"}, steps[2])
+	assert.Equal(t, 7, steps[3].Line)
+	assert.Contains(t, steps[3].Input, "/not a command\n\ninside fence")
+}
+
+func TestREPL_runScript(t *testing.T) {
+	readResults := func(t *testing.T, output string) []scriptResult {
+		var results []scriptResult
+		scanner := bufio.NewScanner(strings.NewReader(output))
+		for scanner.Scan() {
+			var result scriptResult
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &result), scanner.Text())
+			results = append(results, result)
+		}
+		return results
+	}
+
+	t.Run("emits one JSON result per step", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+
+		script := filepath.Join(t.TempDir(), "commands.txt")
+		require.NoError(t, os.WriteFile(script, []byte(":temperature 0.3\nhello\n"), 0644))
+
+		require.NoError(t, repl.runScript(script))
+		results := readResults(t, output.String())
+		require.Len(t, results, 2)
+
+		assert.Equal(t, "command", results[0].Type)
+		assert.True(t, results[0].OK)
+		assert.Contains(t, results[0].Output, "0.3")
+
+		assert.Equal(t, "message", results[1].Type)
+		assert.Equal(t, "hello", results[1].Input)
+		assert.Equal(t, "Mock response to: hello", results[1].Response)
+		assert.Equal(t, repl.session.ID, results[1].Session)
+		assert.Equal(t, 0.3, repl.session.Conversation.Temperature)
+	})
+
+	t.Run("reads stdin and stops at the first error", func(t *testing.T) {
+		repl, output, cleanup := setupTestREPL(t)
+		defer cleanup()
+		repl.reader = bufio.NewReader(strings.NewReader("/nosuchcommand\nhello\n"))
+
+		err := repl.runScript("-")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "script line 1")
+
+		results := readResults(t, output.String())
+		require.Len(t, results, 1)
+		assert.False(t, results[0].OK)
+		assert.NotEmpty(t, results[0].Error)
+		assert.Empty(t, repl.session.Conversation.Messages)
+	})
+}
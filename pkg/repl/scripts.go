@@ -0,0 +1,130 @@
+// ABOUTME: User script hooks and script-provided slash commands for the REPL
+// ABOUTME: Loads scripts from the config dir and runs them around messages and commands
+
+package repl
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/lexlapax/magellai/internal/configdir"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/scripting"
+)
+
+// loadScripts loads user scripts when repl.scripts.enabled is set, reporting
+// scripts that could not be loaded to w
+func (r *REPL) loadScripts(w io.Writer) {
+	r.scripts = nil
+	if !r.config.GetBool("repl.scripts.enabled") {
+		return
+	}
+
+	dir := r.config.GetString("repl.scripts.directory")
+	if dir == "" {
+		paths, err := configdir.GetPaths()
+		if err != nil {
+			logging.LogWarn("Failed to locate scripts directory", "error", err)
+			return
+		}
+		dir = paths.Scripts
+	}
+
+	engine, warnings := scripting.Load(r.baseContext(), dir)
+	for _, err := range warnings {
+		logging.LogWarn("Failed to load script", "error", err)
+		fmt.Fprintf(w, "Warning: %v\n", err)
+	}
+	r.scripts = engine
+}
+
+// scriptEvent returns an event for hook filled in with the session state
+func (r *REPL) scriptEvent(hook scripting.Hook) scripting.Event {
+	return scripting.Event{
+		Hook:      hook,
+		SessionID: r.session.ID,
+		Model:     r.session.Conversation.Model,
+	}
+}
+
+// runScriptHook runs the scripts registered for an event and prints their
+// output. A failing script is reported and otherwise ignored.
+func (r *REPL) runScriptHook(ctx context.Context, event scripting.Event) scripting.Reply {
+	if r.scripts == nil {
+		return scripting.Reply{}
+	}
+	reply, err := r.scripts.RunHook(ctx, event)
+	if err != nil {
+		logging.LogWarn("Script hook failed", "hook", event.Hook, "error", err)
+		fmt.Fprintf(r.writer, "Warning: %v\n", err)
+		return scripting.Reply{}
+	}
+	if reply.Output != "" {
+		fmt.Fprintln(r.writer, reply.Output)
+	}
+	return reply
+}
+
+// runScriptCommand runs a script-provided slash command, reporting whether
+// one was found. A reply with send set is sent to the model as a message.
+func (r *REPL) runScriptCommand(name string, args []string) (bool, error) {
+	if r.scripts == nil || !r.scripts.HasCommand(name) {
+		return false, nil
+	}
+
+	event := r.scriptEvent("")
+	event.Command = name
+	event.Args = args
+	reply, err := r.scripts.RunCommand(r.baseContext(), event)
+	if err != nil {
+		return true, err
+	}
+	if reply.Output != "" {
+		fmt.Fprintln(r.writer, reply.Output)
+	}
+	if reply.Send != "" {
+		return true, r.processMessage(reply.Send)
+	}
+	return true, nil
+}
+
+// cmdScripts lists loaded scripts or reloads them.
+//
+//	/scripts         list scripts with their hooks and commands
+//	/scripts reload  load the scripts directory again
+func (r *REPL) cmdScripts(args []string) error {
+	if len(args) > 0 {
+		if args[0] != "reload" {
+			return fmt.Errorf("usage: /scripts [reload]")
+		}
+		r.loadScripts(r.writer)
+		if r.scripts == nil {
+			fmt.Fprintln(r.writer, "Scripts are disabled (repl.scripts.enabled is false).")
+			return nil
+		}
+		fmt.Fprintf(r.writer, "Loaded %d scripts from %s\n", len(r.scripts.Scripts()), r.scripts.Dir)
+		return nil
+	}
+
+	if r.scripts == nil {
+		fmt.Fprintln(r.writer, "Scripts are disabled (repl.scripts.enabled is false).")
+		return nil
+	}
+	if len(r.scripts.Scripts()) == 0 {
+		fmt.Fprintf(r.writer, "No scripts loaded (directory: %s)\n", r.scripts.Dir)
+		return nil
+	}
+
+	fmt.Fprintf(r.writer, "Scripts (%s):\n", r.scripts.Dir)
+	for _, script := range r.scripts.Scripts() {
+		fmt.Fprintf(r.writer, "  %s\n", script.Name)
+		if len(script.Hooks) > 0 {
+			fmt.Fprintf(r.writer, "    hooks: %v\n", script.Hooks)
+		}
+		for _, cmd := range script.Commands {
+			fmt.Fprintf(r.writer, "    /%-12s %s\n", cmd.Name, cmd.Description)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// ABOUTME: Tests for user script hooks and script commands in the REPL
+// ABOUTME: Verifies message rewriting, cancellation, response hooks, and /scripts
+
+package repl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPL_scripts(t *testing.T) {
+	dir := t.TempDir()
+	script := `
+def on_message_send(event):
+    if "secret" in event.message:
+        return {"cancel": True, "output": "refusing to send secrets"}
+    return {"message": "rewritten"}
+
+def on_response(event):
+    return {"output": "script saw: " + event.response}
+
+def on_command(event):
+    if event.command == "blocked":
+        return {"cancel": True, "output": "blocked by script"}
+
+def greet(event):
+    return {"output": "greeting the model", "send": "hello from script"}
+
+command("greet", greet, description = "Say hello")
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hooks.star"), []byte(script), 0644))
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+	repl.config.(*testConfig).values["repl.scripts.enabled"] = true
+	repl.config.(*testConfig).values["repl.scripts.directory"] = dir
+	repl.loadScripts(output)
+	require.NotNil(t, repl.scripts)
+
+	var sent []string
+	provider := newMockProvider()
+	provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+		sent = append(sent, messages[len(messages)-1].Content)
+		return &llm.Response{Content: "model reply"}, nil
+	}
+	repl.provider = provider
+
+	t.Run("on_message_send rewrites the message", func(t *testing.T) {
+		require.NoError(t, repl.processMessage("original"))
+		assert.Equal(t, []string{"rewritten"}, sent)
+		assert.Contains(t, output.String(), "script saw: model reply")
+	})
+
+	t.Run("on_message_send can cancel", func(t *testing.T) {
+		sent = nil
+		require.NoError(t, repl.processMessage("my secret"))
+		assert.Empty(t, sent)
+		assert.Contains(t, output.String(), "refusing to send secrets")
+	})
+
+	t.Run("script command sends a message", func(t *testing.T) {
+		sent = nil
+		require.NoError(t, repl.handleCommand("/greet"))
+		assert.Contains(t, output.String(), "greeting the model")
+		assert.Equal(t, []string{"rewritten"}, sent)
+	})
+
+	t.Run("on_command can cancel", func(t *testing.T) {
+		output.Reset()
+		require.NoError(t, repl.handleCommand("/blocked"))
+		assert.Contains(t, output.String(), "blocked by script")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		output.Reset()
+		require.NoError(t, repl.handleCommand("/scripts"))
+		assert.Contains(t, output.String(), "hooks")
+		assert.Contains(t, output.String(), "/greet")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		repl.config.(*testConfig).values["repl.scripts.enabled"] = false
+		output.Reset()
+		require.NoError(t, repl.handleCommand("/scripts reload"))
+		assert.Nil(t, repl.scripts)
+		assert.Contains(t, output.String(), "disabled")
+	})
+}
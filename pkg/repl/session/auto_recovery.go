@@ -284,3 +284,39 @@ func (arm *AutoRecoveryManager) GetLastSaveTime() time.Time {
 func (arm *AutoRecoveryManager) ForceRecoverySave() error {
 	return arm.SaveRecoveryState()
 }
+
+// RecoveryFile is a recovery state read from the recovery directory
+type RecoveryFile struct {
+	Path  string
+	State *RecoveryState
+}
+
+// ReadRecoveryFiles returns the recovery states in the recovery directory: the
+// current file and its rotated backups, whatever their age. Files that cannot
+// be read are skipped.
+func ReadRecoveryFiles(config *AutoRecoveryConfig) ([]RecoveryFile, error) {
+	if config == nil {
+		config = DefaultAutoRecoveryConfig()
+	}
+
+	paths, err := filepath.Glob(filepath.Join(config.RecoveryDirectory, config.RecoveryFile+"*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recovery files: %w", err)
+	}
+
+	var files []RecoveryFile
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logging.LogDebug("Skipping unreadable recovery file", "path", path, "error", err)
+			continue
+		}
+		var state RecoveryState
+		if err := json.Unmarshal(data, &state); err != nil || state.ConversationData == nil {
+			logging.LogDebug("Skipping invalid recovery file", "path", path, "error", err)
+			continue
+		}
+		files = append(files, RecoveryFile{Path: path, State: &state})
+	}
+	return files, nil
+}
@@ -0,0 +1,47 @@
+// ABOUTME: Tracing spans and metrics around the storage operations of the storage manager
+// ABOUTME: Spans are children of the span in the context the manager was given, such as a command's
+
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/metrics"
+	"github.com/lexlapax/magellai/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetTraceContext sets the context the spans of storage operations are
+// started in, so they become children of the span it holds
+func (sm *StorageManager) SetTraceContext(ctx context.Context) {
+	sm.traceCtx = ctx
+}
+
+// storageOp is one instrumented storage operation
+type storageOp struct {
+	backend   string
+	operation string
+	span      trace.Span
+	started   time.Time
+}
+
+// startOp starts instrumenting a storage operation
+func (sm *StorageManager) startOp(operation, sessionID string) *storageOp {
+	backend := string(sm.backendType)
+	attrs := []attribute.KeyValue{tracing.AttrStorage.String(backend)}
+	if sessionID != "" {
+		attrs = append(attrs, tracing.AttrSessionID.String(sessionID))
+	}
+	_, span := tracing.Start(sm.traceCtx, "storage."+operation, attrs...)
+	return &storageOp{backend: backend, operation: operation, span: span, started: time.Now()}
+}
+
+// end records the operation's duration and the error it returned
+func (op *storageOp) end(err *error) {
+	metrics.StorageOperations.Inc(op.backend, op.operation, metrics.Result(*err))
+	metrics.StorageOperationDuration.Observe(time.Since(op.started).Seconds(), op.backend, op.operation)
+	op.span.SetAttributes(tracing.Latency(op.started))
+	tracing.End(op.span, *err)
+}
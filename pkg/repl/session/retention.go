@@ -0,0 +1,44 @@
+// ABOUTME: Session retention that deletes expired and ephemeral sessions
+// ABOUTME: Applies per-session expiry times and the configured maximum session age
+
+package session
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// PurgeExpiredSessions deletes the sessions whose expiry time has passed and,
+// when maxAge is positive, the sessions not updated within maxAge. It returns
+// the IDs of the deleted sessions. Read-only storage is left alone.
+func (sm *StorageManager) PurgeExpiredSessions(maxAge time.Duration) ([]string, error) {
+	if sm.readOnly {
+		return nil, nil
+	}
+
+	sessions, err := sm.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	now := time.Now()
+	var deleted []string
+	for _, info := range sessions {
+		expired := info.IsExpired(now) || (maxAge > 0 && now.Sub(info.Updated) > maxAge)
+		if !expired {
+			continue
+		}
+		if err := sm.DeleteSession(info.ID); err != nil {
+			logging.LogWarn("Failed to delete expired session", "id", info.ID, "error", err)
+			continue
+		}
+		deleted = append(deleted, info.ID)
+	}
+
+	if len(deleted) > 0 {
+		logging.LogInfo("Deleted expired sessions", "count", len(deleted), "maxAge", maxAge)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,48 @@
+// ABOUTME: Tests for session retention
+// ABOUTME: Ensures expired and too-old sessions are deleted and others are kept
+
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageManager_PurgeExpiredSessions(t *testing.T) {
+	backend := NewMockStorageBackend()
+	manager, err := NewStorageManager(backend)
+	require.NoError(t, err)
+
+	expired := domain.NewSession("expired")
+	past := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &past
+
+	ephemeral := domain.NewSession("ephemeral")
+	ephemeral.SetExpiry(time.Hour)
+
+	old := domain.NewSession("old")
+	old.Updated = time.Now().Add(-48 * time.Hour)
+
+	kept := domain.NewSession("kept")
+
+	for _, s := range []*domain.Session{expired, ephemeral, old, kept} {
+		require.NoError(t, backend.Create(s))
+	}
+
+	// Without a maximum age only expired sessions are deleted
+	deleted, err := manager.PurgeExpiredSessions(0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"expired"}, deleted)
+
+	deleted, err = manager.PurgeExpiredSessions(24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old"}, deleted)
+
+	infos, err := manager.ListSessions()
+	require.NoError(t, err)
+	assert.Len(t, infos, 2)
+}
@@ -34,7 +34,10 @@ func (sm *SessionManager) NewSession(name string) (*domain.Session, error) {
 	logging.LogInfo("Creating new session", "name", name)
 	session := sm.StorageManager.NewSession(name)
 
-	// Save the initial session
+	// Save the initial session; with read-only storage it is kept in memory
+	if sm.ReadOnly() {
+		return session, nil
+	}
 	if err := sm.StorageManager.SaveSession(session); err != nil {
 		logging.LogError(err, "Failed to save new session", "id", session.ID)
 		return nil, err
@@ -0,0 +1,143 @@
+// ABOUTME: Session snapshots taken by the storage manager and point-in-time restore
+// ABOUTME: Snapshots sessions periodically on save and before destructive operations, pruning to the configured count
+
+package session
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// SetSnapshotPolicy sets when snapshots are taken and how many are kept
+func (sm *StorageManager) SetSnapshotPolicy(policy storage.SnapshotPolicy) {
+	sm.snapshots = policy
+}
+
+// SnapshotStore returns the backend's snapshot storage, if it has one
+func (sm *StorageManager) SnapshotStore() (storage.SnapshotStore, bool) {
+	store, ok := sm.storeBackend().(storage.SnapshotStore)
+	return store, ok
+}
+
+// Snapshot records the current state of a session, dropping its oldest
+// snapshots beyond the number kept. It returns nil without doing anything
+// when snapshots are off or the backend cannot keep them.
+func (sm *StorageManager) Snapshot(session *domain.Session, reason string) (*domain.SessionSnapshot, error) {
+	store, ok := sm.SnapshotStore()
+	if !ok || !sm.snapshots.Enabled() {
+		return nil, nil
+	}
+	if sm.readOnly {
+		return nil, fmt.Errorf("cannot snapshot session %s: %w", session.ID, storage.ErrReadOnly)
+	}
+
+	snapshot := domain.NewSessionSnapshot(session, reason)
+	if err := store.SaveSnapshot(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to snapshot session %s: %w", session.ID, err)
+	}
+	if sm.lastSnapshot == nil {
+		sm.lastSnapshot = make(map[string]time.Time)
+	}
+	sm.lastSnapshot[session.ID] = snapshot.Created
+	logging.LogDebug("Snapshotted session", "session_id", session.ID, "snapshot_id", snapshot.ID, "reason", reason)
+
+	sm.pruneSnapshots(store, session.ID)
+	snapshot.Session = nil
+	return snapshot, nil
+}
+
+// snapshotIfDue snapshots a session that was just saved when its last
+// snapshot is older than the snapshot interval. Failures are logged; the
+// session itself was saved.
+func (sm *StorageManager) snapshotIfDue(session *domain.Session) {
+	store, ok := sm.SnapshotStore()
+	if !ok || !sm.snapshots.Enabled() || sm.snapshots.Interval <= 0 || sm.readOnly {
+		return
+	}
+
+	last, known := sm.lastSnapshot[session.ID]
+	if !known {
+		// Snapshots taken by earlier runs count towards the interval
+		if snapshots, err := store.ListSnapshots(session.ID); err == nil && len(snapshots) > 0 {
+			last = snapshots[len(snapshots)-1].Created
+		}
+	}
+	if time.Since(last) < sm.snapshots.Interval {
+		return
+	}
+	if _, err := sm.Snapshot(session, domain.SnapshotReasonInterval); err != nil {
+		logging.LogWarn("Failed to snapshot session", "session_id", session.ID, "error", err)
+	}
+}
+
+// pruneSnapshots deletes the oldest snapshots of a session beyond the
+// number kept
+func (sm *StorageManager) pruneSnapshots(store storage.SnapshotStore, sessionID string) {
+	snapshots, err := store.ListSnapshots(sessionID)
+	if err != nil {
+		logging.LogWarn("Failed to list snapshots for pruning", "session_id", sessionID, "error", err)
+		return
+	}
+	for i := 0; i < len(snapshots)-sm.snapshots.Keep; i++ {
+		if err := store.DeleteSnapshot(sessionID, snapshots[i].ID); err != nil {
+			logging.LogWarn("Failed to delete old snapshot", "session_id", sessionID, "snapshot_id", snapshots[i].ID, "error", err)
+		}
+	}
+}
+
+// ListSnapshots returns the snapshots of a session, oldest first
+func (sm *StorageManager) ListSnapshots(sessionID string) ([]*domain.SessionSnapshot, error) {
+	store, ok := sm.SnapshotStore()
+	if !ok {
+		return nil, fmt.Errorf("%s storage does not keep snapshots", sm.backendType)
+	}
+	return store.ListSnapshots(sessionID)
+}
+
+// RestoreSession rolls a session back to the newest snapshot taken at or
+// before at. The current state is snapshotted first, so a restore can itself
+// be undone by restoring to a later time. It returns the snapshot restored.
+func (sm *StorageManager) RestoreSession(sessionID string, at time.Time) (*domain.SessionSnapshot, error) {
+	if sm.readOnly {
+		return nil, fmt.Errorf("cannot restore session %s: %w", sessionID, storage.ErrReadOnly)
+	}
+	store, ok := sm.SnapshotStore()
+	if !ok {
+		return nil, fmt.Errorf("%s storage does not keep snapshots", sm.backendType)
+	}
+
+	snapshots, err := store.ListSnapshots(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	found := domain.SnapshotAt(snapshots, at)
+	if found == nil {
+		return nil, fmt.Errorf("%w: session %s has no snapshot at or before %s",
+			storage.ErrSnapshotNotFound, sessionID, at.Format("2006-01-02 15:04:05"))
+	}
+	snapshot, err := store.GetSnapshot(sessionID, found.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := sm.LoadSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sm.Snapshot(current, domain.SnapshotReasonBeforeRestore); err != nil {
+		return nil, err
+	}
+
+	restored := snapshot.Session
+	restored.Updated = time.Now()
+	if err := sm.SaveSession(restored); err != nil {
+		return nil, fmt.Errorf("failed to save restored session: %w", err)
+	}
+	logging.LogInfo("Restored session from snapshot", "session_id", sessionID, "snapshot_id", snapshot.ID, "created", snapshot.Created)
+	snapshot.Session = nil
+	return snapshot, nil
+}
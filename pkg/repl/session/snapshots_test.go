@@ -0,0 +1,108 @@
+// ABOUTME: Tests for session snapshots taken by the storage manager
+// ABOUTME: Ensures interval and explicit snapshots are taken and pruned, and restore rolls a session back
+
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+	_ "github.com/lexlapax/magellai/pkg/storage/filesystem" // Register filesystem backend
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSnapshotManager(t *testing.T, interval string, keep int) *StorageManager {
+	manager, err := CreateStorageManager(storage.FileSystemBackend, storage.Config{
+		"base_dir":          t.TempDir(),
+		"snapshot_interval": interval,
+		"snapshot_keep":     keep,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+func TestStorageManager_IntervalSnapshots(t *testing.T) {
+	manager := newSnapshotManager(t, "1h", 20)
+	session := manager.NewSession("snapshotted")
+	require.NoError(t, manager.SaveSession(session))
+
+	// The first update is snapshotted, later ones within the interval are not
+	for _, content := range []string{"one", "two"} {
+		session.Conversation.AddMessage(*domain.NewMessage("msg-"+content, domain.MessageRoleUser, content))
+		require.NoError(t, manager.SaveSession(session))
+	}
+	snapshots, err := manager.ListSnapshots(session.ID)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, domain.SnapshotReasonInterval, snapshots[0].Reason)
+	assert.Equal(t, 1, snapshots[0].MessageCount)
+
+	t.Run("disabled", func(t *testing.T) {
+		manager := newSnapshotManager(t, "1h", 0)
+		session := manager.NewSession("unsnapshotted")
+		require.NoError(t, manager.SaveSession(session))
+		require.NoError(t, manager.SaveSession(session))
+		snapshot, err := manager.Snapshot(session, domain.SnapshotReasonBeforeReset)
+		require.NoError(t, err)
+		assert.Nil(t, snapshot)
+		snapshots, err := manager.ListSnapshots(session.ID)
+		require.NoError(t, err)
+		assert.Empty(t, snapshots)
+	})
+}
+
+func TestStorageManager_SnapshotPruning(t *testing.T) {
+	manager := newSnapshotManager(t, "0s", 2)
+	session := manager.NewSession("pruned")
+	require.NoError(t, manager.SaveSession(session))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		snapshot, err := manager.Snapshot(session, domain.SnapshotReasonBeforeUndo)
+		require.NoError(t, err)
+		ids = append(ids, snapshot.ID)
+	}
+	snapshots, err := manager.ListSnapshots(session.ID)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, ids[1:], []string{snapshots[0].ID, snapshots[1].ID})
+}
+
+func TestStorageManager_RestoreSession(t *testing.T) {
+	manager := newSnapshotManager(t, "0s", 20)
+	session := manager.NewSession("restored")
+	session.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "keep me"))
+	require.NoError(t, manager.SaveSession(session))
+
+	before, err := manager.Snapshot(session, domain.SnapshotReasonBeforeReset)
+	require.NoError(t, err)
+	session.Conversation.Messages = nil
+	require.NoError(t, manager.SaveSession(session))
+
+	_, err = manager.RestoreSession(session.ID, before.Created.Add(-time.Minute))
+	assert.ErrorIs(t, err, storage.ErrSnapshotNotFound)
+
+	restored, err := manager.RestoreSession(session.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, before.ID, restored.ID)
+
+	loaded, err := manager.LoadSession(session.ID)
+	require.NoError(t, err)
+	require.Len(t, loaded.Conversation.Messages, 1)
+	assert.Equal(t, "keep me", loaded.Conversation.Messages[0].Content)
+
+	// The emptied session was kept so the restore can be undone
+	snapshots, err := manager.ListSnapshots(session.ID)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, domain.SnapshotReasonBeforeRestore, snapshots[1].Reason)
+	assert.Zero(t, snapshots[1].MessageCount)
+
+	manager.SetReadOnly(true)
+	_, err = manager.RestoreSession(session.ID, time.Now())
+	assert.ErrorIs(t, err, storage.ErrReadOnly)
+}
@@ -4,6 +4,7 @@
 package session
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
@@ -16,6 +17,12 @@ import (
 type StorageManager struct {
 	backend     storage.Backend
 	backendType storage.BackendType
+	readOnly    bool // Refuse changes with storage.ErrReadOnly
+
+	snapshots    storage.SnapshotPolicy
+	lastSnapshot map[string]time.Time // When each session was last snapshotted
+
+	traceCtx context.Context // Parent of the spans of storage operations
 }
 
 // NewStorageManager creates a new storage manager with the specified backend
@@ -35,41 +42,101 @@ func (sm *StorageManager) NewSession(name string) *domain.Session {
 	return sm.backend.NewSession(name)
 }
 
+// SetReadOnly makes the manager refuse every change to storage with
+// storage.ErrReadOnly, so sessions can be browsed and exported without writes
+func (sm *StorageManager) SetReadOnly(readOnly bool) {
+	sm.readOnly = readOnly
+}
+
+// ReadOnly returns true if the manager refuses changes to storage
+func (sm *StorageManager) ReadOnly() bool {
+	return sm.readOnly
+}
+
 // SaveSession saves a session
-func (sm *StorageManager) SaveSession(session *domain.Session) error {
+func (sm *StorageManager) SaveSession(session *domain.Session) (err error) {
+	op := sm.startOp("save", session.ID)
+	defer op.end(&err)
+
+	if sm.readOnly {
+		return fmt.Errorf("cannot save session %s: %w", session.ID, storage.ErrReadOnly)
+	}
+
 	// Check if session exists to determine if this is a create or update
 	existing, err := sm.backend.Get(session.ID)
 	if err != nil || existing == nil {
 		// Session doesn't exist, create it
-		return sm.backend.Create(session)
+		if err := sm.backend.Create(session); err != nil {
+			return err
+		}
+		publishSessionEvent(domain.EventSessionCreated, session)
+		if session.IsBranch() {
+			event := domain.NewEvent(domain.EventSessionBranched, session.ID)
+			event.Session = session
+			event.Data["parent_id"] = session.ParentID
+			event.Data["branch_point"] = session.BranchPoint
+			domain.DefaultEventBus.Publish(event)
+		}
+		return nil
 	}
 
 	// Session exists, update it
-	return sm.backend.Update(session)
+	if err := sm.backend.Update(session); err != nil {
+		return err
+	}
+	publishSessionEvent(domain.EventSessionSaved, session)
+	sm.snapshotIfDue(session)
+	return nil
+}
+
+// publishSessionEvent announces a change to a session on the default event bus
+func publishSessionEvent(eventType domain.EventType, session *domain.Session) {
+	event := domain.NewEvent(eventType, session.ID)
+	event.Session = session
+	domain.DefaultEventBus.Publish(event)
 }
 
 // LoadSession loads a session by ID
-func (sm *StorageManager) LoadSession(id string) (*domain.Session, error) {
+func (sm *StorageManager) LoadSession(id string) (session *domain.Session, err error) {
+	op := sm.startOp("load", id)
+	defer op.end(&err)
 	return sm.backend.Get(id)
 }
 
 // ListSessions lists all available sessions
-func (sm *StorageManager) ListSessions() ([]*domain.SessionInfo, error) {
+func (sm *StorageManager) ListSessions() (sessions []*domain.SessionInfo, err error) {
+	op := sm.startOp("list", "")
+	defer op.end(&err)
 	return sm.backend.List()
 }
 
 // DeleteSession removes a session
-func (sm *StorageManager) DeleteSession(id string) error {
-	return sm.backend.Delete(id)
+func (sm *StorageManager) DeleteSession(id string) (err error) {
+	op := sm.startOp("delete", id)
+	defer op.end(&err)
+
+	if sm.readOnly {
+		return fmt.Errorf("cannot delete session %s: %w", id, storage.ErrReadOnly)
+	}
+	if err := sm.backend.Delete(id); err != nil {
+		return err
+	}
+	domain.DefaultEventBus.Publish(domain.NewEvent(domain.EventSessionDeleted, id))
+	return nil
 }
 
 // SearchSessions searches for sessions by query
-func (sm *StorageManager) SearchSessions(query string) ([]*domain.SearchResult, error) {
+func (sm *StorageManager) SearchSessions(query string) (results []*domain.SearchResult, err error) {
+	op := sm.startOp("search", "")
+	defer op.end(&err)
 	return sm.backend.Search(query)
 }
 
 // ExportSession exports a session in the specified format
-func (sm *StorageManager) ExportSession(id string, format string, w io.Writer) error {
+func (sm *StorageManager) ExportSession(id string, format string, w io.Writer) (err error) {
+	op := sm.startOp("export", id)
+	defer op.end(&err)
+
 	// Convert string format to domain.ExportFormat
 	var exportFormat domain.ExportFormat
 	switch format {
@@ -79,11 +146,120 @@ func (sm *StorageManager) ExportSession(id string, format string, w io.Writer) e
 		exportFormat = domain.ExportFormatMarkdown
 	case "text":
 		exportFormat = domain.ExportFormatText
+	case "org":
+		exportFormat = domain.ExportFormatOrg
+	case "jsonl":
+		exportFormat = domain.ExportFormatJSONL
+	case "csv":
+		exportFormat = domain.ExportFormatCSV
 	default:
 		return fmt.Errorf("unsupported export format: %s", format)
 	}
 
-	return sm.backend.ExportSession(id, exportFormat, w)
+	if err := sm.backend.ExportSession(id, exportFormat, w); err != nil {
+		return err
+	}
+	publishExported(id, format)
+	return nil
+}
+
+// ExportSessionWithOptions exports a session, applying the options of
+// formats that reshape it, such as the roles and attachments of a JSONL
+// fine-tuning dataset or the header of a CSV table
+func (sm *StorageManager) ExportSessionWithOptions(id string, format string, opts storage.ExportOptions, w io.Writer) (err error) {
+	exportFormat := domain.ExportFormat(format)
+	if exportFormat != domain.ExportFormatJSONL && exportFormat != domain.ExportFormatCSV {
+		return sm.ExportSession(id, format, w)
+	}
+	op := sm.startOp("export", id)
+	defer op.end(&err)
+
+	switch exportFormat {
+	case domain.ExportFormatJSONL:
+		session, err := sm.backend.Get(id)
+		if err != nil {
+			return err
+		}
+		if !opts.StripAttachments {
+			if err := storage.LoadAttachmentContent(session); err != nil {
+				return fmt.Errorf("failed to load attachment content: %w", err)
+			}
+		}
+		if err := storage.ExportJSONL(session, w, opts); err != nil {
+			return err
+		}
+
+	case domain.ExportFormatCSV:
+		session, err := sm.backend.Get(id)
+		if err != nil {
+			return err
+		}
+		if err := storage.ExportCSV(session, w, opts); err != nil {
+			return err
+		}
+	}
+	publishExported(id, format)
+	return nil
+}
+
+// publishExported announces that a session was exported
+func publishExported(id, format string) {
+	event := domain.NewEvent(domain.EventSessionExported, id)
+	event.Data["format"] = format
+	domain.DefaultEventBus.Publish(event)
+}
+
+// storeBackend returns the backend holding indexes and templates, which for
+// a replicating backend is its primary
+func (sm *StorageManager) storeBackend() storage.Backend {
+	if replicating, ok := sm.backend.(*storage.ReplicatingBackend); ok {
+		return replicating.Primary()
+	}
+	return sm.backend
+}
+
+// IndexStore returns the backend's retrieval index storage, if it has one
+func (sm *StorageManager) IndexStore() (storage.IndexStore, bool) {
+	store, ok := sm.storeBackend().(storage.IndexStore)
+	if ok && sm.readOnly {
+		return readOnlyIndexStore{store}, true
+	}
+	return store, ok
+}
+
+// TemplateStore returns the backend's session template storage, if it has one
+func (sm *StorageManager) TemplateStore() (storage.TemplateStore, bool) {
+	store, ok := sm.storeBackend().(storage.TemplateStore)
+	if ok && sm.readOnly {
+		return readOnlyTemplateStore{store}, true
+	}
+	return store, ok
+}
+
+// readOnlyIndexStore refuses to change the indexes of read-only storage
+type readOnlyIndexStore struct {
+	storage.IndexStore
+}
+
+func (s readOnlyIndexStore) SaveIndex(name string, data []byte) error {
+	return fmt.Errorf("cannot save index %s: %w", name, storage.ErrReadOnly)
+}
+
+func (s readOnlyIndexStore) DeleteIndex(name string) error {
+	return fmt.Errorf("cannot delete index %s: %w", name, storage.ErrReadOnly)
+}
+
+// readOnlyTemplateStore refuses to change the templates of read-only storage
+type readOnlyTemplateStore struct {
+	storage.TemplateStore
+}
+
+func (s readOnlyTemplateStore) SaveTemplate(template *domain.SessionTemplate) error {
+	return fmt.Errorf("cannot save template %s: %w", template.Name, storage.ErrReadOnly)
+}
+
+func (s readOnlyTemplateStore) DeleteTemplate(name string) error {
+	return fmt.Errorf("cannot delete template %s: %w", name, storage.ErrReadOnly)
 }
 
 // Close closes the storage backend
@@ -103,6 +279,8 @@ func CreateStorageManager(backendType storage.BackendType, config storage.Config
 		return nil, err
 	}
 	sm.backendType = backendType
+	sm.readOnly = config.ReadOnly()
+	sm.snapshots = config.SnapshotPolicy()
 	return sm, nil
 }
 
@@ -145,6 +323,25 @@ func (sm *StorageManager) GetBranchTree(sessionID string) (*domain.BranchTree, e
 }
 
 // MergeSessions merges two sessions according to the specified options
-func (sm *StorageManager) MergeSessions(targetID, sourceID string, options domain.MergeOptions) (*domain.MergeResult, error) {
-	return sm.backend.MergeSessions(targetID, sourceID, options)
+func (sm *StorageManager) MergeSessions(targetID, sourceID string, options domain.MergeOptions) (result *domain.MergeResult, err error) {
+	op := sm.startOp("merge", targetID)
+	defer op.end(&err)
+
+	if sm.readOnly {
+		return nil, fmt.Errorf("cannot merge sessions: %w", storage.ErrReadOnly)
+	}
+	result, err = sm.backend.MergeSessions(targetID, sourceID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	event := domain.NewEvent(domain.EventSessionMerged, result.SessionID)
+	event.Data["source_id"] = sourceID
+	event.Data["target_id"] = targetID
+	event.Data["merged_count"] = result.MergedCount
+	if result.NewBranchID != "" {
+		event.Data["new_branch_id"] = result.NewBranchID
+	}
+	domain.DefaultEventBus.Publish(event)
+	return result, nil
 }
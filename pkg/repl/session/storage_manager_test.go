@@ -5,13 +5,20 @@ package session
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/lexlapax/magellai/pkg/tracing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 func TestNewStorageManager(t *testing.T) {
@@ -304,3 +311,133 @@ func TestStorageManager_Close(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "close error")
 }
+
+func TestStorageManager_PublishesEvents(t *testing.T) {
+	backend := NewMockStorageBackend()
+	manager, err := NewStorageManager(backend)
+	require.NoError(t, err)
+
+	var events []domain.Event
+	unsubscribe := domain.DefaultEventBus.SubscribeAll(func(e domain.Event) { events = append(events, e) })
+	defer unsubscribe()
+
+	parent := domain.NewSession("parent")
+	parent.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "hello"))
+	require.NoError(t, manager.SaveSession(parent))
+	require.NoError(t, manager.SaveSession(parent))
+
+	branch, err := parent.CreateBranch("branch", "branch", 1)
+	require.NoError(t, err)
+	require.NoError(t, manager.SaveSession(branch))
+
+	_, err = manager.MergeSessions("parent", "branch", domain.MergeOptions{Type: domain.MergeTypeContinuation})
+	require.NoError(t, err)
+	require.NoError(t, manager.DeleteSession("branch"))
+
+	var types []domain.EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []domain.EventType{
+		domain.EventSessionCreated,
+		domain.EventSessionSaved,
+		domain.EventSessionCreated,
+		domain.EventSessionBranched,
+		domain.EventSessionMerged,
+		domain.EventSessionDeleted,
+	}, types)
+	assert.Equal(t, "parent", events[3].Data["parent_id"])
+	assert.Equal(t, "branch", events[4].Data["source_id"])
+	assert.Equal(t, "branch", events[5].SessionID)
+	assert.Nil(t, events[5].Session)
+
+	// Exports announce their format
+	events = nil
+	var buf bytes.Buffer
+	require.NoError(t, manager.ExportSession("parent", "markdown", &buf))
+	require.NoError(t, manager.ExportSessionWithOptions("parent", "jsonl", storage.ExportOptions{}, &buf))
+	require.Len(t, events, 2)
+	assert.Equal(t, domain.EventSessionExported, events[0].Type)
+	assert.Equal(t, "parent", events[0].SessionID)
+	assert.Equal(t, "markdown", events[0].Data["format"])
+	assert.Equal(t, "jsonl", events[1].Data["format"])
+
+	// Failed operations publish nothing
+	events = nil
+	backend.err = fmt.Errorf("storage error")
+	assert.Error(t, manager.DeleteSession("parent"))
+	assert.Error(t, manager.ExportSession("parent", "json", &buf))
+	assert.Empty(t, events)
+}
+
+func TestStorageManager_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	shutdown := tracing.Install(recorder, tracing.Config{Sampling: 1})
+	defer func() {
+		_ = shutdown(context.Background())
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	}()
+
+	backend := NewMockStorageBackend()
+	manager, err := NewStorageManager(backend)
+	require.NoError(t, err)
+
+	ctx, parent := tracing.Start(context.Background(), "command.session")
+	manager.SetTraceContext(ctx)
+	session := domain.NewSession("traced")
+	require.NoError(t, manager.SaveSession(session))
+	backend.err = fmt.Errorf("storage error")
+	_, err = manager.LoadSession("traced")
+	assert.Error(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "storage.save", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), tracing.AttrSessionID.String("traced"))
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, "storage.load", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestStorageManager_ReadOnly(t *testing.T) {
+	backend := NewMockStorageBackend()
+	backend.sessions["existing"] = &domain.Session{ID: "existing", Name: "Existing", Created: time.Now(), Updated: time.Now()}
+	expired := &domain.Session{ID: "expired", Name: "Expired", Created: time.Now(), Updated: time.Now()}
+	expired.SetExpiry(-time.Hour)
+	backend.sessions["expired"] = expired
+
+	manager, err := NewStorageManager(backend)
+	require.NoError(t, err)
+	manager.SetReadOnly(true)
+	assert.True(t, manager.ReadOnly())
+
+	// Reads still work
+	loaded, err := manager.LoadSession("existing")
+	require.NoError(t, err)
+	assert.Equal(t, "Existing", loaded.Name)
+	var buf bytes.Buffer
+	require.NoError(t, manager.ExportSession("existing", "json", &buf))
+
+	// Changes are refused without reaching the backend
+	err = manager.SaveSession(loaded)
+	assert.ErrorIs(t, err, storage.ErrReadOnly)
+	err = manager.DeleteSession("existing")
+	assert.ErrorIs(t, err, storage.ErrReadOnly)
+	_, err = manager.MergeSessions("existing", "expired", domain.MergeOptions{})
+	assert.ErrorIs(t, err, storage.ErrReadOnly)
+	assert.Equal(t, 0, backend.calls["Create"]+backend.calls["Update"]+backend.calls["DeleteSession"])
+
+	// Expired sessions are kept
+	deleted, err := manager.PurgeExpiredSessions(0)
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	assert.Contains(t, backend.sessions, "expired")
+
+	// New sessions stay in memory
+	sessionManager, err := NewSessionManager(manager)
+	require.NoError(t, err)
+	session, err := sessionManager.NewSession("Scratch")
+	require.NoError(t, err)
+	assert.NotContains(t, backend.sessions, session.ID)
+}
@@ -0,0 +1,98 @@
+// ABOUTME: Shell command execution from the REPL prompt
+// ABOUTME: Runs !command and, with !>, adds its output to the next message as context
+
+package repl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// maxShellContext is the most command output attached to a message
+const maxShellContext = 64 * 1024
+
+// runShell handles input starting with "!". "!cmd" runs cmd and shows its
+// output; "!>cmd" also attaches the output to the next message.
+func (r *REPL) runShell(input string) error {
+	line := strings.TrimPrefix(input, "!")
+	capture := strings.HasPrefix(line, ">")
+	line = strings.TrimSpace(strings.TrimPrefix(line, ">"))
+	if line == "" {
+		return fmt.Errorf("usage: !<command> or !><command>")
+	}
+
+	if !r.config.GetBool("repl.shell.enabled") {
+		return fmt.Errorf("shell commands are disabled (set repl.shell.enabled to true)")
+	}
+
+	ctx := r.beginGeneration()
+	defer r.endGeneration()
+
+	name, args := shellCommand()
+	cmd := exec.CommandContext(ctx, name, append(args, line)...)
+	// Only a terminal session hands its stdin to the command; under --script
+	// or piped input, stdin carries the rest of the input and must not be read
+	if r.isTerminal {
+		cmd.Stdin = os.Stdin
+	}
+
+	// Show output as it is produced, keeping a copy for the context
+	var output bytes.Buffer
+	cmd.Stdout = io.MultiWriter(r.writer, &output)
+	cmd.Stderr = io.MultiWriter(r.writer, &output)
+
+	logging.LogInfo("Running shell command", "command", line, "capture", capture)
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return ErrGenerationCancelled
+	}
+
+	status := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		status = exitErr.ExitCode()
+		fmt.Fprintf(r.writer, "[exit status %d]\n", status)
+	} else if err != nil {
+		return fmt.Errorf("failed to run command: %w", err)
+	}
+
+	if capture {
+		r.addPendingAttachment(shellOutputAttachment(line, status, output.String()))
+		fmt.Fprintln(r.writer, "Command output will be added to your next message.")
+	}
+	return nil
+}
+
+// shellCommand returns the shell and arguments used to run a command line
+func shellCommand() (string, []string) {
+	if runtime.GOOS == "windows" {
+		return "cmd", []string{"/C"}
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell, []string{"-c"}
+	}
+	return "/bin/sh", []string{"-c"}
+}
+
+// shellOutputAttachment wraps command output as a text attachment
+func shellOutputAttachment(line string, status int, output string) domain.Attachment {
+	if len(output) > maxShellContext {
+		output = output[len(output)-maxShellContext:] + "\n[output truncated to the last 64 KiB]"
+	}
+	content := fmt.Sprintf("Output of `%s` (exit status %d):\n\n```\n%s\n```", line, status, strings.TrimRight(output, "\n"))
+	return domain.Attachment{
+		Type:     domain.AttachmentTypeText,
+		Content:  []byte(content),
+		Name:     "shell-output",
+		MimeType: "text/plain",
+	}
+}
@@ -0,0 +1,59 @@
+// ABOUTME: Tests for shell command execution in the REPL
+// ABOUTME: Verifies output display, capture into context, and the safety toggle
+
+package repl
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	t.Setenv("SHELL", "/bin/sh")
+
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	// Disabled unless configured
+	assert.ErrorContains(t, repl.runShell("!echo hi"), "shell commands are disabled")
+
+	repl.config.(*testConfig).values["repl.shell.enabled"] = true
+
+	require.NoError(t, repl.runShell("!echo hello shell"))
+	assert.Contains(t, output.String(), "hello shell")
+	assert.Nil(t, repl.session.Metadata["pending_attachments"])
+
+	output.Reset()
+	require.NoError(t, repl.runShell("!> echo captured; exit 3"))
+	assert.Contains(t, output.String(), "captured")
+	assert.Contains(t, output.String(), "[exit status 3]")
+
+	pending := repl.session.Metadata["pending_attachments"].([]domain.Attachment)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "Output of `echo captured; exit 3` (exit status 3):\n\n```\ncaptured\n```", string(pending[0].Content))
+
+	// Without a terminal the command gets empty stdin rather than the REPL's input
+	output.Reset()
+	require.NoError(t, repl.runShell("!wc -c"))
+	assert.Equal(t, "0", strings.TrimSpace(output.String()))
+
+	assert.Error(t, repl.runShell("!"))
+}
+
+func TestShellOutputAttachmentTruncates(t *testing.T) {
+	output := make([]byte, maxShellContext+10)
+	for i := range output {
+		output[i] = 'x'
+	}
+	att := shellOutputAttachment("cat big", 0, string(output))
+	assert.Contains(t, string(att.Content), "[output truncated to the last 64 KiB]")
+	assert.Less(t, len(att.Content), maxShellContext+200)
+}
@@ -0,0 +1,27 @@
+// ABOUTME: Snapshots of the current session before destructive REPL commands
+// ABOUTME: Lets /reset, /merge and /undo be rolled back with history restore
+
+package repl
+
+import (
+	"fmt"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// snapshotBefore snapshots a session before a command changes it. A session
+// without messages has nothing to roll back to. A failed snapshot is reported
+// but does not stop the command.
+func (r *REPL) snapshotBefore(session *domain.Session, reason string) {
+	if r.manager == nil || r.manager.StorageManager == nil || r.manager.StorageManager.ReadOnly() {
+		return
+	}
+	if session.Conversation == nil || len(session.Conversation.Messages) == 0 {
+		return
+	}
+	if _, err := r.manager.StorageManager.Snapshot(session, reason); err != nil {
+		logging.LogWarn("Failed to snapshot session", "session_id", session.ID, "reason", reason, "error", err)
+		fmt.Fprintf(r.writer, "Warning: could not snapshot the session first: %v\n", err)
+	}
+}
@@ -0,0 +1,48 @@
+// ABOUTME: Tests for snapshots taken before destructive REPL commands
+// ABOUTME: Verifies /reset and /undo leave a snapshot the session can be restored from
+
+package repl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotBeforeDestructiveCommands(t *testing.T) {
+	repl, _, cleanup := setupTestREPL(t)
+	defer cleanup()
+	manager := repl.manager.StorageManager
+	manager.SetSnapshotPolicy(storage.SnapshotPolicy{Keep: 10})
+
+	conv := repl.session.Conversation
+	addTestMessage(conv, "user", "Hi", nil)
+	addTestMessage(conv, "assistant", "Hello", nil)
+	addTestMessage(conv, "user", "Bye", nil)
+	addTestMessage(conv, "assistant", "Goodbye", nil)
+	require.NoError(t, manager.SaveSession(repl.session))
+
+	require.NoError(t, repl.handleCommand("/undo"))
+	require.NoError(t, repl.handleCommand("/reset"))
+	// An empty conversation has nothing to roll back to
+	require.NoError(t, repl.handleCommand("/reset"))
+	require.NoError(t, manager.SaveSession(repl.session))
+
+	snapshots, err := manager.ListSnapshots(repl.session.ID)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, domain.SnapshotReasonBeforeUndo, snapshots[0].Reason)
+	assert.Equal(t, 4, snapshots[0].MessageCount)
+	assert.Equal(t, domain.SnapshotReasonBeforeReset, snapshots[1].Reason)
+	assert.Equal(t, 2, snapshots[1].MessageCount)
+
+	_, err = manager.RestoreSession(repl.session.ID, time.Now())
+	require.NoError(t, err)
+	restored, err := manager.LoadSession(repl.session.ID)
+	require.NoError(t, err)
+	assert.Len(t, restored.Conversation.Messages, 2)
+}
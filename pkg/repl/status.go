@@ -0,0 +1,185 @@
+// ABOUTME: Token and cost status line for the REPL
+// ABOUTME: Tracks session usage, shows context fill and running cost, and the wait spinner
+
+package repl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
+	"github.com/lexlapax/magellai/pkg/ui"
+)
+
+// Status line placements for repl.status
+const (
+	statusOff      = "off"
+	statusResponse = "response"
+	statusPrompt   = "prompt"
+)
+
+// sessionUsage accumulates token usage and cost over the REPL session
+type sessionUsage struct {
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+	PricingKnown bool
+}
+
+// statusMode returns where the status line is shown. It is only shown in
+// interactive terminals.
+func (r *REPL) statusMode() string {
+	if !r.isTerminal {
+		return statusOff
+	}
+	switch mode := r.config.GetString("repl.status"); mode {
+	case statusResponse, statusPrompt:
+		return mode
+	default:
+		return statusOff
+	}
+}
+
+// modelInventory loads the models inventory once, returning nil if unavailable
+func (r *REPL) modelInventory() *models.Inventory {
+	r.inventoryOnce.Do(func() {
+		inventory, err := models.LoadDefaultInventory()
+		if err != nil {
+			logging.LogDebug("Models inventory unavailable for status line", "error", err)
+			return
+		}
+		r.inventory = inventory
+	})
+	return r.inventory
+}
+
+// currentModel returns the inventory entry for the session's model, if known
+func (r *REPL) currentModel() *models.Model {
+	return r.lookupModel(r.session.Conversation.Model)
+}
+
+// lookupModel returns the inventory entry for a provider/model string, if known
+func (r *REPL) lookupModel(model string) *models.Model {
+	providerName, modelName := llm.ParseModelString(model)
+	if inventory := r.modelInventory(); inventory != nil {
+		return inventory.GetModel(providerName, modelName)
+	}
+	return nil
+}
+
+// recordUsage adds a completed request to the session totals and returns its
+// usage for the assistant message. Provider-reported usage is preferred;
+// otherwise tokens are estimated from the text.
+func (r *REPL) recordUsage(model string, messages []domain.Message, response string, usage *llm.Usage, latency time.Duration) *domain.Usage {
+	providerName, modelName := llm.ParseModelString(model)
+	recorded := &domain.Usage{Latency: latency, Provider: providerName, Model: modelName}
+	if usage != nil && (usage.InputTokens > 0 || usage.OutputTokens > 0) {
+		recorded.PromptTokens, recorded.CompletionTokens = usage.InputTokens, usage.OutputTokens
+		recorded.CachedTokens = usage.CachedTokens
+	} else {
+		counter := llm.NewEstimatedTokenCounter()
+		recorded.PromptTokens = counter.CountMessageTokens(messages)
+		recorded.CompletionTokens = counter.CountTokens(response)
+		recorded.Estimated = true
+	}
+
+	r.usage.InputTokens += recorded.PromptTokens
+	r.usage.OutputTokens += recorded.CompletionTokens
+	if info := r.lookupModel(model); info != nil {
+		cost := info.Cost(recorded.PromptTokens, recorded.CompletionTokens)
+		if cost.PricingKnown {
+			recorded.Cost, recorded.CostKnown = cost.TotalCost, true
+			r.usage.Cost += cost.TotalCost
+			r.usage.PricingKnown = true
+		}
+	}
+
+	logging.LogDebug("Recorded usage",
+		"input", recorded.PromptTokens, "output", recorded.CompletionTokens, "latency", latency,
+		"sessionInput", r.usage.InputTokens, "sessionOutput", r.usage.OutputTokens,
+		"sessionCost", r.usage.Cost)
+	return recorded
+}
+
+// Usage returns the input and output tokens used this session
+func (r *REPL) Usage() (inputTokens, outputTokens int) {
+	return r.usage.InputTokens, r.usage.OutputTokens
+}
+
+// statusLine summarizes context usage and the running session cost
+func (r *REPL) statusLine() string {
+	contextTokens := llm.NewEstimatedTokenCounter().CountMessageTokens(GetHistory(r.session.Conversation))
+
+	parts := []string{}
+	if model := r.currentModel(); model != nil && model.ContextWindow > 0 {
+		percent := float64(contextTokens) / float64(model.ContextWindow) * 100
+		parts = append(parts, fmt.Sprintf("ctx %s/%s (%.0f%%)",
+			formatTokenCount(contextTokens), formatTokenCount(model.ContextWindow), percent))
+	} else {
+		parts = append(parts, fmt.Sprintf("ctx %s", formatTokenCount(contextTokens)))
+	}
+
+	if r.usage.PricingKnown {
+		parts = append(parts, fmt.Sprintf("session $%.4f", r.usage.Cost))
+	} else {
+		parts = append(parts, fmt.Sprintf("session %s tokens",
+			formatTokenCount(r.usage.InputTokens+r.usage.OutputTokens)))
+	}
+
+	return "[" + strings.Join(parts, " | ") + "]"
+}
+
+// printStatusLine prints the status line after a response when configured
+func (r *REPL) printStatusLine() {
+	if r.statusMode() != statusResponse {
+		return
+	}
+	line := r.statusLine()
+	if r.colorFormatter.Enabled() {
+		line = r.colorFormatter.FormatSystemMessage(line)
+	}
+	fmt.Fprintln(r.writer, line)
+}
+
+// startSpinner shows a progress spinner while waiting for the provider. It
+// returns nil, which is safe to use, when not in a terminal or disabled with
+// repl.spinner.
+func (r *REPL) startSpinner() *ui.Spinner {
+	if !r.isTerminal || !r.config.GetBool("repl.spinner") {
+		return nil
+	}
+	spinner := ui.NewSpinner(r.writer, "Waiting for "+r.session.Conversation.Model)
+	spinner.Start()
+	return spinner
+}
+
+// currentPrompt returns the input prompt, prefixed by the status line when
+// it is shown in the prompt
+func (r *REPL) currentPrompt() string {
+	prompt := r.renderPrompt()
+	if r.statusMode() != statusPrompt {
+		return prompt
+	}
+
+	status := r.statusLine() + " "
+	if r.colorFormatter.Enabled() {
+		status = r.colorFormatter.FormatSystemMessage(status)
+	}
+	return status + prompt
+}
+
+// formatTokenCount renders token counts compactly (950, 12.3k, 1.0M)
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
@@ -0,0 +1,122 @@
+// ABOUTME: Tests for the REPL token and cost status line
+// ABOUTME: Verifies usage accounting, placement modes, and compact formatting
+
+package repl
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPL_statusLine(t *testing.T) {
+	inventoryFile, err := filepath.Abs(filepath.Join("..", "..", "models.json"))
+	require.NoError(t, err)
+	t.Setenv(models.InventoryEnvVar, inventoryFile)
+
+	newREPL := func(t *testing.T, mode string) (*REPL, func() string) {
+		repl, output, cleanup := setupTestREPL(t)
+		t.Cleanup(cleanup)
+		repl.isTerminal = true
+		repl.config.(*testConfig).values["repl.status"] = mode
+		repl.session.Conversation.Model = "openai/gpt-4o"
+
+		provider := newMockProvider()
+		provider.generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+			return &llm.Response{
+				Content: "Paris",
+				Usage:   &llm.Usage{InputTokens: 1000, OutputTokens: 500, TotalTokens: 1500},
+			}, nil
+		}
+		repl.provider = provider
+		return repl, output.String
+	}
+
+	t.Run("shown after response with provider usage", func(t *testing.T) {
+		repl, output := newREPL(t, statusResponse)
+
+		require.NoError(t, repl.processMessage("capital of France?"))
+		assert.Equal(t, 1000, repl.usage.InputTokens)
+		assert.Equal(t, 500, repl.usage.OutputTokens)
+		assert.True(t, repl.usage.PricingKnown)
+		assert.Greater(t, repl.usage.Cost, 0.0)
+		assert.Contains(t, output(), "[ctx ")
+		assert.Contains(t, output(), "/128.0k")
+		assert.Contains(t, output(), "| session $")
+
+		// The response carries its usage
+		reply := repl.session.Conversation.Messages[1]
+		require.NotNil(t, reply.Usage)
+		assert.Equal(t, 1000, reply.Usage.PromptTokens)
+		assert.Equal(t, 500, reply.Usage.CompletionTokens)
+		assert.Equal(t, "openai", reply.Usage.Provider)
+		assert.Equal(t, "gpt-4o", reply.Usage.Model)
+		assert.True(t, reply.Usage.CostKnown)
+		assert.False(t, reply.Usage.Estimated)
+
+		// Totals accumulate across requests
+		require.NoError(t, repl.processMessage("and Germany?"))
+		assert.Equal(t, 2000, repl.usage.InputTokens)
+		assert.Equal(t, 2, repl.session.TotalUsage().Requests)
+		assert.Equal(t, 2000, repl.session.TotalUsage().PromptTokens)
+	})
+
+	t.Run("prompt mode prefixes the prompt", func(t *testing.T) {
+		repl, output := newREPL(t, statusPrompt)
+
+		require.NoError(t, repl.processMessage("capital of France?"))
+		assert.NotContains(t, output(), "[ctx ")
+		assert.Contains(t, repl.currentPrompt(), "[ctx ")
+		assert.Contains(t, repl.currentPrompt(), repl.promptStyle)
+	})
+
+	t.Run("off hides the status", func(t *testing.T) {
+		repl, output := newREPL(t, statusOff)
+
+		require.NoError(t, repl.processMessage("capital of France?"))
+		assert.NotContains(t, output(), "[ctx ")
+		assert.Equal(t, repl.promptStyle, repl.currentPrompt())
+	})
+
+	t.Run("unknown model falls back to estimated tokens", func(t *testing.T) {
+		repl, _ := newREPL(t, statusResponse)
+		repl.session.Conversation.Model = "mock/unknown"
+		repl.provider.(*mockProvider).generateFunc = func(ctx context.Context, messages []domain.Message) (*llm.Response, error) {
+			return &llm.Response{Content: "Paris"}, nil
+		}
+
+		require.NoError(t, repl.processMessage("capital of France?"))
+		assert.Greater(t, repl.usage.InputTokens, 0)
+		assert.False(t, repl.usage.PricingKnown)
+		assert.True(t, repl.session.Conversation.Messages[1].Usage.Estimated)
+		assert.Contains(t, repl.statusLine(), "tokens]")
+		assert.NotContains(t, repl.statusLine(), "$")
+	})
+}
+
+func TestFormatTokenCount(t *testing.T) {
+	assert.Equal(t, "950", formatTokenCount(950))
+	assert.Equal(t, "12.3k", formatTokenCount(12300))
+	assert.Equal(t, "1.0M", formatTokenCount(1_000_000))
+}
+
+func TestREPL_startSpinner(t *testing.T) {
+	repl, output, cleanup := setupTestREPL(t)
+	defer cleanup()
+
+	assert.Nil(t, repl.startSpinner(), "not shown outside a terminal")
+
+	repl.isTerminal = true
+	assert.Nil(t, repl.startSpinner(), "disabled by repl.spinner")
+
+	repl.config.(*testConfig).values["repl.spinner"] = true
+	require.NoError(t, repl.processMessage("hello"))
+	assert.Contains(t, output.String(), "Waiting for "+repl.session.Conversation.Model)
+	assert.Contains(t, output.String(), "\r\033[K\nMock response")
+}
@@ -4,7 +4,9 @@
 package replapi
 
 import (
+	"context"
 	"io"
+	"time"
 
 	"github.com/lexlapax/magellai/pkg/domain"
 )
@@ -23,10 +25,18 @@ type REPLOptions struct {
 	Config      ConfigInterface
 	StorageDir  string
 	PromptStyle string
-	SessionID   string // Optional: resume existing session
-	Model       string // Optional: override default model
+	SessionID   string        // Optional: resume existing session
+	Model       string        // Optional: override default model
+	Template    string        // Optional: start the new session from a session template
+	Ephemeral   time.Duration // Optional: delete the session this long after it starts
+	ReadOnly    bool          // Open session storage without writing to it
+	RCFile      string        // Optional: startup commands file (default ~/.config/magellai/replrc)
+	NoRC        bool          // Skip the startup commands file
+	Script      string        // Optional: file of commands and prompts to run non-interactively ("-" for stdin)
+	Quiet       bool          // Skip the welcome banner
 	Writer      io.Writer
 	Reader      io.Reader
+	Context     context.Context // Optional: parent of every request; canceling it stops the REPL
 }
 
 // REPL defines the minimal interface for chat REPL functionality
@@ -0,0 +1,25 @@
+// ABOUTME: Package for local retrieval-augmented generation over directories
+// ABOUTME: Builds, persists, and searches embeddings indexes of local text files
+
+/*
+Package retrieval indexes the text files of a local directory so relevant
+excerpts can be added to prompts.
+
+Files are split into overlapping line chunks and each chunk is embedded as a
+sparse vector. The default embedder hashes words and word pairs locally, so
+indexing works offline and without provider support for embeddings. Queries
+are embedded the same way and chunks are ranked by cosine similarity.
+
+Indexes are persisted through storage backends that implement
+storage.IndexStore:
+
+	idx, err := retrieval.Build("project", ".", retrieval.BuildOptions{})
+	if err != nil {
+	    // Handle error
+	}
+	err = retrieval.Save(store, idx)
+
+	results := idx.Search("how are sessions saved", 4)
+	prompt := retrieval.FormatContext(results)
+*/
+package retrieval
@@ -0,0 +1,134 @@
+// ABOUTME: Text embeddings used to rank indexed chunks against a query
+// ABOUTME: Provides a local feature-hashing embedder that needs no network access
+
+package retrieval
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Vector is a sparse embedding: feature index to weight, L2-normalized
+type Vector map[uint32]float32
+
+// Embedder turns text into a vector. Embedders used to build and query an
+// index must be the same.
+type Embedder interface {
+	// Name identifies the embedder so stored indexes can be checked for compatibility
+	Name() string
+	// Embed returns the embedding of text
+	Embed(text string) Vector
+}
+
+// HashingEmbedder embeds text by hashing words and adjacent word pairs into a
+// fixed number of dimensions with sublinear term frequency weighting
+type HashingEmbedder struct {
+	Dimensions uint32
+}
+
+// DefaultEmbedder is the embedder used when none is configured
+var DefaultEmbedder Embedder = &HashingEmbedder{Dimensions: 1 << 18}
+
+// Name implements Embedder
+func (e *HashingEmbedder) Name() string {
+	return "hashing-v1"
+}
+
+// Embed implements Embedder
+func (e *HashingEmbedder) Embed(text string) Vector {
+	counts := make(map[uint32]float64)
+	words := tokenize(text)
+	for i, word := range words {
+		counts[e.feature(word)]++
+		if i > 0 {
+			// Word pairs capture identifiers and phrases; weighted below single words
+			counts[e.feature(words[i-1]+" "+word)] += 0.5
+		}
+	}
+
+	vector := make(Vector, len(counts))
+	var norm float64
+	for feature, count := range counts {
+		weight := 1 + math.Log(count)
+		if count < 1 {
+			weight = count
+		}
+		vector[feature] = float32(weight)
+		norm += weight * weight
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for feature, weight := range vector {
+		vector[feature] = float32(float64(weight) / norm)
+	}
+	return vector
+}
+
+// feature hashes a term into the embedder's dimensions
+func (e *HashingEmbedder) feature(term string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(term))
+	return h.Sum32() % e.Dimensions
+}
+
+// tokenize lowercases text and splits it into words, also splitting
+// camelCase and snake_case identifiers into their parts
+func tokenize(text string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		parts := splitIdentifier(field)
+		if len(parts) > 1 {
+			words = append(words, strings.ToLower(field))
+		}
+		for _, part := range parts {
+			if len(part) > 1 {
+				words = append(words, strings.ToLower(part))
+			}
+		}
+	}
+	return words
+}
+
+// splitIdentifier splits camelCase and snake_case identifiers
+func splitIdentifier(word string) []string {
+	var parts []string
+	start := 0
+	runes := []rune(word)
+	for i := 1; i < len(runes); i++ {
+		switch {
+		case runes[i] == '_':
+			parts = append(parts, string(runes[start:i]))
+			start = i + 1
+		case unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]):
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	parts = append(parts, string(runes[start:]))
+
+	nonEmpty := parts[:0]
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return nonEmpty
+}
+
+// Cosine returns the cosine similarity of two normalized vectors
+func Cosine(a, b Vector) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for feature, weight := range a {
+		dot += float64(weight) * float64(b[feature])
+	}
+	return dot
+}
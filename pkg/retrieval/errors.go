@@ -0,0 +1,17 @@
+// ABOUTME: Error definitions for the retrieval package
+// ABOUTME: Reports invalid index names and empty or unusable indexes
+
+package retrieval
+
+import "errors"
+
+var (
+	// ErrInvalidIndexName is returned for index names that are not simple identifiers
+	ErrInvalidIndexName = errors.New("invalid index name")
+
+	// ErrEmptyIndex is returned when a directory contains no indexable files
+	ErrEmptyIndex = errors.New("no indexable files")
+
+	// ErrIndexVersion is returned when a stored index was built by an incompatible version
+	ErrIndexVersion = errors.New("unsupported index version")
+)
@@ -0,0 +1,241 @@
+// ABOUTME: Retrieval index over the text files of a local directory
+// ABOUTME: Chunks files by lines, embeds each chunk, and ranks chunks against queries
+
+package retrieval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// indexVersion is bumped when the stored index format changes
+const indexVersion = 1
+
+// Defaults for building indexes
+const (
+	DefaultChunkLines   = 40
+	DefaultChunkOverlap = 8
+	DefaultMaxFileSize  = 1 << 20
+)
+
+// skippedDirs are directories never indexed
+var skippedDirs = map[string]bool{
+	".git": true, ".hg": true, ".svn": true, "node_modules": true, "vendor": true,
+	"__pycache__": true, ".venv": true, "venv": true, "dist": true, "build": true, "target": true,
+}
+
+// validIndexName matches names usable as storage keys
+var validIndexName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Chunk is a contiguous range of lines from an indexed file
+type Chunk struct {
+	Source    string `json:"source"` // Path relative to the index root
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Text      string `json:"text"`
+	Vector    Vector `json:"vector"`
+}
+
+// Index is a searchable set of embedded chunks
+type Index struct {
+	Version  int       `json:"version"`
+	Name     string    `json:"name"`
+	Root     string    `json:"root"`
+	Embedder string    `json:"embedder"`
+	Created  time.Time `json:"created"`
+	Files    int       `json:"files"`
+	Chunks   []Chunk   `json:"chunks"`
+
+	embedder Embedder
+}
+
+// Result is a chunk matching a query
+type Result struct {
+	Chunk
+	Score float64 `json:"score"`
+}
+
+// BuildOptions controls how a directory is indexed
+type BuildOptions struct {
+	ChunkLines   int
+	ChunkOverlap int
+	MaxFileSize  int64
+	Embedder     Embedder
+}
+
+// ValidateName checks that an index name is a simple identifier
+func ValidateName(name string) error {
+	if !validIndexName.MatchString(name) {
+		return fmt.Errorf("%w: %q (use letters, digits, '.', '-', '_')", ErrInvalidIndexName, name)
+	}
+	return nil
+}
+
+// Build indexes the text files under root
+func Build(name, root string, opts BuildOptions) (*Index, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	if opts.ChunkLines <= 0 {
+		opts.ChunkLines = DefaultChunkLines
+	}
+	if opts.ChunkOverlap < 0 || opts.ChunkOverlap >= opts.ChunkLines {
+		opts.ChunkOverlap = min(DefaultChunkOverlap, opts.ChunkLines/2)
+	}
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = DefaultMaxFileSize
+	}
+	if opts.Embedder == nil {
+		opts.Embedder = DefaultEmbedder
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &Index{
+		Version:  indexVersion,
+		Name:     name,
+		Root:     root,
+		Embedder: opts.Embedder.Name(),
+		Created:  time.Now(),
+		embedder: opts.Embedder,
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			logging.LogDebug("Skipping unreadable path", "path", path, "error", err)
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > opts.MaxFileSize || info.Size() == 0 {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil || !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		index.addFile(rel, string(data), opts)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index %s: %w", root, err)
+	}
+	if len(index.Chunks) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrEmptyIndex, root)
+	}
+
+	logging.LogInfo("Built retrieval index", "name", name, "root", root, "files", index.Files, "chunks", len(index.Chunks))
+	return index, nil
+}
+
+// addFile splits a file into overlapping line chunks and embeds them
+func (idx *Index) addFile(source, content string, opts BuildOptions) {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	step := opts.ChunkLines - opts.ChunkOverlap
+	added := false
+
+	for start := 0; start < len(lines); start += step {
+		end := min(start+opts.ChunkLines, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) != "" {
+			idx.Chunks = append(idx.Chunks, Chunk{
+				Source:    source,
+				StartLine: start + 1,
+				EndLine:   end,
+				Text:      text,
+				// The path is embedded with the text so file names match queries
+				Vector: opts.Embedder.Embed(source + "\n" + text),
+			})
+			added = true
+		}
+		if end == len(lines) {
+			break
+		}
+	}
+	if added {
+		idx.Files++
+	}
+}
+
+// Search returns the k chunks most similar to query, best first. Chunks with
+// no overlap with the query are not returned.
+func (idx *Index) Search(query string, k int) []Result {
+	embedder := idx.embedder
+	if embedder == nil {
+		embedder = DefaultEmbedder
+	}
+	queryVector := embedder.Embed(query)
+
+	var results []Result
+	for _, chunk := range idx.Chunks {
+		if score := Cosine(queryVector, chunk.Vector); score > 0 {
+			results = append(results, Result{Chunk: chunk, Score: score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// Marshal serializes the index for storage
+func (idx *Index) Marshal() ([]byte, error) {
+	return json.Marshal(idx)
+}
+
+// Unmarshal restores an index serialized with Marshal
+func Unmarshal(data []byte) (*Index, error) {
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("failed to parse index: %w", err)
+	}
+	if idx.Version != indexVersion {
+		return nil, fmt.Errorf("%w: %d (rebuild the index)", ErrIndexVersion, idx.Version)
+	}
+	if idx.Embedder != DefaultEmbedder.Name() {
+		return nil, fmt.Errorf("%w: embedder %q (rebuild the index)", ErrIndexVersion, idx.Embedder)
+	}
+	idx.embedder = DefaultEmbedder
+	return &idx, nil
+}
+
+// FormatContext renders results as prompt context with source references
+func FormatContext(results []Result) string {
+	var b strings.Builder
+	b.WriteString("Relevant excerpts from local files:\n")
+	for _, r := range results {
+		lang := strings.TrimPrefix(strings.ToLower(filepath.Ext(r.Source)), ".")
+		fmt.Fprintf(&b, "\n%s (lines %d-%d):\n```%s\n%s\n```\n", r.Source, r.StartLine, r.EndLine, lang, r.Text)
+	}
+	return b.String()
+}
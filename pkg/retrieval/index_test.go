@@ -0,0 +1,118 @@
+// ABOUTME: Tests for retrieval indexes
+// ABOUTME: Verifies chunking, ranking, persistence, and name validation
+
+package retrieval
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates files under a temp dir from a path to content map
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+	return root
+}
+
+func TestBuildAndSearch(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"storage/session.go": "package storage\n\n// SaveSession writes the session file to disk\nfunc SaveSession() {}\n",
+		"ui/color.go":        "package ui\n\n// ColorFormatter adds ANSI colors to terminal output\ntype ColorFormatter struct{}\n",
+		"docs/intro.md":      "# Intro\n\nMagellai talks to language models.\n",
+		".git/config":        "[core] session save",
+		"image.png":          "\x89PNG\x00\x00",
+	})
+
+	idx, err := Build("project", root, BuildOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, idx.Files)
+
+	results := idx.Search("how is the session saved to disk", 2)
+	require.NotEmpty(t, results)
+	assert.Equal(t, filepath.Join("storage", "session.go"), results[0].Source)
+	assert.Equal(t, 1, results[0].StartLine)
+
+	results = idx.Search("terminal colors", 1)
+	require.Len(t, results, 1)
+	assert.Equal(t, filepath.Join("ui", "color.go"), results[0].Source)
+
+	assert.Empty(t, idx.Search("zzz qqq", 3))
+}
+
+func TestChunking(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 25; i++ {
+		lines = append(lines, "line")
+	}
+	root := writeFiles(t, map[string]string{"long.txt": strings.Join(lines, "\n")})
+
+	idx, err := Build("long", root, BuildOptions{ChunkLines: 10, ChunkOverlap: 2})
+	require.NoError(t, err)
+	require.Len(t, idx.Chunks, 3)
+	assert.Equal(t, []int{1, 9, 17}, []int{idx.Chunks[0].StartLine, idx.Chunks[1].StartLine, idx.Chunks[2].StartLine})
+	assert.Equal(t, 25, idx.Chunks[2].EndLine)
+}
+
+func TestBuildErrors(t *testing.T) {
+	_, err := Build("../bad", t.TempDir(), BuildOptions{})
+	assert.ErrorIs(t, err, ErrInvalidIndexName)
+
+	_, err = Build("empty", t.TempDir(), BuildOptions{})
+	assert.ErrorIs(t, err, ErrEmptyIndex)
+}
+
+// memoryIndexStore is an in-memory storage.IndexStore
+type memoryIndexStore map[string][]byte
+
+func (m memoryIndexStore) SaveIndex(name string, data []byte) error { m[name] = data; return nil }
+func (m memoryIndexStore) LoadIndex(name string) ([]byte, error) {
+	if data, ok := m[name]; ok {
+		return data, nil
+	}
+	return nil, storage.ErrIndexNotFound
+}
+func (m memoryIndexStore) ListIndexes() ([]string, error) { return nil, nil }
+func (m memoryIndexStore) DeleteIndex(name string) error  { delete(m, name); return nil }
+
+func TestSaveAndLoad(t *testing.T) {
+	root := writeFiles(t, map[string]string{"notes.md": "Deploy with make release.\n"})
+	idx, err := Build("notes", root, BuildOptions{})
+	require.NoError(t, err)
+
+	store := memoryIndexStore{}
+	require.NoError(t, Save(store, idx))
+
+	loaded, err := Load(store, "notes")
+	require.NoError(t, err)
+	assert.Equal(t, idx.Root, loaded.Root)
+	require.NotEmpty(t, loaded.Search("how do I deploy", 1))
+
+	_, err = Load(store, "missing")
+	assert.ErrorIs(t, err, storage.ErrIndexNotFound)
+
+	store["old"] = []byte(`{"version": 0}`)
+	_, err = Load(store, "old")
+	assert.ErrorIs(t, err, ErrIndexVersion)
+}
+
+func TestFormatContext(t *testing.T) {
+	text := FormatContext([]Result{{Chunk: Chunk{Source: "main.go", StartLine: 3, EndLine: 5, Text: "func main() {}"}}})
+	assert.Contains(t, text, "main.go (lines 3-5):\n```go\nfunc main() {}\n```")
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"savesession", "save", "session", "to", "disk_io", "disk", "io"},
+		tokenize("SaveSession to disk_io!"))
+}
@@ -0,0 +1,29 @@
+// ABOUTME: Persistence of retrieval indexes through the storage backend
+// ABOUTME: Saves and loads indexes with any backend implementing storage.IndexStore
+
+package retrieval
+
+import (
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// Save stores the index under its name
+func Save(store storage.IndexStore, idx *Index) error {
+	data, err := idx.Marshal()
+	if err != nil {
+		return err
+	}
+	return store.SaveIndex(idx.Name, data)
+}
+
+// Load reads the named index from the store
+func Load(store storage.IndexStore, name string) (*Index, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	data, err := store.LoadIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
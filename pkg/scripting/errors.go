@@ -0,0 +1,17 @@
+// ABOUTME: Error definitions for the scripting package
+// ABOUTME: Reports failed scripts, malformed replies, and unknown script commands
+
+package scripting
+
+import "errors"
+
+var (
+	// ErrScriptFailed is returned when a script raises an error or times out
+	ErrScriptFailed = errors.New("script failed")
+
+	// ErrInvalidReply is returned when a script function returns something other than a reply
+	ErrInvalidReply = errors.New("invalid script reply")
+
+	// ErrCommandNotFound is returned when no script provides a slash command
+	ErrCommandNotFound = errors.New("script command not found")
+)
@@ -0,0 +1,419 @@
+// ABOUTME: User script hooks and slash commands loaded from the config directory
+// ABOUTME: Runs Starlark scripts in an embedded interpreter, passing events in and replies out
+
+// Package scripting lets users extend the REPL without recompiling. Every
+// .star file in the scripts directory (~/.config/magellai/scripts) is a
+// Starlark script, run by an interpreter built into magellai.
+//
+// A script handles a hook by defining a function named after it, which is
+// called with the event and may return a reply:
+//
+//	def on_message_send(event):
+//	    return {"message": event.message.replace("colour", "color")}
+//
+// Slash commands are registered when the script is loaded with the
+// predeclared command function:
+//
+//	def weather(event):
+//	    return {"send": "What is the weather in " + " ".join(event.args) + "?"}
+//
+//	command("weather", weather, description = "Ask about the weather")
+//
+// Events are structs with the fields of Event (hook, session_id, model,
+// message, response, command, args). Replies are dicts with the keys of
+// Reply (message, send, output, cancel); returning None changes nothing. The
+// json module is predeclared, and print writes to the debug log.
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkjson"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// Hook names a point in the REPL where scripts are called
+type Hook string
+
+const (
+	// HookMessageSend runs before a user message is sent and may rewrite or cancel it
+	HookMessageSend Hook = "on_message_send"
+	// HookResponse runs after a response is received
+	HookResponse Hook = "on_response"
+	// HookCommand runs before a slash command and may cancel it
+	HookCommand Hook = "on_command"
+
+	// hookRunCommand is the hook of events passed to script commands
+	hookRunCommand Hook = "command"
+)
+
+// hooks are the hooks a script can handle, in the order they are reported
+var hooks = []Hook{HookMessageSend, HookResponse, HookCommand}
+
+// loadingKey marks the interpreter thread that loads a script
+const loadingKey = "magellai.loading"
+
+// Extension is the file extension of scripts
+const Extension = ".star"
+
+// DefaultTimeout bounds how long a script may run
+const DefaultTimeout = 30 * time.Second
+
+// Event is what a hook or command function receives
+type Event struct {
+	Hook      Hook
+	SessionID string
+	Model     string
+	Message   string
+	Response  string
+	Command   string
+	Args      []string
+}
+
+// Reply is what a hook or command function may return
+type Reply struct {
+	Message string // Replacement user message (on_message_send)
+	Send    string // Message to send to the model (commands)
+	Output  string // Text to show the user
+	Cancel  bool   // Stop the message or command
+}
+
+// CommandInfo describes a slash command provided by a script
+type CommandInfo struct {
+	Name        string
+	Description string
+	Script      string
+}
+
+// Script is one loaded script file
+type Script struct {
+	Name     string
+	Path     string
+	Hooks    []Hook
+	Commands []CommandInfo
+
+	globals  starlark.StringDict
+	handlers map[string]starlark.Callable // Command functions by command name
+}
+
+// handles reports whether the script defines a hook function
+func (s *Script) handles(hook Hook) bool {
+	for _, h := range s.Hooks {
+		if h == hook {
+			return true
+		}
+	}
+	return false
+}
+
+// Engine runs the loaded scripts
+type Engine struct {
+	Dir      string
+	Timeout  time.Duration
+	scripts  []*Script
+	commands map[string]CommandInfo
+}
+
+// Load runs every script in dir and registers its hooks and commands. A
+// missing directory yields an empty engine. Scripts that fail to load are
+// skipped and returned as warnings.
+func Load(ctx context.Context, dir string) (*Engine, []error) {
+	engine := &Engine{
+		Dir:      dir,
+		Timeout:  DefaultTimeout,
+		commands: make(map[string]CommandInfo),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return engine, nil
+	}
+	if err != nil {
+		return engine, []error{fmt.Errorf("failed to read scripts directory: %w", err)}
+	}
+
+	var warnings []error
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != Extension {
+			continue
+		}
+
+		script, err := engine.load(ctx, name)
+		if err != nil {
+			warnings = append(warnings, err)
+			continue
+		}
+
+		var accepted []CommandInfo
+		for _, cmd := range script.Commands {
+			if existing, ok := engine.commands[cmd.Name]; ok {
+				warnings = append(warnings, fmt.Errorf("script %s: command /%s already provided by %s", name, cmd.Name, existing.Script))
+				continue
+			}
+			engine.commands[cmd.Name] = cmd
+			accepted = append(accepted, cmd)
+		}
+		script.Commands = accepted
+		engine.scripts = append(engine.scripts, script)
+
+		logging.LogInfo("Loaded script", "name", name, "hooks", script.Hooks, "commands", len(script.Commands))
+	}
+
+	return engine, warnings
+}
+
+// load executes a script file, collecting the hook functions it defines and
+// the commands it registers
+func (e *Engine) load(ctx context.Context, name string) (*Script, error) {
+	script := &Script{
+		Name:     name,
+		Path:     filepath.Join(e.Dir, name),
+		handlers: make(map[string]starlark.Callable),
+	}
+
+	src, err := os.ReadFile(script.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrScriptFailed, name, err)
+	}
+
+	register := starlark.NewBuiltin("command", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if thread.Local(loadingKey) == nil {
+			return nil, fmt.Errorf("%s: commands can only be registered while the script loads", b.Name())
+		}
+		var cmdName, description string
+		var fn starlark.Callable
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &cmdName, "fn", &fn, "description?", &description); err != nil {
+			return nil, err
+		}
+		cmdName = strings.TrimPrefix(cmdName, "/")
+		if cmdName == "" {
+			return nil, fmt.Errorf("%s: empty command name", b.Name())
+		}
+		if _, ok := script.handlers[cmdName]; ok {
+			return nil, fmt.Errorf("%s: /%s registered twice", b.Name(), cmdName)
+		}
+		script.handlers[cmdName] = fn
+		script.Commands = append(script.Commands, CommandInfo{Name: cmdName, Description: description, Script: name})
+		return starlark.None, nil
+	})
+	predeclared := starlark.StringDict{
+		"command": register,
+		"json":    starlarkjson.Module,
+		"struct":  starlark.NewBuiltin("struct", starlarkstruct.Make),
+	}
+
+	var globals starlark.StringDict
+	err = e.withThread(ctx, name, func(thread *starlark.Thread) error {
+		var execErr error
+		thread.SetLocal(loadingKey, true)
+		globals, execErr = starlark.ExecFile(thread, script.Path, src, predeclared)
+		return execErr
+	})
+	if err != nil {
+		return nil, e.scriptError(name, "load", err)
+	}
+	globals.Freeze()
+	script.globals = globals
+
+	for _, hook := range hooks {
+		if _, ok := globals[string(hook)].(starlark.Callable); ok {
+			script.Hooks = append(script.Hooks, hook)
+		}
+	}
+	return script, nil
+}
+
+// Scripts returns the loaded scripts
+func (e *Engine) Scripts() []*Script {
+	return e.scripts
+}
+
+// Commands returns the script-provided slash commands sorted by name
+func (e *Engine) Commands() []CommandInfo {
+	commands := make([]CommandInfo, 0, len(e.commands))
+	for _, cmd := range e.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// HasCommand reports whether a script provides the slash command
+func (e *Engine) HasCommand(name string) bool {
+	_, ok := e.commands[name]
+	return ok
+}
+
+// RunHook calls every script that handles the event's hook, in name order.
+// For on_message_send each script sees the message as rewritten by the ones
+// before it. Replies are combined: outputs are joined and any cancel wins.
+func (e *Engine) RunHook(ctx context.Context, event Event) (Reply, error) {
+	var combined Reply
+	var outputs []string
+
+	for _, script := range e.scripts {
+		if !script.handles(event.Hook) {
+			continue
+		}
+		fn := script.globals[string(event.Hook)].(starlark.Callable)
+		reply, err := e.call(ctx, script, fn, event)
+		if err != nil {
+			return combined, err
+		}
+		if reply.Output != "" {
+			outputs = append(outputs, reply.Output)
+		}
+		if reply.Message != "" && event.Hook == HookMessageSend {
+			event.Message = reply.Message
+			combined.Message = reply.Message
+		}
+		if reply.Cancel {
+			combined.Cancel = true
+			break
+		}
+	}
+
+	combined.Output = strings.Join(outputs, "\n")
+	return combined, nil
+}
+
+// RunCommand runs a script-provided slash command
+func (e *Engine) RunCommand(ctx context.Context, event Event) (Reply, error) {
+	cmd, ok := e.commands[event.Command]
+	if !ok {
+		return Reply{}, fmt.Errorf("%w: /%s", ErrCommandNotFound, event.Command)
+	}
+	for _, script := range e.scripts {
+		if script.Name == cmd.Script {
+			event.Hook = hookRunCommand
+			return e.call(ctx, script, script.handlers[cmd.Name], event)
+		}
+	}
+	return Reply{}, fmt.Errorf("%w: /%s", ErrCommandNotFound, event.Command)
+}
+
+// call runs a script function with an event and converts its reply
+func (e *Engine) call(ctx context.Context, script *Script, fn starlark.Callable, event Event) (Reply, error) {
+	var result starlark.Value
+	err := e.withThread(ctx, script.Name, func(thread *starlark.Thread) error {
+		var callErr error
+		result, callErr = starlark.Call(thread, fn, starlark.Tuple{event.value()}, nil)
+		return callErr
+	})
+	if err != nil {
+		return Reply{}, e.scriptError(script.Name, string(event.Hook), err)
+	}
+
+	reply, err := replyFrom(result)
+	if err != nil {
+		return Reply{}, fmt.Errorf("%w: %s %s: %v", ErrInvalidReply, script.Name, event.Hook, err)
+	}
+	return reply, nil
+}
+
+// withThread runs f on a new interpreter thread that is cancelled when the
+// timeout passes or ctx is done
+func (e *Engine) withThread(ctx context.Context, name string, f func(*starlark.Thread) error) error {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout())
+	defer cancel()
+
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			logging.LogDebug("Script output", "script", name, "output", msg)
+		},
+	}
+	stop := context.AfterFunc(ctx, func() { thread.Cancel(ctx.Err().Error()) })
+	defer stop()
+
+	logging.LogDebug("Running script", "script", name)
+	err := f(thread)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (e *Engine) timeout() time.Duration {
+	if e.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return e.Timeout
+}
+
+// scriptError wraps an error raised while running a script
+func (e *Engine) scriptError(name, stage string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %s %s: timed out after %s", ErrScriptFailed, name, stage, e.timeout())
+	}
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%w: %s %s: %s", ErrScriptFailed, name, stage, evalErr.Backtrace())
+	}
+	return fmt.Errorf("%w: %s %s: %v", ErrScriptFailed, name, stage, err)
+}
+
+// value converts the event to the struct passed to script functions
+func (ev Event) value() starlark.Value {
+	args := make([]starlark.Value, len(ev.Args))
+	for i, arg := range ev.Args {
+		args[i] = starlark.String(arg)
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"hook":       starlark.String(ev.Hook),
+		"session_id": starlark.String(ev.SessionID),
+		"model":      starlark.String(ev.Model),
+		"message":    starlark.String(ev.Message),
+		"response":   starlark.String(ev.Response),
+		"command":    starlark.String(ev.Command),
+		"args":       starlark.NewList(args),
+	})
+}
+
+// replyFrom converts the value returned by a script function into a reply
+func replyFrom(v starlark.Value) (Reply, error) {
+	var reply Reply
+	if v == starlark.None {
+		return reply, nil
+	}
+	dict, ok := v.(*starlark.Dict)
+	if !ok {
+		return reply, fmt.Errorf("got %s, want dict or None", v.Type())
+	}
+
+	for _, item := range dict.Items() {
+		key, ok := starlark.AsString(item[0])
+		if !ok {
+			return reply, fmt.Errorf("reply key %s is not a string", item[0])
+		}
+		if key == "cancel" {
+			reply.Cancel = bool(item[1].Truth())
+			continue
+		}
+		value, ok := starlark.AsString(item[1])
+		if !ok {
+			return reply, fmt.Errorf("reply %q is %s, want string", key, item[1].Type())
+		}
+		switch key {
+		case "message":
+			reply.Message = value
+		case "send":
+			reply.Send = value
+		case "output":
+			reply.Output = value
+		default:
+			return reply, fmt.Errorf("unknown reply key %q", key)
+		}
+	}
+	return reply, nil
+}
@@ -0,0 +1,133 @@
+// ABOUTME: Tests for user script hooks and commands
+// ABOUTME: Uses small Starlark scripts to exercise hook chaining, commands, and failures
+
+package scripting
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript writes a Starlark script into dir
+func writeScript(t *testing.T, dir, name, body string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0644))
+}
+
+func TestEngine(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "a-upper.star", `
+def on_message_send(event):
+    return {"message": "REWRITTEN", "output": "a saw it"}
+
+def on_response(event):
+    pass
+
+def shout(event):
+    return {"output": "LOUD " + ",".join(event.args), "send": "say it loud"}
+
+command("/shout", shout, description = "Shout it")
+`)
+	writeScript(t, dir, "b-guard.star", `
+def on_message_send(event):
+    if event.message == "REWRITTEN":
+        return {"cancel": True, "output": "blocked"}
+
+def on_command(event):
+    return {}
+`)
+	writeScript(t, dir, "c-broken.star", `def on_response(event)`)
+	writeScript(t, dir, "d-duplicate.star", `command("shout", lambda event: None)`)
+	writeScript(t, dir, "notes.txt", "not a script")
+
+	engine, warnings := Load(context.Background(), dir)
+	require.Len(t, warnings, 2)
+	assert.ErrorIs(t, warnings[0], ErrScriptFailed)
+	assert.Contains(t, warnings[1].Error(), "already provided by a-upper.star")
+	require.Len(t, engine.Scripts(), 3)
+	assert.Equal(t, []Hook{HookMessageSend, HookResponse}, engine.Scripts()[0].Hooks)
+
+	t.Run("commands", func(t *testing.T) {
+		commands := engine.Commands()
+		require.Len(t, commands, 1)
+		assert.Equal(t, "shout", commands[0].Name)
+		assert.Equal(t, "Shout it", commands[0].Description)
+		assert.Equal(t, "a-upper.star", commands[0].Script)
+		assert.True(t, engine.HasCommand("shout"))
+
+		reply, err := engine.RunCommand(context.Background(), Event{Command: "shout", Args: []string{"x", "y"}})
+		require.NoError(t, err)
+		assert.Equal(t, "LOUD x,y", reply.Output)
+		assert.Equal(t, "say it loud", reply.Send)
+
+		_, err = engine.RunCommand(context.Background(), Event{Command: "missing"})
+		assert.ErrorIs(t, err, ErrCommandNotFound)
+	})
+
+	t.Run("hooks chain in name order", func(t *testing.T) {
+		reply, err := engine.RunHook(context.Background(), Event{Hook: HookMessageSend, Message: "hello"})
+		require.NoError(t, err)
+		assert.Equal(t, "REWRITTEN", reply.Message)
+		assert.True(t, reply.Cancel)
+		assert.Equal(t, "a saw it\nblocked", reply.Output)
+	})
+
+	t.Run("empty reply changes nothing", func(t *testing.T) {
+		reply, err := engine.RunHook(context.Background(), Event{Hook: HookResponse, Response: "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, Reply{}, reply)
+	})
+
+	t.Run("failures, bad replies, and timeouts", func(t *testing.T) {
+		failDir := t.TempDir()
+		writeScript(t, failDir, "fail.star", `
+def on_command(event):
+    fail("boom")
+`)
+		writeScript(t, failDir, "reply.star", `
+def on_message_send(event):
+    return {"message": 42}
+`)
+		writeScript(t, failDir, "slow.star", `
+def on_response(event):
+    for _ in range(1000000000):
+        pass
+`)
+		writeScript(t, failDir, "late.star", `
+def later(event):
+    command("late", later)
+
+command("register", later)
+`)
+		engine, warnings := Load(context.Background(), failDir)
+		require.Empty(t, warnings)
+		engine.Timeout = 200 * time.Millisecond
+
+		_, err := engine.RunHook(context.Background(), Event{Hook: HookCommand, Command: "help"})
+		assert.ErrorIs(t, err, ErrScriptFailed)
+		assert.Contains(t, err.Error(), "boom")
+
+		_, err = engine.RunHook(context.Background(), Event{Hook: HookMessageSend, Message: "hi"})
+		assert.ErrorIs(t, err, ErrInvalidReply)
+
+		_, err = engine.RunHook(context.Background(), Event{Hook: HookResponse})
+		assert.ErrorIs(t, err, ErrScriptFailed)
+		assert.Contains(t, err.Error(), "timed out")
+
+		_, err = engine.RunCommand(context.Background(), Event{Command: "register"})
+		assert.ErrorIs(t, err, ErrScriptFailed)
+		assert.Contains(t, err.Error(), "only be registered while the script loads")
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		engine, warnings := Load(context.Background(), filepath.Join(dir, "missing"))
+		assert.Empty(t, warnings)
+		assert.Empty(t, engine.Scripts())
+	})
+}
@@ -0,0 +1,103 @@
+// ABOUTME: Built-in secrets backends for environment variables, the OS keychain, and commands
+// ABOUTME: Each backend resolves the key part of a secret:// reference
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/keyring"
+)
+
+// EnvBackend reads secrets from environment variables:
+// secret://env/OPENAI_API_KEY
+type EnvBackend struct{}
+
+// Name returns "env"
+func (EnvBackend) Name() string { return "env" }
+
+// Lookup returns the value of the environment variable named key
+func (EnvBackend) Lookup(ctx context.Context, key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// KeychainBackend reads secrets from the OS keychain under the magellai
+// service: secret://keychain/openai
+type KeychainBackend struct{}
+
+// Name returns "keychain"
+func (KeychainBackend) Name() string { return "keychain" }
+
+// Lookup returns the keychain secret for the account named key
+func (KeychainBackend) Lookup(ctx context.Context, key string) (string, error) {
+	secret, err := keyring.Get(key)
+	switch {
+	case errors.Is(err, keyring.ErrNotFound):
+		return "", ErrNotFound
+	case errors.Is(err, keyring.ErrUnsupported):
+		return "", fmt.Errorf("%w: %v", ErrBackendUnavailable, err)
+	}
+	return secret, err
+}
+
+// DefaultCommandTimeout bounds a secrets command that sets no Timeout
+const DefaultCommandTimeout = 30 * time.Second
+
+// CommandBackend runs a configured shell command and uses its output as
+// the secret: secret://command/openai runs the command with {key}
+// replaced by openai, shell-quoted, such as "pass show magellai/{key}" or
+// "op read op://Private/{key}/credential".
+type CommandBackend struct {
+	// Command is the shell command template
+	Command string
+	// Timeout bounds each lookup; zero means DefaultCommandTimeout
+	Timeout time.Duration
+}
+
+// Name returns "command"
+func (CommandBackend) Name() string { return "command" }
+
+// Lookup runs the command for key and returns its trimmed output
+func (b CommandBackend) Lookup(ctx context.Context, key string) (string, error) {
+	if b.Command == "" {
+		return "", fmt.Errorf("%w: no command configured (set secrets.command)", ErrBackendUnavailable)
+	}
+	timeout := b.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", strings.ReplaceAll(b.Command, "{key}", shellQuote(key)))
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("secret command timed out after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("secret command failed: %s", msg)
+		}
+		return "", fmt.Errorf("secret command failed: %w", err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// shellQuote quotes s as a single sh word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
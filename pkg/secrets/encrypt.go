@@ -0,0 +1,211 @@
+// ABOUTME: Encrypts and decrypts secret values stored in config files
+// ABOUTME: Uses AES-256-GCM with a key derived from a passphrase or kept in the OS keychain
+
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lexlapax/magellai/pkg/keyring"
+)
+
+// EncryptedPrefix marks an encrypted value: enc:<method>:<base64 data>
+const EncryptedPrefix = "enc:"
+
+// Encryption methods, naming where the key comes from
+const (
+	// MethodPassphrase derives the key from a passphrase
+	MethodPassphrase = "passphrase"
+	// MethodKeyring keeps a random key in the OS keychain
+	MethodKeyring = "keyring"
+)
+
+// KeyringAccount is the keychain account holding the key for MethodKeyring
+const KeyringAccount = "config-encryption-key"
+
+const (
+	saltSize          = 16
+	keySize           = 32
+	pbkdf2Iterations  = 600000
+	passphraseMinSize = 8
+)
+
+var (
+	derivedMu   sync.Mutex
+	derivedKeys = map[string][]byte{} // passphrase keys by salt and passphrase
+)
+
+// IsEncrypted reports whether value is an encrypted value
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
+
+// Encrypter encrypts values with one key. Values encrypted with a passphrase
+// share a salt, so decrypting a file derives the key once.
+type Encrypter struct {
+	method string
+	key    []byte
+	salt   []byte
+}
+
+// NewPassphraseEncrypter creates an encrypter with a key derived from
+// passphrase
+func NewPassphraseEncrypter(passphrase string) (*Encrypter, error) {
+	if len(passphrase) < passphraseMinSize {
+		return nil, fmt.Errorf("%w: passphrase must be at least %d characters", ErrEncryption, passphraseMinSize)
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEncryption, err)
+	}
+	key, err := passphraseKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &Encrypter{method: MethodPassphrase, key: key, salt: salt}, nil
+}
+
+// NewKeyringEncrypter creates an encrypter with the key in the OS keychain,
+// generating and storing a key the first time
+func NewKeyringEncrypter() (*Encrypter, error) {
+	key, err := keyringKey()
+	if errors.Is(err, keyring.ErrNotFound) {
+		key = make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrEncryption, err)
+		}
+		if err := keyring.Set(KeyringAccount, base64.StdEncoding.EncodeToString(key)); err != nil {
+			return nil, fmt.Errorf("%w: failed to store key in %s: %v", ErrBackendUnavailable, keyring.Name(), err)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return &Encrypter{method: MethodKeyring, key: key}, nil
+}
+
+// Method returns where the encrypter's key comes from
+func (e *Encrypter) Method() string {
+	return e.method
+}
+
+// Encrypt returns plaintext as an encrypted value
+func (e *Encrypter) Encrypt(plaintext string) (string, error) {
+	gcm, err := newGCM(e.key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrEncryption, err)
+	}
+
+	data := append([]byte{}, e.salt...)
+	data = append(data, nonce...)
+	data = gcm.Seal(data, nonce, []byte(plaintext), nil)
+	return EncryptedPrefix + e.method + ":" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Decrypt returns the plaintext of an encrypted value. Values that are not
+// encrypted are returned unchanged. The passphrase is only needed for
+// values encrypted with one.
+func Decrypt(value, passphrase string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	method, encoded, ok := strings.Cut(strings.TrimPrefix(value, EncryptedPrefix), ":")
+	if !ok {
+		return "", fmt.Errorf("%w: malformed encrypted value", ErrDecryption)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed encrypted value: %v", ErrDecryption, err)
+	}
+
+	var key []byte
+	switch method {
+	case MethodPassphrase:
+		if passphrase == "" {
+			return "", ErrPassphraseRequired
+		}
+		if len(data) < saltSize {
+			return "", fmt.Errorf("%w: malformed encrypted value", ErrDecryption)
+		}
+		if key, err = passphraseKey(passphrase, data[:saltSize]); err != nil {
+			return "", err
+		}
+		data = data[saltSize:]
+	case MethodKeyring:
+		if key, err = keyringKey(); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("%w: unknown encryption method %q", ErrDecryption, method)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("%w: malformed encrypted value", ErrDecryption)
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: wrong key or corrupted value", ErrDecryption)
+	}
+	return string(plaintext), nil
+}
+
+// passphraseKey derives a key from a passphrase, caching it for the salt
+func passphraseKey(passphrase string, salt []byte) ([]byte, error) {
+	id := string(salt) + "\x00" + passphrase
+	derivedMu.Lock()
+	defer derivedMu.Unlock()
+	if key, ok := derivedKeys[id]; ok {
+		return key, nil
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEncryption, err)
+	}
+	derivedKeys[id] = key
+	return key, nil
+}
+
+// keyringKey returns the encryption key stored in the OS keychain
+func keyringKey() ([]byte, error) {
+	encoded, err := keyring.Get(KeyringAccount)
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrBackendUnavailable, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("%w: invalid key in %s", ErrDecryption, keyring.Name())
+	}
+	return key, nil
+}
+
+// newGCM returns an AES-GCM cipher for key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEncryption, err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEncryption, err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,78 @@
+// ABOUTME: Tests for encrypting and decrypting config values
+// ABOUTME: Covers passphrase and keychain keys, wrong keys, and malformed values
+
+package secrets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassphraseEncryption(t *testing.T) {
+	enc, err := NewPassphraseEncrypter("correct horse")
+	require.NoError(t, err)
+
+	value, err := enc.Encrypt("sk-secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, "enc:passphrase:"))
+	assert.NotContains(t, value, "sk-secret")
+
+	other, err := enc.Encrypt("sk-secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, value, other, "each value gets its own nonce")
+
+	plaintext, err := Decrypt(value, "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", plaintext)
+
+	_, err = Decrypt(value, "wrong passphrase")
+	assert.ErrorIs(t, err, ErrDecryption)
+	_, err = Decrypt(value, "")
+	assert.ErrorIs(t, err, ErrPassphraseRequired)
+
+	_, err = NewPassphraseEncrypter("short")
+	assert.ErrorIs(t, err, ErrEncryption)
+}
+
+func TestKeyringEncryption(t *testing.T) {
+	backend := keyring.NewMemoryBackend()
+	defer keyring.SetBackend(backend)()
+
+	enc, err := NewKeyringEncrypter()
+	require.NoError(t, err)
+	stored, err := backend.Get(KeyringAccount)
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored, "a key is generated on first use")
+
+	value, err := enc.Encrypt("sk-secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, "enc:keyring:"))
+
+	// A second encrypter reuses the stored key
+	again, err := NewKeyringEncrypter()
+	require.NoError(t, err)
+	assert.Equal(t, enc.key, again.key)
+
+	plaintext, err := Decrypt(value, "")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", plaintext)
+
+	require.NoError(t, keyring.Delete(KeyringAccount))
+	_, err = Decrypt(value, "")
+	assert.ErrorIs(t, err, keyring.ErrNotFound)
+}
+
+func TestDecrypt(t *testing.T) {
+	plaintext, err := Decrypt("sk-plain", "")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-plain", plaintext, "values that are not encrypted are unchanged")
+
+	for _, value := range []string{"enc:", "enc:passphrase:!!!", "enc:passphrase:YWJj", "enc:rot13:YWJj"} {
+		_, err := Decrypt(value, "passphrase")
+		assert.ErrorIs(t, err, ErrDecryption, value)
+	}
+}
@@ -0,0 +1,29 @@
+// ABOUTME: Error definitions for the secrets package
+// ABOUTME: Reports malformed secret references, failed lookups, and failed encryption
+
+package secrets
+
+import "errors"
+
+var (
+	// ErrInvalidReference indicates a secret:// reference that cannot be parsed
+	ErrInvalidReference = errors.New("invalid secret reference")
+
+	// ErrUnknownBackend indicates a reference to a backend that is not registered
+	ErrUnknownBackend = errors.New("unknown secrets backend")
+
+	// ErrNotFound indicates the backend has no secret for the key
+	ErrNotFound = errors.New("secret not found")
+
+	// ErrBackendUnavailable indicates the backend is not configured or cannot be reached
+	ErrBackendUnavailable = errors.New("secrets backend unavailable")
+
+	// ErrEncryption indicates a value could not be encrypted
+	ErrEncryption = errors.New("encryption failed")
+
+	// ErrDecryption indicates an encrypted value could not be decrypted
+	ErrDecryption = errors.New("decryption failed")
+
+	// ErrPassphraseRequired indicates a value is encrypted with a passphrase that was not given
+	ErrPassphraseRequired = errors.New("passphrase required to decrypt value")
+)
@@ -0,0 +1,107 @@
+// ABOUTME: Resolves secret:// references in configuration to secret values
+// ABOUTME: Dispatches each reference to a named backend and caches the results
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// Scheme prefixes a secret reference: secret://<backend>/<key>
+const Scheme = "secret://"
+
+// Backend looks up secrets by key
+type Backend interface {
+	// Name is the backend's name in references
+	Name() string
+	// Lookup returns the secret for key, or ErrNotFound
+	Lookup(ctx context.Context, key string) (string, error)
+}
+
+// Resolver resolves references using its backends. Resolved values are
+// cached for the life of the resolver.
+type Resolver struct {
+	mu       sync.Mutex
+	backends map[string]Backend
+	cache    map[string]string
+}
+
+// NewResolver creates a resolver using the given backends
+func NewResolver(backends ...Backend) *Resolver {
+	r := &Resolver{
+		backends: make(map[string]Backend),
+		cache:    make(map[string]string),
+	}
+	for _, b := range backends {
+		r.backends[b.Name()] = b
+	}
+	return r
+}
+
+// IsReference reports whether value is a secret:// reference
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, Scheme)
+}
+
+// Parse splits a reference into its backend name and key
+func Parse(ref string) (backend, key string, err error) {
+	if !IsReference(ref) {
+		return "", "", fmt.Errorf("%w: %q does not start with %s", ErrInvalidReference, ref, Scheme)
+	}
+	backend, key, _ = strings.Cut(strings.TrimPrefix(ref, Scheme), "/")
+	if backend == "" || key == "" {
+		return "", "", fmt.Errorf("%w: %q (expected %s<backend>/<key>)", ErrInvalidReference, ref, Scheme)
+	}
+	return backend, key, nil
+}
+
+// Backends returns the names of the registered backends
+func (r *Resolver) Backends() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.backends))
+	for name := range r.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the secret a reference points to. Values that are not
+// references are returned unchanged.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+	name, key, err := Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if secret, ok := r.cache[value]; ok {
+		return secret, nil
+	}
+	backend, ok := r.backends[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownBackend, name)
+	}
+
+	secret, err := backend.Lookup(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("%s%s/%s: %w", Scheme, name, key, err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("%s%s/%s: %w", Scheme, name, key, ErrNotFound)
+	}
+	logging.LogDebug("Resolved secret reference", "backend", name, "key", key)
+	r.cache[value] = secret
+	return secret, nil
+}
@@ -0,0 +1,148 @@
+// ABOUTME: Tests for secret:// reference resolution
+// ABOUTME: Covers parsing, caching, and the env, keychain, command, and Vault backends
+
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingBackend returns fixed secrets and counts lookups
+type countingBackend struct {
+	values  map[string]string
+	lookups int
+}
+
+func (b *countingBackend) Name() string { return "test" }
+
+func (b *countingBackend) Lookup(ctx context.Context, key string) (string, error) {
+	b.lookups++
+	if v, ok := b.values[key]; ok {
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+
+func TestParse(t *testing.T) {
+	backend, key, err := Parse("secret://vault/secret/data/app#key")
+	require.NoError(t, err)
+	assert.Equal(t, "vault", backend)
+	assert.Equal(t, "secret/data/app#key", key)
+
+	for _, ref := range []string{"secret://", "secret://env", "secret://env/", "sk-123"} {
+		_, _, err := Parse(ref)
+		assert.ErrorIs(t, err, ErrInvalidReference, ref)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	backend := &countingBackend{values: map[string]string{"openai": "sk-test"}}
+	r := NewResolver(backend)
+	ctx := context.Background()
+
+	value, err := r.Resolve(ctx, "plain-key")
+	require.NoError(t, err)
+	assert.Equal(t, "plain-key", value)
+
+	for i := 0; i < 2; i++ {
+		value, err = r.Resolve(ctx, "secret://test/openai")
+		require.NoError(t, err)
+		assert.Equal(t, "sk-test", value)
+	}
+	assert.Equal(t, 1, backend.lookups, "resolved values are cached")
+
+	_, err = r.Resolve(ctx, "secret://test/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = r.Resolve(ctx, "secret://nope/key")
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+	assert.Equal(t, []string{"test"}, r.Backends())
+}
+
+func TestEnvBackend(t *testing.T) {
+	t.Setenv("MAGELLAI_TEST_SECRET", "from-env")
+	value, err := EnvBackend{}.Lookup(context.Background(), "MAGELLAI_TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	_, err = EnvBackend{}.Lookup(context.Background(), "MAGELLAI_TEST_UNSET_SECRET")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestKeychainBackend(t *testing.T) {
+	restore := keyring.SetBackend(keyring.NewMemoryBackend())
+	defer restore()
+	require.NoError(t, keyring.Set("anthropic", "sk-ant"))
+
+	value, err := KeychainBackend{}.Lookup(context.Background(), "anthropic")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-ant", value)
+
+	_, err = KeychainBackend{}.Lookup(context.Background(), "gemini")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCommandBackend(t *testing.T) {
+	b := CommandBackend{Command: "echo secret-for-{key}"}
+	value, err := b.Lookup(context.Background(), "openai")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-for-openai", value)
+
+	_, err = CommandBackend{Command: "echo denied >&2; exit 1"}.Lookup(context.Background(), "openai")
+	assert.ErrorContains(t, err, "denied")
+
+	// Keys are passed as one quoted word, never run as shell syntax
+	value, err = b.Lookup(context.Background(), "x'; echo pwned; '")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-for-x'; echo pwned; '", value)
+
+	_, err = CommandBackend{Command: "sleep 5", Timeout: 50 * time.Millisecond}.Lookup(context.Background(), "openai")
+	assert.ErrorContains(t, err, "timed out")
+
+	_, err = CommandBackend{}.Lookup(context.Background(), "openai")
+	assert.ErrorIs(t, err, ErrBackendUnavailable)
+}
+
+func TestVaultBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/magellai":
+			_, _ = w.Write([]byte(`{"data": {"data": {"openai": "sk-v2"}, "metadata": {"version": 1}}}`))
+		case "/v1/kv/magellai":
+			_, _ = w.Write([]byte(`{"data": {"value": "sk-v1"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	b := &VaultBackend{Address: server.URL, Token: "root"}
+	ctx := context.Background()
+
+	value, err := b.Lookup(ctx, "secret/data/magellai#openai")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-v2", value)
+
+	value, err = b.Lookup(ctx, "kv/magellai")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-v1", value)
+
+	_, err = b.Lookup(ctx, "secret/data/magellai#anthropic")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = b.Lookup(ctx, "secret/data/other")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = (&VaultBackend{Address: server.URL, Token: "wrong"}).Lookup(ctx, "kv/magellai")
+	assert.ErrorContains(t, err, "403")
+}
@@ -0,0 +1,118 @@
+// ABOUTME: HashiCorp Vault secrets backend using the Vault HTTP API
+// ABOUTME: Reads fields from KV version 1 and 2 secrets with a token from config or the environment
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// VaultBackend reads secrets from HashiCorp Vault:
+// secret://vault/secret/data/magellai#openai reads the openai field of the
+// KV secret at secret/data/magellai. Without #field, the "value" field is
+// read.
+type VaultBackend struct {
+	// Address is the Vault server URL (default $VAULT_ADDR)
+	Address string
+	// Token authenticates requests (default $VAULT_TOKEN or ~/.vault-token)
+	Token string
+	// Namespace is the Vault Enterprise namespace (default $VAULT_NAMESPACE)
+	Namespace string
+	// Client sends requests (default: 30 second timeout)
+	Client *http.Client
+}
+
+// Name returns "vault"
+func (*VaultBackend) Name() string { return "vault" }
+
+// Lookup reads path#field from Vault
+func (b *VaultBackend) Lookup(ctx context.Context, key string) (string, error) {
+	path, field, _ := strings.Cut(key, "#")
+	if field == "" {
+		field = "value"
+	}
+
+	address := firstSet(b.Address, os.Getenv("VAULT_ADDR"))
+	if address == "" {
+		return "", fmt.Errorf("%w: no Vault address (set secrets.vault.address or VAULT_ADDR)", ErrBackendUnavailable)
+	}
+	token := firstSet(b.Token, os.Getenv("VAULT_TOKEN"), vaultTokenFile())
+	if token == "" {
+		return "", fmt.Errorf("%w: no Vault token (set VAULT_TOKEN or log in with vault login)", ErrBackendUnavailable)
+	}
+
+	url := strings.TrimRight(address, "/") + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid Vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := firstSet(b.Namespace, os.Getenv("VAULT_NAMESPACE")); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := b.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBackendUnavailable, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("vault returned %s for %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse Vault response: %w", err)
+	}
+	// KV version 2 nests the fields in data.data
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+	value, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: no field %q in %s", ErrNotFound, field, path)
+	}
+	return value, nil
+}
+
+// vaultTokenFile returns the token saved by vault login, if any
+func vaultTokenFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// firstSet returns the first non-empty value
+func firstSet(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+// ABOUTME: Error definitions for the HTTP API server
+// ABOUTME: Provides sentinel errors mapped to HTTP status codes
+
+package server
+
+import "errors"
+
+var (
+	// ErrBadRequest indicates a malformed or incomplete request body
+	ErrBadRequest = errors.New("bad request")
+
+	// ErrUnsupportedMediaType indicates a request body that is not declared as JSON
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+	// ErrInsecureAddress indicates a non-loopback address without a token
+	ErrInsecureAddress = errors.New("refusing to serve on a non-loopback address without a token")
+
+	// ErrUnauthorized indicates a missing or wrong bearer token
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrNotConfigured indicates the server was created without a required dependency
+	ErrNotConfigured = errors.New("server not configured")
+)
@@ -0,0 +1,59 @@
+// ABOUTME: Request metrics for the API server and the /metrics endpoint that exposes them
+// ABOUTME: Counts requests by route and status and times them for Prometheus scraping
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/metrics"
+)
+
+// statusRecorder remembers the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Flush lets streamed responses through the recorder
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// recordRequest counts and times a handled request. Requests are labelled
+// with the route pattern they matched, so each session ID does not become
+// a series of its own.
+func recordRequest(r *http.Request, status int, started time.Time) {
+	route := r.Pattern
+	if route == "" {
+		route = "unmatched"
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	metrics.HTTPRequests.Inc(r.Method, route, strconv.Itoa(status))
+	metrics.HTTPRequestDuration.Observe(time.Since(started).Seconds(), r.Method, route)
+}
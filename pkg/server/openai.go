@@ -0,0 +1,438 @@
+// ABOUTME: OpenAI-compatible chat completions endpoint for the API server
+// ABOUTME: Routes /v1/chat/completions through magellai providers, profiles, and fallback chains
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/models"
+)
+
+// newProvider creates providers; tests replace it with a mock
+var newProvider = llm.NewProvider
+
+// chainTimeout bounds each request routed through a fallback chain
+const chainTimeout = 5 * time.Minute
+
+// chatCompletionRequest is the subset of the OpenAI request body magellai honors
+type chatCompletionRequest struct {
+	Model            string        `json:"model"`
+	Messages         []chatMessage `json:"messages"`
+	Stream           bool          `json:"stream,omitempty"`
+	Temperature      *float64      `json:"temperature,omitempty"`
+	TopP             *float64      `json:"top_p,omitempty"`
+	MaxTokens        int           `json:"max_tokens,omitempty"`
+	MaxCompletion    int           `json:"max_completion_tokens,omitempty"`
+	Stop             stopSequences `json:"stop,omitempty"`
+	PresencePenalty  *float64      `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64      `json:"frequency_penalty,omitempty"`
+	Seed             *int          `json:"seed,omitempty"`
+}
+
+// chatMessage is an OpenAI message; content is a string or a list of parts
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// stopSequences accepts a string or a list of strings
+type stopSequences []string
+
+func (s *stopSequences) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = []string{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("stop must be a string or list of strings")
+	}
+	*s = list
+	return nil
+}
+
+// text returns the message content, joining text parts
+func (m chatMessage) text() (string, error) {
+	if len(m.Content) == 0 || string(m.Content) == "null" {
+		return "", nil
+	}
+	var content string
+	if err := json.Unmarshal(m.Content, &content); err == nil {
+		return content, nil
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return "", fmt.Errorf("%w: content must be a string or list of parts", ErrBadRequest)
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type != "text" {
+			return "", fmt.Errorf("%w: unsupported content part type %q", ErrBadRequest, part.Type)
+		}
+		texts = append(texts, part.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// toDomainMessages converts OpenAI messages to conversation messages
+func toDomainMessages(messages []chatMessage) ([]domain.Message, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("%w: messages are required", ErrBadRequest)
+	}
+
+	result := make([]domain.Message, 0, len(messages))
+	for i, msg := range messages {
+		var role domain.MessageRole
+		switch msg.Role {
+		case "system", "developer":
+			role = domain.MessageRoleSystem
+		case "user":
+			role = domain.MessageRoleUser
+		case "assistant":
+			role = domain.MessageRoleAssistant
+		default:
+			return nil, fmt.Errorf("%w: messages[%d]: unsupported role %q", ErrBadRequest, i, msg.Role)
+		}
+		content, err := msg.text()
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		result = append(result, domain.Message{Role: role, Content: content, Timestamp: time.Now()})
+	}
+	return result, nil
+}
+
+// route is a resolved model: the provider to call and defaults from its profile
+type route struct {
+	provider llm.Provider
+	model    string
+	options  []llm.ProviderOption
+}
+
+// resolveRoute maps a requested model to a provider. The model may be a
+// profile name, whose fallbacks form a chain, or a provider/model pair. An
+// empty model uses the current profile or model.default.
+func (s *Server) resolveRoute(ctx context.Context, requested string) (*route, error) {
+	if s.config == nil {
+		return nil, fmt.Errorf("%w: configuration is required for chat completions", ErrNotConfigured)
+	}
+
+	name := requested
+	if name == "" {
+		name = s.config.GetString("profile.current")
+	}
+	if name != "" && !strings.Contains(name, "/") {
+		if profile, err := s.config.GetProfile(name); err == nil {
+			return s.profileRoute(ctx, name, profile)
+		} else if !errors.Is(err, config.ErrProfileNotFound) {
+			return nil, err
+		}
+	}
+
+	model := requested
+	if model == "" {
+		model = s.config.GetString("model.default")
+	}
+	if !strings.Contains(model, "/") {
+		return nil, fmt.Errorf("%w: unknown model %q (use provider/model or a profile name)", ErrBadRequest, model)
+	}
+	provider, err := s.createProvider(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	return &route{provider: provider, model: model}, nil
+}
+
+// profileRoute builds the provider chain and default options for a profile
+func (s *Server) profileRoute(ctx context.Context, name string, profile *config.ProfileConfig) (*route, error) {
+	model := profile.Model
+	if !strings.Contains(model, "/") {
+		if model == "" {
+			model = s.config.GetString(fmt.Sprintf("provider.%s.default_model", profile.Provider))
+		}
+		model = profile.Provider + "/" + model
+	}
+
+	primary, err := s.createProvider(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	var fallbacks []llm.Provider
+	for _, fallbackModel := range profile.Fallbacks {
+		fallback, err := s.createProvider(ctx, fallbackModel)
+		if err != nil {
+			logging.LogWarn("Skipping fallback provider", "profile", name, "model", fallbackModel, "error", err)
+			continue
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+
+	r := &route{provider: primary, model: model}
+	if len(fallbacks) > 0 {
+		r.provider = llm.NewResilientProvider(llm.ResilientProviderConfig{
+			Primary:        primary,
+			Fallbacks:      fallbacks,
+			RetryConfig:    llm.DefaultRetryConfig(),
+			EnableFallback: true,
+			Timeout:        chainTimeout,
+		})
+	}
+
+	if temp, ok := profile.Settings["temperature"].(float64); ok {
+		r.options = append(r.options, llm.WithTemperature(temp))
+	}
+	switch maxTokens := profile.Settings["max_tokens"].(type) {
+	case int:
+		r.options = append(r.options, llm.WithMaxTokens(maxTokens))
+	case float64:
+		r.options = append(r.options, llm.WithMaxTokens(int(maxTokens)))
+	}
+
+	logging.LogDebug("Resolved profile route", "profile", name, "model", model, "fallbacks", len(fallbacks))
+	return r, nil
+}
+
+// createProvider creates a provider for a provider/model pair using the configured API key
+func (s *Server) createProvider(ctx context.Context, model string) (llm.Provider, error) {
+	providerName, modelName := llm.ParseModelString(model)
+	apiKey := s.config.GetProviderAPIKeyContext(ctx, providerName)
+	provider, err := newProvider(providerName, modelName, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider for %s: %w", model, err)
+	}
+	return provider, nil
+}
+
+// requestOptions converts request parameters to provider options, after the
+// route's defaults so request values win
+func (req *chatCompletionRequest) options(defaults []llm.ProviderOption) []llm.ProviderOption {
+	opts := append([]llm.ProviderOption{}, defaults...)
+	if req.Temperature != nil {
+		opts = append(opts, llm.WithTemperature(*req.Temperature))
+	}
+	if req.TopP != nil {
+		opts = append(opts, llm.WithTopP(*req.TopP))
+	}
+	if maxTokens := max(req.MaxTokens, req.MaxCompletion); maxTokens > 0 {
+		opts = append(opts, llm.WithMaxTokens(maxTokens))
+	}
+	if len(req.Stop) > 0 {
+		opts = append(opts, llm.WithStopSequences(req.Stop...))
+	}
+	if req.PresencePenalty != nil {
+		opts = append(opts, llm.WithPresencePenalty(*req.PresencePenalty))
+	}
+	if req.FrequencyPenalty != nil {
+		opts = append(opts, llm.WithFrequencyPenalty(*req.FrequencyPenalty))
+	}
+	if req.Seed != nil {
+		opts = append(opts, llm.WithSeed(*req.Seed))
+	}
+	return opts
+}
+
+// handleChatCompletions implements POST /v1/chat/completions
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if err := requireJSON(r); err != nil {
+		writeOpenAIError(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+	var req chatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, fmt.Errorf("%w: invalid JSON body: %v", ErrBadRequest, err))
+		return
+	}
+	messages, err := toDomainMessages(req.Messages)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, err)
+		return
+	}
+	rt, err := s.resolveRoute(r.Context(), req.Model)
+	if err != nil {
+		writeOpenAIError(w, statusForRoute(err), err)
+		return
+	}
+
+	id := "chatcmpl-" + uuid.New().String()
+	model := req.Model
+	if model == "" {
+		model = rt.model
+	}
+	opts := req.options(rt.options)
+	logging.LogInfo("Chat completion", "model", model, "provider_model", rt.model, "messages", len(messages), "stream", req.Stream)
+
+	if req.Stream {
+		s.streamChatCompletion(w, r, rt, id, model, messages, opts)
+		return
+	}
+
+	resp, err := rt.provider.GenerateMessage(r.Context(), messages, opts...)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	finishReason := resp.FinishReason
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+	result := map[string]interface{}{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": resp.Content},
+			"finish_reason": finishReason,
+		}},
+	}
+	if resp.Usage != nil {
+		result["usage"] = map[string]int{
+			"prompt_tokens":     resp.Usage.InputTokens,
+			"completion_tokens": resp.Usage.OutputTokens,
+			"total_tokens":      resp.Usage.TotalTokens,
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// streamChatCompletion writes the response as OpenAI server-sent event chunks
+func (s *Server) streamChatCompletion(w http.ResponseWriter, r *http.Request, rt *route, id, model string, messages []domain.Message, opts []llm.ProviderOption) {
+	stream, err := rt.provider.StreamMessage(r.Context(), messages, opts...)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	created := time.Now().Unix()
+	send := func(delta map[string]string, finishReason interface{}) {
+		chunk := map[string]interface{}{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   model,
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"delta":         delta,
+				"finish_reason": finishReason,
+			}},
+		}
+		writeEvent(w, chunk)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	send(map[string]string{"role": "assistant", "content": ""}, nil)
+	finishReason := "stop"
+	for chunk := range stream {
+		if chunk.Error != nil {
+			logging.LogWarn("Chat completion stream failed", "error", chunk.Error)
+			writeEvent(w, openAIError(chunk.Error))
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return
+		}
+		if chunk.Content != "" {
+			send(map[string]string{"content": chunk.Content}, nil)
+		}
+		if chunk.FinishReason != "" {
+			finishReason = chunk.FinishReason
+		}
+	}
+	send(map[string]string{}, finishReason)
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// handleModels implements GET /v1/models, listing profiles and known models
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	if s.config != nil {
+		if profiles, ok := s.config.Get("profiles").(map[string]interface{}); ok {
+			for name := range profiles {
+				ids = append(ids, name)
+			}
+		}
+	}
+	sort.Strings(ids)
+	if inventory, err := models.LoadDefaultInventory(); err == nil {
+		for _, model := range inventory.Models {
+			ids = append(ids, model.Provider+"/"+model.Name)
+		}
+	}
+
+	data := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		data = append(data, map[string]interface{}{"id": id, "object": "model", "owned_by": "magellai"})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"object": "list", "data": data})
+}
+
+// statusForRoute maps model resolution errors to HTTP status codes
+func statusForRoute(err error) int {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotConfigured):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// openAIError wraps an error in the OpenAI error envelope
+func openAIError(err error) map[string]interface{} {
+	errType := "api_error"
+	if errors.Is(err, ErrBadRequest) || errors.Is(err, ErrUnsupportedMediaType) {
+		errType = "invalid_request_error"
+	}
+	return map[string]interface{}{"error": map[string]string{"message": err.Error(), "type": errType}}
+}
+
+// writeOpenAIError writes an error in the OpenAI error format
+func writeOpenAIError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		logging.LogError(err, "Chat completion failed")
+	}
+	writeJSON(w, status, openAIError(err))
+}
+
+// writeEvent writes v as a server-sent event
+func writeEvent(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logging.LogWarn("Failed to encode event", "error", err)
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
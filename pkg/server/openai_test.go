@@ -0,0 +1,191 @@
+// ABOUTME: Tests for the OpenAI-compatible chat completions endpoint
+// ABOUTME: Covers model and profile routing, fallbacks, streaming, and error envelopes
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/repl/session"
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/lexlapax/magellai/pkg/testutil/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newChatServer starts a server whose providers come from the given map of
+// provider/model to mock; unknown models fail to create
+func newChatServer(t *testing.T, providers map[string]*mocks.MockProvider) *httptest.Server {
+	t.Helper()
+	require.NoError(t, config.Init())
+	require.NoError(t, config.Manager.Load(nil))
+	cfg := config.Manager
+	require.NoError(t, cfg.SetValue("model.default", "openai/gpt-4o"))
+	require.NoError(t, cfg.SetValue("profile.current", ""))
+	require.NoError(t, cfg.SetValue("profiles.router.provider", "openai"))
+	require.NoError(t, cfg.SetValue("profiles.router.model", "gpt-4o"))
+	require.NoError(t, cfg.SetValue("profiles.router.fallbacks", []string{"anthropic/claude-3-5-haiku-latest"}))
+
+	original := newProvider
+	t.Cleanup(func() { newProvider = original })
+	newProvider = func(providerType, model string, apiKey ...string) (llm.Provider, error) {
+		if p, ok := providers[providerType+"/"+model]; ok {
+			return p, nil
+		}
+		return nil, fmt.Errorf("no such model %s/%s", providerType, model)
+	}
+
+	manager, err := session.CreateStorageManager(storage.FileSystemBackend, storage.Config{"base_dir": t.TempDir()})
+	require.NoError(t, err)
+	srv, err := New(Options{Config: cfg, Registry: command.NewRegistry(), Sessions: &session.SessionManager{StorageManager: manager}})
+	require.NoError(t, err)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postChat(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestChatCompletions(t *testing.T) {
+	t.Run("provider/model request", func(t *testing.T) {
+		gpt := mocks.NewMockProvider()
+		gpt.SetResponse(&llm.Response{Content: "Paris", Usage: &llm.Usage{InputTokens: 5, OutputTokens: 1, TotalTokens: 6}})
+		ts := newChatServer(t, map[string]*mocks.MockProvider{"openai/gpt-4o": gpt})
+
+		resp := postChat(t, ts.URL, `{"model":"openai/gpt-4o","messages":[
+			{"role":"system","content":"Be brief"},
+			{"role":"user","content":[{"type":"text","text":"Capital of France?"}]}],
+			"temperature":0.2,"stop":"\n","user":"ignored"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Object  string `json:"object"`
+			Model   string `json:"model"`
+			Choices []struct {
+				Message      map[string]string `json:"message"`
+				FinishReason string            `json:"finish_reason"`
+			} `json:"choices"`
+			Usage map[string]int `json:"usage"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "chat.completion", body.Object)
+		assert.Equal(t, "openai/gpt-4o", body.Model)
+		require.Len(t, body.Choices, 1)
+		assert.Equal(t, "Paris", body.Choices[0].Message["content"])
+		assert.Equal(t, "stop", body.Choices[0].FinishReason)
+		assert.Equal(t, 6, body.Usage["total_tokens"])
+	})
+
+	t.Run("profile falls back when its model fails", func(t *testing.T) {
+		gpt := mocks.NewMockProvider()
+		gpt.SetError(fmt.Errorf("primary down"))
+		haiku := mocks.NewMockProvider()
+		haiku.SetResponse(&llm.Response{Content: "from fallback"})
+		ts := newChatServer(t, map[string]*mocks.MockProvider{
+			"openai/gpt-4o":                     gpt,
+			"anthropic/claude-3-5-haiku-latest": haiku,
+		})
+
+		resp := postChat(t, ts.URL, `{"model":"router","messages":[{"role":"user","content":"hi"}]}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "router", body["model"])
+		assert.Contains(t, fmt.Sprint(body["choices"]), "from fallback")
+		assert.Equal(t, 1, haiku.GetCallCount("GenerateMessage"))
+	})
+
+	t.Run("streams server-sent events", func(t *testing.T) {
+		gpt := mocks.NewMockProvider()
+		gpt.SetStreamChunks([]llm.StreamChunk{{Content: "Hel"}, {Content: "lo"}, {FinishReason: "stop", Done: true}})
+		ts := newChatServer(t, map[string]*mocks.MockProvider{"openai/gpt-4o": gpt})
+
+		resp := postChat(t, ts.URL, `{"messages":[{"role":"user","content":"hi"}],"stream":true}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		var content strings.Builder
+		var events []string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			events = append(events, data)
+			if data == "[DONE]" {
+				break
+			}
+			var chunk struct {
+				Object  string `json:"object"`
+				Choices []struct {
+					Delta        map[string]string `json:"delta"`
+					FinishReason *string           `json:"finish_reason"`
+				} `json:"choices"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+			assert.Equal(t, "chat.completion.chunk", chunk.Object)
+			content.WriteString(chunk.Choices[0].Delta["content"])
+		}
+		assert.Equal(t, "Hello", content.String())
+		assert.Equal(t, "[DONE]", events[len(events)-1])
+		assert.Contains(t, events[len(events)-2], `"finish_reason":"stop"`)
+	})
+
+	t.Run("errors use the OpenAI envelope", func(t *testing.T) {
+		ts := newChatServer(t, nil)
+
+		for _, body := range []string{
+			`{"model":"openai/gpt-4o","messages":[]}`,
+			`{"model":"openai/gpt-4o","messages":[{"role":"tool","content":"x"}]}`,
+			`{"model":"nosuchprofile","messages":[{"role":"user","content":"hi"}]}`,
+		} {
+			resp := postChat(t, ts.URL, body)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+			var errBody struct {
+				Error map[string]string `json:"error"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&errBody))
+			assert.Equal(t, "invalid_request_error", errBody.Error["type"])
+		}
+
+		resp := postChat(t, ts.URL, `{"model":"openai/unknown","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	})
+}
+
+func TestModels(t *testing.T) {
+	ts := newChatServer(t, nil)
+	resp, err := http.Get(ts.URL + "/v1/models")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body struct {
+		Object string `json:"object"`
+		Data   []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "list", body.Object)
+	var ids []string
+	for _, m := range body.Data {
+		ids = append(ids, m.ID)
+	}
+	assert.Contains(t, ids, "router")
+}
@@ -0,0 +1,491 @@
+// ABOUTME: HTTP API server exposing magellai over REST
+// ABOUTME: Serves one-shot asks, session CRUD, search, export, OpenAI-compatible chat, and Prometheus metrics
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/config"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/llm"
+	"github.com/lexlapax/magellai/pkg/metrics"
+	"github.com/lexlapax/magellai/pkg/repl/session"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// DefaultAddress is where the server listens unless configured otherwise
+const DefaultAddress = "127.0.0.1:8080"
+
+// maxBodySize caps request bodies
+const maxBodySize = 10 << 20
+
+// Options configures the server
+type Options struct {
+	Config   *config.Config          // Profiles, models, and API keys for /v1/chat/completions
+	Registry *command.Registry       // Runs the ask command for POST /ask
+	Sessions *session.SessionManager // Session storage for the /sessions endpoints
+	Token    string                  // Optional bearer token required on every request
+	OnListen func(addr net.Addr)     // Optional: called once the listener is accepted and serving starts
+}
+
+// Server serves the REST API
+type Server struct {
+	config   *config.Config
+	registry *command.Registry
+	sessions *session.SessionManager
+	token    string
+	onListen func(addr net.Addr)
+	mux      *http.ServeMux
+}
+
+// New creates a server and registers its routes
+func New(opts Options) (*Server, error) {
+	if opts.Registry == nil || opts.Sessions == nil {
+		return nil, fmt.Errorf("%w: registry and session manager are required", ErrNotConfigured)
+	}
+
+	s := &Server{
+		config:   opts.Config,
+		registry: opts.Registry,
+		sessions: opts.Sessions,
+		token:    opts.Token,
+		onListen: opts.OnListen,
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.Handle("GET /metrics", metrics.Default.Handler())
+	s.mux.HandleFunc("POST /ask", s.handleAsk)
+	s.mux.HandleFunc("GET /sessions", s.handleListSessions)
+	s.mux.HandleFunc("POST /sessions", s.handleCreateSession)
+	s.mux.HandleFunc("GET /sessions/{id}", s.handleGetSession)
+	s.mux.HandleFunc("PATCH /sessions/{id}", s.handleUpdateSession)
+	s.mux.HandleFunc("DELETE /sessions/{id}", s.handleDeleteSession)
+	s.mux.HandleFunc("GET /sessions/{id}/export", s.handleExportSession)
+	s.mux.HandleFunc("GET /search", s.handleSearch)
+	s.mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("GET /v1/models", s.handleModels)
+	return s, nil
+}
+
+// Handle registers an additional route on the server
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Handler returns the server's HTTP handler, including authentication
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		defer func() { recordRequest(r, recorder.status, start) }()
+		if s.token != "" && r.URL.Path != "/health" && !s.authorized(r) {
+			writeError(recorder, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+		r.Body = http.MaxBytesReader(recorder, r.Body, maxBodySize)
+		s.mux.ServeHTTP(recorder, r)
+		logging.LogDebug("Handled request", "method", r.Method, "path", r.URL.Path, "duration", time.Since(start))
+	})
+}
+
+// ListenAndServe serves on addr until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on listener until ctx is cancelled, then shuts down gracefully.
+// Without a token it only serves on loopback addresses.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	if s.token == "" && !isLoopback(listener.Addr()) {
+		listener.Close()
+		return fmt.Errorf("%w: %s (set --token or server.token)", ErrInsecureAddress, listener.Addr())
+	}
+
+	httpServer := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.Serve(listener)
+	}()
+	logging.LogInfo("API server listening", "address", listener.Addr().String())
+	if s.onListen != nil {
+		s.onListen(listener.Addr())
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		logging.LogInfo("Shutting down API server")
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shut down server: %w", err)
+		}
+		return nil
+	}
+}
+
+// isLoopback reports whether addr only accepts connections from this machine
+func isLoopback(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	return ok && tcpAddr.IP.IsLoopback()
+}
+
+// authorized checks the request's bearer token
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// askRequest is the body of POST /ask
+type askRequest struct {
+	Prompt         string          `json:"prompt"`
+	Model          string          `json:"model,omitempty"`
+	System         string          `json:"system,omitempty"`
+	Temperature    float64         `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	ResponseFormat string          `json:"response_format,omitempty"`
+	Attachments    []askAttachment `json:"attachments,omitempty"`
+}
+
+// askAttachment is an attachment sent inline with POST /ask. The server never
+// reads attachments from its own filesystem.
+type askAttachment struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"` // Base64-encoded content
+}
+
+// attachment converts an inline attachment into the form providers take:
+// text is decoded, while images and other files stay base64 encoded
+func (a askAttachment) attachment() (domain.Attachment, error) {
+	mediaType, _, err := mime.ParseMediaType(a.MimeType)
+	if err != nil {
+		return domain.Attachment{}, fmt.Errorf("%w: attachment %q needs a valid mime_type", ErrBadRequest, a.Name)
+	}
+	content, err := base64.StdEncoding.DecodeString(a.Data)
+	if err != nil || len(content) == 0 {
+		return domain.Attachment{}, fmt.Errorf("%w: attachment %q data must be non-empty base64", ErrBadRequest, a.Name)
+	}
+
+	attachment := domain.Attachment{
+		Type:     domain.AttachmentTypeFile,
+		Content:  []byte(a.Data),
+		Name:     a.Name,
+		MimeType: mediaType,
+		Size:     int64(len(content)),
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "image/"):
+		attachment.Type = domain.AttachmentTypeImage
+	case strings.HasPrefix(mediaType, "text/"), mediaType == "application/json":
+		attachment.Type = domain.AttachmentTypeText
+		attachment.Content = content
+	}
+	return attachment, nil
+}
+
+// handleAsk runs the ask command and returns its JSON output
+func (s *Server) handleAsk(w http.ResponseWriter, r *http.Request) {
+	var req askRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err)
+		return
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: prompt is required", ErrBadRequest))
+		return
+	}
+
+	flags := command.NewFlags(nil)
+	flags.Set("output", "json")
+	if req.Model != "" {
+		flags.Set("model", req.Model)
+	}
+	if req.System != "" {
+		flags.Set("system", req.System)
+	}
+	if req.Temperature != 0 {
+		flags.Set("temperature", req.Temperature)
+	}
+	if req.MaxTokens > 0 {
+		flags.Set("max-tokens", req.MaxTokens)
+	}
+	if req.ResponseFormat != "" {
+		flags.Set("format", req.ResponseFormat)
+	}
+	var attachments []domain.Attachment
+	for _, inline := range req.Attachments {
+		attachment, err := inline.attachment()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	var stdout, stderr bytes.Buffer
+	exec := &command.ExecutionContext{
+		Args:    []string{req.Prompt},
+		Flags:   flags,
+		Stdin:   strings.NewReader(""),
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		Data:    map[string]interface{}{"attachments": attachments},
+		Context: r.Context(),
+	}
+	if err := s.registry.GetExecutor().Execute(r.Context(), "ask", exec); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(stdout.Bytes())
+}
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.sessions.ListSessions()
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if sessions == nil {
+		sessions = []*domain.SessionInfo{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions, "count": len(sessions)})
+}
+
+// sessionRequest is the body of POST and PATCH /sessions
+type sessionRequest struct {
+	Name  *string  `json:"name,omitempty"`
+	Model string   `json:"model,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	var req sessionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err)
+		return
+	}
+
+	name := ""
+	if req.Name != nil {
+		name = *req.Name
+	}
+	sess := s.sessions.StorageManager.NewSession(name)
+	if req.Model != "" {
+		providerName, _ := llm.ParseModelString(req.Model)
+		sess.Conversation.SetModel(providerName, req.Model)
+	}
+	for _, tag := range req.Tags {
+		sess.AddTag(tag)
+	}
+	if err := s.sessions.SaveSession(sess); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	logging.LogInfo("Created session via API", "id", sess.ID)
+	writeJSON(w, http.StatusCreated, sess)
+}
+
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	sess, err := s.sessions.StorageManager.LoadSession(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (s *Server) handleUpdateSession(w http.ResponseWriter, r *http.Request) {
+	var req sessionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err)
+		return
+	}
+
+	sess, err := s.sessions.StorageManager.LoadSession(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if req.Name != nil {
+		sess.Name = *req.Name
+	}
+	if req.Model != "" {
+		providerName, _ := llm.ParseModelString(req.Model)
+		sess.Conversation.SetModel(providerName, req.Model)
+	}
+	if req.Tags != nil {
+		sess.Tags = nil
+		for _, tag := range req.Tags {
+			sess.AddTag(tag)
+		}
+	}
+	sess.Updated = time.Now()
+	if err := s.sessions.SaveSession(sess); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.sessions.DeleteSession(id); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	logging.LogInfo("Deleted session via API", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleExportSession(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = "json"
+	case "json", "markdown", "org", "text", "jsonl", "csv":
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: unsupported export format %q", ErrBadRequest, format))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := s.sessions.ExportSession(r.PathValue("id"), format, &buf); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	contentType := "application/json"
+	switch format {
+	case "markdown":
+		contentType = "text/markdown; charset=utf-8"
+	case "org":
+		contentType = "text/org; charset=utf-8"
+	case "text":
+		contentType = "text/plain; charset=utf-8"
+	case "jsonl":
+		contentType = "application/jsonl"
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: query parameter q is required", ErrBadRequest))
+		return
+	}
+
+	results, err := s.sessions.SearchSessions(query)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	if results == nil {
+		results = []*domain.SearchResult{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"query": query, "results": results, "count": len(results)})
+}
+
+// decodeJSON decodes a request body, rejecting unknown fields and bodies not
+// sent as JSON
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := requireJSON(r); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: invalid JSON body: %v", ErrBadRequest, err)
+	}
+	return nil
+}
+
+// requireJSON rejects bodies whose Content-Type is not application/json.
+// Browsers only send cross-site posts without asking first when the type is
+// one a form could send, so this keeps web pages from driving the API.
+func requireJSON(r *http.Request) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return fmt.Errorf("%w: Content-Type must be application/json", ErrUnsupportedMediaType)
+	}
+	return nil
+}
+
+// decodeStatus maps a decodeJSON error to an HTTP status code
+func decodeStatus(err error) int {
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusBadRequest
+}
+
+// statusFor maps storage errors to HTTP status codes
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidSearchQuery):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrReadOnly):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.LogWarn("Failed to write response", "error", err)
+	}
+}
+
+// writeError writes an error as {"error": "..."}
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		logging.LogError(err, "API request failed")
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
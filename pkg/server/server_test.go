@@ -0,0 +1,271 @@
+// ABOUTME: Tests for the HTTP API server
+// ABOUTME: Exercises ask, session CRUD, search, export, token authentication, and metrics
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/command"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/metrics"
+	"github.com/lexlapax/magellai/pkg/repl/session"
+	"github.com/lexlapax/magellai/pkg/storage"
+	_ "github.com/lexlapax/magellai/pkg/storage/filesystem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoAsk stands in for the ask command, echoing the prompt as JSON
+type echoAsk struct{}
+
+func (echoAsk) Metadata() *command.Metadata {
+	return &command.Metadata{Name: "ask", Category: command.CategoryCLI}
+}
+
+func (echoAsk) Validate() error { return nil }
+
+func (echoAsk) Execute(ctx context.Context, exec *command.ExecutionContext) error {
+	if exec.Args[0] == "fail" {
+		return fmt.Errorf("provider unavailable")
+	}
+	attachments, _ := exec.Data["attachments"].([]domain.Attachment)
+	var types []string
+	for _, att := range attachments {
+		types = append(types, string(att.Type)+":"+string(att.Content))
+	}
+	return json.NewEncoder(exec.Stdout).Encode(map[string]interface{}{
+		"content":     "echo: " + exec.Args[0],
+		"model":       exec.Flags.GetString("model"),
+		"output":      exec.Flags.GetString("output"),
+		"attach":      exec.Flags.GetStringSlice("attach"),
+		"attachments": types,
+	})
+}
+
+func newTestServer(t *testing.T, token string) (*httptest.Server, *session.SessionManager) {
+	t.Helper()
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(echoAsk{}))
+
+	manager, err := session.CreateStorageManager(storage.FileSystemBackend, storage.Config{"base_dir": t.TempDir()})
+	require.NoError(t, err)
+	sessions := &session.SessionManager{StorageManager: manager}
+
+	srv, err := New(Options{Registry: registry, Sessions: sessions, Token: token})
+	require.NoError(t, err)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts, sessions
+}
+
+func doJSON(t *testing.T, method, url, body string, out interface{}) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp
+}
+
+func TestNew_requiresDependencies(t *testing.T) {
+	_, err := New(Options{})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
+
+func TestServer_ask(t *testing.T) {
+	ts, _ := newTestServer(t, "")
+
+	var result map[string]interface{}
+	resp := doJSON(t, http.MethodPost, ts.URL+"/ask", `{"prompt":"hi","model":"openai/gpt-4o"}`, &result)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "echo: hi", result["content"])
+	assert.Equal(t, "openai/gpt-4o", result["model"])
+	assert.Equal(t, "json", result["output"])
+
+	var errBody map[string]string
+	resp = doJSON(t, http.MethodPost, ts.URL+"/ask", `{"prompt":""}`, &errBody)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, errBody["error"], "prompt is required")
+
+	resp = doJSON(t, http.MethodPost, ts.URL+"/ask", `{"prompt":"fail"}`, &errBody)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Contains(t, errBody["error"], "provider unavailable")
+
+	resp = doJSON(t, http.MethodPost, ts.URL+"/ask", `{"prompt":"hi","bogus":1}`, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	form, err := http.Post(ts.URL+"/ask", "text/plain", strings.NewReader(`{"prompt":"hi"}`))
+	require.NoError(t, err)
+	form.Body.Close()
+	assert.Equal(t, http.StatusUnsupportedMediaType, form.StatusCode)
+}
+
+func TestServer_askAttachments(t *testing.T) {
+	ts, _ := newTestServer(t, "")
+
+	var result struct {
+		Attach      []string `json:"attach"`
+		Attachments []string `json:"attachments"`
+	}
+	resp := doJSON(t, http.MethodPost, ts.URL+"/ask", `{"prompt":"hi","attachments":[
+		{"name":"notes.txt","mime_type":"text/plain; charset=utf-8","data":"aGVsbG8="},
+		{"name":"cat.png","mime_type":"image/png","data":"iVBORw=="}]}`, &result)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, result.Attach, "nothing is read from the server's filesystem")
+	assert.Equal(t, []string{"text:hello", "image:iVBORw=="}, result.Attachments)
+
+	for _, body := range []string{
+		`{"prompt":"hi","attachments":["/etc/passwd"]}`,
+		`{"prompt":"hi","attachments":[{"mime_type":"text/plain","data":"not base64!"}]}`,
+		`{"prompt":"hi","attachments":[{"data":"aGVsbG8="}]}`,
+		`{"prompt":"hi","attachments":[{"file_path":"/etc/passwd"}]}`,
+	} {
+		resp = doJSON(t, http.MethodPost, ts.URL+"/ask", body, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+}
+
+func TestServer_serveRequiresTokenOffLoopback(t *testing.T) {
+	registry := command.NewRegistry()
+	manager, err := session.CreateStorageManager(storage.FileSystemBackend, storage.Config{"base_dir": t.TempDir()})
+	require.NoError(t, err)
+	sessions := &session.SessionManager{StorageManager: manager}
+
+	var listening []string
+	onListen := func(addr net.Addr) { listening = append(listening, addr.String()) }
+
+	srv, err := New(Options{Registry: registry, Sessions: sessions, OnListen: onListen})
+	require.NoError(t, err)
+	err = srv.ListenAndServe(context.Background(), "0.0.0.0:0")
+	assert.ErrorIs(t, err, ErrInsecureAddress)
+	assert.Empty(t, listening, "rejected addresses are never announced")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, srv.ListenAndServe(ctx, "127.0.0.1:0"))
+	require.Len(t, listening, 1)
+	assert.Contains(t, listening[0], "127.0.0.1:")
+
+	srv, err = New(Options{Registry: registry, Sessions: sessions, Token: "secret"})
+	require.NoError(t, err)
+	assert.NoError(t, srv.ListenAndServe(ctx, "0.0.0.0:0"))
+}
+
+func TestServer_sessions(t *testing.T) {
+	ts, sessions := newTestServer(t, "")
+
+	// Create
+	var created domain.Session
+	resp := doJSON(t, http.MethodPost, ts.URL+"/sessions", `{"name":"notes","model":"openai/gpt-4o","tags":["work"]}`, &created)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "notes", created.Name)
+	assert.Equal(t, "openai", created.Conversation.Provider)
+	assert.Equal(t, []string{"work"}, created.Tags)
+
+	// List
+	var list struct {
+		Sessions []domain.SessionInfo `json:"sessions"`
+		Count    int                  `json:"count"`
+	}
+	doJSON(t, http.MethodGet, ts.URL+"/sessions", "", &list)
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, created.ID, list.Sessions[0].ID)
+
+	// Update
+	var updated domain.Session
+	resp = doJSON(t, http.MethodPatch, ts.URL+"/sessions/"+created.ID, `{"name":"renamed"}`, &updated)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Equal(t, []string{"work"}, updated.Tags)
+
+	// Show, search, and export see stored messages
+	stored, err := sessions.StorageManager.LoadSession(created.ID)
+	require.NoError(t, err)
+	stored.Conversation.AddMessage(*domain.NewMessage("m1", domain.MessageRoleUser, "the quick brown fox"))
+	require.NoError(t, sessions.SaveSession(stored))
+
+	var shown domain.Session
+	doJSON(t, http.MethodGet, ts.URL+"/sessions/"+created.ID, "", &shown)
+	assert.Len(t, shown.Conversation.Messages, 1)
+
+	var search struct {
+		Count int `json:"count"`
+	}
+	doJSON(t, http.MethodGet, ts.URL+"/search?q=fox", "", &search)
+	assert.Equal(t, 1, search.Count)
+	resp = doJSON(t, http.MethodGet, ts.URL+"/search", "", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	exported, err := http.Get(ts.URL + "/sessions/" + created.ID + "/export?format=markdown")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(exported.Body)
+	exported.Body.Close()
+	assert.Equal(t, http.StatusOK, exported.StatusCode)
+	assert.Contains(t, exported.Header.Get("Content-Type"), "text/markdown")
+	assert.Contains(t, string(body), "the quick brown fox")
+
+	resp = doJSON(t, http.MethodGet, ts.URL+"/sessions/"+created.ID+"/export?format=pdf", "", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Delete, then the session is gone
+	resp = doJSON(t, http.MethodDelete, ts.URL+"/sessions/"+created.ID, "", nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = doJSON(t, http.MethodGet, ts.URL+"/sessions/"+created.ID, "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServer_token(t *testing.T) {
+	ts, _ := newTestServer(t, "secret")
+
+	resp := doJSON(t, http.MethodGet, ts.URL+"/health", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "health needs no token")
+
+	resp = doJSON(t, http.MethodGet, ts.URL+"/sessions", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/sessions", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	authed, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	authed.Body.Close()
+	assert.Equal(t, http.StatusOK, authed.StatusCode)
+}
+
+func TestServer_metrics(t *testing.T) {
+	ts, _ := newTestServer(t, "")
+	notFound := metrics.HTTPRequests.Value("GET", "GET /sessions/{id}", "404")
+	loads := metrics.StorageOperations.Value("filesystem", "load", "error")
+
+	resp := doJSON(t, http.MethodGet, ts.URL+"/sessions/missing", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, notFound+1, metrics.HTTPRequests.Value("GET", "GET /sessions/{id}", "404"))
+	assert.Equal(t, loads+1, metrics.StorageOperations.Value("filesystem", "load", "error"))
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `magellai_http_requests_total{method="GET",route="GET /sessions/{id}",status="404"}`)
+	assert.Contains(t, string(body), `magellai_http_request_duration_seconds_count{method="GET",route="GET /sessions/{id}"}`)
+	assert.Contains(t, string(body), `magellai_storage_operation_duration_seconds_bucket{backend="filesystem",operation="load",le="0.005"}`)
+	assert.Contains(t, string(body), "# TYPE magellai_provider_errors_total counter")
+}
@@ -5,6 +5,7 @@ package storage
 
 import (
 	"io"
+	"strconv"
 
 	"github.com/lexlapax/magellai/pkg/domain"
 )
@@ -165,6 +166,20 @@ type Backend interface {
 // Config represents backend-specific configuration
 type Config map[string]interface{}
 
+// ReadOnly returns true if the "read_only" setting asks for storage that is
+// opened without writing to it. Backends then neither create nor upgrade
+// anything, and the session manager refuses changes with ErrReadOnly.
+func (c Config) ReadOnly() bool {
+	switch v := c["read_only"].(type) {
+	case bool:
+		return v
+	case string:
+		readOnly, _ := strconv.ParseBool(v)
+		return readOnly
+	}
+	return false
+}
+
 // BackendType represents the type of storage backend
 type BackendType string
 
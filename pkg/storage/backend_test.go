@@ -39,6 +39,14 @@ func TestConfig_Type(t *testing.T) {
 	assert.Equal(t, "/tmp/test.db", dbPath)
 }
 
+func TestConfig_ReadOnly(t *testing.T) {
+	assert.False(t, Config{}.ReadOnly())
+	assert.False(t, Config{"read_only": false}.ReadOnly())
+	assert.True(t, Config{"read_only": true}.ReadOnly())
+	assert.True(t, Config{"read_only": "true"}.ReadOnly())
+	assert.False(t, Config{"read_only": "nope"}.ReadOnly())
+}
+
 func TestBackendInterface_Compliance(t *testing.T) {
 	// Create mock backend using centralized mock
 	mock := storagemock.NewMockBackend()
@@ -0,0 +1,93 @@
+// ABOUTME: Helpers for storing attachment content apart from session records
+// ABOUTME: Splits content out by hash on save and sets up lazy loading on load
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// SplitAttachments returns a copy of the session whose attachment content has
+// been replaced by content hashes, and the content keyed by hash. The session
+// itself is not changed. Attachments whose content was never loaded keep
+// their hash.
+func SplitAttachments(session *domain.Session) (*domain.Session, map[string][]byte) {
+	blobs := make(map[string][]byte)
+	if session.Conversation == nil {
+		return session, blobs
+	}
+
+	stripped := *session
+	conv := *session.Conversation
+	conv.Messages = make([]domain.Message, len(session.Conversation.Messages))
+	for i, msg := range session.Conversation.Messages {
+		msg = msg.Clone()
+		for j := range msg.Attachments {
+			att := &msg.Attachments[j]
+			if len(att.Content) == 0 {
+				continue
+			}
+			att.Hash = domain.ContentHash(att.Content)
+			if att.Size == 0 {
+				att.Size = int64(len(att.Content))
+			}
+			blobs[att.Hash] = att.Content
+			att.Content = nil
+		}
+		conv.Messages[i] = msg
+	}
+	stripped.Conversation = &conv
+	return &stripped, blobs
+}
+
+// AttachmentHashes returns the content hashes of the attachments a session
+// stores separately
+func AttachmentHashes(session *domain.Session) map[string]bool {
+	hashes := make(map[string]bool)
+	if session == nil || session.Conversation == nil {
+		return hashes
+	}
+	for _, msg := range session.Conversation.Messages {
+		for _, att := range msg.Attachments {
+			if att.Hash != "" {
+				hashes[att.Hash] = true
+			}
+		}
+	}
+	return hashes
+}
+
+// SetContentLoaders arranges for the session's attachment content stored by
+// hash to be loaded with loader the first time it is needed
+func SetContentLoaders(session *domain.Session, loader domain.ContentLoader) {
+	if session.Conversation == nil {
+		return
+	}
+	for i := range session.Conversation.Messages {
+		msg := &session.Conversation.Messages[i]
+		for j := range msg.Attachments {
+			if msg.Attachments[j].Hash != "" {
+				msg.Attachments[j].SetContentLoader(loader)
+			}
+		}
+	}
+}
+
+// LoadAttachmentContent loads all of the session's attachment content stored
+// by hash, for example before exporting it
+func LoadAttachmentContent(session *domain.Session) error {
+	if session.Conversation == nil {
+		return nil
+	}
+	for i := range session.Conversation.Messages {
+		msg := &session.Conversation.Messages[i]
+		for j := range msg.Attachments {
+			if _, err := msg.Attachments[j].LoadContent(); err != nil {
+				return fmt.Errorf("message %s: %w", msg.ID, err)
+			}
+		}
+	}
+	return nil
+}
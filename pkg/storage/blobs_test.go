@@ -0,0 +1,43 @@
+// ABOUTME: Tests for the helpers that store attachment content by hash
+// ABOUTME: Ensures content is split out without changing the session and loaded lazily
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitAttachments(t *testing.T) {
+	content := []byte("attachment body")
+	session := domain.NewSession("session-1")
+	msg := domain.NewMessage("msg-1", domain.MessageRoleUser, "see attached")
+	msg.AddAttachment(domain.Attachment{ID: "att-1", Type: domain.AttachmentTypeText, Content: content})
+	msg.AddAttachment(domain.Attachment{ID: "att-2", Type: domain.AttachmentTypeText, Content: content})
+	msg.AddAttachment(domain.Attachment{ID: "att-3", Type: domain.AttachmentTypeFile, FilePath: "/tmp/notes.txt"})
+	session.Conversation.AddMessage(*msg)
+
+	stripped, blobs := SplitAttachments(session)
+	hash := domain.ContentHash(content)
+
+	require.Len(t, blobs, 1)
+	assert.Equal(t, content, blobs[hash])
+
+	atts := stripped.Conversation.Messages[0].Attachments
+	assert.Nil(t, atts[0].Content)
+	assert.Equal(t, hash, atts[0].Hash)
+	assert.Equal(t, int64(len(content)), atts[0].Size)
+	assert.Equal(t, hash, atts[1].Hash)
+	assert.Empty(t, atts[2].Hash)
+	assert.Equal(t, map[string]bool{hash: true}, AttachmentHashes(stripped))
+
+	// The original session keeps its content
+	assert.Equal(t, content, session.Conversation.Messages[0].Attachments[0].Content)
+
+	SetContentLoaders(stripped, func(h string) ([]byte, error) { return blobs[h], nil })
+	require.NoError(t, LoadAttachmentContent(stripped))
+	assert.Equal(t, content, stripped.Conversation.Messages[0].Attachments[1].Content)
+}
@@ -39,4 +39,16 @@ var (
 
 	// ErrMergeConflict indicates a merge conflict occurred
 	ErrMergeConflict = errors.New("merge conflict")
+
+	// ErrIndexNotFound indicates the requested retrieval index was not found
+	ErrIndexNotFound = errors.New("index not found")
+
+	// ErrTemplateNotFound indicates the requested session template was not found
+	ErrTemplateNotFound = errors.New("template not found")
+
+	// ErrReadOnly indicates a write to storage opened read-only
+	ErrReadOnly = errors.New("storage is read-only")
+
+	// ErrSnapshotNotFound indicates the requested session snapshot was not found
+	ErrSnapshotNotFound = errors.New("snapshot not found")
 )
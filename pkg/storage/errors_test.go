@@ -72,6 +72,26 @@ func TestErrorConstants(t *testing.T) {
 			err:      ErrMergeConflict,
 			expected: "merge conflict",
 		},
+		{
+			name:     "ErrIndexNotFound",
+			err:      ErrIndexNotFound,
+			expected: "index not found",
+		},
+		{
+			name:     "ErrTemplateNotFound",
+			err:      ErrTemplateNotFound,
+			expected: "template not found",
+		},
+		{
+			name:     "ErrReadOnly",
+			err:      ErrReadOnly,
+			expected: "storage is read-only",
+		},
+		{
+			name:     "ErrSnapshotNotFound",
+			err:      ErrSnapshotNotFound,
+			expected: "snapshot not found",
+		},
 	}
 
 	for _, tt := range tests {
@@ -109,6 +129,10 @@ func TestErrorComparison(t *testing.T) {
 		ErrBranchNotFound,
 		ErrInvalidBranch,
 		ErrMergeConflict,
+		ErrIndexNotFound,
+		ErrTemplateNotFound,
+		ErrReadOnly,
+		ErrSnapshotNotFound,
 	}
 
 	for i, err1 := range allErrors {
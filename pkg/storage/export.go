@@ -0,0 +1,434 @@
+// ABOUTME: Export formats shared by every storage backend
+// ABOUTME: Writes org-mode and plain-text transcripts, JSONL fine-tuning datasets and CSV tables
+
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// orgTimestamp is the layout of an inactive org-mode timestamp
+const orgTimestamp = "[2006-01-02 Mon 15:04]"
+
+// ExportOrg writes a session as an org-mode document: the conversation is a
+// heading with one subheading per message, and the session details are
+// properties
+func ExportOrg(session *domain.Session, w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#+TITLE: %s\n", session.Name)
+	fmt.Fprintf(&b, "#+DATE: %s\n", session.Created.Format(orgTimestamp))
+	if len(session.Tags) > 0 {
+		tags := make([]string, len(session.Tags))
+		for i, tag := range session.Tags {
+			tags[i] = orgTag(tag)
+		}
+		fmt.Fprintf(&b, "#+FILETAGS: :%s:\n", strings.Join(tags, ":"))
+	}
+	b.WriteString("\n")
+
+	if session.Conversation != nil && session.Conversation.SystemPrompt != "" {
+		fmt.Fprintf(&b, "* System Prompt\n%s\n\n", orgText(session.Conversation.SystemPrompt))
+	}
+
+	b.WriteString("* Conversation\n")
+	b.WriteString(":PROPERTIES:\n")
+	fmt.Fprintf(&b, ":SESSION_ID: %s\n", session.ID)
+	fmt.Fprintf(&b, ":CREATED:  %s\n", session.Created.Format(orgTimestamp))
+	fmt.Fprintf(&b, ":UPDATED:  %s\n", session.Updated.Format(orgTimestamp))
+	if session.Conversation != nil && session.Conversation.Model != "" {
+		fmt.Fprintf(&b, ":MODEL:    %s\n", session.Conversation.Model)
+	}
+	b.WriteString(":END:\n\n")
+
+	if session.Conversation != nil {
+		for _, msg := range session.Conversation.Messages {
+			fmt.Fprintf(&b, "** %s\n", roleTitle(msg.Role))
+			fmt.Fprintf(&b, ":PROPERTIES:\n:TIMESTAMP: %s\n:END:\n", msg.Timestamp.Format(orgTimestamp))
+			fmt.Fprintf(&b, "%s\n\n", orgText(msg.Content))
+
+			if len(msg.Attachments) > 0 {
+				b.WriteString("Attachments:\n")
+				for _, att := range msg.Attachments {
+					fmt.Fprintf(&b, "- %s\n", attachmentLabel(att))
+				}
+				b.WriteString("\n")
+			}
+			if len(msg.ToolCalls) > 0 {
+				b.WriteString("Tool calls:\n")
+				for _, call := range msg.ToolCalls {
+					fmt.Fprintf(&b, "- =%s= %s\n", call.ID, call.String())
+				}
+				b.WriteString("\n")
+			}
+			if len(msg.ToolResults) > 0 {
+				b.WriteString("Tool results:\n")
+				for _, result := range msg.ToolResults {
+					fmt.Fprintf(&b, "- =%s= (%s): %s\n", result.ToolCallID, result.Status, result.Text())
+				}
+				b.WriteString("\n")
+			}
+			if len(msg.Citations) > 0 {
+				b.WriteString("Sources:\n")
+				for _, citation := range msg.Citations {
+					fmt.Fprintf(&b, "- %s\n", orgCitation(citation))
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to export session %s as org: %w", session.ID, err)
+	}
+	return nil
+}
+
+// ExportText writes a session as a plain-text transcript
+func ExportText(session *domain.Session, w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Session: %s\n", session.Name)
+	fmt.Fprintf(&b, "ID: %s\n", session.ID)
+	fmt.Fprintf(&b, "Created: %s\n", session.Created.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Updated: %s\n", session.Updated.Format(time.RFC3339))
+	if len(session.Tags) > 0 {
+		fmt.Fprintf(&b, "Tags: %s\n", strings.Join(session.Tags, ", "))
+	}
+	b.WriteString("\n")
+
+	if session.Conversation != nil {
+		if session.Conversation.SystemPrompt != "" {
+			fmt.Fprintf(&b, "System prompt:\n%s\n\n", session.Conversation.SystemPrompt)
+		}
+
+		for _, msg := range session.Conversation.Messages {
+			fmt.Fprintf(&b, "[%s] %s:\n", msg.Timestamp.Format("2006-01-02 15:04:05"), roleTitle(msg.Role))
+			fmt.Fprintf(&b, "%s\n", msg.Content)
+			for _, att := range msg.Attachments {
+				fmt.Fprintf(&b, "  Attachment: %s\n", attachmentLabel(att))
+			}
+			for _, call := range msg.ToolCalls {
+				fmt.Fprintf(&b, "  Tool call %s: %s\n", call.ID, call.String())
+			}
+			for _, result := range msg.ToolResults {
+				fmt.Fprintf(&b, "  Tool result %s (%s): %s\n", result.ToolCallID, result.Status, result.Text())
+			}
+			for _, citation := range msg.Citations {
+				fmt.Fprintf(&b, "  Source: %s\n", textCitation(citation))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to export session %s as text: %w", session.ID, err)
+	}
+	return nil
+}
+
+// roleTitle returns a role with its first letter capitalized, for headings
+func roleTitle(role domain.MessageRole) string {
+	name := string(role)
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// attachmentLabel names an attachment and its type
+func attachmentLabel(att domain.Attachment) string {
+	name := att.GetDisplayName()
+	if name == "" {
+		name = string(att.Type) + "_attachment"
+	}
+	if att.MimeType != "" {
+		return fmt.Sprintf("%s (%s)", name, att.MimeType)
+	}
+	return fmt.Sprintf("%s (%s)", name, att.Type)
+}
+
+// orgText keeps lines of message text that start with an asterisk from
+// being read as org headings
+func orgText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "*") {
+			lines[i] = " " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// orgTag turns a session tag into an org tag, which may only hold letters,
+// digits and _@#%
+func orgTag(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_@#%", r) {
+			return r
+		}
+		return '_'
+	}, tag)
+}
+
+// orgCitation renders a citation as an org link, noting the confidence when
+// it is known
+func orgCitation(c domain.Citation) string {
+	text := c.String()
+	if c.URL != "" {
+		if c.Title != "" {
+			text = fmt.Sprintf("[[%s][%s]]", c.URL, c.Title)
+		} else {
+			text = fmt.Sprintf("[[%s]]", c.URL)
+		}
+	} else if c.Title != "" {
+		text = fmt.Sprintf("%s (%s)", c.Title, text)
+	}
+	if c.Confidence > 0 {
+		text += fmt.Sprintf(" — confidence %.2f", c.Confidence)
+	}
+	return text
+}
+
+// textCitation renders a citation for a plain-text export
+func textCitation(c domain.Citation) string {
+	text := c.String()
+	if c.Title != "" {
+		text = fmt.Sprintf("%s (%s)", c.Title, text)
+	}
+	if c.Confidence > 0 {
+		text += fmt.Sprintf(" — confidence %.2f", c.Confidence)
+	}
+	return text
+}
+
+// JSONL dialects name the fine-tuning message format of a JSONL export
+const (
+	JSONLDialectOpenAI    = "openai"
+	JSONLDialectAnthropic = "anthropic"
+)
+
+// ExportOptions controls exports that reshape a session, such as JSONL
+// fine-tuning datasets
+type ExportOptions struct {
+	PerExchange      bool                 // One line per user message and its replies instead of one per session
+	Roles            []domain.MessageRole // Roles to keep; empty keeps system, user and assistant
+	StripAttachments bool                 // Leave attachments out
+	Dialect          string               // JSONLDialectOpenAI (default) or JSONLDialectAnthropic
+	OmitHeader       bool                 // CSV: leave out the header row, to append to an earlier export
+}
+
+// defaultExportRoles are the roles kept when ExportOptions.Roles is empty;
+// tool messages need the tool call they answer, which a dataset line lacks
+var defaultExportRoles = []domain.MessageRole{domain.MessageRoleSystem, domain.MessageRoleUser, domain.MessageRoleAssistant}
+
+// ParseExportRoles parses role names for ExportOptions.Roles
+func ParseExportRoles(names []string) ([]domain.MessageRole, error) {
+	roles := make([]domain.MessageRole, 0, len(names))
+	for _, name := range names {
+		role := domain.MessageRole(strings.ToLower(strings.TrimSpace(name)))
+		if !role.IsValid() {
+			return nil, fmt.Errorf("unknown role %q: want system, user, assistant or tool", name)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// jsonlLine is one training example: a whole conversation or one exchange
+type jsonlLine struct {
+	System   string         `json:"system,omitempty"`
+	Messages []jsonlMessage `json:"messages"`
+}
+
+// jsonlMessage is a message of a training example. Content is a string, or
+// a list of content parts when the message has attachments.
+type jsonlMessage struct {
+	Role    domain.MessageRole `json:"role"`
+	Content interface{}        `json:"content"`
+}
+
+// ExportJSONL writes a session as JSONL for fine-tuning: one line holding the
+// whole conversation, or with PerExchange one line per user message and the
+// replies to it. Attachment content must already be loaded. Lines without
+// both a user and an assistant message teach nothing and are left out.
+func ExportJSONL(session *domain.Session, w io.Writer, opts ExportOptions) error {
+	dialect := opts.Dialect
+	if dialect == "" {
+		dialect = JSONLDialectOpenAI
+	}
+	if dialect != JSONLDialectOpenAI && dialect != JSONLDialectAnthropic {
+		return fmt.Errorf("unsupported JSONL dialect %q: want %s or %s", dialect, JSONLDialectOpenAI, JSONLDialectAnthropic)
+	}
+	if session.Conversation == nil {
+		return nil
+	}
+
+	keep := make(map[domain.MessageRole]bool)
+	roles := opts.Roles
+	if len(roles) == 0 {
+		roles = defaultExportRoles
+	}
+	for _, role := range roles {
+		keep[role] = true
+	}
+
+	// The system prompt and system messages lead every line
+	var system []string
+	if keep[domain.MessageRoleSystem] && session.Conversation.SystemPrompt != "" {
+		system = append(system, session.Conversation.SystemPrompt)
+	}
+	var exchanges [][]domain.Message
+	for _, msg := range session.Conversation.Messages {
+		if !keep[msg.Role] {
+			continue
+		}
+		if msg.Role == domain.MessageRoleSystem {
+			system = append(system, msg.Content)
+			continue
+		}
+		if len(exchanges) == 0 || (opts.PerExchange && msg.Role == domain.MessageRoleUser) {
+			exchanges = append(exchanges, nil)
+		}
+		exchanges[len(exchanges)-1] = append(exchanges[len(exchanges)-1], msg)
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, exchange := range exchanges {
+		if !isTrainingExample(exchange) {
+			continue
+		}
+		line := jsonlLine{Messages: make([]jsonlMessage, 0, len(exchange)+1)}
+		if len(system) > 0 {
+			if dialect == JSONLDialectAnthropic {
+				line.System = strings.Join(system, "\n\n")
+			} else {
+				line.Messages = append(line.Messages, jsonlMessage{Role: domain.MessageRoleSystem, Content: strings.Join(system, "\n\n")})
+			}
+		}
+		for _, msg := range exchange {
+			line.Messages = append(line.Messages, jsonlMessage{Role: msg.Role, Content: jsonlContent(msg, dialect, opts.StripAttachments)})
+		}
+		if err := encoder.Encode(line); err != nil {
+			return fmt.Errorf("failed to encode session %s as JSONL: %w", session.ID, err)
+		}
+	}
+	return nil
+}
+
+// isTrainingExample reports whether messages include a prompt and a reply to
+// learn from
+func isTrainingExample(messages []domain.Message) bool {
+	var user, assistant bool
+	for _, msg := range messages {
+		user = user || msg.Role == domain.MessageRoleUser
+		assistant = assistant || msg.Role == domain.MessageRoleAssistant
+	}
+	return user && assistant
+}
+
+// jsonlContent returns the content of a message: its text, or the text and
+// its attachments as content parts of the dialect. Attachments that are not
+// images or text, or whose content is not available, are left out.
+func jsonlContent(msg domain.Message, dialect string, stripAttachments bool) interface{} {
+	if stripAttachments || len(msg.Attachments) == 0 {
+		return msg.Content
+	}
+
+	var parts []map[string]interface{}
+	if msg.Content != "" {
+		parts = append(parts, map[string]interface{}{"type": "text", "text": msg.Content})
+	}
+	for _, att := range msg.Attachments {
+		switch {
+		case att.Type == domain.AttachmentTypeImage:
+			if part := imagePart(att, dialect); part != nil {
+				parts = append(parts, part)
+			}
+		case att.Type == domain.AttachmentTypeText || strings.HasPrefix(att.MimeType, "text/"):
+			if len(att.Content) > 0 {
+				parts = append(parts, map[string]interface{}{"type": "text", "text": string(att.Content)})
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return msg.Content
+	}
+	return parts
+}
+
+// imagePart returns an image content part, embedding the image as base64 or
+// linking its URL, or nil if the image has neither
+func imagePart(att domain.Attachment, dialect string) map[string]interface{} {
+	mimeType := att.MimeType
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+	data := base64.StdEncoding.EncodeToString(att.Content)
+
+	if dialect == JSONLDialectAnthropic {
+		switch {
+		case len(att.Content) > 0:
+			return map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "base64", "media_type": mimeType, "data": data}}
+		case att.URL != "":
+			return map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "url", "url": att.URL}}
+		}
+		return nil
+	}
+
+	switch {
+	case len(att.Content) > 0:
+		return map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "data:" + mimeType + ";base64," + data}}
+	case att.URL != "":
+		return map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": att.URL}}
+	}
+	return nil
+}
+
+// csvHeader names the columns of a CSV export
+var csvHeader = []string{"session_id", "timestamp", "role", "content", "tokens", "model"}
+
+// ExportCSV writes the messages of a session as CSV rows for spreadsheets
+// and BI tools, one row per message. Tokens are the completion tokens
+// recorded for a reply and empty when none were recorded; the model is the
+// one recorded for the message, or else the session's.
+func ExportCSV(session *domain.Session, w io.Writer, opts ExportOptions) error {
+	writer := csv.NewWriter(w)
+	if !opts.OmitHeader {
+		if err := writer.Write(csvHeader); err != nil {
+			return fmt.Errorf("failed to write CSV header: %w", err)
+		}
+	}
+
+	if session.Conversation != nil {
+		for _, msg := range session.Conversation.Messages {
+			tokens, model := "", session.Conversation.Model
+			if msg.Usage != nil {
+				if msg.Usage.CompletionTokens > 0 {
+					tokens = strconv.Itoa(msg.Usage.CompletionTokens)
+				}
+				if msg.Usage.Model != "" {
+					model = msg.Usage.Model
+				}
+			}
+			row := []string{session.ID, msg.Timestamp.Format(time.RFC3339), string(msg.Role), msg.Content, tokens, model}
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("failed to write message %s as CSV: %w", msg.ID, err)
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to export session %s as CSV: %w", session.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// ABOUTME: Tests for the export formats shared by every storage backend
+// ABOUTME: Ensures JSONL fine-tuning exports follow the OpenAI and Anthropic message formats
+
+package storage
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExportSession() *domain.Session {
+	session := domain.NewSession("session-1")
+	session.Conversation.SetSystemPrompt("Be brief.")
+	question := domain.NewMessage("msg-1", domain.MessageRoleUser, "What is in this picture?")
+	question.AddAttachment(domain.Attachment{ID: "att-1", Type: domain.AttachmentTypeImage, MimeType: "image/jpeg", Content: []byte("jpeg")})
+	session.Conversation.AddMessage(*question)
+	session.Conversation.AddMessage(*domain.NewMessage("msg-2", domain.MessageRoleAssistant, "A cat."))
+	session.Conversation.AddMessage(*domain.NewMessage("msg-3", domain.MessageRoleTool, "lookup result"))
+	session.Conversation.AddMessage(*domain.NewMessage("msg-4", domain.MessageRoleUser, "Thanks"))
+	session.Conversation.AddMessage(*domain.NewMessage("msg-5", domain.MessageRoleAssistant, "You're welcome."))
+	session.Conversation.AddMessage(*domain.NewMessage("msg-6", domain.MessageRoleUser, "Unanswered"))
+	return session
+}
+
+// exportLines exports a session as JSONL and decodes each line
+func exportLines(t *testing.T, session *domain.Session, opts ExportOptions) []map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, ExportJSONL(session, &buf, opts))
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded))
+		lines = append(lines, decoded)
+	}
+	return lines
+}
+
+// roles returns the role of each message of a line
+func roles(line map[string]interface{}) []string {
+	var result []string
+	for _, m := range line["messages"].([]interface{}) {
+		result = append(result, m.(map[string]interface{})["role"].(string))
+	}
+	return result
+}
+
+func TestExportJSONL(t *testing.T) {
+	t.Run("whole conversation in OpenAI format", func(t *testing.T) {
+		lines := exportLines(t, newExportSession(), ExportOptions{})
+		require.Len(t, lines, 1)
+		assert.Equal(t, []string{"system", "user", "assistant", "user", "assistant", "user"}, roles(lines[0]))
+
+		messages := lines[0]["messages"].([]interface{})
+		assert.Equal(t, "Be brief.", messages[0].(map[string]interface{})["content"])
+		parts := messages[1].(map[string]interface{})["content"].([]interface{})
+		require.Len(t, parts, 2)
+		assert.Equal(t, map[string]interface{}{"type": "text", "text": "What is in this picture?"}, parts[0])
+		assert.Equal(t, "data:image/jpeg;base64,anBlZw==", parts[1].(map[string]interface{})["image_url"].(map[string]interface{})["url"])
+	})
+
+	t.Run("per exchange in Anthropic format", func(t *testing.T) {
+		lines := exportLines(t, newExportSession(), ExportOptions{PerExchange: true, Dialect: JSONLDialectAnthropic})
+		require.Len(t, lines, 2, "the unanswered message is left out")
+		for _, line := range lines {
+			assert.Equal(t, "Be brief.", line["system"])
+			assert.Equal(t, []string{"user", "assistant"}, roles(line))
+		}
+
+		parts := lines[0]["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+		source := parts[1].(map[string]interface{})["source"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"type": "base64", "media_type": "image/jpeg", "data": "anBlZw=="}, source)
+	})
+
+	t.Run("role filter and stripped attachments", func(t *testing.T) {
+		lines := exportLines(t, newExportSession(), ExportOptions{
+			PerExchange:      true,
+			Roles:            []domain.MessageRole{domain.MessageRoleUser, domain.MessageRoleAssistant, domain.MessageRoleTool},
+			StripAttachments: true,
+		})
+		require.Len(t, lines, 2)
+		assert.Equal(t, []string{"user", "assistant", "tool"}, roles(lines[0]))
+		assert.Equal(t, "What is in this picture?", lines[0]["messages"].([]interface{})[0].(map[string]interface{})["content"])
+	})
+
+	t.Run("no exchanges", func(t *testing.T) {
+		session := domain.NewSession("empty")
+		session.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "hello?"))
+		assert.Empty(t, exportLines(t, session, ExportOptions{}))
+	})
+
+	t.Run("unknown dialect", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Error(t, ExportJSONL(newExportSession(), &buf, ExportOptions{Dialect: "llama"}))
+	})
+}
+
+func TestParseExportRoles(t *testing.T) {
+	roles, err := ParseExportRoles([]string{"User", " assistant"})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.MessageRole{domain.MessageRoleUser, domain.MessageRoleAssistant}, roles)
+
+	_, err = ParseExportRoles([]string{"narrator"})
+	assert.Error(t, err)
+}
+
+func TestExportCSV(t *testing.T) {
+	session := domain.NewSession("session-1")
+	session.Conversation.Model = "gpt-4o"
+	session.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "Say \"hi\",\nplease"))
+	reply := domain.NewMessage("msg-2", domain.MessageRoleAssistant, "hi")
+	reply.Usage = &domain.Usage{PromptTokens: 12, CompletionTokens: 3, Model: "gpt-4o-mini"}
+	session.Conversation.AddMessage(*reply)
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportCSV(session, &buf, ExportOptions{}))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"session_id", "timestamp", "role", "content", "tokens", "model"}, rows[0])
+	assert.Equal(t, []string{"session-1", "user", "Say \"hi\",\nplease", "", "gpt-4o"}, append(rows[1][:1], rows[1][2:]...))
+	assert.Equal(t, []string{"assistant", "hi", "3", "gpt-4o-mini"}, rows[2][2:])
+
+	buf.Reset()
+	require.NoError(t, ExportCSV(session, &buf, ExportOptions{OmitHeader: true}))
+	rows, err = csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+}
+
+func newTranscriptSession() *domain.Session {
+	session := domain.NewSession("session-1")
+	session.Name = "Notes"
+	session.Tags = []string{"work", "q3 plan"}
+	session.Conversation.Model = "gpt-4o"
+	session.Conversation.SetSystemPrompt("Be brief.")
+	session.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "Outline:\n* first\n* second"))
+	reply := domain.NewMessage("msg-2", domain.MessageRoleAssistant, "Done.")
+	reply.Citations = []domain.Citation{*domain.NewURLCitation("https://example.com", "Example", 0)}
+	reply.AddAttachment(domain.Attachment{ID: "att-1", Type: domain.AttachmentTypeFile, Name: "plan.pdf", MimeType: "application/pdf"})
+	session.Conversation.AddMessage(*reply)
+	return session
+}
+
+func TestExportOrg(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, ExportOrg(newTranscriptSession(), &buf))
+	output := buf.String()
+
+	assert.True(t, strings.HasPrefix(output, "#+TITLE: Notes\n"))
+	assert.Contains(t, output, "#+FILETAGS: :work:q3_plan:\n")
+	assert.Contains(t, output, "* System Prompt\nBe brief.\n")
+	assert.Contains(t, output, ":SESSION_ID: session-1\n")
+	assert.Contains(t, output, ":MODEL:    gpt-4o\n")
+	assert.Contains(t, output, "** User\n:PROPERTIES:\n:TIMESTAMP: [")
+	assert.Contains(t, output, "** Assistant\n")
+	assert.Contains(t, output, "- plan.pdf (application/pdf)\n")
+	assert.Contains(t, output, "- [[https://example.com][Example]]\n")
+
+	// List items in the message stay out of the heading structure
+	assert.Contains(t, output, "Outline:\n * first\n * second\n")
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "*") {
+			assert.Regexp(t, `^\*{1,2} (System Prompt|Conversation|User|Assistant)$`, line)
+		}
+	}
+}
+
+func TestExportText(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, ExportText(newTranscriptSession(), &buf))
+	output := buf.String()
+
+	assert.True(t, strings.HasPrefix(output, "Session: Notes\nID: session-1\n"))
+	assert.Contains(t, output, "Tags: work, q3 plan\n")
+	assert.Contains(t, output, "System prompt:\nBe brief.\n")
+	assert.Regexp(t, `\[\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\] User:\nOutline:\n\* first\n\* second\n`, output)
+	assert.Contains(t, output, "  Attachment: plan.pdf (application/pdf)\n")
+	assert.Contains(t, output, "  Source: Example (https://example.com)\n")
+	assert.NotContains(t, output, "#")
+}
@@ -32,7 +32,8 @@ func (f *Factory) RegisterBackend(backendType BackendType, factory func(config C
 	f.backends[backendType] = factory
 }
 
-// CreateBackend creates a storage backend of the specified type
+// CreateBackend creates a storage backend of the specified type. When the
+// config names a replica, the backend mirrors its writes to it.
 func (f *Factory) CreateBackend(backendType BackendType, config Config) (Backend, error) {
 	f.mu.RLock()
 	factory, exists := f.backends[backendType]
@@ -42,7 +43,21 @@ func (f *Factory) CreateBackend(backendType BackendType, config Config) (Backend
 		return nil, fmt.Errorf("unknown storage backend type: %s", backendType)
 	}
 
-	return factory(config)
+	backend, err := factory(config)
+	if err != nil {
+		return nil, err
+	}
+	replicaType, replicaConfig, ok := config.Replica()
+	if !ok {
+		return backend, nil
+	}
+
+	replica, err := f.CreateBackend(replicaType, replicaConfig)
+	if err != nil {
+		backend.Close()
+		return nil, fmt.Errorf("failed to create %s replica: %w", replicaType, err)
+	}
+	return NewReplicatingBackend(backend, replica), nil
 }
 
 // IsBackendAvailable checks if a storage backend type is registered
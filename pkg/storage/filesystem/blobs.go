@@ -0,0 +1,178 @@
+// ABOUTME: Content-addressed attachment storage for the filesystem backend
+// ABOUTME: Keeps attachment bytes in blob files keyed by hash with reference counts
+
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// blobsDir is the directory under the base directory holding attachment content
+const blobsDir = "blobs"
+
+// refsFile holds the number of sessions referencing each blob
+const refsFile = "refs.json"
+
+// blobPath returns the file holding the content with the given hash
+func (b *Backend) blobPath(hash string) (string, error) {
+	if !domain.IsContentHash(hash) {
+		return "", fmt.Errorf("invalid content hash: %q", hash)
+	}
+	_, digest, _ := strings.Cut(hash, ":")
+	return filepath.Join(b.baseDir, blobsDir, digest), nil
+}
+
+// loadBlob implements domain.ContentLoader for attachments of loaded sessions
+func (b *Backend) loadBlob(hash string) ([]byte, error) {
+	path, err := b.blobPath(hash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment content: %w", err)
+	}
+	return data, nil
+}
+
+// writeBlobs writes content that is not stored yet. Content is keyed by its
+// hash, so an existing blob never needs rewriting.
+func (b *Backend) writeBlobs(blobs map[string][]byte) error {
+	if len(blobs) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(b.baseDir, blobsDir), 0755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	for hash, content := range blobs {
+		path, err := b.blobPath(hash)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("failed to write attachment content: %w", err)
+		}
+		logging.LogDebug("Stored attachment content", "hash", hash, "size", len(content))
+	}
+	return nil
+}
+
+// storedHashes returns the attachment hashes of the session as last saved
+func (b *Backend) storedHashes(id string) map[string]bool {
+	data, err := os.ReadFile(filepath.Join(b.baseDir, id+".json"))
+	if err != nil {
+		return map[string]bool{}
+	}
+	var session domain.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return map[string]bool{}
+	}
+	return storage.AttachmentHashes(&session)
+}
+
+// updateRefs adds a reference to each hash in added and drops one from each
+// hash in removed. Blobs left without references are deleted. The caller
+// must hold the storage lock (b.lock), since other processes update the
+// counts too.
+func (b *Backend) updateRefs(added, removed map[string]bool) error {
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	refs, err := b.readRefs()
+	if err != nil {
+		return err
+	}
+
+	for hash := range added {
+		refs[hash]++
+	}
+	for hash := range removed {
+		refs[hash]--
+		if refs[hash] > 0 {
+			continue
+		}
+		delete(refs, hash)
+		path, err := b.blobPath(hash)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logging.LogWarn("Failed to remove unreferenced attachment content", "hash", hash, "error", err)
+			continue
+		}
+		logging.LogDebug("Removed unreferenced attachment content", "hash", hash)
+	}
+
+	return b.writeRefs(refs)
+}
+
+// readRefs returns the stored reference count of each blob
+func (b *Backend) readRefs() (map[string]int, error) {
+	refs := make(map[string]int)
+	data, err := os.ReadFile(filepath.Join(b.baseDir, blobsDir, refsFile))
+	if os.IsNotExist(err) {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment references: %w", err)
+	}
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, fmt.Errorf("failed to parse attachment references: %w", err)
+	}
+	return refs, nil
+}
+
+// writeRefs stores the reference counts. They are written beside the refs
+// file and renamed over it, so a crash never leaves them truncated.
+func (b *Backend) writeRefs(refs map[string]int) error {
+	data, err := json.MarshalIndent(refs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal attachment references: %w", err)
+	}
+	dir := filepath.Join(b.baseDir, blobsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".refs-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write attachment references: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write attachment references: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write attachment references: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write attachment references: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, refsFile)); err != nil {
+		return fmt.Errorf("failed to write attachment references: %w", err)
+	}
+	return nil
+}
+
+// diffHashes returns the hashes in a that are not in b
+func diffHashes(a, b map[string]bool) map[string]bool {
+	diff := make(map[string]bool)
+	for hash := range a {
+		if !b[hash] {
+			diff[hash] = true
+		}
+	}
+	return diff
+}
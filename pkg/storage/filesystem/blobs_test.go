@@ -0,0 +1,175 @@
+// ABOUTME: Tests for content-addressed attachment storage in the filesystem backend
+// ABOUTME: Ensures attachment content is deduplicated, lazily loaded, and removed when unreferenced
+
+package filesystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createAttachmentSession(id string, content []byte) *domain.Session {
+	session := createTestSession(id, "Attachments", "")
+	msg := domain.NewMessage("msg-"+id, domain.MessageRoleUser, "see attached")
+	msg.AddAttachment(domain.Attachment{ID: "att-1", Type: domain.AttachmentTypeText, Name: "notes.txt", Content: content})
+	session.Conversation.AddMessage(*msg)
+	return session
+}
+
+func blobFiles(t *testing.T, backend *Backend) []string {
+	entries, err := os.ReadDir(filepath.Join(backend.baseDir, blobsDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != refsFile {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func TestBackend_AttachmentContentStoredByHash(t *testing.T) {
+	backend := setupTestBackend(t)
+	content := []byte(strings.Repeat("large attachment ", 1000))
+
+	session := createAttachmentSession("blob-1", content)
+	require.NoError(t, backend.Create(session))
+
+	// The in-memory session keeps its content
+	assert.Equal(t, content, session.Conversation.Messages[0].Attachments[0].Content)
+
+	// The session file holds only the hash
+	data, err := os.ReadFile(filepath.Join(backend.baseDir, "blob-1.json"))
+	require.NoError(t, err)
+	assert.Less(t, len(data), len(content))
+	assert.Contains(t, string(data), domain.ContentHash(content))
+	assert.Len(t, blobFiles(t, backend), 1)
+
+	// Content is loaded when first needed
+	loaded, err := backend.Get("blob-1")
+	require.NoError(t, err)
+	att := &loaded.Conversation.Messages[0].Attachments[0]
+	assert.Empty(t, att.Content)
+	assert.Equal(t, int64(len(content)), att.Size)
+	got, err := att.LoadContent()
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// Saving again without loading the content keeps it
+	loaded, err = backend.Get("blob-1")
+	require.NoError(t, err)
+	loaded.Name = "Renamed"
+	require.NoError(t, backend.Update(loaded))
+	assert.Len(t, blobFiles(t, backend), 1)
+	reloaded, err := backend.Get("blob-1")
+	require.NoError(t, err)
+	got, err = reloaded.Conversation.Messages[0].Attachments[0].LoadContent()
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+}
+
+func TestBackend_AttachmentContentSharedAcrossBranches(t *testing.T) {
+	backend := setupTestBackend(t)
+	content := []byte("shared attachment content")
+
+	parent := createAttachmentSession("parent", content)
+	require.NoError(t, backend.Create(parent))
+	branch, err := parent.ForkAt(1)
+	require.NoError(t, err)
+	require.NoError(t, backend.Create(branch))
+	require.NoError(t, backend.Update(parent))
+
+	assert.Len(t, blobFiles(t, backend), 1)
+
+	// The content stays until the last session referencing it is deleted
+	require.NoError(t, backend.Delete("parent"))
+	assert.Len(t, blobFiles(t, backend), 1)
+	loaded, err := backend.Get(branch.ID)
+	require.NoError(t, err)
+	got, err := loaded.Conversation.Messages[0].Attachments[0].LoadContent()
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	require.NoError(t, backend.Delete(branch.ID))
+	assert.Empty(t, blobFiles(t, backend))
+}
+
+func TestBackend_AttachmentContentReleasedWhenRemoved(t *testing.T) {
+	backend := setupTestBackend(t)
+
+	session := createAttachmentSession("blob-2", []byte("first version"))
+	require.NoError(t, backend.Create(session))
+	session.Conversation.Messages[0].Attachments[0].Content = []byte("second version")
+	require.NoError(t, backend.Update(session))
+
+	files := blobFiles(t, backend)
+	require.Len(t, files, 1)
+	assert.Equal(t, "sha256:"+files[0], domain.ContentHash([]byte("second version")))
+}
+
+func TestBackend_ExportSessionIncludesAttachmentContent(t *testing.T) {
+	backend := setupTestBackend(t)
+	content := []byte("exported attachment")
+	require.NoError(t, backend.Create(createAttachmentSession("blob-3", content)))
+
+	var buf bytes.Buffer
+	require.NoError(t, backend.ExportSession("blob-3", domain.ExportFormatJSON, &buf))
+
+	var exported domain.Session
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &exported))
+	assert.Equal(t, content, exported.Conversation.Messages[0].Attachments[0].Content)
+}
+
+func TestBackend_ReferenceCountsSharedAcrossBackends(t *testing.T) {
+	// Two backends on one directory stand in for two processes, such as a
+	// REPL autosaving while a CLI command runs
+	first := setupTestBackend(t)
+	second := &Backend{baseDir: first.baseDir}
+	content := []byte("shared attachment")
+
+	const perBackend = 10
+	var wg sync.WaitGroup
+	for i, backend := range []*Backend{first, second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perBackend; j++ {
+				assert.NoError(t, backend.Create(createAttachmentSession(fmt.Sprintf("shared-%d-%d", i, j), content)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	refs, err := first.readRefs()
+	require.NoError(t, err)
+	assert.Equal(t, 2*perBackend, refs[domain.ContentHash(content)], "no reference count is lost")
+
+	// Releasing every session but one keeps the content
+	for i, backend := range []*Backend{first, second} {
+		for j := 0; j < perBackend; j++ {
+			if i == 1 && j == perBackend-1 {
+				continue
+			}
+			require.NoError(t, backend.Delete(fmt.Sprintf("shared-%d-%d", i, j)))
+		}
+	}
+	assert.Len(t, blobFiles(t, first), 1)
+	loaded, err := second.Get(fmt.Sprintf("shared-1-%d", perBackend-1))
+	require.NoError(t, err)
+	got, err := loaded.Conversation.Messages[0].Attachments[0].LoadContent()
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+}
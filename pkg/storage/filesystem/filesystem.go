@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lexlapax/magellai/internal/logging"
@@ -24,6 +25,10 @@ func init() {
 // Backend implements the storage.Backend interface using filesystem storage
 type Backend struct {
 	baseDir string
+
+	// mu serializes session writes with updates to attachment references
+	// within this process; lock adds an OS lock for other processes
+	mu sync.Mutex
 }
 
 // Ensure Backend implements storage.Backend
@@ -38,6 +43,14 @@ func New(config storage.Config) (storage.Backend, error) {
 
 	logging.LogDebug("Creating filesystem backend", "baseDir", baseDir)
 
+	// Read-only storage must already exist; nothing is created for it
+	if config.ReadOnly() {
+		if info, err := os.Stat(baseDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("storage directory %s does not exist: %w", baseDir, storage.ErrReadOnly)
+		}
+		return &Backend{baseDir: baseDir}, nil
+	}
+
 	// Ensure directory exists
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		logging.LogError(err, "Failed to create storage directory", "baseDir", baseDir)
@@ -85,18 +98,37 @@ func (b *Backend) saveSession(session *domain.Session) error {
 	filename := fmt.Sprintf("%s.json", session.ID)
 	filepath := filepath.Join(b.baseDir, filename)
 
-	// Use domain type directly - no conversion needed
-	data, err := json.MarshalIndent(session, "", "  ")
+	// Attachment content is stored once per hash, apart from the session file
+	stripped, blobs := storage.SplitAttachments(session)
+	data, err := json.MarshalIndent(stripped, "", "  ")
 	if err != nil {
 		logging.LogError(err, "Failed to marshal session", "id", session.ID)
 		return fmt.Errorf("failed to marshal session: %w", err)
 	}
 
+	unlock, err := b.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := b.writeBlobs(blobs); err != nil {
+		logging.LogError(err, "Failed to write attachment content", "id", session.ID)
+		return err
+	}
+	oldHashes := b.storedHashes(session.ID)
+	newHashes := storage.AttachmentHashes(stripped)
+
 	if err := os.WriteFile(filepath, data, 0644); err != nil {
 		logging.LogError(err, "Failed to write session file", "path", filepath)
 		return fmt.Errorf("failed to write session file: %w", err)
 	}
 
+	if err := b.updateRefs(diffHashes(newHashes, oldHashes), diffHashes(oldHashes, newHashes)); err != nil {
+		logging.LogError(err, "Failed to update attachment references", "id", session.ID)
+		return err
+	}
+
 	logging.LogInfo("Session saved successfully", "id", session.ID, "duration", time.Since(start))
 	return nil
 }
@@ -124,6 +156,7 @@ func (b *Backend) Get(id string) (*domain.Session, error) {
 		logging.LogError(err, "Failed to unmarshal session", "id", id)
 		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
 	}
+	storage.SetContentLoaders(&session, b.loadBlob)
 
 	logging.LogInfo("Session loaded successfully", "id", id, "duration", time.Since(start))
 	return &session, nil
@@ -174,6 +207,13 @@ func (b *Backend) Delete(id string) error {
 	filename := fmt.Sprintf("%s.json", id)
 	filepath := filepath.Join(b.baseDir, filename)
 
+	unlock, err := b.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	hashes := b.storedHashes(id)
 	if err := os.Remove(filepath); err != nil {
 		if os.IsNotExist(err) {
 			logging.LogWarn("Session not found for deletion", "id", id)
@@ -182,16 +222,26 @@ func (b *Backend) Delete(id string) error {
 		logging.LogError(err, "Failed to delete session", "id", id)
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
+	if err := b.updateRefs(nil, hashes); err != nil {
+		logging.LogWarn("Failed to release attachment content", "id", id, "error", err)
+	}
+	if err := b.deleteSnapshots(id); err != nil {
+		logging.LogWarn("Failed to delete session snapshots", "id", id, "error", err)
+	}
 
 	logging.LogInfo("Session deleted", "id", id)
 	return nil
 }
 
-// SearchSessions searches for sessions containing the given query
+// SearchSessions searches for sessions matching the given query
 // Search implements storage.Backend.Search
 func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 	logging.LogInfo("Searching sessions", "query", query)
-	lowerQuery := strings.ToLower(query)
+	q, err := domain.ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	highlight := q.Highlight()
 
 	entries, err := os.ReadDir(b.baseDir)
 	if err != nil {
@@ -216,12 +266,15 @@ func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 			continue
 		}
 
-		// Create session info for searching
+		// Apply the session filters before looking at any text
 		sessionInfo := session.ToSessionInfo()
+		if !q.MatchesSession(sessionInfo) {
+			continue
+		}
 		result := domain.NewSearchResult(sessionInfo)
 
 		// Search in session name
-		if strings.Contains(strings.ToLower(session.Name), lowerQuery) {
+		if q.MatchesField(session.Name) {
 			result.AddMatch(domain.NewSearchMatch(
 				domain.SearchMatchTypeName,
 				"",
@@ -234,8 +287,8 @@ func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 		// Search in messages
 		if session.Conversation != nil {
 			for i, msg := range session.Conversation.Messages {
-				if strings.Contains(strings.ToLower(msg.Content), lowerQuery) {
-					snippet := extractSnippet(msg.Content, lowerQuery, 50)
+				if q.MatchesMessage(msg) {
+					snippet := extractSnippet(msg.Content, highlight, 50)
 					result.AddMatch(domain.NewSearchMatch(
 						domain.SearchMatchTypeMessage,
 						string(msg.Role),
@@ -247,8 +300,8 @@ func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 			}
 
 			// Search in system prompt
-			if strings.Contains(strings.ToLower(session.Conversation.SystemPrompt), lowerQuery) {
-				snippet := extractSnippet(session.Conversation.SystemPrompt, lowerQuery, 50)
+			if session.Conversation.SystemPrompt != "" && q.MatchesField(session.Conversation.SystemPrompt) {
+				snippet := extractSnippet(session.Conversation.SystemPrompt, highlight, 50)
 				result.AddMatch(domain.NewSearchMatch(
 					domain.SearchMatchTypeSystemPrompt,
 					"",
@@ -261,7 +314,7 @@ func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 
 		// Search in tags
 		for _, tag := range session.Tags {
-			if strings.Contains(strings.ToLower(tag), lowerQuery) {
+			if q.MatchesField(tag) {
 				result.AddMatch(domain.NewSearchMatch(
 					domain.SearchMatchTypeTag,
 					"",
@@ -272,6 +325,11 @@ func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 			}
 		}
 
+		// A query made only of filters matches every session that passes them
+		for _, match := range q.FilterMatches(sessionInfo) {
+			result.AddMatch(match)
+		}
+
 		if result.HasMatches() {
 			results = append(results, result)
 		}
@@ -292,6 +350,10 @@ func (b *Backend) ExportSession(id string, format domain.ExportFormat, w io.Writ
 
 	switch format {
 	case domain.ExportFormatJSON:
+		// Exports are self-contained, so include the attachment content
+		if err := storage.LoadAttachmentContent(session); err != nil {
+			return fmt.Errorf("failed to load attachment content: %w", err)
+		}
 		encoder := json.NewEncoder(w)
 		encoder.SetIndent("", "  ")
 		if err := encoder.Encode(session); err != nil {
@@ -303,6 +365,29 @@ func (b *Backend) ExportSession(id string, format domain.ExportFormat, w io.Writ
 			return fmt.Errorf("failed to export session as Markdown: %w", err)
 		}
 
+	case domain.ExportFormatOrg:
+		if err := storage.ExportOrg(session, w); err != nil {
+			return err
+		}
+
+	case domain.ExportFormatText:
+		if err := storage.ExportText(session, w); err != nil {
+			return err
+		}
+
+	case domain.ExportFormatJSONL:
+		if err := storage.LoadAttachmentContent(session); err != nil {
+			return fmt.Errorf("failed to load attachment content: %w", err)
+		}
+		if err := storage.ExportJSONL(session, w, storage.ExportOptions{}); err != nil {
+			return err
+		}
+
+	case domain.ExportFormatCSV:
+		if err := storage.ExportCSV(session, w, storage.ExportOptions{}); err != nil {
+			return err
+		}
+
 	default:
 		return fmt.Errorf("unsupported export format: %s", format)
 	}
@@ -421,6 +506,30 @@ func exportMarkdown(session *domain.Session, w io.Writer) error {
 				}
 				fmt.Fprintf(w, "\n")
 			}
+
+			if len(msg.ToolCalls) > 0 {
+				fmt.Fprintf(w, "**Tool calls:**\n")
+				for _, call := range msg.ToolCalls {
+					fmt.Fprintf(w, "- `%s` %s\n", call.ID, call.String())
+				}
+				fmt.Fprintf(w, "\n")
+			}
+
+			if len(msg.ToolResults) > 0 {
+				fmt.Fprintf(w, "**Tool results:**\n")
+				for _, result := range msg.ToolResults {
+					fmt.Fprintf(w, "- `%s` (%s): %s\n", result.ToolCallID, result.Status, result.Text())
+				}
+				fmt.Fprintf(w, "\n")
+			}
+
+			if len(msg.Citations) > 0 {
+				fmt.Fprintf(w, "**Sources:**\n")
+				for _, citation := range msg.Citations {
+					fmt.Fprintf(w, "- %s\n", storage.MarkdownCitation(citation))
+				}
+				fmt.Fprintf(w, "\n")
+			}
 		}
 	}
 
@@ -524,3 +633,177 @@ func (b *Backend) MergeSessions(targetID, sourceID string, options domain.MergeO
 	logging.LogInfo("Sessions merged successfully", "target", targetID, "source", sourceID, "mergedCount", result.MergedCount)
 	return result, nil
 }
+
+// Ensure Backend implements storage.IndexStore
+var _ storage.IndexStore = (*Backend)(nil)
+
+// indexPath returns the file holding the named index
+func (b *Backend) indexPath(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid index name: %q", name)
+	}
+	return filepath.Join(b.baseDir, "indexes", name+".json"), nil
+}
+
+// SaveIndex implements storage.IndexStore.SaveIndex
+func (b *Backend) SaveIndex(name string, data []byte) error {
+	path, err := b.indexPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	logging.LogDebug("Saved index", "name", name, "size", len(data))
+	return nil
+}
+
+// LoadIndex implements storage.IndexStore.LoadIndex
+func (b *Backend) LoadIndex(name string) ([]byte, error) {
+	path, err := b.indexPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", storage.ErrIndexNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	return data, nil
+}
+
+// ListIndexes implements storage.IndexStore.ListIndexes
+func (b *Backend) ListIndexes() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(b.baseDir, "indexes"))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index directory: %w", err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+		}
+	}
+	return names, nil
+}
+
+// DeleteIndex implements storage.IndexStore.DeleteIndex
+func (b *Backend) DeleteIndex(name string) error {
+	path, err := b.indexPath(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", storage.ErrIndexNotFound, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete index: %w", err)
+	}
+	return nil
+}
+
+// Ensure Backend implements storage.TemplateStore
+var _ storage.TemplateStore = (*Backend)(nil)
+
+// templatePath returns the file holding the named template
+func (b *Backend) templatePath(name string) (string, error) {
+	if !(&domain.SessionTemplate{Name: name}).IsValid() {
+		return "", fmt.Errorf("invalid template name: %q", name)
+	}
+	return filepath.Join(b.baseDir, "templates", name+".json"), nil
+}
+
+// SaveTemplate implements storage.TemplateStore.SaveTemplate
+func (b *Backend) SaveTemplate(template *domain.SessionTemplate) error {
+	if !template.IsValid() {
+		return fmt.Errorf("invalid template: %q", template.Name)
+	}
+	path, err := b.templatePath(template.Name)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create template directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write template: %w", err)
+	}
+	logging.LogDebug("Saved template", "name", template.Name)
+	return nil
+}
+
+// GetTemplate implements storage.TemplateStore.GetTemplate
+func (b *Backend) GetTemplate(name string) (*domain.SessionTemplate, error) {
+	path, err := b.templatePath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", storage.ErrTemplateNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	var template domain.SessionTemplate
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template: %w", err)
+	}
+	return &template, nil
+}
+
+// ListTemplates implements storage.TemplateStore.ListTemplates
+func (b *Backend) ListTemplates() ([]*domain.SessionTemplate, error) {
+	entries, err := os.ReadDir(filepath.Join(b.baseDir, "templates"))
+	if os.IsNotExist(err) {
+		return []*domain.SessionTemplate{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template directory: %w", err)
+	}
+
+	templates := []*domain.SessionTemplate{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		template, err := b.GetTemplate(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			logging.LogWarn("Failed to load template", "file", entry.Name(), "error", err)
+			continue
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+// DeleteTemplate implements storage.TemplateStore.DeleteTemplate
+func (b *Backend) DeleteTemplate(name string) error {
+	path, err := b.templatePath(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", storage.ErrTemplateNotFound, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
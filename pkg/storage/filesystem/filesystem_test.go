@@ -6,8 +6,10 @@ package filesystem
 import (
 	"bytes"
 	"encoding/json"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/lexlapax/magellai/pkg/domain"
 	"github.com/lexlapax/magellai/pkg/storage"
@@ -38,6 +40,21 @@ func TestNew(t *testing.T) {
 	assert.Nil(t, backend)
 }
 
+func TestNew_ReadOnly(t *testing.T) {
+	// Read-only storage is not created
+	missing := filepath.Join(t.TempDir(), "missing")
+	_, err := New(storage.Config{"base_dir": missing, "read_only": true})
+	assert.ErrorIs(t, err, storage.ErrReadOnly)
+	_, statErr := os.Stat(missing)
+	assert.True(t, os.IsNotExist(statErr))
+
+	backend, err := New(storage.Config{"base_dir": t.TempDir(), "read_only": true})
+	require.NoError(t, err)
+	sessions, err := backend.List()
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
 func TestBackend_NewSession(t *testing.T) {
 	backend := setupTestBackend(t)
 
@@ -226,6 +243,31 @@ func TestBackend_SearchSessions(t *testing.T) {
 			query:    "expert",
 			expected: []string{"search-1", "search-2", "search-3"},
 		},
+		{
+			name:     "Filter by tag",
+			query:    "tag:golang",
+			expected: []string{"search-2"},
+		},
+		{
+			name:     "Filter by tag and role",
+			query:    "tag:tutorial role:assistant closure",
+			expected: []string{"search-3"},
+		},
+		{
+			name:     "Exact phrase",
+			query:    `"object blueprints"`,
+			expected: []string{"search-1"},
+		},
+		{
+			name:     "Role excludes other messages",
+			query:    `role:assistant "tell me"`,
+			expected: []string{},
+		},
+		{
+			name:     "Text outside the filtered sessions",
+			query:    "python tag:golang",
+			expected: []string{},
+		},
 	}
 
 	for _, tt := range tests {
@@ -245,6 +287,9 @@ func TestBackend_SearchSessions(t *testing.T) {
 			}
 		})
 	}
+
+	_, err := backend.Search("role:robot")
+	assert.ErrorIs(t, err, domain.ErrInvalidSearchQuery)
 }
 
 func TestBackend_ExportSession(t *testing.T) {
@@ -283,12 +328,60 @@ func TestBackend_ExportSession(t *testing.T) {
 	assert.Contains(t, markdown, "User")
 	assert.Contains(t, markdown, "Assistant")
 
+	// Org-mode and plain-text exports
+	buf.Reset()
+	require.NoError(t, backend.ExportSession(session.ID, domain.ExportFormatOrg, &buf))
+	assert.Contains(t, buf.String(), "#+TITLE: Export Test")
+	assert.Contains(t, buf.String(), "** Assistant\n")
+
+	buf.Reset()
+	require.NoError(t, backend.ExportSession(session.ID, domain.ExportFormatText, &buf))
+	assert.Contains(t, buf.String(), "Session: Export Test")
+	assert.Contains(t, buf.String(), "Hi there!")
+
 	// Test unsupported format
 	err = backend.ExportSession(session.ID, domain.ExportFormat("invalid"), &buf)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported export format")
 }
 
+func TestBackend_MessageFieldsRoundTrip(t *testing.T) {
+	backend := setupTestBackend(t)
+
+	session := createTestSession("tool-test", "Tool Test", "")
+	call := domain.NewToolCall("call_1", "get_weather", map[string]interface{}{"city": "Paris"})
+	assistant := domain.NewMessage("msg-1", domain.MessageRoleAssistant, "")
+	assistant.AddToolCall(*call)
+	tool := domain.NewMessage("msg-2", domain.MessageRoleTool, "")
+	tool.AddToolResult(*domain.NewToolResult(*call, "18C and sunny"))
+	answer := domain.NewMessage("msg-3", domain.MessageRoleAssistant, "It is sunny.")
+	answer.AddCitation(*domain.NewURLCitation("https://weather.example/paris", "Paris weather", 0.9))
+	answer.AddCitation(*domain.NewFileCitation("notes/paris.md", 3, 7, 0))
+	answer.Usage = &domain.Usage{PromptTokens: 120, CompletionTokens: 8, Latency: 900 * time.Millisecond, Provider: "openai", Model: "gpt-4o"}
+	session.Conversation.AddMessage(*assistant)
+	session.Conversation.AddMessage(*tool)
+	session.Conversation.AddMessage(*answer)
+	require.NoError(t, backend.Create(session))
+
+	loaded, err := backend.Get(session.ID)
+	require.NoError(t, err)
+	require.Len(t, loaded.Conversation.Messages, 3)
+	assert.Equal(t, []domain.ToolCall{*call}, loaded.Conversation.Messages[0].ToolCalls)
+	assert.Equal(t, answer.Citations, loaded.Conversation.Messages[2].Citations)
+	assert.Equal(t, answer.Usage, loaded.Conversation.Messages[2].Usage)
+	assert.Equal(t, 128, loaded.TotalUsage().TotalTokens())
+	assert.Equal(t, domain.MessageRoleTool, loaded.Conversation.Messages[1].Role)
+	require.Len(t, loaded.Conversation.Messages[1].ToolResults, 1)
+	assert.Equal(t, "call_1", loaded.Conversation.Messages[1].ToolResults[0].ToolCallID)
+	assert.Equal(t, domain.ToolStatusSuccess, loaded.Conversation.Messages[1].ToolResults[0].Status)
+
+	var buf bytes.Buffer
+	require.NoError(t, backend.ExportSession(session.ID, domain.ExportFormatMarkdown, &buf))
+	assert.Contains(t, buf.String(), "`call_1` get_weather({\"city\":\"Paris\"})")
+	assert.Contains(t, buf.String(), "`call_1` (success): 18C and sunny")
+	assert.Contains(t, buf.String(), "**Sources:**\n- [Paris weather](https://weather.example/paris) — confidence 0.90\n- notes/paris.md:3-7\n")
+}
+
 func TestBackend_Close(t *testing.T) {
 	backend := setupTestBackend(t)
 	err := backend.Close()
@@ -352,3 +445,80 @@ func TestExtractSnippet(t *testing.T) {
 		})
 	}
 }
+
+func TestBackend_Indexes(t *testing.T) {
+	backend, err := New(storage.Config{"base_dir": t.TempDir()})
+	require.NoError(t, err)
+	store := backend.(storage.IndexStore)
+
+	names, err := store.ListIndexes()
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	require.NoError(t, store.SaveIndex("docs", []byte(`{"chunks":[]}`)))
+	require.NoError(t, store.SaveIndex("code", []byte(`{}`)))
+
+	data, err := store.LoadIndex("docs")
+	require.NoError(t, err)
+	assert.Equal(t, `{"chunks":[]}`, string(data))
+
+	names, err = store.ListIndexes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code", "docs"}, names)
+
+	// Indexes are not mistaken for sessions
+	sessions, err := backend.List()
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	require.NoError(t, store.DeleteIndex("docs"))
+	_, err = store.LoadIndex("docs")
+	assert.ErrorIs(t, err, storage.ErrIndexNotFound)
+	assert.ErrorIs(t, store.DeleteIndex("docs"), storage.ErrIndexNotFound)
+
+	assert.Error(t, store.SaveIndex("../escape", nil))
+}
+
+func TestBackend_Templates(t *testing.T) {
+	backend, err := New(storage.Config{"base_dir": t.TempDir()})
+	require.NoError(t, err)
+	store := backend.(storage.TemplateStore)
+
+	templates, err := store.ListTemplates()
+	require.NoError(t, err)
+	assert.Empty(t, templates)
+
+	review := domain.NewSessionTemplate("review")
+	review.SystemPrompt = "You review Go code"
+	review.Model = "openai/gpt-4o"
+	review.Tags = []string{"code"}
+	review.Messages = append(review.Messages, *domain.NewMessage("msg-1", domain.MessageRoleUser, "Be strict"))
+	require.NoError(t, store.SaveTemplate(review))
+	require.NoError(t, store.SaveTemplate(domain.NewSessionTemplate("blank")))
+
+	loaded, err := store.GetTemplate("review")
+	require.NoError(t, err)
+	assert.Equal(t, "You review Go code", loaded.SystemPrompt)
+	assert.Equal(t, "openai/gpt-4o", loaded.Model)
+	assert.Equal(t, []string{"code"}, loaded.Tags)
+	require.Len(t, loaded.Messages, 1)
+	assert.Equal(t, "Be strict", loaded.Messages[0].Content)
+
+	templates, err = store.ListTemplates()
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "blank", templates[0].Name)
+	assert.Equal(t, "review", templates[1].Name)
+
+	// Templates are not mistaken for sessions
+	sessions, err := backend.List()
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	require.NoError(t, store.DeleteTemplate("review"))
+	_, err = store.GetTemplate("review")
+	assert.ErrorIs(t, err, storage.ErrTemplateNotFound)
+	assert.ErrorIs(t, store.DeleteTemplate("review"), storage.ErrTemplateNotFound)
+
+	assert.Error(t, store.SaveTemplate(domain.NewSessionTemplate("../escape")))
+}
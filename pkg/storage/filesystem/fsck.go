@@ -0,0 +1,177 @@
+// ABOUTME: Consistency check and repair for the session files of the filesystem backend
+// ABOUTME: Finds truncated or invalid session JSON and restores it from recovery copies
+
+package filesystem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// corruptSuffix is appended to a damaged session file when it is replaced,
+// keeping it for inspection and out of session listings
+const corruptSuffix = ".corrupt"
+
+// FileStatus is the outcome of checking a damaged session file
+type FileStatus string
+
+const (
+	// FileRestored means the file was replaced with a recovery copy
+	FileRestored FileStatus = "restored"
+
+	// FileRestorable means a recovery copy exists but the check did not repair
+	FileRestorable FileStatus = "restorable"
+
+	// FileUnrecoverable means no copy of the session was found
+	FileUnrecoverable FileStatus = "unrecoverable"
+)
+
+// RecoveryCopy is a copy of a session kept outside the storage directory,
+// such as the REPL's crash recovery files
+type RecoveryCopy struct {
+	Source  string // Where the copy came from, for the report
+	Saved   time.Time
+	Session *domain.Session
+}
+
+// CheckOptions controls a consistency check
+type CheckOptions struct {
+	Repair bool           // Replace damaged files with the newest copy of their session
+	Copies []RecoveryCopy // Copies damaged sessions can be restored from
+}
+
+// FileProblem describes one damaged session file
+type FileProblem struct {
+	Path      string     `json:"path"`
+	SessionID string     `json:"session_id"`
+	Problem   string     `json:"problem"`
+	Status    FileStatus `json:"status"`
+	Source    string     `json:"source,omitempty"` // The copy restored from, or that could be
+	Saved     *time.Time `json:"saved,omitempty"`  // When that copy was saved
+}
+
+// CheckReport is the result of a consistency check
+type CheckReport struct {
+	Checked  int           `json:"checked"`
+	Problems []FileProblem `json:"problems"`
+}
+
+// Unrecoverable returns the number of damaged files left without a copy
+func (r *CheckReport) Unrecoverable() int {
+	count := 0
+	for _, p := range r.Problems {
+		if p.Status == FileUnrecoverable {
+			count++
+		}
+	}
+	return count
+}
+
+// Check reads every session file and reports the ones that are empty,
+// truncated or not a valid session, such as files cut short by a crash in the
+// middle of a write. With Repair, a damaged file with a recovery copy of its
+// session is moved aside with a .corrupt suffix and the copy is saved in its
+// place.
+func (b *Backend) Check(opts CheckOptions) (*CheckReport, error) {
+	entries, err := os.ReadDir(b.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage directory: %w", err)
+	}
+
+	copies := newestCopies(opts.Copies)
+	report := &CheckReport{Problems: []FileProblem{}}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		report.Checked++
+
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		path := filepath.Join(b.baseDir, entry.Name())
+		problem := checkSessionFile(path, id)
+		if problem == "" {
+			continue
+		}
+
+		result := FileProblem{Path: path, SessionID: id, Problem: problem, Status: FileUnrecoverable}
+		if c, ok := copies[id]; ok {
+			saved := c.Saved
+			result.Source, result.Saved = c.Source, &saved
+			result.Status = FileRestorable
+			if opts.Repair {
+				if err := b.restore(path, c.Session); err != nil {
+					return nil, err
+				}
+				result.Status = FileRestored
+				logging.LogInfo("Restored damaged session file", "id", id, "source", c.Source)
+			}
+		}
+		report.Problems = append(report.Problems, result)
+	}
+	return report, nil
+}
+
+// checkSessionFile returns what is wrong with a session file, or "" if it
+// holds a valid session
+func checkSessionFile(path, id string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("unreadable: %v", err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return "empty file"
+	}
+
+	var session domain.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) && syntaxErr.Offset >= int64(len(data)) {
+			return "truncated JSON"
+		}
+		return fmt.Sprintf("invalid JSON: %v", err)
+	}
+	if session.ID == "" {
+		return "no session ID"
+	}
+	if session.ID != id {
+		return fmt.Sprintf("session ID %q does not match the file name", session.ID)
+	}
+	return ""
+}
+
+// newestCopies returns the newest copy of each session
+func newestCopies(copies []RecoveryCopy) map[string]RecoveryCopy {
+	sorted := append([]RecoveryCopy(nil), copies...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Saved.After(sorted[j].Saved) })
+
+	newest := make(map[string]RecoveryCopy)
+	for _, c := range sorted {
+		if c.Session == nil || c.Session.ID == "" {
+			continue
+		}
+		if _, ok := newest[c.Session.ID]; !ok {
+			newest[c.Session.ID] = c
+		}
+	}
+	return newest
+}
+
+// restore moves a damaged session file aside and saves the copy in its place
+func (b *Backend) restore(path string, session *domain.Session) error {
+	if err := os.Rename(path, path+corruptSuffix); err != nil {
+		return fmt.Errorf("failed to move aside damaged session file: %w", err)
+	}
+	if err := b.saveSession(session); err != nil {
+		return fmt.Errorf("failed to restore session %s: %w", session.ID, err)
+	}
+	return nil
+}
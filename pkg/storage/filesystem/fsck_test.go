@@ -0,0 +1,125 @@
+// ABOUTME: Tests for the consistency check of the filesystem backend
+// ABOUTME: Ensures damaged session files are found and restored from the newest recovery copy
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSessionFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		problem string
+	}{
+		{"valid", `{"id": "s1", "name": "ok"}`, ""},
+		{"empty", "", "empty file"},
+		{"whitespace", "\n  \n", "empty file"},
+		{"truncated", `{"id": "s1", "name": "cut sh`, "truncated JSON"},
+		{"invalid", `{"id": "s1",, }`, "invalid JSON"},
+		{"not a session", `[1, 2, 3]`, "invalid JSON"},
+		{"no id", `{"name": "nameless"}`, "no session ID"},
+		{"wrong id", `{"id": "s2"}`, "does not match the file name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "s1.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			problem := checkSessionFile(path, "s1")
+			if tt.problem == "" {
+				assert.Empty(t, problem)
+			} else {
+				assert.Contains(t, problem, tt.problem)
+			}
+		})
+	}
+}
+
+func TestBackend_Check(t *testing.T) {
+	setup := func(t *testing.T) (*Backend, []RecoveryCopy) {
+		backend := setupTestBackend(t)
+		for _, id := range []string{"good", "restorable", "lost"} {
+			session := createTestSession(id, id, "")
+			session.Conversation.AddMessage(*domain.NewMessage("msg-"+id, domain.MessageRoleUser, "hello "+id))
+			require.NoError(t, backend.Create(session))
+		}
+
+		// Crash mid-write: two files are cut short
+		for _, id := range []string{"restorable", "lost"} {
+			path := filepath.Join(backend.baseDir, id+".json")
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, data[:len(data)/2], 0644))
+		}
+
+		older := createTestSession("restorable", "older copy", "")
+		newer := createTestSession("restorable", "newer copy", "")
+		newer.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "recovered"))
+		now := time.Now()
+		return backend, []RecoveryCopy{
+			{Source: "recovery.json.1", Saved: now.Add(-time.Hour), Session: older},
+			{Source: "recovery.json", Saved: now, Session: newer},
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		backend, copies := setup(t)
+		report, err := backend.Check(CheckOptions{Copies: copies})
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, report.Checked)
+		require.Len(t, report.Problems, 2)
+		assert.Equal(t, 1, report.Unrecoverable())
+		statuses := map[string]FileStatus{}
+		for _, p := range report.Problems {
+			assert.Equal(t, "truncated JSON", p.Problem)
+			statuses[p.SessionID] = p.Status
+		}
+		assert.Equal(t, FileRestorable, statuses["restorable"])
+		assert.Equal(t, FileUnrecoverable, statuses["lost"])
+
+		// Nothing was changed
+		_, err = backend.Get("restorable")
+		assert.Error(t, err)
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		backend, copies := setup(t)
+		report, err := backend.Check(CheckOptions{Repair: true, Copies: copies})
+		require.NoError(t, err)
+
+		require.Len(t, report.Problems, 2)
+		for _, p := range report.Problems {
+			if p.SessionID == "restorable" {
+				assert.Equal(t, FileRestored, p.Status)
+				assert.Equal(t, "recovery.json", p.Source)
+			}
+		}
+
+		// The newest copy replaced the damaged file, which was kept aside
+		restored, err := backend.Get("restorable")
+		require.NoError(t, err)
+		assert.Equal(t, "newer copy", restored.Name)
+		_, err = os.Stat(filepath.Join(backend.baseDir, "restorable.json"+corruptSuffix))
+		assert.NoError(t, err)
+
+		// The unrecoverable file is left in place
+		_, err = os.Stat(filepath.Join(backend.baseDir, "lost.json"))
+		assert.NoError(t, err)
+
+		// A second check only finds the unrecoverable file
+		report, err = backend.Check(CheckOptions{Repair: true, Copies: copies})
+		require.NoError(t, err)
+		require.Len(t, report.Problems, 1)
+		assert.Equal(t, "lost", report.Problems[0].SessionID)
+	})
+}
@@ -0,0 +1,144 @@
+// ABOUTME: Garbage collection of attachment content left behind by the filesystem backend
+// ABOUTME: Deletes snapshots of missing sessions and blob files nothing refers to, and rebuilds the reference counts
+
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// Ensure Backend implements storage.GarbageCollector
+var _ storage.GarbageCollector = (*Backend)(nil)
+
+// CollectGarbage implements storage.GarbageCollector.CollectGarbage. A
+// session file holds its whole conversation, so the only data a deleted
+// session can leave behind is its snapshots and attachment content: blob
+// files and reference counts that a crash between writes failed to release.
+// Nothing is collected while a session file is damaged, since it may be what
+// refers to the content.
+func (b *Backend) CollectGarbage(dryRun bool) (*storage.GCReport, error) {
+	unlock, err := b.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := os.ReadDir(b.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage directory: %w", err)
+	}
+	refs := make(map[string]int)
+	sessions := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(b.baseDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session file: %w", err)
+		}
+		var session domain.Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			return nil, fmt.Errorf("session file %s is damaged, run storage fsck first: %w", entry.Name(), err)
+		}
+		for hash := range storage.AttachmentHashes(&session) {
+			refs[hash]++
+		}
+		sessions[strings.TrimSuffix(entry.Name(), ".json")] = true
+	}
+
+	report := &storage.GCReport{}
+
+	// Snapshots of sessions that no longer exist; the content of the others
+	// stays referenced
+	snapshotDirs, err := os.ReadDir(filepath.Join(b.baseDir, snapshotsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+	var orphanedSnapshots []string
+	for _, dir := range snapshotDirs {
+		if !dir.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(b.baseDir, snapshotsDir, dir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+		}
+		if !sessions[dir.Name()] {
+			report.Snapshots += len(files)
+			orphanedSnapshots = append(orphanedSnapshots, dir.Name())
+			continue
+		}
+		for _, file := range files {
+			snapshot, err := readSnapshot(filepath.Join(b.baseDir, snapshotsDir, dir.Name(), file.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("snapshot %s of session %s is damaged: %w", file.Name(), dir.Name(), err)
+			}
+			for hash := range storage.AttachmentHashes(snapshot.Session) {
+				refs[hash]++
+			}
+		}
+	}
+
+	// Reference counts of content no session uses
+	stored, err := b.readRefs()
+	if err != nil {
+		return nil, err
+	}
+	for hash := range stored {
+		if refs[hash] == 0 {
+			report.AttachmentRefs++
+		}
+	}
+
+	// Blob files no session uses
+	used := make(map[string]bool, len(refs))
+	for hash := range refs {
+		if _, digest, ok := strings.Cut(hash, ":"); ok {
+			used[digest] = true
+		}
+	}
+	blobs, err := os.ReadDir(filepath.Join(b.baseDir, blobsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read blob directory: %w", err)
+	}
+	var unused []string
+	for _, blob := range blobs {
+		if !blob.IsDir() && blob.Name() != refsFile && !used[blob.Name()] {
+			unused = append(unused, filepath.Join(b.baseDir, blobsDir, blob.Name()))
+		}
+	}
+	report.Attachments = len(unused)
+
+	if dryRun {
+		return report, nil
+	}
+	for _, id := range orphanedSnapshots {
+		if err := os.RemoveAll(filepath.Join(b.baseDir, snapshotsDir, id)); err != nil {
+			return nil, fmt.Errorf("failed to remove snapshots of missing session: %w", err)
+		}
+	}
+	if len(stored) > 0 || len(refs) > 0 {
+		if err := b.writeRefs(refs); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range unused {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove unreferenced attachment content: %w", err)
+		}
+	}
+	report.Collected = true
+	if report.Total() > 0 {
+		logging.LogInfo("Collected unreferenced data", "snapshots", report.Snapshots, "files", report.Attachments, "references", report.AttachmentRefs)
+	}
+	return report, nil
+}
@@ -0,0 +1,62 @@
+// ABOUTME: Tests for garbage collection in the filesystem backend
+// ABOUTME: Ensures attachment content no session uses is found and deleted, and damaged files stop collection
+
+package filesystem
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend_CollectGarbage(t *testing.T) {
+	backend := setupTestBackend(t)
+	kept := createAttachmentSession("kept", []byte("still attached"))
+	require.NoError(t, backend.Create(kept))
+	lost := createAttachmentSession("lost", []byte("left behind"))
+	require.NoError(t, backend.Create(lost))
+
+	// A crash after removing the session file leaves its content behind
+	require.NoError(t, os.Remove(filepath.Join(backend.baseDir, "lost.json")))
+	require.Len(t, blobFiles(t, backend), 2)
+
+	report, err := backend.CollectGarbage(true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Attachments)
+	assert.Equal(t, 1, report.AttachmentRefs)
+	assert.False(t, report.Collected)
+	assert.Len(t, blobFiles(t, backend), 2)
+
+	report, err = backend.CollectGarbage(false)
+	require.NoError(t, err)
+	assert.True(t, report.Collected)
+	assert.Equal(t, 2, report.Total())
+	assert.Len(t, blobFiles(t, backend), 1)
+
+	data, err := os.ReadFile(filepath.Join(backend.baseDir, blobsDir, refsFile))
+	require.NoError(t, err)
+	var refs map[string]int
+	require.NoError(t, json.Unmarshal(data, &refs))
+	assert.Len(t, refs, 1)
+
+	loaded, err := backend.Get("kept")
+	require.NoError(t, err)
+	content, err := loaded.Conversation.Messages[0].Attachments[0].LoadContent()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("still attached"), content)
+
+	report, err = backend.CollectGarbage(false)
+	require.NoError(t, err)
+	assert.Zero(t, report.Total())
+
+	t.Run("damaged session file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(backend.baseDir, "kept.json"), []byte(`{"id": "ke`), 0644))
+		_, err := backend.CollectGarbage(false)
+		assert.ErrorContains(t, err, "storage fsck")
+		assert.Len(t, blobFiles(t, backend), 1)
+	})
+}
@@ -0,0 +1,35 @@
+// ABOUTME: Storage lock shared by every magellai process using a directory
+// ABOUTME: Serializes session writes and attachment reference updates across goroutines and processes
+
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockFile is locked while sessions and attachment references are written,
+// so a REPL autosaving and a CLI command in another process take turns
+const lockFile = ".lock"
+
+// lock takes b.mu and an OS lock on the lock file, and returns the
+// function that releases both
+func (b *Backend) lock() (func(), error) {
+	b.mu.Lock()
+	f, err := os.OpenFile(filepath.Join(b.baseDir, lockFile), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		b.mu.Unlock()
+		return nil, fmt.Errorf("failed to open storage lock: %w", err)
+	}
+	if err := lockFileHandle(f); err != nil {
+		f.Close()
+		b.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock storage: %w", err)
+	}
+	return func() {
+		_ = unlockFileHandle(f)
+		f.Close()
+		b.mu.Unlock()
+	}, nil
+}
@@ -0,0 +1,26 @@
+// ABOUTME: Storage lock using flock on Unix systems
+// ABOUTME: Blocks until no other process holds the lock file
+
+//go:build !windows
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFileHandle takes an exclusive lock on f, waiting for other holders
+func lockFileHandle(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// unlockFileHandle releases the lock on f
+func unlockFileHandle(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
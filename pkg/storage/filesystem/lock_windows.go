@@ -0,0 +1,22 @@
+// ABOUTME: Storage lock using LockFileEx on Windows
+// ABOUTME: Blocks until no other process holds the lock file
+
+//go:build windows
+
+package filesystem
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFileHandle takes an exclusive lock on f, waiting for other holders
+func lockFileHandle(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &windows.Overlapped{})
+}
+
+// unlockFileHandle releases the lock on f
+func unlockFileHandle(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &windows.Overlapped{})
+}
@@ -0,0 +1,211 @@
+// ABOUTME: Session snapshots for the filesystem backend
+// ABOUTME: Keeps each snapshot as a JSON file under snapshots/<session-id>, sharing attachment blobs with sessions
+
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// snapshotsDir is the directory under the base directory holding snapshots
+const snapshotsDir = "snapshots"
+
+// Ensure Backend implements storage.SnapshotStore
+var _ storage.SnapshotStore = (*Backend)(nil)
+
+// snapshotDir returns the directory holding the snapshots of a session
+func (b *Backend) snapshotDir(sessionID string) (string, error) {
+	if sessionID == "" || sessionID == "." || sessionID == ".." || strings.ContainsAny(sessionID, `/\`) {
+		return "", fmt.Errorf("invalid session ID: %q", sessionID)
+	}
+	return filepath.Join(b.baseDir, snapshotsDir, sessionID), nil
+}
+
+// snapshotPath returns the file holding a snapshot
+func (b *Backend) snapshotPath(sessionID, snapshotID string) (string, error) {
+	dir, err := b.snapshotDir(sessionID)
+	if err != nil {
+		return "", err
+	}
+	if snapshotID == "" || snapshotID == "." || snapshotID == ".." || strings.ContainsAny(snapshotID, `/\`) {
+		return "", fmt.Errorf("invalid snapshot ID: %q", snapshotID)
+	}
+	return filepath.Join(dir, snapshotID+".json"), nil
+}
+
+// SaveSnapshot implements storage.SnapshotStore.SaveSnapshot
+func (b *Backend) SaveSnapshot(snapshot *domain.SessionSnapshot) error {
+	if snapshot.Session == nil {
+		return fmt.Errorf("snapshot %s has no session", snapshot.ID)
+	}
+	path, err := b.snapshotPath(snapshot.SessionID, snapshot.ID)
+	if err != nil {
+		return err
+	}
+
+	stripped, blobs := storage.SplitAttachments(snapshot.Session)
+	record := *snapshot
+	record.Session = stripped
+	data, err := json.MarshalIndent(&record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	unlock, err := b.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := b.writeBlobs(blobs); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := b.updateRefs(storage.AttachmentHashes(stripped), nil); err != nil {
+		return err
+	}
+	logging.LogDebug("Saved snapshot", "session_id", snapshot.SessionID, "snapshot_id", snapshot.ID, "reason", snapshot.Reason)
+	return nil
+}
+
+// ListSnapshots implements storage.SnapshotStore.ListSnapshots
+func (b *Backend) ListSnapshots(sessionID string) ([]*domain.SessionSnapshot, error) {
+	dir, err := b.snapshotDir(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []*domain.SessionSnapshot{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	snapshots := []*domain.SessionSnapshot{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		snapshot, err := readSnapshot(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			logging.LogWarn("Skipping unreadable snapshot", "file", entry.Name(), "error", err)
+			continue
+		}
+		snapshot.Session = nil
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Created.Before(snapshots[j].Created) })
+	return snapshots, nil
+}
+
+// GetSnapshot implements storage.SnapshotStore.GetSnapshot
+func (b *Backend) GetSnapshot(sessionID, snapshotID string) (*domain.SessionSnapshot, error) {
+	path, err := b.snapshotPath(sessionID, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := readSnapshot(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", storage.ErrSnapshotNotFound, snapshotID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Session == nil {
+		return nil, fmt.Errorf("snapshot %s has no session", snapshotID)
+	}
+	storage.SetContentLoaders(snapshot.Session, b.loadBlob)
+	return snapshot, nil
+}
+
+// DeleteSnapshot implements storage.SnapshotStore.DeleteSnapshot
+func (b *Backend) DeleteSnapshot(sessionID, snapshotID string) error {
+	path, err := b.snapshotPath(sessionID, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := b.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", storage.ErrSnapshotNotFound, snapshotID)
+	}
+	return b.removeSnapshotFiles([]string{path})
+}
+
+// deleteSnapshots removes every snapshot of a session. The caller must hold
+// the storage lock.
+func (b *Backend) deleteSnapshots(sessionID string) error {
+	dir, err := b.snapshotDir(sessionID)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	if err := b.removeSnapshotFiles(paths); err != nil {
+		return err
+	}
+	if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove snapshot directory: %w", err)
+	}
+	return nil
+}
+
+// removeSnapshotFiles deletes snapshot files and releases the attachment
+// content they refer to. The caller must hold the storage lock.
+func (b *Backend) removeSnapshotFiles(paths []string) error {
+	for _, path := range paths {
+		var hashes map[string]bool
+		if snapshot, err := readSnapshot(path); err == nil {
+			hashes = storage.AttachmentHashes(snapshot.Session)
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete snapshot: %w", err)
+		}
+		if err := b.updateRefs(nil, hashes); err != nil {
+			logging.LogWarn("Failed to release attachment content of snapshot", "path", path, "error", err)
+		}
+	}
+	return nil
+}
+
+// readSnapshot reads a snapshot file
+func readSnapshot(path string) (*domain.SessionSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot domain.SessionSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,83 @@
+// ABOUTME: Tests for session snapshots in the filesystem backend
+// ABOUTME: Ensures snapshots round-trip, keep attachment content alive and go away with their session
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend_Snapshots(t *testing.T) {
+	backend := setupTestBackend(t)
+	session := createAttachmentSession("snap", []byte("attached then"))
+	require.NoError(t, backend.Create(session))
+
+	first := domain.NewSessionSnapshot(session, domain.SnapshotReasonInterval)
+	first.Created = time.Now().Add(-time.Hour)
+	require.NoError(t, backend.SaveSnapshot(first))
+	second := domain.NewSessionSnapshot(session, domain.SnapshotReasonBeforeReset)
+	second.ID = first.ID + "_2"
+	require.NoError(t, backend.SaveSnapshot(second))
+
+	snapshots, err := backend.ListSnapshots("snap")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, first.ID, snapshots[0].ID)
+	assert.Equal(t, domain.SnapshotReasonBeforeReset, snapshots[1].Reason)
+	assert.Equal(t, 1, snapshots[1].MessageCount)
+	assert.Nil(t, snapshots[0].Session)
+
+	// The snapshot keeps the attachment content after the session drops it
+	session.Conversation.Messages = nil
+	require.NoError(t, backend.Update(session))
+	assert.Len(t, blobFiles(t, backend), 1)
+
+	loaded, err := backend.GetSnapshot("snap", first.ID)
+	require.NoError(t, err)
+	require.Len(t, loaded.Session.Conversation.Messages, 1)
+	content, err := loaded.Session.Conversation.Messages[0].Attachments[0].LoadContent()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("attached then"), content)
+
+	report, err := backend.CollectGarbage(true)
+	require.NoError(t, err)
+	assert.Zero(t, report.Total())
+
+	_, err = backend.GetSnapshot("snap", "snap_missing")
+	assert.ErrorIs(t, err, storage.ErrSnapshotNotFound)
+	assert.ErrorIs(t, backend.DeleteSnapshot("snap", "snap_missing"), storage.ErrSnapshotNotFound)
+	_, err = backend.ListSnapshots("../snap")
+	assert.Error(t, err)
+
+	require.NoError(t, backend.DeleteSnapshot("snap", first.ID))
+	assert.Len(t, blobFiles(t, backend), 1, "the second snapshot still refers to the content")
+
+	require.NoError(t, backend.Delete("snap"))
+	assert.NoDirExists(t, filepath.Join(backend.baseDir, snapshotsDir, "snap"))
+	assert.Empty(t, blobFiles(t, backend))
+}
+
+func TestBackend_CollectGarbage_Snapshots(t *testing.T) {
+	backend := setupTestBackend(t)
+	session := createAttachmentSession("gone", []byte("only in a snapshot"))
+	require.NoError(t, backend.Create(session))
+	require.NoError(t, backend.SaveSnapshot(domain.NewSessionSnapshot(session, domain.SnapshotReasonInterval)))
+
+	// A crash after removing the session file leaves its snapshots behind
+	require.NoError(t, os.Remove(filepath.Join(backend.baseDir, "gone.json")))
+
+	report, err := backend.CollectGarbage(false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Snapshots)
+	assert.Equal(t, 1, report.Attachments)
+	assert.NoDirExists(t, filepath.Join(backend.baseDir, snapshotsDir, "gone"))
+	assert.Empty(t, blobFiles(t, backend))
+}
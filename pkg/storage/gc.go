@@ -0,0 +1,40 @@
+// ABOUTME: Optional storage interface for removing data no session refers to
+// ABOUTME: Backends report and delete orphaned conversations, messages, tags, snapshots, search entries and attachments
+
+package storage
+
+// GarbageCollector is implemented by backends that can find data left behind
+// by deleted sessions, such as messages of a conversation whose session is
+// gone. It is optional; callers check for it with a type assertion.
+type GarbageCollector interface {
+	// CollectGarbage finds orphaned data and, unless dryRun is set, deletes it
+	CollectGarbage(dryRun bool) (*GCReport, error)
+}
+
+// GCReport counts the orphaned data a garbage collection found
+type GCReport struct {
+	Conversations  int  `json:"conversations"`   // Conversations no session refers to
+	Messages       int  `json:"messages"`        // Messages of missing conversations
+	Tags           int  `json:"tags"`            // Tags of missing sessions
+	Snapshots      int  `json:"snapshots"`       // Snapshots of missing sessions
+	SearchEntries  int  `json:"search_entries"`  // Full-text search rows of missing conversations
+	AttachmentRefs int  `json:"attachment_refs"` // References to attachment content from missing messages
+	Attachments    int  `json:"attachments"`     // Attachment content no message refers to
+	Collected      bool `json:"collected"`       // Whether the orphaned data was deleted
+}
+
+// Total returns the number of orphaned items found
+func (r *GCReport) Total() int {
+	return r.Conversations + r.Messages + r.Tags + r.Snapshots + r.SearchEntries + r.AttachmentRefs + r.Attachments
+}
+
+// add adds the counts of other to r
+func (r *GCReport) add(other *GCReport) {
+	r.Conversations += other.Conversations
+	r.Messages += other.Messages
+	r.Tags += other.Tags
+	r.Snapshots += other.Snapshots
+	r.SearchEntries += other.SearchEntries
+	r.AttachmentRefs += other.AttachmentRefs
+	r.Attachments += other.Attachments
+}
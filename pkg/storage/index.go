@@ -0,0 +1,20 @@
+// ABOUTME: Optional storage interface for persisting retrieval indexes
+// ABOUTME: Lets backends keep serialized search indexes alongside sessions
+
+package storage
+
+// IndexStore is implemented by backends that can persist named retrieval
+// indexes. It is optional; callers check for it with a type assertion.
+type IndexStore interface {
+	// SaveIndex stores serialized index data under name, replacing any existing index
+	SaveIndex(name string, data []byte) error
+
+	// LoadIndex returns the serialized index stored under name, or ErrIndexNotFound
+	LoadIndex(name string) ([]byte, error)
+
+	// ListIndexes returns the names of all stored indexes, sorted
+	ListIndexes() ([]string, error)
+
+	// DeleteIndex removes the index stored under name, or returns ErrIndexNotFound
+	DeleteIndex(name string) error
+}
@@ -0,0 +1,256 @@
+// ABOUTME: Replicating backend that mirrors session writes to a secondary backend
+// ABOUTME: Reads come from the primary; Repair reconciles a replica that has diverged from it
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// ReplicatingBackend wraps a primary backend and mirrors every session
+// write to a secondary one, such as a filesystem copy of a SQLite database.
+// The primary is the source of truth: reads come from it, and a write fails
+// only when the primary fails. A failed mirror write is logged and leaves the
+// replica behind until Repair brings it up to date. Retrieval indexes and
+// session templates live in the primary only.
+type ReplicatingBackend struct {
+	Backend
+	secondary Backend
+}
+
+// Ensure ReplicatingBackend implements Backend and GarbageCollector
+var (
+	_ Backend          = (*ReplicatingBackend)(nil)
+	_ GarbageCollector = (*ReplicatingBackend)(nil)
+)
+
+// NewReplicatingBackend mirrors the session writes of primary to secondary
+func NewReplicatingBackend(primary, secondary Backend) *ReplicatingBackend {
+	return &ReplicatingBackend{Backend: primary, secondary: secondary}
+}
+
+// Primary returns the backend reads come from
+func (r *ReplicatingBackend) Primary() Backend {
+	return r.Backend
+}
+
+// Secondary returns the backend writes are mirrored to
+func (r *ReplicatingBackend) Secondary() Backend {
+	return r.secondary
+}
+
+// Create creates the session in the primary and mirrors it
+func (r *ReplicatingBackend) Create(session *domain.Session) error {
+	if err := r.Backend.Create(session); err != nil {
+		return err
+	}
+	r.mirror(session)
+	return nil
+}
+
+// Update updates the session in the primary and mirrors it
+func (r *ReplicatingBackend) Update(session *domain.Session) error {
+	if err := r.Backend.Update(session); err != nil {
+		return err
+	}
+	r.mirror(session)
+	return nil
+}
+
+// Delete deletes the session from the primary and the replica
+func (r *ReplicatingBackend) Delete(id string) error {
+	if err := r.Backend.Delete(id); err != nil {
+		return err
+	}
+	if err := r.secondary.Delete(id); err != nil && !isNotFound(err) {
+		logging.LogWarn("Failed to delete session from replica", "id", id, "error", err)
+	}
+	return nil
+}
+
+// MergeSessions merges in the primary and mirrors the sessions it changed
+func (r *ReplicatingBackend) MergeSessions(targetID, sourceID string, options domain.MergeOptions) (*domain.MergeResult, error) {
+	result, err := r.Backend.MergeSessions(targetID, sourceID, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range []string{result.SessionID, result.NewBranchID} {
+		if id == "" {
+			continue
+		}
+		if err := r.copySession(id); err != nil {
+			logging.LogWarn("Failed to mirror merged session to replica", "id", id, "error", err)
+		}
+	}
+	return result, nil
+}
+
+// Close closes both backends
+func (r *ReplicatingBackend) Close() error {
+	return errors.Join(r.Backend.Close(), r.secondary.Close())
+}
+
+// CollectGarbage collects garbage in each backend that supports it and
+// reports the combined counts
+func (r *ReplicatingBackend) CollectGarbage(dryRun bool) (*GCReport, error) {
+	report := &GCReport{Collected: !dryRun}
+	for _, backend := range []Backend{r.Backend, r.secondary} {
+		gc, ok := backend.(GarbageCollector)
+		if !ok {
+			continue
+		}
+		collected, err := gc.CollectGarbage(dryRun)
+		if err != nil {
+			return nil, err
+		}
+		report.add(collected)
+	}
+	return report, nil
+}
+
+// mirror writes a session the primary has saved to the replica
+func (r *ReplicatingBackend) mirror(session *domain.Session) {
+	// Content stored by hash in the primary is not in the replica's blobs
+	if err := LoadAttachmentContent(session); err != nil {
+		logging.LogWarn("Failed to mirror session to replica", "id", session.ID, "error", err)
+		return
+	}
+	if err := r.save(session); err != nil {
+		logging.LogWarn("Failed to mirror session to replica", "id", session.ID, "error", err)
+	}
+}
+
+// save writes a session to the replica, creating it if the replica lacks it
+func (r *ReplicatingBackend) save(session *domain.Session) error {
+	err := r.secondary.Update(session)
+	if isNotFound(err) {
+		err = r.secondary.Create(session)
+	}
+	return err
+}
+
+// copySession copies a session from the primary to the replica
+func (r *ReplicatingBackend) copySession(id string) error {
+	session, err := r.Backend.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := LoadAttachmentContent(session); err != nil {
+		return err
+	}
+	return r.save(session)
+}
+
+// ReplicaReport lists the sessions of a replica that differ from the primary
+type ReplicaReport struct {
+	Checked  int      `json:"checked"`  // Sessions in the primary
+	Missing  []string `json:"missing"`  // In the primary only
+	Stale    []string `json:"stale"`    // Older or different in the replica
+	Extra    []string `json:"extra"`    // In the replica only
+	Repaired bool     `json:"repaired"` // Whether the replica was brought up to date
+}
+
+// Diverged returns the number of sessions that differ between the backends
+func (r *ReplicaReport) Diverged() int {
+	return len(r.Missing) + len(r.Stale) + len(r.Extra)
+}
+
+// Repair compares the replica with the primary and, unless dryRun is set,
+// copies missing and stale sessions to it and deletes sessions the primary
+// no longer has. A replica session is stale when it was updated before the
+// primary's copy or has a different name or number of messages.
+func (r *ReplicatingBackend) Repair(dryRun bool) (*ReplicaReport, error) {
+	primary, err := r.Backend.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list primary sessions: %w", err)
+	}
+	replica, err := r.secondary.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replica sessions: %w", err)
+	}
+
+	replicaInfo := make(map[string]*domain.SessionInfo, len(replica))
+	for _, info := range replica {
+		replicaInfo[info.ID] = info
+	}
+	report := &ReplicaReport{Checked: len(primary), Missing: []string{}, Stale: []string{}, Extra: []string{}}
+	for _, info := range primary {
+		mirrored, ok := replicaInfo[info.ID]
+		delete(replicaInfo, info.ID)
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, info.ID)
+		case mirrored.Updated.Before(info.Updated) || mirrored.Name != info.Name || mirrored.MessageCount != info.MessageCount:
+			report.Stale = append(report.Stale, info.ID)
+		}
+	}
+	for id := range replicaInfo {
+		report.Extra = append(report.Extra, id)
+	}
+	sort.Strings(report.Missing)
+	sort.Strings(report.Stale)
+	sort.Strings(report.Extra)
+
+	if dryRun {
+		return report, nil
+	}
+	for _, id := range append(append([]string{}, report.Missing...), report.Stale...) {
+		if err := r.copySession(id); err != nil {
+			return nil, fmt.Errorf("failed to copy session %s to replica: %w", id, err)
+		}
+	}
+	for _, id := range report.Extra {
+		if err := r.secondary.Delete(id); err != nil && !isNotFound(err) {
+			return nil, fmt.Errorf("failed to delete session %s from replica: %w", id, err)
+		}
+	}
+	report.Repaired = true
+	logging.LogInfo("Repaired storage replica", "missing", len(report.Missing), "stale", len(report.Stale), "extra", len(report.Extra))
+	return report, nil
+}
+
+// Replica returns the backend type and settings of the replica named by the
+// "replica_type" and "replica_settings" settings, and false if there is none.
+// A replica without a location of its own is kept next to the primary's
+// base_dir, in replica/ or replica.db, and is read-only when the primary is.
+func (c Config) Replica() (BackendType, Config, bool) {
+	replicaType, _ := c["replica_type"].(string)
+	if replicaType == "" {
+		return "", nil, false
+	}
+
+	settings := Config{}
+	switch s := c["replica_settings"].(type) {
+	case map[string]interface{}:
+		for k, v := range s {
+			settings[k] = v
+		}
+	case Config:
+		for k, v := range s {
+			settings[k] = v
+		}
+	}
+	if baseDir, ok := c["base_dir"].(string); ok && baseDir != "" {
+		if _, ok := settings["base_dir"]; !ok {
+			settings["base_dir"] = filepath.Join(baseDir, "replica")
+		}
+		if _, ok := settings["db_path"]; !ok {
+			settings["db_path"] = filepath.Join(baseDir, "replica.db")
+		}
+	}
+	if c.ReadOnly() {
+		settings["read_only"] = true
+	}
+	return BackendType(replicaType), settings, true
+}
+
+// isNotFound reports whether err means a session does not exist
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrSessionNotFound) || errors.Is(err, domain.ErrSessionNotFound)
+}
@@ -0,0 +1,119 @@
+// ABOUTME: Tests for the replicating backend
+// ABOUTME: Ensures writes are mirrored to the replica and Repair reconciles a diverged replica
+
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+	_ "github.com/lexlapax/magellai/pkg/storage/filesystem" // Register filesystem backend
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newReplicatedStorage creates a filesystem backend mirrored to another one
+func newReplicatedStorage(t *testing.T) *storage.ReplicatingBackend {
+	t.Helper()
+	dir := t.TempDir()
+	backend, err := storage.CreateBackend(storage.FileSystemBackend, storage.Config{
+		"base_dir":     dir,
+		"replica_type": string(storage.FileSystemBackend),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { backend.Close() })
+
+	replicating, ok := backend.(*storage.ReplicatingBackend)
+	require.True(t, ok, "a configured replica wraps the backend")
+	return replicating
+}
+
+func newReplicaSession(id string) *domain.Session {
+	session := domain.NewSession(id)
+	session.Name = id
+	session.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "hello"))
+	return session
+}
+
+func TestReplicatingBackend_MirrorsWrites(t *testing.T) {
+	r := newReplicatedStorage(t)
+
+	session := newReplicaSession("s1")
+	require.NoError(t, r.Create(session))
+	mirrored, err := r.Secondary().Get("s1")
+	require.NoError(t, err)
+	assert.Len(t, mirrored.Conversation.Messages, 1)
+
+	session.Conversation.AddMessage(*domain.NewMessage("msg-2", domain.MessageRoleAssistant, "hi"))
+	require.NoError(t, r.Update(session))
+	mirrored, err = r.Secondary().Get("s1")
+	require.NoError(t, err)
+	assert.Len(t, mirrored.Conversation.Messages, 2)
+
+	require.NoError(t, r.Delete("s1"))
+	_, err = r.Secondary().Get("s1")
+	assert.ErrorIs(t, err, storage.ErrSessionNotFound)
+}
+
+func TestReplicatingBackend_Repair(t *testing.T) {
+	r := newReplicatedStorage(t)
+	require.NoError(t, r.Create(newReplicaSession("in-sync")))
+	require.NoError(t, r.Create(newReplicaSession("stale")))
+
+	// Writes the replica missed, and a session only it has
+	require.NoError(t, r.Primary().Create(newReplicaSession("missing")))
+	stale, err := r.Primary().Get("stale")
+	require.NoError(t, err)
+	stale.Conversation.AddMessage(*domain.NewMessage("msg-2", domain.MessageRoleAssistant, "new reply"))
+	require.NoError(t, r.Primary().Update(stale))
+	require.NoError(t, r.Secondary().Create(newReplicaSession("extra")))
+
+	report, err := r.Repair(true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, []string{"missing"}, report.Missing)
+	assert.Equal(t, []string{"stale"}, report.Stale)
+	assert.Equal(t, []string{"extra"}, report.Extra)
+	assert.False(t, report.Repaired)
+	_, err = r.Secondary().Get("missing")
+	assert.Error(t, err, "a dry run changes nothing")
+
+	report, err = r.Repair(false)
+	require.NoError(t, err)
+	assert.True(t, report.Repaired)
+	assert.Equal(t, 3, report.Diverged())
+
+	mirrored, err := r.Secondary().Get("stale")
+	require.NoError(t, err)
+	assert.Len(t, mirrored.Conversation.Messages, 2)
+	_, err = r.Secondary().Get("extra")
+	assert.ErrorIs(t, err, storage.ErrSessionNotFound)
+
+	report, err = r.Repair(true)
+	require.NoError(t, err)
+	assert.Zero(t, report.Diverged())
+}
+
+func TestConfig_Replica(t *testing.T) {
+	_, _, ok := storage.Config{"base_dir": "/data"}.Replica()
+	assert.False(t, ok)
+
+	replicaType, settings, ok := storage.Config{
+		"base_dir":     "/data",
+		"read_only":    true,
+		"replica_type": "sqlite",
+	}.Replica()
+	require.True(t, ok)
+	assert.Equal(t, storage.SQLiteBackend, replicaType)
+	assert.Equal(t, filepath.Join("/data", "replica.db"), settings["db_path"])
+	assert.True(t, settings.ReadOnly())
+
+	_, settings, _ = storage.Config{
+		"base_dir":         "/data",
+		"replica_type":     "filesystem",
+		"replica_settings": map[string]interface{}{"base_dir": "/backup"},
+	}.Replica()
+	assert.Equal(t, "/backup", settings["base_dir"])
+}
@@ -0,0 +1,71 @@
+// ABOUTME: Optional storage interface for point-in-time snapshots of sessions
+// ABOUTME: Lets backends keep earlier states of a session so it can be rolled back
+
+package storage
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// SnapshotStore is implemented by backends that can keep snapshots of
+// sessions. It is optional; callers check for it with a type assertion.
+// Attachment content a snapshot refers to is kept as long as the snapshot is,
+// and deleting a session deletes its snapshots.
+type SnapshotStore interface {
+	// SaveSnapshot stores a snapshot and the session it holds
+	SaveSnapshot(snapshot *domain.SessionSnapshot) error
+
+	// ListSnapshots returns the snapshots of a session, oldest first, without their sessions
+	ListSnapshots(sessionID string) ([]*domain.SessionSnapshot, error)
+
+	// GetSnapshot returns a snapshot with its session, or ErrSnapshotNotFound
+	GetSnapshot(sessionID, snapshotID string) (*domain.SessionSnapshot, error)
+
+	// DeleteSnapshot removes a snapshot, or returns ErrSnapshotNotFound
+	DeleteSnapshot(sessionID, snapshotID string) error
+}
+
+// SnapshotPolicy says when the session manager takes snapshots and how many
+// it keeps per session
+type SnapshotPolicy struct {
+	Interval time.Duration // Minimum time between snapshots taken on save; 0 takes none
+	Keep     int           // Snapshots kept per session, oldest dropped first; 0 turns snapshots off
+}
+
+// Enabled reports whether snapshots are taken at all
+func (p SnapshotPolicy) Enabled() bool {
+	return p.Keep > 0
+}
+
+// SnapshotPolicy returns the policy set by the "snapshot_interval" and
+// "snapshot_keep" settings. Without them no snapshots are taken; values that
+// cannot be parsed count as unset.
+func (c Config) SnapshotPolicy() SnapshotPolicy {
+	var policy SnapshotPolicy
+	switch v := c["snapshot_interval"].(type) {
+	case time.Duration:
+		policy.Interval = v
+	case string:
+		policy.Interval, _ = time.ParseDuration(v)
+	}
+	switch v := c["snapshot_keep"].(type) {
+	case int:
+		policy.Keep = v
+	case int64:
+		policy.Keep = int(v)
+	case float64:
+		policy.Keep = int(v)
+	case string:
+		policy.Keep, _ = strconv.Atoi(v)
+	}
+	if policy.Interval < 0 {
+		policy.Interval = 0
+	}
+	if policy.Keep < 0 {
+		policy.Keep = 0
+	}
+	return policy
+}
@@ -0,0 +1,21 @@
+// ABOUTME: Tests for the snapshot settings of storage configuration
+// ABOUTME: Ensures the snapshot policy is read from strings and numbers and defaults to off
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_SnapshotPolicy(t *testing.T) {
+	policy := Config{"snapshot_interval": "15m", "snapshot_keep": 20}.SnapshotPolicy()
+	assert.Equal(t, SnapshotPolicy{Interval: 15 * time.Minute, Keep: 20}, policy)
+	assert.True(t, policy.Enabled())
+
+	assert.False(t, Config{}.SnapshotPolicy().Enabled())
+	assert.Equal(t, SnapshotPolicy{Keep: 3}, Config{"snapshot_interval": "often", "snapshot_keep": "3"}.SnapshotPolicy())
+	assert.Equal(t, SnapshotPolicy{}, Config{"snapshot_interval": "-1h", "snapshot_keep": -2}.SnapshotPolicy())
+}
@@ -0,0 +1,275 @@
+// ABOUTME: Batched writes of messages and tags for the SQLite storage backend
+// ABOUTME: Rewrites only the rows that changed since the last save, using multi-row statements
+
+//go:build sqlite || db
+
+package sqlite
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// messageBatchSize is the number of rows written by one statement. It keeps
+// the number of bound parameters well under SQLite's limit.
+const messageBatchSize = 100
+
+// messageColumns are the columns written for each message, in the order of
+// the values returned by messageValues
+var messageColumns = []string{
+	"id", "conversation_id", "user_id", "role", "content", "timestamp", "attachments", "metadata",
+	"position", "tool_calls", "tool_results", "revisions", "citations", "usage", "pinned", "digest",
+}
+
+// txStatements prepares each distinct statement once per transaction, so a
+// batch statement is reused for every full batch of rows
+type txStatements struct {
+	tx    *sql.Tx
+	stmts map[string]*sql.Stmt
+}
+
+// newTxStatements creates a statement cache for a transaction. The statements
+// are closed when the transaction ends.
+func newTxStatements(tx *sql.Tx) *txStatements {
+	return &txStatements{tx: tx, stmts: make(map[string]*sql.Stmt)}
+}
+
+// exec runs a query with a prepared statement
+func (s *txStatements) exec(query string, args ...interface{}) error {
+	stmt, ok := s.stmts[query]
+	if !ok {
+		var err error
+		stmt, err = s.tx.Prepare(query)
+		if err != nil {
+			return err
+		}
+		s.stmts[query] = stmt
+	}
+	_, err := stmt.Exec(args...)
+	return err
+}
+
+// execBatches runs the statement built by query once per batch of rows. Each
+// statement is given the fixed arguments followed by the values of its rows.
+func (s *txStatements) execBatches(rows [][]interface{}, query func(n int) string, fixed ...interface{}) error {
+	for start := 0; start < len(rows); start += messageBatchSize {
+		end := start + messageBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		args := append([]interface{}{}, fixed...)
+		for _, row := range rows[start:end] {
+			args = append(args, row...)
+		}
+		if err := s.exec(query(end-start), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// placeholders returns n groups of width comma-separated parameters, such as
+// "(?, ?), (?, ?)"
+func placeholders(n, width int) string {
+	group := "(" + strings.TrimSuffix(strings.Repeat("?, ", width), ", ") + ")"
+	return strings.TrimSuffix(strings.Repeat(group+", ", n), ", ")
+}
+
+// idList returns n parameters for an IN clause, such as "(?, ?)"
+func idList(n int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", n), ", ") + ")"
+}
+
+// idRows wraps ids as single-value rows for execBatches
+func idRows(ids []string) [][]interface{} {
+	rows := make([][]interface{}, len(ids))
+	for i, id := range ids {
+		rows[i] = []interface{}{id}
+	}
+	return rows
+}
+
+// messageDigest identifies the stored form of a message at a position in its
+// conversation, which tells a later save whether the row needs rewriting
+func messageDigest(position int, msg domain.Message) string {
+	data, _ := json.Marshal(msg)
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\x00", position)
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// messageValues returns the column values stored for a message, in the order
+// of messageColumns
+func (b *Backend) messageValues(conversationID string, position int, msg domain.Message, digest string) []interface{} {
+	attachmentsJSON, _ := json.Marshal(msg.Attachments)
+	metadataJSON, _ := json.Marshal(msg.Metadata)
+	toolCallsJSON, _ := json.Marshal(msg.ToolCalls)
+	toolResultsJSON, _ := json.Marshal(msg.ToolResults)
+	revisionsJSON, _ := json.Marshal(msg.Revisions)
+	citationsJSON, _ := json.Marshal(msg.Citations)
+	usageJSON, _ := json.Marshal(msg.Usage)
+
+	return []interface{}{
+		msg.ID, conversationID, b.userID, string(msg.Role), msg.Content,
+		msg.Timestamp, string(attachmentsJSON), string(metadataJSON), position,
+		string(toolCallsJSON), string(toolResultsJSON), string(revisionsJSON), string(citationsJSON), string(usageJSON), msg.Pinned,
+		digest,
+	}
+}
+
+// saveMessages writes the messages of a conversation that are new or changed
+// since the last save and deletes the ones that were removed. Rows whose
+// digest matches are left alone. Attachment content must already have been
+// split out with storage.SplitAttachments.
+func (b *Backend) saveMessages(stmts *txStatements, conversation *domain.Conversation) error {
+	stored := make(map[string]string)
+	rows, err := stmts.tx.Query("SELECT id, digest FROM messages WHERE conversation_id = ? AND user_id = ?", conversation.ID, b.userID)
+	if err != nil {
+		return fmt.Errorf("failed to read stored messages: %w", err)
+	}
+	for rows.Next() {
+		var id string
+		var digest sql.NullString
+		if err := rows.Scan(&id, &digest); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read stored messages: %w", err)
+		}
+		stored[id] = digest.String
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read stored messages: %w", err)
+	}
+
+	var (
+		written  [][]interface{}
+		messages []domain.Message // the messages of the written rows
+		stale    []string         // stored rows that are replaced or removed
+		added    []string         // IDs of the written rows
+	)
+	current := make(map[string]bool, len(conversation.Messages))
+	for idx, msg := range conversation.Messages {
+		current[msg.ID] = true
+		digest := messageDigest(idx, msg)
+		storedDigest, ok := stored[msg.ID]
+		if ok && storedDigest == digest {
+			continue
+		}
+		if ok {
+			stale = append(stale, msg.ID)
+		}
+		written = append(written, b.messageValues(conversation.ID, idx, msg, digest))
+		messages = append(messages, msg)
+		added = append(added, msg.ID)
+	}
+	for id := range stored {
+		if !current[id] {
+			stale = append(stale, id)
+		}
+	}
+
+	if len(stale) == 0 && len(written) == 0 {
+		return nil
+	}
+
+	if err := b.releaseBlobs(stmts, stale); err != nil {
+		return err
+	}
+	if err := stmts.execBatches(idRows(stale), func(n int) string {
+		return "DELETE FROM messages WHERE conversation_id = ? AND user_id = ? AND id IN " + idList(n)
+	}, conversation.ID, b.userID); err != nil {
+		return fmt.Errorf("failed to delete messages: %w", err)
+	}
+
+	if err := stmts.execBatches(written, func(n int) string {
+		return fmt.Sprintf("INSERT INTO messages (%s) VALUES %s", strings.Join(messageColumns, ", "), placeholders(n, len(messageColumns)))
+	}); err != nil {
+		return fmt.Errorf("failed to save message: %w", err)
+	}
+	if err := b.referenceBlobs(stmts, messages); err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		if err := pruneBlobs(stmts.tx); err != nil {
+			return err
+		}
+	}
+
+	b.updateSearchIndex(stmts, conversation.ID, added, len(stale) > 0)
+	return nil
+}
+
+// updateSearchIndex keeps the FTS table in step with the messages. Appended
+// messages are indexed on their own; when messages were changed or removed
+// the conversation is indexed again. Errors are ignored because the FTS table
+// may not exist.
+func (b *Backend) updateSearchIndex(stmts *txStatements, conversationID string, added []string, reindex bool) {
+	const insert = `INSERT INTO messages_fts (conversation_id, user_id, content, role)
+		SELECT conversation_id, user_id, content, role FROM messages
+		WHERE conversation_id = ? AND user_id = ?`
+
+	if reindex {
+		stmts.tx.Exec("DELETE FROM messages_fts WHERE conversation_id = ? AND user_id = ?", conversationID, b.userID)
+		stmts.tx.Exec(insert, conversationID, b.userID)
+		return
+	}
+	stmts.execBatches(idRows(added), func(n int) string {
+		return insert + " AND id IN " + idList(n)
+	}, conversationID, b.userID)
+}
+
+// saveTags replaces the tag rows of a session when its tags changed
+func (b *Backend) saveTags(stmts *txStatements, sessionID string, tags []string) error {
+	rows, err := stmts.tx.Query("SELECT tag FROM tags WHERE session_id = ? AND user_id = ?", sessionID, b.userID)
+	if err != nil {
+		return fmt.Errorf("failed to read stored tags: %w", err)
+	}
+	var stored []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read stored tags: %w", err)
+		}
+		stored = append(stored, tag)
+	}
+	rows.Close()
+
+	// Tags are a set in the table, so compare them sorted and without duplicates
+	unique := make(map[string]bool, len(tags))
+	var wanted []string
+	for _, tag := range tags {
+		if !unique[tag] {
+			unique[tag] = true
+			wanted = append(wanted, tag)
+		}
+	}
+	sort.Strings(stored)
+	sort.Strings(wanted)
+	if strings.Join(stored, "\x00") == strings.Join(wanted, "\x00") && len(stored) == len(wanted) {
+		return nil
+	}
+
+	if err := stmts.exec("DELETE FROM tags WHERE session_id = ? AND user_id = ?", sessionID, b.userID); err != nil {
+		return fmt.Errorf("failed to delete tags: %w", err)
+	}
+
+	values := make([][]interface{}, len(wanted))
+	for i, tag := range wanted {
+		values[i] = []interface{}{sessionID, b.userID, tag}
+	}
+	if err := stmts.execBatches(values, func(n int) string {
+		return "INSERT INTO tags (session_id, user_id, tag) VALUES " + placeholders(n, 3)
+	}); err != nil {
+		return fmt.Errorf("failed to save tag: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// ABOUTME: Content-addressed attachment storage for the SQLite backend
+// ABOUTME: Keeps attachment bytes in a blobs table keyed by hash, referenced from messages
+
+//go:build sqlite || db
+
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// loadBlob implements domain.ContentLoader for attachments of loaded sessions
+func (b *Backend) loadBlob(hash string) ([]byte, error) {
+	var data []byte
+	err := b.db.QueryRow("SELECT data FROM blobs WHERE hash = ?", hash).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("attachment content %s is missing", hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment content: %w", err)
+	}
+	return data, nil
+}
+
+// writeBlobs stores attachment content keyed by hash. Content that is already
+// stored is left alone.
+func (b *Backend) writeBlobs(stmts *txStatements, blobs map[string][]byte) error {
+	rows := make([][]interface{}, 0, len(blobs))
+	for hash, content := range blobs {
+		rows = append(rows, []interface{}{hash, content, len(content)})
+	}
+	if err := stmts.execBatches(rows, func(n int) string {
+		return "INSERT INTO blobs (hash, data, size) VALUES " + placeholders(n, 3) + " ON CONFLICT(hash) DO NOTHING"
+	}); err != nil {
+		return fmt.Errorf("failed to write attachment content: %w", err)
+	}
+	return nil
+}
+
+// referenceBlobs records which content the attachments of messages use
+func (b *Backend) referenceBlobs(stmts *txStatements, messages []domain.Message) error {
+	var rows [][]interface{}
+	for _, msg := range messages {
+		for _, att := range msg.Attachments {
+			if att.Hash != "" {
+				rows = append(rows, []interface{}{msg.ID, att.Hash})
+			}
+		}
+	}
+	if err := stmts.execBatches(rows, func(n int) string {
+		return "INSERT OR IGNORE INTO message_blobs (message_id, hash) VALUES " + placeholders(n, 2)
+	}); err != nil {
+		return fmt.Errorf("failed to save attachment references: %w", err)
+	}
+	return nil
+}
+
+// releaseBlobs drops the content references of messages that are rewritten
+// or removed
+func (b *Backend) releaseBlobs(stmts *txStatements, messageIDs []string) error {
+	if err := stmts.execBatches(idRows(messageIDs), func(n int) string {
+		return "DELETE FROM message_blobs WHERE message_id IN " + idList(n)
+	}); err != nil {
+		return fmt.Errorf("failed to release attachment references: %w", err)
+	}
+	return nil
+}
+
+// unusedBlobs selects content that no message or snapshot refers to
+const unusedBlobs = "NOT EXISTS (SELECT 1 FROM message_blobs mb WHERE mb.hash = blobs.hash) AND " +
+	"NOT EXISTS (SELECT 1 FROM snapshot_blobs sb WHERE sb.hash = blobs.hash)"
+
+// pruneBlobs deletes content that no message or snapshot refers to any more
+func pruneBlobs(tx *sql.Tx) error {
+	if _, err := tx.Exec("DELETE FROM blobs WHERE " + unusedBlobs); err != nil {
+		return fmt.Errorf("failed to remove unreferenced attachment content: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// ABOUTME: Garbage collection of rows left behind by deleted sessions in the SQLite backend
+// ABOUTME: Removes orphaned conversations, messages, tags, snapshots, search entries and attachments, on demand or periodically
+
+//go:build sqlite || db
+
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// defaultGCInterval is how often orphaned rows are collected when
+// gc_interval is not set
+const defaultGCInterval = 24 * time.Hour
+
+// gcTask names garbage collection in the maintenance table
+const gcTask = "gc"
+
+// Ensure Backend implements storage.GarbageCollector
+var _ storage.GarbageCollector = (*Backend)(nil)
+
+// orphanQueries select the rows of each table that nothing refers to, in the
+// order they are collected. Messages go before their conversations so that
+// they are counted rather than removed by the cascade. A message whose
+// conversation row is missing is kept while a session still refers to the
+// conversation, as in databases created before conversations had rows.
+var orphanQueries = []struct {
+	table string
+	where string
+	count func(*storage.GCReport) *int
+}{
+	{"messages", "NOT EXISTS (SELECT 1 FROM sessions s WHERE s.conversation_id = messages.conversation_id AND s.user_id = messages.user_id)",
+		func(r *storage.GCReport) *int { return &r.Messages }},
+	{"conversations", "NOT EXISTS (SELECT 1 FROM sessions s WHERE s.conversation_id = conversations.id AND s.user_id = conversations.user_id)",
+		func(r *storage.GCReport) *int { return &r.Conversations }},
+	{"tags", "NOT EXISTS (SELECT 1 FROM sessions s WHERE s.id = tags.session_id AND s.user_id = tags.user_id)",
+		func(r *storage.GCReport) *int { return &r.Tags }},
+	{"session_snapshots", "NOT EXISTS (SELECT 1 FROM sessions s WHERE s.id = session_snapshots.session_id AND s.user_id = session_snapshots.user_id)",
+		func(r *storage.GCReport) *int { return &r.Snapshots }},
+	{"message_blobs", "NOT EXISTS (SELECT 1 FROM messages m WHERE m.id = message_blobs.message_id)",
+		func(r *storage.GCReport) *int { return &r.AttachmentRefs }},
+	{"snapshot_blobs", "NOT EXISTS (SELECT 1 FROM session_snapshots ss WHERE ss.id = snapshot_blobs.snapshot_id AND ss.user_id = snapshot_blobs.user_id)",
+		func(r *storage.GCReport) *int { return &r.AttachmentRefs }},
+	{"blobs", unusedBlobs,
+		func(r *storage.GCReport) *int { return &r.Attachments }},
+}
+
+// ftsOrphans selects the search entries of conversations no session refers to
+const ftsOrphans = "NOT EXISTS (SELECT 1 FROM sessions s WHERE s.conversation_id = messages_fts.conversation_id AND s.user_id = messages_fts.user_id)"
+
+// CollectGarbage implements storage.GarbageCollector.CollectGarbage. Rows of
+// every user are collected, since an orphaned row belongs to no session.
+func (b *Backend) CollectGarbage(dryRun bool) (*storage.GCReport, error) {
+	if !dryRun && b.readOnly {
+		return nil, fmt.Errorf("cannot collect garbage: %w", storage.ErrReadOnly)
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	report := &storage.GCReport{}
+	for _, q := range orphanQueries {
+		n, err := collectOrphans(tx, q.table, q.where, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		*q.count(report) += n
+	}
+	if b.ftsAvailable {
+		if report.SearchEntries, err = collectOrphans(tx, "messages_fts", ftsOrphans, dryRun); err != nil {
+			return nil, err
+		}
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	if _, err := tx.Exec(`INSERT INTO maintenance (task, last_run) VALUES (?, ?)
+		ON CONFLICT(task) DO UPDATE SET last_run = excluded.last_run`, gcTask, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to record garbage collection: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit garbage collection: %w", err)
+	}
+	report.Collected = true
+	if report.Total() > 0 {
+		logging.LogInfo("Collected orphaned rows", "conversations", report.Conversations, "messages", report.Messages,
+			"tags", report.Tags, "snapshots", report.Snapshots, "search_entries", report.SearchEntries, "attachments", report.Attachments)
+	}
+	return report, nil
+}
+
+// collectOrphans counts the rows of table matching where and, unless dryRun
+// is set, deletes them
+func collectOrphans(tx *sql.Tx, table, where string, dryRun bool) (int, error) {
+	var n int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM " + table + " WHERE " + where).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to find orphaned %s: %w", table, err)
+	}
+	if n == 0 || dryRun {
+		return n, nil
+	}
+	if _, err := tx.Exec("DELETE FROM " + table + " WHERE " + where); err != nil {
+		return 0, fmt.Errorf("failed to delete orphaned %s: %w", table, err)
+	}
+	return n, nil
+}
+
+// startGC collects garbage now if it has not run within interval, then
+// again every interval until the backend is closed. Failures are logged;
+// they leave the orphaned rows for the next run.
+func (b *Backend) startGC(interval time.Duration) {
+	if b.gcDue(interval) {
+		if _, err := b.CollectGarbage(false); err != nil {
+			logging.LogWarn("Failed to collect orphaned rows", "error", err)
+		}
+	}
+
+	b.stopGC = make(chan struct{})
+	b.gcDone = make(chan struct{})
+	go func() {
+		defer close(b.gcDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := b.CollectGarbage(false); err != nil {
+					logging.LogWarn("Failed to collect orphaned rows", "error", err)
+				}
+			case <-b.stopGC:
+				return
+			}
+		}
+	}()
+}
+
+// gcDue reports whether garbage was last collected longer than interval ago
+func (b *Backend) gcDue(interval time.Duration) bool {
+	var lastRun time.Time
+	err := b.db.QueryRow("SELECT last_run FROM maintenance WHERE task = ?", gcTask).Scan(&lastRun)
+	if err == sql.ErrNoRows {
+		return true
+	}
+	if err != nil {
+		logging.LogDebug("Failed to read last garbage collection", "error", err)
+		return false
+	}
+	return time.Since(lastRun) >= interval
+}
@@ -0,0 +1,222 @@
+// ABOUTME: Versioned schema migrations for the SQLite storage backend
+// ABOUTME: Applies the numbered SQL files in migrations/ in order and records them in schema_migrations
+
+//go:build sqlite || db
+
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// migrationFiles holds the schema migrations. Each file is named
+// NNNN_description.sql and is applied once, in order of its number. A new
+// schema change is a new file; applied files must not be edited.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is one numbered schema change
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations returns the embedded migrations sorted by version
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		prefix, name, found := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %q: want NNNN_description.sql", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %q and %q have the same version", other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		data, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// schemaVersion returns the version of the last migration applied, or 0 for
+// a new database or one created before versioned migrations
+func (b *Backend) schemaVersion() (int, error) {
+	var version int
+	if err := b.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// migrate applies the migrations the database has not had yet, each in its
+// own transaction
+func (b *Backend) migrate() error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied TIMESTAMP NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	current, err := b.schemaVersion()
+	if err != nil {
+		return err
+	}
+	if latest := migrations[len(migrations)-1].version; current > latest {
+		return fmt.Errorf("database schema version %d is newer than this version of magellai supports (%d)", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := b.applyMigration(m); err != nil {
+			return err
+		}
+		logging.LogInfo("Applied database migration", "version", m.version, "name", m.name)
+	}
+	return nil
+}
+
+// checkSchema verifies, without writing, that a database opened read-only
+// has every migration, and finds out whether it has the FTS table
+func (b *Backend) checkSchema() error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	latest := migrations[len(migrations)-1].version
+
+	var tables int
+	if err := b.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'").Scan(&tables); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	current := 0
+	if tables > 0 {
+		if current, err = b.schemaVersion(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case current > latest:
+		return fmt.Errorf("database schema version %d is newer than this version of magellai supports (%d)", current, latest)
+	case current < latest:
+		return fmt.Errorf("database schema version %d needs upgrading to %d; open it once without read_only: %w", current, latest, storage.ErrReadOnly)
+	}
+
+	var fts int
+	if err := b.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'messages_fts'").Scan(&fts); err == nil {
+		b.ftsAvailable = fts > 0
+	}
+	return nil
+}
+
+// applyMigration runs one migration and records it
+func (b *Backend) applyMigration(m migration) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	// Another process may have applied it while this one waited for the lock
+	var applied int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", m.version).Scan(&applied); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(m.sql); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+	}
+
+	// Databases created before versioned migrations already had the tables
+	// of the first migration, but may be missing columns added since
+	if m.version == 1 {
+		if err := upgradeUnversioned(tx); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied) VALUES (?, ?, ?)", m.version, m.name, time.Now()); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+	return tx.Commit()
+}
+
+// upgradeUnversioned adds the columns that tables of a database created
+// before versioned migrations may lack
+func upgradeUnversioned(tx *sql.Tx) error {
+	if err := addMissingColumns(tx, "sessions", []string{"expires_at TIMESTAMP"}); err != nil {
+		return err
+	}
+	return addMissingColumns(tx, "messages", []string{"tool_calls TEXT", "tool_results TEXT", "revisions TEXT", "citations TEXT", "usage TEXT", "pinned INTEGER DEFAULT 0", "digest TEXT"})
+}
+
+// addMissingColumns adds the columns, given as "name TYPE", that a table
+// does not have yet
+func addMissingColumns(tx *sql.Tx, table string, columns []string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, column := range columns {
+		name := strings.Fields(column)[0]
+		if existing[name] {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, column)); err != nil {
+			return fmt.Errorf("failed to add column %s to %s: %w", name, table, err)
+		}
+	}
+	return nil
+}
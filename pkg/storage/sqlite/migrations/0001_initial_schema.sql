@@ -0,0 +1,94 @@
+-- Sessions, conversations and messages, with the tables used alongside them.
+-- Databases created before versioned migrations already have these tables;
+-- the runner adds any columns they are missing after this migration.
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	name TEXT,
+	config TEXT,
+	created TIMESTAMP,
+	updated TIMESTAMP,
+	metadata TEXT,
+	conversation_id TEXT,
+	tags TEXT,
+	expires_at TIMESTAMP,
+	UNIQUE(user_id, id)
+);
+
+CREATE TABLE IF NOT EXISTS conversations (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	model TEXT,
+	provider TEXT,
+	temperature REAL,
+	max_tokens INTEGER,
+	system_prompt TEXT,
+	created TIMESTAMP,
+	updated TIMESTAMP,
+	metadata TEXT,
+	UNIQUE(user_id, id)
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+	id TEXT PRIMARY KEY,
+	conversation_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	role TEXT NOT NULL,
+	content TEXT,
+	timestamp TIMESTAMP,
+	attachments TEXT,
+	metadata TEXT,
+	position INTEGER,
+	tool_calls TEXT,
+	tool_results TEXT,
+	revisions TEXT,
+	citations TEXT,
+	usage TEXT,
+	pinned INTEGER DEFAULT 0,
+	digest TEXT,
+	FOREIGN KEY (conversation_id, user_id) REFERENCES conversations(id, user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	session_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	tag TEXT NOT NULL,
+	PRIMARY KEY (session_id, user_id, tag),
+	FOREIGN KEY (session_id, user_id) REFERENCES sessions(id, user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS blobs (
+	hash TEXT PRIMARY KEY,
+	data BLOB NOT NULL,
+	size INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS message_blobs (
+	message_id TEXT NOT NULL,
+	hash TEXT NOT NULL,
+	PRIMARY KEY (message_id, hash),
+	FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS indexes (
+	name TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	data BLOB,
+	updated TIMESTAMP,
+	PRIMARY KEY (name, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS templates (
+	name TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	data TEXT NOT NULL,
+	updated TIMESTAMP,
+	PRIMARY KEY (name, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_conversations_user ON conversations(user_id);
+CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages(conversation_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_tags_session ON tags(session_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_message_blobs_hash ON message_blobs(hash);
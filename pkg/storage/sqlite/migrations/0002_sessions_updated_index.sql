@@ -0,0 +1,3 @@
+-- Lists sessions most recently updated first without sorting the whole table.
+
+CREATE INDEX IF NOT EXISTS idx_sessions_updated ON sessions(user_id, updated);
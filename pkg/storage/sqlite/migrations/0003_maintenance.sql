@@ -0,0 +1,7 @@
+-- Records when periodic maintenance such as garbage collection last ran, so
+-- that processes sharing the database do not each repeat it.
+
+CREATE TABLE IF NOT EXISTS maintenance (
+	task TEXT PRIMARY KEY,
+	last_run TIMESTAMP NOT NULL
+);
@@ -0,0 +1,24 @@
+-- Point-in-time copies of sessions, taken periodically and before
+-- destructive operations so that a session can be rolled back. Attachment
+-- content is shared with messages through the blobs table.
+
+CREATE TABLE IF NOT EXISTS session_snapshots (
+	id TEXT NOT NULL,
+	session_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	created TIMESTAMP NOT NULL,
+	reason TEXT,
+	message_count INTEGER,
+	data TEXT NOT NULL,
+	PRIMARY KEY (id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_snapshots_session ON session_snapshots (session_id, user_id, created);
+
+CREATE TABLE IF NOT EXISTS snapshot_blobs (
+	snapshot_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	hash TEXT NOT NULL,
+	PRIMARY KEY (snapshot_id, user_id, hash),
+	FOREIGN KEY (snapshot_id, user_id) REFERENCES session_snapshots(id, user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,212 @@
+// ABOUTME: Connection settings for the SQLite storage backend
+// ABOUTME: Builds the DSN pragmas and connection pool limits from storage config
+
+//go:build sqlite || db
+
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// Defaults that let the REPL and CLI share a database without
+// "database is locked" errors
+const (
+	defaultJournalMode  = "WAL"
+	defaultBusyTimeout  = 5 * time.Second
+	defaultMaxIdleConns = 2
+)
+
+// journalModes are the values SQLite accepts for PRAGMA journal_mode
+var journalModes = map[string]bool{
+	"DELETE":   true,
+	"TRUNCATE": true,
+	"PERSIST":  true,
+	"MEMORY":   true,
+	"WAL":      true,
+	"OFF":      true,
+}
+
+// connectionSettings holds the per-connection pragmas and pool limits
+type connectionSettings struct {
+	journalMode     string
+	busyTimeout     time.Duration
+	foreignKeys     bool
+	maxOpenConns    int // 0 means unlimited
+	maxIdleConns    int
+	connMaxLifetime time.Duration // 0 means connections are reused forever
+	readOnly        bool          // Open with query_only and leave the journal mode alone
+}
+
+// parseConnectionSettings reads the connection settings from storage config.
+// Keys that are not set keep their defaults:
+//
+//	journal_mode       WAL
+//	busy_timeout       5s (a duration string or milliseconds)
+//	foreign_keys       true
+//	max_open_conns     0 (unlimited)
+//	max_idle_conns     2
+//	conn_max_lifetime  0 (a duration string or seconds)
+func parseConnectionSettings(config storage.Config) (connectionSettings, error) {
+	settings := connectionSettings{
+		journalMode:  defaultJournalMode,
+		busyTimeout:  defaultBusyTimeout,
+		foreignKeys:  true,
+		maxIdleConns: defaultMaxIdleConns,
+		readOnly:     config.ReadOnly(),
+	}
+
+	if v, ok := config["journal_mode"]; ok {
+		mode := strings.ToUpper(fmt.Sprint(v))
+		if !journalModes[mode] {
+			return settings, fmt.Errorf("invalid journal_mode %q", v)
+		}
+		settings.journalMode = mode
+	}
+
+	var err error
+	if settings.busyTimeout, err = configDuration(config, "busy_timeout", time.Millisecond, settings.busyTimeout); err != nil {
+		return settings, err
+	}
+	if settings.foreignKeys, err = configBool(config, "foreign_keys", settings.foreignKeys); err != nil {
+		return settings, err
+	}
+	if settings.maxOpenConns, err = configInt(config, "max_open_conns", settings.maxOpenConns); err != nil {
+		return settings, err
+	}
+	if settings.maxIdleConns, err = configInt(config, "max_idle_conns", settings.maxIdleConns); err != nil {
+		return settings, err
+	}
+	if settings.connMaxLifetime, err = configDuration(config, "conn_max_lifetime", time.Second, settings.connMaxLifetime); err != nil {
+		return settings, err
+	}
+
+	return settings, nil
+}
+
+// dsn returns the data source name that opens dbPath with the pragmas
+// applied to every connection in the pool
+func (s connectionSettings) dsn(dbPath string) string {
+	foreignKeys := 0
+	if s.foreignKeys {
+		foreignKeys = 1
+	}
+
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", s.busyTimeout.Milliseconds()))
+	if s.readOnly {
+		// Setting the journal mode writes to the database, so a read-only
+		// connection uses whatever mode it was created with
+		query.Add("_pragma", "query_only(1)")
+		return dbPath + "?" + query.Encode()
+	}
+	query.Add("_pragma", fmt.Sprintf("journal_mode(%s)", s.journalMode))
+	query.Add("_pragma", fmt.Sprintf("foreign_keys(%d)", foreignKeys))
+	// Take the write lock when a transaction begins, so that two writers
+	// wait on busy_timeout instead of failing when one upgrades its read lock
+	query.Set("_txlock", "immediate")
+
+	return dbPath + "?" + query.Encode()
+}
+
+// applyPool sets the connection pool limits on db
+func (s connectionSettings) applyPool(db *sql.DB) {
+	db.SetMaxOpenConns(s.maxOpenConns)
+	db.SetMaxIdleConns(s.maxIdleConns)
+	db.SetConnMaxLifetime(s.connMaxLifetime)
+}
+
+// configInt reads a non-negative integer setting
+func configInt(config storage.Config, key string, def int) (int, error) {
+	v, ok := config[key]
+	if !ok {
+		return def, nil
+	}
+
+	var n int
+	switch value := v.(type) {
+	case int:
+		n = value
+	case int64:
+		n = int(value)
+	case float64:
+		n = int(value)
+	case string:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return def, fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+		n = parsed
+	default:
+		return def, fmt.Errorf("invalid %s: %v", key, v)
+	}
+
+	if n < 0 {
+		return def, fmt.Errorf("invalid %s: %d must not be negative", key, n)
+	}
+	return n, nil
+}
+
+// configDuration reads a duration setting given as a duration string such as
+// "5s", or as a number of units
+func configDuration(config storage.Config, key string, unit time.Duration, def time.Duration) (time.Duration, error) {
+	v, ok := config[key]
+	if !ok {
+		return def, nil
+	}
+
+	var d time.Duration
+	switch value := v.(type) {
+	case time.Duration:
+		d = value
+	case string:
+		if n, err := strconv.Atoi(value); err == nil {
+			d = time.Duration(n) * unit
+			break
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return def, fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+		d = parsed
+	default:
+		n, err := configInt(config, key, 0)
+		if err != nil {
+			return def, err
+		}
+		d = time.Duration(n) * unit
+	}
+
+	if d < 0 {
+		return def, fmt.Errorf("invalid %s: %s must not be negative", key, d)
+	}
+	return d, nil
+}
+
+// configBool reads a boolean setting
+func configBool(config storage.Config, key string, def bool) (bool, error) {
+	v, ok := config[key]
+	if !ok {
+		return def, nil
+	}
+
+	switch value := v.(type) {
+	case bool:
+		return value, nil
+	case string:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return def, fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+		return b, nil
+	default:
+		return def, fmt.Errorf("invalid %s: %v", key, v)
+	}
+}
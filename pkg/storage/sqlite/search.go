@@ -0,0 +1,148 @@
+// ABOUTME: Translates structured search queries into SQL for the SQLite backend
+// ABOUTME: Narrows searches with tag and model filters, LIKE patterns and FTS5 matches
+
+//go:build sqlite || db
+
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+)
+
+// searchCandidates returns the IDs of the sessions that may match a query.
+// The conditions are looser than the query where SQL cannot express it
+// exactly, such as dates stored as text, so callers check each candidate
+// with the query.
+func (b *Backend) searchCandidates(q *domain.SearchQuery) (map[string]bool, error) {
+	sqlQuery := `SELECT s.id FROM sessions s
+		LEFT JOIN conversations c ON c.id = s.conversation_id AND c.user_id = s.user_id
+		WHERE s.user_id = ?`
+	args := []interface{}{b.userID}
+
+	for _, tag := range q.Tags {
+		sqlQuery += ` AND EXISTS (SELECT 1 FROM tags t WHERE t.session_id = s.id AND t.user_id = s.user_id AND t.tag = ? COLLATE NOCASE)`
+		args = append(args, tag)
+	}
+	if q.Model != "" {
+		sqlQuery += ` AND (c.model = ? COLLATE NOCASE OR c.provider || '/' || c.model = ? COLLATE NOCASE OR c.model LIKE ? ESCAPE '\')`
+		args = append(args, q.Model, q.Model, "%/"+escapeLike(q.Model))
+	}
+	if q.Provider != "" {
+		sqlQuery += ` AND c.provider = ? COLLATE NOCASE`
+		args = append(args, q.Provider)
+	}
+
+	if q.HasText() || q.Role != "" {
+		clause, clauseArgs := b.textClause(q)
+		sqlQuery += " AND " + clause
+		args = append(args, clauseArgs...)
+	}
+
+	rows, err := b.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search sessions: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to search sessions: %w", err)
+		}
+		candidates[id] = true
+	}
+	return candidates, rows.Err()
+}
+
+// textClause returns the condition for sessions whose name, system prompt,
+// tags or messages may match the text and role of a query
+func (b *Backend) textClause(q *domain.SearchQuery) (string, []interface{}) {
+	patterns := q.TextPatterns()
+	var clauses []string
+	var args []interface{}
+
+	// Fields only match a query that is not limited to a role
+	if q.Role == "" {
+		for _, column := range []string{"s.name", "c.system_prompt", "s.tags"} {
+			clause, clauseArgs := likeAll(column, patterns)
+			clauses = append(clauses, clause)
+			args = append(args, clauseArgs...)
+		}
+	}
+
+	if match, ok := ftsQuery(q); ok && b.ftsAvailable && q.Role == "" {
+		clauses = append(clauses, `c.id IN (SELECT conversation_id FROM messages_fts WHERE messages_fts MATCH ? AND user_id = ?)`)
+		args = append(args, match, b.userID)
+	} else {
+		messageClause := `EXISTS (SELECT 1 FROM messages m WHERE m.conversation_id = c.id AND m.user_id = c.user_id`
+		if q.Role != "" {
+			messageClause += ` AND m.role = ?`
+			args = append(args, string(q.Role))
+		}
+		if len(patterns) > 0 {
+			clause, clauseArgs := likeAll("m.content", patterns)
+			messageClause += " AND " + clause
+			args = append(args, clauseArgs...)
+		}
+		clauses = append(clauses, messageClause+")")
+	}
+
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
+// likeAll returns a condition that column contains every pattern, ignoring case
+func likeAll(column string, patterns []string) (string, []interface{}) {
+	conditions := make([]string, len(patterns))
+	args := make([]interface{}, len(patterns))
+	for i, pattern := range patterns {
+		conditions[i] = column + ` LIKE ? ESCAPE '\'`
+		args[i] = "%" + escapeLike(pattern) + "%"
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")", args
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ftsQuery returns an FTS5 expression matching messages that contain every
+// term as a word prefix and every phrase. It returns false when a term has no
+// words FTS5 can index, in which case LIKE is used instead.
+func ftsQuery(q *domain.SearchQuery) (string, bool) {
+	if !q.HasText() {
+		return "", false
+	}
+
+	var parts []string
+	for _, term := range q.Terms {
+		if !hasWord(term) {
+			return "", false
+		}
+		parts = append(parts, quoteFTS(term)+"*")
+	}
+	for _, phrase := range q.Phrases {
+		if !hasWord(phrase) {
+			return "", false
+		}
+		parts = append(parts, quoteFTS(phrase))
+	}
+	return strings.Join(parts, " "), true
+}
+
+// quoteFTS quotes s as an FTS5 string
+func quoteFTS(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// hasWord returns true if s contains a letter or digit
+func hasWord(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
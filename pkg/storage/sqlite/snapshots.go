@@ -0,0 +1,143 @@
+// ABOUTME: Session snapshots for the SQLite backend
+// ABOUTME: Stores each snapshot as a JSON row, with its attachment content shared through the blobs table
+
+//go:build sqlite || db
+
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
+	"github.com/lexlapax/magellai/pkg/storage"
+)
+
+// Ensure Backend implements storage.SnapshotStore
+var _ storage.SnapshotStore = (*Backend)(nil)
+
+// SaveSnapshot implements storage.SnapshotStore.SaveSnapshot
+func (b *Backend) SaveSnapshot(snapshot *domain.SessionSnapshot) error {
+	if snapshot.Session == nil {
+		return fmt.Errorf("snapshot %s has no session", snapshot.ID)
+	}
+	stripped, blobs := storage.SplitAttachments(snapshot.Session)
+	data, err := json.Marshal(stripped)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	stmts := newTxStatements(tx)
+
+	if err := b.writeBlobs(stmts, blobs); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO session_snapshots (id, session_id, user_id, created, reason, message_count, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		snapshot.ID, snapshot.SessionID, b.userID, snapshot.Created, snapshot.Reason, snapshot.MessageCount, string(data)); err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	for hash := range storage.AttachmentHashes(stripped) {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO snapshot_blobs (snapshot_id, user_id, hash) VALUES (?, ?, ?)",
+			snapshot.ID, b.userID, hash); err != nil {
+			return fmt.Errorf("failed to save snapshot attachment references: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListSnapshots implements storage.SnapshotStore.ListSnapshots
+func (b *Backend) ListSnapshots(sessionID string) ([]*domain.SessionSnapshot, error) {
+	rows, err := b.db.Query(`SELECT id, created, reason, message_count FROM session_snapshots
+		WHERE session_id = ? AND user_id = ? ORDER BY created, id`, sessionID, b.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []*domain.SessionSnapshot{}
+	for rows.Next() {
+		snapshot := &domain.SessionSnapshot{SessionID: sessionID}
+		var reason sql.NullString
+		var messageCount sql.NullInt64
+		if err := rows.Scan(&snapshot.ID, &snapshot.Created, &reason, &messageCount); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshot.Reason = reason.String
+		snapshot.MessageCount = int(messageCount.Int64)
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// GetSnapshot implements storage.SnapshotStore.GetSnapshot
+func (b *Backend) GetSnapshot(sessionID, snapshotID string) (*domain.SessionSnapshot, error) {
+	snapshot := &domain.SessionSnapshot{ID: snapshotID, SessionID: sessionID}
+	var created time.Time
+	var reason sql.NullString
+	var messageCount sql.NullInt64
+	var data string
+	err := b.db.QueryRow(`SELECT created, reason, message_count, data FROM session_snapshots
+		WHERE id = ? AND session_id = ? AND user_id = ?`, snapshotID, sessionID, b.userID).
+		Scan(&created, &reason, &messageCount, &data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", storage.ErrSnapshotNotFound, snapshotID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	var session domain.Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	storage.SetContentLoaders(&session, b.loadBlob)
+	snapshot.Created = created
+	snapshot.Reason = reason.String
+	snapshot.MessageCount = int(messageCount.Int64)
+	snapshot.Session = &session
+	return snapshot, nil
+}
+
+// DeleteSnapshot implements storage.SnapshotStore.DeleteSnapshot
+func (b *Backend) DeleteSnapshot(sessionID, snapshotID string) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM session_snapshots WHERE id = ? AND session_id = ? AND user_id = ?", snapshotID, sessionID, b.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("%w: %s", storage.ErrSnapshotNotFound, snapshotID)
+	}
+	if _, err := tx.Exec("DELETE FROM snapshot_blobs WHERE snapshot_id = ? AND user_id = ?", snapshotID, b.userID); err != nil {
+		return fmt.Errorf("failed to release snapshot attachment references: %w", err)
+	}
+	if err := pruneBlobs(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteSnapshots removes every snapshot of a session within a transaction
+func (b *Backend) deleteSnapshots(tx *sql.Tx, sessionID string) error {
+	if _, err := tx.Exec(`DELETE FROM snapshot_blobs WHERE user_id = ? AND snapshot_id IN
+		(SELECT id FROM session_snapshots WHERE session_id = ? AND user_id = ?)`, b.userID, sessionID, b.userID); err != nil {
+		return fmt.Errorf("failed to release snapshot attachment references: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM session_snapshots WHERE session_id = ? AND user_id = ?", sessionID, b.userID); err != nil {
+		return fmt.Errorf("failed to delete snapshots: %w", err)
+	}
+	return nil
+}
@@ -25,8 +25,14 @@ import (
 
 // Backend implements the storage.Backend interface using SQLite
 type Backend struct {
-	db     *sql.DB
-	userID string
+	db           *sql.DB
+	userID       string
+	ftsAvailable bool
+	readOnly     bool
+
+	// stopGC ends periodic garbage collection, which closes gcDone when done
+	stopGC chan struct{}
+	gcDone chan struct{}
 }
 
 // Ensure Backend implements storage.Backend
@@ -44,17 +50,34 @@ func New(config storage.Config) (storage.Backend, error) {
 		dbPath = filepath.Join(home, ".config", "magellai", "sessions.db")
 	}
 
-	// Ensure directory exists
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	settings, err := parseConnectionSettings(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sqlite settings: %w", err)
+	}
+	gcInterval, err := configDuration(config, "gc_interval", time.Hour, defaultGCInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sqlite settings: %w", err)
+	}
+
+	if settings.readOnly {
+		// Opening a missing database would create it
+		if _, err := os.Stat(dbPath); err != nil {
+			return nil, fmt.Errorf("database %s does not exist: %w", dbPath, storage.ErrReadOnly)
+		}
+	} else {
+		// Ensure directory exists
+		dir := filepath.Dir(dbPath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
 	}
 
 	// Open database
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", settings.dsn(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	settings.applyPool(db)
 
 	// Get current user ID for multi-tenant support
 	currentUser, err := user.Current()
@@ -64,8 +87,9 @@ func New(config storage.Config) (storage.Backend, error) {
 	}
 
 	backend := &Backend{
-		db:     db,
-		userID: userID,
+		db:       db,
+		userID:   userID,
+		readOnly: settings.readOnly,
 	}
 
 	// Initialize database schema
@@ -74,6 +98,11 @@ func New(config storage.Config) (storage.Backend, error) {
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
+	// Collect rows left behind by deleted sessions; 0 turns this off
+	if !settings.readOnly && gcInterval > 0 {
+		backend.startGC(gcInterval)
+	}
+
 	return backend, nil
 }
 
@@ -82,63 +111,14 @@ func init() {
 	storage.RegisterBackend(storage.SQLiteBackend, New)
 }
 
-// initSchema creates the necessary database tables
+// initSchema brings the database schema up to date. A read-only database
+// must already be up to date.
 func (b *Backend) initSchema() error {
-	schemas := []string{
-		`CREATE TABLE IF NOT EXISTS sessions (
-			id TEXT PRIMARY KEY,
-			user_id TEXT NOT NULL,
-			name TEXT,
-			config TEXT,
-			created TIMESTAMP,
-			updated TIMESTAMP,
-			metadata TEXT,
-			conversation_id TEXT,
-			tags TEXT,
-			UNIQUE(user_id, id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS conversations (
-			id TEXT PRIMARY KEY,
-			user_id TEXT NOT NULL,
-			model TEXT,
-			provider TEXT,
-			temperature REAL,
-			max_tokens INTEGER,
-			system_prompt TEXT,
-			created TIMESTAMP,
-			updated TIMESTAMP,
-			metadata TEXT,
-			UNIQUE(user_id, id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS messages (
-			id TEXT PRIMARY KEY,
-			conversation_id TEXT NOT NULL,
-			user_id TEXT NOT NULL,
-			role TEXT NOT NULL,
-			content TEXT,
-			timestamp TIMESTAMP,
-			attachments TEXT,
-			metadata TEXT,
-			position INTEGER,
-			FOREIGN KEY (conversation_id, user_id) REFERENCES conversations(id, user_id) ON DELETE CASCADE
-		)`,
-		`CREATE TABLE IF NOT EXISTS tags (
-			session_id TEXT NOT NULL,
-			user_id TEXT NOT NULL,
-			tag TEXT NOT NULL,
-			PRIMARY KEY (session_id, user_id, tag),
-			FOREIGN KEY (session_id, user_id) REFERENCES sessions(id, user_id) ON DELETE CASCADE
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_conversations_user ON conversations(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages(conversation_id, user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_tags_session ON tags(session_id, user_id)`,
-	}
-
-	for _, schema := range schemas {
-		if _, err := b.db.Exec(schema); err != nil {
-			return fmt.Errorf("failed to create schema: %w", err)
-		}
+	if b.readOnly {
+		return b.checkSchema()
+	}
+	if err := b.migrate(); err != nil {
+		return err
 	}
 
 	// Try to create FTS5 virtual table for search
@@ -159,7 +139,9 @@ func (b *Backend) createFTSTable() {
 
 	if _, err := b.db.Exec(fts5Schema); err != nil {
 		logging.LogDebug("FTS5 table creation failed, falling back to LIKE queries", "error", err)
+		return
 	}
+	b.ftsAvailable = true
 }
 
 // NewSession creates a new session
@@ -195,12 +177,16 @@ func (b *Backend) saveSession(session *domain.Session) error {
 
 	// Insert or update session
 	_, err = tx.Exec(`
-		INSERT OR REPLACE INTO sessions 
-		(id, user_id, name, config, created, updated, metadata, conversation_id, tags)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		INSERT INTO sessions 
+		(id, user_id, name, config, created, updated, metadata, conversation_id, tags, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			user_id = excluded.user_id, name = excluded.name, config = excluded.config,
+			created = excluded.created, updated = excluded.updated, metadata = excluded.metadata,
+			conversation_id = excluded.conversation_id, tags = excluded.tags, expires_at = excluded.expires_at`,
 		session.ID, b.userID, session.Name, string(configJSON),
 		session.Created, session.Updated, string(metadataJSON),
-		session.Conversation.ID, strings.Join(session.Tags, ","),
+		session.Conversation.ID, strings.Join(session.Tags, ","), session.ExpiresAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save session: %w", err)
@@ -209,9 +195,14 @@ func (b *Backend) saveSession(session *domain.Session) error {
 	// Save conversation
 	convMetadataJSON, _ := json.Marshal(session.Conversation.Metadata)
 	_, err = tx.Exec(`
-		INSERT OR REPLACE INTO conversations
+		INSERT INTO conversations
 		(id, user_id, model, provider, temperature, max_tokens, system_prompt, created, updated, metadata)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			user_id = excluded.user_id, model = excluded.model, provider = excluded.provider,
+			temperature = excluded.temperature, max_tokens = excluded.max_tokens,
+			system_prompt = excluded.system_prompt, created = excluded.created,
+			updated = excluded.updated, metadata = excluded.metadata`,
 		session.Conversation.ID, b.userID, session.Conversation.Model,
 		session.Conversation.Provider, session.Conversation.Temperature,
 		session.Conversation.MaxTokens, session.Conversation.SystemPrompt,
@@ -222,48 +213,18 @@ func (b *Backend) saveSession(session *domain.Session) error {
 		return fmt.Errorf("failed to save conversation: %w", err)
 	}
 
-	// Delete existing messages and tags
-	if _, err := tx.Exec("DELETE FROM messages WHERE conversation_id = ? AND user_id = ?", session.Conversation.ID, b.userID); err != nil {
-		return fmt.Errorf("failed to delete messages: %w", err)
-	}
-	if _, err := tx.Exec("DELETE FROM tags WHERE session_id = ? AND user_id = ?", session.ID, b.userID); err != nil {
-		return fmt.Errorf("failed to delete tags: %w", err)
+	// Write only the messages and tags that changed since the last save,
+	// with attachment content kept in the blobs table
+	stmts := newTxStatements(tx)
+	stored, blobs := storage.SplitAttachments(session)
+	if err := b.writeBlobs(stmts, blobs); err != nil {
+		return err
 	}
-
-	// Insert messages
-	for idx, msg := range session.Conversation.Messages {
-		attachmentsJSON, _ := json.Marshal(msg.Attachments)
-		metadataJSON, _ := json.Marshal(msg.Metadata)
-
-		_, err = tx.Exec(`
-			INSERT INTO messages 
-			(id, conversation_id, user_id, role, content, timestamp, attachments, metadata, position)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			msg.ID, session.Conversation.ID, b.userID, string(msg.Role), msg.Content,
-			msg.Timestamp, string(attachmentsJSON), string(metadataJSON), idx,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to save message: %w", err)
-		}
-
-		// Update FTS table if it exists
-		tx.Exec(`
-			INSERT INTO messages_fts (conversation_id, user_id, content)
-			VALUES (?, ?, ?)`,
-			session.Conversation.ID, b.userID, msg.Content,
-		)
+	if err := b.saveMessages(stmts, stored.Conversation); err != nil {
+		return err
 	}
-
-	// Insert tags
-	for _, tag := range session.Tags {
-		_, err = tx.Exec(`
-			INSERT INTO tags (session_id, user_id, tag)
-			VALUES (?, ?, ?)`,
-			session.ID, b.userID, tag,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to save tag: %w", err)
-		}
+	if err := b.saveTags(stmts, session.ID, session.Tags); err != nil {
+		return err
 	}
 
 	return tx.Commit()
@@ -276,9 +237,10 @@ func (b *Backend) Get(id string) (*domain.Session, error) {
 	var configJSON, metadataJSON sql.NullString
 	var conversationID string
 	var tagsStr string
+	var expiresAt sql.NullTime
 
 	row := b.db.QueryRow(`
-		SELECT id, name, config, created, updated, metadata, conversation_id, tags
+		SELECT id, name, config, created, updated, metadata, conversation_id, tags, expires_at
 		FROM sessions 
 		WHERE id = ? AND user_id = ?`,
 		id, b.userID,
@@ -286,7 +248,7 @@ func (b *Backend) Get(id string) (*domain.Session, error) {
 
 	err := row.Scan(
 		&session.ID, &session.Name, &configJSON, &session.Created,
-		&session.Updated, &metadataJSON, &conversationID, &tagsStr,
+		&session.Updated, &metadataJSON, &conversationID, &tagsStr, &expiresAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("%w: %s", storage.ErrSessionNotFound, id)
@@ -295,6 +257,10 @@ func (b *Backend) Get(id string) (*domain.Session, error) {
 		return nil, fmt.Errorf("failed to load session: %w", err)
 	}
 
+	if expiresAt.Valid {
+		session.ExpiresAt = &expiresAt.Time
+	}
+
 	// Unmarshal JSON fields
 	if configJSON.Valid {
 		json.Unmarshal([]byte(configJSON.String), &session.Config)
@@ -354,7 +320,7 @@ func (b *Backend) Get(id string) (*domain.Session, error) {
 
 	// Load messages
 	rows, err := b.db.Query(`
-		SELECT id, role, content, timestamp, attachments, metadata
+		SELECT id, role, content, timestamp, attachments, metadata, tool_calls, tool_results, revisions, citations, usage, pinned
 		FROM messages
 		WHERE conversation_id = ? AND user_id = ?
 		ORDER BY position`,
@@ -369,17 +335,19 @@ func (b *Backend) Get(id string) (*domain.Session, error) {
 	for rows.Next() {
 		var msg domain.Message
 		var roleStr string
-		var attachmentsJSON, msgMetadataJSON sql.NullString
+		var attachmentsJSON, msgMetadataJSON, toolCallsJSON, toolResultsJSON, revisionsJSON, citationsJSON, usageJSON sql.NullString
+		var pinned sql.NullBool
 
 		err := rows.Scan(
 			&msg.ID, &roleStr, &msg.Content, &msg.Timestamp,
-			&attachmentsJSON, &msgMetadataJSON,
+			&attachmentsJSON, &msgMetadataJSON, &toolCallsJSON, &toolResultsJSON, &revisionsJSON, &citationsJSON, &usageJSON, &pinned,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 
 		msg.Role = domain.MessageRole(roleStr)
+		msg.Pinned = pinned.Bool
 
 		if attachmentsJSON.Valid {
 			json.Unmarshal([]byte(attachmentsJSON.String), &msg.Attachments)
@@ -391,11 +359,27 @@ func (b *Backend) Get(id string) (*domain.Session, error) {
 		} else {
 			msg.Metadata = make(map[string]interface{})
 		}
+		if toolCallsJSON.Valid {
+			json.Unmarshal([]byte(toolCallsJSON.String), &msg.ToolCalls)
+		}
+		if toolResultsJSON.Valid {
+			json.Unmarshal([]byte(toolResultsJSON.String), &msg.ToolResults)
+		}
+		if revisionsJSON.Valid {
+			json.Unmarshal([]byte(revisionsJSON.String), &msg.Revisions)
+		}
+		if citationsJSON.Valid {
+			json.Unmarshal([]byte(citationsJSON.String), &msg.Citations)
+		}
+		if usageJSON.Valid {
+			json.Unmarshal([]byte(usageJSON.String), &msg.Usage)
+		}
 
 		conv.Messages = append(conv.Messages, msg)
 	}
 
 	session.Conversation = &conv
+	storage.SetContentLoaders(&session, b.loadBlob)
 
 	return &session, nil
 }
@@ -404,7 +388,7 @@ func (b *Backend) Get(id string) (*domain.Session, error) {
 // List implements storage.Backend.List
 func (b *Backend) List() ([]*domain.SessionInfo, error) {
 	rows, err := b.db.Query(`
-		SELECT s.id, s.name, s.created, s.updated, s.tags,
+		SELECT s.id, s.name, s.created, s.updated, s.tags, s.expires_at,
 		       c.model, c.provider,
 		       COUNT(m.id) as message_count
 		FROM sessions s
@@ -424,14 +408,18 @@ func (b *Backend) List() ([]*domain.SessionInfo, error) {
 	for rows.Next() {
 		var info domain.SessionInfo
 		var tagsStr string
+		var expiresAt sql.NullTime
 
 		err := rows.Scan(
-			&info.ID, &info.Name, &info.Created, &info.Updated, &tagsStr,
+			&info.ID, &info.Name, &info.Created, &info.Updated, &tagsStr, &expiresAt,
 			&info.Model, &info.Provider, &info.MessageCount,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session info: %w", err)
 		}
+		if expiresAt.Valid {
+			info.ExpiresAt = &expiresAt.Time
+		}
 
 		// Parse tags
 		if tagsStr != "" {
@@ -480,20 +468,46 @@ func (b *Backend) Delete(id string) error {
 		return fmt.Errorf("%w: %s", storage.ErrSessionNotFound, id)
 	}
 
+	// Release the attachment content of the messages
+	_, err = tx.Exec("DELETE FROM message_blobs WHERE message_id IN (SELECT id FROM messages WHERE conversation_id = ? AND user_id = ?)", conversationID, b.userID)
+	if err != nil {
+		return fmt.Errorf("failed to release attachment references: %w", err)
+	}
+
 	// Delete conversation (cascades to messages)
 	_, err = tx.Exec("DELETE FROM conversations WHERE id = ? AND user_id = ?", conversationID, b.userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete conversation: %w", err)
 	}
+	if err := b.deleteSnapshots(tx, id); err != nil {
+		return err
+	}
+	if err := pruneBlobs(tx); err != nil {
+		return err
+	}
+	if b.ftsAvailable {
+		if _, err := tx.Exec("DELETE FROM messages_fts WHERE conversation_id = ? AND user_id = ?", conversationID, b.userID); err != nil {
+			return fmt.Errorf("failed to delete search index entries: %w", err)
+		}
+	}
 
 	return tx.Commit()
 }
 
-// SearchSessions searches for sessions by text content
+// SearchSessions searches for sessions matching a query
 // Search implements storage.Backend.Search
 func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
-	// For now, don't use FTS5 - simplify for testing
-	ftsAvailable := false
+	q, err := domain.ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	highlight := q.Highlight()
+
+	// SQL narrows the sessions down; the query decides the exact matches
+	candidates, err := b.searchCandidates(q)
+	if err != nil {
+		return nil, err
+	}
 
 	sessions, err := b.List()
 	if err != nil {
@@ -501,9 +515,11 @@ func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 	}
 
 	var results []*domain.SearchResult
-	lowerQuery := strings.ToLower(query)
 
 	for _, info := range sessions {
+		if !candidates[info.ID] || !q.MatchesSession(info) {
+			continue
+		}
 		session, err := b.Get(info.ID)
 		if err != nil {
 			continue
@@ -512,59 +528,29 @@ func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 		result := domain.NewSearchResult(info)
 
 		// Search in system prompt
-		if session.Conversation.SystemPrompt != "" && strings.Contains(strings.ToLower(session.Conversation.SystemPrompt), lowerQuery) {
+		if session.Conversation.SystemPrompt != "" && q.MatchesField(session.Conversation.SystemPrompt) {
 			result.AddMatch(domain.SearchMatch{
 				Type:    domain.SearchMatchTypeSystemPrompt,
-				Content: extractSnippet(session.Conversation.SystemPrompt, query, 50),
+				Content: extractSnippet(session.Conversation.SystemPrompt, highlight, 50),
 				Context: "System Prompt",
 			})
 		}
 
-		// Search in messages using FTS5 or LIKE
-		if ftsAvailable {
-			rows, err := b.db.Query(`
-				SELECT content, role, position 
-				FROM messages m
-				JOIN messages_fts ON m.conversation_id = messages_fts.conversation_id 
-				    AND m.user_id = messages_fts.user_id
-				WHERE messages_fts MATCH ? 
-				    AND m.conversation_id = ? 
-				    AND m.user_id = ?`,
-				query, session.Conversation.ID, b.userID,
-			)
-			if err == nil {
-				defer rows.Close()
-				for rows.Next() {
-					var content, role string
-					var position int
-					if err := rows.Scan(&content, &role, &position); err == nil {
-						result.AddMatch(domain.SearchMatch{
-							Type:     domain.SearchMatchTypeMessage,
-							Role:     role,
-							Content:  extractSnippet(content, query, 50),
-							Context:  fmt.Sprintf("Message %d (%s)", position+1, role),
-							Position: position,
-						})
-					}
-				}
-			}
-		} else {
-			// Fallback to searching in loaded messages
-			for idx, msg := range session.Conversation.Messages {
-				if strings.Contains(strings.ToLower(msg.Content), lowerQuery) {
-					result.AddMatch(domain.SearchMatch{
-						Type:     domain.SearchMatchTypeMessage,
-						Role:     string(msg.Role),
-						Content:  extractSnippet(msg.Content, query, 50),
-						Context:  fmt.Sprintf("Message %d (%s)", idx+1, msg.Role),
-						Position: idx,
-					})
-				}
+		// Search in messages
+		for idx, msg := range session.Conversation.Messages {
+			if q.MatchesMessage(msg) {
+				result.AddMatch(domain.SearchMatch{
+					Type:     domain.SearchMatchTypeMessage,
+					Role:     string(msg.Role),
+					Content:  extractSnippet(msg.Content, highlight, 50),
+					Context:  fmt.Sprintf("Message %d (%s)", idx+1, msg.Role),
+					Position: idx,
+				})
 			}
 		}
 
 		// Search in session name
-		if strings.Contains(strings.ToLower(session.Name), lowerQuery) {
+		if q.MatchesField(session.Name) {
 			result.AddMatch(domain.SearchMatch{
 				Type:    domain.SearchMatchTypeName,
 				Content: session.Name,
@@ -574,7 +560,7 @@ func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 
 		// Search in tags
 		for _, tag := range session.Tags {
-			if strings.Contains(strings.ToLower(tag), lowerQuery) {
+			if q.MatchesField(tag) {
 				result.AddMatch(domain.SearchMatch{
 					Type:    domain.SearchMatchTypeTag,
 					Content: tag,
@@ -583,6 +569,11 @@ func (b *Backend) Search(query string) ([]*domain.SearchResult, error) {
 			}
 		}
 
+		// A query made only of filters matches every session that passes them
+		for _, match := range q.FilterMatches(info) {
+			result.AddMatch(match)
+		}
+
 		if result.HasMatches() {
 			results = append(results, result)
 		}
@@ -600,6 +591,10 @@ func (b *Backend) ExportSession(id string, format domain.ExportFormat, w io.Writ
 
 	switch format {
 	case domain.ExportFormatJSON:
+		// Exports are self-contained, so include the attachment content
+		if err := storage.LoadAttachmentContent(session); err != nil {
+			return fmt.Errorf("failed to load attachment content: %w", err)
+		}
 		encoder := json.NewEncoder(w)
 		encoder.SetIndent("", "  ")
 		return encoder.Encode(session)
@@ -607,6 +602,21 @@ func (b *Backend) ExportSession(id string, format domain.ExportFormat, w io.Writ
 	case domain.ExportFormatMarkdown:
 		return exportMarkdown(session, w)
 
+	case domain.ExportFormatOrg:
+		return storage.ExportOrg(session, w)
+
+	case domain.ExportFormatText:
+		return storage.ExportText(session, w)
+
+	case domain.ExportFormatJSONL:
+		if err := storage.LoadAttachmentContent(session); err != nil {
+			return fmt.Errorf("failed to load attachment content: %w", err)
+		}
+		return storage.ExportJSONL(session, w, storage.ExportOptions{})
+
+	case domain.ExportFormatCSV:
+		return storage.ExportCSV(session, w, storage.ExportOptions{})
+
 	default:
 		return fmt.Errorf("unsupported export format: %s", format)
 	}
@@ -614,6 +624,11 @@ func (b *Backend) ExportSession(id string, format domain.ExportFormat, w io.Writ
 
 // Close closes the database connection
 func (b *Backend) Close() error {
+	if b.stopGC != nil {
+		close(b.stopGC)
+		<-b.gcDone
+		b.stopGC = nil
+	}
 	return b.db.Close()
 }
 
@@ -686,6 +701,30 @@ func exportMarkdown(session *domain.Session, w io.Writer) error {
 			}
 			fmt.Fprintln(w)
 		}
+
+		if len(msg.ToolCalls) > 0 {
+			fmt.Fprintln(w, "Tool calls:")
+			for _, call := range msg.ToolCalls {
+				fmt.Fprintf(w, "- `%s` %s\n", call.ID, call.String())
+			}
+			fmt.Fprintln(w)
+		}
+
+		if len(msg.ToolResults) > 0 {
+			fmt.Fprintln(w, "Tool results:")
+			for _, result := range msg.ToolResults {
+				fmt.Fprintf(w, "- `%s` (%s): %s\n", result.ToolCallID, result.Status, result.Text())
+			}
+			fmt.Fprintln(w)
+		}
+
+		if len(msg.Citations) > 0 {
+			fmt.Fprintln(w, "Sources:")
+			for _, citation := range msg.Citations {
+				fmt.Fprintln(w, "- "+storage.MarkdownCitation(citation))
+			}
+			fmt.Fprintln(w)
+		}
 	}
 
 	return nil
@@ -785,3 +824,138 @@ func (b *Backend) MergeSessions(targetID, sourceID string, options domain.MergeO
 	logging.LogInfo("Sessions merged successfully", "target", targetID, "source", sourceID, "mergedCount", result.MergedCount)
 	return result, nil
 }
+
+// Ensure Backend implements storage.IndexStore
+var _ storage.IndexStore = (*Backend)(nil)
+
+// SaveIndex implements storage.IndexStore.SaveIndex
+func (b *Backend) SaveIndex(name string, data []byte) error {
+	_, err := b.db.Exec(`INSERT INTO indexes (name, user_id, data, updated) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name, user_id) DO UPDATE SET data = excluded.data, updated = excluded.updated`,
+		name, b.userID, data, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	logging.LogDebug("Saved index", "name", name, "size", len(data))
+	return nil
+}
+
+// LoadIndex implements storage.IndexStore.LoadIndex
+func (b *Backend) LoadIndex(name string) ([]byte, error) {
+	var data []byte
+	err := b.db.QueryRow("SELECT data FROM indexes WHERE name = ? AND user_id = ?", name, b.userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", storage.ErrIndexNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+	return data, nil
+}
+
+// ListIndexes implements storage.IndexStore.ListIndexes
+func (b *Backend) ListIndexes() ([]string, error) {
+	rows, err := b.db.Query("SELECT name FROM indexes WHERE user_id = ? ORDER BY name", b.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan index name: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// DeleteIndex implements storage.IndexStore.DeleteIndex
+func (b *Backend) DeleteIndex(name string) error {
+	result, err := b.db.Exec("DELETE FROM indexes WHERE name = ? AND user_id = ?", name, b.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete index: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", storage.ErrIndexNotFound, name)
+	}
+	return nil
+}
+
+// Ensure Backend implements storage.TemplateStore
+var _ storage.TemplateStore = (*Backend)(nil)
+
+// SaveTemplate implements storage.TemplateStore.SaveTemplate
+func (b *Backend) SaveTemplate(template *domain.SessionTemplate) error {
+	if !template.IsValid() {
+		return fmt.Errorf("invalid template: %q", template.Name)
+	}
+	data, err := json.Marshal(template)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %w", err)
+	}
+	_, err = b.db.Exec(`INSERT INTO templates (name, user_id, data, updated) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name, user_id) DO UPDATE SET data = excluded.data, updated = excluded.updated`,
+		template.Name, b.userID, string(data), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+	logging.LogDebug("Saved template", "name", template.Name)
+	return nil
+}
+
+// GetTemplate implements storage.TemplateStore.GetTemplate
+func (b *Backend) GetTemplate(name string) (*domain.SessionTemplate, error) {
+	var data string
+	err := b.db.QueryRow("SELECT data FROM templates WHERE name = ? AND user_id = ?", name, b.userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", storage.ErrTemplateNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+
+	var template domain.SessionTemplate
+	if err := json.Unmarshal([]byte(data), &template); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template: %w", err)
+	}
+	return &template, nil
+}
+
+// ListTemplates implements storage.TemplateStore.ListTemplates
+func (b *Backend) ListTemplates() ([]*domain.SessionTemplate, error) {
+	rows, err := b.db.Query("SELECT name, data FROM templates WHERE user_id = ? ORDER BY name", b.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*domain.SessionTemplate{}
+	for rows.Next() {
+		var name, data string
+		if err := rows.Scan(&name, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		var template domain.SessionTemplate
+		if err := json.Unmarshal([]byte(data), &template); err != nil {
+			logging.LogWarn("Failed to unmarshal template", "name", name, "error", err)
+			continue
+		}
+		templates = append(templates, &template)
+	}
+	return templates, rows.Err()
+}
+
+// DeleteTemplate implements storage.TemplateStore.DeleteTemplate
+func (b *Backend) DeleteTemplate(name string) error {
+	result, err := b.db.Exec("DELETE FROM templates WHERE name = ? AND user_id = ?", name, b.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", storage.ErrTemplateNotFound, name)
+	}
+	return nil
+}
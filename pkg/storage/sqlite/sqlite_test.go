@@ -9,9 +9,12 @@ package sqlite
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -299,6 +302,64 @@ func TestBackend_SearchSessions(t *testing.T) {
 	assert.Len(t, results, 0)
 }
 
+func TestBackend_SearchQuery(t *testing.T) {
+	backend := setupTestBackend(t)
+	defer backend.Close()
+
+	work := backend.NewSession("Work")
+	work.Conversation.Model = "openai/gpt-4o"
+	work.Conversation.Provider = "openai"
+	work.Tags = []string{"work"}
+	work.Conversation.AddMessage(*domain.NewMessage("w-1", domain.MessageRoleUser, "Draft the release notes"))
+	work.Conversation.AddMessage(*domain.NewMessage("w-2", domain.MessageRoleAssistant, "Here are the release notes for 100% of the changes"))
+	require.NoError(t, backend.Create(work))
+
+	old := backend.NewSession("Old")
+	old.Created = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	old.Conversation.Model = "anthropic/claude-3-haiku"
+	old.Conversation.Provider = "anthropic"
+	old.Tags = []string{"home"}
+	old.Conversation.AddMessage(*domain.NewMessage("o-1", domain.MessageRoleUser, "Which release notes format is best?"))
+	require.NoError(t, backend.Create(old))
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"release notes", []string{work.ID, old.ID}},
+		{"tag:work", []string{work.ID}},
+		{"model:gpt-4o release", []string{work.ID}},
+		{"model:anthropic/claude-3-haiku", []string{old.ID}},
+		{"provider:anthropic", []string{old.ID}},
+		{"role:assistant release", []string{work.ID}},
+		{"role:user format", []string{old.ID}},
+		{`"release notes format"`, []string{old.ID}},
+		{"before:2025-01-01", []string{old.ID}},
+		{"after:2025-01-01 notes", []string{work.ID}},
+		{"100%", []string{work.ID}},
+		{"tag:home draft", nil},
+	}
+
+	// Both the FTS5 and the LIKE paths find the same sessions
+	require.True(t, backend.ftsAvailable)
+	for _, fts := range []bool{true, false} {
+		backend.ftsAvailable = fts
+		for _, tt := range tests {
+			results, err := backend.Search(tt.query)
+			require.NoError(t, err, tt.query)
+
+			var ids []string
+			for _, result := range results {
+				ids = append(ids, result.Session.ID)
+			}
+			assert.ElementsMatch(t, tt.expected, ids, "query %q (fts %v)", tt.query, fts)
+		}
+	}
+
+	_, err := backend.Search("before:soon")
+	assert.ErrorIs(t, err, domain.ErrInvalidSearchQuery)
+}
+
 func TestBackend_ExportSession(t *testing.T) {
 	backend := setupTestBackend(t)
 	defer backend.Close()
@@ -384,6 +445,57 @@ func TestBackend_ConcurrentAccess(t *testing.T) {
 }
 
 // Helper function to setup test backend
+func TestBackend_MessageFieldsRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "old.db")
+
+	// A database created before messages had tool columns gets them on open
+	db, err := sql.Open("sqlite", dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE messages (
+		id TEXT PRIMARY KEY, conversation_id TEXT NOT NULL, user_id TEXT NOT NULL,
+		role TEXT NOT NULL, content TEXT, timestamp TIMESTAMP, attachments TEXT,
+		metadata TEXT, position INTEGER)`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	backend, err := New(storage.Config{"base_dir": tmpDir, "db_path": dbPath})
+	require.NoError(t, err)
+	defer backend.Close()
+
+	session := backend.NewSession("Tool Test")
+	call := domain.NewToolCall("call_1", "get_weather", map[string]interface{}{"city": "Paris"})
+	assistant := domain.NewMessage("msg-1", domain.MessageRoleAssistant, "Checking")
+	assistant.AddToolCall(*call)
+	assistant.Revise("Checking the weather")
+	tool := domain.NewMessage("msg-2", domain.MessageRoleTool, "")
+	tool.AddToolResult(*domain.NewToolError(*call, fmt.Errorf("service down")))
+	tool.AddCitation(*domain.NewFileCitation("notes/paris.md", 3, 7, 0.4))
+	assistant.Usage = &domain.Usage{PromptTokens: 120, CompletionTokens: 8, Cost: 0.002, CostKnown: true, Provider: "openai", Model: "gpt-4o"}
+	assistant.Pinned = true
+	session.SetExpiry(time.Hour)
+	session.Conversation.AddMessage(*assistant)
+	session.Conversation.AddMessage(*tool)
+	require.NoError(t, backend.Create(session))
+
+	loaded, err := backend.Get(session.ID)
+	require.NoError(t, err)
+	require.Len(t, loaded.Conversation.Messages, 2)
+	assert.Equal(t, []domain.ToolCall{*call}, loaded.Conversation.Messages[0].ToolCalls)
+	assert.Equal(t, "Checking", loaded.Conversation.Messages[0].OriginalContent())
+	assert.Equal(t, assistant.Usage, loaded.Conversation.Messages[0].Usage)
+	assert.Nil(t, loaded.Conversation.Messages[1].Usage)
+	assert.True(t, loaded.Conversation.Messages[0].Pinned)
+	require.NotNil(t, loaded.ExpiresAt)
+	assert.WithinDuration(t, *session.ExpiresAt, *loaded.ExpiresAt, time.Second)
+	assert.False(t, loaded.Conversation.Messages[1].Pinned)
+	require.Len(t, loaded.Conversation.Messages[1].ToolResults, 1)
+	result := loaded.Conversation.Messages[1].ToolResults[0]
+	assert.True(t, result.IsError())
+	assert.Equal(t, "service down", result.Error)
+	assert.Equal(t, tool.Citations, loaded.Conversation.Messages[1].Citations)
+}
+
 func setupTestBackend(t *testing.T) *Backend {
 	tmpDir := t.TempDir()
 	dbPath := filepath.Join(tmpDir, "test.db")
@@ -399,3 +511,639 @@ func setupTestBackend(t *testing.T) *Backend {
 
 	return backend.(*Backend)
 }
+
+func TestBackend_Indexes(t *testing.T) {
+	backend := setupTestBackend(t)
+	defer backend.Close()
+
+	require.NoError(t, backend.SaveIndex("docs", []byte(`{"chunks":[]}`)))
+	require.NoError(t, backend.SaveIndex("docs", []byte(`{"chunks":[1]}`)))
+	require.NoError(t, backend.SaveIndex("code", []byte(`{}`)))
+
+	data, err := backend.LoadIndex("docs")
+	require.NoError(t, err)
+	assert.Equal(t, `{"chunks":[1]}`, string(data))
+
+	names, err := backend.ListIndexes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code", "docs"}, names)
+
+	require.NoError(t, backend.DeleteIndex("docs"))
+	_, err = backend.LoadIndex("docs")
+	assert.ErrorIs(t, err, storage.ErrIndexNotFound)
+	assert.ErrorIs(t, backend.DeleteIndex("docs"), storage.ErrIndexNotFound)
+}
+
+func TestBackend_Templates(t *testing.T) {
+	backend := setupTestBackend(t)
+	defer backend.Close()
+
+	review := domain.NewSessionTemplate("review")
+	review.SystemPrompt = "You review Go code"
+	review.Model = "openai/gpt-4o"
+	review.Messages = append(review.Messages, *domain.NewMessage("msg-1", domain.MessageRoleUser, "Be strict"))
+	require.NoError(t, backend.SaveTemplate(review))
+	review.Tags = []string{"code"}
+	require.NoError(t, backend.SaveTemplate(review))
+	require.NoError(t, backend.SaveTemplate(domain.NewSessionTemplate("blank")))
+
+	loaded, err := backend.GetTemplate("review")
+	require.NoError(t, err)
+	assert.Equal(t, "You review Go code", loaded.SystemPrompt)
+	assert.Equal(t, []string{"code"}, loaded.Tags)
+	require.Len(t, loaded.Messages, 1)
+	assert.Equal(t, "Be strict", loaded.Messages[0].Content)
+
+	templates, err := backend.ListTemplates()
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "blank", templates[0].Name)
+	assert.Equal(t, "review", templates[1].Name)
+
+	require.NoError(t, backend.DeleteTemplate("review"))
+	_, err = backend.GetTemplate("review")
+	assert.ErrorIs(t, err, storage.ErrTemplateNotFound)
+	assert.ErrorIs(t, backend.DeleteTemplate("review"), storage.ErrTemplateNotFound)
+}
+
+func TestNew_ConnectionSettings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		backend := setupTestBackend(t)
+		defer backend.Close()
+
+		var journalMode string
+		require.NoError(t, backend.db.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+		assert.Equal(t, "wal", journalMode)
+
+		var foreignKeys, busyTimeout int
+		require.NoError(t, backend.db.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys))
+		assert.Equal(t, 1, foreignKeys)
+		require.NoError(t, backend.db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout))
+		assert.Equal(t, 5000, busyTimeout)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		backend, err := New(storage.Config{
+			"base_dir":          tmpDir,
+			"db_path":           filepath.Join(tmpDir, "test.db"),
+			"journal_mode":      "delete",
+			"busy_timeout":      "2s",
+			"foreign_keys":      false,
+			"max_open_conns":    float64(4),
+			"max_idle_conns":    "1",
+			"conn_max_lifetime": 60,
+		})
+		require.NoError(t, err)
+		b := backend.(*Backend)
+		defer b.Close()
+
+		var journalMode string
+		require.NoError(t, b.db.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+		assert.Equal(t, "delete", journalMode)
+
+		var foreignKeys, busyTimeout int
+		require.NoError(t, b.db.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys))
+		assert.Equal(t, 0, foreignKeys)
+		require.NoError(t, b.db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout))
+		assert.Equal(t, 2000, busyTimeout)
+		assert.Equal(t, 4, b.db.Stats().MaxOpenConnections)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for key, value := range map[string]interface{}{
+			"journal_mode":      "fast",
+			"busy_timeout":      "soon",
+			"foreign_keys":      "maybe",
+			"max_open_conns":    -1,
+			"conn_max_lifetime": []string{"1m"},
+		} {
+			tmpDir := t.TempDir()
+			_, err := New(storage.Config{
+				"base_dir": tmpDir,
+				"db_path":  filepath.Join(tmpDir, "test.db"),
+				key:        value,
+			})
+			assert.Error(t, err, key)
+		}
+	})
+}
+
+func TestBackend_ForeignKeys(t *testing.T) {
+	backend := setupTestBackend(t)
+	defer backend.Close()
+
+	session := backend.NewSession("Keys")
+	session.Tags = []string{"one", "two"}
+	session.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "Hello"))
+	require.NoError(t, backend.Create(session))
+
+	// Saving again updates the session in place rather than deleting and
+	// reinserting it, which would cascade to its tags and messages
+	session.Name = "Renamed"
+	session.Conversation.AddMessage(*domain.NewMessage("msg-2", domain.MessageRoleAssistant, "Hi"))
+	require.NoError(t, backend.Update(session))
+
+	loaded, err := backend.Get(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", loaded.Name)
+	assert.ElementsMatch(t, []string{"one", "two"}, loaded.Tags)
+	assert.Len(t, loaded.Conversation.Messages, 2)
+
+	// Deleting the session cascades to its rows
+	require.NoError(t, backend.Delete(session.ID))
+	var count int
+	require.NoError(t, backend.db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&count))
+	assert.Zero(t, count)
+	require.NoError(t, backend.db.QueryRow("SELECT COUNT(*) FROM tags").Scan(&count))
+	assert.Zero(t, count)
+
+	// Messages must belong to an existing conversation
+	_, err = backend.db.Exec("INSERT INTO messages (id, conversation_id, user_id, role) VALUES ('orphan', 'missing', ?, 'user')", backend.userID)
+	assert.Error(t, err)
+}
+
+func TestBackend_SaveWritesOnlyChangedMessages(t *testing.T) {
+	backend := setupTestBackend(t)
+	defer backend.Close()
+
+	// More messages than fit in one batch
+	session := backend.NewSession("Long")
+	for i := 0; i < messageBatchSize*2+5; i++ {
+		session.Conversation.AddMessage(*domain.NewMessage(fmt.Sprintf("msg-%03d", i), domain.MessageRoleUser, fmt.Sprintf("message %d", i)))
+	}
+	require.NoError(t, backend.Create(session))
+
+	rowIDs := func() map[string]int64 {
+		rows, err := backend.db.Query("SELECT id, rowid FROM messages WHERE conversation_id = ?", session.Conversation.ID)
+		require.NoError(t, err)
+		defer rows.Close()
+		ids := make(map[string]int64)
+		for rows.Next() {
+			var id string
+			var rowID int64
+			require.NoError(t, rows.Scan(&id, &rowID))
+			ids[id] = rowID
+		}
+		return ids
+	}
+	before := rowIDs()
+	assert.Len(t, before, len(session.Conversation.Messages))
+
+	// Saving without changes leaves every row alone
+	require.NoError(t, backend.Update(session))
+	assert.Equal(t, before, rowIDs())
+
+	// Editing one message, removing the last and appending another rewrites
+	// only those rows
+	session.Conversation.Messages[3].Content = "edited"
+	session.Conversation.Messages = session.Conversation.Messages[:len(session.Conversation.Messages)-1]
+	session.Conversation.AddMessage(*domain.NewMessage("msg-new", domain.MessageRoleAssistant, "appended"))
+	require.NoError(t, backend.Update(session))
+
+	after := rowIDs()
+	assert.Len(t, after, len(session.Conversation.Messages))
+	assert.NotEqual(t, before["msg-003"], after["msg-003"])
+	assert.Equal(t, before["msg-000"], after["msg-000"])
+	assert.Equal(t, before["msg-100"], after["msg-100"])
+	assert.NotContains(t, after, fmt.Sprintf("msg-%03d", messageBatchSize*2+4))
+
+	loaded, err := backend.Get(session.ID)
+	require.NoError(t, err)
+	require.Len(t, loaded.Conversation.Messages, len(session.Conversation.Messages))
+	assert.Equal(t, "edited", loaded.Conversation.Messages[3].Content)
+	assert.Equal(t, "appended", loaded.Conversation.Messages[len(loaded.Conversation.Messages)-1].Content)
+
+	// The search index holds each message once
+	var indexed int
+	if err := backend.db.QueryRow("SELECT COUNT(*) FROM messages_fts WHERE conversation_id = ?", session.Conversation.ID).Scan(&indexed); err == nil {
+		assert.Equal(t, len(session.Conversation.Messages), indexed)
+	}
+}
+
+func TestBackend_SaveTags(t *testing.T) {
+	backend := setupTestBackend(t)
+	defer backend.Close()
+
+	session := backend.NewSession("Tagged")
+	session.Tags = []string{"b", "a", "a"}
+	require.NoError(t, backend.Create(session))
+
+	tags := func() []string {
+		rows, err := backend.db.Query("SELECT tag FROM tags WHERE session_id = ? ORDER BY tag", session.ID)
+		require.NoError(t, err)
+		defer rows.Close()
+		var tags []string
+		for rows.Next() {
+			var tag string
+			require.NoError(t, rows.Scan(&tag))
+			tags = append(tags, tag)
+		}
+		return tags
+	}
+	assert.Equal(t, []string{"a", "b"}, tags())
+
+	session.Tags = []string{"c"}
+	require.NoError(t, backend.Update(session))
+	assert.Equal(t, []string{"c"}, tags())
+}
+
+func BenchmarkBackend_SaveLongConversation(b *testing.B) {
+	tmpDir := b.TempDir()
+	backend, err := New(storage.Config{"base_dir": tmpDir, "db_path": filepath.Join(tmpDir, "bench.db")})
+	require.NoError(b, err)
+	defer backend.Close()
+
+	session := backend.NewSession("Bench")
+	for i := 0; i < 1000; i++ {
+		session.Conversation.AddMessage(*domain.NewMessage(fmt.Sprintf("msg-%d", i), domain.MessageRoleUser, "a reasonably long message body"))
+	}
+	require.NoError(b, backend.Create(session))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		session.Conversation.AddMessage(*domain.NewMessage(fmt.Sprintf("bench-%d", i), domain.MessageRoleAssistant, "reply"))
+		if err := backend.Update(session); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBackend_AttachmentBlobs(t *testing.T) {
+	backend := setupTestBackend(t)
+	defer backend.Close()
+
+	content := []byte(strings.Repeat("large attachment ", 1000))
+	newSession := func(name string) *domain.Session {
+		session := backend.NewSession(name)
+		msg := domain.NewMessage("msg-"+name, domain.MessageRoleUser, "see attached")
+		msg.AddAttachment(domain.Attachment{ID: "att-1", Type: domain.AttachmentTypeText, Name: "notes.txt", Content: content})
+		session.Conversation.AddMessage(*msg)
+		return session
+	}
+	count := func(table string) int {
+		var n int
+		require.NoError(t, backend.db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	first := newSession("first")
+	require.NoError(t, backend.Create(first))
+	assert.Equal(t, content, first.Conversation.Messages[0].Attachments[0].Content)
+
+	// The message row holds only the hash
+	var attachmentsJSON string
+	require.NoError(t, backend.db.QueryRow("SELECT attachments FROM messages WHERE id = ?", "msg-first").Scan(&attachmentsJSON))
+	assert.Less(t, len(attachmentsJSON), len(content))
+	assert.Contains(t, attachmentsJSON, domain.ContentHash(content))
+
+	// The same content is stored once
+	second := newSession("second")
+	require.NoError(t, backend.Create(second))
+	assert.Equal(t, 1, count("blobs"))
+	assert.Equal(t, 2, count("message_blobs"))
+
+	// Content is loaded when first needed, and kept by saves that never loaded it
+	loaded, err := backend.Get(first.ID)
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Conversation.Messages[0].Attachments[0].Content)
+	loaded.Name = "renamed"
+	require.NoError(t, backend.Update(loaded))
+	loaded, err = backend.Get(first.ID)
+	require.NoError(t, err)
+	got, err := loaded.Conversation.Messages[0].Attachments[0].LoadContent()
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// JSON exports include the content
+	var buf bytes.Buffer
+	require.NoError(t, backend.ExportSession(first.ID, domain.ExportFormatJSON, &buf))
+	var exported domain.Session
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &exported))
+	assert.Equal(t, content, exported.Conversation.Messages[0].Attachments[0].Content)
+
+	// Content is removed with the last message that refers to it
+	require.NoError(t, backend.Delete(first.ID))
+	assert.Equal(t, 1, count("blobs"))
+	second.Conversation.Messages[0].Attachments = nil
+	require.NoError(t, backend.Update(second))
+	assert.Equal(t, 0, count("blobs"))
+	assert.Equal(t, 0, count("message_blobs"))
+}
+
+func TestBackend_Snapshots(t *testing.T) {
+	backend := setupTestBackend(t)
+	defer backend.Close()
+
+	count := func(table string) int {
+		var n int
+		require.NoError(t, backend.db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	content := []byte("attached then")
+	session := backend.NewSession("snapshotted")
+	msg := domain.NewMessage("msg-snap", domain.MessageRoleUser, "see attached")
+	msg.AddAttachment(domain.Attachment{ID: "att-1", Type: domain.AttachmentTypeText, Name: "notes.txt", Content: content})
+	session.Conversation.AddMessage(*msg)
+	require.NoError(t, backend.Create(session))
+
+	first := domain.NewSessionSnapshot(session, domain.SnapshotReasonInterval)
+	first.Created = time.Now().Add(-time.Hour)
+	require.NoError(t, backend.SaveSnapshot(first))
+	second := domain.NewSessionSnapshot(session, domain.SnapshotReasonBeforeMerge)
+	second.ID = first.ID + "_2"
+	require.NoError(t, backend.SaveSnapshot(second))
+
+	snapshots, err := backend.ListSnapshots(session.ID)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, first.ID, snapshots[0].ID)
+	assert.Equal(t, domain.SnapshotReasonBeforeMerge, snapshots[1].Reason)
+	assert.Equal(t, 1, snapshots[1].MessageCount)
+	assert.Nil(t, snapshots[0].Session)
+
+	// The snapshots keep the attachment content after the session drops it
+	session.Conversation.Messages = nil
+	require.NoError(t, backend.Update(session))
+	assert.Equal(t, 1, count("blobs"))
+
+	loaded, err := backend.GetSnapshot(session.ID, first.ID)
+	require.NoError(t, err)
+	require.Len(t, loaded.Session.Conversation.Messages, 1)
+	got, err := loaded.Session.Conversation.Messages[0].Attachments[0].LoadContent()
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	report, err := backend.CollectGarbage(true)
+	require.NoError(t, err)
+	assert.Zero(t, report.Total())
+
+	_, err = backend.GetSnapshot(session.ID, "snap_missing")
+	assert.ErrorIs(t, err, storage.ErrSnapshotNotFound)
+	assert.ErrorIs(t, backend.DeleteSnapshot(session.ID, "snap_missing"), storage.ErrSnapshotNotFound)
+
+	require.NoError(t, backend.DeleteSnapshot(session.ID, first.ID))
+	assert.Equal(t, 1, count("blobs"), "the second snapshot still refers to the content")
+
+	// Deleting the session deletes its snapshots and their content
+	require.NoError(t, backend.Delete(session.ID))
+	assert.Equal(t, 0, count("session_snapshots"))
+	assert.Equal(t, 0, count("snapshot_blobs"))
+	assert.Equal(t, 0, count("blobs"))
+}
+
+func TestBackend_CollectGarbage(t *testing.T) {
+	// Without foreign keys, deleting a session leaves its messages and tags
+	tmpDir := t.TempDir()
+	config := storage.Config{"base_dir": tmpDir, "db_path": filepath.Join(tmpDir, "test.db"), "foreign_keys": false}
+	opened, err := New(config)
+	require.NoError(t, err)
+	backend := opened.(*Backend)
+	defer backend.Close()
+
+	count := func(table string) int {
+		var n int
+		require.NoError(t, backend.db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	kept := backend.NewSession("kept")
+	kept.Tags = []string{"keep"}
+	kept.Conversation.AddMessage(*domain.NewMessage("msg-kept", domain.MessageRoleUser, "hello"))
+	require.NoError(t, backend.Create(kept))
+
+	deleted := backend.NewSession("deleted")
+	deleted.Tags = []string{"gone", "also-gone"}
+	deleted.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "hello"))
+	deleted.Conversation.AddMessage(*domain.NewMessage("msg-2", domain.MessageRoleAssistant, "hi"))
+	require.NoError(t, backend.Create(deleted))
+	require.NoError(t, backend.Delete(deleted.ID))
+	require.Equal(t, 3, count("messages"))
+
+	// A conversation whose session was never written, with search entries
+	_, err = backend.db.Exec("INSERT INTO conversations (id, user_id) VALUES ('stray', ?)", backend.userID)
+	require.NoError(t, err)
+	searchEntries := 0
+	if backend.ftsAvailable {
+		_, err = backend.db.Exec("INSERT INTO messages_fts (conversation_id, user_id, content, role) VALUES ('stray', ?, 'lost words', 'user')", backend.userID)
+		require.NoError(t, err)
+		searchEntries = 1
+	}
+
+	// A snapshot of a session that no longer exists
+	_, err = backend.db.Exec("INSERT INTO session_snapshots (id, session_id, user_id, created, data) VALUES ('snap_stray', 'stray', ?, ?, '{}')",
+		backend.userID, time.Now())
+	require.NoError(t, err)
+
+	report, err := backend.CollectGarbage(true)
+	require.NoError(t, err)
+	assert.Equal(t, storage.GCReport{Conversations: 1, Messages: 2, Tags: 2, Snapshots: 1, SearchEntries: searchEntries}, *report)
+	assert.Equal(t, 3, count("messages"))
+
+	report, err = backend.CollectGarbage(false)
+	require.NoError(t, err)
+	assert.True(t, report.Collected)
+	assert.Equal(t, 6+searchEntries, report.Total())
+	assert.Equal(t, 1, count("messages"))
+	assert.Equal(t, 1, count("conversations"))
+	assert.Equal(t, 1, count("tags"))
+	assert.Equal(t, 0, count("session_snapshots"))
+	if backend.ftsAvailable {
+		assert.Equal(t, 1, count("messages_fts"))
+	}
+
+	loaded, err := backend.Get(kept.ID)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Conversation.Messages, 1)
+	assert.Equal(t, []string{"keep"}, loaded.Tags)
+
+	// The run is recorded, so reopening does not collect again
+	assert.False(t, backend.gcDue(time.Hour))
+	assert.True(t, backend.gcDue(0))
+}
+
+func TestNew_GCInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	_, err := New(storage.Config{"db_path": dbPath, "gc_interval": "often"})
+	assert.Error(t, err)
+
+	// Collection runs when the database is opened
+	backend, err := New(storage.Config{"db_path": dbPath})
+	require.NoError(t, err)
+	_, err = backend.(*Backend).db.Exec("INSERT INTO conversations (id, user_id) VALUES ('stray', 'someone')")
+	require.NoError(t, err)
+	require.NoError(t, backend.Close())
+
+	// but not again within the interval
+	backend, err = New(storage.Config{"db_path": dbPath, "gc_interval": "1h"})
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, backend.(*Backend).db.QueryRow("SELECT COUNT(*) FROM conversations").Scan(&n))
+	assert.Equal(t, 1, n)
+	require.NoError(t, backend.Close())
+
+	// and never when turned off
+	backend, err = New(storage.Config{"db_path": dbPath, "gc_interval": 0})
+	require.NoError(t, err)
+	_, err = backend.(*Backend).db.Exec("DELETE FROM maintenance")
+	require.NoError(t, err)
+	require.NoError(t, backend.Close())
+	backend, err = New(storage.Config{"db_path": dbPath, "gc_interval": 0})
+	require.NoError(t, err)
+	require.NoError(t, backend.(*Backend).db.QueryRow("SELECT COUNT(*) FROM conversations").Scan(&n))
+	assert.Equal(t, 1, n)
+	require.NoError(t, backend.Close())
+
+	backend, err = New(storage.Config{"db_path": dbPath})
+	require.NoError(t, err)
+	require.NoError(t, backend.(*Backend).db.QueryRow("SELECT COUNT(*) FROM conversations").Scan(&n))
+	assert.Zero(t, n)
+	require.NoError(t, backend.Close())
+}
+
+func TestBackend_ReadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+	readOnly := storage.Config{"base_dir": tmpDir, "db_path": dbPath, "read_only": true}
+
+	// A missing database is not created
+	_, err := New(readOnly)
+	assert.ErrorIs(t, err, storage.ErrReadOnly)
+	_, statErr := os.Stat(dbPath)
+	assert.True(t, os.IsNotExist(statErr))
+
+	writable, err := New(storage.Config{"base_dir": tmpDir, "db_path": dbPath})
+	require.NoError(t, err)
+	session := writable.NewSession("Shared")
+	session.Conversation.AddMessage(*domain.NewMessage("msg-1", domain.MessageRoleUser, "hello from the share"))
+	require.NoError(t, writable.Create(session))
+	require.NoError(t, writable.Close())
+
+	backend, err := New(readOnly)
+	require.NoError(t, err)
+	defer backend.Close()
+
+	loaded, err := backend.Get(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Shared", loaded.Name)
+	results, err := backend.Search("share")
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// The connection refuses writes even without the session manager
+	loaded.Name = "Renamed"
+	assert.Error(t, backend.Update(loaded))
+	assert.Error(t, backend.Delete(session.ID))
+
+	t.Run("schema behind", func(t *testing.T) {
+		dir := t.TempDir()
+		oldPath := filepath.Join(dir, "old.db")
+		db, err := sql.Open("sqlite", oldPath)
+		require.NoError(t, err)
+		_, err = db.Exec("CREATE TABLE sessions (id TEXT PRIMARY KEY)")
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		_, err = New(storage.Config{"base_dir": dir, "db_path": oldPath, "read_only": true})
+		assert.ErrorIs(t, err, storage.ErrReadOnly)
+		assert.Contains(t, err.Error(), "needs upgrading")
+	})
+}
+
+func TestBackend_Migrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.version, "migrations are numbered from 1 without gaps")
+	}
+	latest := migrations[len(migrations)-1].version
+
+	t.Run("new database", func(t *testing.T) {
+		backend := setupTestBackend(t)
+		defer backend.Close()
+
+		version, err := backend.schemaVersion()
+		require.NoError(t, err)
+		assert.Equal(t, latest, version)
+
+		var index string
+		require.NoError(t, backend.db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'index' AND name = 'idx_sessions_updated'").Scan(&index))
+	})
+
+	t.Run("reopening applies nothing", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		config := storage.Config{"base_dir": tmpDir, "db_path": filepath.Join(tmpDir, "test.db")}
+		for i := 0; i < 2; i++ {
+			backend, err := New(config)
+			require.NoError(t, err)
+			var count int
+			require.NoError(t, backend.(*Backend).db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count))
+			assert.Equal(t, len(migrations), count)
+			require.NoError(t, backend.Close())
+		}
+	})
+
+	t.Run("database created before versioned migrations", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dbPath := filepath.Join(tmpDir, "old.db")
+		db, err := sql.Open("sqlite", dbPath)
+		require.NoError(t, err)
+		for _, stmt := range []string{
+			`CREATE TABLE sessions (id TEXT PRIMARY KEY, user_id TEXT NOT NULL, name TEXT, config TEXT, created TIMESTAMP,
+				updated TIMESTAMP, metadata TEXT, conversation_id TEXT, tags TEXT, UNIQUE(user_id, id))`,
+			`CREATE TABLE messages (id TEXT PRIMARY KEY, conversation_id TEXT NOT NULL, user_id TEXT NOT NULL, role TEXT NOT NULL,
+				content TEXT, timestamp TIMESTAMP, attachments TEXT, metadata TEXT, position INTEGER)`,
+			`INSERT INTO sessions (id, user_id, name, created, updated, conversation_id, tags) VALUES ('old-1', 'someone', 'Old', '2024-01-01', '2024-01-01', 'conv-1', '')`,
+		} {
+			_, err := db.Exec(stmt)
+			require.NoError(t, err)
+		}
+		require.NoError(t, db.Close())
+
+		backend, err := New(storage.Config{"base_dir": tmpDir, "db_path": dbPath})
+		require.NoError(t, err)
+		b := backend.(*Backend)
+		defer b.Close()
+
+		version, err := b.schemaVersion()
+		require.NoError(t, err)
+		assert.Equal(t, latest, version)
+
+		columns := func(table string) map[string]bool {
+			rows, err := b.db.Query("SELECT name FROM pragma_table_info(?)", table)
+			require.NoError(t, err)
+			defer rows.Close()
+			names := make(map[string]bool)
+			for rows.Next() {
+				var name string
+				require.NoError(t, rows.Scan(&name))
+				names[name] = true
+			}
+			return names
+		}
+		assert.True(t, columns("sessions")["expires_at"])
+		assert.True(t, columns("messages")["pinned"])
+		assert.True(t, columns("messages")["digest"])
+
+		var name string
+		require.NoError(t, b.db.QueryRow("SELECT name FROM sessions WHERE id = 'old-1'").Scan(&name))
+		assert.Equal(t, "Old", name)
+	})
+
+	t.Run("database from a newer version", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		config := storage.Config{"base_dir": tmpDir, "db_path": filepath.Join(tmpDir, "test.db")}
+		backend, err := New(config)
+		require.NoError(t, err)
+		_, err = backend.(*Backend).db.Exec("INSERT INTO schema_migrations (version, name, applied) VALUES (?, 'future', ?)", latest+1, time.Now())
+		require.NoError(t, err)
+		require.NoError(t, backend.Close())
+
+		_, err = New(config)
+		assert.ErrorContains(t, err, "newer than this version")
+	})
+}
@@ -0,0 +1,22 @@
+// ABOUTME: Optional storage interface for persisting session templates
+// ABOUTME: Lets backends keep the templates new sessions start from alongside sessions
+
+package storage
+
+import "github.com/lexlapax/magellai/pkg/domain"
+
+// TemplateStore is implemented by backends that can persist session
+// templates. It is optional; callers check for it with a type assertion.
+type TemplateStore interface {
+	// SaveTemplate stores the template under its name, replacing any existing template
+	SaveTemplate(template *domain.SessionTemplate) error
+
+	// GetTemplate returns the template stored under name, or ErrTemplateNotFound
+	GetTemplate(name string) (*domain.SessionTemplate, error)
+
+	// ListTemplates returns all stored templates, sorted by name
+	ListTemplates() ([]*domain.SessionTemplate, error)
+
+	// DeleteTemplate removes the template stored under name, or returns ErrTemplateNotFound
+	DeleteTemplate(name string) error
+}
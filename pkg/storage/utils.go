@@ -4,19 +4,31 @@
 package storage
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
-	"time"
+
+	"github.com/lexlapax/magellai/pkg/domain"
 )
 
 // GenerateSessionID generates a unique session ID with timestamp and random suffix
 func GenerateSessionID() string {
-	// Generate a random suffix using crypto/rand for guaranteed uniqueness
-	b := make([]byte, 4)
-	if _, err := rand.Read(b); err != nil {
-		// Fallback to timestamp only if random generation fails
-		return fmt.Sprintf("%s-%08d", time.Now().Format("20060102-150405-000000000"), time.Now().UnixNano()%100000000)
+	return domain.NewSessionID()
+}
+
+// MarkdownCitation renders a citation for a Markdown export, linking URLs
+// and noting the confidence when it is known
+func MarkdownCitation(c domain.Citation) string {
+	text := c.String()
+	if c.URL != "" {
+		label := c.Title
+		if label == "" {
+			label = c.URL
+		}
+		text = fmt.Sprintf("[%s](%s)", label, c.URL)
+	} else if c.Title != "" {
+		text = fmt.Sprintf("%s (%s)", c.Title, text)
+	}
+	if c.Confidence > 0 {
+		text += fmt.Sprintf(" — confidence %.2f", c.Confidence)
 	}
-	return fmt.Sprintf("%s-%08s", time.Now().Format("20060102-150405-000000000"), hex.EncodeToString(b))
+	return text
 }
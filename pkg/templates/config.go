@@ -0,0 +1,58 @@
+// ABOUTME: Named prompts stored in configuration under the prompts key
+// ABOUTME: Converts prompts.<name> config entries into templates
+
+package templates
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PromptsKey is the config key holding named prompts, each stored as
+// prompts.<name>.content and an optional prompts.<name>.description
+const PromptsKey = "prompts"
+
+// PromptKey returns the config key for a named prompt
+func PromptKey(name string) string {
+	return PromptsKey + "." + name
+}
+
+// ConfigPrompts converts the value of the prompts config key into
+// templates sorted by name. Entries without content are skipped.
+func ConfigPrompts(raw interface{}) []*Template {
+	entries, _ := raw.(map[string]interface{})
+	prompts := make([]*Template, 0, len(entries))
+	for name, entry := range entries {
+		if t := configPrompt(name, entry); t != nil {
+			prompts = append(prompts, t)
+		}
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts
+}
+
+// ConfigPrompt returns one named prompt from the value of the prompts config key
+func ConfigPrompt(raw interface{}, name string) (*Template, error) {
+	entries, _ := raw.(map[string]interface{})
+	if t := configPrompt(name, entries[name]); t != nil {
+		return t, nil
+	}
+	return nil, fmt.Errorf("%w: prompt '%s'", ErrTemplateNotFound, name)
+}
+
+// configPrompt converts one config entry, which is either a map with
+// content and description or just the content string
+func configPrompt(name string, entry interface{}) *Template {
+	t := &Template{Name: name}
+	switch v := entry.(type) {
+	case string:
+		t.Content = v
+	case map[string]interface{}:
+		t.Content, _ = v["content"].(string)
+		t.Description, _ = v["description"].(string)
+	}
+	if t.Content == "" {
+		return nil
+	}
+	return t
+}
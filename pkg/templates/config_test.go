@@ -0,0 +1,42 @@
+// ABOUTME: Tests for named prompts stored in configuration
+// ABOUTME: Verifies conversion of prompts config entries into templates
+
+package templates
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigPrompts(t *testing.T) {
+	raw := map[string]interface{}{
+		"summarize": map[string]interface{}{
+			"content":     "Summarize {{file}}",
+			"description": "Summarize a file",
+		},
+		"explain": "Explain {{topic}}",
+		"empty":   map[string]interface{}{"description": "no content"},
+	}
+
+	prompts := ConfigPrompts(raw)
+	require.Len(t, prompts, 2)
+	assert.Equal(t, "explain", prompts[0].Name)
+	assert.Equal(t, "Explain {{topic}}", prompts[0].Content)
+	assert.Equal(t, "summarize", prompts[1].Name)
+	assert.Equal(t, "Summarize a file", prompts[1].Description)
+
+	p, err := ConfigPrompt(raw, "summarize")
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize {{file}}", p.Content)
+
+	_, err = ConfigPrompt(raw, "empty")
+	assert.True(t, errors.Is(err, ErrTemplateNotFound))
+	_, err = ConfigPrompt(nil, "summarize")
+	assert.True(t, errors.Is(err, ErrTemplateNotFound))
+	assert.Empty(t, ConfigPrompts(nil))
+
+	assert.Equal(t, "prompts.summarize", PromptKey("summarize"))
+}
@@ -0,0 +1,33 @@
+// ABOUTME: Package for reusable prompt templates with variables
+// ABOUTME: Provides parsing, rendering, and file storage for templates
+
+/*
+Package templates provides reusable prompt templates with variables.
+
+Templates contain {{placeholders}} that are filled in when the template is
+used. A placeholder may declare a default value after a pipe, which is used
+when no value is supplied:
+
+	Summarize {{file}} in {{style|three bullet points}}.
+
+Variable values are given as name=value pairs. A value of @path reads the
+contents of a file, and a value of - reads standard input, so file contents
+can be piped straight into a template:
+
+	vars, err := templates.ParseVariables([]string{"file=@README.md"}, os.Stdin)
+	text, err := tmpl.Render(vars)
+
+Templates are stored as JSON files in the templates directory under the
+user's configuration directory (~/.config/magellai/templates).
+
+Usage:
+
+	store, err := templates.NewDefaultStore()
+	if err != nil {
+	    // Handle error
+	}
+
+	err = store.Save(&templates.Template{Name: "review", Content: "Review {{code}}"})
+	tmpl, err := store.Get("review")
+*/
+package templates
@@ -0,0 +1,21 @@
+// ABOUTME: Error definitions for the templates package
+// ABOUTME: Provides standard errors for template storage and rendering
+
+package templates
+
+import "errors"
+
+// Template-specific errors
+var (
+	// ErrTemplateNotFound indicates the requested template does not exist
+	ErrTemplateNotFound = errors.New("template not found")
+
+	// ErrInvalidTemplateName indicates a template name that cannot be stored
+	ErrInvalidTemplateName = errors.New("invalid template name")
+
+	// ErrMissingVariable indicates a placeholder without a value or default
+	ErrMissingVariable = errors.New("missing template variable")
+
+	// ErrInvalidVariable indicates a malformed name=value variable argument
+	ErrInvalidVariable = errors.New("invalid template variable")
+)
@@ -0,0 +1,34 @@
+// ABOUTME: Tests for templates package error definitions
+// ABOUTME: Validates error messages and wrapping behavior
+
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorConstants(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "ErrTemplateNotFound", err: ErrTemplateNotFound, expected: "template not found"},
+		{name: "ErrInvalidTemplateName", err: ErrInvalidTemplateName, expected: "invalid template name"},
+		{name: "ErrMissingVariable", err: ErrMissingVariable, expected: "missing template variable"},
+		{name: "ErrInvalidVariable", err: ErrInvalidVariable, expected: "invalid template variable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.err.Error())
+
+			wrapped := fmt.Errorf("context: %w", tt.err)
+			assert.True(t, errors.Is(wrapped, tt.err))
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// ABOUTME: File-based storage for prompt templates
+// ABOUTME: Saves each template as a JSON file in the templates directory
+
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/configdir"
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/util/stringutil"
+)
+
+// validName restricts template names to safe file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Store persists templates in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store backed by dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// NewDefaultStore creates a store in the user's templates directory
+func NewDefaultStore() (*Store, error) {
+	paths, err := configdir.GetPaths()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(paths.Templates), nil
+}
+
+// OpenStore creates a store in dir, expanding a leading ~, or in the
+// default templates directory when dir is empty
+func OpenStore(dir string) (*Store, error) {
+	if dir == "" {
+		return NewDefaultStore()
+	}
+	return NewStore(stringutil.ExpandPath(dir)), nil
+}
+
+// Dir returns the directory the store uses
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save writes a template, replacing any existing one with the same name
+func (s *Store) Save(t *Template) error {
+	if err := checkName(t.Name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, configdir.DirPermission); err != nil {
+		return fmt.Errorf("failed to create templates directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode template: %w", err)
+	}
+	if err := os.WriteFile(s.path(t.Name), data, configdir.FilePermission); err != nil {
+		return fmt.Errorf("failed to write template: %w", err)
+	}
+
+	logging.LogDebug("Saved template", "name", t.Name, "dir", s.dir)
+	return nil
+}
+
+// Get loads a template by name
+func (s *Store) Get(name string) (*Template, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	t.Name = name
+	return &t, nil
+}
+
+// List returns all stored templates sorted by name
+func (s *Store) List() ([]*Template, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	var list []*Template
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		t, err := s.Get(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			logging.LogWarn("Skipping unreadable template", "file", entry.Name(), "error", err)
+			continue
+		}
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Delete removes a template
+func (s *Store) Delete(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+func checkName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidTemplateName, name)
+	}
+	return nil
+}
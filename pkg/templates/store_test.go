@@ -0,0 +1,53 @@
+// ABOUTME: Tests for file-based template storage
+// ABOUTME: Verifies save, get, list, delete, and name validation
+
+package templates
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	list, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	require.NoError(t, store.Save(&Template{Name: "review", Description: "Code review", Content: "Review {{code}}"}))
+	require.NoError(t, store.Save(&Template{Name: "explain", Content: "Explain {{topic}}"}))
+
+	got, err := store.Get("review")
+	require.NoError(t, err)
+	assert.Equal(t, "Code review", got.Description)
+	assert.Equal(t, "Review {{code}}", got.Content)
+
+	list, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "explain", list[0].Name)
+
+	// Saving again replaces the template
+	require.NoError(t, store.Save(&Template{Name: "review", Content: "Review {{code}} strictly"}))
+	got, err = store.Get("review")
+	require.NoError(t, err)
+	assert.Equal(t, "Review {{code}} strictly", got.Content)
+
+	require.NoError(t, store.Delete("review"))
+	_, err = store.Get("review")
+	assert.True(t, errors.Is(err, ErrTemplateNotFound))
+	assert.True(t, errors.Is(store.Delete("review"), ErrTemplateNotFound))
+}
+
+func TestStoreInvalidNames(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	for _, name := range []string{"", "../escape", "a/b", ".hidden"} {
+		err := store.Save(&Template{Name: name, Content: "x"})
+		assert.True(t, errors.Is(err, ErrInvalidTemplateName), name)
+	}
+}
@@ -0,0 +1,152 @@
+// ABOUTME: Prompt template parsing and rendering
+// ABOUTME: Fills {{placeholders}} with variables, defaults, and file contents
+
+package templates
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Template is a named prompt with {{placeholders}}
+type Template struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Content     string            `json:"content"`
+	Defaults    map[string]string `json:"defaults,omitempty"`
+}
+
+// Variable describes a placeholder found in a template
+type Variable struct {
+	Name       string
+	Default    string
+	HasDefault bool
+}
+
+// placeholderPattern matches {{name}} and {{name|default}}
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*(?:\|([^}]*))?\}\}`)
+
+// Variables returns the placeholders in the template in order of first use.
+// Defaults stored on the template apply when the placeholder has none.
+func (t *Template) Variables() []Variable {
+	var vars []Variable
+	seen := make(map[string]int)
+
+	for _, m := range placeholderPattern.FindAllStringSubmatchIndex(t.Content, -1) {
+		name := t.Content[m[2]:m[3]]
+		v := Variable{Name: name}
+		if m[4] >= 0 {
+			v.Default = t.Content[m[4]:m[5]]
+			v.HasDefault = true
+		} else if def, ok := t.Defaults[name]; ok {
+			v.Default = def
+			v.HasDefault = true
+		}
+
+		if i, ok := seen[name]; ok {
+			// A later inline default fills in an earlier bare placeholder
+			if !vars[i].HasDefault && v.HasDefault {
+				vars[i] = v
+			}
+			continue
+		}
+		seen[name] = len(vars)
+		vars = append(vars, v)
+	}
+	return vars
+}
+
+// Render substitutes vars into the template. Placeholders without a value
+// use their default; if any have neither, ErrMissingVariable lists them.
+func (t *Template) Render(vars map[string]string) (string, error) {
+	values := make(map[string]string)
+	var missing []string
+	for _, v := range t.Variables() {
+		switch value, ok := vars[v.Name]; {
+		case ok:
+			values[v.Name] = value
+		case v.HasDefault:
+			values[v.Name] = v.Default
+		default:
+			missing = append(missing, v.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrMissingVariable, strings.Join(missing, ", "))
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(t.Content, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		return values[name]
+	}), nil
+}
+
+// ParseVariables parses name=value arguments. A value of @path is replaced
+// by the file's contents and a value of - by everything read from stdin.
+func ParseVariables(args []string, stdin io.Reader) (map[string]string, error) {
+	vars := make(map[string]string, len(args))
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%w: %q (expected name=value)", ErrInvalidVariable, arg)
+		}
+
+		switch {
+		case value == "-":
+			if stdin == nil {
+				return nil, fmt.Errorf("%w: %s: no standard input available", ErrInvalidVariable, name)
+			}
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read stdin for %s: %w", name, err)
+			}
+			value = string(data)
+		case strings.HasPrefix(value, "@"):
+			data, err := os.ReadFile(value[1:])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file for %s: %w", name, err)
+			}
+			value = string(data)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// SplitVariableArgs separates name=value arguments from the rest
+func SplitVariableArgs(args []string) (vars []string, rest []string) {
+	for _, arg := range args {
+		if name, _, ok := strings.Cut(arg, "="); ok && placeholderName(name) {
+			vars = append(vars, arg)
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	return vars, rest
+}
+
+// placeholderName reports whether s is a valid placeholder name
+func placeholderName(s string) bool {
+	return placeholderPattern.MatchString("{{" + s + "}}")
+}
+
+// Describe returns a one-line summary of the template's variables
+func (t *Template) Describe() string {
+	vars := t.Variables()
+	if len(vars) == 0 {
+		return "no variables"
+	}
+	parts := make([]string, len(vars))
+	for i, v := range vars {
+		if v.HasDefault {
+			parts[i] = fmt.Sprintf("%s=%q", v.Name, v.Default)
+		} else {
+			parts[i] = v.Name
+		}
+	}
+	return strings.Join(parts, ", ")
+}
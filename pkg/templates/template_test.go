@@ -0,0 +1,76 @@
+// ABOUTME: Tests for prompt template parsing and rendering
+// ABOUTME: Covers placeholders, defaults, and variable parsing from files and stdin
+
+package templates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateVariables(t *testing.T) {
+	tmpl := &Template{
+		Content:  "Translate {{text}} into {{ language | French }} for {{audience}}. Again: {{text}}",
+		Defaults: map[string]string{"audience": "children", "language": "German"},
+	}
+
+	assert.Equal(t, []Variable{
+		{Name: "text"},
+		{Name: "language", Default: " French ", HasDefault: true},
+		{Name: "audience", Default: "children", HasDefault: true},
+	}, tmpl.Variables())
+	assert.Equal(t, `text, language=" French ", audience="children"`, tmpl.Describe())
+}
+
+func TestTemplateRender(t *testing.T) {
+	tmpl := &Template{Content: "Summarize {{file}} in {{style|three bullets}}."}
+
+	got, err := tmpl.Render(map[string]string{"file": "the report"})
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize the report in three bullets.", got)
+
+	got, err = tmpl.Render(map[string]string{"file": "x", "style": "one line"})
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize x in one line.", got)
+
+	_, err = tmpl.Render(nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrMissingVariable))
+	assert.Contains(t, err.Error(), "file")
+
+	plain := &Template{Content: "No placeholders, just {braces}"}
+	got, err = plain.Render(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "No placeholders, just {braces}", got)
+	assert.Equal(t, "no variables", plain.Describe())
+}
+
+func TestParseVariables(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(file, []byte("file contents"), 0644))
+
+	vars, err := ParseVariables([]string{"a=1", "eq=x=y", "doc=@" + file, "in=-"}, strings.NewReader("piped"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "eq": "x=y", "doc": "file contents", "in": "piped"}, vars)
+
+	_, err = ParseVariables([]string{"novalue"}, nil)
+	assert.True(t, errors.Is(err, ErrInvalidVariable))
+
+	_, err = ParseVariables([]string{"in=-"}, nil)
+	assert.True(t, errors.Is(err, ErrInvalidVariable))
+
+	_, err = ParseVariables([]string{"doc=@" + filepath.Join(t.TempDir(), "missing")}, nil)
+	assert.Error(t, err)
+}
+
+func TestSplitVariableArgs(t *testing.T) {
+	vars, rest := SplitVariableArgs([]string{"name=value", "--model", "a b=c", "x.y=1"})
+	assert.Equal(t, []string{"name=value", "x.y=1"}, vars)
+	assert.Equal(t, []string{"--model", "a b=c"}, rest)
+}
@@ -0,0 +1,115 @@
+// ABOUTME: OpenTelemetry tracing of commands, provider calls, and storage operations
+// ABOUTME: Sets up an OTLP/HTTP span exporter when tracing is configured; spans are no-ops otherwise
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName names the instrumentation that creates magellai's spans
+const tracerName = "github.com/lexlapax/magellai"
+
+// Attribute keys shared by the instrumented packages. Provider calls use
+// the OpenTelemetry semantic conventions for generative AI.
+const (
+	AttrCommand      = attribute.Key("magellai.command")
+	AttrSessionID    = attribute.Key("magellai.session.id")
+	AttrStorage      = attribute.Key("magellai.storage.backend")
+	AttrLatencyMS    = attribute.Key("magellai.latency_ms")
+	AttrEstimated    = attribute.Key("magellai.usage.estimated")
+	AttrProvider     = attribute.Key("gen_ai.system")
+	AttrModel        = attribute.Key("gen_ai.request.model")
+	AttrInputTokens  = attribute.Key("gen_ai.usage.input_tokens")
+	AttrOutputTokens = attribute.Key("gen_ai.usage.output_tokens")
+)
+
+// Config configures span export
+type Config struct {
+	// Endpoint is the OTLP/HTTP collector, as host:port or a URL. Empty uses
+	// OTEL_EXPORTER_OTLP_ENDPOINT, or localhost:4318.
+	Endpoint string
+	// Insecure sends spans over http instead of https to a host:port endpoint
+	Insecure bool
+	// Sampling is the fraction of traces kept, from 0 to 1
+	Sampling float64
+	// ServiceVersion is reported as the service.version resource attribute
+	ServiceVersion string
+}
+
+// Setup exports spans to the configured OTLP collector. It returns a
+// function that flushes pending spans and stops exporting, which must be
+// called before the program exits.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	var opts []otlptracehttp.Option
+	switch {
+	case strings.Contains(cfg.Endpoint, "://"):
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	case cfg.Endpoint != "":
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	return Install(sdktrace.NewBatchSpanProcessor(exporter), cfg), nil
+}
+
+// Install makes processor receive the spans of every traced operation and
+// returns a function that shuts it down
+func Install(processor sdktrace.SpanProcessor, cfg Config) func(context.Context) error {
+	res := resource.NewSchemaless(
+		attribute.String("service.name", "magellai"),
+		attribute.String("service.version", cfg.ServiceVersion),
+	)
+	sampling := cfg.Sampling
+	if sampling <= 0 || sampling > 1 {
+		sampling = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampling))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown
+}
+
+// Start starts a span as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends a span, recording err as its error status when it is not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Latency returns the latency attribute of an operation that started at
+// started
+func Latency(started time.Time) attribute.KeyValue {
+	return AttrLatencyMS.Int64(time.Since(started).Milliseconds())
+}
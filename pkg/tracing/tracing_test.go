@@ -0,0 +1,58 @@
+// ABOUTME: Unit tests for span helpers and tracer provider installation
+// ABOUTME: Records spans in memory to check their names, attributes, and error status
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordSpans installs an in-memory span recorder for the duration of the
+// test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	shutdown := Install(recorder, Config{Sampling: 1})
+	t.Cleanup(func() {
+		_ = shutdown(context.Background())
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+	return recorder
+}
+
+func TestStartEnd(t *testing.T) {
+	recorder := recordSpans(t)
+
+	ctx, parent := Start(context.Background(), "parent", AttrCommand.String("ask"))
+	_, child := Start(ctx, "child")
+	End(child, errors.New("boom"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+	assert.Len(t, spans[0].Events(), 1, "expected the error to be recorded")
+
+	assert.Equal(t, "parent", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+	assert.Contains(t, spans[1].Attributes(), AttrCommand.String("ask"))
+}
+
+func TestLatency(t *testing.T) {
+	attr := Latency(time.Now().Add(-1500 * time.Millisecond))
+	assert.Equal(t, AttrLatencyMS, attr.Key)
+	assert.GreaterOrEqual(t, attr.Value.AsInt64(), int64(1500))
+}
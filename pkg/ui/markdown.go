@@ -0,0 +1,407 @@
+// ABOUTME: Terminal markdown renderer for assistant responses
+// ABOUTME: Renders headings, lists, tables, quotes, and highlighted code blocks with ANSI codes
+
+package ui
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MarkdownRenderer renders markdown to ANSI-styled terminal text.
+// It is line oriented so it can render streamed responses as they arrive:
+// complete lines are written immediately, tables are held until they end,
+// and Flush writes whatever remains.
+type MarkdownRenderer struct {
+	out      io.Writer
+	pending  strings.Builder
+	inCode   bool
+	codeLang string
+	fence    string
+	table    []string
+}
+
+// NewMarkdownRenderer creates a renderer writing to out
+func NewMarkdownRenderer(out io.Writer) *MarkdownRenderer {
+	return &MarkdownRenderer{out: out}
+}
+
+// RenderMarkdown renders a complete markdown document
+func RenderMarkdown(text string) string {
+	var buf strings.Builder
+	r := NewMarkdownRenderer(&buf)
+	_, _ = r.Write([]byte(text))
+	_ = r.Flush()
+	return buf.String()
+}
+
+// Write buffers p and renders every complete line it contains
+func (r *MarkdownRenderer) Write(p []byte) (int, error) {
+	r.pending.Write(p)
+
+	text := r.pending.String()
+	idx := strings.LastIndexByte(text, '\n')
+	if idx < 0 {
+		return len(p), nil
+	}
+
+	r.pending.Reset()
+	r.pending.WriteString(text[idx+1:])
+
+	for _, line := range strings.Split(text[:idx], "\n") {
+		if err := r.renderLine(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush renders any buffered partial line and pending table
+func (r *MarkdownRenderer) Flush() error {
+	if r.pending.Len() > 0 {
+		line := r.pending.String()
+		r.pending.Reset()
+		if err := r.renderLine(line); err != nil {
+			return err
+		}
+	}
+	return r.flushTable()
+}
+
+var (
+	headingPattern    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletPattern     = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	numberedPattern   = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	taskPattern       = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	rulePattern       = regexp.MustCompile(`^\s*([-*_])(\s*([-*_]))*\s*$`)
+	tableSepPattern   = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	inlineCodeRegex   = regexp.MustCompile("`([^`]+)`")
+	boldPattern       = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicPattern     = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*|(^|[^_\w])_([^_\s][^_]*)_`)
+	linkPattern       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	inlinePlaceholder = "\x00"
+)
+
+// renderLine renders a single markdown line
+func (r *MarkdownRenderer) renderLine(line string) error {
+	line = strings.TrimRight(line, "\r")
+	trimmed := strings.TrimSpace(line)
+
+	// Fenced code blocks
+	if r.inCode {
+		if strings.HasPrefix(trimmed, r.fence) && strings.Trim(trimmed, r.fence[:1]) == "" {
+			r.inCode = false
+			return r.writeLine(ColorDim + "└" + strings.Repeat("─", 39) + ColorReset)
+		}
+		return r.writeLine(ColorDim + "│ " + ColorReset + HighlightCode(line, r.codeLang))
+	}
+
+	if fence := codeFence(trimmed); fence != "" {
+		if err := r.flushTable(); err != nil {
+			return err
+		}
+		r.inCode = true
+		r.fence = fence
+		r.codeLang = strings.ToLower(strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])))
+		label := ""
+		if r.codeLang != "" {
+			label = " " + r.codeLang + " "
+		}
+		return r.writeLine(ColorDim + "┌" + label + strings.Repeat("─", max(1, 39-len(label))) + ColorReset)
+	}
+
+	// Tables are buffered until a non-table line so columns can be aligned
+	if strings.HasPrefix(trimmed, "|") {
+		r.table = append(r.table, trimmed)
+		return nil
+	}
+	if err := r.flushTable(); err != nil {
+		return err
+	}
+
+	switch {
+	case trimmed == "":
+		return r.writeLine("")
+
+	case headingPattern.MatchString(trimmed):
+		m := headingPattern.FindStringSubmatch(trimmed)
+		style := ColorBold + ColorBrightCyan
+		if len(m[1]) == 1 {
+			style = ColorBold + ColorBrightMagenta
+		}
+		return r.writeLine(style + stripInlineMarkers(m[2]) + ColorReset)
+
+	case rulePattern.MatchString(trimmed) && len(strings.ReplaceAll(trimmed, " ", "")) >= 3:
+		return r.writeLine(ColorDim + strings.Repeat("─", 40) + ColorReset)
+
+	case strings.HasPrefix(trimmed, ">"):
+		quote := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+		return r.writeLine(ColorDim + "│ " + ColorReset + ColorItalic + renderInline(quote) + ColorReset)
+
+	case bulletPattern.MatchString(line):
+		m := bulletPattern.FindStringSubmatch(line)
+		marker := ColorCyan + "•" + ColorReset
+		text := m[2]
+		if t := taskPattern.FindStringSubmatch(text); t != nil {
+			marker = ColorCyan + "☐" + ColorReset
+			if t[1] != " " {
+				marker = ColorGreen + "☑" + ColorReset
+			}
+			text = t[2]
+		}
+		return r.writeLine(m[1] + "  " + marker + " " + renderInline(text))
+
+	case numberedPattern.MatchString(line):
+		m := numberedPattern.FindStringSubmatch(line)
+		return r.writeLine(m[1] + "  " + ColorCyan + m[2] + ColorReset + " " + renderInline(m[3]))
+	}
+
+	return r.writeLine(renderInline(line))
+}
+
+// flushTable renders buffered table rows with aligned columns
+func (r *MarkdownRenderer) flushTable() error {
+	if len(r.table) == 0 {
+		return nil
+	}
+	lines := r.table
+	r.table = nil
+
+	rows := make([][]string, 0, len(lines))
+	header := -1
+	for i, line := range lines {
+		if i == 1 && tableSepPattern.MatchString(line) {
+			header = 0
+			continue
+		}
+		rows = append(rows, splitTableRow(line))
+	}
+
+	widths := []int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], VisibleWidth(renderInline(cell)))
+		}
+	}
+
+	border := func(left, mid, right string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			parts[i] = strings.Repeat("─", w+2)
+		}
+		return ColorDim + left + strings.Join(parts, mid) + right + ColorReset
+	}
+
+	if err := r.writeLine(border("┌", "┬", "┐")); err != nil {
+		return err
+	}
+	sep := ColorDim + "│" + ColorReset
+	for i, row := range rows {
+		var b strings.Builder
+		b.WriteString(sep)
+		for j, w := range widths {
+			cell := ""
+			if j < len(row) {
+				cell = renderInline(row[j])
+			}
+			if i == header {
+				cell = ColorBold + cell + ColorReset
+			}
+			b.WriteString(" " + cell + strings.Repeat(" ", w-VisibleWidth(cell)) + " " + sep)
+		}
+		if err := r.writeLine(b.String()); err != nil {
+			return err
+		}
+		if i == header {
+			if err := r.writeLine(border("├", "┼", "┤")); err != nil {
+				return err
+			}
+		}
+	}
+	return r.writeLine(border("└", "┴", "┘"))
+}
+
+func (r *MarkdownRenderer) writeLine(s string) error {
+	_, err := io.WriteString(r.out, s+"\n")
+	return err
+}
+
+// codeFence returns the fence marker if line opens a fenced code block
+func codeFence(line string) string {
+	for _, fence := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, fence) {
+			return fence
+		}
+	}
+	return ""
+}
+
+// splitTableRow splits a markdown table row into trimmed cells
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// renderInline styles inline code, bold, italic, and links
+func renderInline(text string) string {
+	// Protect code spans so their contents are not treated as emphasis
+	var spans []string
+	text = inlineCodeRegex.ReplaceAllStringFunc(text, func(m string) string {
+		spans = append(spans, ColorYellow+m[1:len(m)-1]+ColorReset)
+		return inlinePlaceholder
+	})
+
+	text = linkPattern.ReplaceAllString(text, "\033[4m$1"+ColorReset+ColorDim+" ($2)"+ColorReset)
+	text = boldPattern.ReplaceAllString(text, ColorBold+"$1$2"+ColorReset)
+	text = italicPattern.ReplaceAllString(text, "$1$3"+ColorItalic+"$2$4"+ColorReset)
+
+	for _, span := range spans {
+		text = strings.Replace(text, inlinePlaceholder, span, 1)
+	}
+	return text
+}
+
+// stripInlineMarkers removes emphasis markers from heading text
+func stripInlineMarkers(text string) string {
+	return strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+}
+
+// VisibleWidth returns the display width of text, ignoring ANSI codes
+func VisibleWidth(text string) int {
+	return utf8.RuneCountInString(StripColors(text))
+}
+
+// codeKeywords lists the keywords highlighted for each supported language
+var codeKeywords = map[string][]string{
+	"go": {"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+		"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range", "return",
+		"select", "struct", "switch", "type", "var", "nil", "true", "false"},
+	"python": {"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del",
+		"elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in", "is",
+		"lambda", "None", "not", "or", "pass", "raise", "return", "True", "False", "try", "while", "with", "yield"},
+	"javascript": {"async", "await", "break", "case", "catch", "class", "const", "continue", "default",
+		"delete", "else", "export", "extends", "finally", "for", "function", "if", "import", "in",
+		"instanceof", "let", "new", "null", "of", "return", "switch", "this", "throw", "true", "false",
+		"try", "typeof", "undefined", "var", "while", "yield", "interface", "type", "enum"},
+	"rust": {"as", "async", "await", "break", "const", "continue", "crate", "else", "enum", "false",
+		"fn", "for", "if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref",
+		"return", "self", "Self", "static", "struct", "trait", "true", "type", "unsafe", "use", "where", "while"},
+	"shell": {"if", "then", "else", "elif", "fi", "for", "while", "do", "done", "case", "esac", "in",
+		"function", "return", "export", "local", "echo", "exit"},
+	"c": {"break", "case", "char", "class", "const", "continue", "default", "do", "double", "else",
+		"enum", "extends", "final", "float", "for", "if", "implements", "import", "int", "long", "new",
+		"null", "NULL", "private", "protected", "public", "return", "static", "struct", "switch",
+		"this", "throw", "try", "catch", "typedef", "void", "while", "true", "false"},
+	"sql": {"SELECT", "FROM", "WHERE", "INSERT", "INTO", "VALUES", "UPDATE", "SET", "DELETE", "CREATE",
+		"TABLE", "DROP", "ALTER", "JOIN", "LEFT", "RIGHT", "INNER", "OUTER", "ON", "AND", "OR", "NOT",
+		"NULL", "GROUP", "BY", "ORDER", "LIMIT", "AS", "INDEX", "PRIMARY", "KEY"},
+}
+
+// languageAliases maps fence info strings to entries in codeKeywords
+var languageAliases = map[string]string{
+	"golang": "go", "py": "python", "python3": "python",
+	"js": "javascript", "jsx": "javascript", "ts": "javascript", "tsx": "javascript", "typescript": "javascript",
+	"rs": "rust", "sh": "shell", "bash": "shell", "zsh": "shell", "console": "shell",
+	"cpp": "c", "c++": "c", "h": "c", "java": "c", "cs": "c", "csharp": "c", "kotlin": "c",
+}
+
+// lineComments lists the line comment markers recognised per language
+var lineComments = map[string][]string{
+	"python": {"#"},
+	"shell":  {"#"},
+	"sql":    {"--"},
+	"yaml":   {"#"},
+	"toml":   {"#"},
+}
+
+// HighlightCode applies syntax colors to a single line of code.
+// Languages without keyword tables still get strings, numbers, and comments colored.
+func HighlightCode(line, lang string) string {
+	if alias, ok := languageAliases[lang]; ok {
+		lang = alias
+	}
+
+	keywords := make(map[string]bool)
+	for _, kw := range codeKeywords[lang] {
+		keywords[kw] = true
+		if lang == "sql" {
+			keywords[strings.ToLower(kw)] = true
+		}
+	}
+	comments, ok := lineComments[lang]
+	if !ok {
+		comments = []string{"//"}
+	}
+
+	var b strings.Builder
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		rest := string(runes[i:])
+
+		// Line comments run to the end of the line
+		for _, marker := range comments {
+			if strings.HasPrefix(rest, marker) {
+				b.WriteString(ColorBrightBlack + rest + ColorReset)
+				return b.String()
+			}
+		}
+
+		ch := runes[i]
+		switch {
+		case ch == '"' || ch == '\'' || ch == '`':
+			j := i + 1
+			for j < len(runes) && runes[j] != ch {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(runes))
+			b.WriteString(ColorGreen + string(runes[i:j]) + ColorReset)
+			i = j
+
+		case unicode.IsDigit(ch) && (i == 0 || !isIdentRune(runes[i-1])):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.' || runes[j] == '_' ||
+				runes[j] == 'x' || unicode.Is(unicode.ASCII_Hex_Digit, runes[j])) {
+				j++
+			}
+			b.WriteString(ColorMagenta + string(runes[i:j]) + ColorReset)
+			i = j
+
+		case isIdentRune(ch):
+			j := i
+			for j < len(runes) && isIdentRune(runes[j]) {
+				j++
+			}
+			word := string(runes[i:j])
+			if keywords[word] {
+				b.WriteString(ColorBlue + word + ColorReset)
+			} else {
+				b.WriteString(word)
+			}
+			i = j
+
+		default:
+			b.WriteRune(ch)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
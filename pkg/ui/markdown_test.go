@@ -0,0 +1,155 @@
+// ABOUTME: Tests for the terminal markdown renderer
+// ABOUTME: Covers block elements, inline styles, tables, streaming, and code highlighting
+
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdownBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		absent   []string
+	}{
+		{
+			name:     "heading",
+			input:    "## Setup ##",
+			contains: []string{ColorBold + ColorBrightCyan + "Setup" + ColorReset},
+			absent:   []string{"#"},
+		},
+		{
+			name:     "bullet list",
+			input:    "- first\n  * nested",
+			contains: []string{"  " + ColorCyan + "•" + ColorReset + " first", "    " + ColorCyan + "•"},
+		},
+		{
+			name:     "task list",
+			input:    "- [x] done\n- [ ] todo",
+			contains: []string{"☑" + ColorReset + " done", "☐" + ColorReset + " todo"},
+		},
+		{
+			name:     "numbered list",
+			input:    "1. one\n2. two",
+			contains: []string{ColorCyan + "1." + ColorReset + " one", ColorCyan + "2." + ColorReset + " two"},
+		},
+		{
+			name:     "blockquote",
+			input:    "> quoted",
+			contains: []string{"│ " + ColorReset + ColorItalic + "quoted"},
+		},
+		{
+			name:     "horizontal rule",
+			input:    "---",
+			contains: []string{strings.Repeat("─", 40)},
+		},
+		{
+			name:     "fenced code",
+			input:    "```go\nreturn nil\n```",
+			contains: []string{" go ", ColorBlue + "return" + ColorReset},
+			absent:   []string{"```"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := RenderMarkdown(tt.input)
+			for _, want := range tt.contains {
+				assert.Contains(t, out, want)
+			}
+			for _, unwanted := range tt.absent {
+				assert.NotContains(t, out, unwanted)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownInline(t *testing.T) {
+	out := RenderMarkdown("Use **bold**, *italic*, `x := 1` and [docs](https://example.com) in snake_case_names")
+
+	assert.Contains(t, out, ColorBold+"bold"+ColorReset)
+	assert.Contains(t, out, ColorItalic+"italic"+ColorReset)
+	assert.Contains(t, out, ColorYellow+"x := 1"+ColorReset)
+	assert.Contains(t, out, "docs"+ColorReset+ColorDim+" (https://example.com)")
+	assert.Contains(t, out, "snake_case_names")
+}
+
+func TestRenderMarkdownTable(t *testing.T) {
+	out := StripColors(RenderMarkdown("| Name | Size |\n|------|-----:|\n| a | 1024 |\n| longer | 1 |"))
+
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, "│ Name   │ Size │", lines[1])
+	assert.Equal(t, "│ a      │ 1024 │", lines[3])
+	assert.Equal(t, "│ longer │ 1    │", lines[4])
+	for _, line := range lines {
+		assert.Equal(t, VisibleWidth(lines[0]), VisibleWidth(line))
+	}
+}
+
+func TestMarkdownRendererStreaming(t *testing.T) {
+	var buf strings.Builder
+	r := NewMarkdownRenderer(&buf)
+
+	for _, chunk := range []string{"# Ti", "tle\n| a |", "\n| b |\n", "done"} {
+		_, err := r.Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+
+	// The table is held back until a non-table line arrives
+	assert.Contains(t, buf.String(), "Title")
+	assert.NotContains(t, buf.String(), "│ a │")
+
+	require.NoError(t, r.Flush())
+	assert.Equal(t, RenderMarkdown("# Title\n| a |\n| b |\ndone"), buf.String())
+}
+
+func TestHighlightCode(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		lang string
+		want []string
+	}{
+		{
+			name: "go keywords strings and comments",
+			line: `func main() { s := "hi" // greet`,
+			lang: "go",
+			want: []string{ColorBlue + "func" + ColorReset, ColorGreen + `"hi"` + ColorReset, ColorBrightBlack + "// greet"},
+		},
+		{
+			name: "python alias and hash comments",
+			line: "def f(): return 42  # answer",
+			lang: "py",
+			want: []string{ColorBlue + "def" + ColorReset, ColorMagenta + "42" + ColorReset, ColorBrightBlack + "# answer"},
+		},
+		{
+			name: "sql is case insensitive",
+			line: "select id from users -- all",
+			lang: "sql",
+			want: []string{ColorBlue + "select" + ColorReset, ColorBrightBlack + "-- all"},
+		},
+		{
+			name: "unknown language still colors strings",
+			line: `say "hello"`,
+			lang: "",
+			want: []string{"say ", ColorGreen + `"hello"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := HighlightCode(tt.line, tt.lang)
+			for _, want := range tt.want {
+				assert.Contains(t, out, want)
+			}
+			assert.Equal(t, tt.line, StripColors(out))
+		})
+	}
+}
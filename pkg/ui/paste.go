@@ -0,0 +1,115 @@
+// ABOUTME: Bracketed paste support for readline input
+// ABOUTME: Keeps pasted newlines and tabs inside one line until the paste is submitted
+
+package ui
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Terminal control sequences for bracketed paste mode
+const (
+	bracketedPasteOn    = "\x1b[?2004h"
+	bracketedPasteOff   = "\x1b[?2004l"
+	bracketedPasteStart = "\x1b[200~"
+	bracketedPasteEnd   = "\x1b[201~"
+)
+
+// Placeholders shown in the line editor for pasted newlines and tabs. They
+// are turned back into newlines and tabs by RestorePasted.
+const (
+	PastedNewline = '↵'
+	PastedTab     = '⇥'
+)
+
+// pasteReader strips bracketed paste markers from terminal input and replaces
+// newlines and tabs inside a paste, so a multi-line paste is edited and
+// submitted as one line instead of sending each line or triggering completion
+type pasteReader struct {
+	r       io.Reader
+	inPaste bool
+	lastCR  bool
+	partial []byte // a marker split across reads
+	out     []byte
+}
+
+// newPasteReader wraps terminal input with bracketed paste handling
+func newPasteReader(r io.Reader) *pasteReader {
+	return &pasteReader{r: r}
+}
+
+// Read implements io.Reader
+func (p *pasteReader) Read(buf []byte) (int, error) {
+	for len(p.out) == 0 {
+		chunk := make([]byte, len(buf))
+		n, err := p.r.Read(chunk)
+		p.filter(append(p.partial, chunk[:n]...))
+		if err != nil && len(p.out) == 0 {
+			return 0, err
+		}
+	}
+
+	n := copy(buf, p.out)
+	p.out = p.out[n:]
+	return n, nil
+}
+
+// filter moves data to the output, handling paste markers and pasted text
+func (p *pasteReader) filter(data []byte) {
+	p.partial = nil
+	for i := 0; i < len(data); i++ {
+		if data[i] == 0x1b {
+			rest := data[i:]
+			switch {
+			case bytes.HasPrefix(rest, []byte(bracketedPasteStart)):
+				p.inPaste = true
+				i += len(bracketedPasteStart) - 1
+				continue
+			case bytes.HasPrefix(rest, []byte(bracketedPasteEnd)):
+				p.inPaste = false
+				i += len(bracketedPasteEnd) - 1
+				continue
+			case len(rest) > 1 && (isPrefix(rest, bracketedPasteStart) || isPrefix(rest, bracketedPasteEnd)):
+				// Wait for the rest of the marker. A lone escape is a key press.
+				p.partial = append([]byte{}, rest...)
+				return
+			}
+		}
+
+		if !p.inPaste {
+			p.out = append(p.out, data[i])
+			continue
+		}
+
+		switch data[i] {
+		case '\r':
+			p.out = append(p.out, string(PastedNewline)...)
+			p.lastCR = true
+			continue
+		case '\n':
+			if !p.lastCR {
+				p.out = append(p.out, string(PastedNewline)...)
+			}
+		case '\t':
+			p.out = append(p.out, string(PastedTab)...)
+		default:
+			p.out = append(p.out, data[i])
+		}
+		p.lastCR = false
+	}
+}
+
+// isPrefix reports whether data is the start of marker
+func isPrefix(data []byte, marker string) bool {
+	return len(data) < len(marker) && strings.HasPrefix(marker, string(data))
+}
+
+// RestorePasted turns paste placeholders back into newlines and tabs
+func RestorePasted(line string) string {
+	if !strings.ContainsAny(line, string(PastedNewline)+string(PastedTab)) {
+		return line
+	}
+	return strings.NewReplacer(string(PastedNewline), "\n", string(PastedTab), "\t").Replace(line)
+}
@@ -0,0 +1,76 @@
+// ABOUTME: Tests for bracketed paste handling
+// ABOUTME: Verifies markers are stripped and pasted newlines survive as one line
+
+package ui
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasteReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "typed input is unchanged",
+			input:    "hello\r",
+			expected: "hello\r",
+		},
+		{
+			name:     "pasted newlines and tabs become placeholders",
+			input:    "see \x1b[200~func f() {\r\n\treturn\r\n}\x1b[201~\r",
+			expected: "see func f() {↵⇥return↵}\r",
+		},
+		{
+			name:     "bare line feeds in a paste",
+			input:    "\x1b[200~a\nb\x1b[201~",
+			expected: "a↵b",
+		},
+		{
+			name:     "other escape sequences pass through",
+			input:    "\x1b[A\x1b",
+			expected: "\x1b[A\x1b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := io.ReadAll(newPasteReader(strings.NewReader(tt.input)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(out))
+
+		})
+	}
+}
+
+func TestPasteReader_splitMarkers(t *testing.T) {
+	// Markers split across reads are still recognized
+	input := io.MultiReader(
+		strings.NewReader("x\x1b[20"),
+		strings.NewReader("0~a\rb\x1b["),
+		strings.NewReader("201~\r"),
+	)
+	out, err := io.ReadAll(newPasteReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, "xa↵b\r", string(out))
+
+	// A lone escape at the end of a read is a key press and is not held back
+	reader := newPasteReader(iotest.OneByteReader(strings.NewReader("\x1bi")))
+	buf := make([]byte, 8)
+	n, err := reader.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "\x1b", string(buf[:n]))
+}
+
+func TestRestorePasted(t *testing.T) {
+	assert.Equal(t, "func f() {\n\treturn\n}", RestorePasted("func f() {↵⇥return↵}"))
+	assert.Equal(t, "plain", RestorePasted("plain"))
+}
@@ -5,25 +5,49 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/chzyer/readline"
 	"github.com/lexlapax/magellai/internal/logging"
 	"github.com/lexlapax/magellai/pkg/command"
 )
 
+// ErrInterrupt is returned by ReadLine when the user presses Ctrl-C.
+// The partially typed line is returned alongside it.
+var ErrInterrupt = readline.ErrInterrupt
+
+// Keybinding modes for line editing
+const (
+	KeybindingsEmacs = "emacs"
+	KeybindingsVi    = "vi"
+)
+
+// Prompt prefixes showing the current vi mode
+const (
+	ViInsertIndicator = "[I] "
+	ViNormalIndicator = "[N] "
+)
+
 // ReadlineConfig contains configuration for readline
 type ReadlineConfig struct {
 	Prompt           string
 	HistoryFile      string
 	EnableCompletion bool
 	MultilineMode    bool
+	Keybindings      string // emacs (default) or vi
+	BracketedPaste   bool   // Keep multi-line pastes in one message
 }
 
 // ReadlineInterface wraps readline functionality
 type ReadlineInterface struct {
 	Instance *readline.Instance
 	config   *ReadlineConfig
+	prompt   string
+	viMode   *viModeTracker
+	lastLine string // Last line saved to history
 }
 
 // NewReadlineInterface creates a new readline interface
@@ -31,10 +55,27 @@ func NewReadlineInterface(config *ReadlineConfig) (*ReadlineInterface, error) {
 	logging.LogDebug("Creating readline interface", "prompt", config.Prompt)
 
 	// Create readline config
+	// History is saved by ReadLine so repeated lines are stored once.
+	// Ctrl-R searches it, ignoring case.
 	readlineConfig := &readline.Config{
-		Prompt:      config.Prompt,
-		HistoryFile: config.HistoryFile,
-		EOFPrompt:   "exit",
+		Prompt:                 config.Prompt,
+		HistoryFile:            config.HistoryFile,
+		HistorySearchFold:      true,
+		DisableAutoSaveHistory: true,
+		EOFPrompt:              "exit",
+	}
+
+	rl := &ReadlineInterface{
+		config: config,
+		prompt: config.Prompt,
+	}
+
+	// Vi keybindings start in insert mode; the prompt shows the current mode
+	if config.Keybindings == KeybindingsVi {
+		rl.viMode = &viModeTracker{onChange: func(bool) { rl.refreshPrompt() }}
+		readlineConfig.VimMode = true
+		readlineConfig.FuncFilterInputRune = rl.viMode.filter
+		readlineConfig.Prompt = rl.decoratedPrompt()
 	}
 
 	// Setup auto completion if enabled
@@ -44,37 +85,155 @@ func NewReadlineInterface(config *ReadlineConfig) (*ReadlineInterface, error) {
 		}
 	}
 
+	// Ask the terminal to mark pastes so pasted newlines don't submit the line
+	if config.BracketedPaste {
+		readlineConfig.Stdin = readline.NewCancelableStdin(newPasteReader(readline.Stdin))
+	}
+
 	// Create readline instance
 	instance, err := readline.NewEx(readlineConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create readline: %w", err)
 	}
 
-	return &ReadlineInterface{
-		Instance: instance,
-		config:   config,
-	}, nil
+	rl.Instance = instance
+	rl.lastLine = lastHistoryLine(config.HistoryFile)
+	if config.BracketedPaste {
+		fmt.Fprint(readline.Stdout, bracketedPasteOn)
+	}
+	return rl, nil
 }
 
-// ReadLine reads a line with completion and history support
+// ReadLine reads a line with completion and history support. Pasted
+// newlines and tabs are restored in the returned line.
 func (r *ReadlineInterface) ReadLine() (string, error) {
-	return r.Instance.Readline()
+	line, err := r.Instance.Readline()
+	if err == nil {
+		r.saveHistory(line)
+	}
+	return RestorePasted(line), err
+}
+
+// saveHistory adds a line to the history unless it is blank or repeats the
+// previous line. Pastes are saved with their placeholders so each stays one
+// history entry.
+func (r *ReadlineInterface) saveHistory(line string) {
+	if strings.TrimSpace(line) == "" || line == r.lastLine {
+		return
+	}
+	if err := r.Instance.SaveHistory(line); err != nil {
+		logging.LogWarn("Failed to save input history", "error", err)
+		return
+	}
+	r.lastLine = line
+}
+
+// lastHistoryLine returns the last entry of a history file, if any
+func lastHistoryLine(path string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	return lines[len(lines)-1]
+}
+
+// ReadLineWithDefault reads a line with text pre-filled for editing
+func (r *ReadlineInterface) ReadLineWithDefault(text string) (string, error) {
+	return r.Instance.ReadlineWithDefault(text)
 }
 
 // SetPrompt changes the prompt
 func (r *ReadlineInterface) SetPrompt(prompt string) {
-	r.Instance.SetPrompt(prompt)
+	r.prompt = prompt
+	r.Instance.SetPrompt(r.decoratedPrompt())
+}
+
+// IsViMode reports whether vi keybindings are active
+func (r *ReadlineInterface) IsViMode() bool {
+	return r.viMode != nil
+}
+
+// decoratedPrompt returns the prompt with the vi mode indicator, if any
+func (r *ReadlineInterface) decoratedPrompt() string {
+	if r.viMode == nil {
+		return r.prompt
+	}
+	if r.viMode.isNormal() {
+		return ViNormalIndicator + r.prompt
+	}
+	return ViInsertIndicator + r.prompt
+}
+
+// refreshPrompt redraws the prompt after a vi mode change
+func (r *ReadlineInterface) refreshPrompt() {
+	if r.Instance == nil {
+		return
+	}
+	r.Instance.SetPrompt(r.decoratedPrompt())
+	r.Instance.Refresh()
+}
+
+// viModeTracker follows readline's vi insert/normal state so the prompt can
+// show it. It mirrors the transitions in readline's own vi handling, which
+// does not expose the current mode.
+type viModeTracker struct {
+	mu       sync.Mutex
+	normal   bool
+	onChange func(normal bool)
+}
+
+// filter observes each input rune without altering it
+func (v *viModeTracker) filter(r rune) (rune, bool) {
+	v.mu.Lock()
+	normal := v.normal
+	switch {
+	case !normal && r == readline.CharEsc:
+		normal = true
+	case normal && strings.ContainsRune("iIaAsSc", r):
+		normal = false
+	case r == readline.CharEnter || r == readline.CharInterrupt:
+		// readline returns to insert mode when a line ends
+		normal = false
+	}
+	changed := normal != v.normal
+	v.normal = normal
+	v.mu.Unlock()
+
+	if changed && v.onChange != nil {
+		v.onChange(normal)
+	}
+	return r, true
+}
+
+func (v *viModeTracker) isNormal() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.normal
 }
 
 // Close closes the readline interface
 func (r *ReadlineInterface) Close() error {
+	if r.config.BracketedPaste {
+		fmt.Fprint(readline.Stdout, bracketedPasteOff)
+	}
 	return r.Instance.Close()
 }
 
+// ArgumentCompleter returns completion candidates for a command argument
+// that start with prefix
+type ArgumentCompleter func(prefix string) []string
+
 // ReplCompleter implements readline.AutoCompleter
 type ReplCompleter struct {
 	Commands []string
 	Registry *command.Registry
+	// Arguments completes the arguments of commands, keyed by the command
+	// with its prefix character (e.g. "/attach", ":model")
+	Arguments map[string]ArgumentCompleter
 }
 
 // Do implements the completion logic
@@ -88,6 +247,11 @@ func (c *ReplCompleter) Do(line []rune, pos int) (newLine [][]rune, offset int)
 		return nil, 0
 	}
 
+	// Complete the argument once the command name is typed
+	if space := strings.LastIndex(lineStr, " "); space >= 0 {
+		return c.completeArgument(strings.Fields(lineStr)[0], lineStr[space+1:])
+	}
+
 	// Extract the command prefix
 	prefix := lineStr[1:] // Remove the / or :
 
@@ -111,6 +275,59 @@ func (c *ReplCompleter) Do(line []rune, pos int) (newLine [][]rune, offset int)
 	return candidates, 0
 }
 
+// completeArgument completes the word being typed after a command. Unlike
+// command names, candidates are returned as the text to insert after the
+// typed prefix, as readline expects.
+func (c *ReplCompleter) completeArgument(cmd, prefix string) ([][]rune, int) {
+	complete, ok := c.Arguments[cmd]
+	if !ok {
+		return nil, 0
+	}
+
+	var candidates [][]rune
+	for _, candidate := range complete(prefix) {
+		if strings.HasPrefix(candidate, prefix) {
+			candidates = append(candidates, []rune(candidate[len(prefix):]))
+		}
+	}
+
+	logging.LogDebug("Found argument completions", "command", cmd, "count", len(candidates), "prefix", prefix)
+	return candidates, len([]rune(prefix))
+}
+
+// CompleteFilePaths completes file and directory paths. Directories end in a
+// path separator so completion can continue into them.
+func CompleteFilePaths(prefix string) []string {
+	dir, base := filepath.Split(prefix)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	} else if strings.HasPrefix(readDir, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			readDir = filepath.Join(home, readDir[2:])
+		}
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		candidate := dir + name
+		if entry.IsDir() {
+			candidate += string(filepath.Separator)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
 // getCommandNames returns all available REPL command names
 func getCommandNames() []string {
 	// This will be populated from the command registry
@@ -1,11 +1,15 @@
 // ABOUTME: Tests for readline functionality including tab completion
-// ABOUTME: Ensures tab completion works correctly for REPL commands
+// ABOUTME: Ensures tab completion works correctly for REPL commands and their arguments
 
 package ui
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/chzyer/readline"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -101,6 +105,43 @@ func TestReadlineInterface(t *testing.T) {
 	assert.NotNil(t, rl.Instance)
 }
 
+func TestReadlineInterfaceViMode(t *testing.T) {
+	rl, err := NewReadlineInterface(&ReadlineConfig{Prompt: "> ", Keybindings: KeybindingsVi})
+	require.NoError(t, err)
+	defer rl.Close()
+
+	assert.True(t, rl.IsViMode())
+	assert.True(t, rl.Instance.IsVimMode())
+	assert.Equal(t, ViInsertIndicator+"> ", rl.decoratedPrompt())
+
+	// Esc switches to normal mode and the indicator follows
+	rl.viMode.filter(readline.CharEsc)
+	assert.Equal(t, ViNormalIndicator+"> ", rl.decoratedPrompt())
+
+	rl.SetPrompt(">>> ")
+	assert.Equal(t, ViNormalIndicator+">>> ", rl.decoratedPrompt())
+
+	emacs, err := NewReadlineInterface(&ReadlineConfig{Prompt: "> "})
+	require.NoError(t, err)
+	defer emacs.Close()
+	assert.False(t, emacs.IsViMode())
+	assert.Equal(t, "> ", emacs.decoratedPrompt())
+}
+
+func TestViModeTracker(t *testing.T) {
+	var changes []bool
+	tracker := &viModeTracker{onChange: func(normal bool) { changes = append(changes, normal) }}
+
+	for _, r := range []rune{'h', readline.CharEsc, 'w', 'x', 'A', 'b', readline.CharEsc, readline.CharEnter} {
+		got, process := tracker.filter(r)
+		assert.Equal(t, r, got, "runes pass through unchanged")
+		assert.True(t, process)
+	}
+
+	assert.Equal(t, []bool{true, false, true, false}, changes)
+	assert.False(t, tracker.isNormal())
+}
+
 func TestGetCommandNames(t *testing.T) {
 	commands := getCommandNames()
 
@@ -113,3 +154,70 @@ func TestGetCommandNames(t *testing.T) {
 		assert.Contains(t, commands, cmd)
 	}
 }
+
+func TestREPLCompleter_arguments(t *testing.T) {
+	completer := &ReplCompleter{
+		Commands: getCommandNames(),
+		Arguments: map[string]ArgumentCompleter{
+			"/load": func(string) []string { return []string{"abc123", "abd456", "xyz789"} },
+		},
+	}
+
+	complete := func(input string) ([]string, int) {
+		newLines, offset := completer.Do([]rune(input), len([]rune(input)))
+		var completions []string
+		for _, runes := range newLines {
+			completions = append(completions, string(runes))
+		}
+		return completions, offset
+	}
+
+	completions, offset := complete("/load ab")
+	assert.Equal(t, []string{"c123", "d456"}, completions)
+	assert.Equal(t, 2, offset)
+
+	completions, offset = complete("/load ")
+	assert.Len(t, completions, 3)
+	assert.Equal(t, 0, offset)
+
+	completions, _ = complete("/history ab")
+	assert.Nil(t, completions, "commands without a completer complete nothing")
+}
+
+func TestCompleteFilePaths(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main_test.go"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), nil, 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "pkg"), 0755))
+
+	prefix := dir + string(filepath.Separator)
+	assert.ElementsMatch(t, []string{
+		prefix + "main.go",
+		prefix + "main_test.go",
+		prefix + "pkg" + string(filepath.Separator),
+	}, CompleteFilePaths(prefix))
+	assert.Equal(t, []string{prefix + ".hidden"}, CompleteFilePaths(prefix+"."))
+	assert.Empty(t, CompleteFilePaths(prefix+"missing/"))
+
+	t.Chdir(dir)
+	assert.ElementsMatch(t, []string{"main.go", "main_test.go"}, CompleteFilePaths("ma"))
+}
+
+func TestReadlineInterface_history(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(historyFile, []byte("/help\n"), 0644))
+
+	rl, err := NewReadlineInterface(&ReadlineConfig{Prompt: "> ", HistoryFile: historyFile})
+	require.NoError(t, err)
+	defer rl.Close()
+	assert.Equal(t, "/help", rl.lastLine)
+
+	for _, line := range []string{"/help", "hello", "hello", "  ", "/model", "hello"} {
+		rl.saveHistory(line)
+	}
+
+	data, err := os.ReadFile(historyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "/help\nhello\n/model\nhello\n", string(data), "consecutive repeats and blank lines are skipped")
+}
@@ -0,0 +1,134 @@
+// ABOUTME: Terminal progress spinner shown while waiting for the LLM
+// ABOUTME: Displays elapsed time and streaming throughput on a single, self-clearing line
+
+package ui
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// SpinnerInterval is how often the spinner redraws
+const SpinnerInterval = 100 * time.Millisecond
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Spinner draws an elapsed-time indicator on the current terminal line until
+// stopped. Methods are safe to call on a nil Spinner, which does nothing.
+type Spinner struct {
+	writer  io.Writer
+	label   string
+	started time.Time
+
+	mu         sync.Mutex
+	frame      int
+	tokens     int
+	firstToken time.Time
+	stopped    bool
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// NewSpinner creates a spinner that writes to w
+func NewSpinner(w io.Writer, label string) *Spinner {
+	return &Spinner{
+		writer: w,
+		label:  label,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start draws the first frame and keeps redrawing until Stop is called
+func (s *Spinner) Start() {
+	if s == nil {
+		return
+	}
+	s.started = time.Now()
+	s.mu.Lock()
+	s.draw()
+	s.mu.Unlock()
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(SpinnerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				if !s.stopped {
+					s.frame++
+					s.draw()
+				}
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// AddTokens records streamed tokens so the spinner can show throughput
+func (s *Spinner) AddTokens(n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.firstToken.IsZero() {
+		s.firstToken = time.Now()
+	}
+	s.tokens += n
+}
+
+// Stop clears the spinner line. It is safe to call more than once.
+func (s *Spinner) Stop() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	fmt.Fprint(s.writer, "\r\033[K")
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+}
+
+// Writer returns a writer that stops the spinner before the first write, so
+// output replaces the spinner line
+func (s *Spinner) Writer(w io.Writer) io.Writer {
+	if s == nil {
+		return w
+	}
+	return &spinnerWriter{spinner: s, writer: w}
+}
+
+// draw renders the current frame; the caller holds s.mu
+func (s *Spinner) draw() {
+	status := fmt.Sprintf("%s %s %.1fs", spinnerFrames[s.frame%len(spinnerFrames)], s.label, time.Since(s.started).Seconds())
+	if streaming := time.Since(s.firstToken).Seconds(); !s.firstToken.IsZero() && streaming > 0 {
+		status += fmt.Sprintf(" · %.1f tok/s", float64(s.tokens)/streaming)
+	}
+	fmt.Fprint(s.writer, "\r\033[K"+ColorDim+status+ColorReset)
+}
+
+// spinnerWriter stops its spinner when output begins
+type spinnerWriter struct {
+	spinner *Spinner
+	writer  io.Writer
+}
+
+func (w *spinnerWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.spinner.Stop()
+	}
+	return w.writer.Write(p)
+}
@@ -0,0 +1,60 @@
+// ABOUTME: Tests for the progress spinner
+// ABOUTME: Verifies drawing, throughput display, clearing, and stop-on-write
+
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpinner(t *testing.T) {
+	t.Run("draws until stopped and clears the line", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := NewSpinner(&buf, "Waiting")
+		s.Start()
+		s.Stop()
+		s.Stop()
+
+		out := buf.String()
+		assert.Contains(t, out, spinnerFrames[0]+" Waiting 0.0s")
+		assert.True(t, strings.HasSuffix(out, "\r\033[K"))
+		assert.Equal(t, 2, strings.Count(out, "\r\033[K"), "stop clears once")
+	})
+
+	t.Run("shows tokens per second once streaming", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := NewSpinner(&buf, "Waiting")
+		s.Start()
+		s.AddTokens(50)
+		time.Sleep(3 * SpinnerInterval / 2)
+		s.Stop()
+
+		assert.Contains(t, buf.String(), "tok/s")
+	})
+
+	t.Run("writer stops the spinner on first output", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := NewSpinner(&buf, "Waiting")
+		s.Start()
+		w := s.Writer(&buf)
+		_, _ = w.Write([]byte("hello"))
+
+		assert.True(t, strings.HasSuffix(buf.String(), "\r\033[Khello"))
+		s.Stop()
+		assert.True(t, strings.HasSuffix(buf.String(), "hello"))
+	})
+
+	t.Run("nil spinner is a no-op", func(t *testing.T) {
+		var buf bytes.Buffer
+		var s *Spinner
+		s.Start()
+		s.AddTokens(1)
+		s.Stop()
+		assert.Same(t, &buf, s.Writer(&buf))
+	})
+}
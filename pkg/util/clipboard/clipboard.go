@@ -0,0 +1,193 @@
+// ABOUTME: Cross-platform system clipboard access through native command line tools
+// ABOUTME: Uses pbcopy on macOS, clip/PowerShell on Windows, and wl-copy/xclip/xsel on Linux
+
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// tool is a clipboard command and its arguments
+type tool struct {
+	name string
+	args []string
+}
+
+// writeTools lists, per platform, the commands that copy stdin to the clipboard
+// in order of preference
+var writeTools = map[string][]tool{
+	"darwin":  {{"pbcopy", nil}},
+	"windows": {{"clip.exe", nil}},
+	"linux": {
+		{"wl-copy", nil},
+		{"xclip", []string{"-selection", "clipboard"}},
+		{"xsel", []string{"--clipboard", "--input"}},
+	},
+}
+
+// readTools lists, per platform, the commands that print the clipboard text
+var readTools = map[string][]tool{
+	"darwin":  {{"pbpaste", nil}},
+	"windows": {{"powershell.exe", []string{"-NoProfile", "-Command", "Get-Clipboard"}}},
+	"linux": {
+		{"wl-paste", []string{"--no-newline"}},
+		{"xclip", []string{"-selection", "clipboard", "-o"}},
+		{"xsel", []string{"--clipboard", "--output"}},
+	},
+}
+
+// pngSignature is the magic number every PNG file starts with
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// imageTool is a command that prints the clipboard image, and how to turn its
+// output into PNG bytes
+type imageTool struct {
+	tool
+	decode func([]byte) ([]byte, error)
+}
+
+// readImageTools lists, per platform, the commands that print the clipboard image
+var readImageTools = map[string][]imageTool{
+	"darwin": {
+		{tool{"pngpaste", []string{"-"}}, raw},
+		{tool{"osascript", []string{"-e", "get the clipboard as «class PNGf»"}}, decodeAppleScriptData},
+	},
+	"windows": {
+		{tool{"powershell.exe", []string{"-NoProfile", "-Command", windowsImageScript}}, decodeBase64},
+	},
+	"linux": {
+		{tool{"wl-paste", []string{"--type", "image/png"}}, raw},
+		{tool{"xclip", []string{"-selection", "clipboard", "-t", "image/png", "-o"}}, raw},
+	},
+}
+
+// windowsImageScript prints the clipboard image as base64-encoded PNG
+const windowsImageScript = `Add-Type -AssemblyName System.Windows.Forms; ` +
+	`$img = [Windows.Forms.Clipboard]::GetImage(); ` +
+	`if ($img) { $ms = New-Object IO.MemoryStream; ` +
+	`$img.Save($ms, [Drawing.Imaging.ImageFormat]::Png); ` +
+	`[Convert]::ToBase64String($ms.ToArray()) }`
+
+// raw returns tool output unchanged
+func raw(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// decodeBase64 decodes base64 tool output
+func decodeBase64(data []byte) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+}
+
+// decodeAppleScriptData decodes AppleScript's «data PNGf89504E47...» notation
+func decodeAppleScriptData(data []byte) ([]byte, error) {
+	text := strings.TrimSpace(string(data))
+	text = strings.TrimPrefix(text, "«data PNGf")
+	text = strings.TrimSuffix(text, "»")
+	return hex.DecodeString(text)
+}
+
+// platform returns the tool table key for the running OS
+func platform() string {
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return runtime.GOOS
+	default:
+		// BSDs and other unixes use the same X11/Wayland tools as Linux
+		return "linux"
+	}
+}
+
+// findTool returns the first available tool from candidates
+func findTool(candidates []tool) (tool, error) {
+	for _, t := range candidates {
+		if t.usable() {
+			return t, nil
+		}
+	}
+	return tool{}, ErrUnavailable
+}
+
+// usable reports whether the tool is installed and can reach a display
+func (t tool) usable() bool {
+	if platform() == "linux" && !hasDisplay(t.name) {
+		return false
+	}
+	_, err := exec.LookPath(t.name)
+	return err == nil
+}
+
+// hasDisplay reports whether the display server a Linux tool needs is running
+func hasDisplay(name string) bool {
+	if strings.HasPrefix(name, "wl-") {
+		return os.Getenv("WAYLAND_DISPLAY") != ""
+	}
+	return os.Getenv("DISPLAY") != ""
+}
+
+// Available reports whether the system clipboard can be written
+func Available() bool {
+	_, err := findTool(writeTools[platform()])
+	return err == nil
+}
+
+// WriteText copies text to the system clipboard
+func WriteText(text string) error {
+	t, err := findTool(writeTools[platform()])
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(t.name, t.args...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", t.name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ReadText returns the text currently on the system clipboard
+func ReadText() (string, error) {
+	t, err := findTool(readTools[platform()])
+	if err != nil {
+		return "", err
+	}
+
+	out, err := exec.Command(t.name, t.args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", t.name, err)
+	}
+	if len(out) == 0 {
+		return "", ErrEmpty
+	}
+	return string(out), nil
+}
+
+// ReadImage returns the image on the system clipboard as PNG data
+func ReadImage() ([]byte, error) {
+	for _, t := range readImageTools[platform()] {
+		if !t.usable() {
+			continue
+		}
+
+		// The tools fail when the clipboard holds no image of the requested type
+		out, err := exec.Command(t.name, t.args...).Output()
+		if err != nil || len(out) == 0 {
+			return nil, fmt.Errorf("%w: no image data", ErrEmpty)
+		}
+		data, err := t.decode(out)
+		if err != nil || !bytes.HasPrefix(data, pngSignature) {
+			return nil, fmt.Errorf("%w: no image data", ErrEmpty)
+		}
+		return data, nil
+	}
+	return nil, ErrUnavailable
+}
@@ -0,0 +1,101 @@
+// ABOUTME: Tests for system clipboard access
+// ABOUTME: Uses a fake xclip on PATH to verify copy and paste round trips
+
+package clipboard
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeXclip installs an xclip script that stores the clipboard in a file
+func fakeXclip(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("fake clipboard tool is only set up for Linux")
+	}
+
+	dir := t.TempDir()
+	store := filepath.Join(dir, "clipboard.txt")
+	script := "#!/bin/sh\n" +
+		"case \"$*\" in\n" +
+		"  *image/png*) cat \"" + store + ".png\" 2>/dev/null || exit 1 ;;\n" +
+		"  *-o*) cat \"" + store + "\" 2>/dev/null || true ;;\n" +
+		"  *) cat > \"" + store + "\" ;;\n" +
+		"esac\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "xclip"), []byte(script), 0o755))
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	return store
+}
+
+func TestWriteAndReadText(t *testing.T) {
+	store := fakeXclip(t)
+
+	assert.True(t, Available())
+	require.NoError(t, WriteText("hello clipboard"))
+
+	data, err := os.ReadFile(store)
+	require.NoError(t, err)
+	assert.Equal(t, "hello clipboard", string(data))
+
+	text, err := ReadText()
+	require.NoError(t, err)
+	assert.Equal(t, "hello clipboard", text)
+}
+
+func TestReadTextEmpty(t *testing.T) {
+	fakeXclip(t)
+
+	_, err := ReadText()
+	assert.ErrorIs(t, err, ErrEmpty)
+}
+
+func TestUnavailable(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("display detection only applies on Linux")
+	}
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	assert.False(t, Available())
+	assert.ErrorIs(t, WriteText("x"), ErrUnavailable)
+	_, err := ReadText()
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestReadImage(t *testing.T) {
+	store := fakeXclip(t)
+
+	_, err := ReadImage()
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	// Text on the clipboard is not an image
+	require.NoError(t, os.WriteFile(store+".png", []byte("not an image"), 0o644))
+	_, err = ReadImage()
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	png := append(append([]byte{}, pngSignature...), 0x00, 0x01)
+	require.NoError(t, os.WriteFile(store+".png", png, 0o644))
+	data, err := ReadImage()
+	require.NoError(t, err)
+	assert.Equal(t, png, data)
+}
+
+func TestDecodeImageOutput(t *testing.T) {
+	data, err := decodeAppleScriptData([]byte("«data PNGf89504E470D0A1A0A00»\n"))
+	require.NoError(t, err)
+	assert.Equal(t, append(append([]byte{}, pngSignature...), 0x00), data)
+
+	data, err = decodeBase64([]byte("iVBORw0KGgo=\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, pngSignature, data)
+}
@@ -0,0 +1,14 @@
+// ABOUTME: Error definitions for the clipboard package
+// ABOUTME: Reports missing clipboard tools and empty clipboards
+
+package clipboard
+
+import "errors"
+
+var (
+	// ErrUnavailable is returned when no clipboard tool is found for the platform
+	ErrUnavailable = errors.New("no clipboard tool available")
+
+	// ErrEmpty is returned when the clipboard holds no content of the requested kind
+	ErrEmpty = errors.New("clipboard is empty")
+)
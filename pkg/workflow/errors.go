@@ -0,0 +1,18 @@
+// ABOUTME: Error definitions for the workflow package
+// ABOUTME: Reports malformed workflow files and failed workflow steps
+
+package workflow
+
+import "errors"
+
+var (
+	// ErrInvalidWorkflow is returned when a workflow file cannot be parsed or
+	// declares steps that cannot run
+	ErrInvalidWorkflow = errors.New("invalid workflow")
+
+	// ErrStepFailed is returned when a step fails and does not allow failure
+	ErrStepFailed = errors.New("workflow step failed")
+
+	// ErrTooManySteps is returned when goto loops run past the step limit
+	ErrTooManySteps = errors.New("workflow exceeded step limit")
+)
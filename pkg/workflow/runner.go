@@ -0,0 +1,260 @@
+// ABOUTME: Runs workflow steps in order, passing outputs between them
+// ABOUTME: Evaluates step conditions, follows goto branches, and runs shell commands
+
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/lexlapax/magellai/internal/logging"
+	"github.com/lexlapax/magellai/pkg/templates"
+)
+
+// Generator sends a prompt to a model and returns the response. An empty
+// model means the caller's default.
+type Generator func(ctx context.Context, model, system, prompt string) (string, error)
+
+// Runner executes workflows
+type Runner struct {
+	// Generate answers prompt steps
+	Generate Generator
+	// Model overrides the workflow model for steps that do not set their own
+	Model string
+	// Dir is the working directory for shell steps
+	Dir string
+}
+
+// StepResult records what one step did
+type StepResult struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Model    string `json:"model,omitempty"`
+	System   string `json:"system,omitempty"`
+	Input    string `json:"input,omitempty"` // Rendered prompt or shell command
+	Output   string `json:"output,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+	Skipped  bool   `json:"skipped,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Result is the outcome of a workflow run
+type Result struct {
+	Workflow string            `json:"workflow,omitempty"`
+	Steps    []StepResult      `json:"steps"`
+	Vars     map[string]string `json:"vars"`
+	// Output is the output of the last step that ran
+	Output string `json:"output"`
+}
+
+// Run executes the workflow. vars override the workflow's own variables.
+// Each step's output is stored as a variable under the step name (and its
+// output name, if set); shell steps also set <name>.exit_code. On error
+// the result holds the steps that ran.
+func (r *Runner) Run(ctx context.Context, wf *Workflow, vars map[string]string) (*Result, error) {
+	result := &Result{Workflow: wf.Name, Vars: make(map[string]string)}
+	for name, value := range wf.Vars {
+		result.Vars[name] = value
+	}
+	for name, value := range vars {
+		result.Vars[name] = value
+	}
+
+	maxSteps := wf.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+
+	for i, count := 0, 0; i < len(wf.Steps); count++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if count >= maxSteps {
+			return result, fmt.Errorf("%w: %d steps", ErrTooManySteps, maxSteps)
+		}
+
+		step := wf.Steps[i]
+		stepResult, err := r.runStep(ctx, wf, step, result.Vars)
+		result.Steps = append(result.Steps, stepResult)
+		if err != nil {
+			return result, err
+		}
+		if stepResult.Skipped {
+			i++
+			continue
+		}
+
+		result.Output = stepResult.Output
+		result.Vars[step.Name] = stepResult.Output
+		if step.Output != "" {
+			result.Vars[step.Output] = stepResult.Output
+		}
+		if step.Shell != "" {
+			result.Vars[step.Name+".exit_code"] = strconv.Itoa(stepResult.ExitCode)
+		}
+
+		switch step.Goto {
+		case "":
+			i++
+		case End:
+			return result, nil
+		default:
+			i = wf.index(step.Goto)
+		}
+	}
+	return result, nil
+}
+
+// runStep renders and runs a single step
+func (r *Runner) runStep(ctx context.Context, wf *Workflow, step Step, vars map[string]string) (StepResult, error) {
+	result := StepResult{Name: step.Name, Kind: step.Kind()}
+
+	if step.If != "" {
+		ok, err := Evaluate(step.If, vars)
+		if err != nil {
+			return result, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+		if !ok {
+			logging.LogDebug("Skipping workflow step", "step", step.Name, "condition", step.If)
+			result.Skipped = true
+			return result, nil
+		}
+	}
+
+	input, err := render(step.Prompt+step.Shell, vars)
+	if err != nil {
+		return result, fmt.Errorf("step %s: %w", step.Name, err)
+	}
+	result.Input = input
+
+	if step.Shell != "" {
+		logging.LogInfo("Running workflow shell step", "step", step.Name)
+		output, exitCode, err := r.shell(ctx, input)
+		result.Output, result.ExitCode = output, exitCode
+		if err != nil {
+			result.Error = err.Error()
+			if !step.AllowFailure {
+				return result, fmt.Errorf("%w: %s: %v", ErrStepFailed, step.Name, err)
+			}
+		}
+		return result, nil
+	}
+
+	result.Model = firstNonEmpty(step.Model, r.Model, wf.Model)
+	if result.System, err = render(firstNonEmpty(step.System, wf.System), vars); err != nil {
+		return result, fmt.Errorf("step %s: %w", step.Name, err)
+	}
+	if r.Generate == nil {
+		return result, fmt.Errorf("%w: %s: no generator configured", ErrStepFailed, step.Name)
+	}
+
+	logging.LogInfo("Running workflow prompt step", "step", step.Name, "model", result.Model)
+	output, err := r.Generate(ctx, result.Model, result.System, input)
+	if err != nil {
+		result.Error = err.Error()
+		if !step.AllowFailure {
+			return result, fmt.Errorf("%w: %s: %w", ErrStepFailed, step.Name, err)
+		}
+		return result, nil
+	}
+	result.Output = strings.TrimSpace(output)
+	return result, nil
+}
+
+// shell runs a command with sh and returns its trimmed standard output and
+// exit code
+func (r *Runner) shell(ctx context.Context, command string) (string, int, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = r.Dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	output := strings.TrimRight(stdout.String(), "\n")
+	if err == nil {
+		return output, 0, nil
+	}
+
+	exitCode := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return output, exitCode, fmt.Errorf("%v: %s", err, msg)
+	}
+	return output, exitCode, err
+}
+
+// Evaluate renders a step condition and reports whether it holds.
+// Conditions compare with ==, != or contains, and a bare value is true
+// unless it is empty, "false", "no", or "0". A leading ! negates.
+func Evaluate(condition string, vars map[string]string) (bool, error) {
+	expr := strings.TrimSpace(condition)
+	negate := strings.HasPrefix(expr, "!") && !strings.HasPrefix(expr, "!=")
+	if negate {
+		expr = expr[1:]
+	}
+	rendered, err := render(expr, vars)
+	if err != nil {
+		return false, err
+	}
+	return evaluate(rendered) != negate, nil
+}
+
+// evaluate tests a rendered condition
+func evaluate(expr string) bool {
+
+	for _, op := range []string{"==", "!=", " contains "} {
+		left, right, found := strings.Cut(expr, op)
+		if !found {
+			continue
+		}
+		left, right = unquote(left), unquote(right)
+		switch op {
+		case "==":
+			return left == right
+		case "!=":
+			return left != right
+		default:
+			return strings.Contains(left, right)
+		}
+	}
+
+	switch strings.ToLower(unquote(expr)) {
+	case "", "false", "no", "0":
+		return false
+	}
+	return true
+}
+
+// render fills {{placeholders}} in text from vars
+func render(text string, vars map[string]string) (string, error) {
+	t := &templates.Template{Content: text}
+	return t.Render(vars)
+}
+
+// unquote trims whitespace and one pair of matching quotes
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
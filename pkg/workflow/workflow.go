@@ -0,0 +1,147 @@
+// ABOUTME: Workflow definitions loaded from YAML files
+// ABOUTME: Declares ordered prompt and shell steps with variables, models, and branches
+
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/knadh/koanf/v2"
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+// DefaultMaxSteps bounds how many steps a workflow may run, so goto loops
+// always end
+const DefaultMaxSteps = 100
+
+// End is the goto target that stops the workflow
+const End = "end"
+
+// Workflow is a multi-step pipeline of prompts and shell commands
+type Workflow struct {
+	Name        string            `koanf:"name"`
+	Description string            `koanf:"description"`
+	Model       string            `koanf:"model"`  // Default model for prompt steps
+	System      string            `koanf:"system"` // Default system prompt for prompt steps
+	Vars        map[string]string `koanf:"vars"`
+	MaxSteps    int               `koanf:"max_steps"`
+	Steps       []Step            `koanf:"steps"`
+}
+
+// Step is one prompt or shell command. Prompt, Shell, and If are templates
+// that can reference workflow variables and earlier step outputs as
+// {{name}}.
+type Step struct {
+	Name         string `koanf:"name"`
+	Prompt       string `koanf:"prompt"`
+	Shell        string `koanf:"shell"`
+	Model        string `koanf:"model"`
+	System       string `koanf:"system"`
+	If           string `koanf:"if"`     // Skip the step unless the condition holds
+	Goto         string `koanf:"goto"`   // Step to run next, or "end"
+	Output       string `koanf:"output"` // Extra variable name for the step's output
+	AllowFailure bool   `koanf:"allow_failure"`
+}
+
+// Kind returns "prompt" or "shell"
+func (s *Step) Kind() string {
+	if s.Shell != "" {
+		return "shell"
+	}
+	return "prompt"
+}
+
+// Load reads a workflow file
+func Load(path string) (*Workflow, error) {
+	k := koanf.New(".")
+	if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidWorkflow, path, err)
+	}
+	wf, err := unmarshal(k)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	logging.LogDebug("Loaded workflow", "path", path, "name", wf.Name, "steps", len(wf.Steps))
+	return wf, nil
+}
+
+// Parse reads a workflow from YAML data
+func Parse(data []byte) (*Workflow, error) {
+	k := koanf.New(".")
+	if err := k.Load(rawbytes.Provider(data), yaml.Parser()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	return unmarshal(k)
+}
+
+// unmarshal decodes and validates a loaded workflow
+func unmarshal(k *koanf.Koanf) (*Workflow, error) {
+	var wf Workflow
+	if err := k.Unmarshal("", &wf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	if wf.MaxSteps <= 0 {
+		wf.MaxSteps = DefaultMaxSteps
+	}
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// Validate checks that every step is runnable and every goto names a step.
+// Unnamed steps are named step1, step2, and so on.
+func (w *Workflow) Validate() error {
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("%w: no steps", ErrInvalidWorkflow)
+	}
+	for _, model := range append([]string{w.Model}, stepModels(w.Steps)...) {
+		if model != "" && !strings.Contains(model, "/") {
+			return fmt.Errorf("%w: model must be provider/model, got %q", ErrInvalidWorkflow, model)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i := range w.Steps {
+		step := &w.Steps[i]
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step%d", i+1)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("%w: duplicate step name %q", ErrInvalidWorkflow, step.Name)
+		}
+		names[step.Name] = true
+		if (step.Prompt == "") == (step.Shell == "") {
+			return fmt.Errorf("%w: step %q needs exactly one of prompt or shell", ErrInvalidWorkflow, step.Name)
+		}
+	}
+	for _, step := range w.Steps {
+		if step.Goto != "" && step.Goto != End && !names[step.Goto] {
+			return fmt.Errorf("%w: step %q jumps to unknown step %q", ErrInvalidWorkflow, step.Name, step.Goto)
+		}
+	}
+	return nil
+}
+
+// stepModels returns the model of each step
+func stepModels(steps []Step) []string {
+	models := make([]string, len(steps))
+	for i, step := range steps {
+		models[i] = step.Model
+	}
+	return models
+}
+
+// index returns the position of the named step, or -1
+func (w *Workflow) index(name string) int {
+	for i, step := range w.Steps {
+		if step.Name == name {
+			return i
+		}
+	}
+	return -1
+}
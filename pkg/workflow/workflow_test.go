@@ -0,0 +1,221 @@
+// ABOUTME: Tests for workflow parsing and execution
+// ABOUTME: Verifies variable passing, model overrides, conditions, goto branches, and shell failures
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoGenerator answers each prompt with the model and prompt it received
+func echoGenerator(calls *[]string) Generator {
+	return func(ctx context.Context, model, system, prompt string) (string, error) {
+		*calls = append(*calls, model+"|"+system+"|"+prompt)
+		return fmt.Sprintf("[%s] %s", model, prompt), nil
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wf.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+name: release
+model: openai/gpt-4o
+vars:
+  version: 1
+steps:
+  - shell: echo hi
+  - name: notes
+    prompt: Notes for {{version}}
+    model: anthropic/claude-3-haiku
+    allow_failure: true
+`), 0o644))
+
+	wf, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "release", wf.Name)
+	assert.Equal(t, map[string]string{"version": "1"}, wf.Vars)
+	assert.Equal(t, DefaultMaxSteps, wf.MaxSteps)
+	require.Len(t, wf.Steps, 2)
+	assert.Equal(t, "step1", wf.Steps[0].Name)
+	assert.Equal(t, "shell", wf.Steps[0].Kind())
+	assert.Equal(t, "prompt", wf.Steps[1].Kind())
+	assert.Equal(t, "anthropic/claude-3-haiku", wf.Steps[1].Model)
+	assert.True(t, wf.Steps[1].AllowFailure)
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no steps":         "name: empty\n",
+		"prompt and shell": "steps:\n  - prompt: a\n    shell: b\n",
+		"neither":          "steps:\n  - name: a\n",
+		"duplicate name":   "steps:\n  - {name: a, prompt: x}\n  - {name: a, prompt: y}\n",
+		"unknown goto":     "steps:\n  - {prompt: x, goto: nowhere}\n",
+		"bad model":        "steps:\n  - {prompt: x, model: gpt-4o}\n",
+		"bad yaml":         "steps: [\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			assert.ErrorIs(t, err, ErrInvalidWorkflow)
+		})
+	}
+}
+
+func TestRunPassesVariables(t *testing.T) {
+	wf, err := Parse([]byte(`
+model: openai/gpt-4o
+system: Be brief.
+vars:
+  topic: cats
+steps:
+  - name: files
+    shell: printf 'a.go\nb.go\n'
+  - name: summary
+    prompt: "Summarize {{topic}}: {{files}}"
+    output: text
+  - name: title
+    prompt: "Title for {{text}}"
+    model: anthropic/claude-3-haiku
+    system: ""
+`))
+	require.NoError(t, err)
+
+	var calls []string
+	runner := &Runner{Generate: echoGenerator(&calls)}
+	result, err := runner.Run(context.Background(), wf, map[string]string{"topic": "dogs"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"openai/gpt-4o|Be brief.|Summarize dogs: a.go\nb.go",
+		"anthropic/claude-3-haiku|Be brief.|Title for [openai/gpt-4o] Summarize dogs: a.go\nb.go",
+	}, calls)
+	require.Len(t, result.Steps, 3)
+	assert.Equal(t, "a.go\nb.go", result.Vars["files"])
+	assert.Equal(t, "0", result.Vars["files.exit_code"])
+	assert.Equal(t, result.Vars["summary"], result.Vars["text"])
+	assert.Equal(t, result.Steps[2].Output, result.Output)
+}
+
+func TestRunModelOverride(t *testing.T) {
+	wf, err := Parse([]byte(`
+model: openai/gpt-4o
+steps:
+  - prompt: one
+  - prompt: two
+    model: anthropic/claude-3-haiku
+`))
+	require.NoError(t, err)
+
+	var calls []string
+	runner := &Runner{Generate: echoGenerator(&calls), Model: "ollama/llama3"}
+	_, err = runner.Run(context.Background(), wf, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ollama/llama3||one", "anthropic/claude-3-haiku||two"}, calls)
+}
+
+func TestRunConditionsAndGoto(t *testing.T) {
+	wf, err := Parse([]byte(`
+vars:
+  mode: fast
+steps:
+  - name: check
+    shell: echo ok
+  - name: fast
+    if: "{{mode}} == fast"
+    shell: echo fast path
+    goto: done
+  - name: slow
+    shell: echo slow path
+  - name: done
+    if: "{{check}} contains ok"
+    shell: echo finished
+`))
+	require.NoError(t, err)
+
+	runner := &Runner{}
+	result, err := runner.Run(context.Background(), wf, nil)
+	require.NoError(t, err)
+	var ran []string
+	for _, step := range result.Steps {
+		if !step.Skipped {
+			ran = append(ran, step.Name)
+		}
+	}
+	assert.Equal(t, []string{"check", "fast", "done"}, ran)
+	assert.Equal(t, "finished", result.Output)
+
+	result, err = runner.Run(context.Background(), wf, map[string]string{"mode": "slow"})
+	require.NoError(t, err)
+	assert.True(t, result.Steps[1].Skipped)
+	assert.Equal(t, "slow path", result.Vars["slow"])
+}
+
+func TestRunLoopLimit(t *testing.T) {
+	wf, err := Parse([]byte(`
+max_steps: 5
+steps:
+  - name: loop
+    shell: "true"
+    goto: loop
+`))
+	require.NoError(t, err)
+
+	result, err := (&Runner{}).Run(context.Background(), wf, nil)
+	assert.ErrorIs(t, err, ErrTooManySteps)
+	assert.Len(t, result.Steps, 5)
+}
+
+func TestRunShellFailure(t *testing.T) {
+	wf, err := Parse([]byte(`
+steps:
+  - name: tests
+    shell: echo failing >&2; exit 3
+    allow_failure: true
+  - name: fix
+    if: "{{tests.exit_code}} != 0"
+    shell: echo fixing
+  - name: strict
+    shell: exit 1
+  - name: never
+    shell: echo unreachable
+`))
+	require.NoError(t, err)
+
+	result, err := (&Runner{}).Run(context.Background(), wf, nil)
+	assert.ErrorIs(t, err, ErrStepFailed)
+	require.Len(t, result.Steps, 3)
+	assert.Equal(t, 3, result.Steps[0].ExitCode)
+	assert.Contains(t, result.Steps[0].Error, "failing")
+	assert.Equal(t, "fixing", result.Steps[1].Output)
+}
+
+func TestEvaluate(t *testing.T) {
+	vars := map[string]string{"answer": "Yes, it compiles", "empty": "", "n": "0"}
+	tests := map[string]bool{
+		"{{answer}}":                         true,
+		"{{empty}}":                          false,
+		"{{n}}":                              false,
+		"!{{n}}":                             true,
+		"false":                              false,
+		"{{n}} == 0":                         true,
+		"'{{n}}' != '0'":                     false,
+		"{{answer}} contains Yes":            true,
+		"!{{answer}} contains Yes":           false,
+		`"{{answer}}" == "Yes, it compiles"`: true,
+	}
+	for condition, want := range tests {
+		got, err := Evaluate(condition, vars)
+		require.NoError(t, err, condition)
+		assert.Equal(t, want, got, condition)
+	}
+
+	_, err := Evaluate("{{missing}}", vars)
+	assert.Error(t, err)
+}
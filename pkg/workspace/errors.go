@@ -0,0 +1,14 @@
+// ABOUTME: Error definitions for the workspace package
+// ABOUTME: Reports missing or malformed project workspace files
+
+package workspace
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when no workspace file exists in a directory or its parents
+	ErrNotFound = errors.New("workspace not found")
+
+	// ErrInvalidWorkspace is returned when a workspace file cannot be parsed
+	ErrInvalidWorkspace = errors.New("invalid workspace")
+)
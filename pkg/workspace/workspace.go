@@ -0,0 +1,152 @@
+// ABOUTME: Project workspace definitions loaded from .magellai/workspace.yaml
+// ABOUTME: Declares context files, a system prompt, and a default model for a project
+
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"github.com/lexlapax/magellai/internal/logging"
+)
+
+const (
+	// Dir is the per-project directory holding the workspace file
+	Dir = ".magellai"
+
+	// FileName is the workspace file inside Dir
+	FileName = "workspace.yaml"
+
+	// maxContextFileSize is the largest context file included in the prompt
+	maxContextFileSize = 256 * 1024
+)
+
+// Workspace is a project's chat configuration
+type Workspace struct {
+	Name         string   `koanf:"name"`
+	Model        string   `koanf:"model"`
+	SystemPrompt string   `koanf:"system_prompt"`
+	Context      []string `koanf:"context"` // Files or glob patterns relative to Root
+
+	// Root is the project directory containing .magellai/
+	Root string `koanf:"-"`
+	// Path is the workspace file location
+	Path string `koanf:"-"`
+}
+
+// Find looks for a workspace file in dir and its parents
+func Find(dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		path := filepath.Join(dir, Dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNotFound
+		}
+		dir = parent
+	}
+}
+
+// Load reads a workspace file
+func Load(path string) (*Workspace, error) {
+	k := koanf.New(".")
+	if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidWorkspace, path, err)
+	}
+
+	var ws Workspace
+	if err := k.Unmarshal("", &ws); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidWorkspace, path, err)
+	}
+	if ws.Model != "" && !strings.Contains(ws.Model, "/") {
+		return nil, fmt.Errorf("%w: model must be provider/model, got %q", ErrInvalidWorkspace, ws.Model)
+	}
+
+	ws.Path = path
+	ws.Root = filepath.Dir(filepath.Dir(path))
+	if ws.Name == "" {
+		ws.Name = filepath.Base(ws.Root)
+	}
+
+	logging.LogDebug("Loaded workspace", "path", path, "name", ws.Name, "contextPatterns", len(ws.Context))
+	return &ws, nil
+}
+
+// ContextFiles resolves the declared context patterns to existing files,
+// in declaration order without duplicates
+func (w *Workspace) ContextFiles() ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+
+	for _, pattern := range w.Context {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(w.Root, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad context pattern %q: %v", ErrInvalidWorkspace, pattern, err)
+		}
+		if len(matches) == 0 {
+			logging.LogWarn("Workspace context pattern matched no files", "pattern", pattern)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || info.IsDir() || seen[match] {
+				continue
+			}
+			seen[match] = true
+			files = append(files, match)
+		}
+	}
+	return files, nil
+}
+
+// Prompt builds the system prompt: the declared prompt followed by the
+// contents of each context file as fenced code
+func (w *Workspace) Prompt() (string, []string, error) {
+	files, err := w.ContextFiles()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(strings.TrimSpace(w.SystemPrompt))
+
+	var loaded []string
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read context file: %w", err)
+		}
+		if len(data) > maxContextFileSize {
+			logging.LogWarn("Skipping large workspace context file", "path", path, "size", len(data))
+			continue
+		}
+
+		rel, err := filepath.Rel(w.Root, path)
+		if err != nil {
+			rel = path
+		}
+		lang := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if prompt.Len() > 0 {
+			prompt.WriteString("\n\n")
+		}
+		fmt.Fprintf(&prompt, "Content of %s:\n\n```%s\n%s\n```", rel, lang, strings.TrimRight(string(data), "\n"))
+		loaded = append(loaded, rel)
+	}
+
+	return prompt.String(), loaded, nil
+}
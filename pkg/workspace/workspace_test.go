@@ -0,0 +1,81 @@
+// ABOUTME: Tests for project workspace loading
+// ABOUTME: Verifies discovery from subdirectories, context resolution, and prompt assembly
+
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeWorkspace creates a project with a workspace file and returns its root
+func writeWorkspace(t *testing.T, content string) string {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, Dir), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, Dir, FileName), []byte(content), 0o644))
+	return root
+}
+
+func TestFind(t *testing.T) {
+	root := writeWorkspace(t, `
+name: demo
+model: openai/gpt-4o
+system_prompt: You help with the demo project.
+context:
+  - README.md
+  - docs/*.md
+`)
+	sub := filepath.Join(root, "pkg", "inner")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	ws, err := Find(sub)
+	require.NoError(t, err)
+	assert.Equal(t, "demo", ws.Name)
+	assert.Equal(t, "openai/gpt-4o", ws.Model)
+	assert.Equal(t, "You help with the demo project.", ws.SystemPrompt)
+	assert.Equal(t, []string{"README.md", "docs/*.md"}, ws.Context)
+	assert.Equal(t, root, ws.Root)
+
+	_, err = Find(t.TempDir())
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	root := writeWorkspace(t, "model: gpt-4o\n")
+	_, err := Load(filepath.Join(root, Dir, FileName))
+	assert.ErrorIs(t, err, ErrInvalidWorkspace)
+
+	root = writeWorkspace(t, "context: [unclosed\n")
+	_, err = Load(filepath.Join(root, Dir, FileName))
+	assert.ErrorIs(t, err, ErrInvalidWorkspace)
+}
+
+func TestPrompt(t *testing.T) {
+	root := writeWorkspace(t, `
+system_prompt: Be brief.
+context:
+  - README.md
+  - docs/*.md
+  - README.md
+  - missing.txt
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "README.md"), []byte("# Demo\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "b.md"), []byte("B"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "a.md"), []byte("A"), 0o644))
+
+	ws, err := Find(root)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Base(root), ws.Name)
+
+	prompt, loaded, err := ws.Prompt()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", filepath.Join("docs", "a.md"), filepath.Join("docs", "b.md")}, loaded)
+	assert.Contains(t, prompt, "Be brief.\n\nContent of README.md:\n\n```md\n# Demo\n```")
+	assert.Contains(t, prompt, "```md\nA\n```")
+}